@@ -0,0 +1,38 @@
+// Command testplugin is an example channel plugin. It adds a "console"
+// channel that writes notifications to stdout, and a failing send for any
+// notification whose metadata sets "fail".
+//
+// Build it from the module root and point PLUGIN_DIR at the output:
+//
+//	go build -buildmode=plugin -o plugins/console.so ./_examples/testplugin
+package main
+
+import (
+	"context"
+	"fmt"
+	"notification-service/internal/models"
+	"notification-service/internal/services"
+	"strings"
+)
+
+type consolePlugin struct{}
+
+func (consolePlugin) ChannelName() models.NotificationChannel {
+	return "console"
+}
+
+func (consolePlugin) Send(ctx context.Context, notification *models.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if reason, ok := notification.Metadata["fail"]; ok {
+		return fmt.Errorf("console plugin: %s", reason)
+	}
+	fmt.Printf("[console] %s: %s (to %s)\n", notification.Title, notification.Content, strings.Join(notification.Recipients, ", "))
+	return nil
+}
+
+// Plugin is the symbol the service looks up when it loads the library.
+var Plugin services.ChannelPlugin = consolePlugin{}
+
+func main() {}
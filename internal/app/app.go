@@ -2,34 +2,406 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"notification-service/internal/config"
+	notificationgrpc "notification-service/internal/grpc"
 	"notification-service/internal/handlers"
+	"notification-service/internal/i18n"
+	"notification-service/internal/logging"
+	"notification-service/internal/metrics"
 	"notification-service/internal/models"
+	"notification-service/internal/repository"
 	"notification-service/internal/services"
+	"notification-service/internal/tracing"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
 type App struct {
 	config              *config.Config
 	notificationFactory *services.NotificationServiceFactory
-	schedulerService    *services.SchedulerService
+	schedulerService    scheduler
+	batcher             *services.BatchingSchedulerService
+	digestService       *services.DigestService
+	callbacks           *services.CallbackService
+	outboxWorker        *services.OutboxWorker
+	templateService     *services.TemplateService
+	unsubscribe         *services.UnsubscribeService
+	userPreferences     *services.UserPreferenceService
+	apiKeys             *services.APIKeyService
+	tokens              *services.TokenService
+	tenants             *services.TenantService
+	auditLog            services.AuditLog
+	httpLimiter         services.RequestLimiter
+	metrics             *metrics.MetricsCollector
+	tracing             *tracing.TracerProvider
+	events              *services.EventBus
+	logger              logging.Logger
+	logLevel            *slog.LevelVar
+	configWatcher       *config.ConfigWatcher
+	repository          repository.NotificationRepository
+	health              *handlers.HealthHandler
 	server              *http.Server
+	redirectServer      *http.Server
+	grpcServer          *grpc.Server
 }
 
-func NewApp(cfg *config.Config) *App {
-	notificationFactory := services.NewNotificationServiceFactory()
-	defaultService, _ := notificationFactory.GetService(models.ChannelSlack)
-	schedulerService := services.NewSchedulerService(defaultService)
+func NewApp(cfg *config.Config) (*App, error) {
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(logging.ParseLevel(cfg.LogLevel))
+	logger := logging.NewWithLevel(os.Stdout, logLevel)
+	// Services without their own logger fall back to the default
+	slog.SetDefault(logger)
+
+	repo, err := newRepository(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification repository: %v", err)
+	}
+	// Notification streams follow the events the repository appends
+	events := services.NewEventBus()
+	if publishing, ok := repo.(repository.PublishingRepository); ok {
+		publishing.WithEventPublisher(events)
+	}
+
+	auditLog, err := newAuditLog(cfg, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	notificationFactory := services.NewNotificationServiceFactory(cfg)
+	notificationFactory.WithDeadLetterQueue(newDeadLetterQueue(cfg, logger))
+	if cfg.PluginDir != "" {
+		channels, err := notificationFactory.LoadPlugins(cfg.PluginDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load channel plugins: %v", err)
+		}
+		logger.Info("Loaded channel plugins", "dir", cfg.PluginDir, "channels", channels)
+	}
+	// The fan-out service routes each scheduled notification to its own channels
+	schedulerService, err := newScheduler(cfg, services.NewFanOutNotificationService(notificationFactory), repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduler: %v", err)
+	}
+	schedulerService.WithLogger(logger)
+	schedulerService.WithDrainTimeout(cfg.ShutdownTimeout)
+	var batcher *services.BatchingSchedulerService
+	if cfg.SchedulerBatchWindow > 0 {
+		batcher = services.NewBatchingSchedulerService(schedulerService, repo, cfg.SchedulerBatchWindow)
+		batcher.WithLogger(logger)
+		batcher.WithSeparator(cfg.SchedulerBatchSeparator)
+	}
+	digestService := services.NewDigestService(services.NewFanOutNotificationService(notificationFactory), repo, cfg.DigestWindow, cfg.DigestMaxSize)
+	digestService.WithLogger(logger)
+	if auditLog != nil {
+		schedulerService.WithAuditLogger(auditLog)
+		digestService.WithAuditLogger(auditLog)
+	}
+	var callbacks *services.CallbackService
+	if cfg.CallbackSecret != "" {
+		callbackRepo, ok := repo.(repository.CallbackRepository)
+		if !ok {
+			callbackRepo = repository.NewMemoryRepository()
+		}
+		callbacks = services.NewCallbackService(cfg.CallbackSecret, callbackRepo, cfg.HTTPTimeout)
+		callbacks.WithLogger(logger)
+		schedulerService.WithCallbackService(callbacks)
+	}
+
+	var outboxWorker *services.OutboxWorker
+	if cfg.EmailOutboxEnabled {
+		// The outbox must share a transaction with the notification
+		outbox, ok := repo.(repository.EmailOutbox)
+		if !ok {
+			return nil, fmt.Errorf("storage backend %q does not support the email outbox", cfg.StorageBackend)
+		}
+		notificationFactory.WithEmailOutbox(outbox)
+		outboxWorker = services.NewOutboxWorker(outbox, repo, notificationFactory, cfg.EmailOutboxPollInterval, cfg.EmailOutboxMaxRetries)
+		outboxWorker.WithLogger(logger)
+	}
+
+	var collector *metrics.MetricsCollector
+	if cfg.MetricsEnabled {
+		collector = metrics.NewMetricsCollector()
+		notificationFactory.WithMetrics(collector)
+		collector.ObserveQueueDepth(schedulerService.QueueDepth)
+		schedulerService.WithMetrics(collector)
+	}
+
+	var tracerProvider *tracing.TracerProvider
+	if cfg.OTLPEndpoint != "" {
+		if tracerProvider, err = tracing.NewTracerProvider(context.Background(), cfg); err != nil {
+			return nil, fmt.Errorf("failed to configure tracing: %v", err)
+		}
+		notificationFactory.WithTracer(tracerProvider.Tracer())
+		schedulerService.WithTracer(tracerProvider.Tracer())
+	}
+
+	templates, err := newTemplateRepository(cfg, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template repository: %v", err)
+	}
+
+	templateService := services.NewTemplateService(templates)
+	templateService.WithCacheTTL(cfg.TemplateCacheTTL)
+	if cfg.LocaleDir != "" {
+		catalog := i18n.NewCatalog()
+		if err := catalog.LoadDir(cfg.LocaleDir); err != nil {
+			return nil, fmt.Errorf("failed to load message catalogs: %v", err)
+		}
+		templateService.WithCatalog(catalog)
+	}
+	notificationFactory.WithTemplateService(templateService)
+
+	// The suppression list is shared by every tenant, since unsubscribe
+	// links carry no tenant
+	var unsubscribe *services.UnsubscribeService
+	if cfg.UnsubscribeSecret != "" {
+		suppressions, ok := repo.(repository.SuppressionRepository)
+		if !ok {
+			suppressions = repository.NewMemoryRepository()
+		}
+		unsubscribe = services.NewUnsubscribeService(cfg.UnsubscribeSecret, cfg.TrackingBaseURL, suppressions)
+		notificationFactory.WithUnsubscribeService(unsubscribe)
+	}
+
+	// Users live alongside notifications in the same database
+	users, ok := repo.(repository.UserRepository)
+	if !ok {
+		users = repository.NewMemoryRepository()
+	}
+	apiKeys, ok := repo.(repository.APIKeyRepository)
+	if !ok {
+		apiKeys = repository.NewMemoryRepository()
+	}
+
+	var tenants *services.TenantService
+	if cfg.MultiTenantEnabled {
+		tenantRepo, ok := repo.(repository.TenantRepository)
+		if !ok {
+			tenantRepo = repository.NewMemoryRepository()
+		}
+		tenants = services.NewTenantService(tenantRepo, notificationFactory)
+		if err := tenants.Load(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to load tenants: %v", err)
+		}
+	}
+
+	httpLimiter, err := newHTTPLimiter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP rate limiter: %v", err)
+	}
+
+	var tokens *services.TokenService
+	if cfg.AuthMode == "jwt" {
+		if tokens, err = services.NewTokenService(users, cfg); err != nil {
+			return nil, fmt.Errorf("failed to configure JWT authentication: %v", err)
+		}
+	}
 
-	return &App{
+	a := &App{
 		config:              cfg,
 		notificationFactory: notificationFactory,
 		schedulerService:    schedulerService,
+		batcher:             batcher,
+		digestService:       digestService,
+		callbacks:           callbacks,
+		outboxWorker:        outboxWorker,
+		templateService:     templateService,
+		unsubscribe:         unsubscribe,
+		userPreferences:     services.NewUserPreferenceService(users),
+		apiKeys:             services.NewAPIKeyService(apiKeys, cfg.APIKeys),
+		tokens:              tokens,
+		tenants:             tenants,
+		auditLog:            auditLog,
+		httpLimiter:         httpLimiter,
+		metrics:             collector,
+		tracing:             tracerProvider,
+		events:              events,
+		logger:              logger,
+		logLevel:            logLevel,
+		repository:          repo,
+	}
+
+	if cfg.ConfigFile != "" {
+		watcher, err := config.NewConfigWatcher(cfg.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		watcher.OnReload(a.applyConfig)
+		watcher.OnError(func(err error) {
+			logger.Error("Error reloading configuration, keeping the current one", "error", err)
+		})
+		a.configWatcher = watcher
+	}
+	return a, nil
+}
+
+// applyConfig puts the settings that can change without a restart into
+// effect: the log level, rate limits and configured API keys.
+func (a *App) applyConfig(next *config.Config) {
+	a.logLevel.Set(logging.ParseLevel(next.LogLevel))
+	if unapplied := a.notificationFactory.SetRateLimits(next.RateLimits); len(unapplied) > 0 {
+		a.logger.Warn("Rate limits for channels started without one apply after a restart", "channels", unapplied)
+	}
+	a.apiKeys.SetConfiguredKeys(next.APIKeys)
+	a.logger.Info("Reloaded configuration", "file", next.ConfigFile, "log_level", next.LogLevel)
+}
+
+func newRepository(cfg *config.Config) (repository.NotificationRepository, error) {
+	switch cfg.StorageBackend {
+	case "", "sqlite":
+		return repository.NewSQLiteRepository(cfg.DatabasePath)
+	case "postgres":
+		return repository.NewPostgresRepository(cfg.DatabaseDSN)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// newAuditLog returns the audit log selected by cfg, or nil if auditing is
+// disabled. The database backend falls back to memory when repo cannot
+// store audit events.
+func newAuditLog(cfg *config.Config, repo repository.NotificationRepository) (services.AuditLog, error) {
+	switch cfg.AuditBackend {
+	case "", "database":
+		audits, ok := repo.(repository.AuditRepository)
+		if !ok {
+			audits = repository.NewMemoryRepository()
+		}
+		return services.NewRepositoryAuditLog(audits), nil
+	case "file":
+		return services.NewFileAuditLog(cfg.AuditLogFile)
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown audit backend %q", cfg.AuditBackend)
+	}
+}
+
+// scheduler holds scheduled and recurring notifications until they are due.
+type scheduler interface {
+	services.Scheduler
+	WithLogger(logger logging.Logger)
+	WithAuditLogger(audit services.AuditLogger)
+	WithCallbackService(callbacks *services.CallbackService)
+	WithMetrics(collector *metrics.MetricsCollector)
+	WithTracer(tracer trace.Tracer)
+	WithDrainTimeout(timeout time.Duration)
+	QueueDepth() int
+	Jobs(ctx context.Context) ([]services.ScheduledJob, error)
+	Start()
+	Stop()
+	Running() bool
+}
+
+// notificationScheduler is the scheduler requests schedule notifications
+// with: the batching scheduler when SCHEDULER_BATCH_WINDOW is set, otherwise
+// the scheduler itself.
+func (a *App) notificationScheduler() services.Scheduler {
+	if a.batcher != nil {
+		return a.batcher
+	}
+	return a.schedulerService
+}
+
+func newScheduler(cfg *config.Config, service services.NotificationService, repo repository.NotificationRepository) (scheduler, error) {
+	switch cfg.SchedulerBackend {
+	case "", "memory":
+		return services.NewSchedulerService(service, repo), nil
+	case "redis":
+		client, err := newRedisClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return services.NewRedisSchedulerService(client, service, repo), nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler backend %q", cfg.SchedulerBackend)
+	}
+}
+
+// newHTTPLimiter returns the limiter counting HTTP requests per client IP,
+// or nil when HTTP_RATE_LIMIT is unset.
+func newHTTPLimiter(cfg *config.Config) (services.RequestLimiter, error) {
+	if cfg.HTTPRateLimit.RequestsPerMinute <= 0 {
+		return nil, nil
+	}
+	switch cfg.HTTPRateLimitBackend {
+	case "", "memory":
+		return services.NewSlidingWindowLimiter(time.Minute), nil
+	case "redis":
+		client, err := newRedisClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return services.NewRedisSlidingWindowLimiter(client, time.Minute), nil
+	default:
+		return nil, fmt.Errorf("unknown HTTP rate limit backend %q", cfg.HTTPRateLimitBackend)
+	}
+}
+
+// newRedisClient connects to the Redis server at REDIS_URL.
+func newRedisClient(cfg *config.Config) (*redis.Client, error) {
+	options, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
+	}
+	return client, nil
+}
+
+// newTemplateRepository returns the template file repository if
+// TEMPLATE_FILE is set, and otherwise keeps templates alongside
+// notifications in repo.
+func newTemplateRepository(cfg *config.Config, repo repository.NotificationRepository) (repository.TemplateRepository, error) {
+	if cfg.TemplateFile != "" {
+		return repository.NewFileTemplateRepository(cfg.TemplateFile)
+	}
+	if templates, ok := repo.(repository.TemplateRepository); ok {
+		return templates, nil
+	}
+	return repository.NewMemoryTemplateRepository(), nil
+}
+
+func newDeadLetterQueue(cfg *config.Config, logger logging.Logger) services.DeadLetterQueue {
+	if cfg.DeadLetterFile == "" {
+		return services.NewMemoryDeadLetterQueue()
+	}
+	queue, err := services.NewFileDeadLetterQueue(cfg.DeadLetterFile)
+	if err != nil {
+		logger.Warn("Falling back to in-memory dead-letter queue", "error", err)
+		return services.NewMemoryDeadLetterQueue()
+	}
+	return queue
+}
+
+// newHTTPServer returns a server for handler on addr with the timeouts and
+// header limit from cfg.
+func newHTTPServer(cfg *config.Config, addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
 	}
 }
 
@@ -38,139 +410,362 @@ func (a *App) Run() error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start the scheduler service
-	a.schedulerService.Start()
-	defer a.schedulerService.Stop()
-
-	fmt.Println("\nNotification service is running with the following examples:")
-	fmt.Println("1. Immediate Slack notification to 3 users")
-	fmt.Println("2. Email notification scheduled for 5 seconds from now")
-	fmt.Println("3. Two SMS notifications scheduled for 10 and 15 seconds from now")
-	fmt.Println("\nPress Ctrl+C to exit.")
-	fmt.Println("\nSending notifications...\n")
+	// Deferred first so spans from the scheduler drain are still exported
+	if a.tracing != nil {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := a.tracing.Shutdown(ctx); err != nil {
+				a.logger.Error("Error flushing traces", "error", err)
+			}
+		}()
+	}
 
-	// Small delay to ensure messages are displayed
-	time.Sleep(1 * time.Second)
+	if closer, ok := a.repository.(io.Closer); ok {
+		defer closer.Close()
+	}
 
-	// Example 1: Immediate Slack notification to multiple users
-	slackNotification := &models.Notification{
-		ID:         "1",
-		Title:      "Team Meeting Reminder",
-		Content:    "Don't forget about the team meeting at 2 PM today!",
-		Channel:    models.ChannelSlack,
-		Recipients: []string{"user1", "user2", "user3"},
-		CreatedAt:  time.Now(),
+	if closer, ok := a.auditLog.(io.Closer); ok {
+		defer closer.Close()
 	}
 
-	slackService, err := a.notificationFactory.GetService(slackNotification.Channel)
-	if err != nil {
-		return fmt.Errorf("failed to get slack service: %v", err)
+	if closer, ok := a.httpLimiter.(io.Closer); ok {
+		defer closer.Close()
 	}
 
-	if err := slackService.Send(slackNotification); err != nil {
-		return fmt.Errorf("failed to send slack notification: %v", err)
+	if a.configWatcher != nil {
+		a.configWatcher.Start()
+		defer a.configWatcher.Close()
 	}
 
-	// Example 2: Scheduled Email notification
-	scheduledTime := time.Now().Add(5 * time.Second)
-	emailNotification := &models.Notification{
-		ID:          "2",
-		Title:       "Weekly Report Ready",
-		Content:     "Your weekly performance report is now available.",
-		Channel:     models.ChannelEmail,
-		Recipients:  []string{"manager@company.com", "hr@company.com"},
-		ScheduledAt: &scheduledTime,
-		CreatedAt:   time.Now(),
+	// Queued emails outlive a restart, so stopping only waits for the
+	// batch being delivered
+	if a.outboxWorker != nil {
+		a.outboxWorker.Start()
+		defer a.outboxWorker.Stop()
 	}
 
-	emailService, err := a.notificationFactory.GetService(emailNotification.Channel)
-	if err != nil {
-		return fmt.Errorf("failed to get email service: %v", err)
+	// Start the scheduler service
+	if closer, ok := a.schedulerService.(io.Closer); ok {
+		defer closer.Close()
 	}
+	a.schedulerService.Start()
+	defer a.schedulerService.Stop()
+	// Hand open batches to the scheduler before it stops
+	if a.batcher != nil {
+		defer a.batcher.Flush()
+	}
+	// Send collected digests rather than dropping them on shutdown
+	defer a.digestService.Flush()
+
+	mux := a.routes()
+
+	// The gRPC API shares the HTTP API's factory and scheduler
+	if a.config.GRPCPort != "" {
+		grpcNotificationServer := notificationgrpc.NewGRPCNotificationServer(a.notificationFactory, a.notificationScheduler(), a.repository, a.config)
+		grpcNotificationServer.WithTemplateService(a.templateService)
+		grpcNotificationServer.WithLogger(a.logger)
+		if a.tenants != nil {
+			grpcNotificationServer.WithTenantService(a.tenants)
+		}
+		authenticator := notificationgrpc.NewAuthenticator(a.config.AuthMode, a.apiKeys, a.tokens)
+		a.grpcServer = notificationgrpc.NewServer(grpcNotificationServer, authenticator.ServerOptions()...)
 
-	emailScheduler := services.NewSchedulerService(emailService)
-	emailScheduler.Start()
-	defer emailScheduler.Stop()
+		listener, err := net.Listen("tcp", a.config.GRPCPort)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC on %s: %v", a.config.GRPCPort, err)
+		}
+		go func() {
+			a.logger.Info("gRPC server listening", "addr", a.config.GRPCPort)
+			if err := a.grpcServer.Serve(listener); err != nil {
+				a.logger.Error("gRPC server error", "error", err)
+			}
+		}()
+		defer a.grpcServer.GracefulStop()
 
-	if err := emailScheduler.ScheduleNotification(emailNotification); err != nil {
-		return fmt.Errorf("failed to schedule email notification: %v", err)
+		if a.config.GRPCGatewayEnabled {
+			gateway, err := notificationgrpc.NewGateway(context.Background(), a.config.GRPCPort)
+			if err != nil {
+				return err
+			}
+			// The gRPC server checks tenant_id itself
+			mux.Handle("POST /v1/", a.authenticate(models.RoleSender, gateway))
+		}
 	}
 
-	// Example 3: Multiple scheduled SMS notifications with different delays
-	smsService, err := a.notificationFactory.GetService(models.ChannelMessage)
-	if err != nil {
-		return fmt.Errorf("failed to get SMS service: %v", err)
-	}
-
-	smsScheduler := services.NewSchedulerService(smsService)
-	smsScheduler.Start()
-	defer smsScheduler.Stop()
-
-	// Schedule multiple SMS notifications with different delays
-	smsNotifications := []*models.Notification{
-		{
-			ID:          "3",
-			Title:       "Appointment Reminder",
-			Content:     "Your doctor's appointment is in 1 hour.",
-			Channel:     models.ChannelMessage,
-			Recipients:  []string{"+1234567890"},
-			ScheduledAt: &time.Time{},
-			CreatedAt:   time.Now(),
-		},
-		{
-			ID:          "4",
-			Title:       "Delivery Update",
-			Content:     "Your package will arrive in 30 minutes.",
-			Channel:     models.ChannelMessage,
-			Recipients:  []string{"+1987654321"},
-			ScheduledAt: &time.Time{},
-			CreatedAt:   time.Now(),
-		},
-	}
-
-	// Set different delays for SMS notifications
-	smsNotifications[0].ScheduledAt = &time.Time{}
-	*smsNotifications[0].ScheduledAt = time.Now().Add(10 * time.Second)
-	smsNotifications[1].ScheduledAt = &time.Time{}
-	*smsNotifications[1].ScheduledAt = time.Now().Add(15 * time.Second)
-
-	for _, notification := range smsNotifications {
-		if err := smsScheduler.ScheduleNotification(notification); err != nil {
-			return fmt.Errorf("failed to schedule SMS notification: %v", err)
+	// Imports are streamed row by row, limiting each row to
+	// MAX_REQUEST_BODY_BYTES and their number to MAX_IMPORT_ROWS instead
+	limited := handlers.BodyLimitMiddleware(a.config.MaxRequestBodyBytes, mux)
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notifications/import" {
+			mux.ServeHTTP(w, r)
+			return
 		}
+		limited.ServeHTTP(w, r)
+	}))
+	// Requests over their client's rate limit are still logged
+	if a.httpLimiter != nil {
+		handler = handlers.IPRateLimitMiddleware(a.httpLimiter, a.config.HTTPRateLimit, a.credentialCheck(), a.logger, handler)
+	}
+	// Outside the rate limit so browsers can read 429s, and outside auth so
+	// preflights need no credentials
+	handler = handlers.CORSMiddleware(a.config.CORSOrigins, handler)
+	// Spans started while handling a request join the caller's trace
+	if a.tracing != nil {
+		handler = a.tracing.Middleware(handler)
 	}
 
-	// Create notification handler
-	notificationHandler := handlers.NewNotificationHandler(a.notificationFactory, a.schedulerService)
+	// Create server; every request is logged with its correlation ID
+	a.server = newHTTPServer(a.config, a.config.ServerPort, handlers.LoggingMiddleware(a.logger, handler))
+	if a.config.WriteTimeout > 0 && a.config.WriteTimeout < a.config.NotificationTimeout {
+		a.logger.Warn("WRITE_TIMEOUT is shorter than NOTIFICATION_TIMEOUT; slow sends will be cut off before they respond",
+			"write_timeout", a.config.WriteTimeout, "notification_timeout", a.config.NotificationTimeout)
+	}
 
-	// Setup routes
-	mux := http.NewServeMux()
-	mux.HandleFunc("/notifications", notificationHandler.SendNotification)
+	// With TLS the API moves to the TLS port and plain HTTP only answers
+	// ACME challenges and redirects
+	if a.config.TLSEnabled {
+		tlsConfig, httpHandler, err := newTLSConfig(a.config)
+		if err != nil {
+			return err
+		}
+		a.server.Addr = a.config.TLSPort
+		a.server.TLSConfig = tlsConfig
+		a.redirectServer = newHTTPServer(a.config, a.config.HTTPRedirectPort, httpHandler)
 
-	// Create server
-	a.server = &http.Server{
-		Addr:    a.config.ServerPort,
-		Handler: mux,
+		go func() {
+			a.logger.Info("HTTP redirect server listening", "addr", a.config.HTTPRedirectPort)
+			if err := a.redirectServer.ListenAndServe(); err != http.ErrServerClosed {
+				a.logger.Error("HTTP redirect server error", "error", err)
+			}
+		}()
 	}
 
 	// Start HTTP server in a goroutine
 	go func() {
-		fmt.Printf("HTTP server listening on %s\n", a.config.ServerPort)
-		if err := a.server.ListenAndServe(); err != http.ErrServerClosed {
-			fmt.Printf("HTTP server error: %v\n", err)
+		a.logger.Info("HTTP server listening", "addr", a.server.Addr, "tls", a.config.TLSEnabled)
+		var err error
+		if a.config.TLSEnabled {
+			// The certificates come from TLSConfig
+			err = a.server.ListenAndServeTLS("", "")
+		} else {
+			err = a.server.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
+			a.logger.Error("HTTP server error", "error", err)
 		}
 	}()
 
 	// Wait for shutdown signal
 	<-sigChan
-	fmt.Println("\nShutting down notification service...")
+	a.logger.Info("Shutting down notification service")
+	// Fail readiness first so probes stop routing traffic here
+	a.health.Drain()
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	if a.redirectServer != nil {
+		if err := a.redirectServer.Shutdown(ctx); err != nil {
+			return fmt.Errorf("redirect server shutdown failed: %v", err)
+		}
+	}
 	if err := a.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server shutdown failed: %v", err)
 	}
 
 	return nil
 }
+
+// routes registers the HTTP API's handlers.
+func (a *App) routes() *http.ServeMux {
+	// Create notification handler
+	notificationHandler := handlers.NewNotificationHandler(a.notificationFactory, a.notificationScheduler(), a.repository, a.config)
+	notificationHandler.WithTemplateService(a.templateService)
+	notificationHandler.WithUserPreferenceService(a.userPreferences)
+	notificationHandler.WithDigestService(a.digestService)
+	if a.callbacks != nil {
+		notificationHandler.WithCallbackService(a.callbacks)
+	}
+	if a.events != nil {
+		notificationHandler.WithEventBus(a.events)
+	}
+	notificationHandler.WithLogger(a.logger)
+	if a.tracing != nil {
+		notificationHandler.WithTracer(a.tracing.Tracer())
+	}
+	if a.auditLog != nil {
+		notificationHandler.WithAuditLogger(a.auditLog)
+	}
+	templateHandler := handlers.NewTemplateHandler(a.templateService)
+	userHandler := handlers.NewUserHandler(a.userPreferences)
+	deliveryHandler := handlers.NewDeliveryHandler(services.NewDeliveryReceiptService(a.repository))
+	deliveryHandler.WithSigningSecret(a.config.DeliveryWebhookSecret)
+	deliveryHandler.WithTwilioAuthToken(a.config.TwilioAuthToken, a.config.TrackingBaseURL)
+	unsubscribeHandler := handlers.NewUnsubscribeHandler(a.unsubscribe)
+	unsubscribeHandler.WithLogger(a.logger)
+
+	// With multi-tenancy, notification and delivery endpoints act on the
+	// request's tenant
+	scoped := func(handler http.HandlerFunc) http.Handler {
+		if a.tenants == nil {
+			return handler
+		}
+		return handlers.TenantMiddleware(a.tenants, a.config.TenantBaseDomain, handler)
+	}
+
+	// Mail clients load tracking links without an X-Tenant-ID header, so
+	// the links carry the tenant in their query instead
+	tracked := func(handler http.HandlerFunc) http.Handler {
+		if a.tenants == nil {
+			return handler
+		}
+		return handlers.TrackingTenantMiddleware(a.tenants, a.config.TenantBaseDomain, handler)
+	}
+
+	protect := func(role string, handler http.HandlerFunc) http.Handler {
+		return a.authenticate(role, scoped(handler))
+	}
+
+	graphqlHandler := handlers.NewGraphQLHandler(notificationHandler)
+	graphqlHandler.WithAllowedOrigins(a.config.CORSOrigins)
+
+	a.health = a.newHealthHandler()
+
+	// Setup routes
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", a.health.Liveness)
+	mux.HandleFunc("GET /readyz", a.health.Readiness)
+	mux.HandleFunc("GET /openapi.json", handlers.OpenAPI)
+	mux.HandleFunc("GET /docs", handlers.Docs)
+	mux.Handle("POST /notifications", protect(models.RoleSender, notificationHandler.SendNotification))
+	mux.Handle("POST /notifications/bulk", protect(models.RoleSender, notificationHandler.SendBulkNotifications))
+	mux.Handle("POST /notifications/import", protect(models.RoleSender, notificationHandler.ImportNotifications))
+	mux.Handle("POST /notifications/preview", protect(models.RoleSender, notificationHandler.PreviewNotification))
+	mux.Handle("GET /notifications", protect(models.RoleSender, notificationHandler.ListNotifications))
+	mux.Handle("GET /notifications/export", protect(models.RoleAdmin, notificationHandler.ExportNotifications))
+	mux.Handle("GET /notifications/{id}", protect(models.RoleSender, notificationHandler.GetNotification))
+	mux.Handle("DELETE /notifications/{id}", protect(models.RoleAdmin, notificationHandler.CancelNotification))
+	mux.Handle("PATCH /notifications/{id}", protect(models.RoleAdmin, notificationHandler.RescheduleNotification))
+	mux.Handle("GET /notifications/{id}/status", protect(models.RoleSender, notificationHandler.NotificationStatus))
+	mux.Handle("GET /notifications/{id}/events", protect(models.RoleSender, notificationHandler.NotificationEvents))
+	mux.Handle("GET /notifications/{id}/stream", protect(models.RoleSender, notificationHandler.StreamNotification))
+	if a.events != nil {
+		broadcaster := handlers.NewWebSocketBroadcaster(a.events, a.repository)
+		broadcaster.WithLogger(a.logger)
+		broadcaster.WithAllowedOrigins(a.config.CORSOrigins)
+		mux.Handle("GET /ws/notifications", protect(models.RoleSender, broadcaster.Notifications))
+	}
+	mux.Handle("GET /stats", protect(models.RoleSender, notificationHandler.Stats))
+	mux.Handle("GET /scheduler/jobs", a.requireAdmin(http.HandlerFunc(handlers.NewSchedulerHandler(a.schedulerService).Jobs)))
+	mux.Handle("GET /notifications/dead-letter", protect(models.RoleSender, notificationHandler.DeadLetters))
+	mux.Handle("POST /notifications/dead-letter", protect(models.RoleAdmin, notificationHandler.DeadLetters))
+	// GET upgrades to a WebSocket for subscriptions
+	mux.Handle("POST /graphql", protect(models.RoleSender, graphqlHandler.GraphQL))
+	mux.Handle("GET /graphql", protect(models.RoleSender, graphqlHandler.GraphQL))
+	// Templates and user preferences are shared by every tenant
+	global := func(role string, handler http.HandlerFunc) http.Handler {
+		return a.authenticate(role, handler)
+	}
+	mux.Handle("GET /templates", global(models.RoleSender, templateHandler.Templates))
+	mux.Handle("POST /templates", global(models.RoleAdmin, templateHandler.Templates))
+	mux.Handle("GET /templates/{name}", global(models.RoleSender, templateHandler.Template))
+	mux.Handle("PUT /templates/{name}", global(models.RoleAdmin, templateHandler.Template))
+	mux.Handle("DELETE /templates/{name}", global(models.RoleAdmin, templateHandler.Template))
+	mux.Handle("GET /templates/{name}/versions", global(models.RoleSender, templateHandler.TemplateVersions))
+	mux.Handle("GET /templates/{name}/versions/{version}", global(models.RoleSender, templateHandler.TemplateVersion))
+	mux.Handle("GET /users/{id}", global(models.RoleSender, userHandler.User))
+	mux.Handle("PUT /users/{id}", global(models.RoleSender, userHandler.User))
+	mux.Handle("GET /users/{id}/subscriptions", global(models.RoleSender, userHandler.Subscriptions))
+	mux.Handle("POST /users/{id}/subscriptions", global(models.RoleSender, userHandler.Subscriptions))
+	mux.Handle("GET /track/open/{id}", tracked(deliveryHandler.TrackOpen))
+	mux.Handle("GET /track/click/{id}", tracked(notificationHandler.TrackClick))
+	mux.HandleFunc("GET /unsubscribe/{token}", unsubscribeHandler.Unsubscribe)
+	mux.Handle("GET /webhooks/{channel}/delivery", scoped(deliveryHandler.Delivery))
+	mux.Handle("POST /webhooks/{channel}/delivery", scoped(deliveryHandler.Delivery))
+	if a.config.SlackSigningSecret != "" {
+		slackHandler := handlers.NewSlackHandler(a.config.SlackSigningSecret, services.NewDeliveryReceiptService(a.repository))
+		mux.HandleFunc("POST /webhooks/slack", slackHandler.Events)
+	}
+	if a.tokens != nil {
+		mux.HandleFunc("POST /auth/token", handlers.NewAuthHandler(a.tokens).Token)
+	}
+	if a.config.AdminAPIKey != "" || a.tokens != nil {
+		adminHandler := handlers.NewAdminHandler(a.apiKeys)
+		adminHandler.WithTokenService(a.tokens)
+		adminHandler.WithTenantService(a.tenants)
+		adminHandler.WithAuditLog(a.auditLog)
+		admin := func(handler http.HandlerFunc) http.Handler {
+			return handlers.AdminMiddleware(a.config.AdminAPIKey, a.tokens, handler)
+		}
+		mux.Handle("POST /admin/api-keys", admin(adminHandler.APIKeys))
+		mux.Handle("PUT /admin/users/{id}/credentials", admin(adminHandler.Credentials))
+		mux.Handle("GET /admin/tenants", admin(adminHandler.Tenants))
+		mux.Handle("POST /admin/tenants", admin(adminHandler.Tenants))
+		mux.Handle("GET /admin/audit", admin(adminHandler.AuditEvents))
+	}
+	if a.metrics != nil {
+		mux.Handle("GET /metrics", a.metrics.Handler())
+	}
+	return mux
+}
+
+// pinger is implemented by dependencies whose connection can be checked.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// newHealthHandler makes readiness depend on the database, on Redis when the
+// scheduler uses it, and on the scheduler running.
+func (a *App) newHealthHandler() *handlers.HealthHandler {
+	health := handlers.NewHealthHandler()
+	if db, ok := a.repository.(pinger); ok {
+		health.AddCheck("database", db.Ping)
+	}
+	if redis, ok := a.schedulerService.(pinger); ok {
+		health.AddCheck("redis", redis.Ping)
+	}
+	health.AddCheck("scheduler", func(ctx context.Context) error {
+		if !a.schedulerService.Running() {
+			return errors.New("scheduler is not running")
+		}
+		return nil
+	})
+	return health
+}
+
+// requireAdmin makes handler require the admin role in every auth mode, for
+// routes that act across tenants. With authentication off it takes the admin
+// key or an admin token, so it is closed when neither is configured.
+func (a *App) requireAdmin(handler http.Handler) http.Handler {
+	if a.config.AuthMode == "" {
+		return handlers.AdminMiddleware(a.config.AdminAPIKey, a.tokens, handler)
+	}
+	return a.authenticate(models.RoleAdmin, handler)
+}
+
+// credentialCheck reports whether a request carries credentials accepted in
+// the auth mode, or the admin key, for the higher authenticated rate limit.
+func (a *App) credentialCheck() func(r *http.Request) bool {
+	var keys *services.APIKeyService
+	if a.config.AuthMode == "api_key" {
+		keys = a.apiKeys
+	}
+	return handlers.CredentialCheck(keys, a.tokens, a.config.AdminAPIKey)
+}
+
+// authenticate makes handler require an API key or a token granting role,
+// depending on the auth mode. API keys carry no roles, so in api_key mode
+// only the admin key opens admin routes.
+func (a *App) authenticate(role string, handler http.Handler) http.Handler {
+	switch a.config.AuthMode {
+	case "api_key":
+		if role == models.RoleAdmin {
+			return handlers.AdminKeyMiddleware(a.config.AdminAPIKey, a.apiKeys, handler)
+		}
+		return handlers.AuthMiddleware(a.apiKeys, handler)
+	case "jwt":
+		return handlers.JWTMiddleware(a.tokens, role, handler)
+	}
+	return handler
+}
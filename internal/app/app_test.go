@@ -0,0 +1,396 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/handlers"
+	"notification-service/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig := func(key, logLevel string) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := fmt.Sprintf("database_path: %s\nauth_mode: api_key\napi_keys: [%q]\nlog_level: %s\n",
+			filepath.Join(dir, "notifications.db"), hash, logLevel)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("old-key", "info")
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer slog.SetDefault(slog.Default())
+	application, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+	defer application.repository.(io.Closer).Close()
+	application.configWatcher.Start()
+	defer application.configWatcher.Close()
+
+	server := httptest.NewServer(application.routes())
+	defer server.Close()
+	listWith := func(key string) int {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/notifications", nil)
+		req.Header.Set(handlers.APIKeyHeader, key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := listWith("old-key"); code != http.StatusOK {
+		t.Fatalf("Expected old key to be accepted, got status %d", code)
+	}
+	if code := listWith("new-key"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected new key to be rejected before reload, got status %d", code)
+	}
+
+	writeConfig("new-key", "debug")
+	deadline := time.Now().Add(time.Second)
+	for listWith("new-key") != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected new key to be accepted within 1s of the config change")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if code := listWith("old-key"); code != http.StatusUnauthorized {
+		t.Errorf("Expected old key to be rejected after reload, got status %d", code)
+	}
+	if level := application.logLevel.Level(); level != slog.LevelDebug {
+		t.Errorf("Expected log level debug after reload, got %s", level)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DatabasePath: filepath.Join(dir, "notifications.db"),
+		AuthMode:     "api_key",
+	}
+	defer slog.SetDefault(slog.Default())
+	application, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+	defer application.repository.(io.Closer).Close()
+
+	server := httptest.NewServer(application.routes())
+	defer server.Close()
+	get := func(path string) int {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Errorf("Expected liveness without credentials to be 200, got %d", code)
+	}
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before the scheduler starts, got %d", code)
+	}
+
+	application.schedulerService.Start()
+	defer application.schedulerService.Stop()
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("Expected 200 once the scheduler runs, got %d", code)
+	}
+
+	application.health.Drain()
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while shutting down, got %d", code)
+	}
+}
+
+func TestHTTPServerReadTimeout(t *testing.T) {
+	cfg := &config.Config{ReadTimeout: 200 * time.Millisecond, ReadHeaderTimeout: 200 * time.Millisecond, MaxHeaderBytes: 1 << 20}
+	server := newHTTPServer(cfg, "127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Start a request and stall before sending its body
+	fmt.Fprint(conn, "POST /notifications HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\n")
+	started := time.Now()
+	conn.SetReadDeadline(started.Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("Expected the server to close the connection, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Expected the connection closed after the read timeout, took %v", elapsed)
+	}
+}
+
+// TestOpenAPIRoutes checks that every operation in the served spec is
+// routed to a handler registered for exactly that method and path.
+func TestOpenAPIRoutes(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{DatabasePath: filepath.Join(dir, "notifications.db")}
+	defer slog.SetDefault(slog.Default())
+	application, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+	defer application.repository.(io.Closer).Close()
+	mux := application.routes()
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse /openapi.json: %v", err)
+	}
+	if len(spec.Paths) == 0 {
+		t.Fatal("Expected the spec to document paths")
+	}
+
+	for path := range spec.Paths {
+		for method := range spec.Paths[path] {
+			route := strings.ToUpper(method) + " " + path
+			req := httptest.NewRequest(strings.ToUpper(method), strings.ReplaceAll(strings.ReplaceAll(path, "{", ""), "}", ""), nil)
+			if _, pattern := mux.Handler(req); pattern != route {
+				t.Errorf("Expected %s to be routed, got pattern %q", route, pattern)
+			}
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected /docs to be 200, got %d", rr.Code)
+	}
+}
+
+func TestTemplateAndUserRoutesRequireAuth(t *testing.T) {
+	dir := t.TempDir()
+	hash, err := bcrypt.GenerateFromPassword([]byte("nsk_test"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash key: %v", err)
+	}
+	cfg := &config.Config{
+		DatabasePath: filepath.Join(dir, "notifications.db"),
+		AuthMode:     "api_key",
+		APIKeys:      []string{string(hash)},
+	}
+	defer slog.SetDefault(slog.Default())
+	application, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+	defer application.repository.(io.Closer).Close()
+	mux := application.routes()
+
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/templates"},
+		{http.MethodPost, "/templates"},
+		{http.MethodGet, "/templates/welcome"},
+		{http.MethodPut, "/templates/welcome"},
+		{http.MethodDelete, "/templates/welcome"},
+		{http.MethodGet, "/templates/welcome/versions"},
+		{http.MethodGet, "/templates/welcome/versions/1"},
+		{http.MethodGet, "/users/user123"},
+		{http.MethodPut, "/users/user123"},
+		{http.MethodGet, "/users/user123/subscriptions"},
+		{http.MethodPost, "/users/user123/subscriptions"},
+	}
+	for _, route := range routes {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(route.method, route.path, strings.NewReader("{}")))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected %s %s without a key to be 401, got %d", route.method, route.path, rr.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/templates", nil)
+	req.Header.Set(handlers.APIKeyHeader, "nsk_test")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected GET /templates with a key to be 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestAdminRoutesRequireAdminKey(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DatabasePath: filepath.Join(dir, "notifications.db"),
+		AuthMode:     "api_key",
+		AdminAPIKey:  "master-key",
+	}
+	defer slog.SetDefault(slog.Default())
+	application, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+	defer application.repository.(io.Closer).Close()
+	tenantKey, _, err := application.apiKeys.Create(context.Background(), "acme", "acme")
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	mux := application.routes()
+
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/templates"},
+		{http.MethodPut, "/templates/welcome"},
+		{http.MethodDelete, "/templates/welcome"},
+		{http.MethodPost, "/notifications/dead-letter"},
+		{http.MethodDelete, "/notifications/missing"},
+		{http.MethodPatch, "/notifications/missing"},
+		{http.MethodGet, "/notifications/export"},
+	}
+	for _, route := range routes {
+		req := httptest.NewRequest(route.method, route.path, strings.NewReader("{}"))
+		req.Header.Set(handlers.APIKeyHeader, tenantKey)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected %s %s with a tenant's key to be 403, got %d", route.method, route.path, rr.Code)
+		}
+
+		req = httptest.NewRequest(route.method, route.path, strings.NewReader("{}"))
+		req.Header.Set(handlers.APIKeyHeader, "master-key")
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code == http.StatusUnauthorized || rr.Code == http.StatusForbidden {
+			t.Errorf("Expected %s %s with the admin key to be allowed, got %d", route.method, route.path, rr.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/templates", nil)
+	req.Header.Set(handlers.APIKeyHeader, tenantKey)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected GET /templates with a tenant's key to be 200, got %d", rr.Code)
+	}
+}
+
+func TestSchedulerJobsRequireAdmin(t *testing.T) {
+	for _, authMode := range []string{"", "api_key"} {
+		t.Run("auth mode "+authMode, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := &config.Config{
+				DatabasePath: filepath.Join(dir, "notifications.db"),
+				AuthMode:     authMode,
+				AdminAPIKey:  "master-key",
+			}
+			defer slog.SetDefault(slog.Default())
+			application, err := NewApp(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create app: %v", err)
+			}
+			defer application.repository.(io.Closer).Close()
+			tenantKey, _, err := application.apiKeys.Create(context.Background(), "acme", "acme")
+			if err != nil {
+				t.Fatalf("Failed to create key: %v", err)
+			}
+			mux := application.routes()
+
+			listWith := func(key string) int {
+				req := httptest.NewRequest(http.MethodGet, "/scheduler/jobs", nil)
+				if key != "" {
+					req.Header.Set(handlers.APIKeyHeader, key)
+				}
+				rr := httptest.NewRecorder()
+				mux.ServeHTTP(rr, req)
+				return rr.Code
+			}
+			if code := listWith(""); code != http.StatusUnauthorized {
+				t.Errorf("Expected 401 without a key, got %d", code)
+			}
+			if code := listWith("master-key"); code != http.StatusOK {
+				t.Errorf("Expected 200 with the admin key, got %d", code)
+			}
+			if authMode == "api_key" {
+				if code := listWith(tenantKey); code != http.StatusForbidden {
+					t.Errorf("Expected 403 with a tenant's key, got %d", code)
+				}
+			}
+		})
+	}
+}
+
+func TestTrackingLinksWithMultiTenancy(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DatabasePath:              filepath.Join(dir, "notifications.db"),
+		MultiTenantEnabled:        true,
+		TrackingBaseURL:           "https://notify.example.com",
+		ClickTrackingAllowedHosts: []string{"shop.example.com"},
+	}
+	defer slog.SetDefault(slog.Default())
+	application, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+	defer application.repository.(io.Closer).Close()
+	ctx := context.Background()
+	if err := application.tenants.Save(ctx, &models.Tenant{ID: "acme"}); err != nil {
+		t.Fatalf("Failed to save tenant: %v", err)
+	}
+	notification := &models.Notification{ID: "email-1", TenantID: "acme", Channel: models.ChannelEmail, Status: models.StatusSent, CreatedAt: time.Now()}
+	if err := application.repository.Save(ctx, notification); err != nil {
+		t.Fatalf("Failed to save notification: %v", err)
+	}
+	mux := application.routes()
+
+	// Mail clients load the links of a tenant's emails without headers
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/track/click/email-1?tenant=acme&url=https%3A%2F%2Fshop.example.com%2Fsale", nil))
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "https://shop.example.com/sale" {
+		t.Errorf("Expected a redirect to the original link, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/track/open/email-1?tenant=acme", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the tracking pixel, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if clicks, err := application.repository.ListClicks(ctx, "acme", "email-1"); err != nil || len(clicks) != 1 {
+		t.Errorf("Expected one click recorded for the tenant, got %v (%v)", clicks, err)
+	}
+	if stored, err := application.repository.GetByID(ctx, "acme", "email-1"); err != nil || stored.OpenedAt == nil {
+		t.Errorf("Expected the open recorded for the tenant, got %+v (%v)", stored, err)
+	}
+}
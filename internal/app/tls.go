@@ -0,0 +1,96 @@
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"notification-service/internal/config"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newTLSConfig returns the HTTPS server's TLS config and the handler for
+// plain HTTP. With a TLSDomain, certificates come from Let's Encrypt and the
+// plain HTTP handler answers its HTTP-01 challenges; without one a
+// self-signed certificate is used for development. Everything else on plain
+// HTTP is redirected to HTTPS.
+func newTLSConfig(cfg *config.Config) (*tls.Config, http.Handler, error) {
+	redirect := redirectToHTTPS(cfg.TLSPort)
+
+	var domains []string
+	for _, domain := range strings.Split(cfg.TLSDomain, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		certificate, err := selfSignedCertificate("localhost")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create self-signed certificate: %v", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}, redirect, nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cfg.TLSCacheDir),
+		Email:      cfg.TLSEmail,
+	}
+	return manager.TLSConfig(), manager.HTTPHandler(redirect), nil
+}
+
+// redirectToHTTPS redirects requests to the same host and path on tlsPort.
+func redirectToHTTPS(tlsPort string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsPort)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// selfSignedCertificate creates a year-long certificate for host and the
+// loopback addresses.
+func selfSignedCertificate(host string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"notification-service"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{host},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"testing"
+)
+
+func TestNewTLSConfigSelfSigned(t *testing.T) {
+	tlsConfig, _, err := newTLSConfig(&config.Config{TLSPort: ":443"})
+	if err != nil {
+		t.Fatalf("Failed to create TLS config: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("Expected a self-signed certificate, got %d certificates", len(tlsConfig.Certificates))
+	}
+	certificate, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	if err := certificate.VerifyHostname("localhost"); err != nil {
+		t.Errorf("Expected the certificate to cover localhost: %v", err)
+	}
+	if err := certificate.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("Expected the certificate to cover 127.0.0.1: %v", err)
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name     string
+		tlsPort  string
+		target   string
+		expected string
+	}{
+		{"Default port", ":443", "http://example.com/notifications?limit=5", "https://example.com/notifications?limit=5"},
+		{"Custom port", ":8443", "http://example.com:8080/metrics", "https://example.com:8443/metrics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			redirectToHTTPS(tt.tlsPort).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rr.Code != http.StatusMovedPermanently {
+				t.Errorf("Expected status code %d, got %d", http.StatusMovedPermanently, rr.Code)
+			}
+			if location := rr.Header().Get("Location"); location != tt.expected {
+				t.Errorf("Expected redirect to %s, got %s", tt.expected, location)
+			}
+		})
+	}
+}
@@ -1,11 +1,697 @@
 package config
 
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RateLimitConfig struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// HTTPRateLimitConfig limits the HTTP API's requests from each client IP
+// over a sliding minute. BurstSize requests are tolerated on top of the
+// per-minute rate. Requests with valid credentials get
+// AuthenticatedRequestsPerMinute instead of RequestsPerMinute. A zero
+// RequestsPerMinute disables the limit.
+type HTTPRateLimitConfig struct {
+	RequestsPerMinute              int
+	BurstSize                      int
+	AuthenticatedRequestsPerMinute int
+}
+
+// CircuitBreakerConfig opens a channel's breaker after FailureThreshold
+// consecutive failures, keeps it open for Timeout and then closes it once
+// HalfOpenProbes trial sends succeed.
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	Timeout          time.Duration
+	HalfOpenProbes   int
+}
+
+// ChannelLimitConfig caps the length, in characters, of a channel's
+// notification title and content. Zero leaves a field unrestricted.
+type ChannelLimitConfig struct {
+	MaxTitleLength   int
+	MaxContentLength int
+	// TruncatePolicy is what happens to content over MaxContentLength;
+	// empty means TruncationReject.
+	TruncatePolicy TruncationPolicy
+}
+
+// TruncationPolicy is how content longer than a channel's limit is handled.
+type TruncationPolicy string
+
+const (
+	// TruncationReject refuses to send the notification.
+	TruncationReject TruncationPolicy = "reject"
+	// TruncationTruncate cuts the content to the limit, ending it with "…".
+	TruncationTruncate TruncationPolicy = "truncate"
+	// TruncationSplit sends the content in parts that fit the limit.
+	TruncationSplit TruncationPolicy = "split"
+)
+
 type Config struct {
-	ServerPort string
+	// ConfigFile is the JSON or YAML file the configuration was loaded
+	// from and is watched for changes; empty uses only the environment.
+	ConfigFile string `env:"NOTIFICATION_CONFIG_FILE"`
+
+	ServerPort string `env:"SERVER_PORT"`
+	// TLSEnabled serves the HTTP API over HTTPS on TLSPort, redirecting
+	// plain HTTP on HTTPRedirectPort. Certificates come from Let's Encrypt
+	// for TLSDomain, or are self-signed when it is empty.
+	TLSEnabled       bool   `env:"TLS_ENABLED"`
+	TLSPort          string `env:"TLS_PORT"`
+	HTTPRedirectPort string `env:"HTTP_REDIRECT_PORT"`
+	// TLSDomain lists the comma-separated host names to request
+	// certificates for.
+	TLSDomain string `env:"TLS_DOMAIN"`
+	// TLSEmail is the optional ACME account contact.
+	TLSEmail string `env:"TLS_EMAIL"`
+	// TLSCacheDir stores issued certificates across restarts.
+	TLSCacheDir string `env:"TLS_CACHE_DIR"`
+	// GRPCPort is where the gRPC API listens; empty disables it.
+	GRPCPort string `env:"GRPC_PORT"`
+	// GRPCGatewayEnabled serves the gRPC API's HTTP mappings from the HTTP
+	// server by proxying them to GRPCPort.
+	GRPCGatewayEnabled bool `env:"GRPC_GATEWAY_ENABLED"`
+
+	// HTTPTimeout bounds outbound calls made by the channel services.
+	HTTPTimeout time.Duration `env:"HTTP_TIMEOUT"`
+	// NotificationTimeout bounds a single send triggered by an API request.
+	NotificationTimeout time.Duration `env:"NOTIFICATION_TIMEOUT"`
+	// ShutdownTimeout bounds how long shutdown waits for scheduled sends
+	// still in flight.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT"`
+	// ReadTimeout, WriteTimeout, IdleTimeout and ReadHeaderTimeout set the
+	// matching http.Server timeouts of the HTTP API; zero disables one.
+	ReadTimeout       time.Duration `env:"READ_TIMEOUT"`
+	WriteTimeout      time.Duration `env:"WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `env:"IDLE_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `env:"READ_HEADER_TIMEOUT"`
+	// MaxHeaderBytes limits the size of request headers.
+	MaxHeaderBytes int `env:"MAX_HEADER_BYTES"`
+	// MaxRequestBodyBytes limits the size of request bodies; zero disables
+	// the limit.
+	MaxRequestBodyBytes int64 `env:"MAX_REQUEST_BODY_BYTES"`
+	// CORSOrigins are the origins browsers may call the API from; "*"
+	// allows any origin. Empty denies all cross-origin requests.
+	CORSOrigins []string `env:"CORS_ORIGINS"`
+	// StreamTimeout bounds how long a notification's event stream stays
+	// open waiting for it to be sent or fail.
+	StreamTimeout time.Duration `env:"STREAM_TIMEOUT"`
+
+	SlackWebhookURL string `env:"SLACK_WEBHOOK_URL"`
+	// SlackSigningSecret verifies event callbacks received from Slack.
+	SlackSigningSecret string `env:"SLACK_SIGNING_SECRET"`
+	// SlackBotToken, when set, resolves "@display-name" recipients to user
+	// IDs with the Slack API.
+	SlackBotToken string `env:"SLACK_BOT_TOKEN"`
+	SlackAPIURL   string `env:"SLACK_API_URL"`
+	// SlackUserCacheTTL is how long the Slack user directory is cached.
+	SlackUserCacheTTL time.Duration `env:"SLACK_USER_CACHE_TTL"`
+
+	// DeliveryWebhookSecret verifies JSON delivery receipts, and
+	// TwilioAuthToken Twilio status callbacks. Receipts of a kind without
+	// its secret are rejected.
+	DeliveryWebhookSecret string `env:"DELIVERY_WEBHOOK_SECRET"`
+	TwilioAuthToken       string `env:"TWILIO_AUTH_TOKEN"`
+
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	SMTPFrom     string `env:"SMTP_FROM"`
+	// SMTPTLSMode is one of "starttls", "tls" (implicit) or "none".
+	SMTPTLSMode string `env:"SMTP_TLS_MODE"`
+	// EmailProvider sends the email channel's notifications: "sendgrid",
+	// "mailgun", or empty to send them over SMTP. Both providers send from
+	// SMTPFrom.
+	EmailProvider  string `env:"EMAIL_PROVIDER"`
+	SendGridAPIURL string `env:"SENDGRID_API_URL"`
+	SendGridAPIKey string `env:"SENDGRID_API_KEY"`
+	// MailgunDomain is the sending domain and MailgunRegion the region it is
+	// hosted in, "us" or "eu".
+	MailgunAPIKey string `env:"MAILGUN_API_KEY"`
+	MailgunDomain string `env:"MAILGUN_DOMAIN"`
+	MailgunRegion string `env:"MAILGUN_REGION"`
+	// TrackingBaseURL is the public URL of this service. When set, HTML
+	// emails load an open-tracking pixel from {TrackingBaseURL}/track/open/{id}.
+	TrackingBaseURL string `env:"TRACKING_BASE_URL"`
+	// ClickTrackingAllowedHosts are the hosts /track/click redirects to; a
+	// "*.example.com" entry allows its subdomains. Links to them in HTML
+	// emails are rewritten to record clicks.
+	ClickTrackingAllowedHosts []string `env:"CLICK_TRACKING_ALLOWED_HOSTS"`
+	// UnsubscribeSecret signs the unsubscribe links added to every email,
+	// {TrackingBaseURL}/unsubscribe/{token}. Emails are not sent to
+	// addresses that have unsubscribed from the notification's category.
+	UnsubscribeSecret string `env:"UNSUBSCRIBE_SECRET"`
+	// EmailAllowedTags are the HTML tags kept in the content of HTML
+	// emails; others are stripped. Empty keeps models.DefaultEmailAllowedTags.
+	EmailAllowedTags []string `env:"EMAIL_ALLOWED_TAGS"`
+	// EmailOutboxEnabled queues emails in the database's email_outbox
+	// table, in the transaction that marks their notification sent, for a
+	// worker to deliver every EmailOutboxPollInterval. An email that fails
+	// EmailOutboxMaxRetries times is given up on.
+	EmailOutboxEnabled      bool          `env:"EMAIL_OUTBOX_ENABLED"`
+	EmailOutboxPollInterval time.Duration `env:"EMAIL_OUTBOX_POLL_INTERVAL"`
+	EmailOutboxMaxRetries   int           `env:"EMAIL_OUTBOX_MAX_RETRIES"`
+
+	WhatsAppAPIURL        string `env:"WHATSAPP_API_URL"`
+	WhatsAppPhoneNumberID string `env:"WHATSAPP_PHONE_NUMBER_ID"`
+	WhatsAppAccessToken   string `env:"WHATSAPP_ACCESS_TOKEN"`
+
+	TeamsWebhookURL string `env:"TEAMS_WEBHOOK_URL"`
+
+	DiscordAPIURL   string `env:"DISCORD_API_URL"`
+	DiscordBotToken string `env:"DISCORD_BOT_TOKEN"`
+
+	PagerDutyEventsURL  string `env:"PAGERDUTY_EVENTS_URL"`
+	PagerDutyRoutingKey string `env:"PAGERDUTY_ROUTING_KEY"`
+
+	FCMAPIURL          string `env:"FCM_API_URL"`
+	FCMProjectID       string `env:"FCM_PROJECT_ID"`
+	FCMCredentialsFile string `env:"FCM_CREDENTIALS_FILE"`
+
+	// APNsKeyFile is the team's .p8 signing key, identified by APNsKeyID.
+	// APNsTopic is the bundle ID of the app notified.
+	APNsAPIURL  string `env:"APNS_API_URL"`
+	APNsKeyFile string `env:"APNS_KEY_FILE"`
+	APNsKeyID   string `env:"APNS_KEY_ID"`
+	APNsTeamID  string `env:"APNS_TEAM_ID"`
+	APNsTopic   string `env:"APNS_TOPIC"`
+
+	TelegramAPIURL   string `env:"TELEGRAM_API_URL"`
+	TelegramBotToken string `env:"TELEGRAM_BOT_TOKEN"`
+
+	// SMSAlphanumericSenderID is set when the SMS sender number supports
+	// alphanumeric sender IDs; a sender alias that is a valid one then
+	// prefixes the content of SMS notifications, or with Vonage is the
+	// sender.
+	SMSAlphanumericSenderID bool `env:"SMS_ALPHANUMERIC_SENDER_ID"`
+	// SMSProvider sends the message channel's notifications: "vonage",
+	// "sns", or empty to only log them.
+	SMSProvider string `env:"SMS_PROVIDER"`
+
+	// VonageAPIKey and VonageAPISecret authenticate with the Vonage Messages
+	// API; VonageFrom is the number messages are sent from.
+	VonageAPIURL    string `env:"VONAGE_API_URL"`
+	VonageAPIKey    string `env:"VONAGE_API_KEY"`
+	VonageAPISecret string `env:"VONAGE_API_SECRET"`
+	VonageFrom      string `env:"VONAGE_FROM"`
+
+	// AWSRegion, AWSAccessKeyID and AWSSecretAccessKey configure the Amazon
+	// SNS client of the sns channel and SMS provider.
+	AWSRegion          string `env:"AWS_REGION"`
+	AWSAccessKeyID     string `env:"AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey string `env:"AWS_SECRET_ACCESS_KEY"`
+
+	// WebhookSecret signs payloads sent by the webhook channel.
+	WebhookSecret string `env:"WEBHOOK_SECRET"`
+	// CallbackSecret signs the delivery status POSTed to notifications'
+	// callback URLs; callback_url is rejected without it.
+	CallbackSecret string `env:"CALLBACK_SECRET"`
+
+	// StorageBackend selects the notification repository: "sqlite" or "postgres".
+	StorageBackend string `env:"STORAGE_BACKEND"`
+	// DatabasePath is the SQLite database file holding notifications.
+	DatabasePath string `env:"DATABASE_PATH"`
+	// DatabaseDSN is the PostgreSQL connection string.
+	DatabaseDSN string `env:"DATABASE_DSN"`
+
+	// SchedulerBackend selects where scheduled notifications wait: "memory"
+	// or "redis", which lets several instances share them.
+	SchedulerBackend string `env:"SCHEDULER_BACKEND"`
+	// RedisURL locates the Redis server, e.g. redis://localhost:6379/0.
+	RedisURL string `env:"REDIS_URL"`
+	// SchedulerBatchWindow merges scheduled notifications to the same
+	// recipients on the same channel due within it of each other into one
+	// delivery, their contents separated by SchedulerBatchSeparator. Zero
+	// disables batching.
+	SchedulerBatchWindow    time.Duration `env:"SCHEDULER_BATCH_WINDOW"`
+	SchedulerBatchSeparator string        `env:"SCHEDULER_BATCH_SEPARATOR"`
+
+	// DeadLetterFile persists failed notifications; empty keeps them in memory.
+	DeadLetterFile string `env:"DEAD_LETTER_FILE"`
+
+	// AuditBackend selects where notification audit events are appended:
+	// "database", the notification repository, "file" or "none".
+	AuditBackend string `env:"AUDIT_BACKEND"`
+	// AuditLogFile is the JSON-lines file used by the "file" audit backend.
+	AuditLogFile string `env:"AUDIT_LOG_FILE"`
+
+	// TemplateFile persists notification templates in a JSON file instead of
+	// the database.
+	TemplateFile string `env:"TEMPLATE_FILE"`
+	// TemplateCacheTTL is how long templates are cached after being looked
+	// up; 0 disables the cache.
+	TemplateCacheTTL time.Duration `env:"TEMPLATE_CACHE_TTL"`
+	// LocaleDir holds message catalogs, one JSON or YAML file per locale,
+	// that extend the embedded English and Spanish ones.
+	LocaleDir string `env:"LOCALE_DIR"`
+
+	// PluginDir holds channel plugins built as Go shared libraries (.so);
+	// empty loads none.
+	PluginDir string `env:"PLUGIN_DIR"`
+
+	// MaxAttachmentBytes caps the decoded size of a notification's
+	// attachments; zero leaves it unlimited.
+	MaxAttachmentBytes int `env:"MAX_ATTACHMENT_BYTES"`
+
+	// BulkMaxNotifications caps the notifications in one bulk request;
+	// BulkWorkers is how many of them are sent concurrently.
+	BulkMaxNotifications int `env:"BULK_MAX_NOTIFICATIONS"`
+	BulkWorkers          int `env:"BULK_WORKERS"`
+	// MaxImportRows caps the rows read from one import file.
+	MaxImportRows int `env:"MAX_IMPORT_ROWS"`
+
+	// DigestWindow is how long digest notifications are collected before
+	// they are sent as one summary; DigestMaxSize sends it early once that
+	// many have been collected.
+	DigestWindow  time.Duration `env:"DIGEST_WINDOW"`
+	DigestMaxSize int           `env:"DIGEST_MAX_SIZE"`
+
+	// IdempotencyTTL is how long responses are kept for replay by idempotency key.
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL"`
+
+	// MetricsEnabled exposes Prometheus metrics on /metrics.
+	MetricsEnabled bool `env:"METRICS_ENABLED"`
+
+	// OTLPEndpoint is the OTLP/HTTP collector traces are exported to, e.g.
+	// http://localhost:4318; empty disables tracing. Spans are reported as
+	// TracingServiceName.
+	OTLPEndpoint       string `env:"OTLP_ENDPOINT"`
+	TracingServiceName string `env:"TRACING_SERVICE_NAME"`
+
+	// LogLevel is the minimum level logged: debug, info, warn or error.
+	LogLevel string `env:"LOG_LEVEL"`
+
+	// AuthMode protects the notification endpoints with "api_key" or "jwt";
+	// empty leaves them open.
+	AuthMode string `env:"AUTH_MODE"`
+	// APIKeys are bcrypt hashes of keys accepted alongside those provisioned
+	// through the admin API.
+	APIKeys []string `env:"API_KEYS"`
+	// AdminAPIKey protects the admin API; empty disables it.
+	AdminAPIKey string `env:"ADMIN_API_KEY"`
+
+	// JWTAlgorithm signs tokens with "HS256" using JWTSecret or "RS256"
+	// using the PEM-encoded RSA key in JWTPrivateKeyFile.
+	JWTAlgorithm      string `env:"JWT_ALGORITHM"`
+	JWTSecret         string `env:"JWT_SECRET"`
+	JWTPrivateKeyFile string `env:"JWT_PRIVATE_KEY_FILE"`
+	// JWTTTL is how long issued tokens stay valid.
+	JWTTTL time.Duration `env:"JWT_TTL"`
+
+	// MultiTenantEnabled scopes every request to a tenant named by a
+	// subdomain of TenantBaseDomain or the X-Tenant-ID header.
+	MultiTenantEnabled bool   `env:"MULTI_TENANT_ENABLED"`
+	TenantBaseDomain   string `env:"TENANT_BASE_DOMAIN"`
+
+	// RateLimits holds per-channel token-bucket limits keyed by channel name.
+	RateLimits map[string]RateLimitConfig `env:"RATE_LIMITS"`
+
+	// HTTPRateLimit limits HTTP API requests per client IP.
+	HTTPRateLimit HTTPRateLimitConfig `env:"HTTP_RATE_LIMIT"`
+	// HTTPRateLimitBackend selects where request counts are kept: "memory",
+	// per instance, or "redis", shared through RedisURL.
+	HTTPRateLimitBackend string `env:"HTTP_RATE_LIMIT_BACKEND"`
+
+	// CircuitBreakers holds per-channel circuit breakers keyed by channel name.
+	CircuitBreakers map[string]CircuitBreakerConfig `env:"CIRCUIT_BREAKERS"`
+
+	// ChannelLimits overrides the default per-channel length limits, keyed by
+	// channel name.
+	ChannelLimits map[string]ChannelLimitConfig `env:"CHANNEL_LIMITS"`
+}
+
+// NewConfig reads the configuration from environment variables and, when
+// NOTIFICATION_CONFIG_FILE (or the older CONFIG_FILE) names one, a JSON or
+// YAML file, as LoadFile does.
+func NewConfig() (*Config, error) {
+	cfg := newConfig(os.LookupEnv)
+	if cfg.ConfigFile == "" {
+		return cfg, nil
+	}
+	return LoadFile(cfg.ConfigFile)
 }
 
-func NewConfig() *Config {
+// LoadFile reads the configuration from environment variables, then from the
+// JSON or YAML file at path for settings the environment leaves unset, then
+// from the built-in defaults. The file maps environment variable names, in
+// any case, to values:
+//
+//	log_level: debug
+//	rate_limits: slack=1:5,email=10:20
+//	api_keys: ["$2a$10$...", "$2a$10$..."]
+func LoadFile(path string) (*Config, error) {
+	values, err := readSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := newConfig(func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			return value, true
+		}
+		value, ok := values[key]
+		return value, ok
+	})
+	cfg.ConfigFile = path
+	return cfg, nil
+}
+
+// settingNames returns the environment variable names of every setting, as
+// declared by the env tags of Config.
+func settingNames() map[string]bool {
+	names := map[string]bool{"CONFIG_FILE": true}
+	fields := reflect.TypeFor[Config]()
+	for i := 0; i < fields.NumField(); i++ {
+		if name := fields.Field(i).Tag.Get("env"); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+func newConfig(env settings) *Config {
 	return &Config{
-		ServerPort: ":8080",
+		ConfigFile:          env.get("NOTIFICATION_CONFIG_FILE", env.value("CONFIG_FILE")),
+		ServerPort:          env.get("SERVER_PORT", ":8080"),
+		TLSEnabled:          env.getBool("TLS_ENABLED", false),
+		TLSPort:             env.get("TLS_PORT", ":443"),
+		HTTPRedirectPort:    env.get("HTTP_REDIRECT_PORT", ":80"),
+		TLSDomain:           env.value("TLS_DOMAIN"),
+		TLSEmail:            env.value("TLS_EMAIL"),
+		TLSCacheDir:         env.get("TLS_CACHE_DIR", "certs"),
+		GRPCPort:            env.get("GRPC_PORT", ":9090"),
+		GRPCGatewayEnabled:  env.getBool("GRPC_GATEWAY_ENABLED", false),
+		HTTPTimeout:         env.getDuration("HTTP_TIMEOUT", 10*time.Second),
+		NotificationTimeout: env.getDuration("NOTIFICATION_TIMEOUT", 30*time.Second),
+		ShutdownTimeout:     env.getDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		ReadTimeout:         env.getDuration("READ_TIMEOUT", 5*time.Second),
+		WriteTimeout:        env.getDuration("WRITE_TIMEOUT", 10*time.Second),
+		IdleTimeout:         env.getDuration("IDLE_TIMEOUT", 60*time.Second),
+		ReadHeaderTimeout:   env.getDuration("READ_HEADER_TIMEOUT", 2*time.Second),
+		MaxHeaderBytes:      env.getInt("MAX_HEADER_BYTES", 1<<20),
+		MaxRequestBodyBytes: int64(env.getInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+		CORSOrigins:         parseList(env.value("CORS_ORIGINS")),
+		StreamTimeout:       env.getDuration("STREAM_TIMEOUT", 5*time.Minute),
+
+		SlackWebhookURL:    env.value("SLACK_WEBHOOK_URL"),
+		SlackSigningSecret: env.value("SLACK_SIGNING_SECRET"),
+		SlackBotToken:      env.value("SLACK_BOT_TOKEN"),
+		SlackAPIURL:        env.get("SLACK_API_URL", "https://slack.com/api"),
+		SlackUserCacheTTL:  env.getDuration("SLACK_USER_CACHE_TTL", 10*time.Minute),
+		SMTPHost:           env.value("SMTP_HOST"),
+		SMTPPort:           env.getInt("SMTP_PORT", 587),
+		SMTPUsername:       env.value("SMTP_USERNAME"),
+		SMTPPassword:       env.value("SMTP_PASSWORD"),
+		SMTPFrom:           env.value("SMTP_FROM"),
+		SMTPTLSMode:        env.get("SMTP_TLS_MODE", "starttls"),
+		EmailProvider:      env.value("EMAIL_PROVIDER"),
+		SendGridAPIURL:     env.get("SENDGRID_API_URL", "https://api.sendgrid.com/v3/mail/send"),
+		SendGridAPIKey:     env.value("SENDGRID_API_KEY"),
+		MailgunAPIKey:      env.value("MAILGUN_API_KEY"),
+		MailgunDomain:      env.value("MAILGUN_DOMAIN"),
+		MailgunRegion:      env.get("MAILGUN_REGION", "us"),
+		TrackingBaseURL:    env.value("TRACKING_BASE_URL"),
+
+		DeliveryWebhookSecret: env.value("DELIVERY_WEBHOOK_SECRET"),
+		TwilioAuthToken:       env.value("TWILIO_AUTH_TOKEN"),
+
+		ClickTrackingAllowedHosts: parseList(env.value("CLICK_TRACKING_ALLOWED_HOSTS")),
+		UnsubscribeSecret:         env.value("UNSUBSCRIBE_SECRET"),
+		EmailAllowedTags:          parseList(env.value("EMAIL_ALLOWED_TAGS")),
+		EmailOutboxEnabled:        env.getBool("EMAIL_OUTBOX_ENABLED", false),
+		EmailOutboxPollInterval:   env.getDuration("EMAIL_OUTBOX_POLL_INTERVAL", 5*time.Second),
+		EmailOutboxMaxRetries:     env.getInt("EMAIL_OUTBOX_MAX_RETRIES", 10),
+
+		WhatsAppAPIURL:        env.get("WHATSAPP_API_URL", "https://graph.facebook.com/v19.0"),
+		WhatsAppPhoneNumberID: env.value("WHATSAPP_PHONE_NUMBER_ID"),
+		WhatsAppAccessToken:   env.value("WHATSAPP_ACCESS_TOKEN"),
+
+		TeamsWebhookURL: env.value("TEAMS_WEBHOOK_URL"),
+
+		DiscordAPIURL:   env.get("DISCORD_API_URL", "https://discord.com/api/v10"),
+		DiscordBotToken: env.value("DISCORD_BOT_TOKEN"),
+
+		PagerDutyEventsURL:  env.get("PAGERDUTY_EVENTS_URL", "https://events.pagerduty.com/v2/enqueue"),
+		PagerDutyRoutingKey: env.value("PAGERDUTY_ROUTING_KEY"),
+
+		FCMAPIURL:          env.get("FCM_API_URL", "https://fcm.googleapis.com/v1"),
+		FCMProjectID:       env.value("FCM_PROJECT_ID"),
+		FCMCredentialsFile: env.value("FCM_CREDENTIALS_FILE"),
+		APNsAPIURL:         env.get("APNS_API_URL", "https://api.push.apple.com"),
+		APNsKeyFile:        env.value("APNS_KEY_FILE"),
+		APNsKeyID:          env.value("APNS_KEY_ID"),
+		APNsTeamID:         env.value("APNS_TEAM_ID"),
+		APNsTopic:          env.value("APNS_TOPIC"),
+
+		TelegramAPIURL:   env.get("TELEGRAM_API_URL", "https://api.telegram.org"),
+		TelegramBotToken: env.value("TELEGRAM_BOT_TOKEN"),
+
+		SMSAlphanumericSenderID: env.getBool("SMS_ALPHANUMERIC_SENDER_ID", false),
+		SMSProvider:             env.value("SMS_PROVIDER"),
+
+		VonageAPIURL:    env.get("VONAGE_API_URL", "https://api.nexmo.com/v1/messages"),
+		VonageAPIKey:    env.value("VONAGE_API_KEY"),
+		VonageAPISecret: env.value("VONAGE_API_SECRET"),
+		VonageFrom:      env.value("VONAGE_FROM"),
+
+		AWSRegion:          env.value("AWS_REGION"),
+		AWSAccessKeyID:     env.value("AWS_ACCESS_KEY_ID"),
+		AWSSecretAccessKey: env.value("AWS_SECRET_ACCESS_KEY"),
+
+		WebhookSecret:  env.value("WEBHOOK_SECRET"),
+		CallbackSecret: env.value("CALLBACK_SECRET"),
+
+		StorageBackend: env.get("STORAGE_BACKEND", "sqlite"),
+		DatabasePath:   env.get("DATABASE_PATH", "notifications.db"),
+		DatabaseDSN:    env.value("DATABASE_DSN"),
+
+		SchedulerBackend: env.get("SCHEDULER_BACKEND", "memory"),
+		RedisURL:         env.get("REDIS_URL", "redis://localhost:6379/0"),
+
+		SchedulerBatchWindow:    env.getDuration("SCHEDULER_BATCH_WINDOW", 0),
+		SchedulerBatchSeparator: env.get("SCHEDULER_BATCH_SEPARATOR", "\n\n"),
+
+		DeadLetterFile: env.value("DEAD_LETTER_FILE"),
+
+		AuditBackend: env.get("AUDIT_BACKEND", "database"),
+		AuditLogFile: env.get("AUDIT_LOG_FILE", "audit.log"),
+
+		TemplateFile:     env.value("TEMPLATE_FILE"),
+		TemplateCacheTTL: env.getDuration("TEMPLATE_CACHE_TTL", time.Minute),
+		LocaleDir:        env.value("LOCALE_DIR"),
+
+		PluginDir: env.value("PLUGIN_DIR"),
+
+		MaxAttachmentBytes: env.getInt("MAX_ATTACHMENT_BYTES", 10<<20),
+
+		BulkMaxNotifications: env.getInt("BULK_MAX_NOTIFICATIONS", 1000),
+		BulkWorkers:          env.getInt("BULK_WORKERS", 10),
+		MaxImportRows:        env.getInt("MAX_IMPORT_ROWS", 10000),
+
+		DigestWindow:  env.getDuration("DIGEST_WINDOW", time.Hour),
+		DigestMaxSize: env.getInt("DIGEST_MAX_SIZE", 50),
+
+		IdempotencyTTL: env.getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		MetricsEnabled: env.getBool("METRICS_ENABLED", false),
+
+		OTLPEndpoint:       env.value("OTLP_ENDPOINT"),
+		TracingServiceName: env.get("TRACING_SERVICE_NAME", "notification-service"),
+
+		LogLevel: env.get("LOG_LEVEL", "info"),
+
+		AuthMode:    env.value("AUTH_MODE"),
+		APIKeys:     parseList(env.value("API_KEYS")),
+		AdminAPIKey: env.value("ADMIN_API_KEY"),
+
+		JWTAlgorithm:      env.get("JWT_ALGORITHM", "HS256"),
+		JWTSecret:         env.value("JWT_SECRET"),
+		JWTPrivateKeyFile: env.value("JWT_PRIVATE_KEY_FILE"),
+		JWTTTL:            env.getDuration("JWT_TTL", 15*time.Minute),
+
+		MultiTenantEnabled: env.getBool("MULTI_TENANT_ENABLED", false),
+		TenantBaseDomain:   env.value("TENANT_BASE_DOMAIN"),
+
+		RateLimits: parseRateLimits(env.value("RATE_LIMITS")),
+
+		HTTPRateLimit:        parseHTTPRateLimit(env.value("HTTP_RATE_LIMIT")),
+		HTTPRateLimitBackend: env.get("HTTP_RATE_LIMIT_BACKEND", "memory"),
+
+		CircuitBreakers: parseCircuitBreakers(env.value("CIRCUIT_BREAKERS")),
+
+		ChannelLimits: parseChannelLimits(env.value("CHANNEL_LIMITS")),
+	}
+}
+
+// settings looks up a setting by its environment variable name.
+type settings func(key string) (string, bool)
+
+func (s settings) value(key string) string {
+	value, _ := s(key)
+	return value
+}
+
+func (s settings) get(key, fallback string) string {
+	if value, ok := s(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (s settings) getDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(s.value(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func (s settings) getBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(s.value(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func (s settings) getInt(key string, fallback int) int {
+	value, err := strconv.Atoi(s.value(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// parseList splits a comma-separated value, dropping empty entries.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRateLimits reads limits in the form "slack=1:5,email=10:20", where
+// each value is requests-per-second:burst. Malformed entries are skipped.
+func parseRateLimits(value string) map[string]RateLimitConfig {
+	limits := make(map[string]RateLimitConfig)
+	for _, entry := range strings.Split(value, ",") {
+		channel, limit, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		rateStr, burstStr, _ := strings.Cut(limit, ":")
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || rate <= 0 {
+			continue
+		}
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil || burst < 1 {
+			burst = 1
+		}
+		limits[channel] = RateLimitConfig{RequestsPerSecond: rate, Burst: burst}
+	}
+	return limits
+}
+
+// parseHTTPRateLimit reads a limit in the form "60:10:600", which is
+// requests-per-minute:burst:authenticated-requests-per-minute. The burst
+// defaults to 0 and the authenticated rate to ten times the rate. A
+// malformed value disables the limit.
+func parseHTTPRateLimit(value string) HTTPRateLimitConfig {
+	parts := strings.Split(value, ":")
+	rate, err := strconv.Atoi(parts[0])
+	if err != nil || rate < 1 {
+		return HTTPRateLimitConfig{}
+	}
+	limit := HTTPRateLimitConfig{RequestsPerMinute: rate, AuthenticatedRequestsPerMinute: 10 * rate}
+	if len(parts) > 1 {
+		if limit.BurstSize, err = strconv.Atoi(parts[1]); err != nil || limit.BurstSize < 0 {
+			return HTTPRateLimitConfig{}
+		}
+	}
+	if len(parts) > 2 {
+		if limit.AuthenticatedRequestsPerMinute, err = strconv.Atoi(parts[2]); err != nil || limit.AuthenticatedRequestsPerMinute < 1 {
+			return HTTPRateLimitConfig{}
+		}
+	}
+	return limit
+}
+
+// parseCircuitBreakers reads breakers in the form "slack=5:30s:1,email=3:1m",
+// where each value is failure-threshold:timeout:half-open-probes. The probe
+// count defaults to 1. Malformed entries are skipped.
+func parseCircuitBreakers(value string) map[string]CircuitBreakerConfig {
+	breakers := make(map[string]CircuitBreakerConfig)
+	for _, entry := range strings.Split(value, ",") {
+		channel, breaker, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(breaker, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		threshold, err := strconv.Atoi(parts[0])
+		if err != nil || threshold < 1 {
+			continue
+		}
+		timeout, err := time.ParseDuration(parts[1])
+		if err != nil || timeout <= 0 {
+			continue
+		}
+		probes := 1
+		if len(parts) > 2 {
+			if n, err := strconv.Atoi(parts[2]); err == nil && n > 0 {
+				probes = n
+			}
+		}
+		breakers[channel] = CircuitBreakerConfig{FailureThreshold: threshold, Timeout: timeout, HalfOpenProbes: probes}
+	}
+	return breakers
+}
+
+// parseChannelLimits reads limits in the form
+// "message=160,slack=4000:150,discord=2000:0:truncate", where each value is
+// max-content-length:max-title-length:truncate-policy. The title limit and
+// policy are optional, and an empty or 0 limit means unrestricted. Malformed
+// entries are skipped.
+func parseChannelLimits(value string) map[string]ChannelLimitConfig {
+	limits := make(map[string]ChannelLimitConfig)
+	for _, entry := range strings.Split(value, ",") {
+		channel, limit, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		contentStr, titleStr, hasTitle := strings.Cut(limit, ":")
+		titleStr, policyStr, _ := strings.Cut(titleStr, ":")
+		content, err := strconv.Atoi(contentStr)
+		if err != nil || content < 0 {
+			continue
+		}
+		title := 0
+		if hasTitle && titleStr != "" {
+			if title, err = strconv.Atoi(titleStr); err != nil || title < 0 {
+				continue
+			}
+		}
+		policy := TruncationPolicy(policyStr)
+		switch policy {
+		case "", TruncationReject, TruncationTruncate, TruncationSplit:
+		default:
+			continue
+		}
+		limits[channel] = ChannelLimitConfig{MaxTitleLength: title, MaxContentLength: content, TruncatePolicy: policy}
 	}
+	return limits
 }
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		file        string
+		expectError bool
+		check       func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "Defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ServerPort != ":8080" || cfg.SMTPPort != 587 || cfg.LogLevel != "info" || cfg.HTTPTimeout != 10*time.Second || cfg.ConfigFile != "" {
+					t.Errorf("Expected defaults, got %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "Environment only",
+			env:  map[string]string{"SERVER_PORT": ":9000", "SMTP_PORT": "2525", "METRICS_ENABLED": "true", "API_KEYS": "a, b", "HTTP_RATE_LIMIT": "60:10"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ServerPort != ":9000" || cfg.SMTPPort != 2525 || !cfg.MetricsEnabled || len(cfg.APIKeys) != 2 {
+					t.Errorf("Expected environment settings, got %+v", cfg)
+				}
+				if cfg.HTTPRateLimit != (HTTPRateLimitConfig{RequestsPerMinute: 60, BurstSize: 10, AuthenticatedRequestsPerMinute: 600}) {
+					t.Errorf("Expected HTTP rate limit from environment, got %+v", cfg.HTTPRateLimit)
+				}
+			},
+		},
+		{
+			name: "Channel limits",
+			env:  map[string]string{"CHANNEL_LIMITS": "slack=3000:150,discord=2000:0:truncate,teams=500::split,email=10:0:drop"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ChannelLimits["slack"] != (ChannelLimitConfig{MaxContentLength: 3000, MaxTitleLength: 150}) {
+					t.Errorf("Expected slack limits without a policy, got %+v", cfg.ChannelLimits["slack"])
+				}
+				if cfg.ChannelLimits["discord"].TruncatePolicy != TruncationTruncate || cfg.ChannelLimits["teams"].TruncatePolicy != TruncationSplit {
+					t.Errorf("Expected truncate and split policies, got %+v", cfg.ChannelLimits)
+				}
+				if _, ok := cfg.ChannelLimits["email"]; ok {
+					t.Error("Expected an unknown policy to be skipped")
+				}
+			},
+		},
+		{
+			name: "YAML file",
+			file: "server_port: \":7000\"\nlog_level: debug\ndigest_window: 5m\napi_keys:\n  - a\n  - b\n  - c\nrate_limits: slack=2:4\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ServerPort != ":7000" || cfg.LogLevel != "debug" || cfg.DigestWindow != 5*time.Minute || len(cfg.APIKeys) != 3 {
+					t.Errorf("Expected file settings, got %+v", cfg)
+				}
+				if cfg.RateLimits["slack"] != (RateLimitConfig{RequestsPerSecond: 2, Burst: 4}) {
+					t.Errorf("Expected slack rate limit from file, got %+v", cfg.RateLimits)
+				}
+				if cfg.SMTPPort != 587 {
+					t.Errorf("Expected default SMTP port, got %d", cfg.SMTPPort)
+				}
+			},
+		},
+		{
+			name: "Environment overrides file",
+			env:  map[string]string{"LOG_LEVEL": "error", "SMTP_HOST": ""},
+			file: "log_level: debug\nsmtp_host: smtp.example.com\nbulk_workers: 4\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.LogLevel != "error" {
+					t.Errorf("Expected log level from environment, got %q", cfg.LogLevel)
+				}
+				// An empty variable does not hide the file's setting
+				if cfg.SMTPHost != "smtp.example.com" || cfg.BulkWorkers != 4 {
+					t.Errorf("Expected remaining settings from file, got %+v", cfg)
+				}
+			},
+		},
+		{
+			name:        "Unknown setting in file",
+			file:        "log_level: debug\nsmpt_host: smtp.example.com\n",
+			expectError: true,
+		},
+		{
+			name:        "Malformed file",
+			file:        "log_level: [debug\n",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			var path string
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv("NOTIFICATION_CONFIG_FILE", path)
+			}
+
+			cfg, err := NewConfig()
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.ConfigFile != path {
+				t.Errorf("Expected config file %q, got %q", path, cfg.ConfigFile)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// readSettings reads a JSON or YAML file of settings keyed by environment
+// variable name. Lists are joined with commas, the form the environment
+// variables take.
+func readSettings(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		// Keeps large integers such as MAX_ATTACHMENT_BYTES out of
+		// exponent notation
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s must be .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config file: %w", err)
+	}
+
+	known := settingNames()
+	values := make(map[string]string, len(raw))
+	var unknown []string
+	for key, value := range raw {
+		name := strings.ToUpper(key)
+		if !known[name] {
+			unknown = append(unknown, key)
+			continue
+		}
+		setting, err := settingValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid setting %s: %w", key, err)
+		}
+		values[name] = setting
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown settings in config file: %s", strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// settingValue formats a decoded scalar or list of scalars as a string.
+func settingValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, float64, json.Number:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			if _, nested := item.([]interface{}); nested {
+				return "", fmt.Errorf("lists cannot be nested")
+			}
+			s, err := settingValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("must be a string, number, boolean or list, got %T", value)
+}
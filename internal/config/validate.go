@@ -0,0 +1,278 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks the configuration for settings the service cannot start
+// with. It reports every problem at once, joined with errors.Join, each
+// naming the environment variable to fix.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.address("SERVER_PORT", c.ServerPort, true)
+	if c.TLSEnabled {
+		v.address("TLS_PORT", c.TLSPort, true)
+		v.address("HTTP_REDIRECT_PORT", c.HTTPRedirectPort, true)
+	}
+	v.address("GRPC_PORT", c.GRPCPort, false)
+	if c.GRPCGatewayEnabled && c.GRPCPort == "" {
+		v.add("GRPC_GATEWAY_ENABLED requires GRPC_PORT")
+	}
+	v.positive("NOTIFICATION_TIMEOUT", int64(c.NotificationTimeout))
+	v.positive("SHUTDOWN_TIMEOUT", int64(c.ShutdownTimeout))
+	v.positive("STREAM_TIMEOUT", int64(c.StreamTimeout))
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"READ_TIMEOUT", c.ReadTimeout},
+		{"WRITE_TIMEOUT", c.WriteTimeout},
+		{"IDLE_TIMEOUT", c.IdleTimeout},
+		{"READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+	} {
+		if timeout.value < 0 {
+			v.add("%s must not be negative", timeout.name)
+		}
+	}
+	if c.MaxHeaderBytes < 0 {
+		v.add("MAX_HEADER_BYTES must not be negative")
+	}
+	if c.MaxRequestBodyBytes < 0 {
+		v.add("MAX_REQUEST_BODY_BYTES must not be negative")
+	}
+
+	v.httpsURL("SLACK_WEBHOOK_URL", c.SlackWebhookURL)
+	v.httpsURL("TEAMS_WEBHOOK_URL", c.TeamsWebhookURL)
+	v.httpURL("SLACK_API_URL", c.SlackAPIURL)
+	v.httpURL("TRACKING_BASE_URL", c.TrackingBaseURL)
+	v.httpURL("WHATSAPP_API_URL", c.WhatsAppAPIURL)
+	v.httpURL("DISCORD_API_URL", c.DiscordAPIURL)
+	v.httpURL("PAGERDUTY_EVENTS_URL", c.PagerDutyEventsURL)
+	v.httpURL("OTLP_ENDPOINT", c.OTLPEndpoint)
+	v.httpURL("FCM_API_URL", c.FCMAPIURL)
+	v.httpURL("APNS_API_URL", c.APNsAPIURL)
+	v.httpURL("VONAGE_API_URL", c.VonageAPIURL)
+	v.httpURL("SENDGRID_API_URL", c.SendGridAPIURL)
+	v.httpURL("TELEGRAM_API_URL", c.TelegramAPIURL)
+
+	// Email is enabled by SMTP_HOST; without it emails are only logged
+	if c.SMTPHost != "" {
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			v.add("SMTP_PORT %d is not a valid port", c.SMTPPort)
+		}
+		if c.SMTPFrom == "" {
+			v.add("SMTP_FROM is required when SMTP_HOST is set")
+		}
+		if (c.SMTPUsername == "") != (c.SMTPPassword == "") {
+			v.add("SMTP_USERNAME and SMTP_PASSWORD must be set together")
+		}
+		v.oneOf("SMTP_TLS_MODE", c.SMTPTLSMode, "starttls", "tls", "none")
+	}
+	switch c.EmailProvider {
+	case "":
+	case "sendgrid":
+		if c.SendGridAPIKey == "" || c.SMTPFrom == "" {
+			v.add("SENDGRID_API_KEY and SMTP_FROM are required for the sendgrid email provider")
+		}
+	case "mailgun":
+		if c.MailgunAPIKey == "" || c.MailgunDomain == "" || c.SMTPFrom == "" {
+			v.add("MAILGUN_API_KEY, MAILGUN_DOMAIN and SMTP_FROM are required for the mailgun email provider")
+		}
+		v.oneOf("MAILGUN_REGION", c.MailgunRegion, "us", "eu")
+	default:
+		v.add("EMAIL_PROVIDER %q must be sendgrid, mailgun or empty", c.EmailProvider)
+	}
+	if c.UnsubscribeSecret != "" && c.TrackingBaseURL == "" {
+		v.add("TRACKING_BASE_URL is required when UNSUBSCRIBE_SECRET is set")
+	}
+	if c.EmailOutboxEnabled {
+		v.positive("EMAIL_OUTBOX_POLL_INTERVAL", int64(c.EmailOutboxPollInterval))
+		v.positive("EMAIL_OUTBOX_MAX_RETRIES", int64(c.EmailOutboxMaxRetries))
+	}
+	if c.SlackBotToken != "" {
+		v.positive("SLACK_USER_CACHE_TTL", int64(c.SlackUserCacheTTL))
+	}
+	if c.WhatsAppAccessToken != "" && c.WhatsAppPhoneNumberID == "" {
+		v.add("WHATSAPP_PHONE_NUMBER_ID is required when WHATSAPP_ACCESS_TOKEN is set")
+	}
+	if c.FCMProjectID != "" && c.FCMCredentialsFile == "" {
+		v.add("FCM_CREDENTIALS_FILE is required when FCM_PROJECT_ID is set")
+	}
+	switch c.SMSProvider {
+	case "":
+	case "vonage":
+		if c.VonageAPIKey == "" || c.VonageAPISecret == "" || c.VonageFrom == "" {
+			v.add("VONAGE_API_KEY, VONAGE_API_SECRET and VONAGE_FROM are required for the vonage SMS provider")
+		}
+	case "sns":
+		if c.AWSAccessKeyID == "" {
+			v.add("AWS_ACCESS_KEY_ID is required for the sns SMS provider")
+		}
+	default:
+		v.add("SMS_PROVIDER %q must be vonage, sns or empty", c.SMSProvider)
+	}
+	// SNS is enabled by AWS_ACCESS_KEY_ID; without it notifications are only
+	// logged
+	if c.AWSAccessKeyID != "" {
+		if c.AWSSecretAccessKey == "" {
+			v.add("AWS_SECRET_ACCESS_KEY is required when AWS_ACCESS_KEY_ID is set")
+		}
+		if c.AWSRegion == "" {
+			v.add("AWS_REGION is required when AWS_ACCESS_KEY_ID is set")
+		}
+	}
+
+	switch c.StorageBackend {
+	case "", "sqlite":
+		if c.DatabasePath == "" {
+			v.add("DATABASE_PATH is required for the sqlite storage backend")
+		}
+	case "postgres":
+		if c.DatabaseDSN == "" {
+			v.add("DATABASE_DSN is required for the postgres storage backend")
+		}
+	default:
+		v.add("STORAGE_BACKEND %q must be sqlite or postgres", c.StorageBackend)
+	}
+
+	switch c.SchedulerBackend {
+	case "", "memory":
+	case "redis":
+		v.redisURL(c.RedisURL)
+	default:
+		v.add("SCHEDULER_BACKEND %q must be memory or redis", c.SchedulerBackend)
+	}
+	if c.SchedulerBatchWindow < 0 {
+		v.add("SCHEDULER_BATCH_WINDOW must not be negative")
+	}
+
+	switch c.HTTPRateLimitBackend {
+	case "", "memory":
+	case "redis":
+		if c.SchedulerBackend != "redis" {
+			v.redisURL(c.RedisURL)
+		}
+	default:
+		v.add("HTTP_RATE_LIMIT_BACKEND %q must be memory or redis", c.HTTPRateLimitBackend)
+	}
+
+	switch c.AuditBackend {
+	case "", "database", "none":
+	case "file":
+		if c.AuditLogFile == "" {
+			v.add("AUDIT_LOG_FILE is required for the file audit backend")
+		}
+	default:
+		v.add("AUDIT_BACKEND %q must be database, file or none", c.AuditBackend)
+	}
+
+	if c.MaxAttachmentBytes < 0 {
+		v.add("MAX_ATTACHMENT_BYTES must not be negative")
+	}
+	if c.TemplateCacheTTL < 0 {
+		v.add("TEMPLATE_CACHE_TTL must not be negative")
+	}
+	v.positive("BULK_MAX_NOTIFICATIONS", int64(c.BulkMaxNotifications))
+	v.positive("BULK_WORKERS", int64(c.BulkWorkers))
+	v.positive("MAX_IMPORT_ROWS", int64(c.MaxImportRows))
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.LogLevel), "debug", "info", "warn", "warning", "error")
+
+	switch c.AuthMode {
+	case "", "api_key":
+	case "jwt":
+		switch c.JWTAlgorithm {
+		case "HS256":
+			if c.JWTSecret == "" {
+				v.add("JWT_SECRET is required for JWT_ALGORITHM HS256")
+			}
+		case "RS256":
+			if c.JWTPrivateKeyFile == "" {
+				v.add("JWT_PRIVATE_KEY_FILE is required for JWT_ALGORITHM RS256")
+			}
+		default:
+			v.add("JWT_ALGORITHM %q must be HS256 or RS256", c.JWTAlgorithm)
+		}
+		v.positive("JWT_TTL", int64(c.JWTTTL))
+	default:
+		v.add("AUTH_MODE %q must be api_key or jwt", c.AuthMode)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// validator collects the problems found by Validate.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+// address checks that value is a listen address such as ":8080" or
+// "127.0.0.1:8080". An empty value is only a problem if required.
+func (v *validator) address(name, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add("%s is required", name)
+		}
+		return
+	}
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		v.add("%s %q must be a :port address", name, value)
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		v.add("%s %q has an invalid port", name, value)
+	}
+}
+
+// httpsURL checks that a set value is an absolute HTTPS URL.
+func (v *validator) httpsURL(name, value string) {
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err != nil || u.Scheme != "https" || u.Host == "" {
+		v.add("%s %q must be an https:// URL", name, value)
+	}
+}
+
+// httpURL checks that a set value is an absolute HTTP or HTTPS URL.
+func (v *validator) httpURL(name, value string) {
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add("%s %q must be an http:// or https:// URL", name, value)
+	}
+}
+
+// redisURL checks that REDIS_URL locates a Redis server.
+func (v *validator) redisURL(value string) {
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		v.add("REDIS_URL %q is not a redis:// or rediss:// URL", value)
+	}
+}
+
+func (v *validator) positive(name string, value int64) {
+	if value <= 0 {
+		v.add("%s must be positive", name)
+	}
+}
+
+func (v *validator) oneOf(name, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add("%s %q must be one of %s", name, value, strings.Join(allowed, ", "))
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	newTestConfig := func(env map[string]string) *Config {
+		return newConfig(func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		})
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		modify   func(c *Config)
+		expected []string
+	}{
+		{"Defaults", nil, nil, nil},
+		{"Server port without colon", nil, func(c *Config) { c.ServerPort = "8080" }, []string{"SERVER_PORT"}},
+		{"Server port out of range", nil, func(c *Config) { c.ServerPort = ":70000" }, []string{"SERVER_PORT"}},
+		{"Plain HTTP Slack webhook", map[string]string{"SLACK_WEBHOOK_URL": "http://hooks.slack.com/services/x"}, nil, []string{"SLACK_WEBHOOK_URL"}},
+		{"Email without sender", map[string]string{"SMTP_HOST": "smtp.example.com", "SMTP_USERNAME": "mailer"}, nil, []string{"SMTP_FROM", "SMTP_USERNAME and SMTP_PASSWORD"}},
+		{"Email configured", map[string]string{"SMTP_HOST": "smtp.example.com", "SMTP_FROM": "noreply@example.com"}, nil, nil},
+		{"SendGrid without API key", map[string]string{"EMAIL_PROVIDER": "sendgrid", "SMTP_FROM": "noreply@example.com"}, nil, []string{"SENDGRID_API_KEY and SMTP_FROM"}},
+		{"SendGrid configured", map[string]string{"EMAIL_PROVIDER": "sendgrid", "SENDGRID_API_KEY": "SG.key", "SMTP_FROM": "noreply@example.com"}, nil, nil},
+		{"Mailgun in unknown region", map[string]string{"EMAIL_PROVIDER": "mailgun", "MAILGUN_API_KEY": "key", "MAILGUN_DOMAIN": "mg.example.com", "MAILGUN_REGION": "ap"}, nil, []string{"MAILGUN_API_KEY, MAILGUN_DOMAIN and SMTP_FROM", "MAILGUN_REGION"}},
+		{"Mailgun configured", map[string]string{"EMAIL_PROVIDER": "mailgun", "MAILGUN_API_KEY": "key", "MAILGUN_DOMAIN": "mg.example.com", "MAILGUN_REGION": "eu", "SMTP_FROM": "noreply@example.com"}, nil, nil},
+		{"Unknown email provider", map[string]string{"EMAIL_PROVIDER": "postmark"}, nil, []string{"EMAIL_PROVIDER"}},
+		{"Postgres without DSN", map[string]string{"STORAGE_BACKEND": "postgres"}, nil, []string{"DATABASE_DSN"}},
+		{"Unknown scheduler backend", map[string]string{"SCHEDULER_BACKEND": "kafka"}, nil, []string{"SCHEDULER_BACKEND"}},
+		{"Negative scheduler batch window", map[string]string{"SCHEDULER_BATCH_WINDOW": "-1m"}, nil, []string{"SCHEDULER_BATCH_WINDOW"}},
+		{"Negative server timeouts", map[string]string{"READ_TIMEOUT": "-1s", "IDLE_TIMEOUT": "-1s", "MAX_HEADER_BYTES": "-1"}, nil, []string{"READ_TIMEOUT", "IDLE_TIMEOUT", "MAX_HEADER_BYTES"}},
+		{"Negative request body limit", map[string]string{"MAX_REQUEST_BODY_BYTES": "-1"}, nil, []string{"MAX_REQUEST_BODY_BYTES"}},
+		{"Unknown HTTP rate limit backend", map[string]string{"HTTP_RATE_LIMIT_BACKEND": "memcached"}, nil, []string{"HTTP_RATE_LIMIT_BACKEND"}},
+		{"Email outbox without retries", map[string]string{"EMAIL_OUTBOX_ENABLED": "true", "EMAIL_OUTBOX_MAX_RETRIES": "0"}, nil, []string{"EMAIL_OUTBOX_MAX_RETRIES"}},
+		{"Unsubscribe links without base URL", map[string]string{"UNSUBSCRIBE_SECRET": "s3cret"}, nil, []string{"TRACKING_BASE_URL"}},
+		{"OTLP endpoint without scheme", map[string]string{"OTLP_ENDPOINT": "localhost:4318"}, nil, []string{"OTLP_ENDPOINT"}},
+		{"Vonage without credentials", map[string]string{"SMS_PROVIDER": "vonage", "VONAGE_API_KEY": "key"}, nil, []string{"VONAGE_API_KEY, VONAGE_API_SECRET and VONAGE_FROM"}},
+		{"Vonage configured", map[string]string{"SMS_PROVIDER": "vonage", "VONAGE_API_KEY": "key", "VONAGE_API_SECRET": "secret", "VONAGE_FROM": "+14155550100"}, nil, nil},
+		{"SNS without credentials", map[string]string{"SMS_PROVIDER": "sns"}, nil, []string{"AWS_ACCESS_KEY_ID"}},
+		{"SNS without region", map[string]string{"SMS_PROVIDER": "sns", "AWS_ACCESS_KEY_ID": "AKIAEXAMPLE"}, nil, []string{"AWS_SECRET_ACCESS_KEY", "AWS_REGION"}},
+		{"SNS configured", map[string]string{"SMS_PROVIDER": "sns", "AWS_ACCESS_KEY_ID": "AKIAEXAMPLE", "AWS_SECRET_ACCESS_KEY": "secret", "AWS_REGION": "eu-west-1"}, nil, nil},
+		{"Unknown SMS provider", map[string]string{"SMS_PROVIDER": "twilio"}, nil, []string{"SMS_PROVIDER"}},
+		{"JWT without secret", map[string]string{"AUTH_MODE": "jwt"}, nil, []string{"JWT_SECRET"}},
+		{"Several problems", map[string]string{"AUTH_MODE": "oauth", "LOG_LEVEL": "verbose", "AUDIT_BACKEND": "s3"}, func(c *Config) { c.ServerPort = "" },
+			[]string{"SERVER_PORT", "AUTH_MODE", "LOG_LEVEL", "AUDIT_BACKEND"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(tt.env)
+			if tt.modify != nil {
+				tt.modify(cfg)
+			}
+			err := cfg.Validate()
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.expected) {
+				t.Errorf("Expected %d problems, got %q", len(tt.expected), lines)
+			}
+			for _, name := range tt.expected {
+				if !strings.Contains(err.Error(), name) {
+					t.Errorf("Expected a problem with %s, got %q", name, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay coalesces the burst of events an editor produces while saving
+// into a single reload.
+const reloadDelay = 100 * time.Millisecond
+
+// ConfigWatcher reloads a configuration file whenever it changes and passes
+// the new configuration to the OnReload callbacks. A file that fails to load
+// is reported to the OnError callbacks and the previous configuration stays
+// in effect.
+type ConfigWatcher struct {
+	path    string
+	watcher *fsnotify.Watcher
+
+	onReload []func(cfg *Config)
+	onError  []func(err error)
+	timer    *time.Timer
+	mu       sync.RWMutex
+}
+
+// NewConfigWatcher watches the file at path. Call Start to begin delivering
+// reloads and Close to stop.
+func NewConfigWatcher(path string) (*ConfigWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+	// Watching the directory also catches editors and deployment tools
+	// that replace the file instead of writing to it
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+	return &ConfigWatcher{path: path, watcher: watcher}, nil
+}
+
+// OnReload registers callback to receive each configuration loaded after a
+// change.
+func (w *ConfigWatcher) OnReload(callback func(cfg *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onReload = append(w.onReload, callback)
+}
+
+// OnError registers callback to receive errors loading the changed file.
+func (w *ConfigWatcher) OnError(callback func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, callback)
+}
+
+// Start delivers reloads in the background until Close is called.
+func (w *ConfigWatcher) Start() {
+	go w.run()
+}
+
+// Close stops watching the file.
+func (w *ConfigWatcher) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	return w.watcher.Close()
+}
+
+func (w *ConfigWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.path || event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			w.mu.Lock()
+			if w.timer == nil {
+				w.timer = time.AfterFunc(reloadDelay, w.reload)
+			} else {
+				w.timer.Reset(reloadDelay)
+			}
+			w.mu.Unlock()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.report(err)
+		}
+	}
+}
+
+// reload loads the file and passes the result to the callbacks, unless it
+// fails validation.
+func (w *ConfigWatcher) reload() {
+	cfg, err := LoadFile(w.path)
+	if err != nil {
+		w.report(err)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		w.report(fmt.Errorf("invalid configuration: %w", err))
+		return
+	}
+
+	w.mu.RLock()
+	callbacks := w.onReload
+	w.mu.RUnlock()
+	for _, callback := range callbacks {
+		callback(cfg)
+	}
+}
+
+func (w *ConfigWatcher) report(err error) {
+	w.mu.RLock()
+	callbacks := w.onError
+	w.mu.RUnlock()
+	for _, callback := range callbacks {
+		callback(err)
+	}
+}
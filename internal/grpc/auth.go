@@ -0,0 +1,135 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/services"
+	"notification-service/proto/notificationpb"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys carrying credentials, as the X-API-Key and Authorization
+// headers do over HTTP.
+const (
+	APIKeyMetadata        = "x-api-key"
+	AuthorizationMetadata = "authorization"
+)
+
+// methodRoles is the role each RPC requires. Methods missing from it, such
+// as server reflection, need no credentials.
+var methodRoles = map[string]string{
+	notificationpb.NotificationService_SendNotification_FullMethodName:     models.RoleSender,
+	notificationpb.NotificationService_ScheduleNotification_FullMethodName: models.RoleSender,
+}
+
+// Authenticator checks the credentials in a call's metadata the way the HTTP
+// auth middleware checks request headers.
+type Authenticator struct {
+	mode   string
+	keys   *services.APIKeyService
+	tokens *services.TokenService
+}
+
+// NewAuthenticator returns an Authenticator for AUTH_MODE mode: "api_key"
+// requires a key known to keys, "jwt" a bearer token issued by tokens that
+// grants the method's role, and any other mode lets every call through.
+func NewAuthenticator(mode string, keys *services.APIKeyService, tokens *services.TokenService) *Authenticator {
+	return &Authenticator{mode: mode, keys: keys, tokens: tokens}
+}
+
+// ServerOptions returns the interceptors authenticating unary and streaming
+// calls, to pass to NewServer.
+func (a *Authenticator) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.unary),
+		grpc.ChainStreamInterceptor(a.stream),
+	}
+}
+
+type credentialTenantContextKey struct{}
+
+// credentialTenant returns the tenant the credentials of a call's context are
+// bound to, or "" if they may act for any tenant.
+func credentialTenant(ctx context.Context) string {
+	tenantID, _ := ctx.Value(credentialTenantContextKey{}).(string)
+	return tenantID
+}
+
+func (a *Authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *Authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream is a stream whose context records its credentials'
+// tenant.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context { return s.ctx }
+
+// authenticate returns ctx recording the tenant the call's credentials are
+// bound to. It returns Unauthenticated for calls without valid credentials
+// and PermissionDenied for tokens lacking the method's role.
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	role, ok := methodRoles[method]
+	if !ok {
+		return ctx, nil
+	}
+	var tenantID string
+	md, _ := metadata.FromIncomingContext(ctx)
+	switch a.mode {
+	case "api_key":
+		key := firstValue(md, APIKeyMetadata)
+		if key == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing "+APIKeyMetadata+" metadata")
+		}
+		var err error
+		tenantID, err = a.keys.Authenticate(ctx, key)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to check API key: %v", err)
+		}
+	case "jwt":
+		scheme, token, _ := strings.Cut(firstValue(md, AuthorizationMetadata), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+		claims, err := a.tokens.Verify(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		if !claims.HasRole(role) {
+			return nil, status.Error(codes.PermissionDenied, "token does not grant the "+role+" role")
+		}
+		tenantID = claims.TenantID
+	}
+	return context.WithValue(ctx, credentialTenantContextKey{}, tenantID), nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
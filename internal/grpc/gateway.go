@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"notification-service/proto/notificationpb"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// NewGateway returns an HTTP handler serving the routes declared in
+// proto/notification.proto by proxying them to the gRPC server at addr. An
+// addr without a host, e.g. ":9090", means this machine. The X-API-Key and
+// Authorization headers are passed on as call metadata.
+func NewGateway(ctx context.Context, addr string) (http.Handler, error) {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+		if strings.EqualFold(key, "X-API-Key") {
+			return APIKeyMetadata, true
+		}
+		return runtime.DefaultHeaderMatcher(key)
+	}))
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if err := notificationpb.RegisterNotificationServiceHandlerFromEndpoint(ctx, mux, addr, opts); err != nil {
+		return nil, fmt.Errorf("failed to register gRPC gateway: %w", err)
+	}
+	return mux, nil
+}
@@ -0,0 +1,330 @@
+// Package grpc serves the notification API over gRPC, and over HTTP through
+// a grpc-gateway reverse proxy.
+package grpc
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=module=notification-service --go-grpc_out=../.. --go-grpc_opt=module=notification-service --grpc-gateway_out=../.. --grpc-gateway_opt=module=notification-service proto/notification.proto
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"notification-service/proto/notificationpb"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCNotificationServer implements notificationpb.NotificationServiceServer
+// on the same services as the HTTP handlers.
+type GRPCNotificationServer struct {
+	notificationpb.UnimplementedNotificationServiceServer
+
+	notificationFactory *services.NotificationServiceFactory
+	fanOutService       *services.FanOutNotificationService
+	schedulerService    services.Scheduler
+	templateService     *services.TemplateService
+	tenantService       *services.TenantService
+	validator           *services.ValidationService
+	repository          repository.NotificationRepository
+	config              *config.Config
+	logger              logging.Logger
+}
+
+func NewGRPCNotificationServer(factory *services.NotificationServiceFactory, scheduler services.Scheduler, repo repository.NotificationRepository, cfg *config.Config) *GRPCNotificationServer {
+	return &GRPCNotificationServer{
+		notificationFactory: factory,
+		fanOutService:       services.NewFanOutNotificationService(factory),
+		schedulerService:    scheduler,
+		validator:           services.NewValidationService(cfg),
+		repository:          repo,
+		config:              cfg,
+		logger:              logging.Default(),
+	}
+}
+
+// WithTemplateService enables template_name in requests.
+func (s *GRPCNotificationServer) WithTemplateService(templates *services.TemplateService) {
+	s.templateService = templates
+}
+
+// WithTenantService requires every request to name a known tenant_id.
+func (s *GRPCNotificationServer) WithTenantService(tenants *services.TenantService) {
+	s.tenantService = tenants
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (s *GRPCNotificationServer) WithLogger(logger logging.Logger) {
+	s.logger = logger
+}
+
+// NewServer returns a gRPC server with s and server reflection registered
+// on it.
+func NewServer(s *GRPCNotificationServer, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	notificationpb.RegisterNotificationServiceServer(server, s)
+	reflection.Register(server)
+	return server
+}
+
+func (s *GRPCNotificationServer) SendNotification(ctx context.Context, req *notificationpb.SendNotificationRequest) (*notificationpb.NotificationResponse, error) {
+	notification, err := s.newNotification(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.Save(ctx, notification); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store notification: %v", err)
+	}
+
+	sendCtx, cancel := s.sendContext(ctx)
+	defer cancel()
+	err = s.fanOutService.Send(sendCtx, notification)
+	if services.DeliverySuppressed(err) {
+		notification.Status = models.StatusSuppressed
+		s.logger.Info("Suppressed notification to unsubscribed recipients", logging.NotificationAttrs(notification)...)
+		s.updateStatus(ctx, notification, repository.StatusUpdate{Status: models.StatusSuppressed})
+		return &notificationpb.NotificationResponse{
+			Success:      true,
+			Message:      "Notification suppressed: every recipient has unsubscribed",
+			Notification: toProto(notification),
+		}, nil
+	}
+	if services.DeliveryFailed(err) {
+		s.logger.Error("Error sending notification", logging.NotificationAttrs(notification, "error", err)...)
+		s.updateStatus(ctx, notification, repository.StatusUpdate{Status: models.StatusFailed, FailureReason: err.Error(), RecipientStatuses: notification.RecipientStatuses})
+		return nil, status.Errorf(sendErrorCode(err), "failed to send notification: %v", err)
+	}
+
+	sentAt := time.Now()
+	notification.SentAt = &sentAt
+	notification.Status = models.StatusSent
+	s.logger.Info("Sent notification", logging.NotificationAttrs(notification)...)
+	s.updateStatus(ctx, notification, repository.StatusUpdate{Status: models.StatusSent, SentAt: &sentAt, RecipientStatuses: notification.RecipientStatuses, Metadata: notification.Metadata})
+
+	return &notificationpb.NotificationResponse{
+		Success:      true,
+		Message:      "Notification sent successfully",
+		Notification: toProto(notification),
+	}, nil
+}
+
+func (s *GRPCNotificationServer) ScheduleNotification(ctx context.Context, req *notificationpb.ScheduleNotificationRequest) (*notificationpb.NotificationResponse, error) {
+	hasTime, hasCron := req.GetScheduledAt() != nil, req.GetCronExpression() != ""
+	switch {
+	case hasTime && hasCron:
+		return nil, status.Error(codes.InvalidArgument, "scheduled_at and cron_expression cannot be combined")
+	case !hasTime && !hasCron:
+		return nil, status.Error(codes.InvalidArgument, "scheduled_at or cron_expression is required")
+	}
+
+	notification, err := s.newNotification(ctx, req.GetNotification())
+	if err != nil {
+		return nil, err
+	}
+
+	if hasCron {
+		if err := services.ValidateCronExpression(req.GetCronExpression()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid cron_expression: %v", err)
+		}
+		if err := s.repository.Save(ctx, notification); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to store notification: %v", err)
+		}
+		if err := s.schedulerService.ScheduleRecurring(notification, req.GetCronExpression()); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to schedule recurring notification: %v", err)
+		}
+		return &notificationpb.NotificationResponse{
+			Success:      true,
+			Message:      "Recurring notification scheduled successfully",
+			Notification: toProto(notification),
+		}, nil
+	}
+
+	scheduledAt := req.GetScheduledAt().AsTime()
+	if !scheduledAt.After(time.Now()) {
+		return nil, status.Error(codes.InvalidArgument, "scheduled time must be in the future")
+	}
+	notification.ScheduledAt = &scheduledAt
+	if err := s.repository.Save(ctx, notification); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store notification: %v", err)
+	}
+	if err := s.schedulerService.ScheduleNotification(ctx, notification); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to schedule notification: %v", err)
+	}
+	return &notificationpb.NotificationResponse{
+		Success:      true,
+		Message:      "Notification scheduled successfully",
+		Notification: toProto(notification),
+	}, nil
+}
+
+// newNotification validates req and builds the pending notification it
+// describes, returning an InvalidArgument status error for bad requests.
+func (s *GRPCNotificationServer) newNotification(ctx context.Context, req *notificationpb.SendNotificationRequest) (*models.Notification, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "notification is required")
+	}
+	if err := s.checkTenant(ctx, req.GetTenantId()); err != nil {
+		return nil, err
+	}
+
+	title, content := req.GetTitle(), req.GetContent()
+	if req.GetTemplateName() != "" {
+		if s.templateService == nil {
+			return nil, status.Error(codes.FailedPrecondition, "templates are not enabled")
+		}
+		data := make(map[string]interface{}, len(req.GetTemplateData()))
+		for key, value := range req.GetTemplateData() {
+			data[key] = value
+		}
+		var err error
+		title, content, err = s.templateService.Render(ctx, req.GetTemplateName(), data)
+		if errors.Is(err, repository.ErrTemplateNotFound) {
+			return nil, status.Errorf(codes.NotFound, "unknown template: %s", req.GetTemplateName())
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to render template: %v", err)
+		}
+	}
+	if title == "" || content == "" {
+		return nil, status.Error(codes.InvalidArgument, "title and content are required")
+	}
+	if len(req.GetRecipients()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one recipient is required")
+	}
+	if req.GetChannel() != "" && len(req.GetChannels()) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "specify either channel or channels, not both")
+	}
+
+	channels := make([]models.NotificationChannel, len(req.GetChannels()))
+	for i, channel := range req.GetChannels() {
+		channels[i] = models.NotificationChannel(channel)
+	}
+	targets := channels
+	if len(targets) == 0 {
+		targets = []models.NotificationChannel{models.NotificationChannel(req.GetChannel())}
+	}
+	for _, channel := range targets {
+		if _, err := s.notificationFactory.GetService(channel); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid notification channel: %v", err)
+		}
+	}
+
+	priority := models.PriorityNormal
+	if p := req.GetPriority(); p != notificationpb.Priority_PRIORITY_UNSPECIFIED {
+		priority = models.NotificationPriority(p - 1)
+		if !priority.Valid() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid priority %v", p)
+		}
+	}
+
+	notification := &models.Notification{
+		ID:         uuid.New().String(),
+		TenantID:   req.GetTenantId(),
+		Title:      title,
+		Content:    content,
+		Channel:    models.NotificationChannel(req.GetChannel()),
+		Recipients: req.GetRecipients(),
+		Category:   req.GetCategory(),
+		CreatedAt:  time.Now(),
+		Status:     models.StatusPending,
+		Priority:   priority,
+		Metadata:   req.GetMetadata(),
+	}
+	if len(channels) > 0 {
+		notification.Channels = channels
+	}
+	if err := s.validator.ValidateNotification(notification); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return notification, nil
+}
+
+// checkTenant requires a known tenantID when tenants are enabled and none
+// otherwise. A tenantID other than the one the call's credentials are bound
+// to is PermissionDenied.
+func (s *GRPCNotificationServer) checkTenant(ctx context.Context, tenantID string) error {
+	if s.tenantService == nil {
+		if tenantID != "" {
+			return status.Error(codes.InvalidArgument, "tenants are not enabled")
+		}
+		return nil
+	}
+	if tenantID == "" {
+		return status.Error(codes.InvalidArgument, "tenant_id is required")
+	}
+	if bound := credentialTenant(ctx); bound != "" && bound != tenantID {
+		return status.Errorf(codes.PermissionDenied, "credentials do not belong to tenant %s", tenantID)
+	}
+	_, err := s.tenantService.Get(ctx, tenantID)
+	if errors.Is(err, repository.ErrTenantNotFound) {
+		return status.Errorf(codes.NotFound, "unknown tenant: %s", tenantID)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to load tenant: %v", err)
+	}
+	return nil
+}
+
+// sendContext derives the context used for outbound sends.
+func (s *GRPCNotificationServer) sendContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.config == nil || s.config.NotificationTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.config.NotificationTimeout)
+}
+
+// updateStatus records a delivery outcome. The send already happened, so a
+// storage failure is logged rather than reported to the client.
+func (s *GRPCNotificationServer) updateStatus(ctx context.Context, notification *models.Notification, update repository.StatusUpdate) {
+	if err := s.repository.UpdateStatus(ctx, notification.TenantID, notification.ID, update); err != nil {
+		s.logger.Error("Error updating notification status", logging.NotificationAttrs(notification, "status", update.Status, "error", err)...)
+	}
+}
+
+func sendErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, services.ErrRateLimitExceeded):
+		return codes.ResourceExhausted
+	case errors.Is(err, services.ErrCircuitOpen):
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+func toProto(notification *models.Notification) *notificationpb.Notification {
+	pb := &notificationpb.Notification{
+		Id:             notification.ID,
+		TenantId:       notification.TenantID,
+		Title:          notification.Title,
+		Content:        notification.Content,
+		Channel:        string(notification.Channel),
+		Recipients:     notification.Recipients,
+		Category:       notification.Category,
+		Status:         string(notification.Status),
+		Priority:       notificationpb.Priority(notification.Priority + 1),
+		FailureReason:  notification.FailureReason,
+		CronExpression: notification.CronExpr,
+		CreatedAt:      timestamppb.New(notification.CreatedAt),
+		Metadata:       notification.Metadata,
+	}
+	for _, channel := range notification.Channels {
+		pb.Channels = append(pb.Channels, string(channel))
+	}
+	if notification.ScheduledAt != nil {
+		pb.ScheduledAt = timestamppb.New(*notification.ScheduledAt)
+	}
+	if notification.SentAt != nil {
+		pb.SentAt = timestamppb.New(*notification.SentAt)
+	}
+	return pb
+}
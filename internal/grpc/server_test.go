@@ -0,0 +1,373 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"notification-service/internal/services/mock"
+	"notification-service/proto/notificationpb"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newTestServer() (*GRPCNotificationServer, *repository.MemoryRepository) {
+	cfg := &config.Config{}
+	repo := repository.NewMemoryRepository()
+	factory := services.NewNotificationServiceFactory(cfg)
+	return NewGRPCNotificationServer(factory, &mock.MockSchedulerService{}, repo, cfg), repo
+}
+
+func TestSendNotification(t *testing.T) {
+	tests := []struct {
+		name         string
+		request      *notificationpb.SendNotificationRequest
+		expectedCode codes.Code
+	}{
+		{
+			name: "Valid request",
+			request: &notificationpb.SendNotificationRequest{
+				Title: "Test", Content: "Test content", Channel: "slack", Recipients: []string{"U1"},
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "Fan-out request",
+			request: &notificationpb.SendNotificationRequest{
+				Title: "Test", Content: "Test content", Channels: []string{"slack", "email"}, Recipients: []string{"U1"},
+				Priority: notificationpb.Priority_PRIORITY_HIGH,
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Missing content",
+			request:      &notificationpb.SendNotificationRequest{Title: "Test", Channel: "slack", Recipients: []string{"U1"}},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Missing recipients",
+			request:      &notificationpb.SendNotificationRequest{Title: "Test", Content: "Test content", Channel: "slack"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Channel and channels",
+			request: &notificationpb.SendNotificationRequest{
+				Title: "Test", Content: "Test content", Channel: "slack", Channels: []string{"email"}, Recipients: []string{"U1"},
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Unknown channel",
+			request: &notificationpb.SendNotificationRequest{
+				Title: "Test", Content: "Test content", Channel: "pigeon", Recipients: []string{"U1"},
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Template without template service",
+			request: &notificationpb.SendNotificationRequest{
+				TemplateName: "welcome", Channel: "slack", Recipients: []string{"U1"},
+			},
+			expectedCode: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, repo := newTestServer()
+			response, err := server.SendNotification(context.Background(), tt.request)
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Fatalf("Expected code %v, got %v (%v)", tt.expectedCode, code, err)
+			}
+			if tt.expectedCode != codes.OK {
+				return
+			}
+
+			if !response.Success || response.Notification.Status != string(models.StatusSent) {
+				t.Errorf("Expected a sent notification, got %+v", response)
+			}
+			stored, err := repo.GetByID(context.Background(), "", response.Notification.Id)
+			if err != nil {
+				t.Fatalf("Expected notification to be stored: %v", err)
+			}
+			if stored.Status != models.StatusSent || stored.Title != tt.request.Title {
+				t.Errorf("Unexpected stored notification: %+v", stored)
+			}
+			if tt.request.Priority == notificationpb.Priority_PRIORITY_HIGH && stored.Priority != models.PriorityHigh {
+				t.Errorf("Expected priority %s, got %s", models.PriorityHigh, stored.Priority)
+			}
+		})
+	}
+}
+
+func TestSendNotificationTenants(t *testing.T) {
+	server, repo := newTestServer()
+	tenants := services.NewTenantService(repo, server.notificationFactory)
+	if err := tenants.Save(context.Background(), &models.Tenant{ID: "acme"}); err != nil {
+		t.Fatalf("Failed to save tenant: %v", err)
+	}
+	server.WithTenantService(tenants)
+
+	tests := []struct {
+		name         string
+		tenantID     string
+		credential   string
+		expectedCode codes.Code
+	}{
+		{"Known tenant", "acme", "", codes.OK},
+		{"Missing tenant", "", "", codes.InvalidArgument},
+		{"Unknown tenant", "globex", "", codes.NotFound},
+		{"Credentials' tenant", "acme", "acme", codes.OK},
+		{"Other tenant's credentials", "acme", "globex", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), credentialTenantContextKey{}, tt.credential)
+			response, err := server.SendNotification(ctx, &notificationpb.SendNotificationRequest{
+				TenantId: tt.tenantID, Title: "Test", Content: "Test content", Channel: "slack", Recipients: []string{"U1"},
+			})
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Fatalf("Expected code %v, got %v (%v)", tt.expectedCode, code, err)
+			}
+			if tt.expectedCode != codes.OK {
+				return
+			}
+			if response.Notification.TenantId != tt.tenantID {
+				t.Errorf("Expected tenant %s, got %s", tt.tenantID, response.Notification.TenantId)
+			}
+			if _, err := repo.GetByID(context.Background(), tt.tenantID, response.Notification.Id); err != nil {
+				t.Errorf("Expected notification to be stored under its tenant: %v", err)
+			}
+			if _, err := repo.GetByID(context.Background(), "", response.Notification.Id); err == nil {
+				t.Error("Expected notification to be hidden from other tenants")
+			}
+		})
+	}
+}
+
+func TestScheduleNotification(t *testing.T) {
+	notification := &notificationpb.SendNotificationRequest{
+		Title: "Reminder", Content: "Stand-up", Channel: "slack", Recipients: []string{"U1"},
+	}
+	tests := []struct {
+		name            string
+		request         *notificationpb.ScheduleNotificationRequest
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		{
+			name:            "Scheduled time",
+			request:         &notificationpb.ScheduleNotificationRequest{Notification: notification, ScheduledAt: timestamppb.New(time.Now().Add(time.Hour))},
+			expectedCode:    codes.OK,
+			expectedMessage: "Notification scheduled successfully",
+		},
+		{
+			name:            "Cron expression",
+			request:         &notificationpb.ScheduleNotificationRequest{Notification: notification, CronExpression: "0 9 * * MON"},
+			expectedCode:    codes.OK,
+			expectedMessage: "Recurring notification scheduled successfully",
+		},
+		{
+			name:         "Past time",
+			request:      &notificationpb.ScheduleNotificationRequest{Notification: notification, ScheduledAt: timestamppb.New(time.Now().Add(-time.Hour))},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Time and cron expression",
+			request: &notificationpb.ScheduleNotificationRequest{
+				Notification: notification, ScheduledAt: timestamppb.New(time.Now().Add(time.Hour)), CronExpression: "@hourly",
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Invalid cron expression",
+			request:      &notificationpb.ScheduleNotificationRequest{Notification: notification, CronExpression: "not a cron"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Neither time nor cron expression",
+			request:      &notificationpb.ScheduleNotificationRequest{Notification: notification},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, repo := newTestServer()
+			response, err := server.ScheduleNotification(context.Background(), tt.request)
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Fatalf("Expected code %v, got %v (%v)", tt.expectedCode, code, err)
+			}
+			if tt.expectedCode != codes.OK {
+				return
+			}
+
+			if response.Message != tt.expectedMessage {
+				t.Errorf("Expected message %q, got %q", tt.expectedMessage, response.Message)
+			}
+			stored, err := repo.GetByID(context.Background(), "", response.Notification.Id)
+			if err != nil || stored.Status != models.StatusPending {
+				t.Errorf("Expected a pending stored notification, got %+v (%v)", stored, err)
+			}
+			scheduler := server.schedulerService.(*mock.MockSchedulerService)
+			if captured := len(scheduler.ScheduledNotifications()) + len(scheduler.RecurringNotifications()); captured != 1 {
+				t.Errorf("Expected the notification to be handed to the scheduler once, got %d", captured)
+			}
+		})
+	}
+}
+
+func TestGateway(t *testing.T) {
+	server, _ := newTestServer()
+	grpcServer := NewServer(server)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	gateway, err := NewGateway(context.Background(), listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to create gateway: %v", err)
+	}
+
+	body := `{"title":"Test","content":"Test content","channel":"slack","recipients":["U1"]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/notifications/send", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	gateway.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "Notification sent successfully") {
+		t.Errorf("Expected success message, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/notifications/send", strings.NewReader(`{"title":"Test"}`))
+	rr = httptest.NewRecorder()
+	gateway.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid request, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+// contextStream is a grpc.ServerStream carrying only a context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+func TestAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	keys := services.NewAPIKeyService(repository.NewMemoryRepository(), nil)
+	key, _, err := keys.Create(ctx, "ci", "")
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	tokens, err := services.NewTokenService(repository.NewMemoryRepository(), &config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	if err := tokens.SetCredentials(ctx, "sender", "password", []string{models.RoleSender}, ""); err != nil {
+		t.Fatalf("Failed to set credentials: %v", err)
+	}
+	if err := tokens.SetCredentials(ctx, "viewer", "password", nil, ""); err != nil {
+		t.Fatalf("Failed to set credentials: %v", err)
+	}
+	senderToken, _, err := tokens.Issue(ctx, "sender", "password")
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	viewerToken, _, err := tokens.Issue(ctx, "viewer", "password")
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	send := notificationpb.NotificationService_SendNotification_FullMethodName
+	tests := []struct {
+		name         string
+		mode         string
+		method       string
+		md           metadata.MD
+		expectedCode codes.Code
+	}{
+		{"Auth disabled", "", send, nil, codes.OK},
+		{"Missing API key", "api_key", send, nil, codes.Unauthenticated},
+		{"Invalid API key", "api_key", send, metadata.Pairs(APIKeyMetadata, "nsk_guess"), codes.Unauthenticated},
+		{"Valid API key", "api_key", send, metadata.Pairs(APIKeyMetadata, key), codes.OK},
+		{"Missing token", "jwt", send, nil, codes.Unauthenticated},
+		{"Invalid token", "jwt", send, metadata.Pairs(AuthorizationMetadata, "Bearer nope"), codes.Unauthenticated},
+		{"Token without role", "jwt", send, metadata.Pairs(AuthorizationMetadata, "Bearer "+viewerToken), codes.PermissionDenied},
+		{"Valid token", "jwt", send, metadata.Pairs(AuthorizationMetadata, "Bearer "+senderToken), codes.OK},
+		{"Reflection", "api_key", "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", nil, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := NewAuthenticator(tt.mode, keys, tokens)
+			callCtx := metadata.NewIncomingContext(ctx, tt.md)
+
+			_, err := authenticator.unary(callCtx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(context.Context, any) (any, error) {
+				return nil, nil
+			})
+			if status.Code(err) != tt.expectedCode {
+				t.Errorf("Expected unary code %v, got %v", tt.expectedCode, err)
+			}
+			err = authenticator.stream(nil, &contextStream{ctx: callCtx}, &grpc.StreamServerInfo{FullMethod: tt.method}, func(any, grpc.ServerStream) error {
+				return nil
+			})
+			if status.Code(err) != tt.expectedCode {
+				t.Errorf("Expected stream code %v, got %v", tt.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestGatewayForwardsAPIKey(t *testing.T) {
+	keys := services.NewAPIKeyService(repository.NewMemoryRepository(), nil)
+	key, _, err := keys.Create(context.Background(), "ci", "")
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	server, _ := newTestServer()
+	grpcServer := NewServer(server, NewAuthenticator("api_key", keys, nil).ServerOptions()...)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	gateway, err := NewGateway(context.Background(), listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to create gateway: %v", err)
+	}
+	send := func(key string) int {
+		body := `{"title":"Test","content":"Test content","channel":"slack","recipients":["U1"]}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/notifications/send", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		gateway.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := send(""); code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without a key, got %d", http.StatusUnauthorized, code)
+	}
+	if code := send(key); code != http.StatusOK {
+		t.Errorf("Expected status code %d with a key, got %d", http.StatusOK, code)
+	}
+}
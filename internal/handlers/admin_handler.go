@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+	// TenantID binds the key to a tenant, so it cannot act for any other.
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// CreateAPIKeyResponse holds a newly provisioned key. Key is not stored and
+// cannot be retrieved again.
+type CreateAPIKeyResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Key       string    `json:"key"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetCredentialsRequest sets a user's password and roles for POST /auth/token.
+type SetCredentialsRequest struct {
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+	// TenantID binds the user's tokens to a tenant, so they cannot act for
+	// any other.
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// SaveTenantRequest creates or updates a tenant. Config overrides the
+// service-wide channel credentials for the tenant.
+type SaveTenantRequest struct {
+	ID     string              `json:"id"`
+	Name   string              `json:"name"`
+	Config models.TenantConfig `json:"config"`
+}
+
+type AdminHandler struct {
+	apiKeys *services.APIKeyService
+	tokens  *services.TokenService
+	tenants *services.TenantService
+	audit   services.AuditLog
+}
+
+func NewAdminHandler(apiKeys *services.APIKeyService) *AdminHandler {
+	return &AdminHandler{apiKeys: apiKeys}
+}
+
+// WithTokenService enables setting user credentials.
+func (h *AdminHandler) WithTokenService(tokens *services.TokenService) {
+	h.tokens = tokens
+}
+
+// WithTenantService enables managing tenants.
+func (h *AdminHandler) WithTenantService(tenants *services.TenantService) {
+	h.tenants = tenants
+}
+
+// WithAuditLog enables reading notification audit events.
+func (h *AdminHandler) WithAuditLog(audit services.AuditLog) {
+	h.audit = audit
+}
+
+// APIKeys provisions a new API key on POST. It must be wrapped in
+// AdminMiddleware.
+func (h *AdminHandler) APIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+	if req.Name == "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Name is required",
+		})
+		return
+	}
+	if !h.checkTenant(w, r, req.TenantID) {
+		return
+	}
+
+	key, apiKey, err := h.apiKeys.Create(r.Context(), req.Name, req.TenantID)
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to create API key: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "API key created; store it now, it will not be shown again",
+		Data: CreateAPIKeyResponse{
+			ID:        apiKey.ID,
+			Name:      apiKey.Name,
+			Key:       key,
+			TenantID:  apiKey.TenantID,
+			CreatedAt: apiKey.CreatedAt,
+		},
+	})
+}
+
+// Credentials sets the password and roles of the user in the path on PUT.
+// It must be wrapped in AdminMiddleware.
+func (h *AdminHandler) Credentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+	if h.tokens == nil {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Token authentication is not enabled",
+		})
+		return
+	}
+
+	var req SetCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+	if req.Password == "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Password is required",
+		})
+		return
+	}
+	for _, role := range req.Roles {
+		if role != models.RoleSender && role != models.RoleAdmin {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Unknown role: " + role,
+			})
+			return
+		}
+	}
+	if !h.checkTenant(w, r, req.TenantID) {
+		return
+	}
+
+	if err := h.tokens.SetCredentials(r.Context(), r.PathValue("id"), req.Password, req.Roles, req.TenantID); err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to set credentials: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Credentials updated",
+	})
+}
+
+// Tenants lists tenants on GET and creates or updates one on POST. It must be
+// wrapped in AdminMiddleware.
+func (h *AdminHandler) Tenants(w http.ResponseWriter, r *http.Request) {
+	if h.tenants == nil {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Multi-tenancy is not enabled",
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tenants, err := h.tenants.List(r.Context())
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to list tenants: " + err.Error(),
+			})
+			return
+		}
+		if tenants == nil {
+			tenants = []*models.Tenant{}
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Tenants retrieved successfully",
+			Data:    tenants,
+		})
+	case http.MethodPost:
+		var req SaveTenantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return
+		}
+
+		tenant := &models.Tenant{ID: req.ID, Name: req.Name, Config: req.Config}
+		err := h.tenants.Save(r.Context(), tenant)
+		if errors.Is(err, services.ErrInvalidTenantID) {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to save tenant: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Tenant saved successfully",
+			Data:    tenant,
+		})
+	default:
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+// AuditEvents returns the audit events of the notification named by the
+// notification_id query parameter on GET, oldest first. It must be wrapped in
+// AdminMiddleware.
+func (h *AdminHandler) AuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+	if h.audit == nil {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Audit logging is not enabled",
+		})
+		return
+	}
+
+	notificationID := r.URL.Query().Get("notification_id")
+	if notificationID == "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "notification_id is required",
+		})
+		return
+	}
+
+	events, err := h.audit.Events(r.Context(), notificationID)
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to read audit events: " + err.Error(),
+		})
+		return
+	}
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Audit events retrieved successfully",
+		Data:    events,
+	})
+}
+
+// checkTenant reports whether credentials may be bound to tenantID: it must
+// be empty or name a known tenant. Otherwise it writes a 400.
+func (h *AdminHandler) checkTenant(w http.ResponseWriter, r *http.Request, tenantID string) bool {
+	if tenantID == "" {
+		return true
+	}
+	if h.tenants == nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Multi-tenancy is not enabled",
+		})
+		return false
+	}
+	_, err := h.tenants.Get(r.Context(), tenantID)
+	if errors.Is(err, repository.ErrTenantNotFound) {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Unknown tenant: " + tenantID,
+		})
+		return false
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to load tenant: " + err.Error(),
+		})
+		return false
+	}
+	return true
+}
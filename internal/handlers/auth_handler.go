@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"notification-service/internal/services"
+	"time"
+)
+
+type TokenRequest struct {
+	UserID   string `json:"user_id"`
+	Password string `json:"password"`
+}
+
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AuthHandler struct {
+	tokens *services.TokenService
+}
+
+func NewAuthHandler(tokens *services.TokenService) *AuthHandler {
+	return &AuthHandler{tokens: tokens}
+}
+
+// Token exchanges a user's credentials for a bearer token on POST.
+func (h *AuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+	if req.UserID == "" || req.Password == "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "User ID and password are required",
+		})
+		return
+	}
+
+	token, expiresAt, err := h.tokens.Issue(r.Context(), req.UserID, req.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+			Success: false,
+			Message: "Invalid credentials",
+		})
+		return
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to issue token: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Token issued",
+		Data: TokenResponse{
+			Token:     token,
+			TokenType: "Bearer",
+			ExpiresAt: expiresAt,
+		},
+	})
+}
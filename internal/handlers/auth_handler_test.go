@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"testing"
+)
+
+func TestAuthHandlerToken(t *testing.T) {
+	tokens, err := services.NewTokenService(repository.NewMemoryRepository(), &config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	if err := tokens.SetCredentials(context.Background(), "user-1", "password", []string{models.RoleSender}, ""); err != nil {
+		t.Fatalf("Failed to set credentials: %v", err)
+	}
+	handler := NewAuthHandler(tokens)
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+	}{
+		{"Valid credentials", http.MethodPost, `{"user_id":"user-1","password":"password"}`, http.StatusOK},
+		{"Wrong password", http.MethodPost, `{"user_id":"user-1","password":"guess"}`, http.StatusUnauthorized},
+		{"Unknown user", http.MethodPost, `{"user_id":"user-2","password":"password"}`, http.StatusUnauthorized},
+		{"Missing password", http.MethodPost, `{"user_id":"user-1"}`, http.StatusBadRequest},
+		{"Invalid body", http.MethodPost, `{`, http.StatusBadRequest},
+		{"Wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/auth/token", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.Token(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminHandlerCredentials(t *testing.T) {
+	tokens, err := services.NewTokenService(repository.NewMemoryRepository(), &config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	handler := NewAdminHandler(nil)
+	handler.WithTokenService(tokens)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{"Sender", `{"password":"password","roles":["sender"]}`, http.StatusOK},
+		{"Unknown role", `{"password":"password","roles":["root"]}`, http.StatusBadRequest},
+		{"Missing password", `{"roles":["sender"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/admin/users/user-1/credentials", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", "user-1")
+			rr := httptest.NewRecorder()
+			handler.Credentials(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if _, _, err := tokens.Issue(context.Background(), "user-1", "password"); err != nil {
+		t.Errorf("Expected the stored credentials to issue a token, got %v", err)
+	}
+}
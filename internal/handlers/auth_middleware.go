@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"notification-service/internal/models"
+	"notification-service/internal/services"
+	"strings"
+)
+
+// APIKeyHeader carries the API key on authenticated requests.
+const APIKeyHeader = "X-API-Key"
+
+// Actors recorded for requests authenticated by something other than a
+// user's token.
+const (
+	ActorAPIKey = "api_key"
+	ActorAdmin  = "admin"
+)
+
+type actorContextKey struct{}
+
+// ActorID returns who the auth middleware authenticated a request's context
+// as: the token's subject, or ActorAPIKey or ActorAdmin for requests
+// authenticated by key. It returns "" when authentication is disabled.
+func ActorID(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorContextKey{}).(string)
+	return actorID
+}
+
+// WithActorID returns a copy of ctx acting as actorID.
+func WithActorID(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actorID)
+}
+
+type credentialTenantContextKey struct{}
+
+// CredentialTenant returns the tenant the API key or token a request's
+// context was authenticated with is bound to, or "" if it may act for any
+// tenant.
+func CredentialTenant(ctx context.Context) string {
+	tenantID, _ := ctx.Value(credentialTenantContextKey{}).(string)
+	return tenantID
+}
+
+// WithCredentialTenant returns a copy of ctx authenticated with credentials
+// bound to tenantID.
+func WithCredentialTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, credentialTenantContextKey{}, tenantID)
+}
+
+type claimsContextKey struct{}
+
+// GrantsRole reports whether the bearer token a request's context was
+// authenticated with grants role. Requests authenticated by an API key are
+// granted every role but admin, which only the admin key grants, and
+// unauthenticated requests are granted every one, as they are on routes the
+// auth middleware protects.
+func GrantsRole(ctx context.Context, role string) bool {
+	if claims, ok := ctx.Value(claimsContextKey{}).(*services.Claims); ok {
+		return claims.HasRole(role)
+	}
+	return role != models.RoleAdmin || ActorID(ctx) != ActorAPIKey
+}
+
+// AuthMiddleware passes requests on to next only if their X-API-Key header
+// holds a key known to keys, and rejects the rest with 401.
+func AuthMiddleware(keys *services.APIKeyService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+				Success: false,
+				Message: "Missing " + APIKeyHeader + " header",
+			})
+			return
+		}
+		tenantID, err := keys.Authenticate(r.Context(), key)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+				Success: false,
+				Message: "Invalid API key",
+			})
+			return
+		}
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to check API key: " + err.Error(),
+			})
+			return
+		}
+		ctx := WithCredentialTenant(r.Context(), tenantID)
+		next.ServeHTTP(w, r.WithContext(WithActorID(ctx, ActorAPIKey)))
+	})
+}
+
+// JWTMiddleware passes requests on to next only if their Authorization
+// header holds a bearer token issued by tokens that grants role. Requests
+// without a valid token get 401; tokens lacking the role get 403.
+func JWTMiddleware(tokens *services.TokenService, role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+				Success: false,
+				Message: "Missing bearer token",
+			})
+			return
+		}
+		claims, err := tokens.Verify(token)
+		if err != nil {
+			sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+				Success: false,
+				Message: "Invalid token",
+			})
+			return
+		}
+		if !claims.HasRole(role) {
+			sendJSONResponse(w, http.StatusForbidden, APIResponse{
+				Success: false,
+				Message: "Token does not grant the " + role + " role",
+			})
+			return
+		}
+		ctx := context.WithValue(r.Context(), claimsContextKey{}, claims)
+		ctx = WithCredentialTenant(ctx, claims.TenantID)
+		next.ServeHTTP(w, r.WithContext(WithActorID(ctx, claims.Subject)))
+	})
+}
+
+// AdminMiddleware passes requests on to next only if their X-API-Key header
+// holds masterKey, or, when tokens is set, they carry a bearer token with the
+// admin role. An empty masterKey disables the X-API-Key check.
+func AdminMiddleware(masterKey string, tokens *services.TokenService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if masterKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(masterKey)) == 1 {
+			next.ServeHTTP(w, r.WithContext(WithActorID(r.Context(), ActorAdmin)))
+			return
+		}
+		if token, ok := bearerToken(r); ok && tokens != nil {
+			if claims, err := tokens.Verify(token); err == nil && claims.HasRole(models.RoleAdmin) {
+				next.ServeHTTP(w, r.WithContext(WithActorID(r.Context(), claims.Subject)))
+				return
+			}
+		}
+		sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+			Success: false,
+			Message: "Invalid admin key",
+		})
+	})
+}
+
+// AdminKeyMiddleware guards admin-only routes in api_key mode, where keys
+// carry no roles. Requests whose X-API-Key header holds adminKey are passed
+// on to next as the admin, other keys known to keys get 403, and requests
+// without a known key get 401.
+func AdminKeyMiddleware(adminKey string, keys *services.APIKeyService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+			next.ServeHTTP(w, r.WithContext(WithActorID(r.Context(), ActorAdmin)))
+			return
+		}
+		_, err := keys.Authenticate(r.Context(), key)
+		switch {
+		case err == nil:
+			sendJSONResponse(w, http.StatusForbidden, APIResponse{
+				Success: false,
+				Message: "API key does not grant the " + models.RoleAdmin + " role",
+			})
+		case errors.Is(err, services.ErrInvalidAPIKey):
+			sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+				Success: false,
+				Message: "Invalid API key",
+			})
+		default:
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to check API key: " + err.Error(),
+			})
+		}
+	})
+}
+
+// CredentialCheck returns a function reporting whether a request carries
+// adminKey or an API key known to keys in its X-API-Key header, or a bearer
+// token issued by tokens. A nil keys or tokens, or an empty adminKey, is not
+// checked.
+func CredentialCheck(keys *services.APIKeyService, tokens *services.TokenService, adminKey string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			if adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+				return true
+			}
+			if keys != nil {
+				if _, err := keys.Authenticate(r.Context(), key); err == nil {
+					return true
+				}
+			}
+		}
+		if token, ok := bearerToken(r); ok && tokens != nil {
+			_, err := tokens.Verify(token)
+			return err == nil
+		}
+		return false
+	}
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"testing"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	keys := services.NewAPIKeyService(repository.NewMemoryRepository(), nil)
+	admin := AdminMiddleware("master-key", nil, http.HandlerFunc(NewAdminHandler(keys).APIKeys))
+	protected := AuthMiddleware(keys, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// Provision a key through the admin API
+	body := bytes.NewBufferString(`{"name":"ci"}`)
+	req := httptest.NewRequest(http.MethodPost, "/admin/api-keys", body)
+	req.Header.Set(APIKeyHeader, "master-key")
+	rr := httptest.NewRecorder()
+	admin.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Data CreateAPIKeyResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil || response.Data.Key == "" {
+		t.Fatalf("Expected a key in the response, got %+v (%v)", response, err)
+	}
+
+	tests := []struct {
+		name         string
+		key          string
+		expectedCode int
+	}{
+		{"Missing key", "", http.StatusUnauthorized},
+		{"Unknown key", "nsk_unknown", http.StatusUnauthorized},
+		{"Master key", "master-key", http.StatusUnauthorized},
+		{"Provisioned key", response.Data.Key, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rr := httptest.NewRecorder()
+			protected.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	keys := services.NewAPIKeyService(repository.NewMemoryRepository(), nil)
+
+	tests := []struct {
+		name         string
+		masterKey    string
+		key          string
+		body         string
+		expectedCode int
+	}{
+		{"Missing key", "master-key", "", `{"name":"ci"}`, http.StatusUnauthorized},
+		{"Wrong key", "master-key", "guess", `{"name":"ci"}`, http.StatusUnauthorized},
+		{"Admin API disabled", "", "", `{"name":"ci"}`, http.StatusUnauthorized},
+		{"Missing name", "master-key", "master-key", `{}`, http.StatusBadRequest},
+		{"Tenant without multi-tenancy", "master-key", "master-key", `{"name":"ci","tenant_id":"acme"}`, http.StatusBadRequest},
+		{"Valid request", "master-key", "master-key", `{"name":"ci"}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := AdminMiddleware(tt.masterKey, nil, http.HandlerFunc(NewAdminHandler(keys).APIKeys))
+			req := httptest.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewBufferString(tt.body))
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestAdminKeyMiddleware(t *testing.T) {
+	keys := services.NewAPIKeyService(repository.NewMemoryRepository(), nil)
+	ctx := context.Background()
+	key, _, err := keys.Create(ctx, "ci", "")
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	tenantKey, _, err := keys.Create(ctx, "acme", "acme")
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	handler := AdminKeyMiddleware("master-key", keys, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ActorID(r.Context()) != ActorAdmin {
+			t.Errorf("Expected the admin actor, got %q", ActorID(r.Context()))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name         string
+		key          string
+		expectedCode int
+	}{
+		{"Missing key", "", http.StatusUnauthorized},
+		{"Unknown key", "nsk_unknown", http.StatusUnauthorized},
+		{"Provisioned key", key, http.StatusForbidden},
+		{"Tenant key", tenantKey, http.StatusForbidden},
+		{"Master key", "master-key", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/notifications/1", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	tokens, err := services.NewTokenService(repository.NewMemoryRepository(), &config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	ctx := context.Background()
+	if err := tokens.SetCredentials(ctx, "sender", "password", []string{models.RoleSender}, ""); err != nil {
+		t.Fatalf("Failed to set credentials: %v", err)
+	}
+	if err := tokens.SetCredentials(ctx, "admin", "password", []string{models.RoleAdmin}, ""); err != nil {
+		t.Fatalf("Failed to set credentials: %v", err)
+	}
+
+	// Tokens come from POST /auth/token
+	issue := func(userID string) string {
+		body := bytes.NewBufferString(`{"user_id":"` + userID + `","password":"password"}`)
+		rr := httptest.NewRecorder()
+		NewAuthHandler(tokens).Token(rr, httptest.NewRequest(http.MethodPost, "/auth/token", body))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Data TokenResponse `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil || response.Data.TokenType != "Bearer" {
+			t.Fatalf("Expected a bearer token in the response, got %+v (%v)", response, err)
+		}
+		return response.Data.Token
+	}
+	senderToken, adminToken := issue("sender"), issue("admin")
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	tests := []struct {
+		name          string
+		role          string
+		authorization string
+		expectedCode  int
+	}{
+		{"Missing token", models.RoleSender, "", http.StatusUnauthorized},
+		{"Wrong scheme", models.RoleSender, "Basic " + senderToken, http.StatusUnauthorized},
+		{"Invalid token", models.RoleSender, "Bearer not-a-token", http.StatusUnauthorized},
+		{"Sender sends", models.RoleSender, "Bearer " + senderToken, http.StatusNoContent},
+		{"Sender cancels", models.RoleAdmin, "Bearer " + senderToken, http.StatusForbidden},
+		{"Admin sends", models.RoleSender, "Bearer " + adminToken, http.StatusNoContent},
+		{"Admin cancels", models.RoleAdmin, "Bearer " + adminToken, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/notifications", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+			JWTMiddleware(tokens, tt.role, ok).ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	// Admin tokens also open the admin API
+	for token, expectedCode := range map[string]int{senderToken: http.StatusUnauthorized, adminToken: http.StatusNoContent} {
+		req := httptest.NewRequest(http.MethodPut, "/admin/users/sender/credentials", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		AdminMiddleware("", tokens, ok).ServeHTTP(rr, req)
+		if rr.Code != expectedCode {
+			t.Errorf("Expected status code %d, got %d", expectedCode, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// BodyLimitMiddleware rejects requests whose body is larger than maxBytes
+// with 413 before next sees them. Bodies without a Content-Length are read
+// up front through http.MaxBytesReader, so handlers never decode a body
+// that would have been cut off. A maxBytes of zero disables the limit.
+func BodyLimitMiddleware(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maxBytes <= 0 || r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.ContentLength > maxBytes {
+			sendBodyTooLarge(w)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendBodyTooLarge(w)
+			return
+		}
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Failed to read request body",
+			})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func sendBodyTooLarge(w http.ResponseWriter) {
+	sendJSONResponse(w, http.StatusRequestEntityTooLarge, APIResponse{
+		Success: false,
+		Message: "Request body too large",
+	})
+}
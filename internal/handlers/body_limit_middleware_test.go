@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	var received int
+	handler := BodyLimitMiddleware(16, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = len(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		expectedCode  int
+	}{
+		{"Within limit", `{"title":"hi"}`, 14, http.StatusOK},
+		{"At limit", strings.Repeat("a", 16), 16, http.StatusOK},
+		{"Content-Length over limit", strings.Repeat("a", 17), 17, http.StatusRequestEntityTooLarge},
+		{"Unknown length over limit", strings.Repeat("a", 1024), -1, http.StatusRequestEntityTooLarge},
+		{"Unknown length within limit", "small", -1, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = -1
+			req := httptest.NewRequest(http.MethodPost, "/notifications", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedCode == http.StatusOK {
+				if received != len(tt.body) {
+					t.Errorf("Expected the handler to read %d bytes, got %d", len(tt.body), received)
+				}
+				return
+			}
+			if received != -1 {
+				t.Error("Expected an oversized body never to reach the handler")
+			}
+			var response APIResponse
+			json.NewDecoder(rr.Body).Decode(&response)
+			if response.Success || response.Message != "Request body too large" {
+				t.Errorf("Unexpected response %+v", response)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsAllowedMethods and corsAllowedHeaders are offered to every allowed
+// origin; they cover the routes of the HTTP API.
+var (
+	corsAllowedMethods = strings.Join([]string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions,
+	}, ", ")
+	corsAllowedHeaders = strings.Join([]string{
+		"Authorization", "Content-Type", APIKeyHeader, TenantHeader, CorrelationIDHeader,
+	}, ", ")
+	corsExposedHeaders = strings.Join([]string{CorrelationIDHeader, "Retry-After"}, ", ")
+)
+
+// CORSMiddleware lets browsers on the given origins call next. An origin of
+// "*" allows every origin, and no origins deny every cross-origin request.
+// Preflight OPTIONS requests are answered with 204 for allowed origins and
+// 403 for the rest, without reaching next. Requests without an Origin
+// header pass through untouched.
+func CORSMiddleware(origins []string, next http.Handler) http.Handler {
+	allowAll := slices.Contains(origins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Responses differ by origin, so caches must key on it
+		w.Header().Add("Vary", "Origin")
+		allowed := allowAll || slices.Contains(origins, origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !allowed {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			// Without the allow headers the browser withholds the response
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowAll {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+		w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+		if preflight {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		origins        []string
+		method         string
+		origin         string
+		preflight      bool
+		expectedCode   int
+		expectedOrigin string
+		reachesNext    bool
+	}{
+		{"Same origin request", []string{"https://app.example.com"}, http.MethodGet, "", false, http.StatusOK, "", true},
+		{"Allowed origin", []string{"https://app.example.com"}, http.MethodPost, "https://app.example.com", false, http.StatusOK, "https://app.example.com", true},
+		{"Allowed preflight", []string{"https://app.example.com"}, http.MethodOptions, "https://app.example.com", true, http.StatusNoContent, "https://app.example.com", false},
+		{"Other origin", []string{"https://app.example.com"}, http.MethodGet, "https://evil.example.com", false, http.StatusOK, "", true},
+		{"Other origin preflight", []string{"https://app.example.com"}, http.MethodOptions, "https://evil.example.com", true, http.StatusForbidden, "", false},
+		{"No origins configured", nil, http.MethodOptions, "https://app.example.com", true, http.StatusForbidden, "", false},
+		{"Wildcard", []string{"*"}, http.MethodGet, "https://anywhere.example.com", false, http.StatusOK, "*", true},
+		{"Plain OPTIONS", []string{"https://app.example.com"}, http.MethodOptions, "https://app.example.com", false, http.StatusOK, "https://app.example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			handler := CORSMiddleware(tt.origins, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tt.method, "/notifications", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if reached != tt.reachesNext {
+				t.Errorf("Expected next to be reached: %v, got %v", tt.reachesNext, reached)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.expectedOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.expectedOrigin, got)
+			}
+			if tt.expectedOrigin == "" && (rr.Header().Get("Access-Control-Allow-Methods") != "" || rr.Header().Get("Access-Control-Allow-Headers") != "") {
+				t.Errorf("Expected no CORS headers, got %v", rr.Header())
+			}
+			if tt.origin == "" && len(rr.Header()) != 0 {
+				t.Errorf("Expected no headers on a non-CORS request, got %v", rr.Header())
+			}
+			if tt.preflight && tt.expectedCode == http.StatusNoContent && rr.Body.Len() != 0 {
+				t.Errorf("Expected an empty preflight response, got %q", rr.Body.String())
+			}
+		})
+	}
+}
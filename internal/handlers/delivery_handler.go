@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strings"
+	"time"
+)
+
+// maxDeliveryReceiptBytes bounds the JSON receipts read for verification.
+const maxDeliveryReceiptBytes = 64 << 10
+
+// trackingPixel is a transparent 1x1 GIF returned to email open-tracking
+// requests.
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// twilioStatuses maps Twilio MessageStatus callback values to notification
+// statuses. Intermediate states such as queued and sent are ignored.
+var twilioStatuses = map[string]models.NotificationStatus{
+	"delivered":   models.StatusDelivered,
+	"read":        models.StatusRead,
+	"failed":      models.StatusFailed,
+	"undelivered": models.StatusFailed,
+}
+
+type DeliveryReceiptRequest struct {
+	NotificationID string                    `json:"notification_id"`
+	Status         models.NotificationStatus `json:"status"`
+	Timestamp      time.Time                 `json:"timestamp,omitempty"`
+	Reason         string                    `json:"reason,omitempty"`
+}
+
+type DeliveryHandler struct {
+	receipts        *services.DeliveryReceiptService
+	secret          string
+	twilioAuthToken string
+	publicURL       string
+}
+
+func NewDeliveryHandler(receipts *services.DeliveryReceiptService) *DeliveryHandler {
+	return &DeliveryHandler{receipts: receipts}
+}
+
+// WithSigningSecret accepts JSON receipts whose X-Signature header is the
+// body signed with secret, as services.SignPayload does.
+func (h *DeliveryHandler) WithSigningSecret(secret string) {
+	h.secret = secret
+}
+
+// WithTwilioAuthToken accepts Twilio status callbacks carrying a valid
+// X-Twilio-Signature. publicURL is the service's URL as Twilio calls it;
+// when empty it is rebuilt from the request.
+func (h *DeliveryHandler) WithTwilioAuthToken(authToken, publicURL string) {
+	h.twilioAuthToken = authToken
+	h.publicURL = publicURL
+}
+
+// requestURL is the URL a provider posted r to, which Twilio signs.
+func (h *DeliveryHandler) requestURL(r *http.Request) string {
+	if h.publicURL != "" {
+		return strings.TrimRight(h.publicURL, "/") + r.URL.RequestURI()
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// Delivery receives delivery receipts for the channel named in the path. POST
+// accepts a signed JSON DeliveryReceiptRequest or a signed form-encoded
+// Twilio status callback with notification_id in the query string; unsigned
+// receipts are rejected. GET is an open-tracking pixel for notification_id.
+func (h *DeliveryHandler) Delivery(w http.ResponseWriter, r *http.Request) {
+	channel := models.NotificationChannel(r.PathValue("channel"))
+
+	switch r.Method {
+	case http.MethodGet:
+		h.trackOpen(w, r, r.URL.Query().Get("notification_id"), channel)
+
+	case http.MethodPost:
+		receipt, ok := h.decodeReceipt(w, r)
+		if !ok {
+			return
+		}
+		if receipt.Status == "" {
+			sendJSONResponse(w, http.StatusOK, APIResponse{
+				Success: true,
+				Message: "Delivery receipt ignored",
+			})
+			return
+		}
+		receipt.TenantID = TenantID(r.Context())
+		receipt.Channel = channel
+
+		notification, err := h.receipts.Record(r.Context(), receipt)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			sendJSONResponse(w, http.StatusNotFound, APIResponse{
+				Success: false,
+				Message: "Notification not found: " + receipt.NotificationID,
+			})
+		case errors.Is(err, services.ErrInvalidDeliveryReceipt):
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+		case err != nil:
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to record delivery receipt: " + err.Error(),
+			})
+		default:
+			sendJSONResponse(w, http.StatusOK, APIResponse{
+				Success: true,
+				Message: "Delivery receipt recorded successfully",
+				Data:    newNotificationStatusResponse(notification),
+			})
+		}
+
+	default:
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+// TrackOpen is the open-tracking pixel that emails sent with TRACKING_BASE_URL
+// load for the notification named in the path.
+func (h *DeliveryHandler) TrackOpen(w http.ResponseWriter, r *http.Request) {
+	h.trackOpen(w, r, r.PathValue("id"), models.ChannelEmail)
+}
+
+// trackOpen records an open of notification id and returns the tracking
+// pixel. Mail clients show a broken image on errors, so the pixel is always
+// returned.
+func (h *DeliveryHandler) trackOpen(w http.ResponseWriter, r *http.Request, id string, channel models.NotificationChannel) {
+	if err := h.receipts.RecordOpen(r.Context(), TenantID(r.Context()), id, channel); err != nil {
+		logging.FromContext(r.Context(), nil).Warn("Error recording open", "notification_id", id, "channel", channel, "error", err)
+	}
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(trackingPixel)
+}
+
+// decodeReceipt verifies and reads a receipt from a JSON or Twilio form body.
+// A receipt with an empty status is one that should be acknowledged and
+// ignored.
+func (h *DeliveryHandler) decodeReceipt(w http.ResponseWriter, r *http.Request) (services.DeliveryReceipt, bool) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return services.DeliveryReceipt{}, false
+		}
+		if !services.VerifyTwilioSignature(h.twilioAuthToken, h.requestURL(r), r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+			sendInvalidReceiptSignature(w)
+			return services.DeliveryReceipt{}, false
+		}
+		receipt := services.DeliveryReceipt{
+			NotificationID: r.FormValue("notification_id"),
+			Status:         twilioStatuses[r.FormValue("MessageStatus")],
+		}
+		if code := r.FormValue("ErrorCode"); code != "" {
+			receipt.Reason = "twilio error " + code
+		}
+		return receipt, true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxDeliveryReceiptBytes))
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return services.DeliveryReceipt{}, false
+	}
+	// An empty secret would let anyone sign
+	if h.secret == "" || !services.VerifySignature(h.secret, body, r.Header.Get("X-Signature")) {
+		sendInvalidReceiptSignature(w)
+		return services.DeliveryReceipt{}, false
+	}
+	var req DeliveryReceiptRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.NotificationID == "" || req.Status == "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body: notification_id and status are required",
+		})
+		return services.DeliveryReceipt{}, false
+	}
+	return services.DeliveryReceipt{
+		NotificationID: req.NotificationID,
+		Status:         req.Status,
+		At:             req.Timestamp,
+		Reason:         req.Reason,
+	}, true
+}
+
+func sendInvalidReceiptSignature(w http.ResponseWriter) {
+	sendJSONResponse(w, http.StatusUnauthorized, APIResponse{
+		Success: false,
+		Message: "Invalid delivery receipt signature",
+	})
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeliveryWebhook(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewDeliveryHandler(services.NewDeliveryReceiptService(repo))
+	handler.WithSigningSecret("receipt-secret")
+	handler.WithTwilioAuthToken("twilio-token", "https://notify.example.com")
+	repo.Save(ctx, &models.Notification{ID: "email-1", Channel: models.ChannelEmail, Status: models.StatusSent})
+	repo.Save(ctx, &models.Notification{ID: "sms-1", Channel: models.ChannelMessage, Status: models.StatusSent})
+
+	// Receipts are signed as their providers would sign them
+	post := func(channel, contentType, body string) *httptest.ResponseRecorder {
+		target := "/webhooks/" + channel + "/delivery"
+		if contentType == "application/x-www-form-urlencoded" {
+			target += "?notification_id=sms-1"
+		}
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.SetPathValue("channel", channel)
+		if contentType == "application/json" {
+			req.Header.Set("X-Signature", services.SignPayload("receipt-secret", []byte(body)))
+		} else {
+			params, _ := url.ParseQuery(body)
+			req.Header.Set("X-Twilio-Signature", services.SignTwilioRequest("twilio-token", "https://notify.example.com"+target, params))
+		}
+		rr := httptest.NewRecorder()
+		handler.Delivery(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		name           string
+		channel        string
+		contentType    string
+		body           string
+		expectedCode   int
+		id             string
+		expectedStatus models.NotificationStatus
+	}{
+		{
+			name:           "JSON receipt",
+			channel:        "email",
+			contentType:    "application/json",
+			body:           `{"notification_id": "email-1", "status": "delivered"}`,
+			expectedCode:   http.StatusOK,
+			id:             "email-1",
+			expectedStatus: models.StatusDelivered,
+		},
+		{
+			name:           "Twilio intermediate status is ignored",
+			channel:        "message",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           url.Values{"MessageStatus": {"queued"}}.Encode(),
+			expectedCode:   http.StatusOK,
+			id:             "sms-1",
+			expectedStatus: models.StatusSent,
+		},
+		{
+			name:           "Twilio undelivered",
+			channel:        "message",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           url.Values{"MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}}.Encode(),
+			expectedCode:   http.StatusOK,
+			id:             "sms-1",
+			expectedStatus: models.StatusFailed,
+		},
+		{
+			name:         "Unknown notification",
+			channel:      "email",
+			contentType:  "application/json",
+			body:         `{"notification_id": "missing", "status": "delivered"}`,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Wrong channel",
+			channel:      "slack",
+			contentType:  "application/json",
+			body:         `{"notification_id": "email-1", "status": "read"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Missing status",
+			channel:      "email",
+			contentType:  "application/json",
+			body:         `{"notification_id": "email-1"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := post(tt.channel, tt.contentType, tt.body)
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.id == "" {
+				return
+			}
+			stored, _ := repo.GetByID(ctx, "", tt.id)
+			if stored.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, stored.Status)
+			}
+		})
+	}
+}
+
+func TestDeliveryWebhookRejectsUnsignedReceipts(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	repo.Save(ctx, &models.Notification{ID: "email-1", Channel: models.ChannelEmail, Status: models.StatusSent})
+	repo.Save(ctx, &models.Notification{ID: "sms-1", Channel: models.ChannelMessage, Status: models.StatusSent})
+	signed := NewDeliveryHandler(services.NewDeliveryReceiptService(repo))
+	signed.WithSigningSecret("receipt-secret")
+	signed.WithTwilioAuthToken("twilio-token", "")
+	unconfigured := NewDeliveryHandler(services.NewDeliveryReceiptService(repo))
+
+	jsonBody := `{"notification_id": "email-1", "status": "failed"}`
+	formBody := url.Values{"MessageStatus": {"failed"}}.Encode()
+	twilioURL := "http://example.com/webhooks/message/delivery?notification_id=sms-1"
+	tests := []struct {
+		name      string
+		handler   *DeliveryHandler
+		channel   string
+		form      bool
+		signature string
+	}{
+		{"Unsigned JSON", signed, "email", false, ""},
+		{"JSON signed with another secret", signed, "email", false, services.SignPayload("guess", []byte(jsonBody))},
+		{"JSON without a configured secret", unconfigured, "email", false, services.SignPayload("", []byte(jsonBody))},
+		{"Unsigned Twilio", signed, "message", true, ""},
+		{"Twilio signed with another token", signed, "message", true, services.SignTwilioRequest("guess", twilioURL, url.Values{"MessageStatus": {"failed"}})},
+		{"Twilio without a configured token", unconfigured, "message", true, services.SignTwilioRequest("", twilioURL, url.Values{"MessageStatus": {"failed"}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.form {
+				req = httptest.NewRequest(http.MethodPost, twilioURL, strings.NewReader(formBody))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				req.Header.Set("X-Twilio-Signature", tt.signature)
+			} else {
+				req = httptest.NewRequest(http.MethodPost, "/webhooks/"+tt.channel+"/delivery", strings.NewReader(jsonBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Signature", tt.signature)
+			}
+			req.SetPathValue("channel", tt.channel)
+			rr := httptest.NewRecorder()
+			tt.handler.Delivery(rr, req)
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status %d, got %d: %s", http.StatusUnauthorized, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	for _, id := range []string{"email-1", "sms-1"} {
+		if stored, _ := repo.GetByID(ctx, "", id); stored.Status != models.StatusSent {
+			t.Errorf("Expected %s to be unchanged, got %s", id, stored.Status)
+		}
+	}
+}
+
+func TestDeliveryTrackingPixel(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewDeliveryHandler(services.NewDeliveryReceiptService(repo))
+	repo.Save(ctx, &models.Notification{ID: "email-1", Channel: models.ChannelEmail, Status: models.StatusSent})
+
+	for _, id := range []string{"email-1", "missing"} {
+		req := httptest.NewRequest(http.MethodGet, "/webhooks/email/delivery?notification_id="+id, nil)
+		req.SetPathValue("channel", "email")
+		rr := httptest.NewRecorder()
+		handler.Delivery(rr, req)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/gif" || !bytes.Equal(rr.Body.Bytes(), trackingPixel) {
+			t.Errorf("Expected tracking pixel for %s, got status %d (%s)", id, rr.Code, rr.Header().Get("Content-Type"))
+		}
+	}
+
+	stored, _ := repo.GetByID(ctx, "", "email-1")
+	if stored.Status != models.StatusRead || stored.ReadAt == nil || stored.DeliveredAt == nil || stored.OpenedAt == nil {
+		t.Errorf("Expected notification to be read and opened, got %s (read at %v, opened at %v)", stored.Status, stored.ReadAt, stored.OpenedAt)
+	}
+}
+
+func TestTrackOpen(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewDeliveryHandler(services.NewDeliveryReceiptService(repo))
+	repo.Save(ctx, &models.Notification{ID: "email-1", Channel: models.ChannelEmail, Status: models.StatusSent})
+
+	var firstOpen *time.Time
+	for _, id := range []string{"email-1", "email-1", "missing"} {
+		req := httptest.NewRequest(http.MethodGet, "/track/open/"+id, nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		handler.TrackOpen(rr, req)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/gif" || !bytes.Equal(rr.Body.Bytes(), trackingPixel) {
+			t.Errorf("Expected tracking pixel for %s, got status %d (%s)", id, rr.Code, rr.Header().Get("Content-Type"))
+		}
+		if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" {
+			t.Errorf("Expected Cache-Control no-store, got %q", cacheControl)
+		}
+		if stored, _ := repo.GetByID(ctx, "", "email-1"); firstOpen == nil {
+			firstOpen = stored.OpenedAt
+		} else if !stored.OpenedAt.Equal(*firstOpen) {
+			t.Errorf("Expected OpenedAt to keep the first open %v, got %v", firstOpen, stored.OpenedAt)
+		}
+	}
+	if firstOpen == nil {
+		t.Error("Expected OpenedAt to be recorded")
+	}
+	if stored, _ := repo.GetByID(ctx, "", "email-1"); stored.Status != models.StatusRead {
+		t.Errorf("Expected status %s, got %s", models.StatusRead, stored.Status)
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec documents the notification API. It is written by hand, so
+// handler changes must be mirrored in it; docs_handler_test.go checks its
+// schemas against the request and response types.
+//
+//go:embed docs/openapi.json
+var openAPISpec []byte
+
+// swaggerUI renders openAPISpec with Swagger UI, which it loads from a CDN.
+//
+//go:embed docs/swagger.html
+var swaggerUI []byte
+
+// OpenAPI serves the OpenAPI 3.0 specification of the API.
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// Docs serves Swagger UI for the specification served by OpenAPI.
+func Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(swaggerUI)
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"notification-service/internal/services"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loadOpenAPISpec fetches the spec through OpenAPI and decodes it.
+func loadOpenAPISpec(t *testing.T) map[string]any {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	OpenAPI(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+	var spec map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+	return spec
+}
+
+// lookup follows a local reference such as "#/components/schemas/Notification".
+func lookup(spec map[string]any, ref string) (map[string]any, bool) {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, false
+	}
+	node := any(spec)
+	for _, key := range strings.Split(path, "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	object, ok := node.(map[string]any)
+	return object, ok
+}
+
+// resolve returns the object node refers to, or node itself.
+func resolve(spec, node map[string]any) map[string]any {
+	if ref, ok := node["$ref"].(string); ok {
+		if target, ok := lookup(spec, ref); ok {
+			return target
+		}
+	}
+	return node
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	if version, _ := spec["openapi"].(string); !strings.HasPrefix(version, "3.0.") {
+		t.Errorf("Expected an OpenAPI 3.0 spec, got version %q", version)
+	}
+	info, _ := spec["info"].(map[string]any)
+	if info["title"] == nil || info["version"] == nil {
+		t.Errorf("Expected info to have a title and version, got %v", info)
+	}
+
+	// Every reference must resolve
+	var walk func(node any)
+	walk = func(node any) {
+		switch node := node.(type) {
+		case map[string]any:
+			if ref, ok := node["$ref"].(string); ok {
+				if _, ok := lookup(spec, ref); !ok {
+					t.Errorf("Unresolved reference %s", ref)
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+
+	paths, _ := spec["paths"].(map[string]any)
+	required := []string{
+		"POST /notifications", "GET /notifications", "GET /notifications/{id}", "DELETE /notifications/{id}",
+		"PATCH /notifications/{id}", "POST /notifications/bulk", "POST /notifications/import", "GET /notifications/export", "GET /stats", "GET /healthz", "GET /readyz",
+	}
+	for _, route := range required {
+		method, path, _ := strings.Cut(route, " ")
+		item, _ := paths[path].(map[string]any)
+		if item[strings.ToLower(method)] == nil {
+			t.Errorf("Expected %s to be documented", route)
+		}
+	}
+
+	pathParam := regexp.MustCompile(`\{([^}]+)\}`)
+	operationIDs := make(map[string]string)
+	for path, item := range paths {
+		for method, value := range item.(map[string]any) {
+			route := strings.ToUpper(method) + " " + path
+			operation, ok := value.(map[string]any)
+			if !ok {
+				t.Errorf("Expected %s to be an operation", route)
+				continue
+			}
+
+			id, _ := operation["operationId"].(string)
+			if id == "" {
+				t.Errorf("Expected %s to have an operationId", route)
+			} else if other, ok := operationIDs[id]; ok {
+				t.Errorf("Expected operationId %s of %s to be unique, also used by %s", id, route, other)
+			}
+			operationIDs[id] = route
+
+			if responses, _ := operation["responses"].(map[string]any); len(responses) == 0 {
+				t.Errorf("Expected %s to document its responses", route)
+			}
+
+			declared := make(map[string]bool)
+			parameters, _ := operation["parameters"].([]any)
+			for _, parameter := range parameters {
+				parameter := resolve(spec, parameter.(map[string]any))
+				if parameter["in"] == "path" {
+					declared[parameter["name"].(string)] = true
+				}
+			}
+			for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+				if !declared[match[1]] {
+					t.Errorf("Expected %s to declare path parameter %s", route, match[1])
+				}
+			}
+		}
+	}
+}
+
+// TestOpenAPISchemasMatchTypes fails when a request or response type gains,
+// loses or renames a field without the spec following.
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	types := map[string]reflect.Type{
+		"APIResponse":                   reflect.TypeFor[APIResponse](),
+		"AttachmentRequest":             reflect.TypeFor[AttachmentRequest](),
+		"SendNotificationRequest":       reflect.TypeFor[SendNotificationRequest](),
+		"RescheduleNotificationRequest": reflect.TypeFor[RescheduleNotificationRequest](),
+		"Notification":                  reflect.TypeFor[models.Notification](),
+		"NotificationAttachment":        reflect.TypeFor[models.NotificationAttachment](),
+		"RecipientStatus":               reflect.TypeFor[models.RecipientStatus](),
+		"NotificationListItem":          reflect.TypeFor[NotificationListItem](),
+		"ListResponse":                  reflect.TypeFor[ListResponse](),
+		"BulkResult":                    reflect.TypeFor[BulkResult](),
+		"BulkAPIResponse":               reflect.TypeFor[BulkAPIResponse](),
+		"ImportRowResult":               reflect.TypeFor[ImportRowResult](),
+		"ImportSummary":                 reflect.TypeFor[ImportSummary](),
+		"BulkImportResponse":            reflect.TypeFor[BulkImportResponse](),
+		"NotificationPreviewResponse":   reflect.TypeFor[NotificationPreviewResponse](),
+		"ChannelPreview":                reflect.TypeFor[services.ChannelPreview](),
+		"NotificationStatusResponse":    reflect.TypeFor[NotificationStatusResponse](),
+		"NotificationEventResponse":     reflect.TypeFor[NotificationEventResponse](),
+		"NotificationEventsResponse":    reflect.TypeFor[NotificationEventsResponse](),
+		"StatsResponse":                 reflect.TypeFor[StatsResponse](),
+		"HealthResponse":                reflect.TypeFor[HealthResponse](),
+	}
+	for name, typ := range types {
+		schema, ok := lookup(spec, "#/components/schemas/"+name)
+		if !ok {
+			t.Errorf("Expected schema %s", name)
+			continue
+		}
+		documented := schemaProperties(spec, schema)
+		fields := jsonFields(typ)
+		slices.Sort(documented)
+		slices.Sort(fields)
+		if !slices.Equal(documented, fields) {
+			t.Errorf("Expected schema %s to have properties %v, got %v", name, fields, documented)
+		}
+	}
+}
+
+// schemaProperties lists the properties of schema, including those of the
+// schemas it combines with allOf.
+func schemaProperties(spec, schema map[string]any) []string {
+	schema = resolve(spec, schema)
+	var names []string
+	properties, _ := schema["properties"].(map[string]any)
+	for name := range properties {
+		names = append(names, name)
+	}
+	parts, _ := schema["allOf"].([]any)
+	for _, part := range parts {
+		names = append(names, schemaProperties(spec, part.(map[string]any))...)
+	}
+	return names
+}
+
+// jsonFields lists the names encoding/json gives the fields of typ,
+// promoting the fields of embedded structs.
+func jsonFields(typ reflect.Type) []string {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	var names []string
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			names = append(names, jsonFields(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestDocs(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Docs(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Expected an HTML page, got %q", ct)
+	}
+	if !strings.Contains(rr.Body.String(), `"/openapi.json"`) {
+		t.Errorf("Expected the page to load /openapi.json, got %s", rr.Body.String())
+	}
+}
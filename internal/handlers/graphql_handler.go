@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"notification-service/internal/logging"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/graphql-go/graphql/language/visitor"
+)
+
+const (
+	// DefaultGraphQLPollInterval is how often status subscriptions check
+	// their notification for changes.
+	DefaultGraphQLPollInterval = time.Second
+	// graphQLWSProtocol is the graphql-transport-ws subprotocol spoken on
+	// GraphQL WebSockets.
+	graphQLWSProtocol = "graphql-transport-ws"
+	// graphQLInitTimeout bounds the wait for connection_init.
+	graphQLInitTimeout = 10 * time.Second
+	// DefaultGraphQLMaxDepth is how deeply operations may nest fields. It
+	// admits the introspection query GraphQL tools send.
+	DefaultGraphQLMaxDepth = 15
+)
+
+// GraphQLHandler serves the GraphQL API, which queries, sends and cancels
+// notifications through the same services and repository as the REST API.
+// Queries and mutations are POSTed as JSON; subscriptions, and any other
+// operation, run over a WebSocket speaking graphql-transport-ws.
+type GraphQLHandler struct {
+	PollInterval time.Duration
+	// MaxDepth is how deeply operations may nest fields; deeper ones fail
+	// validation.
+	MaxDepth int
+
+	notifications *NotificationHandler
+	origins       []string
+	schema        graphql.Schema
+}
+
+func NewGraphQLHandler(notifications *NotificationHandler) *GraphQLHandler {
+	h := &GraphQLHandler{PollInterval: DefaultGraphQLPollInterval, MaxDepth: DefaultGraphQLMaxDepth, notifications: notifications}
+	h.schema = h.newSchema()
+	return h
+}
+
+// WithAllowedOrigins lets browsers on origins open WebSockets, as
+// WebSocketBroadcaster.WithAllowedOrigins does.
+func (h *GraphQLHandler) WithAllowedOrigins(origins []string) {
+	h.origins = origins
+}
+
+// graphQLRequest is a GraphQL request as POSTed over HTTP, or as the payload
+// of a graphql-transport-ws subscribe message.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQL executes a POSTed graphQLRequest, or upgrades a GET to a
+// WebSocket. Errors in the request itself are reported in the response
+// body with a 200 status, as GraphQL clients expect.
+func (h *GraphQLHandler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		h.serveWebSocket(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+		sendJSON(w, http.StatusBadRequest, graphql.Result{Errors: []gqlerrors.FormattedError{{Message: "Invalid request body"}}})
+		return
+	}
+	ctx := context.WithValue(r.Context(), httpRequestContextKey{}, r)
+	sendJSON(w, http.StatusOK, h.execute(ctx, req))
+}
+
+// parse parses and validates req, returning the errors that keep it from
+// running as a result.
+func (h *GraphQLHandler) parse(req graphQLRequest) (*ast.Document, *graphql.Result) {
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return nil, &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	rules := append(slices.Clone(graphql.SpecifiedRules), maxDepthRule(h.MaxDepth))
+	if validation := graphql.ValidateDocument(&h.schema, document, rules); !validation.IsValid {
+		return nil, &graphql.Result{Errors: validation.Errors}
+	}
+	return document, nil
+}
+
+// execute runs the query or mutation in req.
+func (h *GraphQLHandler) execute(ctx context.Context, req graphQLRequest) *graphql.Result {
+	document, failed := h.parse(req)
+	if failed != nil {
+		return failed
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           document,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+}
+
+// subscribe runs the operation in req, returning a result per event of a
+// subscription, or the single result of a query or mutation. Requests that
+// fail before any event, such as invalid ones, yield a result without data.
+func (h *GraphQLHandler) subscribe(ctx context.Context, req graphQLRequest) <-chan *graphql.Result {
+	results := make(chan *graphql.Result, 1)
+	document, failed := h.parse(req)
+	if failed != nil {
+		results <- failed
+		close(results)
+		return results
+	}
+	params := graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           document,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	}
+	if operation := findOperation(document, req.OperationName); operation != nil && operation.Operation == ast.OperationTypeSubscription {
+		return graphql.ExecuteSubscription(params)
+	}
+	results <- graphql.Execute(params)
+	close(results)
+	return results
+}
+
+// findOperation returns the operation named name in document, or its only
+// operation when name is empty.
+func findOperation(document *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil
+			}
+			found = operation
+		} else if operation.Name != nil && operation.Name.Value == name {
+			return operation
+		}
+	}
+	return found
+}
+
+// maxDepthRule rejects operations nesting fields more than limit deep, so
+// that a small request cannot make the server resolve an unbounded tree,
+// such as recursive introspection of types.
+func maxDepthRule(limit int) graphql.ValidationRuleFn {
+	return func(context *graphql.ValidationContext) *graphql.ValidationRuleInstance {
+		return &graphql.ValidationRuleInstance{VisitorOpts: &visitor.VisitorOptions{
+			KindFuncMap: map[string]visitor.NamedVisitFuncs{
+				kinds.OperationDefinition: {
+					Kind: func(p visitor.VisitFuncParams) (string, any) {
+						if operation, ok := p.Node.(*ast.OperationDefinition); ok {
+							depth := selectionDepth(context, operation.SelectionSet, map[string]int{})
+							if depth > limit {
+								context.ReportError(gqlerrors.NewError(
+									fmt.Sprintf("Operation depth %d exceeds the maximum of %d", depth, limit),
+									[]ast.Node{operation}, "", nil, []int{}, nil,
+								))
+							}
+						}
+						return visitor.ActionNoChange, nil
+					},
+				},
+			},
+		}}
+	}
+}
+
+// selectionDepth returns how deeply set nests fields, following fragment
+// spreads. depths memoizes fragments; a fragment being walked is marked -1
+// so that cycles, which validation reports separately, end the walk.
+func selectionDepth(context *graphql.ValidationContext, set *ast.SelectionSet, depths map[string]int) int {
+	if set == nil {
+		return 0
+	}
+	depth := 0
+	for _, selection := range set.Selections {
+		var d int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d = 1 + selectionDepth(context, selection.SelectionSet, depths)
+		case *ast.InlineFragment:
+			d = selectionDepth(context, selection.SelectionSet, depths)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			known, walked := depths[name]
+			fragment := context.Fragment(name)
+			if fragment == nil || (walked && known < 0) {
+				continue
+			}
+			if !walked {
+				depths[name] = -1
+				known = selectionDepth(context, fragment.SelectionSet, depths)
+				depths[name] = known
+			}
+			d = known
+		}
+		depth = max(depth, d)
+	}
+	return depth
+}
+
+// graphQLMessage is a graphql-transport-ws message.
+type graphQLMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func (h *GraphQLHandler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Upgrade answers failed handshakes, such as from disallowed origins
+	ws, err := webSocketUpgrader(h.origins, graphQLWSProtocol).Upgrade(hijacker{w}, r, nil)
+	if err != nil {
+		return
+	}
+	h.session(ws, r)
+}
+
+// session runs the graphql-transport-ws protocol until the client leaves.
+// Protocol errors close the connection.
+func (h *GraphQLHandler) session(ws *websocket.Conn, r *http.Request) {
+	defer ws.Close()
+	log := logging.FromContext(r.Context(), h.notifications.logger)
+	ws.SetReadDeadline(time.Now().Add(graphQLInitTimeout))
+
+	ctx, cancel := context.WithCancel(context.WithValue(r.Context(), httpRequestContextKey{}, r))
+	var (
+		mu            sync.Mutex
+		wg            sync.WaitGroup
+		subscriptions = make(map[string]context.CancelFunc)
+	)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	send := func(message graphQLMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := ws.WriteJSON(message); err != nil {
+			log.Debug("Error writing GraphQL WebSocket message", "error", err)
+		}
+	}
+	payload := func(v any) json.RawMessage {
+		data, _ := json.Marshal(v)
+		return data
+	}
+
+	acknowledged := false
+	for {
+		var message graphQLMessage
+		if err := ws.ReadJSON(&message); err != nil {
+			return
+		}
+
+		switch message.Type {
+		case "connection_init":
+			if acknowledged {
+				return
+			}
+			acknowledged = true
+			ws.SetReadDeadline(time.Time{})
+			send(graphQLMessage{Type: "connection_ack"})
+		case "ping":
+			send(graphQLMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			var req graphQLRequest
+			if !acknowledged || message.ID == "" || json.Unmarshal(message.Payload, &req) != nil {
+				return
+			}
+			mu.Lock()
+			_, exists := subscriptions[message.ID]
+			mu.Unlock()
+			if exists {
+				return
+			}
+
+			subCtx, subCancel := context.WithCancel(ctx)
+			results := h.subscribe(subCtx, req)
+			mu.Lock()
+			subscriptions[message.ID] = subCancel
+			mu.Unlock()
+
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				failed := false
+				for result := range results {
+					if subCtx.Err() != nil {
+						continue
+					}
+					if result.Data == nil && result.HasErrors() {
+						// The operation never started, as when it is invalid
+						failed = true
+						send(graphQLMessage{ID: id, Type: "error", Payload: payload(result.Errors)})
+						continue
+					}
+					send(graphQLMessage{ID: id, Type: "next", Payload: payload(result)})
+				}
+				// Subscriptions the client completed, and failed ones, are
+				// not completed back
+				mu.Lock()
+				_, active := subscriptions[id]
+				delete(subscriptions, id)
+				mu.Unlock()
+				if active && !failed && subCtx.Err() == nil {
+					send(graphQLMessage{ID: id, Type: "complete"})
+				}
+				subCancel()
+			}(message.ID)
+		case "complete":
+			mu.Lock()
+			if subCancel, ok := subscriptions[message.ID]; ok {
+				delete(subscriptions, message.ID)
+				subCancel()
+			}
+			mu.Unlock()
+		default:
+			return
+		}
+	}
+}
+
+// hijacker lets the WebSocket server take over connections whose writer is
+// wrapped by middleware, through http.ResponseController.
+type hijacker struct {
+	http.ResponseWriter
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/testutil"
+)
+
+func newGraphQLTestHandler() (*GraphQLHandler, *repository.MemoryRepository) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	defaultService, _ := factory.GetService(models.ChannelSlack)
+	repo := repository.NewMemoryRepository()
+	scheduler := services.NewSchedulerService(defaultService, repo)
+	return NewGraphQLHandler(NewNotificationHandler(factory, scheduler, repo, &config.Config{})), repo
+}
+
+// postGraphQL executes query with variables and decodes the result's data
+// into data.
+func postGraphQL(t *testing.T, ctx context.Context, handler *GraphQLHandler, query string, variables map[string]any, data any) []gqlerrors.FormattedError {
+	t.Helper()
+	body, _ := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBuffer(body)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+	handler.GraphQL(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var result struct {
+		Data   json.RawMessage            `json:"data"`
+		Errors []gqlerrors.FormattedError `json:"errors"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if data != nil {
+		json.Unmarshal(result.Data, data)
+	}
+	return result.Errors
+}
+
+func TestGraphQLNotifications(t *testing.T) {
+	handler, repo := newGraphQLTestHandler()
+	ctx := context.Background()
+
+	var sent struct {
+		SendNotification struct {
+			ID             string
+			Status         string
+			Channel        string
+			Recipients     []string
+			Metadata       []struct{ Key, Value string }
+			FiresInSeconds *int
+		}
+	}
+	errs := postGraphQL(t, ctx, handler, `mutation Send($input: SendNotificationInput!) {
+		sendNotification(input: $input) { id status channel recipients metadata { key value } firesInSeconds }
+	}`, map[string]any{"input": map[string]any{
+		"title":       "Later",
+		"content":     "Scheduled content",
+		"channel":     "slack",
+		"recipients":  []any{"user1"},
+		"scheduledAt": time.Now().Add(time.Hour).Format(time.RFC3339),
+		"metadata":    []any{map[string]any{"key": "team", "value": "ops"}},
+	}}, &sent)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	notification := sent.SendNotification
+	if notification.ID == "" || notification.Status != "pending" || notification.Channel != "slack" {
+		t.Errorf("Expected a pending slack notification, got %+v", notification)
+	}
+	if len(notification.Metadata) != 1 || notification.Metadata[0].Value != "ops" {
+		t.Errorf("Expected metadata team=ops, got %+v", notification.Metadata)
+	}
+	if notification.FiresInSeconds == nil || *notification.FiresInSeconds <= 0 {
+		t.Errorf("Expected a countdown, got %v", notification.FiresInSeconds)
+	}
+	if stored, err := repo.GetByID(ctx, "", notification.ID); err != nil || stored.Title != "Later" {
+		t.Errorf("Expected the notification to be stored, got %+v (%v)", stored, err)
+	}
+
+	var found struct {
+		Notification *struct{ Title string }
+		Missing      *struct{ Title string }
+	}
+	errs = postGraphQL(t, ctx, handler, `query($id: ID!) {
+		notification(id: $id) { title }
+		missing: notification(id: "does-not-exist") { title }
+	}`, map[string]any{"id": notification.ID}, &found)
+	if len(errs) != 0 || found.Notification == nil || found.Notification.Title != "Later" || found.Missing != nil {
+		t.Errorf("Expected the notification and null, got %+v (%v)", found, errs)
+	}
+
+	repo.Save(ctx, &models.Notification{ID: "sent", Title: "Done", Channel: models.ChannelEmail, Status: models.StatusSent, CreatedAt: time.Now()})
+	var listed struct {
+		Notifications struct {
+			Items      []struct{ ID string }
+			NextCursor *string
+			TotalCount int
+		}
+	}
+	errs = postGraphQL(t, ctx, handler, `{ notifications(filter: {status: sent}, page: {limit: 10}) { items { id } nextCursor totalCount } }`, nil, &listed)
+	if len(errs) != 0 || listed.Notifications.TotalCount != 1 || listed.Notifications.Items[0].ID != "sent" || listed.Notifications.NextCursor != nil {
+		t.Errorf("Expected only the sent notification, got %+v (%v)", listed, errs)
+	}
+	errs = postGraphQL(t, ctx, handler, `{ notifications(page: {limit: 0}) { totalCount } }`, nil, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "invalid limit") {
+		t.Errorf("Expected an invalid limit error, got %v", errs)
+	}
+
+	var cancelled struct{ CancelNotification bool }
+	errs = postGraphQL(t, ctx, handler, `mutation($id: ID!) { cancelNotification(id: $id) }`, map[string]any{"id": notification.ID}, &cancelled)
+	if len(errs) != 0 || !cancelled.CancelNotification {
+		t.Errorf("Expected the notification to be cancelled, got %v", errs)
+	}
+	errs = postGraphQL(t, ctx, handler, `mutation($id: ID!) { cancelNotification(id: $id) }`, map[string]any{"id": notification.ID}, nil)
+	if len(errs) != 1 || errs[0].Message != "Notification has already been sent" {
+		t.Errorf("Expected cancelling twice to fail, got %v", errs)
+	}
+
+	senderOnly := context.WithValue(ctx, claimsContextKey{}, &services.Claims{Roles: []string{models.RoleSender}})
+	errs = postGraphQL(t, senderOnly, handler, `mutation { cancelNotification(id: "sent") }`, nil, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "admin") {
+		t.Errorf("Expected cancelling without the admin role to fail, got %v", errs)
+	}
+	errs = postGraphQL(t, WithActorID(ctx, ActorAPIKey), handler, `mutation { cancelNotification(id: "sent") }`, nil, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "admin") {
+		t.Errorf("Expected cancelling with an API key to fail, got %v", errs)
+	}
+
+	errs = postGraphQL(t, ctx, handler, `mutation { sendNotification(input: {content: "No recipients", channel: "slack"}) { id } }`, nil, nil)
+	if len(errs) != 1 {
+		t.Errorf("Expected an invalid notification to fail, got %v", errs)
+	}
+}
+
+func TestGraphQLInvalidRequests(t *testing.T) {
+	handler, _ := newGraphQLTestHandler()
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+	}{
+		{"Invalid JSON", http.MethodPost, `{`, http.StatusBadRequest},
+		{"Missing query", http.MethodPost, `{}`, http.StatusBadRequest},
+		{"GET without upgrade", http.MethodGet, ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.GraphQL(rr, httptest.NewRequest(tt.method, "/graphql", strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestGraphQLDepthLimit(t *testing.T) {
+	handler, _ := newGraphQLTestHandler()
+	ctx := context.Background()
+
+	var introspection struct {
+		Schema struct {
+			QueryType struct{ Name string }
+		} `json:"__schema"`
+	}
+	if errs := postGraphQL(t, ctx, handler, testutil.IntrospectionQuery, nil, &introspection); len(errs) != 0 || introspection.Schema.QueryType.Name != "Query" {
+		t.Fatalf("Expected the introspection query to run, got %+v (%v)", introspection, errs)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"Fields", `{ __type(name: "Query") { fields { type { fields { type { fields { type { fields { type { fields { type { fields { name } } } } } } } } } } } } }`},
+		{"Fragments", `{ ...Deep } fragment Deep on Query { notifications { items { metadata { ...Entry } } } } fragment Entry on MetadataEntry { key }`},
+	}
+	handler.MaxDepth = 3
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := postGraphQL(t, ctx, handler, tt.query, nil, nil)
+			if len(errs) != 1 || !strings.Contains(errs[0].Message, "exceeds the maximum of 3") {
+				t.Errorf("Expected a depth error, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestGraphQLSubscription(t *testing.T) {
+	handler, repo := newGraphQLTestHandler()
+	handler.PollInterval = 10 * time.Millisecond
+	repo.Save(context.Background(), &models.Notification{ID: "watched", Channel: models.ChannelSlack, Status: models.StatusPending, CreatedAt: time.Now()})
+
+	server := httptest.NewServer(LoggingMiddleware(nil, http.HandlerFunc(handler.GraphQL)))
+	defer server.Close()
+	dialer := websocket.Dialer{Subprotocols: []string{graphQLWSProtocol}}
+	ws, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+	if ws.Subprotocol() != graphQLWSProtocol {
+		t.Errorf("Expected the %s subprotocol, got %q", graphQLWSProtocol, ws.Subprotocol())
+	}
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	receive := func() graphQLMessage {
+		t.Helper()
+		var message graphQLMessage
+		if err := ws.ReadJSON(&message); err != nil {
+			t.Fatalf("Failed to receive: %v", err)
+		}
+		return message
+	}
+	status := func(message graphQLMessage) string {
+		var result struct {
+			Data struct {
+				NotificationStatus struct{ Status string }
+			}
+		}
+		json.Unmarshal(message.Payload, &result)
+		return result.Data.NotificationStatus.Status
+	}
+
+	ws.WriteJSON(graphQLMessage{Type: "connection_init"})
+	if message := receive(); message.Type != "connection_ack" {
+		t.Fatalf("Expected connection_ack, got %s", message.Type)
+	}
+
+	subscribe := func(id, query string) {
+		payload, _ := json.Marshal(graphQLRequest{Query: query})
+		ws.WriteJSON(graphQLMessage{ID: id, Type: "subscribe", Payload: payload})
+	}
+	subscribe("missing", `subscription { notificationStatus(id: "does-not-exist") { status } }`)
+	if message := receive(); message.Type != "error" || message.ID != "missing" {
+		t.Errorf("Expected an error for an unknown notification, got %+v", message)
+	}
+
+	subscribe("1", `subscription { notificationStatus(id: "watched") { status } }`)
+	if message := receive(); message.Type != "next" || status(message) != "pending" {
+		t.Fatalf("Expected the pending status, got %+v", message)
+	}
+	now := time.Now()
+	repo.UpdateStatus(context.Background(), "", "watched", repository.StatusUpdate{Status: models.StatusSent, SentAt: &now})
+	if message := receive(); message.Type != "next" || message.ID != "1" || status(message) != "sent" {
+		t.Fatalf("Expected the sent status, got %+v", message)
+	}
+
+	// Queries run over the socket too, and complete after their result
+	subscribe("2", `{ notification(id: "watched") { status } }`)
+	if message := receive(); message.Type != "next" || message.ID != "2" {
+		t.Errorf("Expected the query result, got %+v", message)
+	}
+	if message := receive(); message.Type != "complete" || message.ID != "2" {
+		t.Errorf("Expected the query to complete, got %+v", message)
+	}
+
+	ws.WriteJSON(graphQLMessage{ID: "1", Type: "complete"})
+	ws.WriteJSON(graphQLMessage{Type: "ping"})
+	if message := receive(); message.Type != "pong" {
+		t.Errorf("Expected pong after completing the subscription, got %+v", message)
+	}
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// timeScalar is an RFC 3339 timestamp.
+var timeScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name: "Time",
+	Serialize: func(value any) any {
+		switch value := value.(type) {
+		case time.Time:
+			return value.Format(time.RFC3339Nano)
+		case *time.Time:
+			return value.Format(time.RFC3339Nano)
+		}
+		return nil
+	},
+	ParseValue: parseTime,
+	ParseLiteral: func(value ast.Value) any {
+		if value, ok := value.(*ast.StringValue); ok {
+			return parseTime(value.Value)
+		}
+		return nil
+	},
+})
+
+// parseTime returns value if it is an RFC 3339 time, and nil otherwise.
+func parseTime(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, s); err != nil {
+		return nil
+	}
+	return s
+}
+
+// jsonScalar is an arbitrary JSON value, such as template data.
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "JSON",
+	Serialize:    func(value any) any { return value },
+	ParseValue:   func(value any) any { return value },
+	ParseLiteral: jsonValue,
+})
+
+// jsonValue converts a GraphQL literal to the value encoding/json would
+// decode it as.
+func jsonValue(value ast.Value) any {
+	switch value := value.(type) {
+	case *ast.StringValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.IntValue:
+		number, _ := strconv.ParseFloat(value.Value, 64)
+		return number
+	case *ast.FloatValue:
+		number, _ := strconv.ParseFloat(value.Value, 64)
+		return number
+	case *ast.ListValue:
+		list := make([]any, len(value.Values))
+		for i, item := range value.Values {
+			list[i] = jsonValue(item)
+		}
+		return list
+	case *ast.ObjectValue:
+		object := make(map[string]any, len(value.Fields))
+		for _, field := range value.Fields {
+			object[field.Name.Value] = jsonValue(field.Value)
+		}
+		return object
+	}
+	return nil
+}
+
+var notificationStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:   "NotificationStatus",
+	Values: notificationStatuses(),
+})
+
+func notificationStatuses() graphql.EnumValueConfigMap {
+	values := graphql.EnumValueConfigMap{}
+	for _, status := range []models.NotificationStatus{
+		models.StatusPending, models.StatusSent, models.StatusFailed,
+		models.StatusCancelled, models.StatusDelivered, models.StatusRead,
+		models.StatusSuppressed, models.StatusExpired, models.StatusMerged,
+	} {
+		values[string(status)] = &graphql.EnumValueConfig{Value: status}
+	}
+	return values
+}
+
+// metadataEntry is a key-value pair of notification metadata, which GraphQL
+// has no map type for.
+var metadataEntry = graphql.NewObject(graphql.ObjectConfig{Name: "MetadataEntry", Fields: graphql.Fields{
+	"key":   {Type: graphql.NewNonNull(graphql.String)},
+	"value": {Type: graphql.NewNonNull(graphql.String)},
+}})
+
+var metadataEntryInput = graphql.NewInputObject(graphql.InputObjectConfig{Name: "MetadataEntryInput", Fields: graphql.InputObjectConfigFieldMap{
+	"key":   {Type: graphql.NewNonNull(graphql.String)},
+	"value": {Type: graphql.NewNonNull(graphql.String)},
+}})
+
+var attachmentInput = graphql.NewInputObject(graphql.InputObjectConfig{Name: "AttachmentInput", Fields: graphql.InputObjectConfigFieldMap{
+	"filename":    {Type: graphql.NewNonNull(graphql.String)},
+	"contentType": {Type: graphql.String},
+	"data":        {Type: graphql.NewNonNull(graphql.String)},
+}})
+
+// sendNotificationInput mirrors SendNotificationRequest, without
+// idempotency_key.
+var sendNotificationInput = graphql.NewInputObject(graphql.InputObjectConfig{Name: "SendNotificationInput", Fields: graphql.InputObjectConfigFieldMap{
+	"title":            {Type: graphql.String},
+	"content":          {Type: graphql.String},
+	"channel":          {Type: graphql.String},
+	"channels":         {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+	"recipients":       {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+	"category":         {Type: graphql.String},
+	"locale":           {Type: graphql.String},
+	"userIds":          {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+	"scheduledAt":      {Type: graphql.String},
+	"timezone":         {Type: graphql.String},
+	"cronExpression":   {Type: graphql.String},
+	"expiresAt":        {Type: graphql.String},
+	"digest":           {Type: graphql.Boolean},
+	"digestKey":        {Type: graphql.String},
+	"priority":         {Type: graphql.Int},
+	"templateName":     {Type: graphql.String},
+	"templateVersion":  {Type: graphql.Int},
+	"templateData":     {Type: jsonScalar},
+	"metadata":         {Type: graphql.NewList(graphql.NewNonNull(metadataEntryInput))},
+	"attachments":      {Type: graphql.NewList(graphql.NewNonNull(attachmentInput))},
+	"callbackUrl":      {Type: graphql.String},
+	"senderAlias":      {Type: graphql.String},
+	"fallbackChannels": {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+}})
+
+var notificationFilter = graphql.NewInputObject(graphql.InputObjectConfig{Name: "NotificationFilter", Fields: graphql.InputObjectConfigFieldMap{
+	"status":    {Type: notificationStatusEnum},
+	"channel":   {Type: graphql.String},
+	"category":  {Type: graphql.String},
+	"recurring": {Type: graphql.Boolean},
+	"q":         {Type: graphql.String},
+}})
+
+var pageInput = graphql.NewInputObject(graphql.InputObjectConfig{Name: "PageInput", Fields: graphql.InputObjectConfigFieldMap{
+	"cursor": {Type: graphql.String},
+	"limit":  {Type: graphql.Int},
+}})
+
+// notificationField resolves a field of a *models.Notification with get.
+func notificationField(t graphql.Output, get func(n *models.Notification) any) *graphql.Field {
+	return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(*models.Notification)), nil
+	}}
+}
+
+// optional returns nil for empty strings, which the API leaves unset.
+func optional[T ~string](value T) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+var notificationType = graphql.NewObject(graphql.ObjectConfig{Name: "Notification", Fields: graphql.Fields{
+	"id":       notificationField(graphql.NewNonNull(graphql.ID), func(n *models.Notification) any { return n.ID }),
+	"tenantId": notificationField(graphql.String, func(n *models.Notification) any { return optional(n.TenantID) }),
+	"title":    notificationField(graphql.NewNonNull(graphql.String), func(n *models.Notification) any { return n.Title }),
+	"content":  notificationField(graphql.NewNonNull(graphql.String), func(n *models.Notification) any { return n.Content }),
+	"channel":  notificationField(graphql.String, func(n *models.Notification) any { return optional(n.Channel) }),
+	"channels": notificationField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), func(n *models.Notification) any {
+		if n.Channels == nil {
+			return []models.NotificationChannel{}
+		}
+		return n.Channels
+	}),
+	"recipients": notificationField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), func(n *models.Notification) any {
+		if n.Recipients == nil {
+			return []string{}
+		}
+		return n.Recipients
+	}),
+	"category":       notificationField(graphql.String, func(n *models.Notification) any { return optional(n.Category) }),
+	"locale":         notificationField(graphql.String, func(n *models.Notification) any { return optional(n.Locale) }),
+	"scheduledAt":    notificationField(timeScalar, func(n *models.Notification) any { return n.ScheduledAt }),
+	"cronExpression": notificationField(graphql.String, func(n *models.Notification) any { return optional(n.CronExpr) }),
+	"expiresAt":      notificationField(timeScalar, func(n *models.Notification) any { return n.ExpiresAt }),
+	"createdAt":      notificationField(graphql.NewNonNull(timeScalar), func(n *models.Notification) any { return n.CreatedAt }),
+	"sentAt":         notificationField(timeScalar, func(n *models.Notification) any { return n.SentAt }),
+	"deliveredAt":    notificationField(timeScalar, func(n *models.Notification) any { return n.DeliveredAt }),
+	"readAt":         notificationField(timeScalar, func(n *models.Notification) any { return n.ReadAt }),
+	"openedAt":       notificationField(timeScalar, func(n *models.Notification) any { return n.OpenedAt }),
+	"status":         notificationField(graphql.NewNonNull(notificationStatusEnum), func(n *models.Notification) any { return n.Status }),
+	"priority":       notificationField(graphql.NewNonNull(graphql.Int), func(n *models.Notification) any { return int(n.Priority) }),
+	"failureReason":  notificationField(graphql.String, func(n *models.Notification) any { return optional(n.FailureReason) }),
+	"callbackUrl":    notificationField(graphql.String, func(n *models.Notification) any { return optional(n.CallbackURL) }),
+	"metadata": notificationField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(metadataEntry))), func(n *models.Notification) any {
+		entries := make([]any, 0, len(n.Metadata))
+		for _, key := range slices.Sorted(maps.Keys(n.Metadata)) {
+			entries = append(entries, map[string]any{"key": key, "value": n.Metadata[key]})
+		}
+		return entries
+	}),
+	// firesInSeconds counts down to the send of a pending scheduled
+	// notification, like fires_in_seconds in GET /notifications
+	"firesInSeconds": notificationField(graphql.Int, func(n *models.Notification) any {
+		firesIn, scheduled := services.FiresIn(n, time.Now())
+		if !scheduled {
+			return nil
+		}
+		return int64((firesIn + time.Second - 1) / time.Second)
+	}),
+}})
+
+var notificationPage = graphql.NewObject(graphql.ObjectConfig{Name: "NotificationPage", Fields: graphql.Fields{
+	"items": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(notificationType))), Resolve: func(p graphql.ResolveParams) (any, error) {
+		return p.Source.(*repository.NotificationPage).Notifications, nil
+	}},
+	"nextCursor": {Type: graphql.String, Resolve: func(p graphql.ResolveParams) (any, error) {
+		return optional(p.Source.(*repository.NotificationPage).NextCursor), nil
+	}},
+	"totalCount": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (any, error) {
+		return p.Source.(*repository.NotificationPage).TotalCount, nil
+	}},
+}})
+
+// newSchema returns the GraphQL schema, resolved with the REST handler's
+// services and repository.
+func (h *GraphQLHandler) newSchema() graphql.Schema {
+	query := graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: graphql.Fields{
+		"notification": {
+			Type:    notificationType,
+			Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: h.resolveNotification,
+		},
+		"notifications": {
+			Type:    graphql.NewNonNull(notificationPage),
+			Args:    graphql.FieldConfigArgument{"filter": {Type: notificationFilter}, "page": {Type: pageInput}},
+			Resolve: h.resolveNotifications,
+		},
+	}})
+	mutation := graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: graphql.Fields{
+		"sendNotification": {
+			Type:    notificationType,
+			Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(sendNotificationInput)}},
+			Resolve: h.resolveSendNotification,
+		},
+		"cancelNotification": {
+			Type:    graphql.NewNonNull(graphql.Boolean),
+			Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: h.resolveCancelNotification,
+		},
+	}})
+	subscription := graphql.NewObject(graphql.ObjectConfig{Name: "Subscription", Fields: graphql.Fields{
+		"notificationStatus": {
+			Type: graphql.NewNonNull(notificationType),
+			Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+			// Each event is the notification itself
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source, nil
+			},
+			Subscribe: h.subscribeNotificationStatus,
+		},
+	}})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation, Subscription: subscription})
+	if err != nil {
+		panic(fmt.Sprintf("invalid GraphQL schema: %v", err))
+	}
+	return schema
+}
+
+func (h *GraphQLHandler) resolveNotification(p graphql.ResolveParams) (any, error) {
+	notification, err := h.notifications.repository.GetByID(p.Context, TenantID(p.Context), p.Args["id"].(string))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification: %w", err)
+	}
+	return notification, nil
+}
+
+func (h *GraphQLHandler) resolveNotifications(p graphql.ResolveParams) (any, error) {
+	opts := repository.ListOptions{TenantID: TenantID(p.Context)}
+	if filter, ok := p.Args["filter"].(map[string]any); ok {
+		channel, _ := filter["channel"].(string)
+		opts.Status, _ = filter["status"].(models.NotificationStatus)
+		opts.Channel = models.NotificationChannel(channel)
+		opts.Category, _ = filter["category"].(string)
+		opts.Recurring, _ = filter["recurring"].(bool)
+		opts.Query, _ = filter["q"].(string)
+	}
+	if page, ok := p.Args["page"].(map[string]any); ok {
+		opts.Cursor, _ = page["cursor"].(string)
+		if limit, ok := page["limit"].(int); ok {
+			if limit < 1 || limit > repository.MaxListLimit {
+				return nil, fmt.Errorf("invalid limit: must be between 1 and %d", repository.MaxListLimit)
+			}
+			opts.Limit = limit
+		}
+	}
+
+	page, err := h.notifications.repository.List(p.Context, opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, errors.New("invalid cursor")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return page, nil
+}
+
+// resolveSendNotification sends, schedules or repeats a notification the
+// way POST /notifications does.
+func (h *GraphQLHandler) resolveSendNotification(p graphql.ResolveParams) (any, error) {
+	req := sendNotificationRequest(p.Args["input"].(map[string]any))
+	notification, reqErr := h.notifications.prepare(p.Context, &req)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+	status, response := h.notifications.dispatch(httpRequest(p.Context), req, notification)
+	if status >= http.StatusBadRequest {
+		return nil, errors.New(response.Message)
+	}
+	return response.Data, nil
+}
+
+// resolveCancelNotification cancels a scheduled notification the way
+// DELETE /notifications/{id} does, which requires the admin role.
+func (h *GraphQLHandler) resolveCancelNotification(p graphql.ResolveParams) (any, error) {
+	if !GrantsRole(p.Context, models.RoleAdmin) {
+		return nil, errors.New("credentials do not grant the " + models.RoleAdmin + " role")
+	}
+	status, response := h.notifications.cancel(p.Context, p.Args["id"].(string))
+	if status != http.StatusOK {
+		return nil, errors.New(response.Message)
+	}
+	return true, nil
+}
+
+// subscribeNotificationStatus sends the notification now and again every
+// time its status changes. The repository is polled, so changes made by
+// other instances are seen too. It stops when the notification is deleted.
+func (h *GraphQLHandler) subscribeNotificationStatus(p graphql.ResolveParams) (any, error) {
+	ctx, tenantID, id := p.Context, TenantID(p.Context), p.Args["id"].(string)
+	notification, err := h.notifications.repository.GetByID(ctx, tenantID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errors.New("notification not found: " + id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification: %w", err)
+	}
+
+	events := make(chan any)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(h.PollInterval)
+		defer ticker.Stop()
+		var last models.NotificationStatus
+		for {
+			if notification.Status != last {
+				last = notification.Status
+				select {
+				case events <- notification:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			next, err := h.notifications.repository.GetByID(ctx, tenantID, id)
+			if errors.Is(err, repository.ErrNotFound) {
+				return
+			}
+			if err != nil {
+				logging.FromContext(ctx, h.notifications.logger).Warn("Error polling notification status", "notification_id", id, "error", err)
+				continue
+			}
+			notification = next
+		}
+	}()
+	return events, nil
+}
+
+// sendNotificationRequest converts a coerced SendNotificationInput.
+func sendNotificationRequest(input map[string]any) SendNotificationRequest {
+	str := func(key string) string {
+		s, _ := input[key].(string)
+		return s
+	}
+	strs := func(key string) []string {
+		var list []string
+		items, _ := input[key].([]any)
+		for _, item := range items {
+			list = append(list, item.(string))
+		}
+		return list
+	}
+
+	req := SendNotificationRequest{
+		Title:          str("title"),
+		Content:        str("content"),
+		Channel:        models.NotificationChannel(str("channel")),
+		Recipients:     strs("recipients"),
+		Category:       str("category"),
+		Locale:         str("locale"),
+		UserIDs:        strs("userIds"),
+		ScheduledAt:    str("scheduledAt"),
+		Timezone:       str("timezone"),
+		CronExpression: str("cronExpression"),
+		ExpiresAt:      str("expiresAt"),
+		DigestKey:      str("digestKey"),
+		TemplateName:   str("templateName"),
+		CallbackURL:    str("callbackUrl"),
+		SenderAlias:    str("senderAlias"),
+	}
+	for _, channel := range strs("channels") {
+		req.Channels = append(req.Channels, models.NotificationChannel(channel))
+	}
+	for _, channel := range strs("fallbackChannels") {
+		req.FallbackChannels = append(req.FallbackChannels, models.NotificationChannel(channel))
+	}
+	req.Digest, _ = input["digest"].(bool)
+	if priority, ok := input["priority"].(int); ok {
+		p := models.NotificationPriority(priority)
+		req.Priority = &p
+	}
+	req.TemplateVersion, _ = input["templateVersion"].(int)
+	if data, ok := input["templateData"].(map[string]any); ok {
+		req.TemplateData = data
+	}
+	if entries, ok := input["metadata"].([]any); ok {
+		req.Metadata = make(map[string]string, len(entries))
+		for _, entry := range entries {
+			entry := entry.(map[string]any)
+			req.Metadata[entry["key"].(string)] = entry["value"].(string)
+		}
+	}
+	if attachments, ok := input["attachments"].([]any); ok {
+		for _, attachment := range attachments {
+			attachment := attachment.(map[string]any)
+			contentType, _ := attachment["contentType"].(string)
+			req.Attachments = append(req.Attachments, AttachmentRequest{
+				Filename:    attachment["filename"].(string),
+				ContentType: contentType,
+				Data:        attachment["data"].(string),
+			})
+		}
+	}
+	return req
+}
+
+type httpRequestContextKey struct{}
+
+// httpRequest returns the request a GraphQL operation arrived in.
+func httpRequest(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(httpRequestContextKey{}).(*http.Request)
+	return r.WithContext(ctx)
+}
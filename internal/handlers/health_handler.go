@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readinessTimeout bounds how long all readiness checks together may take,
+// so a hanging dependency fails the probe instead of stalling it.
+const readinessTimeout = 2 * time.Second
+
+// errShuttingDown fails readiness once the service has begun shutting down.
+var errShuttingDown = errors.New("shutting down")
+
+// HealthCheck reports whether a dependency is usable.
+type HealthCheck func(ctx context.Context) error
+
+// HealthResponse is the body of the health endpoints. Checks maps each
+// readiness check to "ok" or its error; Failing lists the failed ones.
+type HealthResponse struct {
+	Status  string            `json:"status"`
+	Checks  map[string]string `json:"checks,omitempty"`
+	Failing []string          `json:"failing,omitempty"`
+}
+
+// HealthHandler serves liveness and readiness probes.
+type HealthHandler struct {
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+// AddCheck makes readiness depend on check, reported under name. Checks must
+// be added before the handler serves requests.
+func (h *HealthHandler) AddCheck(name string, check HealthCheck) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes readiness fail from now on, so load balancers stop sending
+// traffic while in-flight requests finish.
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Liveness answers 200 for as long as the process can serve requests.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readiness runs every check concurrently and answers 200 if all pass, or
+// 503 listing those that failed.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	errs := make([]error, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	response := HealthResponse{Status: "ok", Checks: make(map[string]string, len(h.checks)+1)}
+	for i, c := range h.checks {
+		response.Checks[c.name] = "ok"
+		if errs[i] != nil {
+			response.Checks[c.name] = errs[i].Error()
+			response.Failing = append(response.Failing, c.name)
+		}
+	}
+	if h.draining.Load() {
+		response.Checks["shutdown"] = errShuttingDown.Error()
+		response.Failing = append(response.Failing, "shutdown")
+	}
+
+	if len(response.Failing) > 0 {
+		response.Status = "unavailable"
+		sendJSON(w, http.StatusServiceUnavailable, response)
+		return
+	}
+	sendJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	databaseErr := error(nil)
+	handler := NewHealthHandler()
+	handler.AddCheck("database", func(ctx context.Context) error { return databaseErr })
+	handler.AddCheck("scheduler", func(ctx context.Context) error { return nil })
+
+	rr := httptest.NewRecorder()
+	handler.Liveness(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != `{"status":"ok"}`+"\n" {
+		t.Errorf("Expected 200 with status ok, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		name            string
+		databaseErr     error
+		drain           bool
+		expectedCode    int
+		expectedFailing []string
+	}{
+		{"All checks pass", nil, false, http.StatusOK, nil},
+		{"Database down", errors.New("connection refused"), false, http.StatusServiceUnavailable, []string{"database"}},
+		{"Shutting down", nil, true, http.StatusServiceUnavailable, []string{"shutdown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			databaseErr = tt.databaseErr
+			if tt.drain {
+				handler.Drain()
+			}
+			rr := httptest.NewRecorder()
+			handler.Readiness(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+
+			var response HealthResponse
+			json.NewDecoder(rr.Body).Decode(&response)
+			if len(response.Failing) != len(tt.expectedFailing) {
+				t.Fatalf("Expected failing checks %v, got %v", tt.expectedFailing, response.Failing)
+			}
+			for i, name := range tt.expectedFailing {
+				if response.Failing[i] != name {
+					t.Errorf("Expected failing checks %v, got %v", tt.expectedFailing, response.Failing)
+				}
+			}
+			if response.Checks["scheduler"] != "ok" {
+				t.Errorf("Expected scheduler check ok, got %q", response.Checks["scheduler"])
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"notification-service/internal/logging"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CorrelationIDHeader carries the ID that ties together the log entries of a
+// request. It is echoed on every response.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// maxCorrelationIDLength bounds client-supplied correlation IDs so they
+// cannot bloat every log entry of a request.
+const maxCorrelationIDLength = 128
+
+// LoggingMiddleware logs every request and its response to logger. Each
+// request keeps the client's X-Correlation-ID or gets a new UUID, which is
+// set on the response and carried in the request's context so that entries
+// logged through logging.FromContext share it.
+func LoggingMiddleware(logger logging.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(CorrelationIDHeader)
+		if id == "" || len(id) > maxCorrelationIDLength {
+			id = uuid.New().String()
+		}
+		ctx := logging.WithCorrelationID(r.Context(), id)
+		w.Header().Set(CorrelationIDHeader, id)
+
+		log := logging.FromContext(ctx, logger)
+		log.Info("HTTP request", "method", r.Method, "path", r.URL.Path, "body_size", max(r.ContentLength, 0))
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		log.Info("HTTP response",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			"bytes_written", recorder.written,
+		)
+	})
+}
+
+// statusRecorder notes the status and size of the response written through
+// it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(p)
+	r.written += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/logging"
+	"strings"
+	"testing"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "info")
+	handler := LoggingMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stands in for a service logging on behalf of the request
+		logging.FromContext(r.Context(), logger).Info("Handling request")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		expectID func(id string) bool
+	}{
+		{"Client correlation ID", "req-123", func(id string) bool { return id == "req-123" }},
+		{"Generated correlation ID", "", func(id string) bool { return len(id) == 36 }},
+		{"Oversized correlation ID", strings.Repeat("x", 200), func(id string) bool { return len(id) == 36 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodPost, "/notifications", strings.NewReader(`{"title":"x"}`))
+			if tt.header != "" {
+				req.Header.Set(CorrelationIDHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(CorrelationIDHeader)
+			if !tt.expectID(id) {
+				t.Fatalf("Unexpected correlation ID %q", id)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 3 {
+				t.Fatalf("Expected 3 entries, got %d: %q", len(lines), buf.String())
+			}
+			var entries []map[string]any
+			for _, line := range lines {
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("Expected JSON entry, got %q", line)
+				}
+				if entry["correlation_id"] != id {
+					t.Errorf("Expected correlation_id %q, got %v", id, entry["correlation_id"])
+				}
+				entries = append(entries, entry)
+			}
+			if entries[0]["method"] != http.MethodPost || entries[0]["path"] != "/notifications" || entries[0]["body_size"] != float64(13) {
+				t.Errorf("Unexpected request entry: %v", entries[0])
+			}
+			if entries[2]["status"] != float64(http.StatusCreated) || entries[2]["bytes_written"] != float64(7) || entries[2]["latency_ms"] == nil {
+				t.Errorf("Unexpected response entry: %v", entries[2])
+			}
+		})
+	}
+}
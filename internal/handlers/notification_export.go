@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportColumns are the CSV columns of an export, named after the
+// Notification fields they hold. Lists are separated by semicolons and maps
+// and recipient outcomes are JSON.
+var exportColumns = []struct {
+	name  string
+	value func(n *models.Notification) string
+}{
+	{"ID", func(n *models.Notification) string { return n.ID }},
+	{"TenantID", func(n *models.Notification) string { return n.TenantID }},
+	{"Title", func(n *models.Notification) string { return n.Title }},
+	{"Content", func(n *models.Notification) string { return n.Content }},
+	{"Channel", func(n *models.Notification) string { return string(n.Channel) }},
+	{"Channels", func(n *models.Notification) string {
+		channels := make([]string, len(n.Channels))
+		for i, channel := range n.Channels {
+			channels[i] = string(channel)
+		}
+		return strings.Join(channels, ";")
+	}},
+	{"Recipients", func(n *models.Notification) string { return strings.Join(n.Recipients, ";") }},
+	{"Category", func(n *models.Notification) string { return n.Category }},
+	{"Locale", func(n *models.Notification) string { return n.Locale }},
+	{"ChannelRecipients", func(n *models.Notification) string { return exportJSON(n.ChannelRecipients) }},
+	{"ScheduledAt", func(n *models.Notification) string { return exportTime(n.ScheduledAt) }},
+	{"CronExpr", func(n *models.Notification) string { return n.CronExpr }},
+	{"ExpiresAt", func(n *models.Notification) string { return exportTime(n.ExpiresAt) }},
+	{"CreatedAt", func(n *models.Notification) string { return exportTime(&n.CreatedAt) }},
+	{"SentAt", func(n *models.Notification) string { return exportTime(n.SentAt) }},
+	{"DeliveredAt", func(n *models.Notification) string { return exportTime(n.DeliveredAt) }},
+	{"ReadAt", func(n *models.Notification) string { return exportTime(n.ReadAt) }},
+	{"OpenedAt", func(n *models.Notification) string { return exportTime(n.OpenedAt) }},
+	{"MergedFrom", func(n *models.Notification) string { return strings.Join(n.MergedFrom, ";") }},
+	{"Status", func(n *models.Notification) string { return string(n.Status) }},
+	{"Priority", func(n *models.Notification) string { return strconv.Itoa(int(n.Priority)) }},
+	{"FailureReason", func(n *models.Notification) string { return n.FailureReason }},
+	{"Metadata", func(n *models.Notification) string { return exportJSON(n.Metadata) }},
+	{"SentMetadata", func(n *models.Notification) string { return exportJSON(n.SentMetadata) }},
+	{"CallbackURL", func(n *models.Notification) string { return n.CallbackURL }},
+	{"RecipientStatuses", func(n *models.Notification) string { return exportJSON(n.RecipientStatuses) }},
+}
+
+func exportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func exportJSON[T any](value T) string {
+	data, _ := json.Marshal(value)
+	if string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
+
+// ExportNotifications streams the caller's notifications created between the
+// optional from and to query parameters, on the optional channel, newest
+// first. format is json (the default), a JSON array of notifications, or
+// csv, one row per notification under a header of exportColumns. The result
+// set is read from the repository a page at a time and each page is flushed
+// to the client before the next is read, so the response is chunked and
+// never held in memory. Each export is recorded in the audit log.
+func (h *NotificationHandler) ExportNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid format: must be json or csv",
+		})
+		return
+	}
+	from, to, message := parseWindow(query)
+	if message != "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+	opts := repository.ListOptions{
+		TenantID: TenantID(r.Context()),
+		Limit:    repository.MaxListLimit,
+		Channel:  models.NotificationChannel(query.Get("channel")),
+		From:     from,
+		To:       to,
+	}
+
+	// The first page is read before anything is written, so a failing
+	// repository still gets an error response
+	page, err := h.repository.List(r.Context(), opts)
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to list notifications: " + err.Error(),
+		})
+		return
+	}
+
+	log := logging.FromContext(r.Context(), h.logger)
+	controller := http.NewResponseController(w)
+	filename := "notifications." + format
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	var writer exportWriter
+	if format == "csv" {
+		writer = newCSVExportWriter(w)
+	} else {
+		writer = newJSONExportWriter(w)
+	}
+	count := 0
+	for {
+		// The server's write timeout would cut a long export short, so each
+		// page gets a fresh one
+		if h.config != nil && h.config.WriteTimeout > 0 {
+			controller.SetWriteDeadline(time.Now().Add(h.config.WriteTimeout))
+		}
+		for _, notification := range page.Notifications {
+			if err = writer.Write(notification); err != nil {
+				break
+			}
+			count++
+		}
+		if err == nil {
+			err = writer.Flush()
+		}
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil || page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+		if page, err = h.repository.List(r.Context(), opts); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		// The status is already sent; an unterminated JSON array or a short
+		// CSV tells the client the export is incomplete
+		log.Error("Error exporting notifications", "format", format, "exported", count, "error", err)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		log.Error("Error exporting notifications", "format", format, "exported", count, "error", err)
+		return
+	}
+
+	if h.audit != nil {
+		event := models.AuditEvent{
+			EventType: models.AuditExported,
+			TenantID:  opts.TenantID,
+			ActorID:   ActorID(r.Context()),
+			Timestamp: time.Now().UTC(),
+			Metadata: map[string]string{
+				"format": format,
+				"count":  strconv.Itoa(count),
+			},
+		}
+		for key, value := range map[string]string{"from": query.Get("from"), "to": query.Get("to"), "channel": string(opts.Channel)} {
+			if value != "" {
+				event.Metadata[key] = value
+			}
+		}
+		if err := h.audit.Append(event); err != nil {
+			log.Error("Error appending audit event", "event_type", event.EventType, "error", err)
+		}
+	}
+}
+
+// exportWriter writes the notifications of an export one at a time.
+type exportWriter interface {
+	Write(notification *models.Notification) error
+	// Flush writes anything buffered so far.
+	Flush() error
+	// Close finishes the export.
+	Close() error
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+	record []string
+	err    error
+}
+
+func newCSVExportWriter(w http.ResponseWriter) *csvExportWriter {
+	writer := &csvExportWriter{writer: csv.NewWriter(w), record: make([]string, len(exportColumns))}
+	for i, column := range exportColumns {
+		writer.record[i] = column.name
+	}
+	writer.err = writer.writer.Write(writer.record)
+	return writer
+}
+
+func (e *csvExportWriter) Write(notification *models.Notification) error {
+	if e.err != nil {
+		return e.err
+	}
+	for i, column := range exportColumns {
+		e.record[i] = column.value(notification)
+	}
+	return e.writer.Write(e.record)
+}
+
+func (e *csvExportWriter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExportWriter) Close() error {
+	return e.Flush()
+}
+
+// jsonExportWriter writes a JSON array an element at a time.
+type jsonExportWriter struct {
+	w       http.ResponseWriter
+	encoder *json.Encoder
+	count   int
+}
+
+func newJSONExportWriter(w http.ResponseWriter) *jsonExportWriter {
+	return &jsonExportWriter{w: w, encoder: json.NewEncoder(w)}
+}
+
+func (e *jsonExportWriter) Write(notification *models.Notification) error {
+	separator := ","
+	if e.count == 0 {
+		separator = "["
+	}
+	if _, err := e.w.Write([]byte(separator)); err != nil {
+		return err
+	}
+	e.count++
+	return e.encoder.Encode(notification)
+}
+
+func (e *jsonExportWriter) Flush() error {
+	return nil
+}
+
+func (e *jsonExportWriter) Close() error {
+	closing := "]\n"
+	if e.count == 0 {
+		closing = "[]\n"
+	}
+	_, err := e.w.Write([]byte(closing))
+	return err
+}
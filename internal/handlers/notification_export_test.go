@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportNotifications(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	audit := services.NewRepositoryAuditLog(repo)
+	handler := NewNotificationHandler(services.NewNotificationServiceFactory(&config.Config{}), &mock.MockSchedulerService{}, repo, &config.Config{})
+	handler.WithAuditLogger(audit)
+
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	// More than a page, so the export has to follow the cursor
+	for i := range repository.MaxListLimit + 5 {
+		channel := models.ChannelSlack
+		if i%2 == 1 {
+			channel = models.ChannelEmail
+		}
+		repo.Save(context.Background(), &models.Notification{
+			ID:         fmt.Sprintf("n-%03d", i),
+			Title:      "Deploy",
+			Content:    "Done, \"quoted\"",
+			Channel:    channel,
+			Recipients: []string{"U1", "U2"},
+			Metadata:   map[string]string{"team": "ops"},
+			Status:     models.StatusSent,
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	export := func(query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/notifications/export?"+query, nil)
+		r = r.WithContext(WithActorID(r.Context(), "alice"))
+		rr := httptest.NewRecorder()
+		handler.ExportNotifications(rr, r)
+		return rr
+	}
+
+	rr := export("")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON export, got %d %q: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+	var notifications []models.Notification
+	if err := json.Unmarshal(rr.Body.Bytes(), &notifications); err != nil {
+		t.Fatalf("Expected a JSON array, got %v", err)
+	}
+	if len(notifications) != repository.MaxListLimit+5 || notifications[0].Metadata["team"] != "ops" {
+		t.Errorf("Expected every notification exported, got %d", len(notifications))
+	}
+
+	rr = export("format=csv&channel=slack&from=" + base.Add(10*time.Minute).Format(time.RFC3339) + "&to=" + base.Add(20*time.Minute).Format(time.RFC3339))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV export, got %d %q: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if len(records) != 6 {
+		t.Fatalf("Expected a header and the 5 slack notifications in the window, got %d records", len(records))
+	}
+	row := map[string]string{}
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	if row["ID"] != "n-018" || row["Recipients"] != "U1;U2" || row["Content"] != `Done, "quoted"` ||
+		row["Metadata"] != `{"team":"ops"}` || row["CreatedAt"] != "2026-10-01T12:18:00Z" || row["SentAt"] != "" {
+		t.Errorf("Unexpected CSV row %v", row)
+	}
+
+	events, _ := audit.Events(context.Background(), "")
+	if len(events) != 2 {
+		t.Fatalf("Expected both exports audited, got %+v", events)
+	}
+	if event := events[1]; event.EventType != models.AuditExported || event.ActorID != "alice" ||
+		event.Metadata["format"] != "csv" || event.Metadata["count"] != "5" || event.Metadata["channel"] != "slack" {
+		t.Errorf("Unexpected audit event %+v", event)
+	}
+
+	for _, query := range []string{"format=xml", "from=yesterday", "from=2026-10-02T00:00:00Z&to=2026-10-01T00:00:00Z"} {
+		if rr := export(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected %q to be rejected, got %d", query, rr.Code)
+		}
+	}
+}
@@ -1,33 +1,193 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"notification-service/internal/config"
+	"notification-service/internal/i18n"
+	"notification-service/internal/logging"
 	"notification-service/internal/models"
+	"notification-service/internal/repository"
 	"notification-service/internal/services"
+	"notification-service/internal/tracing"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type NotificationHandler struct {
 	notificationFactory *services.NotificationServiceFactory
-	schedulerService    *services.SchedulerService
+	fanOutService       *services.FanOutNotificationService
+	schedulerService    services.Scheduler
+	templateService     *services.TemplateService
+	userPreferences     *services.UserPreferenceService
+	digestService       *services.DigestService
+	validator           *services.ValidationService
+	idempotency         services.IdempotencyStore
+	audit               services.AuditLogger
+	callbacks           *services.CallbackService
+	projector           *services.NotificationProjector
+	stats               *services.StatsService
+	events              services.NotificationEventBus
+	emailSanitizer      *models.EmailSanitizer
+	repository          repository.NotificationRepository
+	config              *config.Config
+	logger              logging.Logger
+	tracer              trace.Tracer
 }
 
-func NewNotificationHandler(factory *services.NotificationServiceFactory, scheduler *services.SchedulerService) *NotificationHandler {
+func NewNotificationHandler(factory *services.NotificationServiceFactory, scheduler services.Scheduler, repo repository.NotificationRepository, cfg *config.Config) *NotificationHandler {
+	var idempotencyTTL time.Duration
+	var emailAllowedTags []string
+	if cfg != nil {
+		idempotencyTTL = cfg.IdempotencyTTL
+		emailAllowedTags = cfg.EmailAllowedTags
+	}
 	return &NotificationHandler{
 		notificationFactory: factory,
+		fanOutService:       services.NewFanOutNotificationService(factory),
 		schedulerService:    scheduler,
+		validator:           services.NewValidationService(cfg),
+		idempotency:         services.NewMemoryIdempotencyStore(idempotencyTTL),
+		projector:           services.NewNotificationProjector(repo),
+		stats:               services.NewStatsService(repo),
+		emailSanitizer:      models.NewEmailSanitizer(emailAllowedTags),
+		repository:          repo,
+		config:              cfg,
+		logger:              logging.Default(),
+	}
+}
+
+// WithTemplateService enables template_name in send requests.
+func (h *NotificationHandler) WithTemplateService(templates *services.TemplateService) {
+	h.templateService = templates
+}
+
+// WithUserPreferenceService enables user_ids in send requests.
+func (h *NotificationHandler) WithUserPreferenceService(preferences *services.UserPreferenceService) {
+	h.userPreferences = preferences
+}
+
+// WithDigestService enables digest in send requests.
+func (h *NotificationHandler) WithDigestService(digests *services.DigestService) {
+	h.digestService = digests
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (h *NotificationHandler) WithLogger(logger logging.Logger) {
+	h.logger = logger
+}
+
+// WithTracer traces each call to SendNotification with tracer.
+func (h *NotificationHandler) WithTracer(tracer trace.Tracer) {
+	h.tracer = tracer
+}
+
+// WithAuditLogger records each notification's creation, immediate sends,
+// cancellation and dead-letter retries, attributed to the request's actor.
+func (h *NotificationHandler) WithAuditLogger(audit services.AuditLogger) {
+	h.audit = audit
+}
+
+// WithCallbackService enables callback_url in send requests.
+func (h *NotificationHandler) WithCallbackService(callbacks *services.CallbackService) {
+	h.callbacks = callbacks
+}
+
+// WithEventBus enables GET /notifications/{id}/stream. events must receive
+// the events the repository appends.
+func (h *NotificationHandler) WithEventBus(events services.NotificationEventBus) {
+	h.events = events
+}
+
+// notifyCallback reports the outcome of notification to its callback URL,
+// if it has one.
+func (h *NotificationHandler) notifyCallback(notification *models.Notification) {
+	if h.callbacks != nil {
+		h.callbacks.Notify(notification)
+	}
+}
+
+// recordAudit appends an event for notification on behalf of the actor of
+// ctx. The transition has already happened, so a failure is only logged.
+func (h *NotificationHandler) recordAudit(ctx context.Context, eventType models.AuditEventType, notification *models.Notification, metadata map[string]string) {
+	if h.audit == nil {
+		return
 	}
+	event := services.NewAuditEvent(eventType, notification, ActorID(ctx), metadata)
+	if err := h.audit.Append(event); err != nil {
+		logging.FromContext(ctx, h.logger).Error("Error appending audit event", "notification_id", event.NotificationID, "event_type", event.EventType, "error", err)
+	}
+}
+
+// sendContext derives the per-request context used for outbound sends.
+func (h *NotificationHandler) sendContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if h.config == nil || h.config.NotificationTimeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), h.config.NotificationTimeout)
 }
 
 type SendNotificationRequest struct {
-	Title       string                     `json:"title"`
-	Content     string                     `json:"content"`
-	Channel     models.NotificationChannel `json:"channel"`
-	Recipients  []string                   `json:"recipients"`
-	ScheduledAt string                     `json:"scheduled_at,omitempty"`
+	// TenantID is optional; when set it must match the request's tenant.
+	TenantID    string                       `json:"tenant_id,omitempty"`
+	Title       string                       `json:"title"`
+	Content     string                       `json:"content"`
+	Channel     models.NotificationChannel   `json:"channel,omitempty"`
+	Channels    []models.NotificationChannel `json:"channels,omitempty"`
+	Recipients  []string                     `json:"recipients"`
+	Category    string                       `json:"category,omitempty"`
+	Locale      string                       `json:"locale,omitempty"`
+	UserIDs     []string                     `json:"user_ids,omitempty"`
+	ScheduledAt string                       `json:"scheduled_at,omitempty"`
+	// Timezone is an IANA zone name, e.g. "America/New_York", in which
+	// scheduled_at and expires_at may be given without a UTC offset.
+	Timezone       string `json:"timezone,omitempty"`
+	CronExpression string `json:"cron_expression,omitempty"`
+	ExpiresAt      string `json:"expires_at,omitempty"`
+	// Digest collects the notification into the recipient's digest named
+	// DigestKey instead of sending it immediately.
+	Digest       bool                         `json:"digest,omitempty"`
+	DigestKey    string                       `json:"digest_key,omitempty"`
+	Priority     *models.NotificationPriority `json:"priority,omitempty"`
+	TemplateName string                       `json:"template_name,omitempty"`
+	// TemplateVersion pins the template to one of its versions rather than
+	// the latest.
+	TemplateVersion int                    `json:"template_version,omitempty"`
+	TemplateData    map[string]interface{} `json:"template_data,omitempty"`
+	Metadata        map[string]string      `json:"metadata,omitempty"`
+	Attachments     []AttachmentRequest    `json:"attachments,omitempty"`
+	IdempotencyKey  string                 `json:"idempotency_key,omitempty"`
+	// CallbackURL is sent a DeliveryEvent once the notification has been
+	// sent or has failed.
+	CallbackURL string `json:"callback_url,omitempty"`
+	// SenderAlias is the display name the notification is sent under,
+	// stored in Metadata["sender_alias"].
+	SenderAlias string `json:"sender_alias,omitempty"`
+	// FallbackChannels are tried in order when delivery to Channel fails.
+	FallbackChannels []models.NotificationChannel `json:"fallback_channels,omitempty"`
+}
+
+// AttachmentRequest is a file to attach to an email or Slack notification.
+// Data is base64-encoded; ContentType defaults from the filename's
+// extension.
+type AttachmentRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Data        string `json:"data"`
 }
 
 type APIResponse struct {
@@ -36,6 +196,92 @@ type APIResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// ListResponse is a page of notifications. NextCursor is empty on the last
+// page and TotalCount counts matches across every page.
+type ListResponse struct {
+	Success    bool                   `json:"success"`
+	Message    string                 `json:"message"`
+	Data       []NotificationListItem `json:"data"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+	TotalCount int                    `json:"total_count"`
+}
+
+// NotificationListItem is a notification in a ListResponse. FiresInSeconds
+// counts down to the send of a pending scheduled notification, rounded up so
+// that 0 means it is due; it is null for every other notification.
+type NotificationListItem struct {
+	*models.Notification
+	FiresInSeconds *int64 `json:"fires_in_seconds"`
+}
+
+// newNotificationListItems computes the countdowns of notifications against
+// a single reading of the clock.
+func newNotificationListItems(notifications []*models.Notification, now time.Time) []NotificationListItem {
+	items := make([]NotificationListItem, len(notifications))
+	for i, notification := range notifications {
+		items[i].Notification = notification
+		if firesIn, scheduled := services.FiresIn(notification, now); scheduled {
+			seconds := int64((firesIn + time.Second - 1) / time.Second)
+			items[i].FiresInSeconds = &seconds
+		}
+	}
+	return items
+}
+
+// RequeueResult reports which dead-letter notifications were re-sent.
+type RequeueResult struct {
+	Requeued []string `json:"requeued"`
+	Failed   []string `json:"failed"`
+}
+
+// NotificationStatusResponse is the delivery state of a single notification.
+type NotificationStatusResponse struct {
+	ID            string                    `json:"id"`
+	Status        models.NotificationStatus `json:"status"`
+	SentAt        *time.Time                `json:"sent_at,omitempty"`
+	DeliveredAt   *time.Time                `json:"delivered_at,omitempty"`
+	ReadAt        *time.Time                `json:"read_at,omitempty"`
+	OpenedAt      *time.Time                `json:"opened_at,omitempty"`
+	FailureReason string                    `json:"failure_reason,omitempty"`
+}
+
+// StatsResponse counts notifications by channel and status.
+type StatsResponse struct {
+	Channel map[models.NotificationChannel]int `json:"channel"`
+	Status  map[models.NotificationStatus]int  `json:"status"`
+	Last24h int                                `json:"last_24h"`
+}
+
+// NotificationEventResponse is one entry of a notification's event history.
+type NotificationEventResponse struct {
+	Sequence  int64                        `json:"sequence"`
+	Type      models.NotificationEventType `json:"type"`
+	Payload   json.RawMessage              `json:"payload"`
+	Timestamp time.Time                    `json:"timestamp"`
+}
+
+// NotificationEventsResponse lists the event history of a notification with
+// the state derived by replaying it.
+type NotificationEventsResponse struct {
+	Events  []NotificationEventResponse `json:"events"`
+	State   NotificationStatusResponse  `json:"state"`
+	Clicks  int                         `json:"clicks"`
+	Deleted bool                        `json:"deleted"`
+	Version int64                       `json:"version"`
+}
+
+func newNotificationStatusResponse(notification *models.Notification) NotificationStatusResponse {
+	return NotificationStatusResponse{
+		ID:            notification.ID,
+		Status:        notification.Status,
+		SentAt:        notification.SentAt,
+		DeliveredAt:   notification.DeliveredAt,
+		ReadAt:        notification.ReadAt,
+		OpenedAt:      notification.OpenedAt,
+		FailureReason: notification.FailureReason,
+	}
+}
+
 func generateID() string {
 	return uuid.New().String()
 }
@@ -57,102 +303,1336 @@ func (h *NotificationHandler) SendNotification(w http.ResponseWriter, r *http.Re
 		})
 		return
 	}
+	ctx, span := tracing.Start(r.Context(), h.tracer, "NotificationHandler.SendNotification")
+	defer span.End()
+	r = r.WithContext(ctx)
 
-	// Validate required fields
-	if req.Title == "" || req.Content == "" {
+	if req.IdempotencyKey == "" {
+		h.send(w, r, req)
+		return
+	}
+
+	// Keys are only unique within a tenant
+	key := TenantID(r.Context()) + "/" + req.IdempotencyKey
+	record, ok := h.idempotency.Begin(key)
+	if !ok {
+		sendJSONResponse(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "A request with this idempotency key is already in progress",
+		})
+		return
+	}
+	if record != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Idempotency-Replayed", "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+		return
+	}
+
+	// Only successful responses are replayed so failed requests can be retried
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.send(recorder, r, req)
+	if recorder.status == http.StatusOK || recorder.status == http.StatusAccepted {
+		h.idempotency.Complete(key, services.IdempotencyRecord{
+			StatusCode: recorder.status,
+			Body:       recorder.body.Bytes(),
+			CreatedAt:  time.Now(),
+		})
+	} else {
+		h.idempotency.Release(key)
+	}
+}
+
+// NotificationPreviewResponse is a notification rendered for each of its
+// channels without being sent.
+type NotificationPreviewResponse struct {
+	Title    string                     `json:"title"`
+	Content  string                     `json:"content"`
+	Channels []*services.ChannelPreview `json:"channels"`
+}
+
+// PreviewNotification takes the same body as SendNotification, renders its
+// template and formats it for each of its channels, and returns the result
+// without storing or sending anything.
+func (h *NotificationHandler) PreviewNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req SendNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+	notification, reqErr := h.prepare(r.Context(), &req)
+	if reqErr != nil {
 		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
 			Success: false,
-			Message: "Title and content are required",
+			Message: reqErr.Error(),
+			Data:    reqErr.data,
+		})
+		return
+	}
+
+	channels := notification.Channels
+	if len(channels) == 0 && notification.Status != models.StatusSuppressed {
+		channels = []models.NotificationChannel{notification.Channel}
+	}
+	response := NotificationPreviewResponse{
+		Title:    notification.Title,
+		Content:  notification.Content,
+		Channels: make([]*services.ChannelPreview, 0, len(channels)),
+	}
+	factory := h.notificationFactory.ForTenant(notification.TenantID)
+	for _, channel := range channels {
+		preview, err := factory.Preview(r.Context(), notification, channel)
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to preview %s notification: %v", channel, err),
+			})
+			return
+		}
+		response.Channels = append(response.Channels, preview)
+	}
+
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Notification preview rendered successfully",
+		Data:    response,
+	})
+}
+
+// BulkResult is the outcome of one notification in a bulk request. Index is
+// its position in the request.
+type BulkResult struct {
+	Index          int    `json:"index"`
+	NotificationID string `json:"notification_id,omitempty"`
+	Success        bool   `json:"success"`
+	Error          string `json:"error,omitempty"`
+}
+
+// BulkAPIResponse reports a bulk request. Success is set only when every
+// notification succeeded.
+type BulkAPIResponse struct {
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Results []BulkResult `json:"results"`
+}
+
+// SendBulkNotifications takes a JSON array of notification requests. Every
+// entry is validated before any is sent; if one is invalid none are sent and
+// the results list the invalid entries. Otherwise the entries are sent,
+// scheduled or repeated concurrently and the results list every entry in
+// request order.
+func (h *NotificationHandler) SendBulkNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
 		})
 		return
 	}
 
-	if len(req.Recipients) == 0 {
+	var requests []SendNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
 		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
 			Success: false,
-			Message: "At least one recipient is required",
+			Message: "Invalid request body",
+		})
+		return
+	}
+	if len(requests) == 0 {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "At least one notification is required",
+		})
+		return
+	}
+	if h.config != nil && h.config.BulkMaxNotifications > 0 && len(requests) > h.config.BulkMaxNotifications {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Too many notifications: a bulk request holds at most %d", h.config.BulkMaxNotifications),
+		})
+		return
+	}
+
+	notifications := make([]*models.Notification, len(requests))
+	var invalid []BulkResult
+	for i := range requests {
+		var err *requestError
+		if requests[i].IdempotencyKey != "" {
+			err = &requestError{message: "idempotency_key is not supported in bulk requests"}
+		} else {
+			notifications[i], err = h.prepare(r.Context(), &requests[i])
+		}
+		if err != nil {
+			invalid = append(invalid, BulkResult{Index: i, Error: err.Error()})
+		}
+	}
+	if len(invalid) > 0 {
+		sendJSON(w, http.StatusBadRequest, BulkAPIResponse{
+			Success: false,
+			Message: fmt.Sprintf("%d of %d notifications are invalid; none were sent", len(invalid), len(requests)),
+			Results: invalid,
 		})
 		return
 	}
 
-	// Get the service for the requested channel
-	service, err := h.notificationFactory.GetService(req.Channel)
+	workers := 1
+	if h.config != nil && h.config.BulkWorkers > 1 {
+		workers = min(h.config.BulkWorkers, len(requests))
+	}
+	results := make([]BulkResult, len(requests))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				status, response := h.dispatch(r, requests[i], notifications[i])
+				results[i] = BulkResult{Index: i, NotificationID: notifications[i].ID, Success: response.Success}
+				if status >= http.StatusBadRequest {
+					results[i].Error = response.Message
+				}
+			}
+		}()
+	}
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+		}
+	}
+	sendJSON(w, http.StatusOK, BulkAPIResponse{
+		Success: failed == 0,
+		Message: fmt.Sprintf("Processed %d notifications, %d failed", len(requests), failed),
+		Results: results,
+	})
+}
+
+// send validates req and sends, schedules or repeats the notification it
+// describes.
+func (h *NotificationHandler) send(w http.ResponseWriter, r *http.Request, req SendNotificationRequest) {
+	notification, err := h.prepare(r.Context(), &req)
 	if err != nil {
 		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
 			Success: false,
-			Message: "Invalid notification channel: " + err.Error(),
+			Message: err.Error(),
+			Data:    err.data,
 		})
 		return
 	}
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(tracing.NotificationAttributes(notification)...)
+	status, response := h.dispatch(r, req, notification)
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, response.Message)
+	}
+	sendJSONResponse(w, status, response)
+}
+
+// requestError is a SendNotificationRequest that cannot be sent as given.
+// Data, when set, details the problem in the response.
+type requestError struct {
+	message string
+	data    interface{}
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// prepare validates req and builds the pending notification it describes.
+// It fills in req's title and content from its template and its channels and
+// recipients from its user IDs.
+func (h *NotificationHandler) prepare(ctx context.Context, req *SendNotificationRequest) (*models.Notification, *requestError) {
+	tenantID := TenantID(ctx)
+	if req.TenantID != "" && req.TenantID != tenantID {
+		return nil, &requestError{message: "tenant_id does not match the request's tenant"}
+	}
+
+	locale := ""
+	if req.Locale != "" {
+		var ok bool
+		if locale, ok = i18n.NormalizeLocale(req.Locale); !ok {
+			return nil, &requestError{message: "Invalid locale: " + req.Locale}
+		}
+	}
+
+	// Render title and content from a stored template
+	if req.TemplateVersion != 0 && (req.TemplateName == "" || req.TemplateVersion < 0) {
+		return nil, &requestError{message: "template_version must be a positive version of template_name"}
+	}
+	if req.TemplateName != "" {
+		if h.templateService == nil {
+			return nil, &requestError{message: "Templates are not enabled"}
+		}
+		var title, content string
+		var err error
+		if req.TemplateVersion > 0 {
+			title, content, err = h.templateService.RenderVersion(ctx, req.TemplateName, req.TemplateVersion, locale, req.TemplateData)
+		} else {
+			title, content, err = h.templateService.RenderLocalized(ctx, req.TemplateName, locale, req.TemplateData)
+		}
+		if err != nil {
+			message := "Failed to render template: " + err.Error()
+			if errors.Is(err, repository.ErrTemplateNotFound) {
+				message = "Unknown template: " + req.TemplateName
+				if req.TemplateVersion > 0 {
+					message += " version " + strconv.Itoa(req.TemplateVersion)
+				}
+			}
+			return nil, &requestError{message: message}
+		}
+		req.Title, req.Content = title, content
+	}
+
+	// Validate required fields
+	if req.Title == "" || req.Content == "" {
+		return nil, &requestError{message: "Title and content are required"}
+	}
+
+	// Route each user to their preferred channel, skipping users who have
+	// opted out of the category
+	var channelRecipients map[models.NotificationChannel][]string
+	suppressed := false
+	if len(req.UserIDs) > 0 {
+		if len(req.Recipients) > 0 || req.Channel != "" || len(req.Channels) > 0 {
+			return nil, &requestError{message: "user_ids cannot be combined with recipients, channel or channels"}
+		}
+		if h.userPreferences == nil {
+			return nil, &requestError{message: "User preferences are not enabled"}
+		}
+		routes, unsubscribed, err := h.userPreferences.Resolve(ctx, req.UserIDs, req.Category)
+		if err != nil {
+			return nil, &requestError{message: "Failed to resolve user_ids: " + err.Error()}
+		}
+		if len(unsubscribed) > 0 {
+			logging.FromContext(ctx, h.logger).Info("Suppressed delivery to unsubscribed users", "user_ids", unsubscribed, "category", req.Category)
+		}
+		suppressed = len(routes) == 0
+		channelRecipients = routes
+		for channel, addresses := range routes {
+			req.Channels = append(req.Channels, channel)
+			req.Recipients = append(req.Recipients, addresses...)
+		}
+		sort.Slice(req.Channels, func(i, j int) bool { return req.Channels[i] < req.Channels[j] })
+	}
+
+	if len(req.Recipients) == 0 && !suppressed {
+		return nil, &requestError{message: "At least one recipient is required"}
+	}
+
+	if req.Channel != "" && len(req.Channels) > 0 {
+		return nil, &requestError{message: "Specify either channel or channels, not both"}
+	}
+
+	// Check every requested channel has a service
+	targets := req.Channels
+	if len(targets) == 0 && !suppressed {
+		targets = []models.NotificationChannel{req.Channel}
+	}
+	for _, channel := range targets {
+		if _, err := h.notificationFactory.GetService(channel); err != nil {
+			return nil, &requestError{message: "Invalid notification channel: " + err.Error()}
+		}
+	}
+
+	if len(req.FallbackChannels) > 0 {
+		var message string
+		switch {
+		case len(req.Channels) > 0 || len(req.UserIDs) > 0:
+			message = "fallback_channels cannot be combined with channels or user_ids"
+		case req.Digest:
+			message = "fallback_channels cannot be combined with digest"
+		case containsChannel(req.FallbackChannels, req.Channel):
+			message = "fallback_channels must not include channel"
+		}
+		for i, channel := range req.FallbackChannels {
+			if containsChannel(req.FallbackChannels[:i], channel) {
+				message = "fallback_channels must not repeat a channel"
+			}
+		}
+		if message != "" {
+			return nil, &requestError{message: message}
+		}
+		// The chain is built the way it will be when the notification is sent
+		chain := append([]models.NotificationChannel{req.Channel}, req.FallbackChannels...)
+		if _, err := h.notificationFactory.Fallback(chain); err != nil {
+			return nil, &requestError{message: "Invalid fallback_channels: " + err.Error()}
+		}
+		targets = chain
+		if req.Metadata == nil {
+			req.Metadata = make(map[string]string)
+		}
+		req.Metadata[services.FallbackChannelsMetadataKey] = services.FormatFallbackChannels(req.FallbackChannels)
+	}
+
+	// WhatsApp, and SMS sent through Vonage or SNS, go to phone numbers
+	phoneChannels := map[models.NotificationChannel]string{models.ChannelWhatsApp: "WhatsApp"}
+	if h.config != nil && (h.config.SMSProvider == "vonage" || h.config.SMSProvider == "sns") {
+		phoneChannels[models.ChannelMessage] = "SMS"
+	}
+	for _, channel := range targets {
+		name, ok := phoneChannels[channel]
+		if !ok {
+			continue
+		}
+		recipients := req.Recipients
+		if channelRecipients != nil {
+			recipients = channelRecipients[channel]
+		}
+		for _, recipient := range recipients {
+			if (services.SMSValidator{}).ValidateRecipient(recipient) != nil {
+				return nil, &requestError{message: "Invalid recipient " + recipient + ": " + name + " recipients must be E.164 phone numbers"}
+			}
+		}
+	}
+
+	var attachments []models.NotificationAttachment
+	if len(req.Attachments) > 0 {
+		if !containsChannel(targets, models.ChannelEmail) && !containsChannel(targets, models.ChannelSlack) {
+			return nil, &requestError{message: "Attachments are only supported on the email and Slack channels"}
+		}
+		var err error
+		if attachments, err = h.decodeAttachments(req.Attachments); err != nil {
+			return nil, &requestError{message: "Invalid attachments: " + err.Error()}
+		}
+	}
+
+	// Times without an offset are local to the requested timezone
+	var location *time.Location
+	if req.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(req.Timezone); err != nil || req.Timezone == "Local" {
+			return nil, &requestError{message: "Unknown timezone: " + req.Timezone + ". Use an IANA time zone name (e.g., America/New_York)"}
+		}
+		if req.Metadata == nil {
+			req.Metadata = make(map[string]string)
+		}
+		req.Metadata["timezone"] = req.Timezone
+	}
+
+	if req.SenderAlias != "" {
+		if err := services.ValidateSenderAlias(req.SenderAlias); err != nil {
+			return nil, &requestError{message: "Invalid sender_alias: " + err.Error()}
+		}
+		if req.Metadata == nil {
+			req.Metadata = make(map[string]string)
+		}
+		req.Metadata[services.SenderAliasMetadataKey] = req.SenderAlias
+	}
 
 	// Parse scheduled time if provided
 	var scheduledTime *time.Time
 	if req.ScheduledAt != "" {
-		parsedTime, err := time.Parse(time.RFC3339, req.ScheduledAt)
+		parsedTime, err := parseRequestTime(req.ScheduledAt, location)
 		if err != nil {
-			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Invalid scheduled_at time format. Use RFC3339 format (e.g., 2024-03-31T21:20:00Z)",
-			})
-			return
+			return nil, &requestError{message: "Invalid scheduled_at time format. " + timeFormatHint(location)}
 		}
 		if parsedTime.Before(time.Now()) {
-			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Scheduled time must be in the future",
-			})
-			return
+			return nil, &requestError{message: "Scheduled time must be in the future"}
 		}
 		scheduledTime = &parsedTime
 	}
 
-	// Create notification
-	notification := &models.Notification{
-		ID:          generateID(),
-		Title:       req.Title,
-		Content:     req.Content,
-		Channel:     req.Channel,
-		Recipients:  req.Recipients,
-		ScheduledAt: scheduledTime,
-		CreatedAt:   time.Now(),
+	if req.CronExpression != "" {
+		if scheduledTime != nil {
+			return nil, &requestError{message: "scheduled_at and cron_expression cannot be combined"}
+		}
+		if err := services.ValidateCronExpression(req.CronExpression); err != nil {
+			return nil, &requestError{message: "Invalid cron_expression: " + err.Error()}
+		}
 	}
 
-	// Handle scheduled vs immediate notifications
-	if scheduledTime != nil {
-		if err := h.schedulerService.ScheduleNotification(notification); err != nil {
-			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Failed to schedule notification: " + err.Error(),
-			})
-			return
+	if req.Digest {
+		var message string
+		switch {
+		case h.digestService == nil:
+			message = "Digests are not enabled"
+		case req.DigestKey == "":
+			message = "digest_key is required for digest notifications"
+		case len(req.Channels) > 0:
+			message = "Digest notifications must target a single channel"
+		case scheduledTime != nil || req.CronExpression != "":
+			message = "digest cannot be combined with scheduled_at or cron_expression"
+		}
+		if message != "" {
+			return nil, &requestError{message: message}
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		parsedTime, err := parseRequestTime(req.ExpiresAt, location)
+		if err != nil {
+			return nil, &requestError{message: "Invalid expires_at time format. " + timeFormatHint(location)}
+		}
+		if !parsedTime.After(time.Now()) || (scheduledTime != nil && !parsedTime.After(*scheduledTime)) {
+			return nil, &requestError{message: "expires_at must be in the future and after scheduled_at"}
 		}
+		expiresAt = &parsedTime
+	}
 
-		sendJSONResponse(w, http.StatusAccepted, APIResponse{
-			Success: true,
-			Message: "Notification scheduled successfully",
-			Data:    notification,
-		})
-		return
+	// Priority defaults to normal when omitted
+	priority := models.PriorityNormal
+	if req.Priority != nil {
+		if !req.Priority.Valid() {
+			return nil, &requestError{message: "Invalid priority: must be 0 (low), 1 (normal), 2 (high) or 3 (critical)"}
+		}
+		priority = *req.Priority
 	}
 
-	// Send immediate notification
-	if err := service.Send(notification); err != nil {
-		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Message: "Failed to send notification: " + err.Error(),
-		})
-		return
+	if req.CallbackURL != "" {
+		if h.callbacks == nil {
+			return nil, &requestError{message: "Callbacks are not enabled"}
+		}
+		if u, err := url.Parse(req.CallbackURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, &requestError{message: "Invalid callback_url: must be an http or https URL"}
+		}
 	}
 
-	sendJSONResponse(w, http.StatusOK, APIResponse{
-		Success: true,
-		Message: "Notification sent successfully",
-		Data:    notification,
-	})
+	for _, channel := range targets {
+		req.Content = h.sanitize(channel, req.Content, req.Metadata)
+	}
+
+	// Create notification
+	notification := &models.Notification{
+		ID:                generateID(),
+		TenantID:          tenantID,
+		Title:             req.Title,
+		Content:           req.Content,
+		Channel:           req.Channel,
+		Channels:          req.Channels,
+		Recipients:        req.Recipients,
+		Category:          req.Category,
+		Locale:            locale,
+		ChannelRecipients: channelRecipients,
+		ScheduledAt:       scheduledTime,
+		CronExpr:          req.CronExpression,
+		ExpiresAt:         expiresAt,
+		CreatedAt:         time.Now(),
+		Status:            models.StatusPending,
+		Priority:          priority,
+		Metadata:          req.Metadata,
+		Attachments:       attachments,
+		CallbackURL:       req.CallbackURL,
+	}
+	if suppressed {
+		notification.Status = models.StatusSuppressed
+	}
+
+	if err := h.validator.ValidateNotification(notification); err != nil {
+		return nil, &requestError{message: err.Error(), data: err}
+	}
+
+	return notification, nil
 }
 
-func sendJSONResponse(w http.ResponseWriter, status int, response APIResponse) {
+// sanitize strips the HTML content may not contain on channel: tags other
+// than formatting and safe links for push notifications, tags off the
+// allowlist for HTML emails, and every tag for Slack, SMS and SNS. A fan-out's
+// content is sanitized for each of its channels in turn.
+func (h *NotificationHandler) sanitize(channel models.NotificationChannel, content string, metadata map[string]string) string {
+	switch channel {
+	case models.ChannelFCM, models.ChannelAPNs:
+		return models.SanitizeContent(content)
+	case models.ChannelEmail:
+		// Plain-text emails are never rendered as HTML
+		if metadata[services.EmailContentTypeMetadataKey] == "text/html" {
+			return h.emailSanitizer.Sanitize(content)
+		}
+	case models.ChannelSlack, models.ChannelMessage, models.ChannelSNS:
+		return models.StripHTML(content)
+	}
+	return content
+}
+
+// dispatch stores notification, prepared from req, then sends, schedules or
+// repeats it. It returns the response status and body.
+func (h *NotificationHandler) dispatch(r *http.Request, req SendNotificationRequest, notification *models.Notification) (int, APIResponse) {
+	// Persist before handing off so the notification survives restarts
+	if err := h.repository.Save(r.Context(), notification); err != nil {
+		return http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to store notification: " + err.Error(),
+		}
+	}
+	h.recordAudit(r.Context(), models.AuditCreated, notification, map[string]string{"status": string(notification.Status)})
+
+	if notification.Status == models.StatusSuppressed {
+		h.notifyCallback(notification)
+		return http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Notification suppressed: every user has unsubscribed from category " + req.Category,
+			Data:    notification,
+		}
+	}
+
+	if req.Digest {
+		if err := h.digestService.Add(notification, req.DigestKey); err != nil {
+			return http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to add notification to digest: " + err.Error(),
+			}
+		}
+
+		return http.StatusAccepted, APIResponse{
+			Success: true,
+			Message: "Notification added to digest " + req.DigestKey,
+			Data:    notification,
+		}
+	}
+
+	if req.CronExpression != "" {
+		if err := h.schedulerService.ScheduleRecurring(notification, req.CronExpression); err != nil {
+			return http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to schedule recurring notification: " + err.Error(),
+			}
+		}
+
+		return http.StatusAccepted, APIResponse{
+			Success: true,
+			Message: "Recurring notification scheduled successfully",
+			Data:    notification,
+		}
+	}
+
+	// Handle scheduled vs immediate notifications
+	if notification.ScheduledAt != nil {
+		if err := h.schedulerService.ScheduleNotification(r.Context(), notification); err != nil {
+			return http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to schedule notification: " + err.Error(),
+			}
+		}
+
+		return http.StatusAccepted, APIResponse{
+			Success: true,
+			Message: "Notification scheduled successfully",
+			Data:    notification,
+		}
+	}
+
+	// Send immediate notification; truncated content is still delivered
+	ctx, cancel := h.sendContext(r)
+	defer cancel()
+	err := h.fanOutService.Send(ctx, notification)
+	if services.DeliverySuppressed(err) {
+		notification.Status = models.StatusSuppressed
+		logging.FromContext(ctx, h.logger).Info("Suppressed notification to unsubscribed recipients", logging.NotificationAttrs(notification)...)
+		h.updateStatus(r.Context(), notification, repository.StatusUpdate{Status: models.StatusSuppressed})
+		h.recordAudit(r.Context(), models.AuditSuppressed, notification, nil)
+		h.notifyCallback(notification)
+		return http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Notification suppressed: every recipient has unsubscribed",
+			Data:    notification,
+		}
+	}
+	if services.DeliveryFailed(err) {
+		notification.Status = models.StatusFailed
+		notification.FailureReason = err.Error()
+		logging.FromContext(ctx, h.logger).Error("Error sending notification", logging.NotificationAttrs(notification, "error", err)...)
+		h.updateStatus(r.Context(), notification, repository.StatusUpdate{Status: models.StatusFailed, FailureReason: err.Error(), RecipientStatuses: notification.RecipientStatuses})
+		h.recordAudit(r.Context(), models.AuditFailed, notification, map[string]string{"reason": err.Error()})
+		h.notifyCallback(notification)
+		response := APIResponse{
+			Success: false,
+			Message: "Failed to send notification: " + err.Error(),
+		}
+		var fanOutErr *services.FanOutError
+		if errors.As(err, &fanOutErr) {
+			failed := make(map[models.NotificationChannel]string, len(fanOutErr.Errors))
+			for channel, channelErr := range fanOutErr.Errors {
+				failed[channel] = channelErr.Error()
+			}
+			response.Data = failed
+		}
+		return http.StatusInternalServerError, response
+	}
+
+	sentAt := time.Now()
+	notification.SentAt = &sentAt
+	notification.Status = models.StatusSent
+	logging.FromContext(ctx, h.logger).Info("Sent notification", logging.NotificationAttrs(notification)...)
+	h.updateStatus(r.Context(), notification, repository.StatusUpdate{Status: models.StatusSent, SentAt: &sentAt, RecipientStatuses: notification.RecipientStatuses, Metadata: notification.Metadata})
+	h.recordAudit(r.Context(), models.AuditSent, notification, nil)
+	h.notifyCallback(notification)
+
+	message := "Notification sent successfully"
+	var attachmentErr *services.SlackAttachmentError
+	if errors.As(err, &attachmentErr) {
+		message = "Notification sent, but " + attachmentErr.Error()
+	}
+	return http.StatusOK, APIResponse{
+		Success: true,
+		Message: message,
+		Data:    notification,
+	}
+}
+
+// responseRecorder copies the response written through it so it can be
+// stored for idempotent replay.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// localTimeLayout is a timestamp without a UTC offset, accepted alongside
+// RFC3339 when the request names a timezone.
+const localTimeLayout = "2006-01-02T15:04:05"
+
+// parseRequestTime parses an RFC3339 timestamp or, when location is set, a
+// local timestamp in that location. The result is in UTC.
+func parseRequestTime(value string, location *time.Location) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil && location != nil {
+		parsed, err = time.ParseInLocation(localTimeLayout, value, location)
+	}
+	return parsed.UTC(), err
+}
+
+func timeFormatHint(location *time.Location) string {
+	if location != nil {
+		return "Use RFC3339 format or a local time without offset (e.g., 2024-03-31T21:20:00)"
+	}
+	return "Use RFC3339 format (e.g., 2024-03-31T21:20:00Z)"
+}
+
+// decodeAttachments decodes base64 attachment data, rejecting attachments
+// whose total size exceeds the configured limit.
+func (h *NotificationHandler) decodeAttachments(requests []AttachmentRequest) ([]models.NotificationAttachment, error) {
+	limit := 0
+	if h.config != nil {
+		limit = h.config.MaxAttachmentBytes
+	}
+
+	attachments := make([]models.NotificationAttachment, len(requests))
+	total := 0
+	for i, attachment := range requests {
+		if attachment.Filename == "" {
+			return nil, errors.New("filename is required")
+		}
+		data, err := base64.StdEncoding.DecodeString(attachment.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not base64-encoded", attachment.Filename)
+		}
+		total += len(data)
+		if limit > 0 && total > limit {
+			return nil, fmt.Errorf("total size exceeds the limit of %d bytes", limit)
+		}
+
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+		}
+		attachments[i] = models.NotificationAttachment{Filename: attachment.Filename, ContentType: contentType, Data: data}
+	}
+	return attachments, nil
+}
+
+func containsChannel(channels []models.NotificationChannel, channel models.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// updateStatus records a delivery outcome. The send already happened, so a
+// storage failure is logged rather than reported to the client.
+func (h *NotificationHandler) updateStatus(ctx context.Context, notification *models.Notification, update repository.StatusUpdate) {
+	if err := h.repository.UpdateStatus(ctx, notification.TenantID, notification.ID, update); err != nil {
+		logging.FromContext(ctx, h.logger).Error("Error updating notification status", logging.NotificationAttrs(notification, "status", update.Status, "error", err)...)
+	}
+}
+
+// ListNotifications returns one page of stored notifications, newest first.
+// The status, channel and category query parameters filter the result and
+// recurring=true limits it to recurring schedules. limit sets the page size
+// and cursor continues from a previous page's next_cursor.
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	opts := repository.ListOptions{
+		TenantID:  TenantID(r.Context()),
+		Cursor:    query.Get("cursor"),
+		Status:    models.NotificationStatus(query.Get("status")),
+		Channel:   models.NotificationChannel(query.Get("channel")),
+		Category:  query.Get("category"),
+		Recurring: query.Get("recurring") == "true",
+		Query:     query.Get("q"),
+	}
+	if opts.Status != "" && !isValidStatus(opts.Status) {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid status: " + string(opts.Status),
+		})
+		return
+	}
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > repository.MaxListLimit {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid limit: must be between 1 and %d", repository.MaxListLimit),
+			})
+			return
+		}
+		opts.Limit = parsed
+	}
+
+	page, err := h.repository.List(r.Context(), opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid cursor",
+		})
+		return
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to list notifications: " + err.Error(),
+		})
+		return
+	}
+
+	sendJSON(w, http.StatusOK, ListResponse{
+		Success:    true,
+		Message:    "Notifications retrieved successfully",
+		Data:       newNotificationListItems(page.Notifications, time.Now()),
+		NextCursor: page.NextCursor,
+		TotalCount: page.TotalCount,
+	})
+}
+
+// parseWindow reads the optional RFC 3339 from and to query parameters,
+// returning a message describing the problem if they are invalid.
+func parseWindow(query url.Values) (from, to time.Time, message string) {
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return from, to, "Invalid " + param.name + ": must be an RFC 3339 time"
+		}
+		*param.value = parsed
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, "Invalid window: from must be before to"
+	}
+	return from, to, ""
+}
+
+func isValidStatus(status models.NotificationStatus) bool {
+	switch status {
+	case models.StatusPending, models.StatusSent, models.StatusFailed, models.StatusCancelled,
+		models.StatusDelivered, models.StatusRead, models.StatusSuppressed, models.StatusExpired, models.StatusMerged:
+		return true
+	}
+	return false
+}
+
+// GetNotification returns the notification named in the path.
+func (h *NotificationHandler) GetNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	id := r.PathValue("id")
+	notification, err := h.repository.GetByID(r.Context(), TenantID(r.Context()), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		})
+		return
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to load notification: " + err.Error(),
+		})
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Notification retrieved successfully",
+		Data:    notification,
+	})
+}
+
+// TrackClick records a click on a tracked link of the notification named in
+// the path and redirects to the link's original URL, taken from the url
+// query parameter. Only http and https URLs on the configured allowed hosts
+// are followed, so the endpoint cannot be used as an open redirect.
+func (h *NotificationHandler) TrackClick(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	target := r.URL.Query().Get("url")
+	var allowed []string
+	if h.config != nil {
+		allowed = h.config.ClickTrackingAllowedHosts
+	}
+	if !services.AllowedRedirect(target, allowed) {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Redirect target not allowed",
+		})
+		return
+	}
+	// The recipient still gets where they were going if recording fails
+	if err := h.repository.RecordClick(r.Context(), TenantID(r.Context()), id, target, time.Now().UTC()); err != nil {
+		logging.FromContext(r.Context(), h.logger).Warn("Error recording click", "notification_id", id, "error", err)
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// NotificationStatus returns the delivery state of the notification named in
+// the path.
+func (h *NotificationHandler) NotificationStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	id := r.PathValue("id")
+	notification, err := h.repository.GetByID(r.Context(), TenantID(r.Context()), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		})
+		return
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to load notification: " + err.Error(),
+		})
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Notification status retrieved successfully",
+		Data:    newNotificationStatusResponse(notification),
+	})
+}
+
+// Stats returns notification counts by channel and status, limited to the
+// notifications created between the optional from and to query parameters.
+// Counts are cached for up to a minute.
+func (h *NotificationHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	from, to, message := parseWindow(r.URL.Query())
+	if message != "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+	opts := repository.StatsOptions{TenantID: TenantID(r.Context()), From: from, To: to}
+
+	stats, err := h.stats.Stats(r.Context(), opts)
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to aggregate notifications: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Notification stats retrieved successfully",
+		Data:    StatsResponse{Channel: stats.ByChannel, Status: stats.ByStatus, Last24h: stats.Last24h},
+	})
+}
+
+// NotificationEvents returns the event history of a notification and the
+// state projected from it. The history of deleted notifications is kept.
+func (h *NotificationHandler) NotificationEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	id := r.PathValue("id")
+	events, err := h.repository.GetEventHistory(r.Context(), TenantID(r.Context()), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		})
+		return
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to load notification events: " + err.Error(),
+		})
+		return
+	}
+	state, err := h.projector.Project(events)
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to replay notification events: " + err.Error(),
+		})
+		return
+	}
+
+	response := NotificationEventsResponse{
+		Events:  make([]NotificationEventResponse, len(events)),
+		State:   newNotificationStatusResponse(&state.Notification),
+		Clicks:  state.Clicks,
+		Deleted: state.Deleted,
+		Version: state.Version,
+	}
+	for i, event := range events {
+		response.Events[i] = NotificationEventResponse{
+			Sequence:  event.Sequence,
+			Type:      event.Type,
+			Payload:   event.Payload,
+			Timestamp: event.Timestamp,
+		}
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Notification events retrieved successfully",
+		Data:    response,
+	})
+}
+
+// CancelNotification cancels a scheduled notification that has not fired yet.
+func (h *NotificationHandler) CancelNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	status, response := h.cancel(r.Context(), r.PathValue("id"))
+	sendJSONResponse(w, status, response)
+}
+
+// cancel cancels the scheduled notification id of the context's tenant. It
+// returns the response status and body.
+func (h *NotificationHandler) cancel(ctx context.Context, id string) (int, APIResponse) {
+	if h.schedulerService == nil {
+		return http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		}
+	}
+
+	tenantID := TenantID(ctx)
+	err := h.schedulerService.CancelNotification(tenantID, id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		}
+	case errors.Is(err, services.ErrNotificationNotPending):
+		return http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Notification has already been sent",
+		}
+	case err != nil:
+		return http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to cancel notification: " + err.Error(),
+		}
+	}
+	h.recordAudit(ctx, models.AuditCancelled, &models.Notification{ID: id, TenantID: tenantID}, nil)
+	return http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Notification cancelled successfully",
+	}
+}
+
+// RescheduleNotificationRequest moves a scheduled notification to
+// ScheduledAt, which is given as in SendNotificationRequest.
+type RescheduleNotificationRequest struct {
+	ScheduledAt string `json:"scheduled_at"`
+	Timezone    string `json:"timezone,omitempty"`
+}
+
+// RescheduleNotification moves a scheduled notification that has not fired
+// yet to a new time and returns the updated notification.
+func (h *NotificationHandler) RescheduleNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req RescheduleNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+	var location *time.Location
+	if req.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(req.Timezone); err != nil || req.Timezone == "Local" {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Unknown timezone: " + req.Timezone + ". Use an IANA time zone name (e.g., America/New_York)",
+			})
+			return
+		}
+	}
+	if req.ScheduledAt == "" {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "scheduled_at is required",
+		})
+		return
+	}
+	scheduledAt, err := parseRequestTime(req.ScheduledAt, location)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid scheduled_at time format. " + timeFormatHint(location),
+		})
+		return
+	}
+
+	id := r.PathValue("id")
+	if h.schedulerService == nil {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		})
+		return
+	}
+
+	tenantID := TenantID(r.Context())
+	err = h.schedulerService.RescheduleNotification(tenantID, id, scheduledAt)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		})
+		return
+	case errors.Is(err, services.ErrNotificationNotPending):
+		sendJSONResponse(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Notification has already been sent",
+		})
+		return
+	case errors.Is(err, services.ErrRecurringNotification):
+		sendJSONResponse(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Recurring notifications follow their cron_expression and cannot be rescheduled",
+		})
+		return
+	case errors.Is(err, services.ErrScheduledTimeNotInFuture):
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Scheduled time must be in the future",
+		})
+		return
+	case errors.Is(err, services.ErrExpiryNotAfterSchedule):
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Scheduled time must be before expires_at",
+		})
+		return
+	case err != nil:
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to reschedule notification: " + err.Error(),
+		})
+		return
+	}
+
+	notification, err := h.repository.GetByID(r.Context(), tenantID, id)
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to load notification: " + err.Error(),
+		})
+		return
+	}
+	h.recordAudit(r.Context(), models.AuditRescheduled, notification, map[string]string{"scheduled_at": scheduledAt.Format(time.RFC3339)})
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Notification rescheduled successfully",
+		Data:    notification,
+	})
+}
+
+// DeadLetters lists the tenant's failed notifications on GET and re-sends
+// each of them on POST. Entries that fail again are put back in the queue by
+// the factory.
+func (h *NotificationHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	queue := h.notificationFactory.DeadLetterQueue()
+	if queue == nil {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Dead-letter queue is not configured",
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := h.tenantDeadLetters(r.Context(), queue)
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to list dead-letter notifications: " + err.Error(),
+			})
+			return
+		}
+
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Dead-letter notifications retrieved successfully",
+			Data:    entries,
+		})
+	case http.MethodPost:
+		entries, err := h.tenantDeadLetters(r.Context(), queue)
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to list dead-letter notifications: " + err.Error(),
+			})
+			return
+		}
+
+		ctx, cancel := h.sendContext(r)
+		defer cancel()
+
+		result := RequeueResult{Requeued: []string{}, Failed: []string{}}
+		for _, entry := range entries {
+			notification := entry.Notification
+			if err := queue.Remove(notification.ID); err != nil {
+				result.Failed = append(result.Failed, notification.ID)
+				continue
+			}
+
+			service, err := h.notificationFactory.ForTenant(notification.TenantID).GetService(notification.Channel)
+			if err == nil {
+				err = service.Send(ctx, notification)
+			}
+			if err != nil && !errors.Is(err, services.ErrMessageTruncated) && !errors.Is(err, services.ErrAttachmentsNotUploaded) {
+				h.recordAudit(r.Context(), models.AuditFailed, notification, map[string]string{"source": "dead_letter", "reason": err.Error()})
+				result.Failed = append(result.Failed, notification.ID)
+				continue
+			}
+			h.recordAudit(r.Context(), models.AuditSent, notification, map[string]string{"source": "dead_letter"})
+			result.Requeued = append(result.Requeued, notification.ID)
+		}
+
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: len(result.Failed) == 0,
+			Message: fmt.Sprintf("Requeued %d of %d dead-letter notification(s)", len(result.Requeued), len(entries)),
+			Data:    result,
+		})
+	default:
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+// tenantDeadLetters returns the entries of queue that belong to the
+// request's tenant.
+func (h *NotificationHandler) tenantDeadLetters(ctx context.Context, queue services.DeadLetterQueue) ([]services.DeadLetter, error) {
+	entries, err := queue.List()
+	if err != nil {
+		return nil, err
+	}
+	tenantID := TenantID(ctx)
+	filtered := make([]services.DeadLetter, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Notification.TenantID == tenantID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+func sendJSONResponse(w http.ResponseWriter, status int, response APIResponse) {
+	sendJSON(w, status, response)
+}
+
+func sendJSON(w http.ResponseWriter, status int, body interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(body)
 }
@@ -2,24 +2,36 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"notification-service/internal/config"
 	"notification-service/internal/models"
+	"notification-service/internal/repository"
 	"notification-service/internal/services"
+	"notification-service/internal/services/mock"
+	"notification-service/internal/tracing"
+	"strings"
 	"testing"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNotificationHandler(t *testing.T) {
 	// Setup
-	factory := services.NewNotificationServiceFactory()
-	defaultService, _ := factory.GetService(models.ChannelSlack)
-	scheduler := services.NewSchedulerService(defaultService)
-	scheduler.Start()
-	defer scheduler.Stop()
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	scheduler := &mock.MockSchedulerService{}
 
-	handler := NewNotificationHandler(factory, scheduler)
+	handler := NewNotificationHandler(factory, scheduler, repository.NewMemoryRepository(), &config.Config{})
 
 	tests := []struct {
 		name          string
@@ -140,6 +152,82 @@ func TestNotificationHandler(t *testing.T) {
 				Message: "Scheduled time must be in the future",
 			},
 		},
+		{
+			name: "Expiry before scheduled time",
+			request: SendNotificationRequest{
+				Title:       "Test",
+				Content:     "Content",
+				Channel:     models.ChannelEmail,
+				Recipients:  []string{"test@example.com"},
+				ScheduledAt: time.Now().Add(2 * time.Hour).Format(time.RFC3339),
+				ExpiresAt:   time.Now().Add(time.Hour).Format(time.RFC3339),
+			},
+			method:       http.MethodPost,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: APIResponse{
+				Success: false,
+				Message: "expires_at must be in the future and after scheduled_at",
+			},
+		},
+		{
+			name: "Invalid WhatsApp recipient",
+			request: SendNotificationRequest{
+				Title:      "Test",
+				Content:    "Content",
+				Channel:    models.ChannelWhatsApp,
+				Recipients: []string{"555-1234"},
+			},
+			method:       http.MethodPost,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: APIResponse{
+				Success: false,
+				Message: "Invalid recipient 555-1234: WhatsApp recipients must be E.164 phone numbers",
+			},
+		},
+		{
+			name: "Content too long for SMS",
+			request: SendNotificationRequest{
+				Title:      "Test",
+				Content:    strings.Repeat("a", 1531),
+				Channel:    models.ChannelMessage,
+				Recipients: []string{"+15551234567"},
+			},
+			method:       http.MethodPost,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: APIResponse{
+				Success: false,
+				Message: "invalid notification: content (message): is 1531 characters, exceeding the limit of 1530",
+			},
+			validateExtra: func(t *testing.T, response APIResponse) {
+				data, ok := response.Data.(map[string]interface{})
+				if !ok {
+					t.Fatalf("Expected field errors in data, got %v", response.Data)
+				}
+				fields, _ := data["fields"].([]interface{})
+				if len(fields) != 1 {
+					t.Fatalf("Expected 1 field error, got %v", data["fields"])
+				}
+				if field := fields[0].(map[string]interface{}); field["field"] != "content" || field["channel"] != "message" {
+					t.Errorf("Unexpected field error: %v", field)
+				}
+			},
+		},
+		{
+			name: "Malformed reply_to",
+			request: SendNotificationRequest{
+				Title:      "Test",
+				Content:    "Content",
+				Channel:    models.ChannelEmail,
+				Recipients: []string{"test@example.com"},
+				Metadata:   map[string]string{"reply_to": "support at example.com"},
+			},
+			method:       http.MethodPost,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: APIResponse{
+				Success: false,
+				Message: "invalid notification: metadata.reply_to (email): is not a valid address: mail: no angle-addr",
+			},
+		},
 		{
 			name:         "Invalid HTTP method",
 			method:       http.MethodGet,
@@ -192,4 +280,1632 @@ func TestNotificationHandler(t *testing.T) {
 			}
 		})
 	}
+
+	if scheduled := scheduler.ScheduledNotifications(); len(scheduled) != 1 || scheduled[0].Channel != models.ChannelEmail {
+		t.Errorf("Expected only the valid email notification to be scheduled, got %+v", scheduled)
+	}
+}
+
+func TestDeadLetterEndpoints(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	factory.WithDeadLetterQueue(services.NewMemoryDeadLetterQueue())
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{})
+
+	// An invalid PagerDuty severity fails without any network call
+	body, _ := json.Marshal(SendNotificationRequest{
+		Title:      "Disk full",
+		Content:    "Volume at 100%",
+		Channel:    models.ChannelPagerDuty,
+		Recipients: []string{"on-call"},
+		Metadata:   map[string]string{"severity": "bogus"},
+	})
+	rr := httptest.NewRecorder()
+	handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.DeadLetters(rr, httptest.NewRequest(http.MethodGet, "/notifications/dead-letter", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var listResponse struct {
+		Data []services.DeadLetter `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&listResponse)
+	if len(listResponse.Data) != 1 || listResponse.Data[0].Notification.Title != "Disk full" {
+		t.Fatalf("Expected the failed notification in the dead-letter queue, got %+v", listResponse.Data)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.DeadLetters(rr, httptest.NewRequest(http.MethodPost, "/notifications/dead-letter", nil))
+	var requeueResponse struct {
+		Success bool          `json:"success"`
+		Data    RequeueResult `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&requeueResponse)
+	if requeueResponse.Success || len(requeueResponse.Data.Failed) != 1 {
+		t.Errorf("Expected requeue to fail again, got %+v", requeueResponse)
+	}
+
+	entries, _ := factory.DeadLetterQueue().List()
+	if len(entries) != 1 {
+		t.Errorf("Expected notification to return to the dead-letter queue, got %d entries", len(entries))
+	}
+}
+
+func TestSendNotificationPersistsNotification(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(factory, nil, repo, &config.Config{})
+
+	body, _ := json.Marshal(SendNotificationRequest{
+		Title:      "Stored",
+		Content:    "Persist me",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"user1"},
+	})
+	rr := httptest.NewRecorder()
+	handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	stored, err := repo.ListAll(context.Background(), "")
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("Expected 1 stored notification, got %d (%v)", len(stored), err)
+	}
+	if stored[0].Title != "Stored" || stored[0].SentAt == nil {
+		t.Errorf("Expected sent notification to be stored with SentAt, got %+v", stored[0])
+	}
+}
+
+func TestListNotificationsByStatus(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{})
+
+	requests := []SendNotificationRequest{
+		{Title: "Delivered", Content: "ok", Channel: models.ChannelSlack, Recipients: []string{"user1"}},
+		{Title: "Disk full", Content: "Volume at 100%", Channel: models.ChannelPagerDuty, Recipients: []string{"on-call"}, Metadata: map[string]string{"severity": "bogus"}},
+	}
+	for _, req := range requests {
+		body, _ := json.Marshal(req)
+		handler.SendNotification(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedTitles []string
+	}{
+		{"All", "", http.StatusOK, []string{"Delivered", "Disk full"}},
+		{"Failed", "?status=failed", http.StatusOK, []string{"Disk full"}},
+		{"Sent", "?status=sent", http.StatusOK, []string{"Delivered"}},
+		{"Pending", "?status=pending", http.StatusOK, nil},
+		{"Expired", "?status=expired", http.StatusOK, nil},
+		{"Unknown", "?status=bogus", http.StatusBadRequest, nil},
+		{"Channel", "?channel=pagerduty", http.StatusOK, []string{"Disk full"}},
+		{"Invalid limit", "?limit=0", http.StatusBadRequest, nil},
+		{"Limit above maximum", "?limit=101", http.StatusBadRequest, nil},
+		{"Invalid cursor", "?cursor=bogus", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ListNotifications(rr, httptest.NewRequest(http.MethodGet, "/notifications"+tt.query, nil))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data []*models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if len(response.Data) != len(tt.expectedTitles) {
+				t.Fatalf("Expected %d notifications, got %d", len(tt.expectedTitles), len(response.Data))
+			}
+			titles := make(map[string]bool)
+			for _, n := range response.Data {
+				titles[n.Title] = true
+			}
+			for _, title := range tt.expectedTitles {
+				if !titles[title] {
+					t.Errorf("Expected notification %q in response", title)
+				}
+			}
+			if tt.name == "Failed" && response.Data[0].FailureReason == "" {
+				t.Error("Expected failure reason on failed notification")
+			}
+		})
+	}
+}
+
+func TestListNotificationsPagination(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, &config.Config{})
+
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		repo.Save(context.Background(), &models.Notification{
+			ID:        fmt.Sprintf("n-%d", i),
+			Title:     fmt.Sprintf("Notification %d", i),
+			Channel:   models.ChannelSlack,
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	var titles []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		rr := httptest.NewRecorder()
+		handler.ListNotifications(rr, httptest.NewRequest(http.MethodGet, "/notifications?limit=2&cursor="+cursor, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var response ListResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if response.TotalCount != 5 {
+			t.Errorf("Expected total_count 5, got %d", response.TotalCount)
+		}
+		for _, n := range response.Data {
+			titles = append(titles, n.Title)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		cursor = response.NextCursor
+	}
+
+	expected := []string{"Notification 4", "Notification 3", "Notification 2", "Notification 1", "Notification 0"}
+	if fmt.Sprint(titles) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, titles)
+	}
+}
+
+func TestListNotificationsSearch(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, &config.Config{})
+
+	base := time.Now()
+	for i, n := range []struct{ title, content string }{
+		{"Invoice ready", "Your March invoice is attached"},
+		{"Password reset", "Use this link to reset your password"},
+		{"Weekly digest", "3 new invoices this week"},
+		{"Welcome", "Thanks for signing up"},
+	} {
+		repo.Save(context.Background(), &models.Notification{
+			ID:        fmt.Sprintf("s-%d", i),
+			Title:     n.title,
+			Content:   n.content,
+			Channel:   models.ChannelEmail,
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"invoice", []string{"s-2", "s-0"}},
+		{"PASSWORD", []string{"s-1"}},
+		{"signing up", []string{"s-3"}},
+		{"refund", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var ids []string
+			cursor := ""
+			for {
+				rr := httptest.NewRecorder()
+				handler.ListNotifications(rr, httptest.NewRequest(http.MethodGet,
+					"/notifications?limit=1&q="+url.QueryEscape(tt.query)+"&cursor="+cursor, nil))
+				if rr.Code != http.StatusOK {
+					t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+				}
+				var response ListResponse
+				json.NewDecoder(rr.Body).Decode(&response)
+				if response.TotalCount != len(tt.expected) {
+					t.Errorf("Expected total_count %d, got %d", len(tt.expected), response.TotalCount)
+				}
+				for _, n := range response.Data {
+					ids = append(ids, n.ID)
+				}
+				if response.NextCursor == "" {
+					break
+				}
+				cursor = response.NextCursor
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func TestNotificationStats(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, &config.Config{})
+
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, n := range []struct {
+		channel models.NotificationChannel
+		status  models.NotificationStatus
+	}{
+		{models.ChannelSlack, models.StatusSent},
+		{models.ChannelSlack, models.StatusFailed},
+		{models.ChannelEmail, models.StatusSent},
+		{models.ChannelEmail, models.StatusPending},
+	} {
+		repo.Save(ctx, &models.Notification{
+			ID:        fmt.Sprintf("stats-%d", i),
+			Channel:   n.channel,
+			Status:    n.status,
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedCode    int
+		expectedChannel map[models.NotificationChannel]int
+		expectedStatus  map[models.NotificationStatus]int
+	}{
+		{"All", "", http.StatusOK,
+			map[models.NotificationChannel]int{models.ChannelSlack: 2, models.ChannelEmail: 2},
+			map[models.NotificationStatus]int{models.StatusSent: 2, models.StatusFailed: 1, models.StatusPending: 1}},
+		{"Window", "?from=2025-03-01T13:00:00Z&to=2025-03-01T15:00:00Z", http.StatusOK,
+			map[models.NotificationChannel]int{models.ChannelSlack: 1, models.ChannelEmail: 1},
+			map[models.NotificationStatus]int{models.StatusSent: 1, models.StatusFailed: 1}},
+		{"Invalid from", "?from=yesterday", http.StatusBadRequest, nil, nil},
+		{"Empty window", "?from=2025-03-02T00:00:00Z&to=2025-03-01T00:00:00Z", http.StatusBadRequest, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.Stats(rr, httptest.NewRequest(http.MethodGet, "/stats"+tt.query, nil))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data StatsResponse `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if fmt.Sprint(response.Data.Channel) != fmt.Sprint(tt.expectedChannel) {
+				t.Errorf("Expected channel counts %v, got %v", tt.expectedChannel, response.Data.Channel)
+			}
+			if fmt.Sprint(response.Data.Status) != fmt.Sprint(tt.expectedStatus) {
+				t.Errorf("Expected status counts %v, got %v", tt.expectedStatus, response.Data.Status)
+			}
+			if response.Data.Last24h != 0 {
+				t.Errorf("Expected no notifications in the last 24h, got %d", response.Data.Last24h)
+			}
+		})
+	}
+}
+
+func TestListNotificationsFiresInSeconds(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, &config.Config{})
+
+	now := time.Now()
+	inFiveMinutes := now.Add(5*time.Minute - 500*time.Millisecond)
+	overdue := now.Add(-time.Second)
+	for _, notification := range []*models.Notification{
+		{ID: "scheduled", Title: "Scheduled", Channel: models.ChannelSlack, ScheduledAt: &inFiveMinutes, Status: models.StatusPending, CreatedAt: now},
+		{ID: "due", Title: "Due", Channel: models.ChannelSlack, ScheduledAt: &overdue, Status: models.StatusPending, CreatedAt: now.Add(time.Second)},
+		{ID: "recurring", Title: "Recurring", Channel: models.ChannelSlack, CronExpr: "@daily", Status: models.StatusPending, CreatedAt: now.Add(2 * time.Second)},
+		{ID: "immediate", Title: "Immediate", Channel: models.ChannelSlack, Status: models.StatusSent, CreatedAt: now.Add(3 * time.Second)},
+	} {
+		repo.Save(context.Background(), notification)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ListNotifications(rr, httptest.NewRequest(http.MethodGet, "/notifications", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var response struct {
+		Data []map[string]any `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+
+	expected := map[string]any{"scheduled": float64(300), "due": float64(0), "recurring": nil, "immediate": nil}
+	if len(response.Data) != len(expected) {
+		t.Fatalf("Expected %d notifications, got %d", len(expected), len(response.Data))
+	}
+	for _, item := range response.Data {
+		firesIn, ok := item["fires_in_seconds"]
+		if !ok {
+			t.Errorf("Expected fires_in_seconds on notification %v", item["ID"])
+		}
+		if want := expected[item["ID"].(string)]; firesIn != want {
+			t.Errorf("Expected fires_in_seconds %v for %v, got %v", want, item["ID"], firesIn)
+		}
+	}
+}
+
+func TestCancelNotification(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	defaultService, _ := factory.GetService(models.ChannelSlack)
+	repo := repository.NewMemoryRepository()
+	scheduler := services.NewSchedulerService(defaultService, repo)
+	handler := NewNotificationHandler(factory, scheduler, repo, &config.Config{})
+
+	body, _ := json.Marshal(SendNotificationRequest{
+		Title:       "Later",
+		Content:     "Scheduled content",
+		Channel:     models.ChannelSlack,
+		Recipients:  []string{"user1"},
+		ScheduledAt: time.Now().Add(time.Hour).Format(time.RFC3339),
+	})
+	rr := httptest.NewRecorder()
+	handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+	var scheduled struct {
+		Data models.Notification `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&scheduled)
+
+	sent := &models.Notification{ID: "already-sent", Channel: models.ChannelSlack, Status: models.StatusSent, CreatedAt: time.Now()}
+	repo.Save(context.Background(), sent)
+
+	tests := []struct {
+		name         string
+		id           string
+		expectedCode int
+	}{
+		{"Pending notification", scheduled.Data.ID, http.StatusOK},
+		{"Cancelled twice", scheduled.Data.ID, http.StatusConflict},
+		{"Already sent", "already-sent", http.StatusConflict},
+		{"Unknown notification", "does-not-exist", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/notifications/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			handler.CancelNotification(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	stored, _ := repo.GetByID(context.Background(), "", scheduled.Data.ID)
+	if stored.Status != models.StatusCancelled {
+		t.Errorf("Expected status %s, got %s", models.StatusCancelled, stored.Status)
+	}
+}
+
+func TestRescheduleNotification(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	defaultService, _ := factory.GetService(models.ChannelSlack)
+	repo := repository.NewMemoryRepository()
+	scheduler := services.NewSchedulerService(defaultService, repo)
+	handler := NewNotificationHandler(factory, scheduler, repo, &config.Config{})
+
+	schedule := func(request SendNotificationRequest) string {
+		body, _ := json.Marshal(request)
+		rr := httptest.NewRecorder()
+		handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+		var scheduled struct {
+			Data models.Notification `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&scheduled)
+		return scheduled.Data.ID
+	}
+	pending := schedule(SendNotificationRequest{
+		Title: "Later", Content: "Scheduled content", Channel: models.ChannelSlack, Recipients: []string{"user1"},
+		ScheduledAt: time.Now().Add(time.Hour).Format(time.RFC3339),
+		ExpiresAt:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+	})
+	recurring := schedule(SendNotificationRequest{
+		Title: "Standup", Content: "Daily standup", Channel: models.ChannelSlack, Recipients: []string{"team"}, CronExpression: "@daily",
+	})
+	sent := &models.Notification{ID: "already-sent", Channel: models.ChannelSlack, Status: models.StatusSent, CreatedAt: time.Now()}
+	repo.Save(context.Background(), sent)
+
+	newTime := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	tests := []struct {
+		name         string
+		id           string
+		body         string
+		expectedCode int
+	}{
+		{"Pending notification", pending, `{"scheduled_at":"` + newTime.Format(time.RFC3339) + `"}`, http.StatusOK},
+		{"Past time", pending, `{"scheduled_at":"` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}`, http.StatusBadRequest},
+		{"After expiry", pending, `{"scheduled_at":"` + time.Now().Add(4*time.Hour).Format(time.RFC3339) + `"}`, http.StatusBadRequest},
+		{"Missing time", pending, `{}`, http.StatusBadRequest},
+		{"Malformed time", pending, `{"scheduled_at":"tomorrow"}`, http.StatusBadRequest},
+		{"Recurring notification", recurring, `{"scheduled_at":"` + newTime.Format(time.RFC3339) + `"}`, http.StatusConflict},
+		{"Already sent", "already-sent", `{"scheduled_at":"` + newTime.Format(time.RFC3339) + `"}`, http.StatusConflict},
+		{"Unknown notification", "does-not-exist", `{"scheduled_at":"` + newTime.Format(time.RFC3339) + `"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/notifications/"+tt.id, strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			handler.RescheduleNotification(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if response.Data.ScheduledAt == nil || !response.Data.ScheduledAt.Equal(newTime) {
+				t.Errorf("Expected scheduled time %v, got %v", newTime, response.Data.ScheduledAt)
+			}
+		})
+	}
+
+	stored, _ := repo.GetByID(context.Background(), "", pending)
+	if stored.Status != models.StatusPending || !stored.ScheduledAt.Equal(newTime) {
+		t.Errorf("Expected pending notification at %v, got %s at %v", newTime, stored.Status, stored.ScheduledAt)
+	}
+}
+
+func TestRecurringNotification(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	defaultService, _ := factory.GetService(models.ChannelSlack)
+	repo := repository.NewMemoryRepository()
+	scheduler := services.NewSchedulerService(defaultService, repo)
+	handler := NewNotificationHandler(factory, scheduler, repo, &config.Config{})
+
+	tests := []struct {
+		name         string
+		request      SendNotificationRequest
+		expectedCode int
+	}{
+		{
+			name:         "Valid cron expression",
+			request:      SendNotificationRequest{Title: "Standup", Content: "Daily standup", Channel: models.ChannelSlack, Recipients: []string{"team"}, CronExpression: "0 9 * * MON-FRI"},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:         "Invalid cron expression",
+			request:      SendNotificationRequest{Title: "Broken", Content: "Never", Channel: models.ChannelSlack, Recipients: []string{"team"}, CronExpression: "every day"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Cron combined with scheduled_at",
+			request: SendNotificationRequest{Title: "Both", Content: "Ambiguous", Channel: models.ChannelSlack, Recipients: []string{"team"},
+				CronExpression: "@daily", ScheduledAt: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.request)
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	// A one-off notification must not show up as recurring
+	body, _ := json.Marshal(SendNotificationRequest{Title: "Once", Content: "Now", Channel: models.ChannelSlack, Recipients: []string{"team"}})
+	handler.SendNotification(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+
+	rr := httptest.NewRecorder()
+	handler.ListNotifications(rr, httptest.NewRequest(http.MethodGet, "/notifications?recurring=true", nil))
+	var response struct {
+		Data []*models.Notification `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].CronExpr != "0 9 * * MON-FRI" {
+		t.Fatalf("Expected the recurring notification only, got %+v", response.Data)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/notifications/"+response.Data[0].ID, nil)
+	req.SetPathValue("id", response.Data[0].ID)
+	rr = httptest.NewRecorder()
+	handler.CancelNotification(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestFanOutNotification(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{})
+
+	tests := []struct {
+		name         string
+		request      SendNotificationRequest
+		expectedCode int
+		failedOn     []models.NotificationChannel
+	}{
+		{
+			name:         "All channels succeed",
+			request:      SendNotificationRequest{Title: "Outage", Content: "API is down", Channels: []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail}, Recipients: []string{"ops"}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "One channel fails",
+			request: SendNotificationRequest{Title: "Outage", Content: "API is down", Channels: []models.NotificationChannel{models.ChannelSlack, models.ChannelPagerDuty},
+				Recipients: []string{"ops"}, Metadata: map[string]string{"severity": "bogus"}},
+			expectedCode: http.StatusInternalServerError,
+			failedOn:     []models.NotificationChannel{models.ChannelPagerDuty},
+		},
+		{
+			name:         "Unknown channel",
+			request:      SendNotificationRequest{Title: "Outage", Content: "API is down", Channels: []models.NotificationChannel{models.ChannelSlack, "fax"}, Recipients: []string{"ops"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Channel and channels together",
+			request:      SendNotificationRequest{Title: "Outage", Content: "API is down", Channel: models.ChannelSlack, Channels: []models.NotificationChannel{models.ChannelEmail}, Recipients: []string{"ops"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.request)
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+
+			if len(tt.failedOn) == 0 {
+				return
+			}
+			var response struct {
+				Data map[models.NotificationChannel]string `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if len(response.Data) != len(tt.failedOn) {
+				t.Errorf("Expected failures on %v, got %v", tt.failedOn, response.Data)
+			}
+			for _, channel := range tt.failedOn {
+				if response.Data[channel] == "" {
+					t.Errorf("Expected an error for channel %s", channel)
+				}
+			}
+		})
+	}
+}
+
+func TestNotificationPriority(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{})
+
+	critical := models.PriorityCritical
+	invalid := models.NotificationPriority(7)
+	tests := []struct {
+		name             string
+		priority         *models.NotificationPriority
+		expectedCode     int
+		expectedPriority models.NotificationPriority
+	}{
+		{"Default priority", nil, http.StatusOK, models.PriorityNormal},
+		{"Critical priority", &critical, http.StatusOK, models.PriorityCritical},
+		{"Invalid priority", &invalid, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(SendNotificationRequest{
+				Title:      "Deploy",
+				Content:    "Rolling out",
+				Channel:    models.ChannelSlack,
+				Recipients: []string{"ops"},
+				Priority:   tt.priority,
+			})
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if response.Data.Priority != tt.expectedPriority {
+				t.Errorf("Expected priority %s, got %s", tt.expectedPriority, response.Data.Priority)
+			}
+		})
+	}
+}
+
+func TestSendNotificationIdempotencyKey(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(factory, nil, repo, &config.Config{})
+
+	send := func(request SendNotificationRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(request)
+		rr := httptest.NewRecorder()
+		handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+		return rr
+	}
+
+	request := SendNotificationRequest{Title: "Invoice", Content: "Your invoice is ready", Channel: models.ChannelSlack, Recipients: []string{"user1"}, IdempotencyKey: "invoice-42"}
+	first := send(request)
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, first.Code)
+	}
+	if first.Header().Get("X-Idempotency-Replayed") != "" {
+		t.Error("Expected first response not to be marked as replayed")
+	}
+
+	second := send(request)
+	if second.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, second.Code)
+	}
+	if second.Header().Get("X-Idempotency-Replayed") != "true" {
+		t.Error("Expected replayed response to set X-Idempotency-Replayed")
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("Expected replayed body %s, got %s", first.Body.String(), second.Body.String())
+	}
+
+	stored, _ := repo.ListAll(context.Background(), "")
+	if len(stored) != 1 {
+		t.Fatalf("Expected 1 stored notification, got %d", len(stored))
+	}
+
+	// Failed requests are not stored, so the key can be reused once fixed
+	invalid := SendNotificationRequest{Title: "Invoice", Channel: models.ChannelSlack, Recipients: []string{"user1"}, IdempotencyKey: "invoice-43"}
+	if rr := send(invalid); rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	invalid.Content = "Your invoice is ready"
+	rr := send(invalid)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Idempotency-Replayed") != "" {
+		t.Errorf("Expected a fresh send after a failed attempt, got status %d", rr.Code)
+	}
+}
+
+func TestNotificationStatusEndpoint(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, nil)
+	repo.Save(ctx, &models.Notification{ID: "failed-1", Channel: models.ChannelEmail, Status: models.StatusFailed, FailureReason: "mailbox full"})
+
+	tests := []struct {
+		id           string
+		expectedCode int
+	}{
+		{"failed-1", http.StatusOK},
+		{"missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/notifications/"+tt.id+"/status", nil)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			handler.NotificationStatus(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data NotificationStatusResponse `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if response.Data.Status != models.StatusFailed || response.Data.FailureReason != "mailbox full" {
+				t.Errorf("Expected failed status with reason, got %+v", response.Data)
+			}
+		})
+	}
+}
+
+func TestNotificationEventsEndpoint(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, nil)
+	sentAt := time.Now().UTC()
+	repo.Save(ctx, &models.Notification{ID: "events-1", Channel: models.ChannelEmail, Recipients: []string{"ana@example.com"}})
+	repo.UpdateStatus(ctx, "", "events-1", repository.StatusUpdate{Status: models.StatusSent, SentAt: &sentAt})
+	repo.RecordClick(ctx, "", "events-1", "https://example.com", sentAt)
+
+	tests := []struct {
+		id           string
+		expectedCode int
+	}{
+		{"events-1", http.StatusOK},
+		{"missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/notifications/"+tt.id+"/events", nil)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			handler.NotificationEvents(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data NotificationEventsResponse `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if len(response.Data.Events) != 3 || response.Data.Events[0].Type != models.EventCreated || response.Data.Events[1].Type != models.EventStatusChanged {
+				t.Fatalf("Unexpected events %+v", response.Data.Events)
+			}
+			if response.Data.State.Status != models.StatusSent || response.Data.State.SentAt == nil || response.Data.Clicks != 1 {
+				t.Errorf("Expected a sent state with 1 click, got %+v", response.Data)
+			}
+			if response.Data.Version != response.Data.Events[2].Sequence {
+				t.Errorf("Expected version %d, got %d", response.Data.Events[2].Sequence, response.Data.Version)
+			}
+		})
+	}
+}
+
+func TestGetNotification(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, nil)
+	sentAt := time.Now().UTC().Truncate(time.Second)
+	repo.Save(ctx, &models.Notification{ID: "sent-1", Channel: models.ChannelSlack, Title: "Deploy", Status: models.StatusSent, SentAt: &sentAt})
+	repo.Save(ctx, &models.Notification{ID: "failed-1", Channel: models.ChannelEmail, Title: "Invoice", Status: models.StatusFailed, FailureReason: "mailbox full"})
+
+	tests := []struct {
+		id           string
+		expectedCode int
+		expected     models.Notification
+	}{
+		{"sent-1", http.StatusOK, models.Notification{ID: "sent-1", Title: "Deploy", Status: models.StatusSent, SentAt: &sentAt}},
+		{"failed-1", http.StatusOK, models.Notification{ID: "failed-1", Title: "Invoice", Status: models.StatusFailed, FailureReason: "mailbox full"}},
+		{"missing", http.StatusNotFound, models.Notification{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/notifications/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			handler.GetNotification(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			var response struct {
+				Success bool                `json:"success"`
+				Message string              `json:"message"`
+				Data    models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if tt.expectedCode != http.StatusOK {
+				if response.Success || response.Message != "Notification not found: "+tt.id {
+					t.Errorf("Expected not found error body, got %+v", response)
+				}
+				return
+			}
+			got := response.Data
+			if got.ID != tt.expected.ID || got.Title != tt.expected.Title || got.Status != tt.expected.Status || got.FailureReason != tt.expected.FailureReason {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+			if (got.SentAt == nil) != (tt.expected.SentAt == nil) || (got.SentAt != nil && !got.SentAt.Equal(*tt.expected.SentAt)) {
+				t.Errorf("Expected SentAt %v, got %v", tt.expected.SentAt, got.SentAt)
+			}
+		})
+	}
+}
+
+func TestSendNotificationSuppressedRecipients(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	unsubscribe := services.NewUnsubscribeService("s3cret", "https://notify.example.com", repo)
+	unsubscribe.Unsubscribe(ctx, unsubscribe.Token("ana@example.com", "billing"))
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	factory.WithUnsubscribeService(unsubscribe)
+	handler := NewNotificationHandler(factory, &mock.MockSchedulerService{}, repo, &config.Config{})
+
+	tests := []struct {
+		category       string
+		expectedStatus models.NotificationStatus
+	}{
+		{"billing", models.StatusSuppressed},
+		{"marketing", models.StatusSent},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(SendNotificationRequest{
+			Title:      "Invoice",
+			Content:    "Your invoice is ready.",
+			Channel:    models.ChannelEmail,
+			Category:   tt.category,
+			Recipients: []string{"ana@example.com"},
+		})
+		rr := httptest.NewRecorder()
+		handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d: %s", http.StatusOK, tt.category, rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Data models.Notification `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&response)
+		if stored, _ := repo.GetByID(ctx, "", response.Data.ID); stored == nil || stored.Status != tt.expectedStatus {
+			t.Errorf("Expected %s notification to be %s, got %+v", tt.category, tt.expectedStatus, stored)
+		}
+	}
+}
+
+func TestSendNotificationCallbackURL(t *testing.T) {
+	received := make(chan services.DeliveryEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event services.DeliveryEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer server.Close()
+
+	repo := repository.NewMemoryRepository()
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, &mock.MockSchedulerService{}, repo, &config.Config{})
+
+	send := func(callbackURL string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(SendNotificationRequest{
+			Title:       "Order shipped",
+			Content:     "Your order is on its way.",
+			Channel:     models.ChannelEmail,
+			Recipients:  []string{"ana@example.com"},
+			CallbackURL: callbackURL,
+		})
+		rr := httptest.NewRecorder()
+		handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+		return rr
+	}
+
+	if rr := send(server.URL); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d with callbacks disabled, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	callbacks := services.NewCallbackService("callback-secret", repo, time.Second)
+	callbacks.Client = server.Client()
+	handler.WithCallbackService(callbacks)
+	if rr := send("ftp://example.com/hook"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for a non-http callback_url, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr := send(server.URL)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	callbacks.Wait()
+
+	var response struct {
+		Data models.Notification `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if response.Data.CallbackURL != server.URL {
+		t.Errorf("Expected callback_url %s, got %q", server.URL, response.Data.CallbackURL)
+	}
+	event := <-received
+	if event.NotificationID != response.Data.ID || event.Status != models.StatusSent || event.SentAt == nil {
+		t.Errorf("Unexpected delivery event %+v", event)
+	}
+	attempts, _ := repo.ListCallbackAttempts(context.Background(), "", response.Data.ID)
+	if len(attempts) != 1 || attempts[0].StatusCode != http.StatusOK {
+		t.Errorf("Expected 1 successful callback attempt recorded, got %+v", attempts)
+	}
+}
+
+func TestTrackClick(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(nil, nil, repo, &config.Config{ClickTrackingAllowedHosts: []string{"shop.example.com"}})
+	repo.Save(ctx, &models.Notification{ID: "email-1", Channel: models.ChannelEmail, Status: models.StatusSent})
+
+	tests := []struct {
+		name           string
+		id             string
+		target         string
+		expectedCode   int
+		expectedClicks int
+	}{
+		{"Allowed target", "email-1", "https://shop.example.com/sale?id=1", http.StatusFound, 1},
+		{"Unknown notification still redirects", "missing", "https://shop.example.com/", http.StatusFound, 1},
+		{"Host not allowed", "email-1", "https://evil.example.com/", http.StatusBadRequest, 1},
+		{"Not a web URL", "email-1", "javascript://shop.example.com/%0Aalert(1)", http.StatusBadRequest, 1},
+		{"Missing target", "email-1", "", http.StatusBadRequest, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/track/click/"+tt.id+"?url="+url.QueryEscape(tt.target), nil)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			handler.TrackClick(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedCode == http.StatusFound && rr.Header().Get("Location") != tt.target {
+				t.Errorf("Expected redirect to %s, got %s", tt.target, rr.Header().Get("Location"))
+			}
+			if clicks, _ := repo.ListClicks(ctx, "", "email-1"); len(clicks) != tt.expectedClicks {
+				t.Errorf("Expected %d recorded clicks, got %d", tt.expectedClicks, len(clicks))
+			}
+		})
+	}
+}
+
+func TestSendNotificationAttachments(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(factory, &mock.MockSchedulerService{}, repo, &config.Config{MaxAttachmentBytes: 10})
+
+	tests := []struct {
+		name            string
+		channel         models.NotificationChannel
+		attachments     []AttachmentRequest
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			name:         "Valid attachment",
+			channel:      models.ChannelEmail,
+			attachments:  []AttachmentRequest{{Filename: "report.json", Data: base64.StdEncoding.EncodeToString([]byte(`{"n": 2}`))}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:            "Exceeds size limit",
+			channel:         models.ChannelEmail,
+			attachments:     []AttachmentRequest{{Filename: "a.txt", Data: "aGVsbG8="}, {Filename: "b.txt", Data: "d29ybGQh"}},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Invalid attachments: total size exceeds the limit of 10 bytes",
+		},
+		{
+			name:            "Not base64",
+			channel:         models.ChannelEmail,
+			attachments:     []AttachmentRequest{{Filename: "a.txt", Data: "not base64!"}},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Invalid attachments: a.txt is not base64-encoded",
+		},
+		{
+			name:            "Missing filename",
+			channel:         models.ChannelEmail,
+			attachments:     []AttachmentRequest{{Data: "aGVsbG8="}},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Invalid attachments: filename is required",
+		},
+		{
+			name:            "Unsupported channel",
+			channel:         models.ChannelTeams,
+			attachments:     []AttachmentRequest{{Filename: "a.txt", Data: "aGVsbG8="}},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Attachments are only supported on the email and Slack channels",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(SendNotificationRequest{
+				Title: "Report", Content: "Attached", Channel: tt.channel,
+				Recipients: []string{"a@example.com"}, Attachments: tt.attachments,
+			})
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewReader(body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+
+			var response struct {
+				Message string              `json:"message"`
+				Data    models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if tt.expectedCode != http.StatusOK {
+				if response.Message != tt.expectedMessage {
+					t.Errorf("Expected message %q, got %q", tt.expectedMessage, response.Message)
+				}
+				return
+			}
+
+			if len(response.Data.Attachments) != 1 {
+				t.Fatalf("Expected 1 attachment, got %d", len(response.Data.Attachments))
+			}
+			attachment := response.Data.Attachments[0]
+			if attachment.ContentType != "application/json" || string(attachment.Data) != `{"n": 2}` {
+				t.Errorf("Unexpected attachment: %+v", attachment)
+			}
+		})
+	}
+}
+
+func TestSendNotificationDigest(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(factory, nil, repo, &config.Config{})
+
+	send := func(req SendNotificationRequest) (int, APIResponse) {
+		body, _ := json.Marshal(req)
+		rr := httptest.NewRecorder()
+		handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewReader(body)))
+		var response APIResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr.Code, response
+	}
+	request := SendNotificationRequest{Title: "Build passed", Content: "main is green", Channel: models.ChannelSlack, Recipients: []string{"U1"}, Digest: true, DigestKey: "builds"}
+
+	if code, response := send(request); code != http.StatusBadRequest || response.Message != "Digests are not enabled" {
+		t.Errorf("Expected digests to be disabled, got %d %q", code, response.Message)
+	}
+
+	digests := services.NewDigestService(services.NewFanOutNotificationService(factory), repo, time.Hour, 10)
+	handler.WithDigestService(digests)
+
+	tests := []struct {
+		name            string
+		modify          func(*SendNotificationRequest)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{"Added", func(*SendNotificationRequest) {}, http.StatusAccepted, "Notification added to digest builds"},
+		{"Missing key", func(r *SendNotificationRequest) { r.DigestKey = "" }, http.StatusBadRequest, "digest_key is required for digest notifications"},
+		{"Several channels", func(r *SendNotificationRequest) {
+			r.Channel, r.Channels = "", []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail}
+		}, http.StatusBadRequest, "Digest notifications must target a single channel"},
+		{"Scheduled", func(r *SendNotificationRequest) { r.CronExpression = "@hourly" }, http.StatusBadRequest, "digest cannot be combined with scheduled_at or cron_expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := request
+			tt.modify(&req)
+			code, response := send(req)
+			if code != tt.expectedCode || response.Message != tt.expectedMessage {
+				t.Errorf("Expected %d %q, got %d %q", tt.expectedCode, tt.expectedMessage, code, response.Message)
+			}
+		})
+	}
+
+	if digests.Pending() != 1 {
+		t.Fatalf("Expected 1 pending digest, got %d", digests.Pending())
+	}
+	stored, _ := repo.ListAll(context.Background(), "")
+	if len(stored) != 1 || stored[0].Status != models.StatusPending {
+		t.Fatalf("Expected the digest notification to be stored as pending, got %+v", stored)
+	}
+	digests.Flush()
+	if sent, _ := repo.GetByID(context.Background(), "", stored[0].ID); sent.Status != models.StatusSent {
+		t.Errorf("Expected flushed notification to be sent, got %s", sent.Status)
+	}
+}
+
+func TestSendNotificationTimezone(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	scheduler := &mock.MockSchedulerService{}
+	handler := NewNotificationHandler(factory, scheduler, repo, &config.Config{})
+
+	tests := []struct {
+		name              string
+		scheduledAt       string
+		timezone          string
+		expectedCode      int
+		expectedMessage   string
+		expectedScheduled time.Time
+	}{
+		{
+			name:              "Local time in timezone",
+			scheduledAt:       "2099-07-01T09:00:00",
+			timezone:          "America/New_York",
+			expectedCode:      http.StatusAccepted,
+			expectedScheduled: time.Date(2099, 7, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:              "Winter time in timezone",
+			scheduledAt:       "2099-01-15T09:00:00",
+			timezone:          "America/New_York",
+			expectedCode:      http.StatusAccepted,
+			expectedScheduled: time.Date(2099, 1, 15, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:              "Explicit offset wins",
+			scheduledAt:       "2099-07-01T09:00:00+02:00",
+			timezone:          "America/New_York",
+			expectedCode:      http.StatusAccepted,
+			expectedScheduled: time.Date(2099, 7, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "Unknown timezone",
+			scheduledAt:     "2099-07-01T09:00:00",
+			timezone:        "Mars/Olympus_Mons",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Unknown timezone: Mars/Olympus_Mons. Use an IANA time zone name (e.g., America/New_York)",
+		},
+		{
+			name:            "Local time without timezone",
+			scheduledAt:     "2099-07-01T09:00:00",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Invalid scheduled_at time format. Use RFC3339 format (e.g., 2024-03-31T21:20:00Z)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(SendNotificationRequest{
+				Title: "Standup", Content: "Daily standup", Channel: models.ChannelSlack, Recipients: []string{"U1"},
+				ScheduledAt: tt.scheduledAt, Timezone: tt.timezone,
+			})
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewReader(body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+
+			var response struct {
+				Message string              `json:"message"`
+				Data    models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if tt.expectedCode != http.StatusAccepted {
+				if response.Message != tt.expectedMessage {
+					t.Errorf("Expected message %q, got %q", tt.expectedMessage, response.Message)
+				}
+				return
+			}
+
+			scheduled := scheduler.ScheduledNotifications()
+			if len(scheduled) == 0 || scheduled[len(scheduled)-1].ID != response.Data.ID {
+				t.Fatalf("Expected notification %s to be scheduled, got %+v", response.Data.ID, scheduled)
+			}
+			stored := scheduled[len(scheduled)-1]
+			if !stored.ScheduledAt.Equal(tt.expectedScheduled) || stored.ScheduledAt.Location() != time.UTC {
+				t.Errorf("Expected scheduled time %s, got %s", tt.expectedScheduled, stored.ScheduledAt)
+			}
+			if stored.Metadata["timezone"] != tt.timezone {
+				t.Errorf("Expected timezone %q in metadata, got %q", tt.timezone, stored.Metadata["timezone"])
+			}
+		})
+	}
+}
+
+func TestSendBulkNotifications(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	later := time.Now().Add(time.Hour).Format(time.RFC3339)
+	valid := SendNotificationRequest{Title: "Bulk", Content: "Content", Channel: models.ChannelSlack, Recipients: []string{"U1"}}
+	scheduled := SendNotificationRequest{Title: "Bulk", Content: "Later", Channel: models.ChannelEmail, Recipients: []string{"ana@example.com"}, ScheduledAt: later}
+	invalid := SendNotificationRequest{Title: "Bulk", Channel: models.ChannelSlack, Recipients: []string{"U1"}}
+
+	tests := []struct {
+		name             string
+		body             interface{}
+		schedulerErr     error
+		expectedCode     int
+		expectedResults  []BulkResult
+		expectedStored   int
+		expectedSchedule int
+	}{
+		{
+			name:             "All valid",
+			body:             []SendNotificationRequest{valid, scheduled, valid},
+			expectedCode:     http.StatusOK,
+			expectedResults:  []BulkResult{{Index: 0, Success: true}, {Index: 1, Success: true}, {Index: 2, Success: true}},
+			expectedStored:   3,
+			expectedSchedule: 1,
+		},
+		{
+			name:            "Invalid entry rejects the batch",
+			body:            []SendNotificationRequest{valid, invalid, valid},
+			expectedCode:    http.StatusBadRequest,
+			expectedResults: []BulkResult{{Index: 1, Error: "Title and content are required"}},
+		},
+		{
+			name:            "Idempotency key",
+			body:            []SendNotificationRequest{{Title: "Bulk", Content: "Content", Channel: models.ChannelSlack, Recipients: []string{"U1"}, IdempotencyKey: "key"}},
+			expectedCode:    http.StatusBadRequest,
+			expectedResults: []BulkResult{{Index: 0, Error: "idempotency_key is not supported in bulk requests"}},
+		},
+		{
+			name:            "Failed entry",
+			body:            []SendNotificationRequest{valid, scheduled},
+			schedulerErr:    errors.New("scheduler down"),
+			expectedCode:    http.StatusOK,
+			expectedResults: []BulkResult{{Index: 0, Success: true}, {Index: 1, Error: "Failed to schedule notification: scheduler down"}},
+			expectedStored:  2,
+		},
+		{
+			name:         "Too many notifications",
+			body:         []SendNotificationRequest{valid, valid, valid, valid},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Empty batch",
+			body:         []SendNotificationRequest{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Not an array",
+			body:         valid,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &mock.MockSchedulerService{}
+			scheduler.SetError(tt.schedulerErr)
+			repo := repository.NewMemoryRepository()
+			handler := NewNotificationHandler(factory, scheduler, repo, &config.Config{BulkMaxNotifications: 3, BulkWorkers: 2})
+
+			body, _ := json.Marshal(tt.body)
+			rr := httptest.NewRecorder()
+			handler.SendBulkNotifications(rr, httptest.NewRequest(http.MethodPost, "/notifications/bulk", bytes.NewReader(body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+
+			var response BulkAPIResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Results) != len(tt.expectedResults) {
+				t.Fatalf("Expected %d results, got %+v", len(tt.expectedResults), response.Results)
+			}
+			for i, expected := range tt.expectedResults {
+				result := response.Results[i]
+				if result.Index != expected.Index || result.Success != expected.Success || result.Error != expected.Error {
+					t.Errorf("Expected result %+v, got %+v", expected, result)
+				}
+				if tt.expectedCode == http.StatusOK && result.NotificationID == "" {
+					t.Errorf("Expected result %d to carry the notification ID", i)
+				}
+			}
+
+			stored, _ := repo.ListAll(context.Background(), "")
+			if len(stored) != tt.expectedStored {
+				t.Errorf("Expected %d stored notifications, got %d", tt.expectedStored, len(stored))
+			}
+			if captured := len(scheduler.ScheduledNotifications()); captured != tt.expectedSchedule {
+				t.Errorf("Expected %d scheduled notifications, got %d", tt.expectedSchedule, captured)
+			}
+		})
+	}
+}
+
+func TestNotificationAuditEvents(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	audit := services.NewRepositoryAuditLog(repo)
+	handler := NewNotificationHandler(factory, &mock.MockSchedulerService{}, repo, &config.Config{})
+	handler.WithAuditLogger(audit)
+	admin := NewAdminHandler(nil)
+	admin.WithAuditLog(audit)
+
+	send := func(req SendNotificationRequest) string {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		handler.SendNotification(rr, r.WithContext(WithActorID(r.Context(), "user-1")))
+		var response struct {
+			Data models.Notification `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&response)
+		return response.Data.ID
+	}
+	immediate := send(SendNotificationRequest{Title: "Now", Content: "Content", Channel: models.ChannelSlack, Recipients: []string{"user1"}})
+	scheduled := send(SendNotificationRequest{Title: "Later", Content: "Content", Channel: models.ChannelSlack, Recipients: []string{"user1"}, ScheduledAt: time.Now().Add(time.Hour).Format(time.RFC3339)})
+
+	cancel := httptest.NewRequest(http.MethodDelete, "/notifications/"+scheduled, nil)
+	cancel.SetPathValue("id", scheduled)
+	handler.CancelNotification(httptest.NewRecorder(), cancel.WithContext(WithActorID(cancel.Context(), ActorAdmin)))
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedEvents []models.AuditEventType
+		expectedActors []string
+	}{
+		{"Immediate notification", "?notification_id=" + immediate, http.StatusOK, []models.AuditEventType{models.AuditCreated, models.AuditSent}, []string{"user-1", "user-1"}},
+		{"Cancelled notification", "?notification_id=" + scheduled, http.StatusOK, []models.AuditEventType{models.AuditCreated, models.AuditCancelled}, []string{"user-1", ActorAdmin}},
+		{"Unknown notification", "?notification_id=missing", http.StatusOK, []models.AuditEventType{}, []string{}},
+		{"Missing notification ID", "", http.StatusBadRequest, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			admin.AuditEvents(rr, httptest.NewRequest(http.MethodGet, "/admin/audit"+tt.query, nil))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedEvents == nil {
+				return
+			}
+
+			var response struct {
+				Data []models.AuditEvent `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if len(response.Data) != len(tt.expectedEvents) {
+				t.Fatalf("Expected %d events, got %+v", len(tt.expectedEvents), response.Data)
+			}
+			for i, event := range response.Data {
+				if event.EventType != tt.expectedEvents[i] || event.ActorID != tt.expectedActors[i] {
+					t.Errorf("Expected %s by %s, got %s by %s", tt.expectedEvents[i], tt.expectedActors[i], event.EventType, event.ActorID)
+				}
+			}
+		})
+	}
+}
+
+func TestPreviewNotification(t *testing.T) {
+	templates := services.NewTemplateService(repository.NewMemoryTemplateRepository())
+	templates.Register(context.Background(), &models.NotificationTemplate{
+		Name:            "welcome",
+		TitleTemplate:   "Welcome {{.Name}}",
+		ContentTemplate: "Thanks for joining, {{.Name}}!",
+	})
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(services.NewNotificationServiceFactory(&config.Config{}), nil, repo, &config.Config{})
+	handler.WithTemplateService(templates)
+
+	tests := []struct {
+		name             string
+		request          SendNotificationRequest
+		expectedCode     int
+		expectedChannels []models.NotificationChannel
+	}{
+		{
+			name:             "Template on one channel",
+			request:          SendNotificationRequest{TemplateName: "welcome", TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"U123"}},
+			expectedCode:     http.StatusOK,
+			expectedChannels: []models.NotificationChannel{models.ChannelSlack},
+		},
+		{
+			name:             "Fan-out",
+			request:          SendNotificationRequest{TemplateName: "welcome", TemplateData: map[string]interface{}{"Name": "Ana"}, Channels: []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail}, Recipients: []string{"ana@example.com"}},
+			expectedCode:     http.StatusOK,
+			expectedChannels: []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail},
+		},
+		{
+			name:         "Unknown template",
+			request:      SendNotificationRequest{TemplateName: "goodbye", Channel: models.ChannelSlack, Recipients: []string{"U123"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unsupported parse mode",
+			request:      SendNotificationRequest{Title: "Hi", Content: "There", Channel: models.ChannelTelegram, Recipients: []string{"42"}, Metadata: map[string]string{"parse_mode": "BBCode"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.request)
+			rr := httptest.NewRecorder()
+			handler.PreviewNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications/preview", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data NotificationPreviewResponse `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if response.Data.Title != "Welcome Ana" || response.Data.Content != "Thanks for joining, Ana!" {
+				t.Errorf("Expected the rendered template, got %q / %q", response.Data.Title, response.Data.Content)
+			}
+			if len(response.Data.Channels) != len(tt.expectedChannels) {
+				t.Fatalf("Expected %d channel previews, got %+v", len(tt.expectedChannels), response.Data.Channels)
+			}
+			for i, channel := range tt.expectedChannels {
+				if response.Data.Channels[i].Channel != channel {
+					t.Errorf("Expected preview %d for %s, got %s", i, channel, response.Data.Channels[i].Channel)
+				}
+			}
+			if slack := response.Data.Channels[0]; slack.Format != services.PreviewFormatSlackMrkdwn || !strings.HasPrefix(slack.Text, "*Welcome Ana*\n") {
+				t.Errorf("Expected Slack mrkdwn with a bold title, got %+v", slack)
+			}
+		})
+	}
+
+	page, _ := repo.List(context.Background(), repository.ListOptions{})
+	if page.TotalCount != 0 {
+		t.Errorf("Expected previews not to be stored, got %d notifications", page.TotalCount)
+	}
+}
+
+func TestSendNotificationSanitizesContent(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{EmailAllowedTags: []string{"p", "a"}})
+	html := map[string]string{services.EmailContentTypeMetadataKey: "text/html"}
+
+	tests := []struct {
+		name            string
+		channels        []models.NotificationChannel
+		content         string
+		metadata        map[string]string
+		expectedContent string
+	}{
+		{"Push keeps formatting", []models.NotificationChannel{models.ChannelFCM}, `<b>Hi</b><script>alert(1)</script> <a href="javascript:alert(1)">x</a>`, nil, "<b>Hi</b> x"},
+		{"HTML email keeps allowed tags", []models.NotificationChannel{models.ChannelEmail}, `<p>Hi <b>there</b> <a href="https://example.com">link</a></p>`, html, `<p>Hi there <a href="https://example.com" rel="nofollow">link</a></p>`},
+		{"Plain-text email is unchanged", []models.NotificationChannel{models.ChannelEmail}, "Use x < y & <b>", nil, "Use x < y & <b>"},
+		{"Slack strips every tag", []models.NotificationChannel{models.ChannelSlack}, "<p>Q&amp;A at <i>noon</i></p>", nil, "Q&A at noon"},
+		{"SMS without tags is unchanged", []models.NotificationChannel{models.ChannelMessage}, "Tom & Jerry", nil, "Tom & Jerry"},
+		{"Fan-out uses every channel's rules", []models.NotificationChannel{models.ChannelEmail, models.ChannelSlack}, "<p>Hi</p>", html, "Hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := SendNotificationRequest{Title: "Title", Content: tt.content, Recipients: []string{"+15551234567"}, Metadata: tt.metadata}
+			if len(tt.channels) == 1 {
+				request.Channel = tt.channels[0]
+			} else {
+				request.Channels = tt.channels
+			}
+			body, _ := json.Marshal(request)
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+			}
+			var response struct {
+				Data models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if response.Data.Content != tt.expectedContent {
+				t.Errorf("Expected content %q, got %q", tt.expectedContent, response.Data.Content)
+			}
+		})
+	}
+}
+
+func TestSendNotificationSenderAlias(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{})
+
+	tests := []struct {
+		name           string
+		alias          string
+		expectedStatus int
+	}{
+		{"Alias", "Reports Bot", http.StatusOK},
+		{"Blank", "   ", http.StatusBadRequest},
+		{"Too long", strings.Repeat("a", services.SenderAliasMaxLength+1), http.StatusBadRequest},
+		{"Header injection", "Bot\r\nBcc: victim@example.com", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(SendNotificationRequest{Title: "Report", Content: "Ready", Channel: models.ChannelEmail, Recipients: []string{"a@example.com"}, SenderAlias: tt.alias})
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if alias := response.Data.Metadata[services.SenderAliasMetadataKey]; alias != tt.alias {
+				t.Errorf("Expected sender alias %q in metadata, got %q", tt.alias, alias)
+			}
+		})
+	}
+}
+
+func TestSendNotificationFallbackChannels(t *testing.T) {
+	// Slack always fails, and email without an SMTP host is logged instead
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer slack.Close()
+	factory := services.NewNotificationServiceFactory(&config.Config{SlackWebhookURL: slack.URL})
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(factory, nil, repo, &config.Config{})
+
+	tests := []struct {
+		name           string
+		request        SendNotificationRequest
+		expectedStatus int
+	}{
+		{"Falls back", SendNotificationRequest{Channel: models.ChannelSlack, FallbackChannels: []models.NotificationChannel{models.ChannelEmail}}, http.StatusOK},
+		{"Unknown channel", SendNotificationRequest{Channel: models.ChannelSlack, FallbackChannels: []models.NotificationChannel{"carrier-pigeon"}}, http.StatusBadRequest},
+		{"Repeats channel", SendNotificationRequest{Channel: models.ChannelSlack, FallbackChannels: []models.NotificationChannel{models.ChannelSlack}}, http.StatusBadRequest},
+		{"Repeats fallback", SendNotificationRequest{Channel: models.ChannelSlack, FallbackChannels: []models.NotificationChannel{models.ChannelEmail, models.ChannelEmail}}, http.StatusBadRequest},
+		{"With channels", SendNotificationRequest{Channels: []models.NotificationChannel{models.ChannelSlack}, FallbackChannels: []models.NotificationChannel{models.ChannelEmail}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.request.Title, tt.request.Content, tt.request.Recipients = "Outage", "API is down", []string{"ops@example.com"}
+			body, _ := json.Marshal(tt.request)
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if via := response.Data.Metadata[services.DeliveredViaMetadataKey]; via != string(models.ChannelEmail) {
+				t.Errorf("Expected delivered_via email, got %q", via)
+			}
+			stored, _ := repo.GetByID(context.Background(), "", response.Data.ID)
+			if stored == nil || stored.Metadata[services.DeliveredViaMetadataKey] != string(models.ChannelEmail) {
+				t.Errorf("Expected delivered_via to be stored, got %+v", stored)
+			}
+		})
+	}
+}
+
+func TestSendNotificationTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	factory.WithTracer(tracer)
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{})
+	handler.WithTracer(tracer)
+
+	body, _ := json.Marshal(SendNotificationRequest{
+		Title:      "Traced",
+		Content:    "Follow me",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"user1", "user2"},
+	})
+	// As the tracing middleware leaves a request carrying a traceparent
+	caller := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9},
+		SpanID:     trace.SpanID{0x00, 0xf0},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	r := httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body))
+	r = r.WithContext(trace.ContextWithRemoteSpanContext(r.Context(), caller))
+	rr := httptest.NewRecorder()
+	handler.SendNotification(rr, r)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	request, send := spans["NotificationHandler.SendNotification"], spans["NotificationService.Send"]
+	if request == nil || send == nil {
+		t.Fatalf("Expected handler and channel spans, got %v", spans)
+	}
+	if request.Parent().SpanID() != caller.SpanID() || request.SpanContext().TraceID() != caller.TraceID() {
+		t.Errorf("Expected the handler span to continue the caller's trace, got parent %v", request.Parent())
+	}
+	if send.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("Expected the channel span to be a child of the handler span, got parent %v", send.Parent())
+	}
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range request.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	if attributes[tracing.NotificationIDKey].AsString() == "" || attributes[tracing.NotificationChannelKey].AsString() != "slack" ||
+		attributes[tracing.RecipientCountKey].AsInt64() != 2 || attributes[tracing.ScheduledKey].AsBool() {
+		t.Errorf("Unexpected handler span attributes %v", attributes)
+	}
 }
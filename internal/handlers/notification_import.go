@@ -0,0 +1,489 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"notification-service/internal/models"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ImportRowResult is the outcome of one row of an import. Row counts the
+// file's rows, or its objects for JSON, from 1; a CSV header is not counted.
+type ImportRowResult struct {
+	Row            int    `json:"row"`
+	NotificationID string `json:"notification_id,omitempty"`
+	Success        bool   `json:"success"`
+	Error          string `json:"error,omitempty"`
+}
+
+// ImportSummary counts the rows of an import. Truncated is set when the file
+// held more than MaxImportRows rows and the rest were not read.
+type ImportSummary struct {
+	Total     int  `json:"total"`
+	Succeeded int  `json:"succeeded"`
+	Failed    int  `json:"failed"`
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// BulkImportResponse reports an import. Success is set only when every row
+// was read and succeeded.
+type BulkImportResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Summary ImportSummary     `json:"summary"`
+	Results []ImportRowResult `json:"results"`
+}
+
+// importRow is a parsed row waiting to be prepared and dispatched.
+type importRow struct {
+	row int
+	req SendNotificationRequest
+	err error
+}
+
+// errImportTruncated stops reading a file that holds more rows than
+// MaxImportRows.
+var errImportTruncated = errors.New("import truncated")
+
+// rowLimitReader fails once more than limit bytes have been read since the
+// last call to nextRow, so that no single row of an import is read into
+// memory however large it is. A limit of zero or less reads everything.
+type rowLimitReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *rowLimitReader) Read(p []byte) (int, error) {
+	if l.limit <= 0 {
+		return l.r.Read(p)
+	}
+	if l.read >= l.limit {
+		return 0, fmt.Errorf("row is larger than %d bytes", l.limit)
+	}
+	if remaining := l.limit - l.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+// nextRow starts counting the bytes of the next row.
+func (l *rowLimitReader) nextRow() {
+	l.read = 0
+}
+
+// ImportNotifications takes a multipart/form-data request whose file field
+// holds notifications as CSV or JSON, and sends, schedules or repeats each
+// as it is read, so the file is never held in memory. Rows are limited to
+// MaxRequestBodyBytes each instead of the whole body. Unlike a bulk request
+// every row stands alone: an invalid row is reported and the rest are still
+// processed. A row that cannot be parsed at all, or is too large, ends the
+// import there.
+func (h *NotificationHandler) ImportNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Expected a multipart/form-data request",
+		})
+		return
+	}
+	var file io.Reader
+	var format string
+	rowLimit := &rowLimitReader{}
+	if h.config != nil {
+		rowLimit.limit = h.config.MaxRequestBodyBytes
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Missing file field",
+			})
+			return
+		}
+		if part.FormName() == "file" {
+			rowLimit.r = part
+			buffered := bufio.NewReader(rowLimit)
+			file, format = buffered, importFormat(part.Header.Get("Content-Type"), part.FileName(), buffered)
+			break
+		}
+	}
+
+	rows := make(chan importRow)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(rows)
+		emit := func(row importRow) error {
+			rowLimit.nextRow()
+			if h.config != nil && h.config.MaxImportRows > 0 && row.row > h.config.MaxImportRows {
+				return errImportTruncated
+			}
+			select {
+			case rows <- row:
+				return nil
+			case <-r.Context().Done():
+				return r.Context().Err()
+			}
+		}
+		if format == "csv" {
+			readErr <- readImportCSV(file, emit)
+		} else {
+			readErr <- readImportJSON(file, emit)
+		}
+	}()
+
+	workers := 1
+	if h.config != nil && h.config.BulkWorkers > 1 {
+		workers = h.config.BulkWorkers
+	}
+	var results []ImportRowResult
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range rows {
+				result := h.importRow(r, row)
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	err = <-readErr
+
+	summary := ImportSummary{Total: len(results), Truncated: errors.Is(err, errImportTruncated)}
+	var malformed *importRowError
+	if errors.As(err, &malformed) {
+		results = append(results, ImportRowResult{Row: malformed.row, Error: malformed.Error()})
+		summary.Total++
+	} else if err != nil && !summary.Truncated {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid file: " + err.Error(),
+		})
+		return
+	}
+	if summary.Total == 0 {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "The file holds no notifications",
+		})
+		return
+	}
+
+	slices.SortFunc(results, func(a, b ImportRowResult) int { return a.Row - b.Row })
+	for _, result := range results {
+		if result.Success {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+	message := fmt.Sprintf("Processed %d notifications, %d failed", summary.Total, summary.Failed)
+	switch {
+	case summary.Truncated:
+		message += fmt.Sprintf("; rows after the first %d were not read", h.config.MaxImportRows)
+	case malformed != nil:
+		message += fmt.Sprintf("; the file could not be read past row %d", malformed.row)
+	}
+	sendJSON(w, http.StatusOK, BulkImportResponse{
+		Success: summary.Failed == 0 && err == nil,
+		Message: message,
+		Summary: summary,
+		Results: results,
+	})
+}
+
+// importRow validates one row and sends, schedules or repeats it.
+func (h *NotificationHandler) importRow(r *http.Request, row importRow) ImportRowResult {
+	result := ImportRowResult{Row: row.row}
+	if row.err != nil {
+		result.Error = row.err.Error()
+		return result
+	}
+	if row.req.IdempotencyKey != "" {
+		result.Error = "idempotency_key is not supported in imports"
+		return result
+	}
+	notification, reqErr := h.prepare(r.Context(), &row.req)
+	if reqErr != nil {
+		result.Error = reqErr.Error()
+		return result
+	}
+	status, response := h.dispatch(r, row.req, notification)
+	result.NotificationID, result.Success = notification.ID, response.Success
+	if status >= http.StatusBadRequest {
+		result.Error = response.Message
+	}
+	return result
+}
+
+// importFormat returns "csv" or "json" from the file's content type or
+// extension, or failing those from its first character.
+func importFormat(contentType, filename string, file *bufio.Reader) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/csv" || strings.EqualFold(path.Ext(filename), ".csv"):
+		return "csv"
+	case mediaType == "application/json" || strings.EqualFold(path.Ext(filename), ".json"):
+		return "json"
+	}
+	if b, err := peekNonSpace(file); err == nil && (b == '[' || b == '{') {
+		return "json"
+	}
+	return "csv"
+}
+
+// importRowError is a row that could not be parsed, after which the rest of
+// the file cannot be read either.
+type importRowError struct {
+	row int
+	err error
+}
+
+func (e *importRowError) Error() string { return "malformed row: " + e.err.Error() }
+
+// readImportJSON reads either an array of notification requests or a
+// sequence of them, such as newline-delimited JSON, one at a time.
+func readImportJSON(file io.Reader, emit func(importRow) error) error {
+	decoder := json.NewDecoder(file)
+	array := false
+	if peeked, ok := file.(*bufio.Reader); ok {
+		if b, err := peekNonSpace(peeked); err == nil && b == '[' {
+			if _, err := decoder.Token(); err != nil {
+				return err
+			}
+			array = true
+		}
+	}
+
+	for row := 1; ; row++ {
+		if array && !decoder.More() {
+			return nil
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF && !array {
+				return nil
+			}
+			return &importRowError{row: row, err: err}
+		}
+		var req SendNotificationRequest
+		err := json.Unmarshal(raw, &req)
+		if err != nil {
+			err = fmt.Errorf("invalid notification: %w", err)
+		}
+		if err := emit(importRow{row: row, req: req, err: err}); err != nil {
+			return err
+		}
+	}
+}
+
+// peekNonSpace returns the first byte of file that is not whitespace,
+// consuming the whitespace before it.
+func peekNonSpace(file *bufio.Reader) (byte, error) {
+	for {
+		b, err := file.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			return b[0], nil
+		}
+		file.ReadByte()
+	}
+}
+
+// readImportCSV reads a CSV file whose header names the columns of each
+// row. Columns are named after the JSON fields of a SendNotificationRequest;
+// list fields separate their values with semicolons, and metadata.<key> and
+// template_data.<key> columns set a single entry. Empty cells are left out.
+func readImportCSV(file io.Reader, emit func(importRow) error) error {
+	reader := csv.NewReader(file)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := slices.Clone(header)
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if !isImportColumn(columns[i]) {
+			return fmt.Errorf("unknown CSV column %q", columns[i])
+		}
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			// The row is intact, just the wrong width
+			if err := emit(importRow{row: row, err: fmt.Errorf("row has %d fields, header has %d", len(record), len(columns))}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return &importRowError{row: row, err: err}
+		}
+		req, err := parseImportRecord(columns, record)
+		if err := emit(importRow{row: row, req: req, err: err}); err != nil {
+			return err
+		}
+	}
+}
+
+// importListColumns are the CSV columns holding semicolon-separated lists.
+var importListColumns = map[string]bool{"recipients": true, "channels": true, "user_ids": true, "fallback_channels": true}
+
+// importColumns are the other CSV columns of a SendNotificationRequest.
+var importColumns = map[string]bool{
+	"tenant_id": true, "title": true, "content": true, "channel": true, "category": true, "locale": true,
+	"scheduled_at": true, "timezone": true, "cron_expression": true, "expires_at": true, "digest": true,
+	"digest_key": true, "priority": true, "template_name": true, "template_version": true,
+	"callback_url": true, "sender_alias": true,
+}
+
+func isImportColumn(column string) bool {
+	if key, ok := strings.CutPrefix(column, "metadata."); ok {
+		return key != ""
+	}
+	if key, ok := strings.CutPrefix(column, "template_data."); ok {
+		return key != ""
+	}
+	return importColumns[column] || importListColumns[column]
+}
+
+// parseImportRecord builds the request described by one CSV row.
+func parseImportRecord(columns, record []string) (SendNotificationRequest, error) {
+	var req SendNotificationRequest
+	for i, column := range columns {
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+		if importListColumns[column] {
+			var list []string
+			for _, item := range strings.Split(value, ";") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			switch column {
+			case "recipients":
+				req.Recipients = list
+			case "user_ids":
+				req.UserIDs = list
+			case "channels":
+				req.Channels = importChannels(list)
+			case "fallback_channels":
+				req.FallbackChannels = importChannels(list)
+			}
+			continue
+		}
+		if key, ok := strings.CutPrefix(column, "metadata."); ok {
+			if req.Metadata == nil {
+				req.Metadata = make(map[string]string)
+			}
+			req.Metadata[key] = value
+			continue
+		}
+		if key, ok := strings.CutPrefix(column, "template_data."); ok {
+			if req.TemplateData == nil {
+				req.TemplateData = make(map[string]interface{})
+			}
+			req.TemplateData[key] = value
+			continue
+		}
+
+		switch column {
+		case "tenant_id":
+			req.TenantID = value
+		case "title":
+			req.Title = value
+		case "content":
+			req.Content = value
+		case "channel":
+			req.Channel = models.NotificationChannel(value)
+		case "category":
+			req.Category = value
+		case "locale":
+			req.Locale = value
+		case "scheduled_at":
+			req.ScheduledAt = value
+		case "timezone":
+			req.Timezone = value
+		case "cron_expression":
+			req.CronExpression = value
+		case "expires_at":
+			req.ExpiresAt = value
+		case "digest_key":
+			req.DigestKey = value
+		case "template_name":
+			req.TemplateName = value
+		case "callback_url":
+			req.CallbackURL = value
+		case "sender_alias":
+			req.SenderAlias = value
+		case "digest":
+			digest, err := strconv.ParseBool(value)
+			if err != nil {
+				return req, fmt.Errorf("invalid digest %q: must be true or false", value)
+			}
+			req.Digest = digest
+		case "priority":
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return req, fmt.Errorf("invalid priority %q: must be an integer", value)
+			}
+			p := models.NotificationPriority(priority)
+			req.Priority = &p
+		case "template_version":
+			version, err := strconv.Atoi(value)
+			if err != nil {
+				return req, fmt.Errorf("invalid template_version %q: must be an integer", value)
+			}
+			req.TemplateVersion = version
+		}
+	}
+	return req, nil
+}
+
+func importChannels(list []string) []models.NotificationChannel {
+	channels := make([]models.NotificationChannel, len(list))
+	for i, channel := range list {
+		channels[i] = models.NotificationChannel(channel)
+	}
+	return channels
+}
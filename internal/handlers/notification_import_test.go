@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+// importRequest returns a multipart request uploading content as the file
+// field under filename.
+func importRequest(filename, content string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write([]byte(content))
+	writer.Close()
+	r := httptest.NewRequest(http.MethodPost, "/notifications/import", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func TestImportNotifications(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	later := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name             string
+		filename         string
+		content          string
+		expectedCode     int
+		expectedSummary  ImportSummary
+		expectedResults  []ImportRowResult
+		expectedStored   int
+		expectedSchedule int
+	}{
+		{
+			name:     "CSV",
+			filename: "notifications.csv",
+			content: "title,content,channel,recipients,scheduled_at,priority,metadata.team\n" +
+				"Deploy,Done,slack,U1;U2,,2,ops\n" +
+				"Digest,Later,email,ana@example.com," + later + ",,\n",
+			expectedCode:     http.StatusOK,
+			expectedSummary:  ImportSummary{Total: 2, Succeeded: 2},
+			expectedResults:  []ImportRowResult{{Row: 1, Success: true}, {Row: 2, Success: true}},
+			expectedStored:   2,
+			expectedSchedule: 1,
+		},
+		{
+			name:     "CSV invalid rows",
+			filename: "notifications.csv",
+			content: "title,content,channel,recipients,priority\n" +
+				"Deploy,,slack,U1,\n" +
+				"Deploy,Done,slack,U1,urgent\n" +
+				"Deploy,Done,slack\n" +
+				"Deploy,Done,slack,U1,\n",
+			expectedCode:    http.StatusOK,
+			expectedSummary: ImportSummary{Total: 4, Succeeded: 1, Failed: 3},
+			expectedResults: []ImportRowResult{
+				{Row: 1, Error: "Title and content are required"},
+				{Row: 2, Error: `invalid priority "urgent": must be an integer`},
+				{Row: 3, Error: "row has 3 fields, header has 5"},
+				{Row: 4, Success: true},
+			},
+			expectedStored: 1,
+		},
+		{
+			name:         "Unknown CSV column",
+			filename:     "notifications.csv",
+			content:      "title,body\nDeploy,Done\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:     "JSON array",
+			filename: "notifications.json",
+			content: `[{"title":"Deploy","content":"Done","channel":"slack","recipients":["U1"]},
+				{"title":"Deploy","content":"Done","channel":"slack","recipients":["U1"],"idempotency_key":"key"}]`,
+			expectedCode:    http.StatusOK,
+			expectedSummary: ImportSummary{Total: 2, Succeeded: 1, Failed: 1},
+			expectedResults: []ImportRowResult{{Row: 1, Success: true}, {Row: 2, Error: "idempotency_key is not supported in imports"}},
+			expectedStored:  1,
+		},
+		{
+			name:     "Newline-delimited JSON detected from content",
+			filename: "notifications.txt",
+			content: `{"title":"Deploy","content":"Done","channel":"slack","recipients":["U1"]}
+{"title":"Deploy","content":"Done","channel":"slack","recipients":"U1"}
+{"title":"Deploy","content":"Done","channel":"slack","recipients":["U1"]}
+{"title":`,
+			expectedCode:    http.StatusOK,
+			expectedSummary: ImportSummary{Total: 4, Succeeded: 2, Failed: 2},
+			expectedResults: []ImportRowResult{
+				{Row: 1, Success: true},
+				{Row: 2, Error: "invalid notification: json: cannot unmarshal string into Go struct field SendNotificationRequest.recipients of type []string"},
+				{Row: 3, Success: true},
+				{Row: 4, Error: "malformed row: unexpected EOF"},
+			},
+			expectedStored: 2,
+		},
+		{
+			name:     "Rows over the cap",
+			filename: "notifications.csv",
+			content: "title,content,channel,recipients\n" +
+				strings.Repeat("Deploy,Done,slack,U1\n", 5),
+			expectedCode:    http.StatusOK,
+			expectedSummary: ImportSummary{Total: 4, Succeeded: 4, Truncated: true},
+			expectedResults: []ImportRowResult{{Row: 1, Success: true}, {Row: 2, Success: true}, {Row: 3, Success: true}, {Row: 4, Success: true}},
+			expectedStored:  4,
+		},
+		{
+			name:     "Oversized CSV row",
+			filename: "notifications.csv",
+			content: "title,content,channel,recipients\n" +
+				"Deploy,Done,slack,U1\n" +
+				"Deploy," + strings.Repeat("x", 2048) + ",slack,U1\n" +
+				"Deploy,Done,slack,U1\n",
+			expectedCode:    http.StatusOK,
+			expectedSummary: ImportSummary{Total: 2, Succeeded: 1, Failed: 1},
+			expectedResults: []ImportRowResult{{Row: 1, Success: true}, {Row: 2, Error: "malformed row: row is larger than 1024 bytes"}},
+			expectedStored:  1,
+		},
+		{
+			name:     "Oversized JSON row",
+			filename: "notifications.json",
+			content: `[{"title":"Deploy","content":"Done","channel":"slack","recipients":["U1"]},
+				{"title":"Deploy","content":"` + strings.Repeat("x", 2048) + `","channel":"slack","recipients":["U1"]}]`,
+			expectedCode:    http.StatusOK,
+			expectedSummary: ImportSummary{Total: 2, Succeeded: 1, Failed: 1},
+			expectedResults: []ImportRowResult{{Row: 1, Success: true}, {Row: 2, Error: "malformed row: row is larger than 1024 bytes"}},
+			expectedStored:  1,
+		},
+		{
+			name:         "Empty file",
+			filename:     "notifications.csv",
+			content:      "title,content,channel,recipients\n",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &mock.MockSchedulerService{}
+			repo := repository.NewMemoryRepository()
+			handler := NewNotificationHandler(factory, scheduler, repo, &config.Config{MaxImportRows: 4, BulkWorkers: 2, MaxRequestBodyBytes: 1024})
+
+			rr := httptest.NewRecorder()
+			handler.ImportNotifications(rr, importRequest(tt.filename, tt.content))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response BulkImportResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Summary != tt.expectedSummary {
+				t.Errorf("Expected summary %+v, got %+v", tt.expectedSummary, response.Summary)
+			}
+			if response.Success != (tt.expectedSummary.Failed == 0 && !tt.expectedSummary.Truncated) {
+				t.Errorf("Expected success only when every row was read and sent, got %+v", response)
+			}
+			if len(response.Results) != len(tt.expectedResults) {
+				t.Fatalf("Expected %d results, got %+v", len(tt.expectedResults), response.Results)
+			}
+			for i, expected := range tt.expectedResults {
+				result := response.Results[i]
+				if result.Row != expected.Row || result.Success != expected.Success || result.Error != expected.Error {
+					t.Errorf("Expected result %+v, got %+v", expected, result)
+				}
+				if result.Success && result.NotificationID == "" {
+					t.Errorf("Expected row %d to carry the notification ID", result.Row)
+				}
+			}
+
+			stored, _ := repo.ListAll(context.Background(), "")
+			if len(stored) != tt.expectedStored {
+				t.Errorf("Expected %d stored notifications, got %d", tt.expectedStored, len(stored))
+			}
+			if captured := len(scheduler.ScheduledNotifications()); captured != tt.expectedSchedule {
+				t.Errorf("Expected %d scheduled notifications, got %d", tt.expectedSchedule, captured)
+			}
+		})
+	}
+}
+
+func TestImportNotificationsCSVFields(t *testing.T) {
+	columns := []string{"title", "content", "channels", "recipients", "priority", "digest", "template_version", "metadata.team", "template_data.name"}
+	req, err := parseImportRecord(columns, []string{"Deploy", "Done", "slack; email", "U1;ana@example.com", "2", "true", "3", "ops", "Ana"})
+	if err != nil {
+		t.Fatalf("Failed to parse row: %v", err)
+	}
+	if len(req.Channels) != 2 || req.Channels[1] != models.ChannelEmail || len(req.Recipients) != 2 {
+		t.Errorf("Expected semicolon-separated lists, got %v %v", req.Channels, req.Recipients)
+	}
+	if req.Priority == nil || *req.Priority != models.PriorityHigh || !req.Digest || req.TemplateVersion != 3 {
+		t.Errorf("Expected typed columns parsed, got %+v", req)
+	}
+	if req.Metadata["team"] != "ops" || req.TemplateData["name"] != "Ana" {
+		t.Errorf("Expected prefixed columns in the maps, got %v %v", req.Metadata, req.TemplateData)
+	}
+}
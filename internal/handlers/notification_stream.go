@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"time"
+)
+
+const (
+	// DefaultStreamTimeout bounds notification streams when no timeout is
+	// configured.
+	DefaultStreamTimeout = 5 * time.Minute
+	// streamKeepAliveInterval is how often an idle stream sends a comment,
+	// so proxies do not close it.
+	streamKeepAliveInterval = 15 * time.Second
+)
+
+// StreamNotification pushes the status of the notification named in the
+// path as server-sent events. A "status" event carrying a
+// NotificationStatusResponse is sent straight away and on every status
+// change. Once the notification leaves pending, by being sent or failing,
+// a "done" event repeats the final status and the stream is closed. A
+// "timeout" event closes streams that outlive the configured timeout.
+func (h *NotificationHandler) StreamNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+	if h.events == nil {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification streams are not enabled",
+		})
+		return
+	}
+
+	id := r.PathValue("id")
+	tenantID := TenantID(r.Context())
+	// Subscribing first means no change is missed while the notification
+	// is loaded
+	events, unsubscribe := h.events.Subscribe(tenantID, id)
+	defer unsubscribe()
+	notification, err := h.repository.GetByID(r.Context(), tenantID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Notification not found: " + id,
+		})
+		return
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to load notification: " + err.Error(),
+		})
+		return
+	}
+
+	log := logging.FromContext(r.Context(), h.logger)
+	timeout := h.streamTimeout()
+	controller := http.NewResponseController(w)
+	// The server's write timeout would cut the stream short; a second more
+	// than the stream's own timeout leaves time for its final event
+	controller.SetWriteDeadline(time.Now().Add(timeout + time.Second))
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	flush := func() bool {
+		if err := controller.Flush(); err != nil {
+			log.Debug("Error flushing notification stream", "notification_id", id, "error", err)
+			return false
+		}
+		return true
+	}
+	send := func(event string) bool {
+		data, _ := json.Marshal(newNotificationStatusResponse(notification))
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		return flush()
+	}
+	// sendStatus reports whether the stream stays open
+	sendStatus := func() bool {
+		if !send("status") {
+			return false
+		}
+		if notification.Status == models.StatusPending {
+			return true
+		}
+		send("done")
+		return false
+	}
+
+	if !sendStatus() {
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+			send("timeout")
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil || !flush() {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			switch event.Type {
+			case models.EventStatusChanged:
+				if err := json.Unmarshal(event.Payload, notification); err != nil {
+					log.Warn("Error decoding status change", "notification_id", id, "error", err)
+					continue
+				}
+				if !sendStatus() {
+					return
+				}
+			case models.EventDeleted:
+				return
+			}
+		}
+	}
+}
+
+func (h *NotificationHandler) streamTimeout() time.Duration {
+	if h.config == nil || h.config.StreamTimeout <= 0 {
+		return DefaultStreamTimeout
+	}
+	return h.config.StreamTimeout
+}
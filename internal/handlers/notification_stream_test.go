@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamEvent is a server-sent event read from a notification stream.
+type streamEvent struct {
+	name   string
+	status NotificationStatusResponse
+}
+
+// readStream returns the events of the stream in body until it is closed.
+// Comments are skipped.
+func readStream(t *testing.T, body *bufio.Reader) []streamEvent {
+	t.Helper()
+	var (
+		events []streamEvent
+		name   string
+	)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return events
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event := streamEvent{name: name}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.status); err != nil {
+				t.Fatalf("Failed to decode event data %q: %v", line, err)
+			}
+			events = append(events, event)
+		}
+	}
+}
+
+func newStreamTestServer(cfg *config.Config) (*httptest.Server, *repository.MemoryRepository) {
+	repo := repository.NewMemoryRepository()
+	bus := services.NewEventBus()
+	repo.WithEventPublisher(bus)
+	handler := NewNotificationHandler(nil, nil, repo, cfg)
+	handler.WithEventBus(bus)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /notifications/{id}/stream", handler.StreamNotification)
+	return httptest.NewServer(LoggingMiddleware(nil, mux)), repo
+}
+
+func TestStreamNotification(t *testing.T) {
+	server, repo := newStreamTestServer(nil)
+	defer server.Close()
+	ctx := context.Background()
+	repo.Save(ctx, &models.Notification{ID: "streamed", Channel: models.ChannelSlack, Status: models.StatusPending, CreatedAt: time.Now()})
+
+	resp, err := http.Get(server.URL + "/notifications/streamed/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %q", contentType)
+	}
+	body := bufio.NewReader(resp.Body)
+	// The first event is flushed before anything changes
+	for line := ""; line != "\n"; {
+		if line, err = body.ReadString('\n'); err != nil {
+			t.Fatalf("Failed to read the first event: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") && !strings.Contains(line, `"status":"pending"`) {
+			t.Errorf("Expected the pending status, got %q", line)
+		}
+	}
+
+	repo.RecordOpen(ctx, "", "streamed", time.Now())
+	repo.UpdateStatus(ctx, "", "streamed", repository.StatusUpdate{Status: models.StatusFailed, FailureReason: "webhook returned 500"})
+	events := readStream(t, body)
+	if len(events) != 2 {
+		t.Fatalf("Expected status and done events, got %+v", events)
+	}
+	for i, name := range []string{"status", "done"} {
+		if events[i].name != name || events[i].status.Status != models.StatusFailed || events[i].status.FailureReason != "webhook returned 500" {
+			t.Errorf("Expected a %s event with the failure, got %+v", name, events[i])
+		}
+	}
+}
+
+func TestStreamNotificationRequests(t *testing.T) {
+	server, repo := newStreamTestServer(&config.Config{StreamTimeout: 50 * time.Millisecond})
+	defer server.Close()
+	ctx := context.Background()
+	sentAt := time.Now().UTC()
+	repo.Save(ctx, &models.Notification{ID: "sent", Channel: models.ChannelSlack, Status: models.StatusSent, SentAt: &sentAt})
+	repo.Save(ctx, &models.Notification{ID: "pending", Channel: models.ChannelSlack, Status: models.StatusPending})
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedCode   int
+		expectedEvents []string
+	}{
+		{"Already sent", "sent", http.StatusOK, []string{"status", "done"}},
+		{"Timeout", "pending", http.StatusOK, []string{"status", "timeout"}},
+		{"Not found", "missing", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "/notifications/" + tt.id + "/stream")
+			if err != nil {
+				t.Fatalf("Failed to open stream: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, resp.StatusCode)
+			}
+			if tt.expectedEvents == nil {
+				return
+			}
+			var names []string
+			for _, event := range readStream(t, bufio.NewReader(resp.Body)) {
+				names = append(names, event.name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedEvents, ",") {
+				t.Errorf("Expected events %v, got %v", tt.expectedEvents, names)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	NewNotificationHandler(nil, nil, repo, nil).StreamNotification(rr, httptest.NewRequest(http.MethodGet, "/notifications/sent/stream", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d without an event bus, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/services"
+	"strconv"
+)
+
+// IPRateLimitMiddleware passes requests on to next while their client IP
+// stays within limits, as counted by limiter, and rejects the rest with 429
+// and a Retry-After header. Every request first counts against the IP's
+// authenticated rate, before authenticated checks its credentials, so
+// guessing keys or tokens is limited too. Requests that authenticated does
+// not report as carrying valid credentials also count against the anonymous
+// rate. If limiter fails the request is let through, so an unavailable Redis
+// does not take the API down with it.
+func IPRateLimitMiddleware(limiter services.RequestLimiter, limits config.HTTPRateLimitConfig, authenticated func(r *http.Request) bool, logger logging.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		if !allowRequest(w, r, limiter, "client:"+ip, max(limits.RequestsPerMinute, limits.AuthenticatedRequestsPerMinute)+limits.BurstSize, logger) {
+			return
+		}
+		if authenticated == nil || !authenticated(r) {
+			if !allowRequest(w, r, limiter, "anonymous:"+ip, limits.RequestsPerMinute+limits.BurstSize, logger) {
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowRequest counts a request against key's limit and reports whether it
+// is within it, rejecting it with 429 if not. Limiter errors are logged and
+// the request allowed.
+func allowRequest(w http.ResponseWriter, r *http.Request, limiter services.RequestLimiter, key string, limit int, logger logging.Logger) bool {
+	allowed, retryAfter, err := limiter.Allow(r.Context(), key, limit)
+	if err != nil {
+		logging.FromContext(r.Context(), logger).Error("Error checking HTTP rate limit", "key", key, "error", err)
+		return true
+	}
+	if !allowed {
+		// Retry-After is in whole seconds, so round up
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+		sendJSONResponse(w, http.StatusTooManyRequests, APIResponse{
+			Success: false,
+			Message: "Rate limit exceeded",
+		})
+	}
+	return allowed
+}
+
+// clientIP returns the IP address a request came from. Forwarding headers
+// are ignored, since clients could set them to dodge their limit.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/services"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIPRateLimitMiddleware(t *testing.T) {
+	limits := config.HTTPRateLimitConfig{RequestsPerMinute: 3, BurstSize: 2, AuthenticatedRequestsPerMinute: 8}
+	authenticated := func(r *http.Request) bool { return r.Header.Get(APIKeyHeader) == "valid" }
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		apiKey     string
+		allowed    int
+	}{
+		{"Anonymous", "192.0.2.1:1234", "", 5},
+		{"Invalid credentials", "192.0.2.2:1234", "invalid", 5},
+		{"Authenticated", "192.0.2.3:1234", "valid", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := IPRateLimitMiddleware(services.NewSlidingWindowLimiter(time.Minute), limits, authenticated, logging.Default(),
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+
+			for i := 1; i <= tt.allowed+1; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+				req.RemoteAddr = tt.remoteAddr
+				if tt.apiKey != "" {
+					req.Header.Set(APIKeyHeader, tt.apiKey)
+				}
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+
+				if i <= tt.allowed {
+					if rr.Code != http.StatusOK {
+						t.Fatalf("Expected status %d for request %d, got %d", http.StatusOK, i, rr.Code)
+					}
+					continue
+				}
+				if rr.Code != http.StatusTooManyRequests {
+					t.Fatalf("Expected status %d for request %d, got %d", http.StatusTooManyRequests, i, rr.Code)
+				}
+				retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+				if err != nil || retryAfter < 1 || retryAfter > 120 {
+					t.Errorf("Expected Retry-After in seconds, got %q", rr.Header().Get("Retry-After"))
+				}
+			}
+
+			// Other clients keep their own allowance
+			req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+			req.RemoteAddr = "198.51.100.1:1234"
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected status %d for another client, got %d", http.StatusOK, rr.Code)
+			}
+		})
+	}
+}
+
+func TestIPRateLimitMiddlewareLimitsCredentialChecks(t *testing.T) {
+	limits := config.HTTPRateLimitConfig{RequestsPerMinute: 3, BurstSize: 2, AuthenticatedRequestsPerMinute: 8}
+	checks := 0
+	authenticated := func(r *http.Request) bool {
+		checks++
+		return false
+	}
+	handler := IPRateLimitMiddleware(services.NewSlidingWindowLimiter(time.Minute), limits, authenticated, logging.Default(),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	for i := 0; i < 20; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(APIKeyHeader, "guess")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if checks != 10 {
+		t.Errorf("Expected credentials checked only within the authenticated rate of 10, got %d checks", checks)
+	}
+}
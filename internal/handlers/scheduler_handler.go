@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"notification-service/internal/services"
+)
+
+// JobLister lists the notifications waiting in a scheduler.
+type JobLister interface {
+	Jobs(ctx context.Context) ([]services.ScheduledJob, error)
+}
+
+type SchedulerHandler struct {
+	scheduler JobLister
+}
+
+func NewSchedulerHandler(scheduler JobLister) *SchedulerHandler {
+	return &SchedulerHandler{scheduler: scheduler}
+}
+
+// Jobs returns every scheduled and recurring notification with when it next
+// fires, soonest first, on GET. Jobs of every tenant are listed, so the
+// route must require the admin role even with authentication off.
+func (h *SchedulerHandler) Jobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	jobs, err := h.scheduler.Jobs(r.Context())
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to list scheduled jobs: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Scheduled jobs retrieved successfully",
+		Data:    jobs,
+	})
+}
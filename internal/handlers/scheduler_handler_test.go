@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"notification-service/internal/services"
+	"notification-service/internal/services/mock"
+	"testing"
+	"time"
+)
+
+func TestSchedulerHandlerJobs(t *testing.T) {
+	scheduler := services.NewSchedulerService(&mock.MockNotificationService{}, nil)
+	scheduledAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	scheduler.ScheduleNotification(context.Background(), &models.Notification{ID: "scheduled", Channel: models.ChannelEmail, Recipients: []string{"a@example.com"}, ScheduledAt: &scheduledAt})
+	handler := NewSchedulerHandler(scheduler)
+
+	tests := []struct {
+		name         string
+		method       string
+		expectedCode int
+	}{
+		{"List jobs", http.MethodGet, http.StatusOK},
+		{"Wrong method", http.MethodPost, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.Jobs(rr, httptest.NewRequest(tt.method, "/scheduler/jobs", nil))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	handler.Jobs(rr, httptest.NewRequest(http.MethodGet, "/scheduler/jobs", nil))
+	var response struct {
+		Data []map[string]any `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 {
+		t.Fatalf("Expected 1 job, got %+v", response.Data)
+	}
+	job := response.Data[0]
+	if job["notification_id"] != "scheduled" || job["channel"] != "email" || job["fires_at"] != scheduledAt.Format(time.RFC3339) {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+}
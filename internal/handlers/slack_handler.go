@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/services"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSlackEventBytes bounds the body read from a Slack event callback.
+const maxSlackEventBytes = 1 << 20
+
+// SlackEventRequest is a Slack Events API request: either a url_verification
+// challenge or an event_callback wrapping a single event.
+type SlackEventRequest struct {
+	Type      string     `json:"type"`
+	Challenge string     `json:"challenge,omitempty"`
+	Event     SlackEvent `json:"event"`
+}
+
+// SlackEvent is the part of a Slack event the service reads. Metadata is
+// only present on messages posted with message metadata.
+type SlackEvent struct {
+	Type     string                         `json:"type"`
+	Subtype  string                         `json:"subtype,omitempty"`
+	Channel  string                         `json:"channel,omitempty"`
+	TS       string                         `json:"ts,omitempty"`
+	Metadata *services.SlackMessageMetadata `json:"metadata,omitempty"`
+}
+
+// SlackHandler receives event callbacks from the Slack Events API.
+type SlackHandler struct {
+	signingSecret string
+	receipts      *services.DeliveryReceiptService
+}
+
+func NewSlackHandler(signingSecret string, receipts *services.DeliveryReceiptService) *SlackHandler {
+	return &SlackHandler{signingSecret: signingSecret, receipts: receipts}
+}
+
+// Events verifies the request's X-Slack-Signature and answers url_verification
+// challenges. A message event carrying the metadata the Slack channel attaches
+// to every notification marks that notification delivered. Other events are
+// acknowledged and ignored, as are receipts that cannot be applied, since
+// Slack would otherwise retry them.
+func (h *SlackHandler) Events(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSlackEventBytes))
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	if !services.VerifySlackSignature(h.signingSecret, timestamp, body, r.Header.Get("X-Slack-Signature"), time.Now()) {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid Slack signature",
+		})
+		return
+	}
+
+	var req SlackEventRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	switch req.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, req.Challenge)
+		return
+	case "event_callback":
+		h.recordDelivery(r, req.Event)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// recordDelivery marks the notification identified by event's metadata as
+// delivered at the time Slack posted the message.
+func (h *SlackHandler) recordDelivery(r *http.Request, event SlackEvent) {
+	if event.Type != "message" || event.Metadata == nil || event.Metadata.EventType != services.SlackSentEventType {
+		return
+	}
+	receipt := services.DeliveryReceipt{
+		TenantID:       event.Metadata.EventPayload["tenant_id"],
+		NotificationID: event.Metadata.EventPayload["notification_id"],
+		Channel:        models.ChannelSlack,
+		Status:         models.StatusDelivered,
+		At:             parseSlackTimestamp(event.TS),
+	}
+	if receipt.NotificationID == "" {
+		return
+	}
+	if _, err := h.receipts.Record(r.Context(), receipt); err != nil {
+		logging.FromContext(r.Context(), nil).Warn("Error recording Slack delivery", "notification_id", receipt.NotificationID, "error", err)
+	}
+}
+
+// parseSlackTimestamp converts a message ts such as "1700000000.000100" to a
+// time, returning the zero time if ts is malformed.
+func parseSlackTimestamp(ts string) time.Time {
+	secondsPart, microsPart, _ := strings.Cut(ts, ".")
+	seconds, err := strconv.ParseInt(secondsPart, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	var micros int64
+	if microsPart != "" {
+		if micros, err = strconv.ParseInt(microsPart, 10, 64); err != nil {
+			return time.Time{}
+		}
+	}
+	return time.Unix(seconds, micros*int64(time.Microsecond)).UTC()
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	handler := NewSlackHandler("signing-secret", services.NewDeliveryReceiptService(repo))
+	repo.Save(ctx, &models.Notification{ID: "slack-1", Channel: models.ChannelSlack, Status: models.StatusSent})
+	repo.Save(ctx, &models.Notification{ID: "slack-2", TenantID: "acme", Channel: models.ChannelSlack, Status: models.StatusSent})
+
+	post := func(body, secret string, sentAt time.Time) *httptest.ResponseRecorder {
+		timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/slack", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		req.Header.Set("X-Slack-Signature", services.SignSlackRequest(secret, timestamp, []byte(body)))
+		rr := httptest.NewRecorder()
+		handler.Events(rr, req)
+		return rr
+	}
+
+	messageEvent := func(payload string) string {
+		return `{"type": "event_callback", "event": {"type": "message", "subtype": "bot_message", "channel": "C123", "ts": "1700000000.000100",
+			"metadata": {"event_type": "notification_sent", "event_payload": ` + payload + `}}}`
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		secret       string
+		sentAt       time.Time
+		expectedCode int
+		expectedBody string
+		id           string
+		tenantID     string
+	}{
+		{
+			name:         "URL verification",
+			body:         `{"type": "url_verification", "challenge": "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`,
+			secret:       "signing-secret",
+			sentAt:       time.Now(),
+			expectedCode: http.StatusOK,
+			expectedBody: "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P",
+		},
+		{
+			name:         "Delivery acknowledgement",
+			body:         messageEvent(`{"notification_id": "slack-1"}`),
+			secret:       "signing-secret",
+			sentAt:       time.Now(),
+			expectedCode: http.StatusOK,
+			id:           "slack-1",
+		},
+		{
+			name:         "Tenant delivery acknowledgement",
+			body:         messageEvent(`{"notification_id": "slack-2", "tenant_id": "acme"}`),
+			secret:       "signing-secret",
+			sentAt:       time.Now(),
+			expectedCode: http.StatusOK,
+			id:           "slack-2",
+			tenantID:     "acme",
+		},
+		{
+			name:         "Unknown notification is acknowledged",
+			body:         messageEvent(`{"notification_id": "missing"}`),
+			secret:       "signing-secret",
+			sentAt:       time.Now(),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid signature",
+			body:         `{"type": "url_verification", "challenge": "abc"}`,
+			secret:       "wrong-secret",
+			sentAt:       time.Now(),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Stale timestamp",
+			body:         `{"type": "url_verification", "challenge": "abc"}`,
+			secret:       "signing-secret",
+			sentAt:       time.Now().Add(-10 * time.Minute),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := post(tt.body, tt.secret, tt.sentAt)
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.expectedCode == http.StatusOK && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+			if tt.id == "" {
+				return
+			}
+
+			stored, _ := repo.GetByID(ctx, tt.tenantID, tt.id)
+			expectedAt := time.Unix(1700000000, 100000)
+			if stored.Status != models.StatusDelivered || stored.DeliveredAt == nil || !stored.DeliveredAt.Equal(expectedAt) {
+				t.Errorf("Expected delivered at %s, got %s at %v", expectedAt, stored.Status, stored.DeliveredAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strconv"
+)
+
+type TemplateHandler struct {
+	templateService *services.TemplateService
+}
+
+func NewTemplateHandler(templates *services.TemplateService) *TemplateHandler {
+	return &TemplateHandler{templateService: templates}
+}
+
+// Templates lists registered templates on GET and registers a template on
+// POST. Registering a name that is already taken fails with 409; use PUT
+// /templates/{name} to replace a template.
+func (h *TemplateHandler) Templates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		templates, err := h.templateService.List(r.Context())
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to list templates: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Templates retrieved successfully",
+			Data:    templates,
+		})
+
+	case http.MethodPost:
+		var tmpl models.NotificationTemplate
+		if err := json.NewDecoder(r.Body).Decode(&tmpl); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return
+		}
+		if err := h.templateService.Create(r.Context(), &tmpl); err != nil {
+			sendJSONResponse(w, templateErrorStatus(err), APIResponse{
+				Success: false,
+				Message: "Failed to register template: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusCreated, APIResponse{
+			Success: true,
+			Message: "Template registered successfully",
+			Data:    tmpl,
+		})
+
+	default:
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+// Template returns the latest version of the template named in the path on
+// GET, stores a new version of it on PUT and deletes it, with every version,
+// on DELETE. A name in the PUT body must match the path.
+func (h *TemplateHandler) Template(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	switch r.Method {
+	case http.MethodGet:
+		tmpl, err := h.templateService.Get(r.Context(), name)
+		if err != nil {
+			sendJSONResponse(w, templateErrorStatus(err), APIResponse{
+				Success: false,
+				Message: "Failed to get template: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Template retrieved successfully",
+			Data:    tmpl,
+		})
+
+	case http.MethodPut:
+		var tmpl models.NotificationTemplate
+		if err := json.NewDecoder(r.Body).Decode(&tmpl); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return
+		}
+		if tmpl.Name != "" && tmpl.Name != name {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Template name does not match the path",
+			})
+			return
+		}
+		tmpl.Name = name
+		if err := h.templateService.Update(r.Context(), &tmpl); err != nil {
+			sendJSONResponse(w, templateErrorStatus(err), APIResponse{
+				Success: false,
+				Message: "Failed to update template: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Template updated successfully",
+			Data:    tmpl,
+		})
+
+	case http.MethodDelete:
+		if err := h.templateService.Delete(r.Context(), name); err != nil {
+			sendJSONResponse(w, templateErrorStatus(err), APIResponse{
+				Success: false,
+				Message: "Failed to delete template: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "Template deleted successfully",
+		})
+
+	default:
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+// TemplateVersions lists every version of the template named in the path,
+// oldest first.
+func (h *TemplateHandler) TemplateVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	versions, err := h.templateService.Versions(r.Context(), r.PathValue("name"))
+	if err != nil {
+		sendJSONResponse(w, templateErrorStatus(err), APIResponse{
+			Success: false,
+			Message: "Failed to list template versions: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Template versions retrieved successfully",
+		Data:    versions,
+	})
+}
+
+// TemplateVersion returns the version of the template named in the path.
+func (h *TemplateHandler) TemplateVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version < 1 {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid template version: " + r.PathValue("version"),
+		})
+		return
+	}
+	tmpl, err := h.templateService.GetVersion(r.Context(), r.PathValue("name"), version)
+	if err != nil {
+		sendJSONResponse(w, templateErrorStatus(err), APIResponse{
+			Success: false,
+			Message: "Failed to get template version: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Template version retrieved successfully",
+		Data:    tmpl,
+	})
+}
+
+// templateErrorStatus maps a template service error to its HTTP status.
+func templateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidTemplate):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrTemplateExists):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrTemplateNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"testing"
+)
+
+func TestTemplateHandler(t *testing.T) {
+	templates := services.NewTemplateService(repository.NewMemoryTemplateRepository())
+	handler := NewTemplateHandler(templates)
+
+	tests := []struct {
+		name         string
+		template     models.NotificationTemplate
+		expectedCode int
+	}{
+		{"Valid template", models.NotificationTemplate{Name: "welcome", TitleTemplate: "Welcome {{.Name}}", ContentTemplate: "Hi {{.Name}}"}, http.StatusCreated},
+		{"Invalid syntax", models.NotificationTemplate{Name: "broken", TitleTemplate: "{{.Name", ContentTemplate: "Hi"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.template)
+			rr := httptest.NewRecorder()
+			handler.Templates(rr, httptest.NewRequest(http.MethodPost, "/templates", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	handler.Templates(rr, httptest.NewRequest(http.MethodGet, "/templates", nil))
+	var response struct {
+		Data []models.NotificationTemplate `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Name != "welcome" {
+		t.Errorf("Expected only the welcome template, got %+v", response.Data)
+	}
+}
+
+func TestTemplateHandlerByName(t *testing.T) {
+	templates := services.NewTemplateService(repository.NewMemoryTemplateRepository())
+	templates.Register(context.Background(), &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Welcome", ContentTemplate: "Hi"})
+	handler := NewTemplateHandler(templates)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		template     *models.NotificationTemplate
+		expectedCode int
+	}{
+		{"Get template", http.MethodGet, "welcome", nil, http.StatusOK},
+		{"Get unknown template", http.MethodGet, "goodbye", nil, http.StatusNotFound},
+		{"Update template", http.MethodPut, "welcome", &models.NotificationTemplate{TitleTemplate: "Welcome {{.Name}}", ContentTemplate: "Hi"}, http.StatusOK},
+		{"Update with invalid syntax", http.MethodPut, "welcome", &models.NotificationTemplate{TitleTemplate: "{{.Name", ContentTemplate: "Hi"}, http.StatusBadRequest},
+		{"Update with mismatched name", http.MethodPut, "welcome", &models.NotificationTemplate{Name: "goodbye", TitleTemplate: "Bye", ContentTemplate: "Bye"}, http.StatusBadRequest},
+		{"Update unknown template", http.MethodPut, "goodbye", &models.NotificationTemplate{TitleTemplate: "Bye", ContentTemplate: "Bye"}, http.StatusNotFound},
+		{"Delete template", http.MethodDelete, "welcome", nil, http.StatusOK},
+		{"Delete unknown template", http.MethodDelete, "welcome", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			if tt.template != nil {
+				json.NewEncoder(&body).Encode(tt.template)
+			}
+			req := httptest.NewRequest(tt.method, "/templates/"+tt.path, &body)
+			req.SetPathValue("name", tt.path)
+			rr := httptest.NewRecorder()
+			handler.Template(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	body, _ := json.Marshal(models.NotificationTemplate{Name: "welcome", TitleTemplate: "Welcome", ContentTemplate: "Hi"})
+	rr := httptest.NewRecorder()
+	handler.Templates(rr, httptest.NewRequest(http.MethodPost, "/templates", bytes.NewBuffer(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d re-creating a deleted template, got %d", http.StatusCreated, rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	handler.Templates(rr, httptest.NewRequest(http.MethodPost, "/templates", bytes.NewBuffer(body)))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected status %d creating an existing template, got %d", http.StatusConflict, rr.Code)
+	}
+}
+
+func TestSendNotificationWithTemplate(t *testing.T) {
+	templates := services.NewTemplateService(repository.NewMemoryTemplateRepository())
+	templates.Register(context.Background(), &models.NotificationTemplate{
+		Name:            "welcome",
+		TitleTemplate:   "Welcome {{.Name}}",
+		ContentTemplate: "Thanks for joining, {{.Name}}!",
+	})
+	templates.Register(context.Background(), &models.NotificationTemplate{
+		Name:            "welcome.es",
+		TitleTemplate:   `{{t "greeting" .Name}}`,
+		ContentTemplate: "¡Gracias por unirte, {{.Name}}!",
+	})
+	for _, title := range []string{"Bye {{.Name}}", "Goodbye {{.Name}}"} {
+		templates.Register(context.Background(), &models.NotificationTemplate{Name: "farewell", TitleTemplate: title, ContentTemplate: "See you"})
+	}
+
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repository.NewMemoryRepository(), &config.Config{})
+	handler.WithTemplateService(templates)
+
+	tests := []struct {
+		name            string
+		request         SendNotificationRequest
+		expectedCode    int
+		expectedTitle   string
+		expectedContent string
+	}{
+		{
+			name:            "Rendered template",
+			request:         SendNotificationRequest{TemplateName: "welcome", TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode:    http.StatusOK,
+			expectedTitle:   "Welcome Ana",
+			expectedContent: "Thanks for joining, Ana!",
+		},
+		{
+			name:            "Regional locale uses language variant",
+			request:         SendNotificationRequest{TemplateName: "welcome", Locale: "es_MX", TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode:    http.StatusOK,
+			expectedTitle:   "Hola Ana",
+			expectedContent: "¡Gracias por unirte, Ana!",
+		},
+		{
+			name:            "Locale without variant",
+			request:         SendNotificationRequest{TemplateName: "welcome", Locale: "fr", TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode:    http.StatusOK,
+			expectedTitle:   "Welcome Ana",
+			expectedContent: "Thanks for joining, Ana!",
+		},
+		{
+			name:         "Invalid locale",
+			request:      SendNotificationRequest{TemplateName: "welcome", Locale: "not a locale", TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Missing template data",
+			request:      SendNotificationRequest{TemplateName: "welcome", Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown template",
+			request:      SendNotificationRequest{TemplateName: "goodbye", Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:            "Latest version",
+			request:         SendNotificationRequest{TemplateName: "farewell", TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode:    http.StatusOK,
+			expectedTitle:   "Goodbye Ana",
+			expectedContent: "See you",
+		},
+		{
+			name:            "Pinned version",
+			request:         SendNotificationRequest{TemplateName: "farewell", TemplateVersion: 1, TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode:    http.StatusOK,
+			expectedTitle:   "Bye Ana",
+			expectedContent: "See you",
+		},
+		{
+			name:         "Unknown version",
+			request:      SendNotificationRequest{TemplateName: "farewell", TemplateVersion: 3, TemplateData: map[string]interface{}{"Name": "Ana"}, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Version without template",
+			request:      SendNotificationRequest{Title: "Hi", Content: "Hi", TemplateVersion: 1, Channel: models.ChannelSlack, Recipients: []string{"ana"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.request)
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedTitle == "" {
+				return
+			}
+			var response struct {
+				Data models.Notification `json:"data"`
+			}
+			json.NewDecoder(rr.Body).Decode(&response)
+			if response.Data.Title != tt.expectedTitle || response.Data.Content != tt.expectedContent {
+				t.Errorf("Expected rendered notification, got %q / %q", response.Data.Title, response.Data.Content)
+			}
+		})
+	}
+}
+
+func TestTemplateHandlerVersions(t *testing.T) {
+	templates := services.NewTemplateService(repository.NewMemoryTemplateRepository())
+	templates.Register(context.Background(), &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Welcome", ContentTemplate: "Hi"})
+	handler := NewTemplateHandler(templates)
+
+	body, _ := json.Marshal(models.NotificationTemplate{TitleTemplate: "Welcome {{.Name}}", ContentTemplate: "Hi"})
+	req := httptest.NewRequest(http.MethodPut, "/templates/welcome", bytes.NewBuffer(body))
+	req.SetPathValue("name", "welcome")
+	rr := httptest.NewRecorder()
+	handler.Template(rr, req)
+	var updated struct {
+		Data models.NotificationTemplate `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&updated)
+	if rr.Code != http.StatusOK || updated.Data.Version != 2 {
+		t.Fatalf("Expected the update stored as version 2, got %d %+v", rr.Code, updated.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/templates/welcome/versions", nil)
+	req.SetPathValue("name", "welcome")
+	rr = httptest.NewRecorder()
+	handler.TemplateVersions(rr, req)
+	var listed struct {
+		Data []models.NotificationTemplate `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&listed)
+	if len(listed.Data) != 2 || listed.Data[0].TitleTemplate != "Welcome" || listed.Data[1].Version != 2 {
+		t.Errorf("Expected both versions oldest first, got %+v", listed.Data)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		version      string
+		expectedCode int
+	}{
+		{"First version", "welcome", "1", http.StatusOK},
+		{"Unknown version", "welcome", "3", http.StatusNotFound},
+		{"Invalid version", "welcome", "latest", http.StatusBadRequest},
+		{"Unknown template", "goodbye", "1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/templates/"+tt.path+"/versions/"+tt.version, nil)
+			req.SetPathValue("name", tt.path)
+			req.SetPathValue("version", tt.version)
+			rr := httptest.NewRecorder()
+			handler.TemplateVersion(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
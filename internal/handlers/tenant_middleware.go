@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strings"
+)
+
+// TenantHeader names the tenant of requests that are not addressed to a
+// tenant subdomain.
+const TenantHeader = "X-Tenant-ID"
+
+// TenantQueryParam names the tenant of the tracking links in emails, which
+// mail clients load without an X-Tenant-ID header.
+const TenantQueryParam = "tenant"
+
+type tenantContextKey struct{}
+
+// TenantID returns the tenant TenantMiddleware resolved for a request's
+// context, or "" for the default tenant.
+func TenantID(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// WithTenantID returns a copy of ctx belonging to tenantID.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantMiddleware resolves the tenant of each request from its subdomain of
+// baseDomain, e.g. acme.notify.example.com, or else from the X-Tenant-ID
+// header, and passes the request on to next only if tenants knows it.
+// Requests without a tenant act for the tenant their credentials are bound
+// to, if any, and otherwise get 400. Unknown tenants get 404, and tenants
+// other than the credentials' 403.
+func TenantMiddleware(tenants *services.TenantService, baseDomain string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID := tenantSubdomain(r.Host, baseDomain)
+		header := r.Header.Get(TenantHeader)
+		switch {
+		case tenantID == "":
+			tenantID = header
+		case header != "" && header != tenantID:
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: TenantHeader + " does not match the tenant subdomain",
+			})
+			return
+		}
+		bound := CredentialTenant(r.Context())
+		if tenantID == "" {
+			tenantID = bound
+		}
+		if tenantID == "" {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Missing tenant: use a tenant subdomain or the " + TenantHeader + " header",
+			})
+			return
+		}
+		if bound != "" && bound != tenantID {
+			sendJSONResponse(w, http.StatusForbidden, APIResponse{
+				Success: false,
+				Message: "Credentials do not belong to tenant " + tenantID,
+			})
+			return
+		}
+
+		_, err := tenants.Get(r.Context(), tenantID)
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, APIResponse{
+				Success: false,
+				Message: "Unknown tenant: " + tenantID,
+			})
+			return
+		}
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to load tenant: " + err.Error(),
+			})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithTenantID(r.Context(), tenantID)))
+	})
+}
+
+// TrackingTenantMiddleware is TenantMiddleware for the open and click
+// tracking links in emails. Requests without an X-Tenant-ID header take it
+// from their tenant query parameter.
+func TrackingTenantMiddleware(tenants *services.TenantService, baseDomain string, next http.Handler) http.Handler {
+	scoped := TenantMiddleware(tenants, baseDomain, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenantID := r.URL.Query().Get(TenantQueryParam); tenantID != "" && r.Header.Get(TenantHeader) == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set(TenantHeader, tenantID)
+		}
+		scoped.ServeHTTP(w, r)
+	})
+}
+
+// tenantSubdomain returns the label host adds in front of baseDomain, or ""
+// if host is not a subdomain of it.
+func tenantSubdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	subdomain, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !ok {
+		return ""
+	}
+	return subdomain
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"notification-service/internal/services/mock"
+	"testing"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	tenants := services.NewTenantService(repository.NewMemoryRepository(), factory)
+	for _, id := range []string{"acme", "globex"} {
+		if err := tenants.Save(context.Background(), &models.Tenant{ID: id}); err != nil {
+			t.Fatalf("Failed to save tenant: %v", err)
+		}
+	}
+
+	var resolved string
+	handler := TenantMiddleware(tenants, "notify.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved = TenantID(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name           string
+		host           string
+		header         string
+		credential     string
+		expectedCode   int
+		expectedTenant string
+	}{
+		{"Subdomain", "acme.notify.example.com", "", "", http.StatusNoContent, "acme"},
+		{"Subdomain with port", "acme.notify.example.com:8080", "", "", http.StatusNoContent, "acme"},
+		{"Header", "localhost:8080", "globex", "", http.StatusNoContent, "globex"},
+		{"Matching header", "acme.notify.example.com", "acme", "", http.StatusNoContent, "acme"},
+		{"Conflicting header", "acme.notify.example.com", "globex", "", http.StatusBadRequest, ""},
+		{"Missing tenant", "notify.example.com", "", "", http.StatusBadRequest, ""},
+		{"Unknown tenant", "initech.notify.example.com", "", "", http.StatusNotFound, ""},
+		{"Credentials' tenant", "acme.notify.example.com", "", "acme", http.StatusNoContent, "acme"},
+		{"Tenant from credentials", "localhost:8080", "", "acme", http.StatusNoContent, "acme"},
+		{"Other tenant's subdomain", "globex.notify.example.com", "", "acme", http.StatusForbidden, ""},
+		{"Other tenant's header", "localhost:8080", "globex", "acme", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved = ""
+			req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			if tt.credential != "" {
+				req = req.WithContext(WithCredentialTenant(req.Context(), tt.credential))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if resolved != tt.expectedTenant {
+				t.Errorf("Expected tenant %q, got %q", tt.expectedTenant, resolved)
+			}
+		})
+	}
+}
+
+func TestTrackingTenantMiddleware(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	tenants := services.NewTenantService(repository.NewMemoryRepository(), factory)
+	if err := tenants.Save(context.Background(), &models.Tenant{ID: "acme"}); err != nil {
+		t.Fatalf("Failed to save tenant: %v", err)
+	}
+
+	var resolved string
+	handler := TrackingTenantMiddleware(tenants, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved = TenantID(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name           string
+		target         string
+		header         string
+		expectedCode   int
+		expectedTenant string
+	}{
+		{"Query", "/track/open/email-1?tenant=acme", "", http.StatusNoContent, "acme"},
+		{"Header", "/track/open/email-1", "acme", http.StatusNoContent, "acme"},
+		{"Header over query", "/track/open/email-1?tenant=initech", "acme", http.StatusNoContent, "acme"},
+		{"Unknown tenant", "/track/open/email-1?tenant=initech", "", http.StatusNotFound, ""},
+		{"Missing tenant", "/track/open/email-1", "", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved = ""
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if resolved != tt.expectedTenant {
+				t.Errorf("Expected tenant %q, got %q", tt.expectedTenant, resolved)
+			}
+		})
+	}
+}
+
+func TestTenantBoundAPIKey(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	tenants := services.NewTenantService(repo, factory)
+	for _, id := range []string{"acme", "globex"} {
+		if err := tenants.Save(context.Background(), &models.Tenant{ID: id}); err != nil {
+			t.Fatalf("Failed to save tenant: %v", err)
+		}
+	}
+	keys := services.NewAPIKeyService(repo, nil)
+	key, _, err := keys.Create(context.Background(), "acme-ci", "acme")
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	handler := AuthMiddleware(keys, TenantMiddleware(tenants, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	for tenantID, expectedCode := range map[string]int{"acme": http.StatusNoContent, "globex": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+		req.Header.Set(APIKeyHeader, key)
+		req.Header.Set(TenantHeader, tenantID)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != expectedCode {
+			t.Errorf("Expected status code %d for tenant %s, got %d: %s", expectedCode, tenantID, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestNotificationHandlerTenantIsolation(t *testing.T) {
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	repo := repository.NewMemoryRepository()
+	handler := NewNotificationHandler(factory, &mock.MockSchedulerService{}, repo, &config.Config{})
+
+	withTenant := func(req *http.Request, tenantID string) *http.Request {
+		return req.WithContext(WithTenantID(req.Context(), tenantID))
+	}
+
+	body, _ := json.Marshal(SendNotificationRequest{Title: "Hello", Content: "World", Channel: models.ChannelSlack, Recipients: []string{"U1"}})
+	rr := httptest.NewRecorder()
+	handler.SendNotification(rr, withTenant(httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewReader(body)), "acme"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var sent struct {
+		Data models.Notification `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&sent)
+	if sent.Data.TenantID != "acme" {
+		t.Errorf("Expected the notification to belong to acme, got %q", sent.Data.TenantID)
+	}
+
+	for tenantID, expectedCode := range map[string]int{"acme": http.StatusOK, "globex": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, "/notifications/"+sent.Data.ID+"/status", nil)
+		req.SetPathValue("id", sent.Data.ID)
+		rr := httptest.NewRecorder()
+		handler.NotificationStatus(rr, withTenant(req, tenantID))
+		if rr.Code != expectedCode {
+			t.Errorf("Expected status code %d for tenant %s, got %d", expectedCode, tenantID, rr.Code)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ListNotifications(rr, withTenant(httptest.NewRequest(http.MethodGet, "/notifications", nil), "globex"))
+	var list ListResponse
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil || list.TotalCount != 0 {
+		t.Errorf("Expected no notifications for globex, got %+v (%v)", list, err)
+	}
+
+	// tenant_id in the body must agree with the request's tenant
+	body, _ = json.Marshal(SendNotificationRequest{TenantID: "globex", Title: "Hello", Content: "World", Channel: models.ChannelSlack, Recipients: []string{"U1"}})
+	rr = httptest.NewRecorder()
+	handler.SendNotification(rr, withTenant(httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewReader(body)), "acme"))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
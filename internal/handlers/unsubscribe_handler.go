@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/services"
+)
+
+// unsubscribePage is shown to people following an unsubscribe link.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+type UnsubscribeHandler struct {
+	unsubscribe *services.UnsubscribeService
+	logger      logging.Logger
+}
+
+// NewUnsubscribeHandler returns a handler for the links signed by
+// unsubscribe. A nil unsubscribe answers every link with 404.
+func NewUnsubscribeHandler(unsubscribe *services.UnsubscribeService) *UnsubscribeHandler {
+	return &UnsubscribeHandler{unsubscribe: unsubscribe}
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (h *UnsubscribeHandler) WithLogger(logger logging.Logger) {
+	h.logger = logger
+}
+
+// Unsubscribe adds the address the token in the path was issued for to the
+// suppression list of its category and shows a confirmation page. Following
+// the same link again is harmless.
+func (h *UnsubscribeHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if h.unsubscribe == nil {
+		sendUnsubscribePage(w, http.StatusNotFound, "Not found", "Unsubscribe links are not enabled.")
+		return
+	}
+	email, category, err := h.unsubscribe.Unsubscribe(r.Context(), r.PathValue("token"))
+	if errors.Is(err, services.ErrInvalidUnsubscribeToken) {
+		sendUnsubscribePage(w, http.StatusBadRequest, "Invalid link", "This unsubscribe link is not valid.")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("Error unsubscribing", "error", err)
+		sendUnsubscribePage(w, http.StatusInternalServerError, "Something went wrong", "We could not unsubscribe you. Please try again later.")
+		return
+	}
+
+	logging.FromContext(r.Context(), h.logger).Info("Unsubscribed email address", "email", email, "category", category)
+	message := email + " will no longer receive these emails."
+	if category != "" {
+		message = email + " will no longer receive " + category + " emails."
+	}
+	sendUnsubscribePage(w, http.StatusOK, "You have been unsubscribed", message)
+}
+
+func sendUnsubscribePage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	unsubscribePage.Execute(w, struct{ Title, Message string }{title, message})
+}
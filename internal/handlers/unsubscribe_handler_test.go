@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribeHandler(t *testing.T) {
+	ctx := context.Background()
+	unsubscribe := services.NewUnsubscribeService("s3cret", "https://notify.example.com", repository.NewMemoryRepository())
+	handler := NewUnsubscribeHandler(unsubscribe)
+
+	tests := []struct {
+		name            string
+		handler         *UnsubscribeHandler
+		token           string
+		expectedCode    int
+		expectedMessage string
+	}{
+		{"Valid token", handler, unsubscribe.Token("ana@example.com", "billing"), http.StatusOK, "ana@example.com will no longer receive billing emails."},
+		{"Same token again", handler, unsubscribe.Token("ana@example.com", "billing"), http.StatusOK, "ana@example.com will no longer receive billing emails."},
+		{"Uncategorized", handler, unsubscribe.Token("bob@example.com", ""), http.StatusOK, "bob@example.com will no longer receive these emails."},
+		{"Tampered token", handler, unsubscribe.Token("ana@example.com", "billing") + "x", http.StatusBadRequest, "not valid"},
+		{"Disabled", NewUnsubscribeHandler(nil), unsubscribe.Token("ana@example.com", "billing"), http.StatusNotFound, "not enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/unsubscribe/"+tt.token, nil)
+			req.SetPathValue("token", tt.token)
+			rr := httptest.NewRecorder()
+			tt.handler.Unsubscribe(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+				t.Errorf("Expected an HTML page, got %s", contentType)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedMessage) {
+				t.Errorf("Expected page to contain %q, got %s", tt.expectedMessage, rr.Body.String())
+			}
+		})
+	}
+
+	for email, expected := range map[string]bool{"ana@example.com": true, "bob@example.com": false} {
+		if suppressed, _ := unsubscribe.Suppressed(ctx, email, "billing"); suppressed != expected {
+			t.Errorf("Expected %s suppressed from billing to be %v, got %v", email, expected, suppressed)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+)
+
+type UserHandler struct {
+	userPreferences *services.UserPreferenceService
+}
+
+func NewUserHandler(preferences *services.UserPreferenceService) *UserHandler {
+	return &UserHandler{userPreferences: preferences}
+}
+
+// User returns the user named in the path on GET and stores their addresses
+// and preferred channel on PUT.
+func (h *UserHandler) User(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		user, err := h.userPreferences.GetPreference(r.Context(), id)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, APIResponse{
+				Success: false,
+				Message: "User not found: " + id,
+			})
+			return
+		}
+		if err != nil {
+			sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Failed to load user: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "User retrieved successfully",
+			Data:    user,
+		})
+
+	case http.MethodPut:
+		var user models.User
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return
+		}
+		user.ID = id
+		if err := h.userPreferences.SetPreference(r.Context(), &user); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Failed to save user preferences: " + err.Error(),
+			})
+			return
+		}
+		sendJSONResponse(w, http.StatusOK, APIResponse{
+			Success: true,
+			Message: "User preferences saved successfully",
+			Data:    user,
+		})
+
+	default:
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+// Subscriptions returns the categories the user named in the path has opted
+// in to or out of on GET. POST takes a list of subscriptions and updates only
+// the categories it names.
+func (h *UserHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	message := "Subscriptions retrieved successfully"
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var subscriptions []models.CategorySubscription
+		if err := json.NewDecoder(r.Body).Decode(&subscriptions); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return
+		}
+		err := h.userPreferences.SetSubscriptions(r.Context(), id, subscriptions)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, APIResponse{
+				Success: false,
+				Message: "User not found: " + id,
+			})
+			return
+		}
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Failed to save subscriptions: " + err.Error(),
+			})
+			return
+		}
+		message = "Subscriptions saved successfully"
+	default:
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	subscriptions, err := h.userPreferences.Subscriptions(r.Context(), id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		sendJSONResponse(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "User not found: " + id,
+		})
+		return
+	}
+	if err != nil {
+		sendJSONResponse(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Failed to load subscriptions: " + err.Error(),
+		})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: message,
+		Data:    subscriptions,
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"testing"
+)
+
+func TestUserHandler(t *testing.T) {
+	handler := NewUserHandler(services.NewUserPreferenceService(repository.NewMemoryRepository()))
+
+	tests := []struct {
+		name         string
+		method       string
+		id           string
+		user         *models.User
+		expectedCode int
+	}{
+		{"Unknown user", http.MethodGet, "ana", nil, http.StatusNotFound},
+		{"Save preference", http.MethodPut, "ana", &models.User{Email: "ana@example.com", PreferredChannel: models.ChannelEmail}, http.StatusOK},
+		{"Missing address", http.MethodPut, "ben", &models.User{Email: "ben@example.com", PreferredChannel: models.ChannelSlack}, http.StatusBadRequest},
+		{"Known user", http.MethodGet, "ana", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			if tt.user != nil {
+				json.NewEncoder(&body).Encode(tt.user)
+			}
+			req := httptest.NewRequest(tt.method, "/users/"+tt.id, &body)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			handler.User(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestSendNotificationToUsers(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	preferences := services.NewUserPreferenceService(repo)
+	preferences.SetPreference(context.Background(), &models.User{ID: "ana", Email: "ana@example.com", PreferredChannel: models.ChannelEmail})
+	preferences.SetPreference(context.Background(), &models.User{ID: "cho", SlackID: "U123", PreferredChannel: models.ChannelSlack})
+
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repo, &config.Config{})
+	handler.WithUserPreferenceService(preferences)
+
+	tests := []struct {
+		name         string
+		request      SendNotificationRequest
+		expectedCode int
+	}{
+		{"Routed by preference", SendNotificationRequest{Title: "Hi", Content: "Hello", UserIDs: []string{"ana", "cho"}}, http.StatusOK},
+		{"Unknown user", SendNotificationRequest{Title: "Hi", Content: "Hello", UserIDs: []string{"ana", "zed"}}, http.StatusBadRequest},
+		{"Combined with recipients", SendNotificationRequest{Title: "Hi", Content: "Hello", UserIDs: []string{"ana"}, Recipients: []string{"x"}}, http.StatusBadRequest},
+		{"Combined with channel", SendNotificationRequest{Title: "Hi", Content: "Hello", UserIDs: []string{"ana"}, Channel: models.ChannelSlack}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.request)
+			rr := httptest.NewRecorder()
+			handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	stored, _ := repo.ListAll(context.Background(), "")
+	if len(stored) != 1 {
+		t.Fatalf("Expected 1 stored notification, got %d", len(stored))
+	}
+	notification := stored[0]
+	if len(notification.Channels) != 2 || notification.Channels[0] != models.ChannelEmail || notification.Channels[1] != models.ChannelSlack {
+		t.Errorf("Expected email and slack channels, got %v", notification.Channels)
+	}
+	if got := notification.ChannelRecipients[models.ChannelSlack]; len(got) != 1 || got[0] != "U123" {
+		t.Errorf("Expected slack recipient U123, got %v", got)
+	}
+}
+
+func TestUserSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	preferences := services.NewUserPreferenceService(repo)
+	preferences.SetPreference(ctx, &models.User{ID: "ana", Email: "ana@example.com", PreferredChannel: models.ChannelEmail})
+	preferences.SetPreference(ctx, &models.User{ID: "cho", SlackID: "U123", PreferredChannel: models.ChannelSlack})
+	userHandler := NewUserHandler(preferences)
+
+	tests := []struct {
+		name         string
+		method       string
+		id           string
+		body         string
+		expectedCode int
+	}{
+		{"Opt out", http.MethodPost, "ana", `[{"Category": "marketing", "Subscribed": false}]`, http.StatusOK},
+		{"Missing category", http.MethodPost, "ana", `[{"Subscribed": true}]`, http.StatusBadRequest},
+		{"Unknown user", http.MethodPost, "zed", `[{"Category": "marketing", "Subscribed": false}]`, http.StatusNotFound},
+		{"List", http.MethodGet, "ana", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/users/"+tt.id+"/subscriptions", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+			userHandler.Subscriptions(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	factory := services.NewNotificationServiceFactory(&config.Config{})
+	handler := NewNotificationHandler(factory, nil, repo, &config.Config{})
+	handler.WithUserPreferenceService(preferences)
+	send := func(userIDs ...string) *models.Notification {
+		body, _ := json.Marshal(SendNotificationRequest{Title: "Sale", Content: "50% off", UserIDs: userIDs, Category: "marketing"})
+		rr := httptest.NewRecorder()
+		handler.SendNotification(rr, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var response struct {
+			Data models.Notification `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&response)
+		return &response.Data
+	}
+
+	if notification := send("ana", "cho"); notification.Status != models.StatusSent || len(notification.Recipients) != 1 || notification.Recipients[0] != "U123" {
+		t.Errorf("Expected only cho to be notified, got %s to %v", notification.Status, notification.Recipients)
+	}
+	if notification := send("ana"); notification.Status != models.StatusSuppressed {
+		t.Errorf("Expected status %s, got %s", models.StatusSuppressed, notification.Status)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ListNotifications(rr, httptest.NewRequest(http.MethodGet, "/notifications?category=marketing&status=suppressed", nil))
+	var response struct {
+		Data []models.Notification `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Category != "marketing" {
+		t.Errorf("Expected 1 suppressed marketing notification, got %+v", response.Data)
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSocketClientBufferSize is how many messages a WebSocket client may fall
+// behind by before it is disconnected.
+const webSocketClientBufferSize = 64
+
+// NotificationEventMessage is a notification event sent to WebSocket
+// clients, with the channel and status of the notification after it.
+// Channel and Status are empty once the notification is deleted.
+type NotificationEventMessage struct {
+	NotificationID string `json:"notification_id"`
+	NotificationEventResponse
+	Channel  models.NotificationChannel   `json:"channel,omitempty"`
+	Channels []models.NotificationChannel `json:"channels,omitempty"`
+	Status   models.NotificationStatus    `json:"status,omitempty"`
+}
+
+// WebSocketBroadcaster sends the events of every notification to the
+// WebSocket clients of GET /ws/notifications. It bridges from the event bus,
+// which it subscribes to while it has clients.
+type WebSocketBroadcaster struct {
+	events      services.NotificationEventBus
+	repository  repository.NotificationRepository
+	logger      logging.Logger
+	origins     []string
+	clients     map[*webSocketClient]struct{}
+	unsubscribe func()
+	mu          sync.Mutex
+}
+
+// webSocketClient is a connection registered with a WebSocketBroadcaster,
+// and the events it asked for.
+type webSocketClient struct {
+	tenantID string
+	channel  models.NotificationChannel
+	status   models.NotificationStatus
+	// messages is closed when the client is deregistered
+	messages chan *NotificationEventMessage
+}
+
+func NewWebSocketBroadcaster(events services.NotificationEventBus, repo repository.NotificationRepository) *WebSocketBroadcaster {
+	return &WebSocketBroadcaster{
+		events:     events,
+		repository: repo,
+		logger:     logging.Default(),
+		clients:    make(map[*webSocketClient]struct{}),
+	}
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (b *WebSocketBroadcaster) WithLogger(logger logging.Logger) {
+	b.logger = logger
+}
+
+// WithAllowedOrigins lets browsers on origins connect, as CORS_ORIGINS lets
+// them call the rest of the API. Without it only the API's own origin and
+// clients that send no Origin, which are not browsers, may connect.
+func (b *WebSocketBroadcaster) WithAllowedOrigins(origins []string) {
+	b.origins = origins
+}
+
+// webSocketUpgrader returns an upgrader that accepts connections from
+// origins, an origin of "*" accepting every one, from the request's own
+// host, and without an Origin header. Browsers send cookies and HTTP
+// credentials cached for the API with any page's WebSocket, so other
+// origins are refused.
+func webSocketUpgrader(origins []string, subprotocols ...string) *websocket.Upgrader {
+	allowAll := slices.Contains(origins, "*")
+	return &websocket.Upgrader{
+		Subprotocols: subprotocols,
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || allowAll || slices.Contains(origins, origin) {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		},
+	}
+}
+
+// Notifications upgrades the request to a WebSocket that receives a
+// NotificationEventMessage for every change to a notification of the
+// request's tenant. The channel and status query parameters limit the
+// events to notifications on that channel, or in that status after the
+// change. Messages from the client are ignored.
+func (b *WebSocketBroadcaster) Notifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Expected a WebSocket upgrade",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	client := &webSocketClient{
+		tenantID: TenantID(r.Context()),
+		channel:  models.NotificationChannel(query.Get("channel")),
+		status:   models.NotificationStatus(query.Get("status")),
+		messages: make(chan *NotificationEventMessage, webSocketClientBufferSize),
+	}
+	if client.status != "" && !isValidStatus(client.status) {
+		sendJSONResponse(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Invalid status: " + string(client.status),
+		})
+		return
+	}
+
+	// Upgrade answers failed handshakes, such as from disallowed origins
+	ws, err := webSocketUpgrader(b.origins).Upgrade(hijacker{w}, r, nil)
+	if err != nil {
+		return
+	}
+	b.serve(ws, r, client)
+}
+
+// serve writes the client's messages to ws until either side hangs up.
+func (b *WebSocketBroadcaster) serve(ws *websocket.Conn, r *http.Request, client *webSocketClient) {
+	defer ws.Close()
+	log := logging.FromContext(r.Context(), b.logger)
+
+	b.register(client)
+	defer b.deregister(client)
+	go func() {
+		// Reading is what notices the client leaving
+		defer b.deregister(client)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for message := range client.messages {
+		if err := ws.WriteJSON(message); err != nil {
+			log.Debug("Error writing notification event", "error", err)
+			return
+		}
+	}
+}
+
+// register adds client, subscribing to the event bus for the first one.
+func (b *WebSocketBroadcaster) register(client *webSocketClient) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clients[client] = struct{}{}
+	if b.unsubscribe == nil {
+		var events <-chan *models.NotificationEvent
+		events, b.unsubscribe = b.events.SubscribeAll()
+		go b.broadcast(events)
+	}
+}
+
+// deregister removes client, if still registered, and closes its messages.
+// The event bus is unsubscribed from once the last client leaves.
+func (b *WebSocketBroadcaster) deregister(client *webSocketClient) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(client)
+}
+
+// remove is deregister with b.mu held.
+func (b *WebSocketBroadcaster) remove(client *webSocketClient) {
+	if _, ok := b.clients[client]; !ok {
+		return
+	}
+	delete(b.clients, client)
+	close(client.messages)
+	if len(b.clients) == 0 {
+		b.unsubscribe()
+		b.unsubscribe = nil
+	}
+}
+
+// broadcast sends each of events to the clients it matches until events is
+// closed.
+func (b *WebSocketBroadcaster) broadcast(events <-chan *models.NotificationEvent) {
+	for event := range events {
+		if !b.listening(event.TenantID) {
+			continue
+		}
+
+		message := &NotificationEventMessage{
+			NotificationID: event.NotificationID,
+			NotificationEventResponse: NotificationEventResponse{
+				Sequence:  event.Sequence,
+				Type:      event.Type,
+				Payload:   event.Payload,
+				Timestamp: event.Timestamp,
+			},
+		}
+		if event.Type != models.EventDeleted {
+			notification, err := b.repository.GetByID(context.Background(), event.TenantID, event.NotificationID)
+			switch {
+			case err == nil:
+				message.Channel = notification.Channel
+				message.Channels = notification.Channels
+				message.Status = notification.Status
+			case !errors.Is(err, repository.ErrNotFound):
+				b.logger.Warn("Error loading notification for broadcast", "notification_id", event.NotificationID, "error", err)
+			}
+		}
+
+		b.mu.Lock()
+		for client := range b.clients {
+			if !client.matches(event.TenantID, message) {
+				continue
+			}
+			select {
+			case client.messages <- message:
+			default:
+				b.logger.Warn("Disconnecting slow WebSocket client", "tenant_id", client.tenantID)
+				b.remove(client)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// listening reports whether any client is registered in tenantID, so
+// events of other tenants are not loaded for nothing.
+func (b *WebSocketBroadcaster) listening(tenantID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for client := range b.clients {
+		if client.tenantID == tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether client asked for message, an event in tenantID.
+func (c *webSocketClient) matches(tenantID string, message *NotificationEventMessage) bool {
+	return c.tenantID == tenantID &&
+		(c.channel == "" || message.Channel == c.channel || slices.Contains(message.Channels, c.channel)) &&
+		(c.status == "" || message.Status == c.status)
+}
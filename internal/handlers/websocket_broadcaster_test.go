@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// waitForClients waits until b has count clients registered.
+func waitForClients(t *testing.T, b *WebSocketBroadcaster, count int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		b.mu.Lock()
+		registered := len(b.clients)
+		b.mu.Unlock()
+		if registered == count {
+			return
+		}
+	}
+	t.Fatalf("Expected %d WebSocket clients to be registered", count)
+}
+
+func TestWebSocketBroadcaster(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	bus := services.NewEventBus()
+	repo.WithEventPublisher(bus)
+	broadcaster := NewWebSocketBroadcaster(bus, repo)
+	server := httptest.NewServer(LoggingMiddleware(nil, http.HandlerFunc(broadcaster.Notifications)))
+	defer server.Close()
+
+	dial := func(query string) *websocket.Conn {
+		t.Helper()
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/notifications"+query, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return ws
+	}
+	receive := func(ws *websocket.Conn) NotificationEventMessage {
+		t.Helper()
+		var message NotificationEventMessage
+		if err := ws.ReadJSON(&message); err != nil {
+			t.Fatalf("Failed to receive: %v", err)
+		}
+		return message
+	}
+
+	all := dial("")
+	defer all.Close()
+	failed := dial("?status=failed")
+	defer failed.Close()
+	email := dial("?channel=email")
+	defer email.Close()
+	waitForClients(t, broadcaster, 3)
+
+	ctx := context.Background()
+	repo.Save(ctx, &models.Notification{ID: "slack", Channel: models.ChannelSlack, Status: models.StatusPending})
+	repo.Save(ctx, &models.Notification{ID: "fan-out", Channel: models.ChannelSlack, Channels: []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail}, Status: models.StatusPending})
+	repo.UpdateStatus(ctx, "", "slack", repository.StatusUpdate{Status: models.StatusFailed, FailureReason: "webhook returned 500"})
+
+	for _, expected := range []struct{ id, eventType string }{{"slack", "created"}, {"fan-out", "created"}, {"slack", "status_changed"}} {
+		if message := receive(all); message.NotificationID != expected.id || string(message.Type) != expected.eventType {
+			t.Errorf("Expected %s %s, got %+v", expected.id, expected.eventType, message)
+		}
+	}
+	if message := receive(failed); message.NotificationID != "slack" || message.Status != models.StatusFailed || message.Channel != models.ChannelSlack {
+		t.Errorf("Expected only the failure, got %+v", message)
+	}
+	if message := receive(email); message.NotificationID != "fan-out" || message.Sequence == 0 {
+		t.Errorf("Expected only the fan-out to email, got %+v", message)
+	}
+
+	// Clients that leave are deregistered, and the last one unsubscribes
+	// from the bus
+	failed.Close()
+	waitForClients(t, broadcaster, 2)
+	all.Close()
+	email.Close()
+	waitForClients(t, broadcaster, 0)
+	broadcaster.mu.Lock()
+	subscribed := broadcaster.unsubscribe != nil
+	broadcaster.mu.Unlock()
+	if subscribed {
+		t.Error("Expected the broadcaster to unsubscribe without clients")
+	}
+}
+
+func TestWebSocketBroadcasterInvalidRequests(t *testing.T) {
+	broadcaster := NewWebSocketBroadcaster(services.NewEventBus(), repository.NewMemoryRepository())
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		upgrade      bool
+		expectedCode int
+	}{
+		{"Without upgrade", http.MethodGet, "/ws/notifications", false, http.StatusBadRequest},
+		{"Invalid status", http.MethodGet, "/ws/notifications?status=lost", true, http.StatusBadRequest},
+		{"Wrong method", http.MethodPost, "/ws/notifications", true, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.upgrade {
+				req.Header.Set("Upgrade", "websocket")
+			}
+			rr := httptest.NewRecorder()
+			broadcaster.Notifications(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestWebSocketBroadcasterOrigins(t *testing.T) {
+	broadcaster := NewWebSocketBroadcaster(services.NewEventBus(), repository.NewMemoryRepository())
+	broadcaster.WithAllowedOrigins([]string{"https://app.example.com"})
+	server := httptest.NewServer(http.HandlerFunc(broadcaster.Notifications))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		origin       string
+		expectedCode int
+	}{
+		{"Without origin", "", http.StatusSwitchingProtocols},
+		{"Same origin", server.URL, http.StatusSwitchingProtocols},
+		{"Allowed origin", "https://app.example.com", http.StatusSwitchingProtocols},
+		{"Other origin", "https://evil.example.com", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			ws, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/notifications", header)
+			if err == nil {
+				ws.Close()
+			}
+			if resp == nil || resp.StatusCode != tt.expectedCode {
+				t.Errorf("Expected status %d, got %v (%v)", tt.expectedCode, resp, err)
+			}
+		})
+	}
+}
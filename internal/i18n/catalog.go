@@ -0,0 +1,166 @@
+// Package i18n loads message catalogs keyed by locale and resolves messages
+// with fallback from regional locales to their language and then to English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// DefaultLocale is the last fallback for every lookup.
+const DefaultLocale = "en"
+
+//go:embed catalogs/*.json
+var embedded embed.FS
+
+// localePattern matches BCP 47 style tags such as "es" or "pt-BR".
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Catalog holds messages keyed by locale and message key.
+type Catalog struct {
+	messages map[string]map[string]string
+	mu       sync.RWMutex
+}
+
+// NewCatalog returns a catalog holding the embedded English and Spanish
+// messages.
+func NewCatalog() *Catalog {
+	c := &Catalog{messages: make(map[string]map[string]string)}
+	if err := c.load(embedded, "catalogs"); err != nil {
+		panic(fmt.Sprintf("invalid embedded catalog: %v", err))
+	}
+	return c
+}
+
+// LoadDir adds the catalogs in dir, one file per locale named e.g. "fr.json",
+// "pt-BR.yaml" or "de.yml". Messages override the embedded ones with the
+// same locale and key.
+func (c *Catalog) LoadDir(dir string) error {
+	return c.load(os.DirFS(dir), ".")
+}
+
+func (c *Catalog) load(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read catalog directory: %w", err)
+	}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		locale, ok := NormalizeLocale(strings.TrimSuffix(entry.Name(), ext))
+		if !ok {
+			return fmt.Errorf("catalog %s is not named after a locale", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read catalog %s: %w", entry.Name(), err)
+		}
+		messages := make(map[string]string)
+		if ext == ".json" {
+			err = json.Unmarshal(data, &messages)
+		} else {
+			err = yaml.Unmarshal(data, &messages)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode catalog %s: %w", entry.Name(), err)
+		}
+		c.Add(locale, messages)
+	}
+	return nil
+}
+
+// Add merges messages into locale's catalog.
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	locale, _ = NormalizeLocale(locale)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string)
+	}
+	for key, message := range messages {
+		c.messages[locale][key] = message
+	}
+}
+
+// Locales returns the locales with a catalog, sorted.
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Lookup returns the message for key in the first of Fallbacks(locale) that
+// defines it.
+func (c *Catalog) Lookup(locale, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, candidate := range Fallbacks(locale) {
+		if message, ok := c.messages[candidate][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Translate formats the message for key with args, as fmt.Sprintf does.
+func (c *Catalog) Translate(locale, key string, args ...interface{}) (string, error) {
+	message, ok := c.Lookup(locale, key)
+	if !ok {
+		return "", fmt.Errorf("no message %q for locale %q", key, locale)
+	}
+	if len(args) == 0 {
+		return message, nil
+	}
+	return fmt.Sprintf(message, args...), nil
+}
+
+// NormalizeLocale lower-cases locale and replaces underscores with hyphens,
+// so "pt_BR" becomes "pt-br". It reports whether the result is a valid tag.
+func NormalizeLocale(locale string) (string, bool) {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	return locale, localePattern.MatchString(locale)
+}
+
+// Fallbacks returns the locales to try for locale, most specific first:
+// "es-mx" gives "es-mx", "es" and DefaultLocale.
+func Fallbacks(locale string) []string {
+	locale, ok := NormalizeLocale(locale)
+	if !ok {
+		return []string{DefaultLocale}
+	}
+
+	var fallbacks []string
+	for {
+		fallbacks = append(fallbacks, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	if fallbacks[len(fallbacks)-1] != DefaultLocale {
+		fallbacks = append(fallbacks, DefaultLocale)
+	}
+	return fallbacks
+}
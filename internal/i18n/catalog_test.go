@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFallbacks(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected []string
+	}{
+		{"en", []string{"en"}},
+		{"es", []string{"es", "en"}},
+		{"es-MX", []string{"es-mx", "es", "en"}},
+		{"zh_Hant_TW", []string{"zh-hant-tw", "zh-hant", "zh", "en"}},
+		{"", []string{"en"}},
+		{"not a locale", []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := Fallbacks(tt.locale); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCatalogTranslate(t *testing.T) {
+	catalog := NewCatalog()
+
+	tests := []struct {
+		name     string
+		locale   string
+		key      string
+		args     []interface{}
+		expected string
+	}{
+		{"English", "en", "greeting", []interface{}{"Ana"}, "Hello Ana"},
+		{"Spanish", "es", "greeting", []interface{}{"Ana"}, "Hola Ana"},
+		{"Regional fallback", "es-AR", "unsubscribe", nil, "Darse de baja"},
+		{"Default fallback", "ja", "unsubscribe", nil, "Unsubscribe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := catalog.Translate(tt.locale, tt.key, tt.args...)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := catalog.Translate("en", "no_such_key"); err == nil {
+		t.Error("Expected error for unknown key, got nil")
+	}
+}
+
+func TestCatalogLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"fr.json":    `{"greeting": "Bonjour %s"}`,
+		"pt_BR.yaml": "greeting: Olá %s\nunsubscribe: Cancelar inscrição\n",
+		"es.yml":     "unsubscribe: Cancelar suscripción\n",
+		"README.md":  "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	catalog := NewCatalog()
+	if err := catalog.LoadDir(dir); err != nil {
+		t.Fatalf("Failed to load catalogs: %v", err)
+	}
+
+	expectedLocales := []string{"en", "es", "fr", "pt-br"}
+	if got := catalog.Locales(); !reflect.DeepEqual(got, expectedLocales) {
+		t.Errorf("Expected locales %v, got %v", expectedLocales, got)
+	}
+
+	tests := []struct {
+		locale   string
+		key      string
+		expected string
+	}{
+		{"fr", "greeting", "Bonjour %s"},
+		{"pt-BR", "unsubscribe", "Cancelar inscrição"},
+		{"es", "unsubscribe", "Cancelar suscripción"},
+		{"es", "greeting", "Hola %s"},
+	}
+	for _, tt := range tests {
+		if got, _ := catalog.Lookup(tt.locale, tt.key); got != tt.expected {
+			t.Errorf("Expected %s/%s to be %q, got %q", tt.locale, tt.key, tt.expected, got)
+		}
+	}
+
+	bad := t.TempDir()
+	os.WriteFile(filepath.Join(bad, "english.json"), []byte(`{}`), 0o644)
+	if err := NewCatalog().LoadDir(bad); err == nil {
+		t.Error("Expected error for a catalog not named after a locale, got nil")
+	}
+}
@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// Logger writes structured log entries. args are alternating keys and
+// values, as with log/slog; *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// New returns a logger writing JSON entries at level and above to w.
+func New(w io.Writer, level string) *slog.Logger {
+	return NewWithLevel(w, ParseLevel(level))
+}
+
+// NewWithLevel is New with the level as a slog.Leveler, so passing a
+// *slog.LevelVar lets the level change while the logger is in use.
+func NewWithLevel(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel maps "debug", "info", "warn" and "error" to slog levels.
+// Anything else is info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// Default returns the process-wide slog default, which App replaces with a
+// logger built from the configured level.
+func Default() Logger {
+	return slog.Default()
+}
+
+// OrDefault returns logger, or the process-wide slog default when logger is
+// nil so zero-value services still log.
+func OrDefault(logger Logger) Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying id, the correlation ID of
+// the request it belongs to.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID carried by ctx, or "".
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// FromContext returns logger, or the process-wide slog default when logger
+// is nil, adding a correlation_id to every entry when ctx carries one, so
+// all entries for a request can be found by it.
+func FromContext(ctx context.Context, logger Logger) Logger {
+	logger = OrDefault(logger)
+	id := CorrelationID(ctx)
+	if id == "" {
+		return logger
+	}
+	if l, ok := logger.(*slog.Logger); ok {
+		return l.With("correlation_id", id)
+	}
+	return correlatedLogger{logger: logger, id: id}
+}
+
+// correlatedLogger adds a correlation_id to the entries of a Logger that is
+// not a *slog.Logger.
+type correlatedLogger struct {
+	logger Logger
+	id     string
+}
+
+func (l correlatedLogger) Debug(msg string, args ...any) {
+	l.logger.Debug(msg, append([]any{"correlation_id", l.id}, args...)...)
+}
+
+func (l correlatedLogger) Info(msg string, args ...any) {
+	l.logger.Info(msg, append([]any{"correlation_id", l.id}, args...)...)
+}
+
+func (l correlatedLogger) Warn(msg string, args ...any) {
+	l.logger.Warn(msg, append([]any{"correlation_id", l.id}, args...)...)
+}
+
+func (l correlatedLogger) Error(msg string, args ...any) {
+	l.logger.Error(msg, append([]any{"correlation_id", l.id}, args...)...)
+}
+
+// NotificationAttrs prefixes args with the notification_id, channel,
+// recipient_count and, outside the default tenant, tenant_id of notification
+// so entries can be filtered by them. Fan-out notifications report their
+// channels comma-separated.
+func NotificationAttrs(notification *models.Notification, args ...any) []any {
+	channel := string(notification.Channel)
+	if len(notification.Channels) > 0 {
+		channels := make([]string, len(notification.Channels))
+		for i, c := range notification.Channels {
+			channels[i] = string(c)
+		}
+		channel = strings.Join(channels, ",")
+	}
+	attrs := []any{
+		"notification_id", notification.ID,
+		"channel", channel,
+		"recipient_count", len(notification.Recipients),
+	}
+	if notification.TenantID != "" {
+		attrs = append(attrs, "tenant_id", notification.TenantID)
+	}
+	return append(attrs, args...)
+}
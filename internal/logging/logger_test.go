@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestNewFiltersByLevel(t *testing.T) {
+	tests := []struct {
+		level    string
+		expected int
+	}{
+		{"debug", 4},
+		{"info", 3},
+		{"WARN", 2},
+		{"error", 1},
+		{"", 3},
+		{"verbose", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, tt.level)
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Warn("warn")
+			logger.Error("error")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != tt.expected {
+				t.Errorf("Expected %d entries, got %d: %q", tt.expected, len(lines), buf.String())
+			}
+		})
+	}
+}
+
+func TestNotificationAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info")
+	notification := &models.Notification{
+		ID:         "n-1",
+		Channels:   []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail},
+		Recipients: []string{"U1", "a@example.com"},
+	}
+	logger.Info("Sent notification", NotificationAttrs(notification, "attempt", 2)...)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON entry, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "Sent notification" || entry["level"] != "INFO" {
+		t.Errorf("Unexpected entry: %v", entry)
+	}
+	if entry["notification_id"] != "n-1" || entry["channel"] != "slack,email" || entry["recipient_count"] != float64(2) {
+		t.Errorf("Expected notification fields, got %v", entry)
+	}
+	if entry["attempt"] != float64(2) {
+		t.Errorf("Expected extra args to follow, got %v", entry)
+	}
+}
+
+// recordingLogger is a Logger that is not a *slog.Logger.
+type recordingLogger struct {
+	args []any
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.args = args }
+func (l *recordingLogger) Info(msg string, args ...any)  { l.args = args }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.args = args }
+func (l *recordingLogger) Error(msg string, args ...any) { l.args = args }
+
+func TestFromContext(t *testing.T) {
+	ctx := WithCorrelationID(context.Background(), "req-1")
+
+	var buf bytes.Buffer
+	FromContext(ctx, New(&buf, "info")).Info("Sent notification", "attempt", 1)
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON entry, got %q: %v", buf.String(), err)
+	}
+	if entry["correlation_id"] != "req-1" || entry["attempt"] != float64(1) {
+		t.Errorf("Expected correlation_id and args, got %v", entry)
+	}
+
+	recorder := &recordingLogger{}
+	FromContext(ctx, recorder).Warn("Retrying", "attempt", 2)
+	if len(recorder.args) != 4 || recorder.args[0] != "correlation_id" || recorder.args[1] != "req-1" {
+		t.Errorf("Expected correlation_id to prefix args, got %v", recorder.args)
+	}
+
+	if logger := FromContext(context.Background(), recorder); logger != Logger(recorder) {
+		t.Errorf("Expected logger unchanged without a correlation ID, got %T", logger)
+	}
+}
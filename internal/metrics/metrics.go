@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsCollector holds the service's Prometheus metrics. Each collector has
+// its own registry so several can exist side by side, e.g. in tests.
+type MetricsCollector struct {
+	registry      *prometheus.Registry
+	sent          *prometheus.CounterVec
+	sendDuration  *prometheus.HistogramVec
+	retryAttempts *prometheus.CounterVec
+	circuitState  *prometheus.GaugeVec
+	scheduleDrift prometheus.Histogram
+}
+
+func NewMetricsCollector() *MetricsCollector {
+	m := &MetricsCollector{
+		registry: prometheus.NewRegistry(),
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "notifications_sent_total",
+			Help: "Notifications handed to a channel, by channel and outcome.",
+		}, []string{"channel", "status"}),
+		sendDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "notification_send_duration_seconds",
+			Help:    "Time taken to send a notification on a channel, including retries.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"channel"}),
+		retryAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retry_attempts_total",
+			Help: "Send attempts repeated after a retryable failure, by channel.",
+		}, []string{"channel"}),
+		circuitState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state by channel: 0 closed, 1 open, 2 half-open.",
+		}, []string{"channel"}),
+		scheduleDrift: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "schedule_drift_seconds",
+			Help: "Delay between a scheduled notification's scheduled time and the scheduler firing it.",
+			// The scheduler polls every second, so drift beyond that means
+			// it is falling behind
+			Buckets: []float64{0.5, 1, 1.5, 2, 5, 10, 20, 30, 45, 60},
+		}),
+	}
+	m.registry.MustRegister(
+		m.sent,
+		m.sendDuration,
+		m.retryAttempts,
+		m.circuitState,
+		m.scheduleDrift,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// ObserveSend records one send on channel that finished with status after
+// duration.
+func (m *MetricsCollector) ObserveSend(channel, status string, duration time.Duration) {
+	m.sent.WithLabelValues(channel, status).Inc()
+	m.sendDuration.WithLabelValues(channel).Observe(duration.Seconds())
+}
+
+// IncRetryAttempts counts one retried send on channel.
+func (m *MetricsCollector) IncRetryAttempts(channel string) {
+	m.retryAttempts.WithLabelValues(channel).Inc()
+}
+
+// SetCircuitBreakerState records the state of channel's circuit breaker,
+// encoded as 0 closed, 1 open, 2 half-open.
+func (m *MetricsCollector) SetCircuitBreakerState(channel string, state int) {
+	m.circuitState.WithLabelValues(channel).Set(float64(state))
+}
+
+// ObserveScheduleDrift records a scheduled notification fired drift after
+// its scheduled time.
+func (m *MetricsCollector) ObserveScheduleDrift(drift time.Duration) {
+	m.scheduleDrift.Observe(drift.Seconds())
+}
+
+// ObserveQueueDepth reports depth() as scheduler_queue_depth on every scrape.
+func (m *MetricsCollector) ObserveQueueDepth(depth func() int) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "scheduler_queue_depth",
+		Help: "Scheduled notifications waiting to be sent.",
+	}, func() float64 { return float64(depth()) }))
+}
+
+// Handler serves the collected metrics in the Prometheus exposition format.
+func (m *MetricsCollector) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	collector := NewMetricsCollector()
+	collector.ObserveSend("slack", "sent", 20*time.Millisecond)
+	collector.ObserveSend("slack", "sent", 30*time.Millisecond)
+	collector.ObserveSend("email", "failed", time.Second)
+	collector.IncRetryAttempts("email")
+	collector.ObserveQueueDepth(func() int { return 3 })
+	collector.SetCircuitBreakerState("slack", 1)
+	collector.ObserveScheduleDrift(700 * time.Millisecond)
+
+	if got := testutil.ToFloat64(collector.sent.WithLabelValues("slack", "sent")); got != 2 {
+		t.Errorf("Expected 2 sent slack notifications, got %v", got)
+	}
+	if got := testutil.ToFloat64(collector.retryAttempts.WithLabelValues("email")); got != 1 {
+		t.Errorf("Expected 1 email retry, got %v", got)
+	}
+	if got := testutil.CollectAndCount(collector.sendDuration); got != 2 {
+		t.Errorf("Expected durations for 2 channels, got %d", got)
+	}
+
+	rr := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, expected := range []string{
+		`notifications_sent_total{channel="email",status="failed"} 1`,
+		`notification_send_duration_seconds_count{channel="slack"} 2`,
+		`retry_attempts_total{channel="email"} 1`,
+		`scheduler_queue_depth 3`,
+		`circuit_breaker_state{channel="slack"} 1`,
+		`schedule_drift_seconds_bucket{le="0.5"} 0`,
+		`schedule_drift_seconds_bucket{le="1"} 1`,
+		`schedule_drift_seconds_bucket{le="60"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in metrics output", expected)
+		}
+	}
+}
@@ -1,31 +1,377 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 type NotificationChannel string
 
 const (
-	ChannelSlack   NotificationChannel = "slack"
-	ChannelEmail   NotificationChannel = "email"
-	ChannelMessage NotificationChannel = "message"
+	ChannelSlack     NotificationChannel = "slack"
+	ChannelEmail     NotificationChannel = "email"
+	ChannelMessage   NotificationChannel = "message"
+	ChannelWhatsApp  NotificationChannel = "whatsapp"
+	ChannelTeams     NotificationChannel = "teams"
+	ChannelDiscord   NotificationChannel = "discord"
+	ChannelPagerDuty NotificationChannel = "pagerduty"
+	ChannelFCM       NotificationChannel = "fcm"
+	ChannelAPNs      NotificationChannel = "apns"
+	ChannelSNS       NotificationChannel = "sns"
+	ChannelTelegram  NotificationChannel = "telegram"
+	ChannelWebhook   NotificationChannel = "webhook"
+)
+
+type NotificationStatus string
+
+const (
+	StatusPending   NotificationStatus = "pending"
+	StatusSent      NotificationStatus = "sent"
+	StatusFailed    NotificationStatus = "failed"
+	StatusCancelled NotificationStatus = "cancelled"
+	// StatusDelivered and StatusRead are reported by delivery receipts from
+	// channels that support them.
+	StatusDelivered NotificationStatus = "delivered"
+	StatusRead      NotificationStatus = "read"
+	// StatusSuppressed marks notifications that were not sent because every
+	// user they target has unsubscribed from their category, or every email
+	// recipient is on the suppression list.
+	StatusSuppressed NotificationStatus = "suppressed"
+	// StatusExpired marks notifications discarded unsent after ExpiresAt.
+	StatusExpired NotificationStatus = "expired"
+	// StatusMerged marks notifications that were batched into another one,
+	// whose MergedFrom lists them, and are not sent on their own.
+	StatusMerged NotificationStatus = "merged"
+)
+
+// NotificationPriority orders notifications that are due at the same time;
+// higher values are sent first.
+type NotificationPriority int
+
+const (
+	PriorityLow NotificationPriority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
 )
 
+func (p NotificationPriority) Valid() bool {
+	return p >= PriorityLow && p <= PriorityCritical
+}
+
+func (p NotificationPriority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	case PriorityCritical:
+		return "critical"
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
 type Notification struct {
-	ID          string
-	Title       string
-	Content     string
-	Channel     NotificationChannel
-	Recipients  []string
-	ScheduledAt *time.Time
-	CreatedAt   time.Time
-	SentAt      *time.Time
+	ID string
+	// TenantID namespaces the notification; empty is the default tenant of
+	// a single-tenant deployment.
+	TenantID   string
+	Title      string
+	Content    string
+	Channel    NotificationChannel
+	Channels   []NotificationChannel
+	Recipients []string
+	// Category groups related notifications, e.g. "billing", so users can
+	// unsubscribe from a subset of them.
+	Category string
+	// Locale, e.g. "es" or "pt-BR", selects localized template variants and
+	// catalog messages; empty uses the default templates in English.
+	Locale string
+	// ChannelRecipients overrides Recipients per channel when a fan-out
+	// sends different addresses to each channel.
+	ChannelRecipients map[NotificationChannel][]string
+	ScheduledAt       *time.Time
+	// CronExpr is set for recurring notifications, which fire on every
+	// match until cancelled.
+	CronExpr string
+	// ExpiresAt, when set, discards the notification if it is still unsent
+	// at that time.
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+	SentAt    *time.Time
+	// DeliveredAt and ReadAt are set from delivery receipts.
+	DeliveredAt *time.Time
+	ReadAt      *time.Time
+	// OpenedAt is when an email's tracking pixel was first loaded.
+	OpenedAt *time.Time
+	// MergedFrom lists the IDs of the notifications a batched notification
+	// was merged from.
+	MergedFrom []string
+	Status     NotificationStatus
+	Priority   NotificationPriority
+	// FailureReason holds the last delivery error when Status is failed.
+	FailureReason string
+	Metadata      map[string]string
+	// Attachments are files sent with email and Slack notifications.
+	Attachments []NotificationAttachment
+	// SentMetadata holds provider identifiers returned on delivery, such
+	// as the PagerDuty dedup key.
+	SentMetadata map[string]string
+	// CallbackURL, when set, is sent the outcome of the notification once it
+	// has been sent or has failed.
+	CallbackURL string
+	// RecipientStatuses holds the outcome for each recipient of channels
+	// that send to several recipients one by one, such as the envelope
+	// recipients of an email, blind copies included.
+	RecipientStatuses []RecipientStatus
+}
+
+// RecipientStatus is the outcome of sending a notification to one
+// recipient. Status is sent or failed, with Error holding the reason.
+type RecipientStatus struct {
+	Recipient string
+	Status    NotificationStatus
+	SentAt    *time.Time
+	Error     string
+}
+
+// RecordRecipient records the outcome of sending to recipient, replacing
+// any earlier outcome for it so that a retried send is not counted twice.
+func (n *Notification) RecordRecipient(recipient string, err error) {
+	status := RecipientStatus{Recipient: recipient, Status: StatusSent}
+	if err != nil {
+		status.Status, status.Error = StatusFailed, err.Error()
+	} else {
+		sentAt := time.Now()
+		status.SentAt = &sentAt
+	}
+	for i := range n.RecipientStatuses {
+		if n.RecipientStatuses[i].Recipient == recipient {
+			n.RecipientStatuses[i] = status
+			return
+		}
+	}
+	n.RecipientStatuses = append(n.RecipientStatuses, status)
+}
+
+// NotificationAttachment is a file attached to an email or Slack notification.
+type NotificationAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// NotificationTemplate renders a notification's title and content with
+// text/template syntax, e.g. "Hello {{.Name}}". HTMLContent and
+// PlainTextContent are email bodies rendered against the Notification itself,
+// e.g. "<h1>{{.Title}}</h1>".
+//
+// Every save of a template stores a new version numbered from 1; Version is
+// the number of this one.
+type NotificationTemplate struct {
+	Name             string
+	Version          int
+	TitleTemplate    string
+	ContentTemplate  string
+	HTMLContent      string
+	PlainTextContent string
+}
+
+// CategorySubscription records whether a user receives notifications in a
+// category. Users are subscribed to every category until they opt out.
+type CategorySubscription struct {
+	Category   string
+	Subscribed bool
+}
+
+// Roles granted through Credentials. Admins can do everything senders can.
+const (
+	RoleSender = "sender"
+	RoleAdmin  = "admin"
+)
+
+// Credentials let a user sign in to the API. Only a bcrypt hash of the
+// password is stored.
+type Credentials struct {
+	PasswordHash string
+	Roles        []string
+	// TenantID binds the user's tokens to one tenant; users without one may
+	// act for any tenant.
+	TenantID string
 }
 
+// User holds a person's addresses and the channel they prefer to be
+// notified on. Addresses for channels without a dedicated field, such as a
+// Telegram chat ID, live in Metadata keyed by channel name.
 type User struct {
-	ID       string
-	Name     string
-	Email    string
-	SlackID  string
-	Phone    string
-	Metadata map[string]string
+	ID               string
+	Name             string
+	Email            string
+	SlackID          string
+	Phone            string
+	PreferredChannel NotificationChannel
+	Metadata         map[string]string
+}
+
+// Address returns the user's address on channel, if they have one.
+func (u *User) Address(channel NotificationChannel) (string, bool) {
+	var address string
+	switch channel {
+	case ChannelEmail:
+		address = u.Email
+	case ChannelSlack:
+		address = u.SlackID
+	case ChannelMessage, ChannelWhatsApp:
+		address = u.Phone
+	default:
+		address = u.Metadata[string(channel)]
+	}
+	return address, address != ""
+}
+
+// APIKey authorizes requests to the HTTP API. Only a bcrypt hash of the key
+// is stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID      string
+	Name    string
+	KeyHash string
+	// LookupHash is the hex SHA-256 of the key, to find it without trying
+	// every bcrypt hash. Keys provisioned before it was recorded lack one.
+	LookupHash string
+	// TenantID binds the key to one tenant; keys without one may act for
+	// any tenant.
+	TenantID  string
+	CreatedAt time.Time
+}
+
+// Tenant is an isolated customer of the service. Each tenant sees only its
+// own notifications and may send through its own channel credentials.
+type Tenant struct {
+	ID        string
+	Name      string
+	Config    TenantConfig
+	CreatedAt time.Time
+}
+
+// TenantConfig overrides channel credentials for a tenant. Empty fields fall
+// back to the service-wide configuration.
+type TenantConfig struct {
+	SlackWebhookURL     string `json:"slack_webhook_url,omitempty"`
+	TeamsWebhookURL     string `json:"teams_webhook_url,omitempty"`
+	DiscordBotToken     string `json:"discord_bot_token,omitempty"`
+	PagerDutyRoutingKey string `json:"pagerduty_routing_key,omitempty"`
+	TelegramBotToken    string `json:"telegram_bot_token,omitempty"`
+	SMTPFrom            string `json:"smtp_from,omitempty"`
+	WebhookSecret       string `json:"webhook_secret,omitempty"`
+}
+
+// AuditEventType names a notification state transition.
+type AuditEventType string
+
+const (
+	AuditCreated     AuditEventType = "created"
+	AuditSent        AuditEventType = "sent"
+	AuditFailed      AuditEventType = "failed"
+	AuditCancelled   AuditEventType = "cancelled"
+	AuditRescheduled AuditEventType = "rescheduled"
+	AuditExpired     AuditEventType = "expired"
+	AuditSuppressed  AuditEventType = "suppressed"
+	// AuditExported records an export of notifications, so it has no
+	// NotificationID.
+	AuditExported AuditEventType = "exported"
+)
+
+// AuditEvent records one state transition of a notification. Events are
+// append-only: once written they are never changed or removed. ActorID is
+// the user or component that caused the transition.
+type AuditEvent struct {
+	EventType      AuditEventType
+	NotificationID string
+	TenantID       string
+	ActorID        string
+	Timestamp      time.Time
+	Metadata       map[string]string
+}
+
+// ClickEvent records a recipient following a tracked link in a notification.
+type ClickEvent struct {
+	NotificationID string
+	TenantID       string
+	URL            string
+	ClickedAt      time.Time
+}
+
+// CallbackAttempt records a single POST of a notification's delivery status
+// to its CallbackURL. StatusCode is 0 when no response was received.
+type CallbackAttempt struct {
+	NotificationID string
+	TenantID       string
+	URL            string
+	Attempt        int
+	StatusCode     int
+	Error          string
+	AttemptedAt    time.Time
+}
+
+// OutboxEmail is an email waiting in the outbox to be delivered. Message is
+// the complete message, headers included, and Recipients every envelope
+// recipient, blind copies included.
+type OutboxEmail struct {
+	ID             int64
+	NotificationID string
+	TenantID       string
+	Recipients     []string
+	Message        []byte
+	// RetryCount is how many deliveries have failed; LastError is the
+	// error of the latest.
+	RetryCount int
+	LastError  string
+	CreatedAt  time.Time
+}
+
+// NotificationEventType names a change in a notification's event history.
+type NotificationEventType string
+
+const (
+	// EventCreated and EventUpdated carry the whole notification as saved.
+	EventCreated NotificationEventType = "created"
+	EventUpdated NotificationEventType = "updated"
+	// EventStatusChanged carries Status and FailureReason, and any of
+	// SentAt, DeliveredAt and ReadAt that were set.
+	EventStatusChanged NotificationEventType = "status_changed"
+	// EventRescheduled carries ScheduledAt.
+	EventRescheduled NotificationEventType = "rescheduled"
+	// EventOpened carries OpenedAt.
+	EventOpened NotificationEventType = "opened"
+	// EventClicked carries the URL and ClickedAt of a ClickEvent.
+	EventClicked NotificationEventType = "clicked"
+	// EventDeleted has an empty payload.
+	EventDeleted NotificationEventType = "deleted"
+)
+
+// NotificationEvent is one entry in the append-only history of a
+// notification. Payloads are JSON objects using the field names of
+// Notification, so replaying them in Sequence order rebuilds its state.
+type NotificationEvent struct {
+	// Sequence orders the events of all notifications.
+	Sequence       int64
+	NotificationID string
+	TenantID       string
+	Type           NotificationEventType
+	Payload        json.RawMessage
+	Timestamp      time.Time
+}
+
+// NotificationReadModel is the state of a notification derived from its
+// event history.
+type NotificationReadModel struct {
+	Notification
+	Clicks  int
+	Deleted bool
+	// Version is the Sequence of the last event applied.
+	Version   int64
+	UpdatedAt time.Time
 }
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// DefaultEmailAllowedTags are the HTML tags kept in email content when no
+// allowlist is configured.
+var DefaultEmailAllowedTags = []string{
+	"a", "b", "blockquote", "br", "code", "div", "em", "h1", "h2", "h3", "h4", "h5", "h6",
+	"hr", "i", "img", "li", "ol", "p", "pre", "span", "strong", "table", "tbody", "td",
+	"th", "thead", "tr", "u", "ul",
+}
+
+var (
+	// webPolicy keeps the formatting and links user-generated content may
+	// use, dropping scripts, styles, event handlers and unsafe URLs.
+	webPolicy = bluemonday.UGCPolicy()
+	// stripPolicy drops every tag.
+	stripPolicy = bluemonday.StrictPolicy()
+)
+
+// SanitizeContent strips the HTML tags that are not allowed in web and push
+// notifications from content, keeping basic formatting and safe links.
+// Content without tags is returned unchanged.
+func SanitizeContent(content string) string {
+	if !hasMarkup(content) {
+		return content
+	}
+	return webPolicy.Sanitize(content)
+}
+
+// StripHTML removes every HTML tag from content for channels that show
+// plain text. Entities are unescaped, so "a &amp; b" becomes "a & b".
+func StripHTML(content string) string {
+	if !hasMarkup(content) {
+		return content
+	}
+	return html.UnescapeString(stripPolicy.Sanitize(content))
+}
+
+// EmailSanitizer strips the HTML tags of email content that are not on its
+// allowlist. Links keep their href and images their src and alt, as long as
+// the URLs are http, https or mailto.
+type EmailSanitizer struct {
+	policy *bluemonday.Policy
+}
+
+// NewEmailSanitizer allows allowedTags in email content, or
+// DefaultEmailAllowedTags when allowedTags is empty.
+func NewEmailSanitizer(allowedTags []string) *EmailSanitizer {
+	if len(allowedTags) == 0 {
+		allowedTags = DefaultEmailAllowedTags
+	}
+	policy := bluemonday.NewPolicy()
+	policy.AllowElements(allowedTags...)
+	policy.AllowStandardURLs()
+	policy.AllowURLSchemes("mailto", "http", "https")
+	policy.AllowAttrs("href").OnElements("a")
+	policy.AllowAttrs("src", "alt", "width", "height").OnElements("img")
+	policy.AllowAttrs("colspan", "rowspan").OnElements("td", "th")
+	return &EmailSanitizer{policy: policy}
+}
+
+// Sanitize strips the tags of content that are not on the allowlist.
+// Content without tags is returned unchanged.
+func (s *EmailSanitizer) Sanitize(content string) string {
+	if !hasMarkup(content) {
+		return content
+	}
+	return s.policy.Sanitize(content)
+}
+
+// hasMarkup reports whether content may contain a tag. Content without one
+// is left alone so plain text keeps characters such as "&" unescaped.
+func hasMarkup(content string) bool {
+	return strings.ContainsRune(content, '<')
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"notification-service/internal/models"
+)
+
+// ErrAPIKeyNotFound is returned when no key has the lookup hash asked for.
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// APIKeyRepository stores provisioned API keys.
+type APIKeyRepository interface {
+	SaveAPIKey(ctx context.Context, key *models.APIKey) error
+	// ListAPIKeys returns every key, oldest first.
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	// GetAPIKeyByLookupHash returns ErrAPIKeyNotFound if no key has
+	// lookupHash.
+	GetAPIKeyByLookupHash(ctx context.Context, lookupHash string) (*models.APIKey, error)
+	// SetAPIKeyLookupHash records the lookup hash of a key stored without
+	// one.
+	SetAPIKeyLookupHash(ctx context.Context, id, lookupHash string) error
+}
+
+const apiKeyColumns = `id, name, key_hash, lookup_hash, tenant_id, created_at`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := row.Scan(&key.ID, &key.Name, &key.KeyHash, &key.LookupHash, &key.TenantID, &key.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func scanAPIKeys(rows *sql.Rows) ([]*models.APIKey, error) {
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
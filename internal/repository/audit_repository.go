@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"notification-service/internal/models"
+)
+
+// AuditRepository stores notification audit events. The SQL backends reject
+// updates and deletes of stored events.
+type AuditRepository interface {
+	AppendAuditEvent(ctx context.Context, event *models.AuditEvent) error
+	// ListAuditEvents returns the events of a notification, oldest first.
+	ListAuditEvents(ctx context.Context, notificationID string) ([]*models.AuditEvent, error)
+}
+
+const auditEventColumns = `event_type, notification_id, tenant_id, actor_id, timestamp, metadata`
+
+func marshalAuditMetadata(event *models.AuditEvent) sql.NullString {
+	if event.Metadata == nil {
+		return sql.NullString{}
+	}
+	data, _ := json.Marshal(event.Metadata)
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]*models.AuditEvent, error) {
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		var (
+			event     models.AuditEvent
+			eventType string
+			metadata  sql.NullString
+		)
+		if err := rows.Scan(&eventType, &event.NotificationID, &event.TenantID, &event.ActorID, &event.Timestamp, &metadata); err != nil {
+			return nil, err
+		}
+		event.EventType = models.AuditEventType(eventType)
+		if metadata.Valid && metadata.String != "" {
+			json.Unmarshal([]byte(metadata.String), &event.Metadata)
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
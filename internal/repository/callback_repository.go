@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"notification-service/internal/models"
+)
+
+// CallbackRepository stores the attempts made to POST notifications'
+// delivery status to their callback URLs.
+type CallbackRepository interface {
+	RecordCallbackAttempt(ctx context.Context, attempt *models.CallbackAttempt) error
+	ListCallbackAttempts(ctx context.Context, tenantID, notificationID string) ([]*models.CallbackAttempt, error)
+}
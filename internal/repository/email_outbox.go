@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"notification-service/internal/models"
+)
+
+// EmailOutbox holds emails accepted for delivery until they are sent, so an
+// email is not lost if the process stops between accepting and sending it.
+type EmailOutbox interface {
+	// EnqueueEmail adds email to the outbox and applies update to its
+	// notification in the same transaction, setting email.ID. Nothing is
+	// added, and ErrNotFound is returned, when the notification is not
+	// stored.
+	EnqueueEmail(ctx context.Context, email *models.OutboxEmail, update StatusUpdate) error
+	// ListOutboxEmails returns up to limit emails, oldest first, that have
+	// failed fewer than maxRetries times.
+	ListOutboxEmails(ctx context.Context, maxRetries, limit int) ([]*models.OutboxEmail, error)
+	// DeleteOutboxEmail removes a delivered email.
+	DeleteOutboxEmail(ctx context.Context, id int64) error
+	// RecordOutboxEmailFailure counts a failed delivery of email id and
+	// keeps reason as its LastError.
+	RecordOutboxEmailFailure(ctx context.Context, id int64, reason string) error
+}
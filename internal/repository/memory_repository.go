@@ -0,0 +1,630 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"notification-service/internal/models"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository is a map-backed NotificationRepository for tests and for
+// running without a database.
+type MemoryRepository struct {
+	notifications map[string]*models.Notification
+	users         map[string]*models.User
+	subscriptions map[string]map[string]bool
+	credentials   map[string]models.Credentials
+	apiKeys       []*models.APIKey
+	tenants       map[string]*models.Tenant
+	auditEvents   []*models.AuditEvent
+	clicks        []*models.ClickEvent
+	suppressions  map[suppressionKey]bool
+	callbacks     []*models.CallbackAttempt
+	outbox        []*models.OutboxEmail
+	outboxID      int64
+	events        []*models.NotificationEvent
+	publisher     EventPublisher
+	mu            sync.RWMutex
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		notifications: make(map[string]*models.Notification),
+		users:         make(map[string]*models.User),
+		subscriptions: make(map[string]map[string]bool),
+		credentials:   make(map[string]models.Credentials),
+		tenants:       make(map[string]*models.Tenant),
+		suppressions:  make(map[suppressionKey]bool),
+	}
+}
+
+type suppressionKey struct {
+	email    string
+	category string
+}
+
+func (r *MemoryRepository) Save(ctx context.Context, notification *models.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.notifications[notification.ID]
+	if exists && existing.TenantID != notification.TenantID {
+		return ErrNotFound
+	}
+	copied := *notification
+	copied.Status = statusOrDefault(copied.Status)
+	r.notifications[notification.ID] = &copied
+	r.appendEvent(savedEvent(notification, exists))
+	return nil
+}
+
+// WithEventPublisher publishes each event appended to a notification's
+// history to publisher.
+func (r *MemoryRepository) WithEventPublisher(publisher EventPublisher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publisher = publisher
+}
+
+// appendEvent adds event to the history with the next sequence number and
+// publishes a copy. It must be called with r.mu held.
+func (r *MemoryRepository) appendEvent(event *models.NotificationEvent) {
+	event.Sequence = int64(len(r.events) + 1)
+	r.events = append(r.events, event)
+	if r.publisher != nil {
+		copied := *event
+		r.publisher.Publish(&copied)
+	}
+}
+
+func (r *MemoryRepository) GetEventHistory(ctx context.Context, tenantID, id string) ([]*models.NotificationEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*models.NotificationEvent
+	for _, event := range r.events {
+		if event.TenantID == tenantID && event.NotificationID == id {
+			copied := *event
+			events = append(events, &copied)
+		}
+	}
+	if len(events) == 0 {
+		return nil, ErrNotFound
+	}
+	return events, nil
+}
+
+// get returns the stored notification id if it belongs to tenantID. It must
+// be called with r.mu held.
+func (r *MemoryRepository) get(tenantID, id string) (*models.Notification, bool) {
+	notification, exists := r.notifications[id]
+	if !exists || notification.TenantID != tenantID {
+		return nil, false
+	}
+	return notification, true
+}
+
+func (r *MemoryRepository) GetByID(ctx context.Context, tenantID, id string) (*models.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notification, exists := r.get(tenantID, id)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	copied := *notification
+	return &copied, nil
+}
+
+func (r *MemoryRepository) ListAll(ctx context.Context, tenantID string) ([]*models.Notification, error) {
+	return r.list(func(n *models.Notification) bool { return n.TenantID == tenantID }), nil
+}
+
+func (r *MemoryRepository) ListByStatus(ctx context.Context, tenantID string, status models.NotificationStatus) ([]*models.Notification, error) {
+	return r.list(func(n *models.Notification) bool { return n.TenantID == tenantID && n.Status == status }), nil
+}
+
+// list returns copies of the notifications matching keep, newest first.
+func (r *MemoryRepository) List(ctx context.Context, opts ListOptions) (*NotificationPage, error) {
+	var cursorAt time.Time
+	var cursorID string
+	if opts.Cursor != "" {
+		var err error
+		if cursorAt, cursorID, err = decodeCursor(opts.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	matching := r.list(func(n *models.Notification) bool {
+		return n.TenantID == opts.TenantID &&
+			(opts.Status == "" || n.Status == opts.Status) &&
+			(opts.Channel == "" || n.Channel == opts.Channel || containsChannel(n.Channels, opts.Channel)) &&
+			(opts.Category == "" || n.Category == opts.Category) &&
+			(!opts.Recurring || n.CronExpr != "") &&
+			(opts.Query == "" || matchesQuery(n, opts.Query)) &&
+			(opts.From.IsZero() || !n.CreatedAt.Before(opts.From)) &&
+			(opts.To.IsZero() || n.CreatedAt.Before(opts.To))
+	})
+
+	page := &NotificationPage{Notifications: []*models.Notification{}, TotalCount: len(matching)}
+	limit := opts.limit()
+	for _, notification := range matching {
+		if opts.Cursor != "" && !(notification.CreatedAt.Before(cursorAt) ||
+			(notification.CreatedAt.Equal(cursorAt) && notification.ID < cursorID)) {
+			continue
+		}
+		if len(page.Notifications) == limit {
+			page.NextCursor = encodeCursor(page.Notifications[limit-1])
+			break
+		}
+		page.Notifications = append(page.Notifications, notification)
+	}
+	return page, nil
+}
+
+func (r *MemoryRepository) Aggregate(ctx context.Context, opts StatsOptions) (*NotificationStats, error) {
+	since := time.Now().Add(-24 * time.Hour)
+	stats := newNotificationStats()
+	for _, n := range r.list(func(n *models.Notification) bool {
+		return n.TenantID == opts.TenantID &&
+			(opts.From.IsZero() || !n.CreatedAt.Before(opts.From)) &&
+			(opts.To.IsZero() || n.CreatedAt.Before(opts.To))
+	}) {
+		stats.ByStatus[n.Status]++
+		if n.Channel != "" {
+			stats.ByChannel[n.Channel]++
+		}
+		for _, channel := range n.Channels {
+			stats.ByChannel[channel]++
+		}
+		if !n.CreatedAt.Before(since) {
+			stats.Last24h++
+		}
+	}
+	return stats, nil
+}
+
+func containsChannel(channels []models.NotificationChannel, channel models.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesQuery reports whether notification's title or content contains
+// query, ignoring case.
+func matchesQuery(notification *models.Notification, query string) bool {
+	query = strings.ToLower(query)
+	return strings.Contains(strings.ToLower(notification.Title), query) ||
+		strings.Contains(strings.ToLower(notification.Content), query)
+}
+
+func (r *MemoryRepository) list(keep func(*models.Notification) bool) []*models.Notification {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifications := make([]*models.Notification, 0, len(r.notifications))
+	for _, notification := range r.notifications {
+		if !keep(notification) {
+			continue
+		}
+		copied := *notification
+		notifications = append(notifications, &copied)
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		if notifications[i].CreatedAt.Equal(notifications[j].CreatedAt) {
+			return notifications[i].ID > notifications[j].ID
+		}
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	return notifications
+}
+
+func (r *MemoryRepository) UpdateStatus(ctx context.Context, tenantID, id string, update StatusUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, exists := r.get(tenantID, id)
+	if !exists {
+		return ErrNotFound
+	}
+	applyStatusUpdate(notification, update)
+	r.appendEvent(statusChangedEvent(tenantID, id, update))
+	return nil
+}
+
+func applyStatusUpdate(notification *models.Notification, update StatusUpdate) {
+	notification.Status = update.Status
+	notification.FailureReason = update.FailureReason
+	if update.SentAt != nil {
+		notification.SentAt = update.SentAt
+	}
+	if update.DeliveredAt != nil {
+		notification.DeliveredAt = update.DeliveredAt
+	}
+	if update.ReadAt != nil {
+		notification.ReadAt = update.ReadAt
+	}
+	if len(update.RecipientStatuses) > 0 {
+		notification.RecipientStatuses = update.RecipientStatuses
+	}
+	if update.Metadata != nil {
+		notification.Metadata = maps.Clone(update.Metadata)
+	}
+}
+
+func (r *MemoryRepository) UpdateScheduledAt(ctx context.Context, tenantID, id string, scheduledAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, exists := r.get(tenantID, id)
+	if !exists {
+		return ErrNotFound
+	}
+	notification.ScheduledAt = &scheduledAt
+	r.appendEvent(newNotificationEvent(tenantID, id, models.EventRescheduled, map[string]any{"ScheduledAt": scheduledAt}))
+	return nil
+}
+
+func (r *MemoryRepository) RecordOpen(ctx context.Context, tenantID, id string, openedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, exists := r.get(tenantID, id)
+	if !exists {
+		return ErrNotFound
+	}
+	if notification.OpenedAt == nil {
+		notification.OpenedAt = &openedAt
+	}
+	r.appendEvent(newNotificationEvent(tenantID, id, models.EventOpened, map[string]any{"OpenedAt": openedAt}))
+	return nil
+}
+
+func (r *MemoryRepository) RecordClick(ctx context.Context, tenantID, id, url string, clickedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.get(tenantID, id); !exists {
+		return ErrNotFound
+	}
+	r.clicks = append(r.clicks, &models.ClickEvent{NotificationID: id, TenantID: tenantID, URL: url, ClickedAt: clickedAt})
+	r.appendEvent(newNotificationEvent(tenantID, id, models.EventClicked, map[string]any{"URL": url, "ClickedAt": clickedAt}))
+	return nil
+}
+
+func (r *MemoryRepository) ListClicks(ctx context.Context, tenantID, id string) ([]*models.ClickEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var clicks []*models.ClickEvent
+	for _, click := range r.clicks {
+		if click.TenantID == tenantID && click.NotificationID == id {
+			copied := *click
+			clicks = append(clicks, &copied)
+		}
+	}
+	return clicks, nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, tenantID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.get(tenantID, id); !exists {
+		return ErrNotFound
+	}
+	delete(r.notifications, id)
+	r.appendEvent(newNotificationEvent(tenantID, id, models.EventDeleted, struct{}{}))
+	return nil
+}
+
+func (r *MemoryRepository) SaveUser(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) GetUser(ctx context.Context, id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// ListUsers returns users sorted by ID.
+func (r *MemoryRepository) ListUsers(ctx context.Context) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.users))
+	for _, user := range r.users {
+		copied := *user
+		users = append(users, &copied)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	return users, nil
+}
+
+func (r *MemoryRepository) DeleteUser(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.users[id]; !exists {
+		return ErrUserNotFound
+	}
+	delete(r.users, id)
+	delete(r.subscriptions, id)
+	delete(r.credentials, id)
+	return nil
+}
+
+func (r *MemoryRepository) SaveSubscription(ctx context.Context, userID string, subscription models.CategorySubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.subscriptions[userID] == nil {
+		r.subscriptions[userID] = make(map[string]bool)
+	}
+	r.subscriptions[userID][subscription.Category] = subscription.Subscribed
+	return nil
+}
+
+func (r *MemoryRepository) ListSubscriptions(ctx context.Context, userID string) ([]models.CategorySubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions := make([]models.CategorySubscription, 0, len(r.subscriptions[userID]))
+	for category, subscribed := range r.subscriptions[userID] {
+		subscriptions = append(subscriptions, models.CategorySubscription{Category: category, Subscribed: subscribed})
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].Category < subscriptions[j].Category
+	})
+	return subscriptions, nil
+}
+
+func (r *MemoryRepository) SaveAPIKey(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *key
+	r.apiKeys = append(r.apiKeys, &copied)
+	return nil
+}
+
+func (r *MemoryRepository) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*models.APIKey, len(r.apiKeys))
+	for i, key := range r.apiKeys {
+		copied := *key
+		keys[i] = &copied
+	}
+	return keys, nil
+}
+
+func (r *MemoryRepository) GetAPIKeyByLookupHash(ctx context.Context, lookupHash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.apiKeys {
+		if key.LookupHash == lookupHash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+func (r *MemoryRepository) SetAPIKeyLookupHash(ctx context.Context, id, lookupHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.apiKeys {
+		if key.ID == id {
+			key.LookupHash = lookupHash
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) AppendAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.auditEvents = append(r.auditEvents, copyAuditEvent(event))
+	return nil
+}
+
+func (r *MemoryRepository) ListAuditEvents(ctx context.Context, notificationID string) ([]*models.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*models.AuditEvent
+	for _, event := range r.auditEvents {
+		if event.NotificationID == notificationID {
+			events = append(events, copyAuditEvent(event))
+		}
+	}
+	return events, nil
+}
+
+func copyAuditEvent(event *models.AuditEvent) *models.AuditEvent {
+	copied := *event
+	if event.Metadata != nil {
+		copied.Metadata = make(map[string]string, len(event.Metadata))
+		for key, value := range event.Metadata {
+			copied.Metadata[key] = value
+		}
+	}
+	return &copied
+}
+
+func (r *MemoryRepository) SaveCredentials(ctx context.Context, userID string, credentials models.Credentials) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credentials.Roles = append([]string(nil), credentials.Roles...)
+	r.credentials[userID] = credentials
+	return nil
+}
+
+func (r *MemoryRepository) GetCredentials(ctx context.Context, userID string) (*models.Credentials, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	credentials, exists := r.credentials[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	credentials.Roles = append([]string(nil), credentials.Roles...)
+	return &credentials, nil
+}
+
+func (r *MemoryRepository) SaveTenant(ctx context.Context, tenant *models.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *tenant
+	if existing, exists := r.tenants[tenant.ID]; exists {
+		copied.CreatedAt = existing.CreatedAt
+	}
+	r.tenants[tenant.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) GetTenant(ctx context.Context, id string) (*models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, exists := r.tenants[id]
+	if !exists {
+		return nil, ErrTenantNotFound
+	}
+	copied := *tenant
+	return &copied, nil
+}
+
+func (r *MemoryRepository) ListTenants(ctx context.Context) ([]*models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]*models.Tenant, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		copied := *tenant
+		tenants = append(tenants, &copied)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants, nil
+}
+
+func (r *MemoryRepository) AddSuppression(ctx context.Context, email, category string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.suppressions[suppressionKey{email, category}] = true
+	return nil
+}
+
+func (r *MemoryRepository) IsSuppressed(ctx context.Context, email, category string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.suppressions[suppressionKey{email, category}], nil
+}
+
+func (r *MemoryRepository) RecordCallbackAttempt(ctx context.Context, attempt *models.CallbackAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *attempt
+	r.callbacks = append(r.callbacks, &copied)
+	return nil
+}
+
+func (r *MemoryRepository) ListCallbackAttempts(ctx context.Context, tenantID, notificationID string) ([]*models.CallbackAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var attempts []*models.CallbackAttempt
+	for _, attempt := range r.callbacks {
+		if attempt.TenantID == tenantID && attempt.NotificationID == notificationID {
+			copied := *attempt
+			attempts = append(attempts, &copied)
+		}
+	}
+	return attempts, nil
+}
+
+func (r *MemoryRepository) EnqueueEmail(ctx context.Context, email *models.OutboxEmail, update StatusUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, exists := r.get(email.TenantID, email.NotificationID)
+	if !exists {
+		return ErrNotFound
+	}
+	applyStatusUpdate(notification, update)
+	r.outboxID++
+	email.ID = r.outboxID
+	copied := *email
+	r.outbox = append(r.outbox, &copied)
+	r.appendEvent(statusChangedEvent(email.TenantID, email.NotificationID, update))
+	return nil
+}
+
+func (r *MemoryRepository) ListOutboxEmails(ctx context.Context, maxRetries, limit int) ([]*models.OutboxEmail, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var emails []*models.OutboxEmail
+	for _, email := range r.outbox {
+		if len(emails) == limit {
+			break
+		}
+		if email.RetryCount < maxRetries {
+			copied := *email
+			emails = append(emails, &copied)
+		}
+	}
+	return emails, nil
+}
+
+func (r *MemoryRepository) DeleteOutboxEmail(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outbox = slices.DeleteFunc(r.outbox, func(email *models.OutboxEmail) bool { return email.ID == id })
+	return nil
+}
+
+func (r *MemoryRepository) RecordOutboxEmailFailure(ctx context.Context, id int64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, email := range r.outbox {
+		if email.ID == id {
+			email.RetryCount++
+			email.LastError = reason
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id            TEXT PRIMARY KEY,
+    title         TEXT NOT NULL,
+    content       TEXT NOT NULL,
+    channel       TEXT NOT NULL,
+    recipients    JSONB NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'pending',
+    scheduled_at  TIMESTAMPTZ NULL,
+    created_at    TIMESTAMPTZ NOT NULL,
+    sent_at       TIMESTAMPTZ NULL,
+    metadata      JSONB NULL,
+    sent_metadata JSONB NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications (created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_status;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS failure_reason;
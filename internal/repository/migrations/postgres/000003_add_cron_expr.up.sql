@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS cron_expr TEXT NOT NULL DEFAULT '';
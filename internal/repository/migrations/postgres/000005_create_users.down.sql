@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS users;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS channel_recipients;
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS category_subscriptions;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS category;
@@ -0,0 +1,8 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS category_subscriptions (
+    user_id    TEXT NOT NULL,
+    category   TEXT NOT NULL,
+    subscribed BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, category)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS tenants;
+
+DROP INDEX IF EXISTS idx_notifications_tenant_created_at;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id              BIGSERIAL PRIMARY KEY,
+    event_type      TEXT NOT NULL,
+    notification_id TEXT NOT NULL,
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    actor_id        TEXT NOT NULL DEFAULT '',
+    timestamp       TIMESTAMPTZ NOT NULL,
+    metadata        TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_notification_id ON audit_events (notification_id, id);
+
+CREATE OR REPLACE FUNCTION reject_audit_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change();
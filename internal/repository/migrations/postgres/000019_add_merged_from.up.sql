@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS merged_from TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS callback_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notification_events;
+
+DROP FUNCTION IF EXISTS reject_notification_event_change();
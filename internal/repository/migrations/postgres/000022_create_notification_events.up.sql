@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS notification_events (
+    id              BIGSERIAL PRIMARY KEY,
+    notification_id TEXT NOT NULL,
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    event_type      TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    timestamp       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_events_notification_id ON notification_events (tenant_id, notification_id, id);
+
+CREATE OR REPLACE FUNCTION reject_notification_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'notification events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER notification_events_append_only BEFORE UPDATE OR DELETE ON notification_events
+    FOR EACH ROW EXECUTE FUNCTION reject_notification_event_change();
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    notification_id TEXT NOT NULL,
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    recipients      TEXT NOT NULL,
+    message         BYTEA NOT NULL,
+    retry_count     INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_retry_count ON email_outbox (retry_count, id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS template_versions;
+
+ALTER TABLE templates DROP COLUMN IF EXISTS version;
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS recipient_statuses TEXT NOT NULL DEFAULT '';
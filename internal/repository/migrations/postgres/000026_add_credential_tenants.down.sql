@@ -0,0 +1,3 @@
+ALTER TABLE user_credentials DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE user_credentials ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id            TEXT PRIMARY KEY,
+    title         TEXT NOT NULL,
+    content       TEXT NOT NULL,
+    channel       TEXT NOT NULL,
+    recipients    TEXT NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'pending',
+    scheduled_at  TIMESTAMP NULL,
+    created_at    TIMESTAMP NOT NULL,
+    sent_at       TIMESTAMP NULL,
+    metadata      TEXT NULL,
+    sent_metadata TEXT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications (created_at);
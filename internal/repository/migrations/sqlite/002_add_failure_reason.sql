@@ -0,0 +1,3 @@
+ALTER TABLE notifications ADD COLUMN failure_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications (status);
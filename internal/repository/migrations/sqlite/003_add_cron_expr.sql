@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN cron_expr TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN channels TEXT NOT NULL DEFAULT '';
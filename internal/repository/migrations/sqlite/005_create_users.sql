@@ -0,0 +1,11 @@
+ALTER TABLE notifications ADD COLUMN channel_recipients TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS users (
+    id                TEXT PRIMARY KEY,
+    name              TEXT NOT NULL DEFAULT '',
+    email             TEXT NOT NULL DEFAULT '',
+    slack_id          TEXT NOT NULL DEFAULT '',
+    phone             TEXT NOT NULL DEFAULT '',
+    preferred_channel TEXT NOT NULL DEFAULT '',
+    metadata          TEXT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN priority INTEGER NOT NULL DEFAULT 1;
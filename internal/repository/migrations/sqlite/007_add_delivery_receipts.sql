@@ -0,0 +1,2 @@
+ALTER TABLE notifications ADD COLUMN delivered_at TIMESTAMP NULL;
+ALTER TABLE notifications ADD COLUMN read_at TIMESTAMP NULL;
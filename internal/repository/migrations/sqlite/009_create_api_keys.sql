@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL DEFAULT '',
+    key_hash   TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS user_credentials (
+    user_id       TEXT PRIMARY KEY,
+    password_hash TEXT NOT NULL,
+    roles         TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,10 @@
+ALTER TABLE notifications ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_notifications_tenant_created_at ON notifications (tenant_id, created_at);
+
+CREATE TABLE IF NOT EXISTS tenants (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL DEFAULT '',
+    config     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN expires_at TIMESTAMP NULL;
@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN locale TEXT NOT NULL DEFAULT '';
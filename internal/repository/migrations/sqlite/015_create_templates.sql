@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS templates (
+    name               TEXT PRIMARY KEY,
+    title_template     TEXT NOT NULL DEFAULT '',
+    content_template   TEXT NOT NULL DEFAULT '',
+    html_content       TEXT NOT NULL DEFAULT '',
+    plain_text_content TEXT NOT NULL DEFAULT ''
+);
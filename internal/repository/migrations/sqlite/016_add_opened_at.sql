@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN opened_at TIMESTAMP NULL;
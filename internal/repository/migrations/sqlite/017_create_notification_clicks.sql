@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS notification_clicks (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    notification_id TEXT NOT NULL,
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    url             TEXT NOT NULL,
+    clicked_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_clicks_notification_id ON notification_clicks (tenant_id, notification_id, id);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS suppressions (
+    email      TEXT NOT NULL,
+    category   TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (email, category)
+);
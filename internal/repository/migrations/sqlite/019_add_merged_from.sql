@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN merged_from TEXT NOT NULL DEFAULT '';
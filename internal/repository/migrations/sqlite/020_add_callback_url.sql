@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN callback_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS callback_attempts (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    notification_id TEXT NOT NULL,
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    url             TEXT NOT NULL,
+    attempt         INTEGER NOT NULL,
+    status_code     INTEGER NOT NULL DEFAULT 0,
+    error           TEXT NOT NULL DEFAULT '',
+    attempted_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_callback_attempts_notification_id ON callback_attempts (tenant_id, notification_id, id);
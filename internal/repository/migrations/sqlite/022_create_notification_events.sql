@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS notification_events (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    notification_id TEXT NOT NULL,
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    event_type      TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    timestamp       TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_events_notification_id ON notification_events (tenant_id, notification_id, id);
+
+CREATE TRIGGER IF NOT EXISTS notification_events_no_update BEFORE UPDATE ON notification_events
+BEGIN
+    SELECT RAISE(ABORT, 'notification events are append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS notification_events_no_delete BEFORE DELETE ON notification_events
+BEGIN
+    SELECT RAISE(ABORT, 'notification events are append-only');
+END;
@@ -0,0 +1,14 @@
+ALTER TABLE templates ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS template_versions (
+    name               TEXT NOT NULL,
+    version            INTEGER NOT NULL,
+    title_template     TEXT NOT NULL DEFAULT '',
+    content_template   TEXT NOT NULL DEFAULT '',
+    html_content       TEXT NOT NULL DEFAULT '',
+    plain_text_content TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (name, version)
+);
+
+INSERT INTO template_versions (name, version, title_template, content_template, html_content, plain_text_content)
+SELECT name, version, title_template, content_template, html_content, plain_text_content FROM templates;
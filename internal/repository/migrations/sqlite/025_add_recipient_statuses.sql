@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN recipient_statuses TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE user_credentials ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
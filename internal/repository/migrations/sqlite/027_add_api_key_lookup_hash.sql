@@ -0,0 +1,3 @@
+ALTER TABLE api_keys ADD COLUMN lookup_hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_lookup_hash ON api_keys (lookup_hash);
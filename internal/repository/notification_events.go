@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"notification-service/internal/models"
+	"strings"
+	"time"
+)
+
+const notificationEventColumns = `notification_id, tenant_id, event_type, payload, timestamp`
+
+// EventPublisher receives notification events once they are stored.
+// Publish must not block.
+type EventPublisher interface {
+	Publish(event *models.NotificationEvent)
+}
+
+// PublishingRepository is implemented by repositories that publish each
+// event they append to a notification's history.
+type PublishingRepository interface {
+	WithEventPublisher(publisher EventPublisher)
+}
+
+// newNotificationEvent returns an event of notification id with payload
+// encoded as JSON.
+func newNotificationEvent(tenantID, id string, eventType models.NotificationEventType, payload any) *models.NotificationEvent {
+	data, _ := json.Marshal(payload)
+	return &models.NotificationEvent{
+		NotificationID: id,
+		TenantID:       tenantID,
+		Type:           eventType,
+		Payload:        data,
+		Timestamp:      time.Now().UTC(),
+	}
+}
+
+// savedEvent returns the event recording notification being saved. Its type
+// is EventCreated unless existed. Attachments are left out, as they are not
+// stored with the notification either.
+func savedEvent(notification *models.Notification, existed bool) *models.NotificationEvent {
+	copied := *notification
+	copied.Status = statusOrDefault(copied.Status)
+	copied.Attachments = nil
+	eventType := models.EventCreated
+	if existed {
+		eventType = models.EventUpdated
+	}
+	return newNotificationEvent(notification.TenantID, notification.ID, eventType, &copied)
+}
+
+// statusChangedEvent returns the event recording update. Times that update
+// leaves untouched are omitted from the payload.
+func statusChangedEvent(tenantID, id string, update StatusUpdate) *models.NotificationEvent {
+	payload := map[string]any{"Status": update.Status, "FailureReason": update.FailureReason}
+	if update.SentAt != nil {
+		payload["SentAt"] = update.SentAt
+	}
+	if update.DeliveredAt != nil {
+		payload["DeliveredAt"] = update.DeliveredAt
+	}
+	if update.ReadAt != nil {
+		payload["ReadAt"] = update.ReadAt
+	}
+	if len(update.RecipientStatuses) > 0 {
+		payload["RecipientStatuses"] = update.RecipientStatuses
+	}
+	if update.Metadata != nil {
+		payload["Metadata"] = update.Metadata
+	}
+	return newNotificationEvent(tenantID, id, models.EventStatusChanged, payload)
+}
+
+// writeWithEvent runs write in a transaction and appends the event it
+// returns to the history of notification id, publishing it to publisher,
+// if any, once committed. Nothing is appended, and ErrNotFound is returned,
+// when write changed no rows. Other errors are reported as failing to
+// action the notification.
+func writeWithEvent(ctx context.Context, db *sql.DB, placeholder func(n int) string, publisher EventPublisher, id, action string, event func() *models.NotificationEvent, write func(tx *sql.Tx) (sql.Result, error)) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to %s notification %s: %w", action, id, err)
+	}
+	defer tx.Rollback()
+
+	result, err := write(tx)
+	if err != nil {
+		return fmt.Errorf("failed to %s notification %s: %w", action, id, err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		return err
+	}
+
+	e := event()
+	placeholders := make([]string, 5)
+	for i := range placeholders {
+		placeholders[i] = placeholder(i + 1)
+	}
+	if err := tx.QueryRowContext(ctx, `INSERT INTO notification_events (`+notificationEventColumns+`) VALUES (`+strings.Join(placeholders, ", ")+`) RETURNING id`,
+		e.NotificationID, e.TenantID, string(e.Type), string(e.Payload), e.Timestamp,
+	).Scan(&e.Sequence); err != nil {
+		return fmt.Errorf("failed to append %s event of notification %s: %w", e.Type, id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to %s notification %s: %w", action, id, err)
+	}
+	if publisher != nil {
+		publisher.Publish(e)
+	}
+	return nil
+}
+
+// notificationExists reports whether a notification with id is stored in
+// any tenant.
+func notificationExists(ctx context.Context, tx *sql.Tx, query, id string) (bool, error) {
+	var count int
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// getEventHistory returns the events of notification id in tenantID, or
+// ErrNotFound when there are none.
+func getEventHistory(ctx context.Context, db *sql.DB, query, tenantID, id string) ([]*models.NotificationEvent, error) {
+	rows, err := db.QueryContext(ctx, query, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event history of notification %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	var events []*models.NotificationEvent
+	for rows.Next() {
+		var (
+			event     models.NotificationEvent
+			eventType string
+			payload   string
+		)
+		if err := rows.Scan(&event.Sequence, &event.NotificationID, &event.TenantID, &eventType, &payload, &event.Timestamp); err != nil {
+			return nil, err
+		}
+		event.Type = models.NotificationEventType(eventType)
+		event.Payload = json.RawMessage(payload)
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrNotFound
+	}
+	return events, nil
+}
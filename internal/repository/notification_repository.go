@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"notification-service/internal/models"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("notification not found")
+
+// ErrInvalidCursor is returned by List for cursors it did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// DefaultListLimit and MaxListLimit bound the page size of List.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// StatusUpdate describes a delivery status transition. A nil SentAt,
+// DeliveredAt or ReadAt leaves the stored time untouched, as do empty
+// RecipientStatuses the stored per-recipient outcomes and a nil Metadata the
+// stored metadata.
+type StatusUpdate struct {
+	Status            models.NotificationStatus
+	FailureReason     string
+	SentAt            *time.Time
+	DeliveredAt       *time.Time
+	ReadAt            *time.Time
+	RecipientStatuses []models.RecipientStatus
+	// Metadata replaces the stored metadata, recording what was learnt while
+	// sending such as the channel a fallback chain delivered to.
+	Metadata map[string]string
+}
+
+// ListOptions filters and pages List. Zero-valued filters other than
+// TenantID match every notification.
+type ListOptions struct {
+	// TenantID limits the results to one tenant's notifications.
+	TenantID string
+	// Limit is the page size, DefaultListLimit when zero and at most
+	// MaxListLimit.
+	Limit int
+	// Cursor is the NextCursor of the previous page; empty starts from the
+	// newest notification.
+	Cursor    string
+	Status    models.NotificationStatus
+	Channel   models.NotificationChannel
+	Category  string
+	Recurring bool
+	// Query matches notifications whose title or content contains it,
+	// ignoring case.
+	Query string
+	// From and To limit the results to notifications created at or after
+	// From and before To; a zero From or To leaves that end open.
+	From time.Time
+	To   time.Time
+}
+
+func (o ListOptions) limit() int {
+	switch {
+	case o.Limit <= 0:
+		return DefaultListLimit
+	case o.Limit > MaxListLimit:
+		return MaxListLimit
+	}
+	return o.Limit
+}
+
+// NotificationPage is one page of List results, newest first.
+type NotificationPage struct {
+	Notifications []*models.Notification
+	// NextCursor fetches the following page and is empty on the last one.
+	NextCursor string
+	// TotalCount counts the notifications matching the filters on every page.
+	TotalCount int
+}
+
+// StatsOptions limits Aggregate to one tenant's notifications created at or
+// after From and before To. A zero From or To leaves that end open.
+type StatsOptions struct {
+	TenantID string
+	From     time.Time
+	To       time.Time
+}
+
+// NotificationStats counts notifications by channel and status. Fan-outs are
+// counted once for each of their channels.
+type NotificationStats struct {
+	ByChannel map[models.NotificationChannel]int
+	ByStatus  map[models.NotificationStatus]int
+	// Last24h counts the notifications created in the 24 hours before the
+	// stats were taken.
+	Last24h int
+}
+
+func newNotificationStats() *NotificationStats {
+	return &NotificationStats{
+		ByChannel: make(map[models.NotificationChannel]int),
+		ByStatus:  make(map[models.NotificationStatus]int),
+	}
+}
+
+// encodeCursor returns an opaque cursor positioned after notification.
+// Cursors use CreatedAt and ID so inserts do not shift later pages.
+func encodeCursor(notification *models.Notification) string {
+	raw := notification.CreatedAt.Format(time.RFC3339Nano) + "|" + notification.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return at, id, nil
+}
+
+// NotificationRepository persists notifications and their delivery status.
+// Every query is scoped to one tenant: notifications of other tenants are
+// reported as ErrNotFound.
+type NotificationRepository interface {
+	// Save stores notification under its TenantID.
+	Save(ctx context.Context, notification *models.Notification) error
+	GetByID(ctx context.Context, tenantID, id string) (*models.Notification, error)
+	ListAll(ctx context.Context, tenantID string) ([]*models.Notification, error)
+	ListByStatus(ctx context.Context, tenantID string, status models.NotificationStatus) ([]*models.Notification, error)
+	// List returns one page of notifications matching opts, newest first.
+	List(ctx context.Context, opts ListOptions) (*NotificationPage, error)
+	UpdateStatus(ctx context.Context, tenantID, id string, update StatusUpdate) error
+	// UpdateScheduledAt moves a notification to scheduledAt.
+	UpdateScheduledAt(ctx context.Context, tenantID, id string, scheduledAt time.Time) error
+	// RecordOpen sets OpenedAt to openedAt unless the notification was
+	// already opened.
+	RecordOpen(ctx context.Context, tenantID, id string, openedAt time.Time) error
+	// RecordClick records a click through to url.
+	RecordClick(ctx context.Context, tenantID, id, url string, clickedAt time.Time) error
+	// ListClicks returns the clicks of a notification, oldest first.
+	ListClicks(ctx context.Context, tenantID, id string) ([]*models.ClickEvent, error)
+	Delete(ctx context.Context, tenantID, id string) error
+	// Aggregate counts the notifications matching opts.
+	Aggregate(ctx context.Context, opts StatsOptions) (*NotificationStats, error)
+	// GetEventHistory returns the append-only history of changes made to a
+	// notification through the methods above, oldest first. The history
+	// outlives Delete.
+	GetEventHistory(ctx context.Context, tenantID, id string) ([]*models.NotificationEvent, error)
+}
@@ -0,0 +1,627 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepostgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/lib/pq"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+type PostgresRepository struct {
+	db        *sql.DB
+	publisher EventPublisher
+}
+
+// NewPostgresRepository connects to dsn and runs pending migrations with
+// golang-migrate.
+func NewPostgresRepository(dsn string) (*PostgresRepository, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	if err := migratePostgres(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresRepository{db: db}, nil
+}
+
+func migratePostgres(db *sql.DB) error {
+	source, err := iofs.New(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return fmt.Errorf("failed to load postgres migrations: %w", err)
+	}
+	driver, err := migratepostgres.WithInstance(db, &migratepostgres.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create migration driver: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("failed to initialise migrations: %w", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply postgres migrations: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Close() error {
+	return r.db.Close()
+}
+
+// Ping checks that the database can be reached.
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *PostgresRepository) Save(ctx context.Context, notification *models.Notification) error {
+	recipients, metadata, sentMetadata, err := marshalNotificationFields(notification)
+	if err != nil {
+		return err
+	}
+
+	// A notification with the same ID in another tenant is left untouched
+	var existed bool
+	return r.write(ctx, notification.ID, "save", func() *models.NotificationEvent {
+		return savedEvent(notification, existed)
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		if existed, err = notificationExists(ctx, tx, `SELECT COUNT(*) FROM notifications WHERE id = $1`, notification.ID); err != nil {
+			return nil, err
+		}
+		return tx.ExecContext(ctx, `
+			INSERT INTO notifications (id, tenant_id, title, content, channel, channels, recipients, category, locale, channel_recipients, status, priority, failure_reason, scheduled_at, cron_expr, expires_at, created_at, sent_at, delivered_at, read_at, opened_at, merged_from, metadata, sent_metadata, callback_url, recipient_statuses)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
+			ON CONFLICT (id) DO UPDATE SET
+				status = EXCLUDED.status,
+				priority = EXCLUDED.priority,
+				failure_reason = EXCLUDED.failure_reason,
+				title = EXCLUDED.title,
+				content = EXCLUDED.content,
+				channel = EXCLUDED.channel,
+				channels = EXCLUDED.channels,
+				recipients = EXCLUDED.recipients,
+				category = EXCLUDED.category,
+				locale = EXCLUDED.locale,
+				channel_recipients = EXCLUDED.channel_recipients,
+				scheduled_at = EXCLUDED.scheduled_at,
+				cron_expr = EXCLUDED.cron_expr,
+				expires_at = EXCLUDED.expires_at,
+				sent_at = EXCLUDED.sent_at,
+				delivered_at = EXCLUDED.delivered_at,
+				read_at = EXCLUDED.read_at,
+				opened_at = EXCLUDED.opened_at,
+				merged_from = EXCLUDED.merged_from,
+				metadata = EXCLUDED.metadata,
+				sent_metadata = EXCLUDED.sent_metadata,
+				callback_url = EXCLUDED.callback_url,
+				recipient_statuses = EXCLUDED.recipient_statuses
+			WHERE notifications.tenant_id = EXCLUDED.tenant_id`,
+			notification.ID, notification.TenantID, notification.Title, notification.Content, string(notification.Channel),
+			encodeChannels(notification.Channels), recipients, notification.Category, notification.Locale, encodeChannelRecipients(notification.ChannelRecipients),
+			statusOrDefault(notification.Status), notification.Priority, notification.FailureReason,
+			notification.ScheduledAt, notification.CronExpr, notification.ExpiresAt, notification.CreatedAt, notification.SentAt,
+			notification.DeliveredAt, notification.ReadAt, notification.OpenedAt, encodeMergedFrom(notification.MergedFrom),
+			metadata, sentMetadata, notification.CallbackURL, encodeRecipientStatuses(notification.RecipientStatuses),
+		)
+	})
+}
+
+func (r *PostgresRepository) GetByID(ctx context.Context, tenantID, id string) (*models.Notification, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+
+	notification, err := scanNotification(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return notification, err
+}
+
+func (r *PostgresRepository) ListAll(ctx context.Context, tenantID string) ([]*models.Notification, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications WHERE tenant_id = $1 ORDER BY created_at DESC, id DESC`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return scanNotifications(rows)
+}
+
+func (r *PostgresRepository) ListByStatus(ctx context.Context, tenantID string, status models.NotificationStatus) ([]*models.Notification, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications WHERE tenant_id = $1 AND status = $2 ORDER BY created_at DESC, id DESC`, tenantID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s notifications: %w", status, err)
+	}
+	return scanNotifications(rows)
+}
+
+func (r *PostgresRepository) List(ctx context.Context, opts ListOptions) (*NotificationPage, error) {
+	return listNotifications(ctx, r.db, opts, func(n int) string { return "$" + strconv.Itoa(n) }, "ILIKE")
+}
+
+func (r *PostgresRepository) Aggregate(ctx context.Context, opts StatsOptions) (*NotificationStats, error) {
+	return aggregateNotifications(ctx, r.db, opts, func(n int) string { return "$" + strconv.Itoa(n) })
+}
+
+func (r *PostgresRepository) UpdateStatus(ctx context.Context, tenantID, id string, update StatusUpdate) error {
+	return r.write(ctx, id, "update status of", func() *models.NotificationEvent {
+		return statusChangedEvent(tenantID, id, update)
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx,
+			`UPDATE notifications SET status = $1, failure_reason = $2, sent_at = COALESCE($3, sent_at),
+				delivered_at = COALESCE($4, delivered_at), read_at = COALESCE($5, read_at),
+				recipient_statuses = COALESCE(NULLIF($6, ''), recipient_statuses), metadata = COALESCE($7, metadata)
+				WHERE tenant_id = $8 AND id = $9`,
+			update.Status, update.FailureReason, update.SentAt, update.DeliveredAt, update.ReadAt,
+			encodeRecipientStatuses(update.RecipientStatuses), encodeMetadata(update.Metadata), tenantID, id)
+	})
+}
+
+func (r *PostgresRepository) UpdateScheduledAt(ctx context.Context, tenantID, id string, scheduledAt time.Time) error {
+	return r.write(ctx, id, "reschedule", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventRescheduled, map[string]any{"ScheduledAt": scheduledAt})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `UPDATE notifications SET scheduled_at = $1 WHERE tenant_id = $2 AND id = $3`, scheduledAt, tenantID, id)
+	})
+}
+
+func (r *PostgresRepository) RecordOpen(ctx context.Context, tenantID, id string, openedAt time.Time) error {
+	return r.write(ctx, id, "record open of", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventOpened, map[string]any{"OpenedAt": openedAt})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `UPDATE notifications SET opened_at = COALESCE(opened_at, $1) WHERE tenant_id = $2 AND id = $3`, openedAt, tenantID, id)
+	})
+}
+
+// RecordClick inserts the click only if the notification exists, so a click
+// on an unknown notification affects no rows and reports ErrNotFound.
+func (r *PostgresRepository) RecordClick(ctx context.Context, tenantID, id, url string, clickedAt time.Time) error {
+	return r.write(ctx, id, "record click of", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventClicked, map[string]any{"URL": url, "ClickedAt": clickedAt})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `
+			INSERT INTO notification_clicks (`+clickEventColumns+`)
+			SELECT id, tenant_id, $1, $2 FROM notifications WHERE tenant_id = $3 AND id = $4`,
+			url, clickedAt, tenantID, id,
+		)
+	})
+}
+
+func (r *PostgresRepository) ListClicks(ctx context.Context, tenantID, id string) ([]*models.ClickEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+clickEventColumns+` FROM notification_clicks WHERE tenant_id = $1 AND notification_id = $2 ORDER BY id`, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clicks of notification %s: %w", id, err)
+	}
+	return scanClickEvents(rows)
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, tenantID, id string) error {
+	return r.write(ctx, id, "delete", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventDeleted, struct{}{})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `DELETE FROM notifications WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	})
+}
+
+// GetEventHistory returns the events of notification id, oldest first.
+func (r *PostgresRepository) GetEventHistory(ctx context.Context, tenantID, id string) ([]*models.NotificationEvent, error) {
+	return getEventHistory(ctx, r.db, `SELECT id, `+notificationEventColumns+` FROM notification_events WHERE tenant_id = $1 AND notification_id = $2 ORDER BY id`, tenantID, id)
+}
+
+// WithEventPublisher publishes each event appended to a notification's
+// history to publisher.
+func (r *PostgresRepository) WithEventPublisher(publisher EventPublisher) {
+	r.publisher = publisher
+}
+
+// write runs write and appends the event it makes in one transaction.
+func (r *PostgresRepository) write(ctx context.Context, id, action string, event func() *models.NotificationEvent, write func(tx *sql.Tx) (sql.Result, error)) error {
+	return writeWithEvent(ctx, r.db, func(n int) string { return "$" + strconv.Itoa(n) }, r.publisher, id, action, event, write)
+}
+
+func (r *PostgresRepository) SaveUser(ctx context.Context, user *models.User) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO users (`+userColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			email = EXCLUDED.email,
+			slack_id = EXCLUDED.slack_id,
+			phone = EXCLUDED.phone,
+			preferred_channel = EXCLUDED.preferred_channel,
+			metadata = EXCLUDED.metadata`,
+		user.ID, user.Name, user.Email, user.SlackID, user.Phone, string(user.PreferredChannel), marshalUserMetadata(user),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save user %s: %w", user.ID, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetUser(ctx context.Context, id string) (*models.User, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *PostgresRepository) ListUsers(ctx context.Context) ([]*models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return scanUsers(rows)
+}
+
+func (r *PostgresRepository) DeleteUser(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_credentials WHERE user_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete credentials of user %s: %w", id, err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM category_subscriptions WHERE user_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete subscriptions of user %s: %w", id, err)
+	}
+	result, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", id, err)
+	}
+	return checkUserRowsAffected(result)
+}
+
+func (r *PostgresRepository) SaveSubscription(ctx context.Context, userID string, subscription models.CategorySubscription) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO category_subscriptions (user_id, category, subscribed)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, category) DO UPDATE SET subscribed = EXCLUDED.subscribed`,
+		userID, subscription.Category, subscription.Subscribed,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save subscription of user %s to %s: %w", userID, subscription.Category, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListSubscriptions(ctx context.Context, userID string) ([]models.CategorySubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT category, subscribed FROM category_subscriptions WHERE user_id = $1 ORDER BY category`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions of user %s: %w", userID, err)
+	}
+	return scanSubscriptions(rows)
+}
+
+func (r *PostgresRepository) SaveAPIKey(ctx context.Context, key *models.APIKey) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (`+apiKeyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		key.ID, key.Name, key.KeyHash, key.LookupHash, key.TenantID, key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save API key %s: %w", key.ID, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return scanAPIKeys(rows)
+}
+
+func (r *PostgresRepository) GetAPIKeyByLookupHash(ctx context.Context, lookupHash string) (*models.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE lookup_hash = $1 ORDER BY created_at, id LIMIT 1`, lookupHash)
+
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *PostgresRepository) SetAPIKeyLookupHash(ctx context.Context, id, lookupHash string) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE api_keys SET lookup_hash = $1 WHERE id = $2`, lookupHash, id); err != nil {
+		return fmt.Errorf("failed to set lookup hash of API key %s: %w", id, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) AppendAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO audit_events (`+auditEventColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		string(event.EventType), event.NotificationID, event.TenantID, event.ActorID, event.Timestamp, marshalAuditMetadata(event),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append %s audit event of notification %s: %w", event.EventType, event.NotificationID, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListAuditEvents(ctx context.Context, notificationID string) ([]*models.AuditEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+auditEventColumns+` FROM audit_events WHERE notification_id = $1 ORDER BY id`, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events of notification %s: %w", notificationID, err)
+	}
+	return scanAuditEvents(rows)
+}
+
+func (r *PostgresRepository) SaveCredentials(ctx context.Context, userID string, credentials models.Credentials) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_credentials (user_id, password_hash, roles, tenant_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			password_hash = EXCLUDED.password_hash,
+			roles = EXCLUDED.roles,
+			tenant_id = EXCLUDED.tenant_id`,
+		userID, credentials.PasswordHash, marshalRoles(credentials.Roles), credentials.TenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save credentials of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetCredentials(ctx context.Context, userID string) (*models.Credentials, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT password_hash, roles, tenant_id FROM user_credentials WHERE user_id = $1`, userID)
+	credentials, err := scanCredentials(row)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get credentials of user %s: %w", userID, err)
+	}
+	return credentials, err
+}
+
+func (r *PostgresRepository) SaveTenant(ctx context.Context, tenant *models.Tenant) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO tenants (`+tenantColumns+`)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			config = EXCLUDED.config`,
+		tenant.ID, tenant.Name, marshalTenantConfig(tenant.Config), tenant.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save tenant %s: %w", tenant.ID, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetTenant(ctx context.Context, id string) (*models.Tenant, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id)
+
+	tenant, err := scanTenant(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+func (r *PostgresRepository) ListTenants(ctx context.Context) ([]*models.Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	return scanTenants(rows)
+}
+
+func (r *PostgresRepository) SaveTemplate(ctx context.Context, template *models.NotificationTemplate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) + 1 FROM template_versions WHERE name = $1`, template.Name).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	args := []any{template.Name, version, template.TitleTemplate, template.ContentTemplate, template.HTMLContent, template.PlainTextContent}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO template_versions (`+templateColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`, args...); err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO templates (`+templateColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO UPDATE SET
+			version = EXCLUDED.version,
+			title_template = EXCLUDED.title_template,
+			content_template = EXCLUDED.content_template,
+			html_content = EXCLUDED.html_content,
+			plain_text_content = EXCLUDED.plain_text_content`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	template.Version = version
+	return nil
+}
+
+func (r *PostgresRepository) GetTemplate(ctx context.Context, name string) (*models.NotificationTemplate, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM templates WHERE name = $1`, name)
+
+	template, err := scanTemplate(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (r *PostgresRepository) GetTemplateVersion(ctx context.Context, name string, version int) (*models.NotificationTemplate, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM template_versions WHERE name = $1 AND version = $2`, name, version)
+
+	template, err := scanTemplate(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (r *PostgresRepository) ListTemplateVersions(ctx context.Context, name string) ([]*models.NotificationTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM template_versions WHERE name = $1 ORDER BY version`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of template %s: %w", name, err)
+	}
+	versions, err := scanTemplates(rows)
+	if err == nil && len(versions) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+	return versions, err
+}
+
+func (r *PostgresRepository) ListTemplates(ctx context.Context) ([]*models.NotificationTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return scanTemplates(rows)
+}
+
+func (r *PostgresRepository) DeleteTemplate(ctx context.Context, name string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM template_versions WHERE name = $1`, name); err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM templates WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrTemplateNotFound
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) AddSuppression(ctx context.Context, email, category string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO suppressions (email, category, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (email, category) DO NOTHING`,
+		email, category, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to suppress %s: %w", email, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) IsSuppressed(ctx context.Context, email, category string) (bool, error) {
+	var suppressed bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM suppressions WHERE email = $1 AND category = $2)`, email, category).Scan(&suppressed)
+	if err != nil {
+		return false, fmt.Errorf("failed to check suppression of %s: %w", email, err)
+	}
+	return suppressed, nil
+}
+
+func (r *PostgresRepository) RecordCallbackAttempt(ctx context.Context, attempt *models.CallbackAttempt) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO callback_attempts (`+callbackAttemptColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		attempt.NotificationID, attempt.TenantID, attempt.URL, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.AttemptedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record callback attempt of notification %s: %w", attempt.NotificationID, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListCallbackAttempts(ctx context.Context, tenantID, notificationID string) ([]*models.CallbackAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+callbackAttemptColumns+` FROM callback_attempts WHERE tenant_id = $1 AND notification_id = $2 ORDER BY id`, tenantID, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list callback attempts of notification %s: %w", notificationID, err)
+	}
+	return scanCallbackAttempts(rows)
+}
+
+// EnqueueEmail marks the notification with update before adding the email,
+// so an unknown notification affects no rows and adds nothing.
+func (r *PostgresRepository) EnqueueEmail(ctx context.Context, email *models.OutboxEmail, update StatusUpdate) error {
+	recipients, err := json.Marshal(email.Recipients)
+	if err != nil {
+		return fmt.Errorf("failed to encode recipients: %w", err)
+	}
+	return r.write(ctx, email.NotificationID, "queue email of", func() *models.NotificationEvent {
+		return statusChangedEvent(email.TenantID, email.NotificationID, update)
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		result, err := tx.ExecContext(ctx,
+			`UPDATE notifications SET status = $1, failure_reason = $2, sent_at = COALESCE($3, sent_at),
+				delivered_at = COALESCE($4, delivered_at), read_at = COALESCE($5, read_at) WHERE tenant_id = $6 AND id = $7`,
+			update.Status, update.FailureReason, update.SentAt, update.DeliveredAt, update.ReadAt, email.TenantID, email.NotificationID)
+		if err != nil {
+			return nil, err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return result, err
+		}
+		return result, tx.QueryRowContext(ctx, `
+			INSERT INTO email_outbox (notification_id, tenant_id, recipients, message, retry_count, last_error, created_at)
+			VALUES ($1, $2, $3, $4, 0, '', $5) RETURNING id`,
+			email.NotificationID, email.TenantID, string(recipients), email.Message, email.CreatedAt,
+		).Scan(&email.ID)
+	})
+}
+
+func (r *PostgresRepository) ListOutboxEmails(ctx context.Context, maxRetries, limit int) ([]*models.OutboxEmail, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+outboxEmailColumns+` FROM email_outbox WHERE retry_count < $1 ORDER BY id LIMIT $2`, maxRetries, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox emails: %w", err)
+	}
+	return scanOutboxEmails(rows)
+}
+
+func (r *PostgresRepository) DeleteOutboxEmail(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM email_outbox WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete outbox email %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) RecordOutboxEmailFailure(ctx context.Context, id int64, reason string) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE email_outbox SET retry_count = retry_count + 1, last_error = $1 WHERE id = $2`, reason, id); err != nil {
+		return fmt.Errorf("failed to record failure of outbox email %d: %w", id, err)
+	}
+	return nil
+}
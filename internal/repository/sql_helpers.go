@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"notification-service/internal/models"
+	"strings"
+	"time"
+)
+
+// notificationColumns is the column list scanNotification expects.
+const notificationColumns = `id, tenant_id, title, content, channel, channels, recipients, category, locale, channel_recipients, status, priority, failure_reason,
+	scheduled_at, cron_expr, expires_at, created_at, sent_at, delivered_at, read_at, opened_at, merged_from, metadata, sent_metadata, callback_url, recipient_statuses`
+
+// clickEventColumns is the column list scanClickEvents expects.
+const clickEventColumns = `notification_id, tenant_id, url, clicked_at`
+
+// callbackAttemptColumns is the column list scanCallbackAttempts expects.
+const callbackAttemptColumns = `notification_id, tenant_id, url, attempt, status_code, error, attempted_at`
+
+// outboxEmailColumns is the column list scanOutboxEmails expects.
+const outboxEmailColumns = `id, notification_id, tenant_id, recipients, message, retry_count, last_error, created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	var (
+		notification      models.Notification
+		channel           string
+		channels          string
+		recipients        string
+		channelRecipients string
+		scheduledAt       sql.NullTime
+		expiresAt         sql.NullTime
+		sentAt            sql.NullTime
+		deliveredAt       sql.NullTime
+		readAt            sql.NullTime
+		openedAt          sql.NullTime
+		mergedFrom        string
+		metadata          sql.NullString
+		sentMetadata      sql.NullString
+		recipientStatuses string
+	)
+
+	err := row.Scan(&notification.ID, &notification.TenantID, &notification.Title, &notification.Content, &channel, &channels, &recipients, &notification.Category, &notification.Locale, &channelRecipients,
+		&notification.Status, &notification.Priority, &notification.FailureReason,
+		&scheduledAt, &notification.CronExpr, &expiresAt, &notification.CreatedAt, &sentAt, &deliveredAt, &readAt, &openedAt, &mergedFrom, &metadata, &sentMetadata, &notification.CallbackURL, &recipientStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	notification.Channel = models.NotificationChannel(channel)
+	if err := json.Unmarshal([]byte(recipients), &notification.Recipients); err != nil {
+		return nil, fmt.Errorf("failed to decode recipients of notification %s: %w", notification.ID, err)
+	}
+	if channels != "" {
+		if err := json.Unmarshal([]byte(channels), &notification.Channels); err != nil {
+			return nil, fmt.Errorf("failed to decode channels of notification %s: %w", notification.ID, err)
+		}
+	}
+	if channelRecipients != "" {
+		if err := json.Unmarshal([]byte(channelRecipients), &notification.ChannelRecipients); err != nil {
+			return nil, fmt.Errorf("failed to decode channel recipients of notification %s: %w", notification.ID, err)
+		}
+	}
+	if scheduledAt.Valid {
+		notification.ScheduledAt = &scheduledAt.Time
+	}
+	if expiresAt.Valid {
+		notification.ExpiresAt = &expiresAt.Time
+	}
+	if sentAt.Valid {
+		notification.SentAt = &sentAt.Time
+	}
+	if deliveredAt.Valid {
+		notification.DeliveredAt = &deliveredAt.Time
+	}
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+	if openedAt.Valid {
+		notification.OpenedAt = &openedAt.Time
+	}
+	if mergedFrom != "" {
+		if err := json.Unmarshal([]byte(mergedFrom), &notification.MergedFrom); err != nil {
+			return nil, fmt.Errorf("failed to decode merged IDs of notification %s: %w", notification.ID, err)
+		}
+	}
+	if metadata.Valid && metadata.String != "" {
+		json.Unmarshal([]byte(metadata.String), &notification.Metadata)
+	}
+	if sentMetadata.Valid && sentMetadata.String != "" {
+		json.Unmarshal([]byte(sentMetadata.String), &notification.SentMetadata)
+	}
+	if recipientStatuses != "" {
+		if err := json.Unmarshal([]byte(recipientStatuses), &notification.RecipientStatuses); err != nil {
+			return nil, fmt.Errorf("failed to decode recipient statuses of notification %s: %w", notification.ID, err)
+		}
+	}
+	return &notification, nil
+}
+
+func scanNotifications(rows *sql.Rows) ([]*models.Notification, error) {
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+func scanClickEvents(rows *sql.Rows) ([]*models.ClickEvent, error) {
+	defer rows.Close()
+
+	var clicks []*models.ClickEvent
+	for rows.Next() {
+		var click models.ClickEvent
+		if err := rows.Scan(&click.NotificationID, &click.TenantID, &click.URL, &click.ClickedAt); err != nil {
+			return nil, err
+		}
+		clicks = append(clicks, &click)
+	}
+	return clicks, rows.Err()
+}
+
+func scanCallbackAttempts(rows *sql.Rows) ([]*models.CallbackAttempt, error) {
+	defer rows.Close()
+
+	var attempts []*models.CallbackAttempt
+	for rows.Next() {
+		var attempt models.CallbackAttempt
+		if err := rows.Scan(&attempt.NotificationID, &attempt.TenantID, &attempt.URL, &attempt.Attempt,
+			&attempt.StatusCode, &attempt.Error, &attempt.AttemptedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+	return attempts, rows.Err()
+}
+
+func scanOutboxEmails(rows *sql.Rows) ([]*models.OutboxEmail, error) {
+	defer rows.Close()
+
+	var emails []*models.OutboxEmail
+	for rows.Next() {
+		var (
+			email      models.OutboxEmail
+			recipients string
+		)
+		if err := rows.Scan(&email.ID, &email.NotificationID, &email.TenantID, &recipients, &email.Message,
+			&email.RetryCount, &email.LastError, &email.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(recipients), &email.Recipients); err != nil {
+			return nil, fmt.Errorf("failed to decode recipients of outbox email %d: %w", email.ID, err)
+		}
+		emails = append(emails, &email)
+	}
+	return emails, rows.Err()
+}
+
+func marshalNotificationFields(notification *models.Notification) (recipients string, metadata, sentMetadata sql.NullString, err error) {
+	data, err := json.Marshal(notification.Recipients)
+	if err != nil {
+		return "", metadata, sentMetadata, fmt.Errorf("failed to encode recipients: %w", err)
+	}
+	recipients = string(data)
+
+	metadata = encodeMetadata(notification.Metadata)
+	if notification.SentMetadata != nil {
+		data, _ := json.Marshal(notification.SentMetadata)
+		sentMetadata = sql.NullString{String: string(data), Valid: true}
+	}
+	return recipients, metadata, sentMetadata, nil
+}
+
+// encodeMetadata stores metadata as a JSON object, or NULL when it is nil.
+func encodeMetadata(metadata map[string]string) sql.NullString {
+	if metadata == nil {
+		return sql.NullString{}
+	}
+	data, _ := json.Marshal(metadata)
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// encodeRecipientStatuses stores per-recipient outcomes as a JSON array, or
+// "" when none were recorded.
+func encodeRecipientStatuses(statuses []models.RecipientStatus) string {
+	if len(statuses) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(statuses)
+	return string(data)
+}
+
+// encodeChannels stores fan-out channels as a JSON array, or "" when the
+// notification targets a single channel.
+func encodeChannels(channels []models.NotificationChannel) string {
+	if len(channels) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(channels)
+	return string(data)
+}
+
+// encodeMergedFrom stores the IDs a batched notification was merged from as
+// a JSON array, or "" when it was not merged.
+func encodeMergedFrom(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+// encodeChannelRecipients stores per-channel recipients as a JSON object, or
+// "" when every channel shares Recipients.
+func encodeChannelRecipients(recipients map[models.NotificationChannel][]string) string {
+	if len(recipients) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(recipients)
+	return string(data)
+}
+
+// listNotifications implements List for both SQL backends. placeholder
+// returns the bind parameter for the nth argument, counting from 1, and like
+// is the backend's case-insensitive LIKE operator.
+func listNotifications(ctx context.Context, db *sql.DB, opts ListOptions, placeholder func(n int) string, like string) (*NotificationPage, error) {
+	var (
+		filters []string
+		args    []interface{}
+	)
+	where := func(format string, values ...interface{}) {
+		params := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			params[i] = placeholder(len(args))
+		}
+		filters = append(filters, fmt.Sprintf(format, params...))
+	}
+
+	where("tenant_id = %s", opts.TenantID)
+	if opts.Status != "" {
+		where("status = %s", opts.Status)
+	}
+	if opts.Channel != "" {
+		where("(channel = %s OR channels LIKE %s)", string(opts.Channel), `%"`+string(opts.Channel)+`"%`)
+	}
+	if opts.Category != "" {
+		where("category = %s", opts.Category)
+	}
+	if opts.Recurring {
+		filters = append(filters, "cron_expr <> ''")
+	}
+	if opts.Query != "" {
+		pattern := likePattern(opts.Query)
+		where(`(title `+like+` %s ESCAPE '\' OR content `+like+` %s ESCAPE '\')`, pattern, pattern)
+	}
+	if !opts.From.IsZero() {
+		where("created_at >= %s", opts.From)
+	}
+	if !opts.To.IsZero() {
+		where("created_at < %s", opts.To)
+	}
+
+	clause := " WHERE " + strings.Join(filters, " AND ")
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications`+clause, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	if opts.Cursor != "" {
+		createdAt, id, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where("(created_at < %s OR (created_at = %s AND id < %s))", createdAt, createdAt, id)
+		clause = " WHERE " + strings.Join(filters, " AND ")
+	}
+
+	// One extra row tells whether there is a next page
+	limit := opts.limit()
+	args = append(args, limit+1)
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications`+clause+`
+		ORDER BY created_at DESC, id DESC LIMIT `+placeholder(len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	notifications, err := scanNotifications(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &NotificationPage{Notifications: notifications, TotalCount: total}
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		page.NextCursor = encodeCursor(notifications[limit-1])
+	}
+	return page, nil
+}
+
+// aggregateNotifications implements Aggregate for both SQL backends.
+// placeholder is as for listNotifications.
+func aggregateNotifications(ctx context.Context, db *sql.DB, opts StatsOptions, placeholder func(n int) string) (*NotificationStats, error) {
+	var (
+		filters []string
+		args    []interface{}
+	)
+	where := func(format string, value interface{}) {
+		args = append(args, value)
+		filters = append(filters, fmt.Sprintf(format, placeholder(len(args))))
+	}
+
+	where("tenant_id = %s", opts.TenantID)
+	if !opts.From.IsZero() {
+		where("created_at >= %s", opts.From)
+	}
+	if !opts.To.IsZero() {
+		where("created_at < %s", opts.To)
+	}
+	clause := " WHERE " + strings.Join(filters, " AND ")
+
+	stats := newNotificationStats()
+	rows, err := db.QueryContext(ctx, `SELECT status, COUNT(*) FROM notifications`+clause+` GROUP BY status`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications by status: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats.ByStatus[models.NotificationStatus(status)] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Fan-outs store their channels as a JSON array instead of channel
+	rows, err = db.QueryContext(ctx, `SELECT channel, channels, COUNT(*) FROM notifications`+clause+` GROUP BY channel, channels`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications by channel: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			channel  string
+			channels string
+			count    int
+		)
+		if err := rows.Scan(&channel, &channels, &count); err != nil {
+			return nil, err
+		}
+		if channel != "" {
+			stats.ByChannel[models.NotificationChannel(channel)] += count
+		}
+		if channels != "" {
+			var fanOut []models.NotificationChannel
+			if err := json.Unmarshal([]byte(channels), &fanOut); err != nil {
+				return nil, fmt.Errorf("failed to decode fan-out channels %s: %w", channels, err)
+			}
+			for _, c := range fanOut {
+				stats.ByChannel[c] += count
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	where("created_at >= %s", time.Now().Add(-24*time.Hour).UTC())
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE `+strings.Join(filters, " AND "), args...).Scan(&stats.Last24h); err != nil {
+		return nil, fmt.Errorf("failed to count recent notifications: %w", err)
+	}
+	return stats, nil
+}
+
+// likePattern returns a LIKE pattern matching values that contain term, with
+// the wildcards in term escaped by a backslash.
+func likePattern(term string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+	return "%" + escaped + "%"
+}
+
+func checkRowsAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func statusOrDefault(status models.NotificationStatus) models.NotificationStatus {
+	if status == "" {
+		return models.StatusPending
+	}
+	return status
+}
@@ -0,0 +1,604 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+type SQLiteRepository struct {
+	db        *sql.DB
+	publisher EventPublisher
+}
+
+// NewSQLiteRepository opens the database at path and applies any pending
+// migrations. Use ":memory:" for a throwaway database.
+func NewSQLiteRepository(path string) (*SQLiteRepository, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// SQLite allows a single writer; one connection also keeps ":memory:"
+	// databases shared across queries.
+	db.SetMaxOpenConns(1)
+
+	if err := applyMigrations(context.Background(), db, sqliteMigrations, "migrations/sqlite"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// Ping checks that the database can be reached.
+func (r *SQLiteRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *SQLiteRepository) Save(ctx context.Context, notification *models.Notification) error {
+	recipients, metadata, sentMetadata, err := marshalNotificationFields(notification)
+	if err != nil {
+		return err
+	}
+
+	// A notification with the same ID in another tenant is left untouched
+	var existed bool
+	return r.write(ctx, notification.ID, "save", func() *models.NotificationEvent {
+		return savedEvent(notification, existed)
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		if existed, err = notificationExists(ctx, tx, `SELECT COUNT(*) FROM notifications WHERE id = ?`, notification.ID); err != nil {
+			return nil, err
+		}
+		return tx.ExecContext(ctx, `
+			INSERT INTO notifications (id, tenant_id, title, content, channel, channels, recipients, category, locale, channel_recipients, status, priority, failure_reason, scheduled_at, cron_expr, expires_at, created_at, sent_at, delivered_at, read_at, opened_at, merged_from, metadata, sent_metadata, callback_url, recipient_statuses)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				status = excluded.status,
+				priority = excluded.priority,
+				failure_reason = excluded.failure_reason,
+				title = excluded.title,
+				content = excluded.content,
+				channel = excluded.channel,
+				channels = excluded.channels,
+				recipients = excluded.recipients,
+				category = excluded.category,
+				locale = excluded.locale,
+				channel_recipients = excluded.channel_recipients,
+				scheduled_at = excluded.scheduled_at,
+				cron_expr = excluded.cron_expr,
+				expires_at = excluded.expires_at,
+				sent_at = excluded.sent_at,
+				delivered_at = excluded.delivered_at,
+				read_at = excluded.read_at,
+				opened_at = excluded.opened_at,
+				merged_from = excluded.merged_from,
+				metadata = excluded.metadata,
+				sent_metadata = excluded.sent_metadata,
+				callback_url = excluded.callback_url,
+				recipient_statuses = excluded.recipient_statuses
+			WHERE notifications.tenant_id = excluded.tenant_id`,
+			notification.ID, notification.TenantID, notification.Title, notification.Content, string(notification.Channel),
+			encodeChannels(notification.Channels), recipients, notification.Category, notification.Locale, encodeChannelRecipients(notification.ChannelRecipients),
+			statusOrDefault(notification.Status), notification.Priority, notification.FailureReason,
+			notification.ScheduledAt, notification.CronExpr, notification.ExpiresAt, notification.CreatedAt, notification.SentAt,
+			notification.DeliveredAt, notification.ReadAt, notification.OpenedAt, encodeMergedFrom(notification.MergedFrom),
+			metadata, sentMetadata, notification.CallbackURL, encodeRecipientStatuses(notification.RecipientStatuses),
+		)
+	})
+}
+
+func (r *SQLiteRepository) GetByID(ctx context.Context, tenantID, id string) (*models.Notification, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications WHERE tenant_id = ? AND id = ?`, tenantID, id)
+
+	notification, err := scanNotification(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return notification, err
+}
+
+func (r *SQLiteRepository) ListAll(ctx context.Context, tenantID string) ([]*models.Notification, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications WHERE tenant_id = ? ORDER BY created_at DESC, id DESC`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return scanNotifications(rows)
+}
+
+func (r *SQLiteRepository) ListByStatus(ctx context.Context, tenantID string, status models.NotificationStatus) ([]*models.Notification, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications WHERE tenant_id = ? AND status = ? ORDER BY created_at DESC, id DESC`, tenantID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s notifications: %w", status, err)
+	}
+	return scanNotifications(rows)
+}
+
+func (r *SQLiteRepository) List(ctx context.Context, opts ListOptions) (*NotificationPage, error) {
+	// LIKE ignores case for ASCII letters in SQLite
+	return listNotifications(ctx, r.db, opts, func(int) string { return "?" }, "LIKE")
+}
+
+func (r *SQLiteRepository) Aggregate(ctx context.Context, opts StatsOptions) (*NotificationStats, error) {
+	return aggregateNotifications(ctx, r.db, opts, func(int) string { return "?" })
+}
+
+func (r *SQLiteRepository) UpdateStatus(ctx context.Context, tenantID, id string, update StatusUpdate) error {
+	return r.write(ctx, id, "update status of", func() *models.NotificationEvent {
+		return statusChangedEvent(tenantID, id, update)
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx,
+			`UPDATE notifications SET status = ?, failure_reason = ?, sent_at = COALESCE(?, sent_at),
+				delivered_at = COALESCE(?, delivered_at), read_at = COALESCE(?, read_at),
+				recipient_statuses = COALESCE(NULLIF(?, ''), recipient_statuses), metadata = COALESCE(?, metadata)
+				WHERE tenant_id = ? AND id = ?`,
+			update.Status, update.FailureReason, update.SentAt, update.DeliveredAt, update.ReadAt,
+			encodeRecipientStatuses(update.RecipientStatuses), encodeMetadata(update.Metadata), tenantID, id)
+	})
+}
+
+func (r *SQLiteRepository) UpdateScheduledAt(ctx context.Context, tenantID, id string, scheduledAt time.Time) error {
+	return r.write(ctx, id, "reschedule", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventRescheduled, map[string]any{"ScheduledAt": scheduledAt})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `UPDATE notifications SET scheduled_at = ? WHERE tenant_id = ? AND id = ?`, scheduledAt, tenantID, id)
+	})
+}
+
+func (r *SQLiteRepository) RecordOpen(ctx context.Context, tenantID, id string, openedAt time.Time) error {
+	return r.write(ctx, id, "record open of", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventOpened, map[string]any{"OpenedAt": openedAt})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `UPDATE notifications SET opened_at = COALESCE(opened_at, ?) WHERE tenant_id = ? AND id = ?`, openedAt, tenantID, id)
+	})
+}
+
+// RecordClick inserts the click only if the notification exists, so a click
+// on an unknown notification affects no rows and reports ErrNotFound.
+func (r *SQLiteRepository) RecordClick(ctx context.Context, tenantID, id, url string, clickedAt time.Time) error {
+	return r.write(ctx, id, "record click of", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventClicked, map[string]any{"URL": url, "ClickedAt": clickedAt})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `
+			INSERT INTO notification_clicks (`+clickEventColumns+`)
+			SELECT id, tenant_id, ?, ? FROM notifications WHERE tenant_id = ? AND id = ?`,
+			url, clickedAt, tenantID, id,
+		)
+	})
+}
+
+func (r *SQLiteRepository) ListClicks(ctx context.Context, tenantID, id string) ([]*models.ClickEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+clickEventColumns+` FROM notification_clicks WHERE tenant_id = ? AND notification_id = ? ORDER BY id`, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clicks of notification %s: %w", id, err)
+	}
+	return scanClickEvents(rows)
+}
+
+func (r *SQLiteRepository) Delete(ctx context.Context, tenantID, id string) error {
+	return r.write(ctx, id, "delete", func() *models.NotificationEvent {
+		return newNotificationEvent(tenantID, id, models.EventDeleted, struct{}{})
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, `DELETE FROM notifications WHERE tenant_id = ? AND id = ?`, tenantID, id)
+	})
+}
+
+// GetEventHistory returns the events of notification id, oldest first.
+func (r *SQLiteRepository) GetEventHistory(ctx context.Context, tenantID, id string) ([]*models.NotificationEvent, error) {
+	return getEventHistory(ctx, r.db, `SELECT id, `+notificationEventColumns+` FROM notification_events WHERE tenant_id = ? AND notification_id = ? ORDER BY id`, tenantID, id)
+}
+
+// WithEventPublisher publishes each event appended to a notification's
+// history to publisher.
+func (r *SQLiteRepository) WithEventPublisher(publisher EventPublisher) {
+	r.publisher = publisher
+}
+
+// write runs write and appends the event it makes in one transaction.
+func (r *SQLiteRepository) write(ctx context.Context, id, action string, event func() *models.NotificationEvent, write func(tx *sql.Tx) (sql.Result, error)) error {
+	return writeWithEvent(ctx, r.db, func(int) string { return "?" }, r.publisher, id, action, event, write)
+}
+
+func (r *SQLiteRepository) SaveUser(ctx context.Context, user *models.User) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO users (`+userColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			email = excluded.email,
+			slack_id = excluded.slack_id,
+			phone = excluded.phone,
+			preferred_channel = excluded.preferred_channel,
+			metadata = excluded.metadata`,
+		user.ID, user.Name, user.Email, user.SlackID, user.Phone, string(user.PreferredChannel), marshalUserMetadata(user),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save user %s: %w", user.ID, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) GetUser(ctx context.Context, id string) (*models.User, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *SQLiteRepository) ListUsers(ctx context.Context) ([]*models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return scanUsers(rows)
+}
+
+func (r *SQLiteRepository) DeleteUser(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_credentials WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete credentials of user %s: %w", id, err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM category_subscriptions WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete subscriptions of user %s: %w", id, err)
+	}
+	result, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", id, err)
+	}
+	return checkUserRowsAffected(result)
+}
+
+func (r *SQLiteRepository) SaveSubscription(ctx context.Context, userID string, subscription models.CategorySubscription) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO category_subscriptions (user_id, category, subscribed)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, category) DO UPDATE SET subscribed = excluded.subscribed`,
+		userID, subscription.Category, subscription.Subscribed,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save subscription of user %s to %s: %w", userID, subscription.Category, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) ListSubscriptions(ctx context.Context, userID string) ([]models.CategorySubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT category, subscribed FROM category_subscriptions WHERE user_id = ? ORDER BY category`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions of user %s: %w", userID, err)
+	}
+	return scanSubscriptions(rows)
+}
+
+func (r *SQLiteRepository) SaveAPIKey(ctx context.Context, key *models.APIKey) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (`+apiKeyColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.KeyHash, key.LookupHash, key.TenantID, key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save API key %s: %w", key.ID, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return scanAPIKeys(rows)
+}
+
+func (r *SQLiteRepository) GetAPIKeyByLookupHash(ctx context.Context, lookupHash string) (*models.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE lookup_hash = ? ORDER BY created_at, id LIMIT 1`, lookupHash)
+
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *SQLiteRepository) SetAPIKeyLookupHash(ctx context.Context, id, lookupHash string) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE api_keys SET lookup_hash = ? WHERE id = ?`, lookupHash, id); err != nil {
+		return fmt.Errorf("failed to set lookup hash of API key %s: %w", id, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) AppendAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO audit_events (`+auditEventColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		string(event.EventType), event.NotificationID, event.TenantID, event.ActorID, event.Timestamp, marshalAuditMetadata(event),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append %s audit event of notification %s: %w", event.EventType, event.NotificationID, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) ListAuditEvents(ctx context.Context, notificationID string) ([]*models.AuditEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+auditEventColumns+` FROM audit_events WHERE notification_id = ? ORDER BY id`, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events of notification %s: %w", notificationID, err)
+	}
+	return scanAuditEvents(rows)
+}
+
+func (r *SQLiteRepository) SaveCredentials(ctx context.Context, userID string, credentials models.Credentials) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_credentials (user_id, password_hash, roles, tenant_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			password_hash = excluded.password_hash,
+			roles = excluded.roles,
+			tenant_id = excluded.tenant_id`,
+		userID, credentials.PasswordHash, marshalRoles(credentials.Roles), credentials.TenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save credentials of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) GetCredentials(ctx context.Context, userID string) (*models.Credentials, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT password_hash, roles, tenant_id FROM user_credentials WHERE user_id = ?`, userID)
+	credentials, err := scanCredentials(row)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get credentials of user %s: %w", userID, err)
+	}
+	return credentials, err
+}
+
+func (r *SQLiteRepository) SaveTenant(ctx context.Context, tenant *models.Tenant) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO tenants (`+tenantColumns+`)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			config = excluded.config`,
+		tenant.ID, tenant.Name, marshalTenantConfig(tenant.Config), tenant.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save tenant %s: %w", tenant.ID, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) GetTenant(ctx context.Context, id string) (*models.Tenant, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = ?`, id)
+
+	tenant, err := scanTenant(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+func (r *SQLiteRepository) ListTenants(ctx context.Context) ([]*models.Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	return scanTenants(rows)
+}
+
+func (r *SQLiteRepository) SaveTemplate(ctx context.Context, template *models.NotificationTemplate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) + 1 FROM template_versions WHERE name = ?`, template.Name).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	args := []any{template.Name, version, template.TitleTemplate, template.ContentTemplate, template.HTMLContent, template.PlainTextContent}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO template_versions (`+templateColumns+`) VALUES (?, ?, ?, ?, ?, ?)`, args...); err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO templates (`+templateColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			version = excluded.version,
+			title_template = excluded.title_template,
+			content_template = excluded.content_template,
+			html_content = excluded.html_content,
+			plain_text_content = excluded.plain_text_content`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	template.Version = version
+	return nil
+}
+
+func (r *SQLiteRepository) GetTemplate(ctx context.Context, name string) (*models.NotificationTemplate, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM templates WHERE name = ?`, name)
+
+	template, err := scanTemplate(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (r *SQLiteRepository) GetTemplateVersion(ctx context.Context, name string, version int) (*models.NotificationTemplate, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM template_versions WHERE name = ? AND version = ?`, name, version)
+
+	template, err := scanTemplate(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (r *SQLiteRepository) ListTemplateVersions(ctx context.Context, name string) ([]*models.NotificationTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM template_versions WHERE name = ? ORDER BY version`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of template %s: %w", name, err)
+	}
+	versions, err := scanTemplates(rows)
+	if err == nil && len(versions) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+	return versions, err
+}
+
+func (r *SQLiteRepository) ListTemplates(ctx context.Context) ([]*models.NotificationTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return scanTemplates(rows)
+}
+
+func (r *SQLiteRepository) DeleteTemplate(ctx context.Context, name string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM template_versions WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM templates WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrTemplateNotFound
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) AddSuppression(ctx context.Context, email, category string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO suppressions (email, category, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(email, category) DO NOTHING`,
+		email, category, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to suppress %s: %w", email, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) IsSuppressed(ctx context.Context, email, category string) (bool, error) {
+	var suppressed bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM suppressions WHERE email = ? AND category = ?)`, email, category).Scan(&suppressed)
+	if err != nil {
+		return false, fmt.Errorf("failed to check suppression of %s: %w", email, err)
+	}
+	return suppressed, nil
+}
+
+func (r *SQLiteRepository) RecordCallbackAttempt(ctx context.Context, attempt *models.CallbackAttempt) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO callback_attempts (`+callbackAttemptColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		attempt.NotificationID, attempt.TenantID, attempt.URL, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.AttemptedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record callback attempt of notification %s: %w", attempt.NotificationID, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) ListCallbackAttempts(ctx context.Context, tenantID, notificationID string) ([]*models.CallbackAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+callbackAttemptColumns+` FROM callback_attempts WHERE tenant_id = ? AND notification_id = ? ORDER BY id`, tenantID, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list callback attempts of notification %s: %w", notificationID, err)
+	}
+	return scanCallbackAttempts(rows)
+}
+
+// EnqueueEmail marks the notification with update before adding the email,
+// so an unknown notification affects no rows and adds nothing.
+func (r *SQLiteRepository) EnqueueEmail(ctx context.Context, email *models.OutboxEmail, update StatusUpdate) error {
+	recipients, err := json.Marshal(email.Recipients)
+	if err != nil {
+		return fmt.Errorf("failed to encode recipients: %w", err)
+	}
+	return r.write(ctx, email.NotificationID, "queue email of", func() *models.NotificationEvent {
+		return statusChangedEvent(email.TenantID, email.NotificationID, update)
+	}, func(tx *sql.Tx) (sql.Result, error) {
+		result, err := tx.ExecContext(ctx,
+			`UPDATE notifications SET status = ?, failure_reason = ?, sent_at = COALESCE(?, sent_at),
+				delivered_at = COALESCE(?, delivered_at), read_at = COALESCE(?, read_at) WHERE tenant_id = ? AND id = ?`,
+			update.Status, update.FailureReason, update.SentAt, update.DeliveredAt, update.ReadAt, email.TenantID, email.NotificationID)
+		if err != nil {
+			return nil, err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return result, err
+		}
+		return result, tx.QueryRowContext(ctx, `
+			INSERT INTO email_outbox (notification_id, tenant_id, recipients, message, retry_count, last_error, created_at)
+			VALUES (?, ?, ?, ?, 0, '', ?) RETURNING id`,
+			email.NotificationID, email.TenantID, string(recipients), email.Message, email.CreatedAt,
+		).Scan(&email.ID)
+	})
+}
+
+func (r *SQLiteRepository) ListOutboxEmails(ctx context.Context, maxRetries, limit int) ([]*models.OutboxEmail, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+outboxEmailColumns+` FROM email_outbox WHERE retry_count < ? ORDER BY id LIMIT ?`, maxRetries, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox emails: %w", err)
+	}
+	return scanOutboxEmails(rows)
+}
+
+func (r *SQLiteRepository) DeleteOutboxEmail(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM email_outbox WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete outbox email %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) RecordOutboxEmailFailure(ctx context.Context, id int64, reason string) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE email_outbox SET retry_count = retry_count + 1, last_error = ? WHERE id = ?`, reason, id); err != nil {
+		return fmt.Errorf("failed to record failure of outbox email %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,756 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLiteRepository(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	scheduledAt := time.Now().Add(time.Hour).UTC()
+	notification := &models.Notification{
+		ID:          "repo-1",
+		Title:       "Weekly Report",
+		Content:     "Your report is ready",
+		Channel:     models.ChannelEmail,
+		Recipients:  []string{"a@example.com", "b@example.com"},
+		ScheduledAt: &scheduledAt,
+		CronExpr:    "0 9 * * MON",
+		Category:    "reports",
+		ChannelRecipients: map[models.NotificationChannel][]string{
+			models.ChannelSlack: {"U123"},
+		},
+		MergedFrom:  []string{"repo-0a", "repo-0b"},
+		CreatedAt:   time.Now().UTC(),
+		Metadata:    map[string]string{"team": "ops"},
+		CallbackURL: "https://example.com/hooks/notifications",
+	}
+
+	if err := repo.Save(ctx, notification); err != nil {
+		t.Fatalf("Failed to save notification: %v", err)
+	}
+
+	stored, err := repo.GetByID(ctx, "", "repo-1")
+	if err != nil {
+		t.Fatalf("Failed to get notification: %v", err)
+	}
+	if stored.Title != notification.Title || stored.Channel != models.ChannelEmail {
+		t.Errorf("Unexpected notification: %+v", stored)
+	}
+	if len(stored.Recipients) != 2 || stored.Metadata["team"] != "ops" || stored.CronExpr != "0 9 * * MON" || stored.Category != "reports" {
+		t.Errorf("Expected recipients, metadata, cron expression and category to round-trip, got %+v", stored)
+	}
+	if got := stored.ChannelRecipients[models.ChannelSlack]; len(got) != 1 || got[0] != "U123" {
+		t.Errorf("Expected channel recipients to round-trip, got %v", stored.ChannelRecipients)
+	}
+	if len(stored.MergedFrom) != 2 || stored.MergedFrom[1] != "repo-0b" {
+		t.Errorf("Expected merged IDs to round-trip, got %v", stored.MergedFrom)
+	}
+	if stored.CallbackURL != notification.CallbackURL {
+		t.Errorf("Expected callback URL to round-trip, got %q", stored.CallbackURL)
+	}
+	if stored.ScheduledAt == nil || !stored.ScheduledAt.Equal(scheduledAt) {
+		t.Errorf("Expected scheduled time %v, got %v", scheduledAt, stored.ScheduledAt)
+	}
+
+	if stored.Status != models.StatusPending {
+		t.Errorf("Expected status %s, got %s", models.StatusPending, stored.Status)
+	}
+
+	update := StatusUpdate{Status: models.StatusFailed, FailureReason: "smtp timeout"}
+	if err := repo.UpdateStatus(ctx, "", "repo-1", update); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	failed, err := repo.ListByStatus(ctx, "", models.StatusFailed)
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("Expected 1 failed notification, got %d (%v)", len(failed), err)
+	}
+	if failed[0].FailureReason != "smtp timeout" {
+		t.Errorf("Expected failure reason 'smtp timeout', got '%s'", failed[0].FailureReason)
+	}
+
+	sentAt := time.Now().UTC()
+	recipientStatuses := []models.RecipientStatus{
+		{Recipient: "user@example.com", Status: models.StatusSent, SentAt: &sentAt},
+		{Recipient: "gone@example.com", Status: models.StatusFailed, Error: "550 no such user"},
+	}
+	metadata := map[string]string{"delivered_via": "email"}
+	if err := repo.UpdateStatus(ctx, "", "repo-1", StatusUpdate{Status: models.StatusSent, SentAt: &sentAt, RecipientStatuses: recipientStatuses, Metadata: metadata}); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	stored, _ = repo.GetByID(ctx, "", "repo-1")
+	if stored.SentAt == nil || !stored.SentAt.Equal(sentAt) {
+		t.Errorf("Expected sent time %v, got %v", sentAt, stored.SentAt)
+	}
+	if len(stored.RecipientStatuses) != 2 || stored.RecipientStatuses[1].Error != "550 no such user" || !stored.RecipientStatuses[0].SentAt.Equal(sentAt) {
+		t.Errorf("Expected recipient statuses to round-trip, got %+v", stored.RecipientStatuses)
+	}
+	if stored.Metadata["delivered_via"] != "email" {
+		t.Errorf("Expected metadata to be replaced, got %v", stored.Metadata)
+	}
+	if stored.Status != models.StatusSent || stored.FailureReason != "" {
+		t.Errorf("Expected status sent with no failure reason, got %s (%q)", stored.Status, stored.FailureReason)
+	}
+	if pending, _ := repo.ListByStatus(ctx, "", models.StatusPending); len(pending) != 0 {
+		t.Errorf("Expected no pending notifications, got %d", len(pending))
+	}
+
+	readAt := sentAt.Add(time.Minute)
+	if err := repo.UpdateStatus(ctx, "", "repo-1", StatusUpdate{Status: models.StatusRead, DeliveredAt: &sentAt, ReadAt: &readAt}); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	stored, _ = repo.GetByID(ctx, "", "repo-1")
+	if stored.DeliveredAt == nil || !stored.DeliveredAt.Equal(sentAt) || stored.ReadAt == nil || !stored.ReadAt.Equal(readAt) {
+		t.Errorf("Expected delivered at %v and read at %v, got %v and %v", sentAt, readAt, stored.DeliveredAt, stored.ReadAt)
+	}
+	if stored.SentAt == nil || !stored.SentAt.Equal(sentAt) {
+		t.Errorf("Expected sent time to be kept, got %v", stored.SentAt)
+	}
+	if len(stored.RecipientStatuses) != 2 || stored.Metadata["delivered_via"] != "email" {
+		t.Errorf("Expected recipient statuses and metadata to be kept, got %+v %v", stored.RecipientStatuses, stored.Metadata)
+	}
+
+	rescheduledAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	if err := repo.UpdateScheduledAt(ctx, "", "repo-1", rescheduledAt); err != nil {
+		t.Fatalf("Failed to reschedule notification: %v", err)
+	}
+	stored, _ = repo.GetByID(ctx, "", "repo-1")
+	if stored.ScheduledAt == nil || !stored.ScheduledAt.Equal(rescheduledAt) || stored.Status != models.StatusRead {
+		t.Errorf("Expected only the scheduled time to change to %v, got %v (%s)", rescheduledAt, stored.ScheduledAt, stored.Status)
+	}
+
+	openedAt := time.Now().UTC().Truncate(time.Second)
+	for _, at := range []time.Time{openedAt, openedAt.Add(time.Minute)} {
+		if err := repo.RecordOpen(ctx, "", "repo-1", at); err != nil {
+			t.Fatalf("Failed to record open: %v", err)
+		}
+	}
+	stored, _ = repo.GetByID(ctx, "", "repo-1")
+	if stored.OpenedAt == nil || !stored.OpenedAt.Equal(openedAt) {
+		t.Errorf("Expected the first open at %v, got %v", openedAt, stored.OpenedAt)
+	}
+
+	if err := repo.RecordClick(ctx, "", "repo-1", "https://example.com/", openedAt); err != nil {
+		t.Fatalf("Failed to record click: %v", err)
+	}
+	clicks, err := repo.ListClicks(ctx, "", "repo-1")
+	if err != nil || len(clicks) != 1 || clicks[0].URL != "https://example.com/" || !clicks[0].ClickedAt.Equal(openedAt) {
+		t.Errorf("Expected the recorded click, got %+v (%v)", clicks, err)
+	}
+
+	all, err := repo.ListAll(ctx, "")
+	if err != nil || len(all) != 1 {
+		t.Fatalf("Expected 1 notification, got %d (%v)", len(all), err)
+	}
+
+	if err := repo.Delete(ctx, "", "repo-1"); err != nil {
+		t.Fatalf("Failed to delete notification: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "", "repo-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, "", "missing", StatusUpdate{Status: models.StatusFailed}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown ID, got %v", err)
+	}
+}
+
+func TestSQLiteUserRepository(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	user := &models.User{
+		ID:               "user-1",
+		Name:             "Ana",
+		Email:            "ana@example.com",
+		SlackID:          "U123",
+		PreferredChannel: models.ChannelTelegram,
+		Metadata:         map[string]string{"telegram": "42"},
+	}
+	if err := repo.SaveUser(ctx, user); err != nil {
+		t.Fatalf("Failed to save user: %v", err)
+	}
+
+	user.PreferredChannel = models.ChannelEmail
+	if err := repo.SaveUser(ctx, user); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	stored, err := repo.GetUser(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if stored.PreferredChannel != models.ChannelEmail || stored.SlackID != "U123" || stored.Metadata["telegram"] != "42" {
+		t.Errorf("Unexpected user: %+v", stored)
+	}
+
+	users, err := repo.ListUsers(ctx)
+	if err != nil || len(users) != 1 {
+		t.Fatalf("Expected 1 user, got %d (%v)", len(users), err)
+	}
+
+	for _, subscription := range []models.CategorySubscription{
+		{Category: "marketing", Subscribed: true},
+		{Category: "billing", Subscribed: true},
+		{Category: "marketing", Subscribed: false},
+	} {
+		if err := repo.SaveSubscription(ctx, "user-1", subscription); err != nil {
+			t.Fatalf("Failed to save subscription: %v", err)
+		}
+	}
+	subscriptions, err := repo.ListSubscriptions(ctx, "user-1")
+	if err != nil || len(subscriptions) != 2 {
+		t.Fatalf("Expected 2 subscriptions, got %d (%v)", len(subscriptions), err)
+	}
+	if subscriptions[0] != (models.CategorySubscription{Category: "billing", Subscribed: true}) ||
+		subscriptions[1] != (models.CategorySubscription{Category: "marketing", Subscribed: false}) {
+		t.Errorf("Unexpected subscriptions: %+v", subscriptions)
+	}
+
+	if err := repo.DeleteUser(ctx, "user-1"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if subscriptions, _ := repo.ListSubscriptions(ctx, "user-1"); len(subscriptions) != 0 {
+		t.Errorf("Expected subscriptions to be deleted with the user, got %+v", subscriptions)
+	}
+	if _, err := repo.GetUser(ctx, "user-1"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound after delete, got %v", err)
+	}
+	if err := repo.DeleteUser(ctx, "user-1"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for unknown ID, got %v", err)
+	}
+}
+
+func TestSQLiteAPIKeyRepository(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	for i, name := range []string{"ci", "billing"} {
+		key := &models.APIKey{ID: fmt.Sprintf("key-%d", i), Name: name, KeyHash: "$2a$10$hash", TenantID: name, CreatedAt: createdAt.Add(time.Duration(i) * time.Minute)}
+		if err := repo.SaveAPIKey(ctx, key); err != nil {
+			t.Fatalf("Failed to save API key: %v", err)
+		}
+	}
+
+	keys, err := repo.ListAPIKeys(ctx)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("Expected 2 API keys, got %d (%v)", len(keys), err)
+	}
+	if keys[0].Name != "ci" || keys[1].Name != "billing" || keys[0].KeyHash != "$2a$10$hash" || keys[1].TenantID != "billing" || !keys[0].CreatedAt.Equal(createdAt) {
+		t.Errorf("Unexpected API keys: %+v, %+v", keys[0], keys[1])
+	}
+
+	if _, err := repo.GetAPIKeyByLookupHash(ctx, "abc"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+	}
+	if err := repo.SetAPIKeyLookupHash(ctx, "key-1", "abc"); err != nil {
+		t.Fatalf("Failed to set lookup hash: %v", err)
+	}
+	if key, err := repo.GetAPIKeyByLookupHash(ctx, "abc"); err != nil || key.ID != "key-1" || key.LookupHash != "abc" {
+		t.Errorf("Expected key-1 by its lookup hash, got %+v (%v)", key, err)
+	}
+}
+
+func TestSQLiteSuppressionRepository(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := repo.AddSuppression(ctx, "ana@example.com", "billing"); err != nil {
+			t.Fatalf("Failed to add suppression: %v", err)
+		}
+	}
+	for category, expected := range map[string]bool{"billing": true, "marketing": false, "": false} {
+		if suppressed, err := repo.IsSuppressed(ctx, "ana@example.com", category); err != nil || suppressed != expected {
+			t.Errorf("Expected suppression from %q to be %v, got %v (%v)", category, expected, suppressed, err)
+		}
+	}
+}
+
+func TestSQLiteCallbackRepository(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	attemptedAt := time.Now().UTC()
+	for _, attempt := range []*models.CallbackAttempt{
+		{NotificationID: "cb-1", TenantID: "acme", URL: "https://example.com/hook", Attempt: 1, Error: "connection refused", AttemptedAt: attemptedAt},
+		{NotificationID: "cb-1", TenantID: "acme", URL: "https://example.com/hook", Attempt: 2, StatusCode: 200, AttemptedAt: attemptedAt.Add(time.Second)},
+		{NotificationID: "cb-1", TenantID: "other", URL: "https://example.com/hook", Attempt: 1, StatusCode: 200, AttemptedAt: attemptedAt},
+	} {
+		if err := repo.RecordCallbackAttempt(ctx, attempt); err != nil {
+			t.Fatalf("Failed to record callback attempt: %v", err)
+		}
+	}
+
+	attempts, err := repo.ListCallbackAttempts(ctx, "acme", "cb-1")
+	if err != nil {
+		t.Fatalf("Failed to list callback attempts: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("Expected 2 callback attempts for the tenant, got %d", len(attempts))
+	}
+	if attempts[0].Attempt != 1 || attempts[0].Error != "connection refused" || attempts[0].StatusCode != 0 {
+		t.Errorf("Unexpected first attempt %+v", attempts[0])
+	}
+	if attempts[1].Attempt != 2 || attempts[1].StatusCode != 200 || !attempts[1].AttemptedAt.Equal(attemptedAt.Add(time.Second)) {
+		t.Errorf("Unexpected second attempt %+v", attempts[1])
+	}
+}
+
+func TestSQLiteEmailOutbox(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	sentAt := time.Now().UTC()
+	update := StatusUpdate{Status: models.StatusSent, SentAt: &sentAt}
+	unknown := &models.OutboxEmail{NotificationID: "missing", Recipients: []string{"a@example.com"}, Message: []byte("x"), CreatedAt: sentAt}
+	if err := repo.EnqueueEmail(ctx, unknown, update); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown notification, got %v", err)
+	}
+
+	for _, id := range []string{"out-1", "out-2"} {
+		if err := repo.Save(ctx, &models.Notification{ID: id, TenantID: "acme", Channel: models.ChannelEmail, Recipients: []string{"a@example.com"}}); err != nil {
+			t.Fatalf("Failed to save notification: %v", err)
+		}
+		email := &models.OutboxEmail{NotificationID: id, TenantID: "acme", Recipients: []string{"a@example.com", "bcc@example.com"}, Message: []byte("Subject: " + id + "\r\n"), CreatedAt: sentAt}
+		if err := repo.EnqueueEmail(ctx, email, update); err != nil {
+			t.Fatalf("Failed to enqueue email: %v", err)
+		}
+		if email.ID == 0 {
+			t.Error("Expected the email to be given an ID")
+		}
+	}
+	stored, _ := repo.GetByID(ctx, "acme", "out-1")
+	if stored.Status != models.StatusSent || stored.SentAt == nil {
+		t.Errorf("Expected the notification marked sent with the email queued, got %s", stored.Status)
+	}
+
+	emails, err := repo.ListOutboxEmails(ctx, 3, 10)
+	if err != nil {
+		t.Fatalf("Failed to list outbox emails: %v", err)
+	}
+	if len(emails) != 2 || emails[0].NotificationID != "out-1" || string(emails[0].Message) != "Subject: out-1\r\n" || len(emails[0].Recipients) != 2 {
+		t.Fatalf("Expected both emails oldest first, got %+v", emails)
+	}
+
+	repo.DeleteOutboxEmail(ctx, emails[0].ID)
+	for range 3 {
+		if err := repo.RecordOutboxEmailFailure(ctx, emails[1].ID, "connection refused"); err != nil {
+			t.Fatalf("Failed to record failure: %v", err)
+		}
+	}
+	if emails, _ := repo.ListOutboxEmails(ctx, 4, 10); len(emails) != 1 || emails[0].RetryCount != 3 || emails[0].LastError != "connection refused" {
+		t.Errorf("Expected the failing email with 3 retries, got %+v", emails)
+	}
+	if emails, _ := repo.ListOutboxEmails(ctx, 3, 10); len(emails) != 0 {
+		t.Errorf("Expected emails out of retries to be left out, got %d", len(emails))
+	}
+}
+
+func TestSQLiteCredentials(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if _, err := repo.GetCredentials(ctx, "user-1"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	for _, roles := range [][]string{{models.RoleSender}, {models.RoleSender, models.RoleAdmin}} {
+		if err := repo.SaveCredentials(ctx, "user-1", models.Credentials{PasswordHash: "$2a$10$hash", Roles: roles, TenantID: "acme"}); err != nil {
+			t.Fatalf("Failed to save credentials: %v", err)
+		}
+	}
+	credentials, err := repo.GetCredentials(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Failed to get credentials: %v", err)
+	}
+	if credentials.PasswordHash != "$2a$10$hash" || len(credentials.Roles) != 2 || credentials.Roles[1] != models.RoleAdmin || credentials.TenantID != "acme" {
+		t.Errorf("Unexpected credentials: %+v", credentials)
+	}
+}
+
+func TestSQLiteTemplateRepository(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if _, err := repo.GetTemplate(ctx, "welcome"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("Expected ErrTemplateNotFound, got %v", err)
+	}
+
+	for _, tmpl := range []*models.NotificationTemplate{
+		{Name: "welcome", TitleTemplate: "Welcome", ContentTemplate: "Hi"},
+		{Name: "alert", TitleTemplate: "Alert", ContentTemplate: "{{.Message}}"},
+		{Name: "welcome", TitleTemplate: "Welcome {{.Name}}", ContentTemplate: "Hi", HTMLContent: "<p>Hi</p>"},
+	} {
+		if err := repo.SaveTemplate(ctx, tmpl); err != nil {
+			t.Fatalf("Failed to save template: %v", err)
+		}
+	}
+	stored, err := repo.GetTemplate(ctx, "welcome")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+	if stored.TitleTemplate != "Welcome {{.Name}}" || stored.HTMLContent != "<p>Hi</p>" || stored.Version != 2 {
+		t.Errorf("Unexpected template: %+v", stored)
+	}
+
+	versions, err := repo.ListTemplateVersions(ctx, "welcome")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("Expected 2 versions, got %d (%v)", len(versions), err)
+	}
+	if versions[0].Version != 1 || versions[0].TitleTemplate != "Welcome" {
+		t.Errorf("Expected the first version kept, got %+v", versions[0])
+	}
+	first, err := repo.GetTemplateVersion(ctx, "welcome", 1)
+	if err != nil || *first != *versions[0] {
+		t.Errorf("Expected version 1, got %+v (%v)", first, err)
+	}
+	if _, err := repo.GetTemplateVersion(ctx, "welcome", 3); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound, got %v", err)
+	}
+
+	templates, err := repo.ListTemplates(ctx)
+	if err != nil || len(templates) != 2 {
+		t.Fatalf("Expected 2 templates, got %d (%v)", len(templates), err)
+	}
+	if templates[0].Name != "alert" || templates[1].Name != "welcome" {
+		t.Errorf("Expected templates sorted by name, got %s, %s", templates[0].Name, templates[1].Name)
+	}
+
+	if err := repo.DeleteTemplate(ctx, "welcome"); err != nil {
+		t.Fatalf("Failed to delete template: %v", err)
+	}
+	if err := repo.DeleteTemplate(ctx, "welcome"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound, got %v", err)
+	}
+	if _, err := repo.ListTemplateVersions(ctx, "welcome"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Expected the versions deleted with the template, got %v", err)
+	}
+}
+
+func TestSQLiteTenantIsolation(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if _, err := repo.GetTenant(ctx, "acme"); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("Expected ErrTenantNotFound, got %v", err)
+	}
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	for _, id := range []string{"globex", "acme"} {
+		tenant := &models.Tenant{ID: id, Name: id, CreatedAt: createdAt}
+		if id == "acme" {
+			tenant.Config.SlackWebhookURL = "https://hooks.slack.com/acme"
+		}
+		if err := repo.SaveTenant(ctx, tenant); err != nil {
+			t.Fatalf("Failed to save tenant: %v", err)
+		}
+	}
+	tenant, err := repo.GetTenant(ctx, "acme")
+	if err != nil || tenant.Config.SlackWebhookURL != "https://hooks.slack.com/acme" || !tenant.CreatedAt.Equal(createdAt) {
+		t.Fatalf("Unexpected tenant: %+v (%v)", tenant, err)
+	}
+	if tenants, err := repo.ListTenants(ctx); err != nil || len(tenants) != 2 || tenants[0].ID != "acme" {
+		t.Errorf("Expected tenants sorted by ID, got %v (%v)", tenants, err)
+	}
+
+	notification := &models.Notification{
+		ID: "n-1", TenantID: "acme", Title: "Hello", Content: "World",
+		Channel: models.ChannelSlack, Recipients: []string{"U1"}, CreatedAt: time.Now().UTC(),
+	}
+	if err := repo.Save(ctx, notification); err != nil {
+		t.Fatalf("Failed to save notification: %v", err)
+	}
+
+	if stored, err := repo.GetByID(ctx, "acme", "n-1"); err != nil || stored.TenantID != "acme" {
+		t.Errorf("Expected the notification in its tenant, got %+v (%v)", stored, err)
+	}
+	if _, err := repo.GetByID(ctx, "globex", "n-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from another tenant, got %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, "globex", "n-1", StatusUpdate{Status: models.StatusFailed}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating from another tenant, got %v", err)
+	}
+	if err := repo.UpdateScheduledAt(ctx, "globex", "n-1", time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound rescheduling from another tenant, got %v", err)
+	}
+	if err := repo.RecordOpen(ctx, "globex", "n-1", time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound recording an open from another tenant, got %v", err)
+	}
+	if err := repo.RecordClick(ctx, "globex", "n-1", "https://example.com/", time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound recording a click from another tenant, got %v", err)
+	}
+	if err := repo.Delete(ctx, "", "n-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting from the default tenant, got %v", err)
+	}
+	hijack := *notification
+	hijack.TenantID = "globex"
+	if err := repo.Save(ctx, &hijack); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound overwriting another tenant's notification, got %v", err)
+	}
+
+	for tenantID, expected := range map[string]int{"acme": 1, "globex": 0, "": 0} {
+		page, err := repo.List(ctx, ListOptions{TenantID: tenantID})
+		if err != nil || page.TotalCount != expected || len(page.Notifications) != expected {
+			t.Errorf("Expected %d notifications for tenant %q, got %+v (%v)", expected, tenantID, page, err)
+		}
+	}
+}
+
+func TestRepositoryList(t *testing.T) {
+	sqliteRepo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := map[string]NotificationRepository{
+		"sqlite": sqliteRepo,
+		"memory": NewMemoryRepository(),
+	}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			base := time.Now().UTC().Truncate(time.Second)
+			for i := 0; i < 5; i++ {
+				notification := &models.Notification{
+					ID:         fmt.Sprintf("n-%d", i),
+					Title:      "Update",
+					Content:    "Something changed",
+					Channel:    models.ChannelEmail,
+					Recipients: []string{"a@example.com"},
+					Status:     models.StatusSent,
+					// n-3 and n-4 share a timestamp so the ID breaks the tie
+					CreatedAt: base.Add(time.Duration(min(i, 3)) * time.Minute),
+				}
+				if i%2 == 0 {
+					notification.Channel = ""
+					notification.Channels = []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail}
+					notification.Category = "billing"
+				}
+				if err := repo.Save(ctx, notification); err != nil {
+					t.Fatalf("Failed to save notification: %v", err)
+				}
+			}
+
+			var ids []string
+			opts := ListOptions{Limit: 2}
+			for {
+				page, err := repo.List(ctx, opts)
+				if err != nil {
+					t.Fatalf("Failed to list notifications: %v", err)
+				}
+				if len(ids) == 0 && page.TotalCount != 5 {
+					t.Errorf("Expected total count 5, got %d", page.TotalCount)
+				}
+				for _, notification := range page.Notifications {
+					ids = append(ids, notification.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+
+				// A newer notification must not shift the pages that follow
+				if len(ids) == 2 {
+					repo.Save(ctx, &models.Notification{ID: "late", Title: "Late", Content: "Late", Channel: models.ChannelSlack,
+						Recipients: []string{"U1"}, CreatedAt: base.Add(time.Hour)})
+				}
+			}
+			expected := []string{"n-4", "n-3", "n-2", "n-1", "n-0"}
+			if fmt.Sprint(ids) != fmt.Sprint(expected) {
+				t.Errorf("Expected pages %v, got %v", expected, ids)
+			}
+
+			page, err := repo.List(ctx, ListOptions{Channel: models.ChannelSlack, Category: "billing"})
+			if err != nil || page.TotalCount != 3 || len(page.Notifications) != 3 || page.NextCursor != "" {
+				t.Errorf("Expected 3 billing notifications on slack, got %+v (%v)", page, err)
+			}
+
+			page, err = repo.List(ctx, ListOptions{From: base.Add(time.Minute), To: base.Add(3 * time.Minute)})
+			if err != nil || page.TotalCount != 2 || len(page.Notifications) != 2 || page.Notifications[0].ID != "n-2" {
+				t.Errorf("Expected n-2 and n-1 created in the window, got %+v (%v)", page, err)
+			}
+
+			repo.Save(ctx, &models.Notification{ID: "wildcard", Title: "50% off_today", Content: "Sale", Channel: models.ChannelEmail,
+				Recipients: []string{"a@example.com"}, CreatedAt: base})
+			for query, expected := range map[string]int{"CHANGED": 5, "late": 1, "% off_": 1, "%": 1, "_": 1, "missing": 0} {
+				page, err := repo.List(ctx, ListOptions{Query: query})
+				if err != nil || page.TotalCount != expected || len(page.Notifications) != expected {
+					t.Errorf("Expected %d notifications matching %q, got %+v (%v)", expected, query, page, err)
+				}
+			}
+
+			stats, err := repo.Aggregate(ctx, StatsOptions{})
+			if err != nil {
+				t.Fatalf("Failed to aggregate notifications: %v", err)
+			}
+			if stats.ByStatus[models.StatusSent] != 5 || stats.ByStatus[models.StatusPending] != 2 {
+				t.Errorf("Unexpected status counts %v", stats.ByStatus)
+			}
+			if stats.ByChannel[models.ChannelEmail] != 6 || stats.ByChannel[models.ChannelSlack] != 4 {
+				t.Errorf("Expected fan-outs counted on each channel, got %v", stats.ByChannel)
+			}
+			if stats.Last24h != 7 {
+				t.Errorf("Expected 7 notifications in the last 24h, got %d", stats.Last24h)
+			}
+			windowed, err := repo.Aggregate(ctx, StatsOptions{From: base.Add(time.Minute), To: base.Add(3 * time.Minute)})
+			if err != nil || windowed.ByStatus[models.StatusSent] != 2 || len(windowed.ByStatus) != 1 {
+				t.Errorf("Expected 2 sent notifications in the window, got %+v (%v)", windowed, err)
+			}
+			if other, _ := repo.Aggregate(ctx, StatsOptions{TenantID: "other"}); len(other.ByStatus) != 0 {
+				t.Errorf("Expected no stats for another tenant, got %v", other.ByStatus)
+			}
+
+			if _, err := repo.List(ctx, ListOptions{Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLiteAuditRepository(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	timestamp := time.Now().UTC().Truncate(time.Second)
+	events := []*models.AuditEvent{
+		{EventType: models.AuditCreated, NotificationID: "audit-1", ActorID: "user-1", Timestamp: timestamp},
+		{EventType: models.AuditCreated, NotificationID: "audit-2", ActorID: "user-1", Timestamp: timestamp},
+		{EventType: models.AuditFailed, NotificationID: "audit-1", ActorID: "scheduler", Timestamp: timestamp, Metadata: map[string]string{"reason": "timeout"}},
+	}
+	for _, event := range events {
+		if err := repo.AppendAuditEvent(ctx, event); err != nil {
+			t.Fatalf("Failed to append audit event: %v", err)
+		}
+	}
+
+	stored, err := repo.ListAuditEvents(ctx, "audit-1")
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("Expected 2 audit events, got %d (%v)", len(stored), err)
+	}
+	if stored[0].EventType != models.AuditCreated || stored[1].EventType != models.AuditFailed || stored[1].Metadata["reason"] != "timeout" || !stored[0].Timestamp.Equal(timestamp) {
+		t.Errorf("Unexpected audit events: %+v, %+v", stored[0], stored[1])
+	}
+
+	if _, err := repo.db.ExecContext(ctx, `UPDATE audit_events SET actor_id = 'someone-else'`); err == nil {
+		t.Error("Expected updating an audit event to fail")
+	}
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM audit_events`); err == nil {
+		t.Error("Expected deleting an audit event to fail")
+	}
+}
+
+func TestSQLiteEventHistory(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	notification := &models.Notification{
+		ID:         "history-1",
+		TenantID:   "acme",
+		Title:      "Welcome",
+		Content:    "Hello",
+		Channel:    models.ChannelEmail,
+		Recipients: []string{"a@example.com"},
+		CreatedAt:  now,
+	}
+	steps := []func() error{
+		func() error { return repo.Save(ctx, notification) },
+		func() error { return repo.UpdateScheduledAt(ctx, "acme", "history-1", now.Add(time.Hour)) },
+		func() error {
+			return repo.UpdateStatus(ctx, "acme", "history-1", StatusUpdate{Status: models.StatusSent, SentAt: &now})
+		},
+		func() error { return repo.RecordOpen(ctx, "acme", "history-1", now) },
+		func() error { return repo.RecordClick(ctx, "acme", "history-1", "https://example.com", now) },
+		func() error { return repo.Save(ctx, notification) },
+		func() error { return repo.Delete(ctx, "acme", "history-1") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d failed: %v", i+1, err)
+		}
+	}
+	if err := repo.UpdateStatus(ctx, "other", "history-1", StatusUpdate{Status: models.StatusFailed}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected %v updating another tenant's notification, got %v", ErrNotFound, err)
+	}
+
+	events, err := repo.GetEventHistory(ctx, "acme", "history-1")
+	if err != nil {
+		t.Fatalf("Failed to load event history: %v", err)
+	}
+	expected := []models.NotificationEventType{
+		models.EventCreated, models.EventRescheduled, models.EventStatusChanged, models.EventOpened,
+		models.EventClicked, models.EventUpdated, models.EventDeleted,
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	for i, event := range events {
+		if event.Type != expected[i] || event.NotificationID != "history-1" || event.TenantID != "acme" {
+			t.Errorf("Expected event %d to be %s, got %+v", i+1, expected[i], event)
+		}
+		if i > 0 && event.Sequence <= events[i-1].Sequence {
+			t.Errorf("Expected increasing sequence numbers, got %d after %d", event.Sequence, events[i-1].Sequence)
+		}
+	}
+	if !strings.Contains(string(events[2].Payload), `"Status":"sent"`) {
+		t.Errorf("Expected the status change in the payload, got %s", events[2].Payload)
+	}
+
+	if _, err := repo.GetEventHistory(ctx, "other", "history-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected %v for another tenant's history, got %v", ErrNotFound, err)
+	}
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM notification_events`); err == nil {
+		t.Error("Expected deleting notification events to be rejected")
+	}
+}
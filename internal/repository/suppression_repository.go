@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// SuppressionRepository stores the email addresses that have unsubscribed
+// from a notification category. Adding an address twice is not an error.
+type SuppressionRepository interface {
+	AddSuppression(ctx context.Context, email, category string) error
+	IsSuppressed(ctx context.Context, email, category string) (bool, error)
+}
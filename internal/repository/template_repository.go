@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"os"
+	"sort"
+	"sync"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// TemplateRepository stores notification templates keyed by name. Saving a
+// template keeps the versions before it: the template is stored as the next
+// version, numbered one past the latest, and its Version is set. Lookups by
+// name return the latest version, and deleting a template deletes them all.
+type TemplateRepository interface {
+	SaveTemplate(ctx context.Context, template *models.NotificationTemplate) error
+	GetTemplate(ctx context.Context, name string) (*models.NotificationTemplate, error)
+	GetTemplateVersion(ctx context.Context, name string, version int) (*models.NotificationTemplate, error)
+	// ListTemplateVersions returns every version of the named template,
+	// oldest first, or ErrTemplateNotFound if there is no such template.
+	ListTemplateVersions(ctx context.Context, name string) ([]*models.NotificationTemplate, error)
+	// ListTemplates returns the latest version of every template sorted by
+	// name.
+	ListTemplates(ctx context.Context) ([]*models.NotificationTemplate, error)
+	DeleteTemplate(ctx context.Context, name string) error
+}
+
+const templateColumns = `name, version, title_template, content_template, html_content, plain_text_content`
+
+func scanTemplate(row rowScanner) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	if err := row.Scan(&template.Name, &template.Version, &template.TitleTemplate, &template.ContentTemplate, &template.HTMLContent, &template.PlainTextContent); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func scanTemplates(rows *sql.Rows) ([]*models.NotificationTemplate, error) {
+	defer rows.Close()
+
+	var templates []*models.NotificationTemplate
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+type MemoryTemplateRepository struct {
+	// versions holds every version of each template, oldest first.
+	versions map[string][]*models.NotificationTemplate
+	mu       sync.RWMutex
+}
+
+func NewMemoryTemplateRepository() *MemoryTemplateRepository {
+	return &MemoryTemplateRepository{versions: make(map[string][]*models.NotificationTemplate)}
+}
+
+func (r *MemoryTemplateRepository) SaveTemplate(ctx context.Context, template *models.NotificationTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *template
+	copied.Version = len(r.versions[template.Name]) + 1
+	r.versions[template.Name] = append(r.versions[template.Name], &copied)
+	template.Version = copied.Version
+	return nil
+}
+
+func (r *MemoryTemplateRepository) GetTemplate(ctx context.Context, name string) (*models.NotificationTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.versions[name]
+	if len(versions) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+	copied := *versions[len(versions)-1]
+	return &copied, nil
+}
+
+func (r *MemoryTemplateRepository) GetTemplateVersion(ctx context.Context, name string, version int) (*models.NotificationTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.versions[name]
+	if version < 1 || version > len(versions) {
+		return nil, ErrTemplateNotFound
+	}
+	copied := *versions[version-1]
+	return &copied, nil
+}
+
+func (r *MemoryTemplateRepository) ListTemplateVersions(ctx context.Context, name string) ([]*models.NotificationTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.versions[name]
+	if len(versions) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+	copies := make([]*models.NotificationTemplate, len(versions))
+	for i, template := range versions {
+		copied := *template
+		copies[i] = &copied
+	}
+	return copies, nil
+}
+
+func (r *MemoryTemplateRepository) ListTemplates(ctx context.Context) ([]*models.NotificationTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := make([]*models.NotificationTemplate, 0, len(r.versions))
+	for _, versions := range r.versions {
+		copied := *versions[len(versions)-1]
+		templates = append(templates, &copied)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+func (r *MemoryTemplateRepository) DeleteTemplate(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.versions[name]; !exists {
+		return ErrTemplateNotFound
+	}
+	delete(r.versions, name)
+	return nil
+}
+
+// allVersions returns every version of every template, sorted by name and
+// then version.
+func (r *MemoryTemplateRepository) allVersions() []*models.NotificationTemplate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var all []*models.NotificationTemplate
+	for _, versions := range r.versions {
+		all = append(all, versions...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Name != all[j].Name {
+			return all[i].Name < all[j].Name
+		}
+		return all[i].Version < all[j].Version
+	})
+	return all
+}
+
+// FileTemplateRepository keeps templates in memory and rewrites a JSON file
+// of every version on every change so they survive restarts. Templates in
+// files written before versioning are loaded as version 1.
+type FileTemplateRepository struct {
+	*MemoryTemplateRepository
+	path string
+	// fileMu serialises writes to path.
+	fileMu sync.Mutex
+}
+
+func NewFileTemplateRepository(path string) (*FileTemplateRepository, error) {
+	r := &FileTemplateRepository{MemoryTemplateRepository: NewMemoryTemplateRepository(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file: %w", err)
+	}
+
+	var templates []*models.NotificationTemplate
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &templates); err != nil {
+			return nil, fmt.Errorf("failed to parse template file: %w", err)
+		}
+	}
+	for _, template := range templates {
+		if template.Version == 0 {
+			template.Version = len(r.versions[template.Name]) + 1
+		}
+		r.versions[template.Name] = append(r.versions[template.Name], template)
+	}
+	return r, nil
+}
+
+func (r *FileTemplateRepository) SaveTemplate(ctx context.Context, template *models.NotificationTemplate) error {
+	r.MemoryTemplateRepository.SaveTemplate(ctx, template)
+	return r.flush(ctx)
+}
+
+func (r *FileTemplateRepository) DeleteTemplate(ctx context.Context, name string) error {
+	if err := r.MemoryTemplateRepository.DeleteTemplate(ctx, name); err != nil {
+		return err
+	}
+	return r.flush(ctx)
+}
+
+func (r *FileTemplateRepository) flush(ctx context.Context) error {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+
+	data, err := json.MarshalIndent(r.allVersions(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal templates: %w", err)
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write template file: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileTemplateRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "templates.json")
+
+	repo, err := NewFileTemplateRepository(path)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	templates := []*models.NotificationTemplate{
+		{Name: "welcome", TitleTemplate: "Welcome {{.Name}}", ContentTemplate: "Thanks for joining, {{.Name}}!"},
+		{Name: "alert", TitleTemplate: "{{.Service}} is down", ContentTemplate: "Investigating."},
+		{Name: "welcome", TitleTemplate: "Welcome back {{.Name}}", ContentTemplate: "Good to see you, {{.Name}}!"},
+	}
+	for _, tmpl := range templates {
+		if err := repo.SaveTemplate(ctx, tmpl); err != nil {
+			t.Fatalf("Failed to save template: %v", err)
+		}
+	}
+	if err := repo.DeleteTemplate(ctx, "alert"); err != nil {
+		t.Fatalf("Failed to delete template: %v", err)
+	}
+	if err := repo.DeleteTemplate(ctx, "alert"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound, got %v", err)
+	}
+
+	// Reopen to check the file round-trips
+	repo, err = NewFileTemplateRepository(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+	stored, err := repo.ListTemplates(ctx)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("Expected 1 template, got %d (%v)", len(stored), err)
+	}
+	if *stored[0] != *templates[2] || stored[0].Version != 2 {
+		t.Errorf("Expected %+v, got %+v", templates[2], stored[0])
+	}
+	first, err := repo.GetTemplateVersion(ctx, "welcome", 1)
+	if err != nil || *first != *templates[0] {
+		t.Errorf("Expected the first version to round-trip, got %+v (%v)", first, err)
+	}
+	if _, err := repo.GetTemplate(ctx, "alert"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound, got %v", err)
+	}
+}
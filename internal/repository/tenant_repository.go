@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+)
+
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// TenantRepository stores the tenants requests are validated against.
+// Saving a tenant with an existing ID replaces its name and config.
+type TenantRepository interface {
+	SaveTenant(ctx context.Context, tenant *models.Tenant) error
+	GetTenant(ctx context.Context, id string) (*models.Tenant, error)
+	// ListTenants returns every tenant sorted by ID.
+	ListTenants(ctx context.Context) ([]*models.Tenant, error)
+}
+
+const tenantColumns = `id, name, config, created_at`
+
+func scanTenant(row rowScanner) (*models.Tenant, error) {
+	var (
+		tenant models.Tenant
+		config string
+	)
+	if err := row.Scan(&tenant.ID, &tenant.Name, &config, &tenant.CreatedAt); err != nil {
+		return nil, err
+	}
+	if config != "" {
+		if err := json.Unmarshal([]byte(config), &tenant.Config); err != nil {
+			return nil, fmt.Errorf("failed to decode config of tenant %s: %w", tenant.ID, err)
+		}
+	}
+	return &tenant, nil
+}
+
+func scanTenants(rows *sql.Rows) ([]*models.Tenant, error) {
+	defer rows.Close()
+
+	var tenants []*models.Tenant
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, rows.Err()
+}
+
+// marshalTenantConfig stores a tenant's overrides as a JSON object, or "" when
+// it has none.
+func marshalTenantConfig(config models.TenantConfig) string {
+	if config == (models.TenantConfig{}) {
+		return ""
+	}
+	data, _ := json.Marshal(config)
+	return string(data)
+}
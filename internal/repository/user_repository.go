@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"notification-service/internal/models"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// UserRepository stores users and their notification preferences. Saving a
+// user with an existing ID replaces it; their category subscriptions are kept
+// until the user is deleted.
+type UserRepository interface {
+	SaveUser(ctx context.Context, user *models.User) error
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	ListUsers(ctx context.Context) ([]*models.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	// SaveSubscription records a user's opt-in or opt-out for a category.
+	SaveSubscription(ctx context.Context, userID string, subscription models.CategorySubscription) error
+	// ListSubscriptions returns a user's recorded subscriptions sorted by
+	// category.
+	ListSubscriptions(ctx context.Context, userID string) ([]models.CategorySubscription, error)
+	// SaveCredentials sets the password hash and roles a user signs in with.
+	SaveCredentials(ctx context.Context, userID string, credentials models.Credentials) error
+	// GetCredentials returns ErrUserNotFound if the user has no credentials.
+	GetCredentials(ctx context.Context, userID string) (*models.Credentials, error)
+}
+
+const userColumns = `id, name, email, slack_id, phone, preferred_channel, metadata`
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var (
+		user     models.User
+		channel  string
+		metadata sql.NullString
+	)
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.SlackID, &user.Phone, &channel, &metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	user.PreferredChannel = models.NotificationChannel(channel)
+	if metadata.Valid && metadata.String != "" {
+		json.Unmarshal([]byte(metadata.String), &user.Metadata)
+	}
+	return &user, nil
+}
+
+func scanUsers(rows *sql.Rows) ([]*models.User, error) {
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]models.CategorySubscription, error) {
+	defer rows.Close()
+
+	var subscriptions []models.CategorySubscription
+	for rows.Next() {
+		var subscription models.CategorySubscription
+		if err := rows.Scan(&subscription.Category, &subscription.Subscribed); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func scanCredentials(row rowScanner) (*models.Credentials, error) {
+	var (
+		credentials models.Credentials
+		roles       string
+	)
+	if err := row.Scan(&credentials.PasswordHash, &roles, &credentials.TenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if roles != "" {
+		json.Unmarshal([]byte(roles), &credentials.Roles)
+	}
+	return &credentials, nil
+}
+
+func marshalRoles(roles []string) string {
+	if len(roles) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(roles)
+	return string(data)
+}
+
+func marshalUserMetadata(user *models.User) sql.NullString {
+	if user.Metadata == nil {
+		return sql.NullString{}
+	}
+	data, _ := json.Marshal(user.Metadata)
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// checkUserRowsAffected maps an update or delete that matched nothing to
+// ErrUserNotFound.
+func checkUserRowsAffected(result sql.Result) error {
+	if err := checkRowsAffected(result); errors.Is(err, ErrNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidAPIKey is returned for keys that match no stored hash.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// apiKeyPrefix marks generated keys so they are easy to spot in logs and
+// secret scanners.
+const apiKeyPrefix = "nsk_"
+
+// APIKeyService provisions API keys and checks keys presented by clients
+// against their bcrypt hashes.
+type APIKeyService struct {
+	repository repository.APIKeyRepository
+	// hashes are configured keys that are not stored in the repository.
+	hashes []string
+	// verified caches the tenant of keys that have matched a hash by their
+	// SHA-256, since bcrypt is deliberately too slow to run on every request.
+	verified map[[sha256.Size]byte]string
+	mu       sync.RWMutex
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, hashes []string) *APIKeyService {
+	return &APIKeyService{
+		repository: repo,
+		hashes:     hashes,
+		verified:   make(map[[sha256.Size]byte]string),
+	}
+}
+
+// SetConfiguredKeys replaces the configured key hashes. Keys verified
+// against the old hashes must be verified again, so removed keys stop working
+// at once.
+func (s *APIKeyService) SetConfiguredKeys(hashes []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashes = hashes
+	s.verified = make(map[[sha256.Size]byte]string)
+}
+
+// Create generates and stores a new key named name, bound to tenantID unless
+// it is empty. The returned key is the only copy; just its hash is kept.
+func (s *APIKeyService) Create(ctx context.Context, name, tenantID string) (string, *models.APIKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to hash API key: %w", err)
+	}
+	apiKey := &models.APIKey{
+		ID:         uuid.New().String(),
+		Name:       name,
+		KeyHash:    string(hash),
+		LookupHash: apiKeyLookupHash(key),
+		TenantID:   tenantID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := s.repository.SaveAPIKey(ctx, apiKey); err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// Authenticate returns the tenant key is bound to if it matches a configured
+// or provisioned key, and ErrInvalidAPIKey if it does not. Configured keys
+// are bound to no tenant. Provisioned keys are found by their lookup hash, so
+// an unknown key is compared only with the bcrypt hashes of configured keys
+// and of keys provisioned before lookup hashes were recorded.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", ErrInvalidAPIKey
+	}
+	digest := sha256.Sum256([]byte(key))
+	s.mu.RLock()
+	tenantID, verified := s.verified[digest]
+	s.mu.RUnlock()
+	if verified {
+		return tenantID, nil
+	}
+
+	lookupHash := apiKeyLookupHash(key)
+	apiKey, err := s.repository.GetAPIKeyByLookupHash(ctx, lookupHash)
+	if err == nil {
+		if bcrypt.CompareHashAndPassword([]byte(apiKey.KeyHash), []byte(key)) != nil {
+			return "", ErrInvalidAPIKey
+		}
+		s.remember(digest, apiKey.TenantID)
+		return apiKey.TenantID, nil
+	}
+	if !errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return "", err
+	}
+
+	stored, err := s.repository.ListAPIKeys(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.mu.RLock()
+	candidates := make([]*models.APIKey, 0, len(s.hashes))
+	for _, hash := range s.hashes {
+		candidates = append(candidates, &models.APIKey{KeyHash: hash})
+	}
+	s.mu.RUnlock()
+	for _, apiKey := range stored {
+		if apiKey.LookupHash == "" {
+			candidates = append(candidates, apiKey)
+		}
+	}
+	for _, apiKey := range candidates {
+		if bcrypt.CompareHashAndPassword([]byte(apiKey.KeyHash), []byte(key)) != nil {
+			continue
+		}
+		// Find the key by its lookup hash from now on
+		if apiKey.ID != "" {
+			if err := s.repository.SetAPIKeyLookupHash(ctx, apiKey.ID, lookupHash); err != nil {
+				return "", err
+			}
+		}
+		s.remember(digest, apiKey.TenantID)
+		return apiKey.TenantID, nil
+	}
+	return "", ErrInvalidAPIKey
+}
+
+// remember caches that the key with digest is valid for tenantID.
+func (s *APIKeyService) remember(digest [sha256.Size]byte, tenantID string) {
+	s.mu.Lock()
+	s.verified[digest] = tenantID
+	s.mu.Unlock()
+}
+
+// apiKeyLookupHash returns the hex SHA-256 a key is found by. Provisioned keys
+// are random enough that it needs no bcrypt cost to resist guessing.
+func apiKeyLookupHash(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAPIKeyService(t *testing.T) {
+	configured, _ := bcrypt.GenerateFromPassword([]byte("configured-key"), bcrypt.MinCost)
+	repo := repository.NewMemoryRepository()
+	service := NewAPIKeyService(repo, []string{string(configured)})
+	ctx := context.Background()
+
+	key, apiKey, err := service.Create(ctx, "billing", "acme")
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) || apiKey.Name != "billing" || apiKey.TenantID != "acme" {
+		t.Errorf("Unexpected key %q (%+v)", key, apiKey)
+	}
+	if apiKey.LookupHash != apiKeyLookupHash(key) {
+		t.Error("Expected the key's lookup hash to be stored")
+	}
+	if apiKey.KeyHash == key || bcrypt.CompareHashAndPassword([]byte(apiKey.KeyHash), []byte(key)) != nil {
+		t.Error("Expected only a bcrypt hash of the key to be stored")
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		tenantID string
+		expected error
+	}{
+		{"Provisioned key", key, "acme", nil},
+		{"Provisioned key again", key, "acme", nil},
+		{"Configured key", "configured-key", "", nil},
+		{"Unknown key", "nsk_unknown", "", ErrInvalidAPIKey},
+		{"Empty key", "", "", ErrInvalidAPIKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID, err := service.Authenticate(ctx, tt.key)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+			if tenantID != tt.tenantID {
+				t.Errorf("Expected tenant %q, got %q", tt.tenantID, tenantID)
+			}
+		})
+	}
+}
+
+func TestAPIKeyServiceLookupHash(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	service := NewAPIKeyService(repo, nil)
+	ctx := context.Background()
+
+	// A key stored before lookup hashes is found by bcrypt once, then by its
+	// lookup hash
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("nsk_legacy"), bcrypt.MinCost)
+	if err := repo.SaveAPIKey(ctx, &models.APIKey{ID: "legacy", KeyHash: string(legacyHash), TenantID: "acme"}); err != nil {
+		t.Fatalf("Failed to save API key: %v", err)
+	}
+	if tenantID, err := service.Authenticate(ctx, "nsk_legacy"); err != nil || tenantID != "acme" {
+		t.Fatalf("Expected the legacy key to authenticate for acme, got %q (%v)", tenantID, err)
+	}
+	stored, err := repo.GetAPIKeyByLookupHash(ctx, apiKeyLookupHash("nsk_legacy"))
+	if err != nil || stored.ID != "legacy" {
+		t.Errorf("Expected the legacy key's lookup hash to be recorded, got %+v (%v)", stored, err)
+	}
+
+	// Keys with a lookup hash are not compared with keys that do not share it
+	guessedHash, _ := bcrypt.GenerateFromPassword([]byte("nsk_guess"), bcrypt.MinCost)
+	if err := repo.SaveAPIKey(ctx, &models.APIKey{ID: "indexed", KeyHash: string(guessedHash), LookupHash: apiKeyLookupHash("nsk_other")}); err != nil {
+		t.Fatalf("Failed to save API key: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "nsk_guess"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for a key without a matching lookup hash, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APNsBadgeMetadataKey and APNsSoundMetadataKey name the notification
+// metadata entries that set the app icon's badge count and the sound played
+// on arrival.
+const (
+	APNsBadgeMetadataKey = "badge"
+	APNsSoundMetadataKey = "sound"
+)
+
+// apnsTokenLifetime is how long a provider token is reused. APNs rejects
+// tokens older than an hour and refreshing more than every 20 minutes.
+const apnsTokenLifetime = 50 * time.Minute
+
+// APNsNotificationService sends push notifications through the Apple Push
+// Notification service HTTP/2 API, authenticating with a provider token.
+// Each recipient is a device token. When KeyID is empty the notification is
+// only printed to stdout.
+type APNsNotificationService struct {
+	APIURL string
+	// KeyID identifies the signing key and Topic is the app's bundle ID.
+	KeyID       string
+	Topic       string
+	TokenSource TokenSource
+	Client      *http.Client
+	Logger      logging.Logger
+}
+
+func NewAPNsNotificationService(cfg *config.Config) *APNsNotificationService {
+	return &APNsNotificationService{
+		APIURL: cfg.APNsAPIURL,
+		KeyID:  cfg.APNsKeyID,
+		Topic:  cfg.APNsTopic,
+		TokenSource: &APNsTokenSource{
+			KeyFile: cfg.APNsKeyFile,
+			KeyID:   cfg.APNsKeyID,
+			TeamID:  cfg.APNsTeamID,
+		},
+		// The default transport negotiates HTTP/2 with APNs
+		Client: &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type apnsAPS struct {
+	Alert apnsAlert `json:"alert"`
+	Badge *int      `json:"badge,omitempty"`
+	Sound string    `json:"sound,omitempty"`
+}
+
+type apnsPayload struct {
+	APS apnsAPS `json:"aps"`
+}
+
+type apnsErrorResponse struct {
+	Reason string `json:"reason"`
+}
+
+func (a *APNsNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if a.KeyID == "" {
+		logDryRun(ctx, a.Logger, notification)
+		return nil
+	}
+
+	payload, err := apnsNotificationPayload(notification)
+	if err != nil {
+		return err
+	}
+	providerToken, err := a.TokenSource.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain apns provider token: %w", err)
+	}
+	headers := map[string]string{
+		"Authorization":  "bearer " + providerToken,
+		"apns-topic":     a.Topic,
+		"apns-push-type": "alert",
+	}
+
+	var invalid []string
+	for _, token := range notification.Recipients {
+		endpoint := fmt.Sprintf("%s/3/device/%s", strings.TrimRight(a.APIURL, "/"), url.PathEscape(token))
+		err := postJSON(ctx, a.Client, "apns", endpoint, headers, payload, nil)
+		notification.RecordRecipient(token, err)
+		if isAPNsInvalidTokenError(err) {
+			invalid = append(invalid, token)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to send apns notification: %w", err)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &InvalidTokenError{Tokens: invalid}
+	}
+	return nil
+}
+
+// apnsNotificationPayload shows the title and content as an alert, with the
+// badge and sound from the notification's metadata.
+func apnsNotificationPayload(notification *models.Notification) (apnsPayload, error) {
+	payload := apnsPayload{APS: apnsAPS{
+		Alert: apnsAlert{Title: notification.Title, Body: notification.Content},
+		Sound: notification.Metadata[APNsSoundMetadataKey],
+	}}
+	if value, ok := notification.Metadata[APNsBadgeMetadataKey]; ok {
+		badge, err := parseAPNsBadge(value)
+		if err != nil {
+			return payload, err
+		}
+		payload.APS.Badge = &badge
+	}
+	return payload, nil
+}
+
+// parseAPNsBadge reads a badge count, which must be a non-negative integer.
+// Zero removes the badge.
+func parseAPNsBadge(value string) (int, error) {
+	badge, err := strconv.Atoi(value)
+	if err != nil || badge < 0 {
+		return 0, fmt.Errorf("invalid apns badge %q: must be a non-negative integer", value)
+	}
+	return badge, nil
+}
+
+func isAPNsInvalidTokenError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	var resp apnsErrorResponse
+	if json.Unmarshal([]byte(apiErr.Body), &resp) != nil {
+		return false
+	}
+	return resp.Reason == "BadDeviceToken" || resp.Reason == "Unregistered"
+}
+
+// APNsTokenSource signs APNs provider tokens with the team's .p8 signing key,
+// reusing each for apnsTokenLifetime.
+type APNsTokenSource struct {
+	KeyFile string
+	KeyID   string
+	TeamID  string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (s *APNsTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.token != "" && now.Before(s.expiry) {
+		return s.token, nil
+	}
+
+	data, err := os.ReadFile(s.KeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read apns key file: %w", err)
+	}
+	key, err := parseECPrivateKey(data)
+	if err != nil {
+		return "", err
+	}
+	token, err := signES256JWT(key, s.KeyID, map[string]interface{}{"iss": s.TeamID, "iat": now.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+	s.expiry = now.Add(apnsTokenLifetime)
+	return s.token, nil
+}
+
+func parseECPrivateKey(pemKey []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an ECDSA key")
+	}
+	return key, nil
+}
+
+// signES256JWT produces an ES256-signed compact JWT for the given claims,
+// naming the signing key in its kid header.
+func signES256JWT(key *ecdsa.PrivateKey, keyID string, claims map[string]interface{}) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": keyID})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal jwt claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign jwt: %w", err)
+	}
+	// JWS signatures are the fixed-width big-endian r and s
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPNsNotificationService(t *testing.T) {
+	var paths []string
+	var sent []apnsPayload
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("Expected an HTTP/2 request, got %s", r.Proto)
+		}
+		if got := r.Header.Get("Authorization"); got != "bearer provider" {
+			t.Errorf("Expected provider token, got %q", got)
+		}
+		if r.Header.Get("apns-topic") != "com.example.app" || r.Header.Get("apns-push-type") != "alert" {
+			t.Errorf("Unexpected APNs headers: %v", r.Header)
+		}
+		paths = append(paths, r.URL.Path)
+		var payload apnsPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		sent = append(sent, payload)
+
+		switch r.URL.Path {
+		case "/3/device/malformed":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"reason":"BadDeviceToken"}`))
+		case "/3/device/uninstalled":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered","timestamp":1700000000000}`))
+		}
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	service := &APNsNotificationService{
+		APIURL:      server.URL,
+		KeyID:       "ABC123",
+		Topic:       "com.example.app",
+		TokenSource: StaticTokenSource("provider"),
+		Client:      server.Client(),
+	}
+	notification := &models.Notification{
+		Title:      "Hello",
+		Content:    "World",
+		Recipients: []string{"good", "malformed", "uninstalled"},
+		Metadata:   map[string]string{"badge": "3", "sound": "chime.caf"},
+	}
+
+	err := service.Send(context.Background(), notification)
+
+	var tokenErr *InvalidTokenError
+	if !errors.As(err, &tokenErr) {
+		t.Fatalf("Expected InvalidTokenError, got %v", err)
+	}
+	if strings.Join(tokenErr.Tokens, ",") != "malformed,uninstalled" {
+		t.Errorf("Expected malformed and uninstalled tokens to be reported, got %v", tokenErr.Tokens)
+	}
+	if strings.Join(paths, ",") != "/3/device/good,/3/device/malformed,/3/device/uninstalled" {
+		t.Errorf("Unexpected request paths: %v", paths)
+	}
+	aps := sent[0].APS
+	if aps.Alert.Title != "Hello" || aps.Alert.Body != "World" || aps.Badge == nil || *aps.Badge != 3 || aps.Sound != "chime.caf" {
+		t.Errorf("Unexpected payload: %+v", aps)
+	}
+	if len(notification.RecipientStatuses) != 3 || notification.RecipientStatuses[0].Status != models.StatusSent {
+		t.Errorf("Expected a status for every token, got %+v", notification.RecipientStatuses)
+	}
+
+	// Other errors stop the send
+	notification = &models.Notification{Title: "Hello", Content: "World", Recipients: []string{"good"}, Metadata: map[string]string{"badge": "lots"}}
+	if err := service.Send(context.Background(), notification); err == nil || errors.As(err, &tokenErr) {
+		t.Errorf("Expected an invalid badge to fail the send, got %v", err)
+	}
+}
+
+func TestAPNsTokenSource(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyFile := filepath.Join(t.TempDir(), "AuthKey.p8")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	source := &APNsTokenSource{KeyFile: keyFile, KeyID: "ABC123", TeamID: "TEAM456"}
+	token, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to sign provider token: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected a compact JWT, got %q", token)
+	}
+
+	var header map[string]string
+	data, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	json.Unmarshal(data, &header)
+	if header["alg"] != "ES256" || header["kid"] != "ABC123" {
+		t.Errorf("Unexpected header: %v", header)
+	}
+	var claims map[string]any
+	data, _ = base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(data, &claims)
+	if claims["iss"] != "TEAM456" || claims["iat"] == nil {
+		t.Errorf("Unexpected claims: %v", claims)
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if len(signature) != 64 || !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Error("Expected a valid ES256 signature")
+	}
+
+	if again, _ := source.Token(context.Background()); again != token {
+		t.Error("Expected the provider token to be reused")
+	}
+}
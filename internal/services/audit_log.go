@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actors recorded for transitions that no API caller made directly.
+const (
+	ActorScheduler = "scheduler"
+	ActorDigest    = "digest"
+)
+
+// AuditLogger records notification state transitions. Events are only ever
+// appended; a logger never changes or removes one.
+type AuditLogger interface {
+	Append(event models.AuditEvent) error
+}
+
+// AuditLog is an AuditLogger whose history can be read back.
+type AuditLog interface {
+	AuditLogger
+	// Events returns the events of a notification, oldest first.
+	Events(ctx context.Context, notificationID string) ([]models.AuditEvent, error)
+}
+
+// NewAuditEvent describes a transition of notification caused by actorID,
+// happening now.
+func NewAuditEvent(eventType models.AuditEventType, notification *models.Notification, actorID string, metadata map[string]string) models.AuditEvent {
+	return models.AuditEvent{
+		EventType:      eventType,
+		NotificationID: notification.ID,
+		TenantID:       notification.TenantID,
+		ActorID:        actorID,
+		Timestamp:      time.Now().UTC(),
+		Metadata:       metadata,
+	}
+}
+
+// statusAuditEvent describes a send of notification recorded as update,
+// which is sent, suppressed or failed. A failure's reason is added to
+// metadata.
+func statusAuditEvent(notification *models.Notification, update repository.StatusUpdate, actorID string, metadata map[string]string) models.AuditEvent {
+	if update.Status == models.StatusSuppressed {
+		return NewAuditEvent(models.AuditSuppressed, notification, actorID, metadata)
+	}
+	if update.Status != models.StatusFailed {
+		return NewAuditEvent(models.AuditSent, notification, actorID, metadata)
+	}
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata["reason"] = update.FailureReason
+	return NewAuditEvent(models.AuditFailed, notification, actorID, metadata)
+}
+
+// appendAudit appends event to audit, if set. The transition has already
+// happened, so a failure is logged rather than returned.
+func appendAudit(audit AuditLogger, logger logging.Logger, event models.AuditEvent) {
+	if audit == nil {
+		return
+	}
+	if err := audit.Append(event); err != nil {
+		logger.Error("Error appending audit event", "notification_id", event.NotificationID, "event_type", event.EventType, "error", err)
+	}
+}
+
+// FileAuditLog appends events to a file as JSON lines. The file is opened in
+// append mode, so existing lines are never rewritten.
+type FileAuditLog struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+func NewFileAuditLog(path string) (*FileAuditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileAuditLog{path: path, file: file}, nil
+}
+
+func (l *FileAuditLog) Append(event models.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Events scans the whole file for the notification's events.
+func (l *FileAuditLog) Events(ctx context.Context, notificationID string) ([]models.AuditEvent, error) {
+	file, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	var events []models.AuditEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event models.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		if event.NotificationID == notificationID {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return events, nil
+}
+
+func (l *FileAuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// RepositoryAuditLog stores events in a database through an
+// AuditRepository.
+type RepositoryAuditLog struct {
+	repository repository.AuditRepository
+}
+
+func NewRepositoryAuditLog(repo repository.AuditRepository) *RepositoryAuditLog {
+	return &RepositoryAuditLog{repository: repo}
+}
+
+func (l *RepositoryAuditLog) Append(event models.AuditEvent) error {
+	return l.repository.AppendAuditEvent(context.Background(), &event)
+}
+
+func (l *RepositoryAuditLog) Events(ctx context.Context, notificationID string) ([]models.AuditEvent, error) {
+	stored, err := l.repository.ListAuditEvents(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]models.AuditEvent, len(stored))
+	for i, event := range stored {
+		events[i] = *event
+	}
+	return events, nil
+}
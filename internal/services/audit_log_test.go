@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewFileAuditLog(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	notification := &models.Notification{ID: "audit-1", TenantID: "acme"}
+	audit.Append(NewAuditEvent(models.AuditCreated, notification, "user-1", nil))
+	audit.Append(NewAuditEvent(models.AuditCreated, &models.Notification{ID: "audit-2"}, "user-1", nil))
+	audit.Append(NewAuditEvent(models.AuditFailed, notification, ActorScheduler, map[string]string{"reason": "timeout"}))
+	audit.Close()
+
+	// Reopening appends after the existing events
+	reopened, err := NewFileAuditLog(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen audit log: %v", err)
+	}
+	defer reopened.Close()
+	reopened.Append(NewAuditEvent(models.AuditSent, notification, ActorScheduler, nil))
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("Expected 4 JSON lines, got %d", lines)
+	}
+
+	events, err := reopened.Events(context.Background(), "audit-1")
+	if err != nil || len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d (%v)", len(events), err)
+	}
+	expected := []models.AuditEventType{models.AuditCreated, models.AuditFailed, models.AuditSent}
+	for i, event := range events {
+		if event.EventType != expected[i] || event.TenantID != "acme" {
+			t.Errorf("Expected %s event of tenant acme, got %+v", expected[i], event)
+		}
+	}
+	if events[1].Metadata["reason"] != "timeout" {
+		t.Errorf("Expected failure reason in metadata, got %v", events[1].Metadata)
+	}
+}
+
+func TestSchedulerServiceAuditEvents(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	audit := NewRepositoryAuditLog(repo)
+	sender := &mock.MockNotificationService{}
+	sender.FailNext(nil, errors.New("provider unavailable"))
+	scheduler := NewSchedulerService(sender, repo)
+	scheduler.WithAuditLogger(audit)
+
+	scheduledAt := time.Now().Add(20 * time.Millisecond)
+	expiresAt := scheduledAt.Add(time.Millisecond)
+	for _, notification := range []*models.Notification{
+		{ID: "audit-sent", Priority: models.PriorityHigh, ScheduledAt: &scheduledAt},
+		{ID: "audit-failed", ScheduledAt: &scheduledAt},
+		{ID: "audit-expired", ScheduledAt: &scheduledAt, ExpiresAt: &expiresAt},
+	} {
+		if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+			t.Fatalf("Failed to schedule notification: %v", err)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	scheduler.dispatchDue()
+
+	tests := []struct {
+		id       string
+		expected models.AuditEventType
+	}{
+		{"audit-sent", models.AuditSent},
+		{"audit-failed", models.AuditFailed},
+		{"audit-expired", models.AuditExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			events, _ := audit.Events(context.Background(), tt.id)
+			if len(events) != 1 || events[0].EventType != tt.expected || events[0].ActorID != ActorScheduler {
+				t.Errorf("Expected one %s event by the scheduler, got %+v", tt.expected, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBatchSeparator goes between the contents of merged notifications
+// unless WithSeparator sets otherwise.
+const DefaultBatchSeparator = "\n\n"
+
+// batchTitleSeparator goes between the titles of merged notifications, which
+// are often used as email subjects and must stay on one line.
+const batchTitleSeparator = "; "
+
+// batchHandoffLead is how long before its earliest scheduled time a batch is
+// handed to the wrapped scheduler, leaving it time to send on schedule.
+const batchHandoffLead = time.Second
+
+// BatchingSchedulerService wraps a Scheduler and merges one-off notifications
+// to the same recipients on the same channel whose scheduled times fall within
+// the batch window into one delivery. Batches are held until shortly before
+// their earliest scheduled time, then handed to the wrapped scheduler as a
+// single notification whose MergedFrom lists the originals, which are marked
+// merged. Notifications are only merged within a tenant and category, and
+// recurring and fan-out notifications are passed straight through.
+type BatchingSchedulerService struct {
+	scheduler  Scheduler
+	repository repository.NotificationRepository
+	window     time.Duration
+	separator  string
+	logger     logging.Logger
+
+	// batches holds the open batches by batchKey; members maps the
+	// scheduleKey of every notification they hold to its batch.
+	batches map[string][]*scheduleBatch
+	members map[string]*scheduleBatch
+	mu      sync.Mutex
+}
+
+// scheduleBatch is a group of notifications waiting to be merged.
+type scheduleBatch struct {
+	key           string
+	notifications []*models.Notification
+	timer         *time.Timer
+}
+
+// NewBatchingSchedulerService creates a scheduler that merges notifications
+// scheduled within window of each other before handing them to scheduler,
+// and records the status of the originals in repo. A nil repo disables
+// persistence.
+func NewBatchingSchedulerService(scheduler Scheduler, repo repository.NotificationRepository, window time.Duration) *BatchingSchedulerService {
+	return &BatchingSchedulerService{
+		scheduler:  scheduler,
+		repository: repo,
+		window:     window,
+		separator:  DefaultBatchSeparator,
+		logger:     logging.Default(),
+		batches:    make(map[string][]*scheduleBatch),
+		members:    make(map[string]*scheduleBatch),
+	}
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (b *BatchingSchedulerService) WithLogger(logger logging.Logger) {
+	b.logger = logger
+}
+
+// WithSeparator sets what goes between the contents of merged notifications.
+func (b *BatchingSchedulerService) WithSeparator(separator string) {
+	b.separator = separator
+}
+
+// ScheduleNotification stores notification as pending and adds it to a
+// batch of notifications to the same recipients.
+func (b *BatchingSchedulerService) ScheduleNotification(ctx context.Context, notification *models.Notification) error {
+	if len(notification.Channels) > 0 || notification.ScheduledAt == nil {
+		return b.scheduler.ScheduleNotification(ctx, notification)
+	}
+	if err := checkSchedule(notification); err != nil {
+		return err
+	}
+
+	notification.Status = models.StatusPending
+	if b.repository != nil {
+		if err := b.repository.Save(context.Background(), notification); err != nil {
+			return fmt.Errorf("failed to store scheduled notification: %v", err)
+		}
+	}
+
+	b.mu.Lock()
+	b.add(notification)
+	b.mu.Unlock()
+
+	b.logger.Info("Added notification to schedule batch", logging.NotificationAttrs(notification, "scheduled_at", notification.ScheduledAt)...)
+	return nil
+}
+
+// ScheduleRecurring passes recurring notifications straight through, since
+// their runs do not line up with one-off notifications.
+func (b *BatchingSchedulerService) ScheduleRecurring(notification *models.Notification, expr string) error {
+	return b.scheduler.ScheduleRecurring(notification, expr)
+}
+
+// CancelNotification removes a tenant's notification from its batch and
+// marks it cancelled. Notifications no longer in a batch are cancelled by
+// the wrapped scheduler.
+func (b *BatchingSchedulerService) CancelNotification(tenantID, id string) error {
+	b.mu.Lock()
+	batched := b.remove(tenantID, id)
+	b.mu.Unlock()
+
+	if !batched {
+		return b.scheduler.CancelNotification(tenantID, id)
+	}
+	if b.repository != nil {
+		update := repository.StatusUpdate{Status: models.StatusCancelled}
+		if err := b.repository.UpdateStatus(context.Background(), tenantID, id, update); err != nil {
+			return fmt.Errorf("failed to mark notification cancelled: %w", err)
+		}
+	}
+
+	attrs := []any{"notification_id", id}
+	if tenantID != "" {
+		attrs = append(attrs, "tenant_id", tenantID)
+	}
+	b.logger.Info("Cancelled scheduled notification", attrs...)
+	return nil
+}
+
+// RescheduleNotification moves a tenant's batched notification to
+// scheduledAt, which may put it in another batch. Notifications no longer in
+// a batch are rescheduled by the wrapped scheduler.
+func (b *BatchingSchedulerService) RescheduleNotification(tenantID, id string, scheduledAt time.Time) error {
+	if !scheduledAt.After(time.Now()) {
+		return ErrScheduledTimeNotInFuture
+	}
+
+	// The lock is held while storing the new time so the batch cannot be
+	// handed off with the old one meanwhile
+	b.mu.Lock()
+	batch, batched := b.members[scheduleKey(tenantID, id)]
+	if !batched {
+		b.mu.Unlock()
+		return b.scheduler.RescheduleNotification(tenantID, id, scheduledAt)
+	}
+	notification := batch.notifications[slices.IndexFunc(batch.notifications, func(n *models.Notification) bool {
+		return n.TenantID == tenantID && n.ID == id
+	})]
+	rescheduled, err := b.storeRescheduled(notification, scheduledAt)
+	if err == nil {
+		b.remove(tenantID, id)
+		b.add(rescheduled)
+	}
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	b.logger.Info("Rescheduled notification", logging.NotificationAttrs(rescheduled, "scheduled_at", scheduledAt)...)
+	return nil
+}
+
+// storeRescheduled returns a copy of notification scheduled at scheduledAt,
+// after checking it has not expired by then and storing the new time.
+func (b *BatchingSchedulerService) storeRescheduled(notification *models.Notification, scheduledAt time.Time) (*models.Notification, error) {
+	if notification.ExpiresAt != nil && !notification.ExpiresAt.After(scheduledAt) {
+		return nil, ErrExpiryNotAfterSchedule
+	}
+	rescheduled := *notification
+	rescheduled.ScheduledAt = &scheduledAt
+	if b.repository != nil {
+		if err := b.repository.UpdateScheduledAt(context.Background(), notification.TenantID, notification.ID, scheduledAt); err != nil {
+			return nil, fmt.Errorf("failed to store rescheduled notification: %w", err)
+		}
+	}
+	return &rescheduled, nil
+}
+
+// Pending returns the number of notifications waiting in batches.
+func (b *BatchingSchedulerService) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.members)
+}
+
+// Flush hands every open batch to the wrapped scheduler immediately, e.g.
+// before shutdown.
+func (b *BatchingSchedulerService) Flush() {
+	b.mu.Lock()
+	var batches []*scheduleBatch
+	for key, open := range b.batches {
+		for _, batch := range open {
+			batch.timer.Stop()
+			b.forget(batch)
+			batches = append(batches, batch)
+		}
+		delete(b.batches, key)
+	}
+	b.mu.Unlock()
+
+	for _, batch := range batches {
+		b.handOff(batch)
+	}
+}
+
+// add puts notification in the first open batch it fits, or a new one. It
+// must be called with b.mu held.
+func (b *BatchingSchedulerService) add(notification *models.Notification) {
+	key := batchKey(notification)
+	var batch *scheduleBatch
+	for _, open := range b.batches[key] {
+		if b.fits(open, notification) {
+			batch = open
+			break
+		}
+	}
+	if batch == nil {
+		batch = &scheduleBatch{key: key, notifications: []*models.Notification{notification}}
+		batch.timer = time.AfterFunc(handoffDelay(batch), func() { b.flush(batch) })
+		b.batches[key] = append(b.batches[key], batch)
+	} else {
+		batch.notifications = append(batch.notifications, notification)
+		// Joining can bring the earliest scheduled time forward
+		batch.timer.Reset(handoffDelay(batch))
+	}
+	b.members[scheduleKey(notification.TenantID, notification.ID)] = batch
+}
+
+// remove takes a tenant's notification out of its batch, closing the batch
+// if it is left empty. It must be called with b.mu held.
+func (b *BatchingSchedulerService) remove(tenantID, id string) bool {
+	key := scheduleKey(tenantID, id)
+	batch, ok := b.members[key]
+	if !ok {
+		return false
+	}
+	delete(b.members, key)
+	batch.notifications = slices.DeleteFunc(batch.notifications, func(n *models.Notification) bool {
+		return n.TenantID == tenantID && n.ID == id
+	})
+	if len(batch.notifications) == 0 {
+		batch.timer.Stop()
+		b.close(batch)
+	} else {
+		batch.timer.Reset(handoffDelay(batch))
+	}
+	return true
+}
+
+// fits reports whether notification can join batch without the scheduled
+// times in it spreading over more than the window.
+func (b *BatchingSchedulerService) fits(batch *scheduleBatch, notification *models.Notification) bool {
+	earliest, latest := *notification.ScheduledAt, *notification.ScheduledAt
+	for _, member := range batch.notifications {
+		if member.ScheduledAt.Before(earliest) {
+			earliest = *member.ScheduledAt
+		}
+		if member.ScheduledAt.After(latest) {
+			latest = *member.ScheduledAt
+		}
+	}
+	return latest.Sub(earliest) <= b.window
+}
+
+// flush hands batch to the wrapped scheduler if it is still open.
+func (b *BatchingSchedulerService) flush(batch *scheduleBatch) {
+	b.mu.Lock()
+	open := slices.Contains(b.batches[batch.key], batch)
+	if open {
+		b.close(batch)
+		b.forget(batch)
+	}
+	b.mu.Unlock()
+
+	if open {
+		b.handOff(batch)
+	}
+}
+
+// close removes batch from the open batches. It must be called with b.mu
+// held.
+func (b *BatchingSchedulerService) close(batch *scheduleBatch) {
+	b.batches[batch.key] = slices.DeleteFunc(b.batches[batch.key], func(open *scheduleBatch) bool { return open == batch })
+	if len(b.batches[batch.key]) == 0 {
+		delete(b.batches, batch.key)
+	}
+}
+
+// forget removes the members of batch from b.members. It must be called with
+// b.mu held.
+func (b *BatchingSchedulerService) forget(batch *scheduleBatch) {
+	for _, notification := range batch.notifications {
+		delete(b.members, scheduleKey(notification.TenantID, notification.ID))
+	}
+}
+
+// handOff schedules batch with the wrapped scheduler, as a single merged
+// notification if it holds more than one, and records the outcome on the
+// notifications it holds.
+func (b *BatchingSchedulerService) handOff(batch *scheduleBatch) {
+	if len(batch.notifications) == 1 {
+		notification := batch.notifications[0]
+		if err := b.scheduler.ScheduleNotification(context.Background(), dueLater(notification)); err != nil {
+			b.logger.Error("Error scheduling notification", logging.NotificationAttrs(notification, "error", err)...)
+			b.updateStatus(batch.notifications, repository.StatusUpdate{Status: models.StatusFailed, FailureReason: err.Error()})
+		}
+		return
+	}
+
+	merged := dueLater(mergeNotifications(batch.notifications, b.separator))
+	if err := b.scheduler.ScheduleNotification(context.Background(), merged); err != nil {
+		b.logger.Error("Error scheduling merged notification", logging.NotificationAttrs(merged, "merged_from", merged.MergedFrom, "error", err)...)
+		b.updateStatus(batch.notifications, repository.StatusUpdate{Status: models.StatusFailed, FailureReason: err.Error()})
+		return
+	}
+	b.logger.Info("Merged scheduled notifications", logging.NotificationAttrs(merged, "merged_from", merged.MergedFrom, "scheduled_at", merged.ScheduledAt)...)
+	b.updateStatus(batch.notifications, repository.StatusUpdate{Status: models.StatusMerged})
+}
+
+func (b *BatchingSchedulerService) updateStatus(notifications []*models.Notification, update repository.StatusUpdate) {
+	if b.repository == nil {
+		return
+	}
+	for _, notification := range notifications {
+		if err := b.repository.UpdateStatus(context.Background(), notification.TenantID, notification.ID, update); err != nil {
+			b.logger.Error("Error updating notification status", logging.NotificationAttrs(notification, "status", update.Status, "error", err)...)
+		}
+	}
+}
+
+// batchKey groups notifications that can be merged: those of one tenant and
+// category to the same recipients on the same channel.
+func batchKey(notification *models.Notification) string {
+	recipients := slices.Clone(notification.Recipients)
+	slices.Sort(recipients)
+	return strings.Join([]string{notification.TenantID, string(notification.Channel), notification.Category, strings.Join(recipients, ",")}, "/")
+}
+
+// handoffDelay is how long until batch should be handed off.
+func handoffDelay(batch *scheduleBatch) time.Duration {
+	earliest := *batch.notifications[0].ScheduledAt
+	for _, notification := range batch.notifications[1:] {
+		if notification.ScheduledAt.Before(earliest) {
+			earliest = *notification.ScheduledAt
+		}
+	}
+	return max(time.Until(earliest)-batchHandoffLead, 0)
+}
+
+// dueLater returns notification, or a copy scheduled shortly from now if its
+// scheduled time has passed while it waited in a batch.
+func dueLater(notification *models.Notification) *models.Notification {
+	if notification.ScheduledAt.After(time.Now()) {
+		return notification
+	}
+	later := *notification
+	scheduledAt := time.Now().Add(batchHandoffLead)
+	later.ScheduledAt = &scheduledAt
+	return &later
+}
+
+// mergeNotifications combines notifications into one, in scheduled order:
+// titles and contents are joined, the earliest scheduled time and highest
+// priority win, and metadata from earlier notifications takes precedence.
+// The merged notification expires with the last of them, or never if any of
+// them does not expire.
+func mergeNotifications(notifications []*models.Notification, separator string) *models.Notification {
+	ordered := slices.Clone(notifications)
+	slices.SortStableFunc(ordered, func(a, b *models.Notification) int {
+		return a.ScheduledAt.Compare(*b.ScheduledAt)
+	})
+
+	first := ordered[0]
+	merged := &models.Notification{
+		ID:          uuid.New().String(),
+		TenantID:    first.TenantID,
+		Channel:     first.Channel,
+		Recipients:  slices.Clone(first.Recipients),
+		Category:    first.Category,
+		Locale:      first.Locale,
+		ScheduledAt: first.ScheduledAt,
+		ExpiresAt:   first.ExpiresAt,
+		CreatedAt:   time.Now(),
+		Status:      models.StatusPending,
+		Priority:    first.Priority,
+	}
+	titles := make([]string, len(ordered))
+	contents := make([]string, len(ordered))
+	for i, notification := range ordered {
+		titles[i] = notification.Title
+		contents[i] = notification.Content
+		merged.MergedFrom = append(merged.MergedFrom, notification.ID)
+		merged.Priority = max(merged.Priority, notification.Priority)
+		merged.Attachments = append(merged.Attachments, notification.Attachments...)
+		if merged.ExpiresAt != nil && (notification.ExpiresAt == nil || notification.ExpiresAt.After(*merged.ExpiresAt)) {
+			merged.ExpiresAt = notification.ExpiresAt
+		}
+	}
+	merged.Title = strings.Join(titles, batchTitleSeparator)
+	merged.Content = strings.Join(contents, separator)
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if len(ordered[i].Metadata) > 0 && merged.Metadata == nil {
+			merged.Metadata = make(map[string]string)
+		}
+		maps.Copy(merged.Metadata, ordered[i].Metadata)
+	}
+	return merged
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
+	"slices"
+	"testing"
+	"time"
+)
+
+func batchedNotification(id, title string, scheduledAt time.Time, recipients ...string) *models.Notification {
+	return &models.Notification{
+		ID:          id,
+		Title:       title,
+		Content:     title + " details",
+		Channel:     models.ChannelEmail,
+		Recipients:  recipients,
+		ScheduledAt: &scheduledAt,
+		CreatedAt:   time.Now(),
+		Priority:    models.PriorityNormal,
+	}
+}
+
+func mergedNotifications(t *testing.T, repo repository.NotificationRepository) []*models.Notification {
+	t.Helper()
+	notifications, err := repo.ListAll(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Failed to list notifications: %v", err)
+	}
+	return slices.DeleteFunc(notifications, func(n *models.Notification) bool { return len(n.MergedFrom) == 0 })
+}
+
+func TestBatchingSchedulerMergesWithinWindow(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repo)
+	batcher := NewBatchingSchedulerService(scheduler, repo, time.Minute)
+	batcher.WithSeparator("\n---\n")
+
+	base := time.Now().Add(time.Hour)
+	first := batchedNotification("batch-1", "Invoice ready", base.Add(30*time.Second), "alice@example.com")
+	first.Priority = models.PriorityHigh
+	first.Metadata = map[string]string{"source": "billing"}
+	second := batchedNotification("batch-2", "Payment received", base, "alice@example.com")
+	second.Metadata = map[string]string{"source": "payments", "reply_to": "billing@example.com"}
+	later := batchedNotification("batch-3", "Receipt", base.Add(2*time.Minute), "alice@example.com")
+	other := batchedNotification("batch-4", "Welcome", base, "bob@example.com")
+	for _, notification := range []*models.Notification{first, second, later, other} {
+		if err := batcher.ScheduleNotification(context.Background(), notification); err != nil {
+			t.Fatalf("Failed to schedule notification %s: %v", notification.ID, err)
+		}
+	}
+
+	if batcher.Pending() != 4 {
+		t.Fatalf("Expected 4 batched notifications, got %d", batcher.Pending())
+	}
+	if scheduler.QueueDepth() != 0 {
+		t.Fatalf("Expected nothing handed to the scheduler before the batch closes, got %d", scheduler.QueueDepth())
+	}
+	stored, err := repo.GetByID(context.Background(), "", "batch-1")
+	if err != nil || stored.Status != models.StatusPending {
+		t.Fatalf("Expected batched notification stored as pending, got %v, %v", stored, err)
+	}
+
+	batcher.Flush()
+	if batcher.Pending() != 0 {
+		t.Errorf("Expected no batched notifications after flush, got %d", batcher.Pending())
+	}
+	if scheduler.QueueDepth() != 3 {
+		t.Fatalf("Expected the merged notification and 2 unmerged ones scheduled, got %d", scheduler.QueueDepth())
+	}
+
+	merged := mergedNotifications(t, repo)
+	if len(merged) != 1 {
+		t.Fatalf("Expected 1 merged notification, got %d", len(merged))
+	}
+	notification := merged[0]
+	if !slices.Equal(notification.MergedFrom, []string{"batch-2", "batch-1"}) {
+		t.Errorf("Expected MergedFrom in scheduled order, got %v", notification.MergedFrom)
+	}
+	if notification.Title != "Payment received; Invoice ready" {
+		t.Errorf("Unexpected merged title %q", notification.Title)
+	}
+	if notification.Content != "Payment received details\n---\nInvoice ready details" {
+		t.Errorf("Unexpected merged content %q", notification.Content)
+	}
+	if !notification.ScheduledAt.Equal(base) {
+		t.Errorf("Expected the earliest scheduled time %v, got %v", base, notification.ScheduledAt)
+	}
+	if notification.Priority != models.PriorityHigh {
+		t.Errorf("Expected the highest priority, got %s", notification.Priority)
+	}
+	if notification.Metadata["source"] != "payments" || notification.Metadata["reply_to"] != "billing@example.com" {
+		t.Errorf("Expected metadata of earlier notifications to win, got %v", notification.Metadata)
+	}
+	if notification.Status != models.StatusPending {
+		t.Errorf("Expected merged notification pending, got %s", notification.Status)
+	}
+
+	for id, expected := range map[string]models.NotificationStatus{
+		"batch-1": models.StatusMerged,
+		"batch-2": models.StatusMerged,
+		"batch-3": models.StatusPending,
+		"batch-4": models.StatusPending,
+	} {
+		stored, err := repo.GetByID(context.Background(), "", id)
+		if err != nil {
+			t.Fatalf("Failed to load notification %s: %v", id, err)
+		}
+		if stored.Status != expected {
+			t.Errorf("Expected %s to be %s, got %s", id, expected, stored.Status)
+		}
+	}
+}
+
+func TestBatchingSchedulerHandsOffBeforeDue(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repo)
+	batcher := NewBatchingSchedulerService(scheduler, repo, time.Minute)
+
+	due := time.Now().Add(batchHandoffLead + 200*time.Millisecond)
+	for _, notification := range []*models.Notification{
+		batchedNotification("due-1", "Build passed", due, "U1"),
+		batchedNotification("due-2", "Deploy started", due.Add(time.Second), "U1"),
+	} {
+		if err := batcher.ScheduleNotification(context.Background(), notification); err != nil {
+			t.Fatalf("Failed to schedule notification: %v", err)
+		}
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	if batcher.Pending() != 0 {
+		t.Fatalf("Expected the batch to be handed off before it is due, got %d pending", batcher.Pending())
+	}
+	if scheduler.QueueDepth() != 1 {
+		t.Errorf("Expected 1 merged notification scheduled, got %d", scheduler.QueueDepth())
+	}
+	if merged := mergedNotifications(t, repo); len(merged) != 1 || len(merged[0].MergedFrom) != 2 {
+		t.Errorf("Expected 1 notification merged from 2, got %v", merged)
+	}
+}
+
+func TestBatchingSchedulerCancelAndReschedule(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repo)
+	batcher := NewBatchingSchedulerService(scheduler, repo, time.Minute)
+
+	base := time.Now().Add(time.Hour)
+	for _, notification := range []*models.Notification{
+		batchedNotification("keep", "Kept", base, "alice@example.com"),
+		batchedNotification("cancel", "Cancelled", base, "alice@example.com"),
+		batchedNotification("move", "Moved", base, "alice@example.com"),
+	} {
+		if err := batcher.ScheduleNotification(context.Background(), notification); err != nil {
+			t.Fatalf("Failed to schedule notification: %v", err)
+		}
+	}
+
+	if err := batcher.CancelNotification("", "cancel"); err != nil {
+		t.Fatalf("Failed to cancel batched notification: %v", err)
+	}
+	if err := batcher.CancelNotification("other-tenant", "keep"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected another tenant's notification to be not found, got %v", err)
+	}
+	if err := batcher.RescheduleNotification("", "move", base.Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to reschedule batched notification: %v", err)
+	}
+	batcher.Flush()
+
+	if merged := mergedNotifications(t, repo); len(merged) != 0 {
+		t.Errorf("Expected nothing left to merge, got %v", merged[0].MergedFrom)
+	}
+	if scheduler.QueueDepth() != 2 {
+		t.Errorf("Expected 2 notifications scheduled on their own, got %d", scheduler.QueueDepth())
+	}
+	cancelled, _ := repo.GetByID(context.Background(), "", "cancel")
+	if cancelled.Status != models.StatusCancelled {
+		t.Errorf("Expected cancelled notification to be %s, got %s", models.StatusCancelled, cancelled.Status)
+	}
+	moved, _ := repo.GetByID(context.Background(), "", "move")
+	if !moved.ScheduledAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("Expected rescheduled time to be stored, got %v", moved.ScheduledAt)
+	}
+
+	// Once handed off, cancelling goes to the wrapped scheduler
+	if err := batcher.CancelNotification("", "keep"); err != nil {
+		t.Errorf("Failed to cancel scheduled notification: %v", err)
+	}
+	if err := batcher.CancelNotification("", "cancel"); !errors.Is(err, ErrNotificationNotPending) {
+		t.Errorf("Expected %v cancelling twice, got %v", ErrNotificationNotPending, err)
+	}
+}
+
+func TestBatchingSchedulerPassesThrough(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repo)
+	batcher := NewBatchingSchedulerService(scheduler, repo, time.Minute)
+
+	scheduledAt := time.Now().Add(time.Hour)
+	fanOut := batchedNotification("fan-out", "Outage", scheduledAt, "ops")
+	fanOut.Channels = []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail}
+	if err := batcher.ScheduleNotification(context.Background(), fanOut); err != nil {
+		t.Fatalf("Failed to schedule fan-out notification: %v", err)
+	}
+	if err := batcher.ScheduleRecurring(batchedNotification("recurring", "Standup", scheduledAt, "team"), "@daily"); err != nil {
+		t.Fatalf("Failed to schedule recurring notification: %v", err)
+	}
+	if batcher.Pending() != 0 {
+		t.Errorf("Expected fan-out and recurring notifications not to be batched, got %d", batcher.Pending())
+	}
+	if scheduler.QueueDepth() != 1 {
+		t.Errorf("Expected the fan-out notification to be scheduled directly, got %d", scheduler.QueueDepth())
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if err := batcher.ScheduleNotification(context.Background(), batchedNotification("past", "Late", past, "ops")); !errors.Is(err, ErrScheduledTimeNotInFuture) {
+		t.Errorf("Expected %v, got %v", ErrScheduledTimeNotInFuture, err)
+	}
+}
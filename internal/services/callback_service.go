@@ -0,0 +1,162 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCallbackRetries is how many times a failed callback is retried.
+	DefaultCallbackRetries = 3
+	// DefaultCallbackRetryDelay is the delay before the first retry; each
+	// later retry waits twice as long as the one before.
+	DefaultCallbackRetryDelay = time.Second
+)
+
+// DeliveryEvent is the JSON body POSTed to a notification's callback URL once
+// it has been sent, has failed or was suppressed.
+type DeliveryEvent struct {
+	NotificationID string                    `json:"notification_id"`
+	Status         models.NotificationStatus `json:"status"`
+	SentAt         *time.Time                `json:"sent_at,omitempty"`
+	Error          string                    `json:"error,omitempty"`
+}
+
+// CallbackService POSTs the delivery status of notifications to their
+// CallbackURL in the background. Bodies are signed like webhook deliveries,
+// with the signature in the X-Notification-Signature header. Transport
+// failures and 5xx responses are retried with exponential backoff, and
+// every attempt is recorded in the repository.
+type CallbackService struct {
+	Secret     string
+	Client     *http.Client
+	MaxRetries int
+	RetryDelay time.Duration
+
+	repository repository.CallbackRepository
+	logger     logging.Logger
+	wg         sync.WaitGroup
+}
+
+// NewCallbackService creates a callback service that signs with secret and
+// records attempts in repo. A nil repo disables recording. Callbacks to
+// internal addresses are refused, as for webhooks.
+func NewCallbackService(secret string, repo repository.CallbackRepository, timeout time.Duration) *CallbackService {
+	return &CallbackService{
+		Secret:     secret,
+		Client:     NewGuardedClient(timeout),
+		MaxRetries: DefaultCallbackRetries,
+		RetryDelay: DefaultCallbackRetryDelay,
+		repository: repo,
+		logger:     logging.Default(),
+	}
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (s *CallbackService) WithLogger(logger logging.Logger) {
+	s.logger = logger
+}
+
+// Notify sends notification's current status to its callback URL without
+// waiting for the result. Notifications without a callback URL are ignored.
+func (s *CallbackService) Notify(notification *models.Notification) {
+	if notification.CallbackURL == "" {
+		return
+	}
+	event := DeliveryEvent{
+		NotificationID: notification.ID,
+		Status:         notification.Status,
+		SentAt:         notification.SentAt,
+		Error:          notification.FailureReason,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("Error encoding delivery callback", logging.NotificationAttrs(notification, "error", err)...)
+		return
+	}
+
+	// Copy what the goroutine needs, since the caller keeps updating the
+	// notification
+	tenantID, id, url := notification.TenantID, notification.ID, notification.CallbackURL
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.deliver(tenantID, id, url, body)
+	}()
+}
+
+// Wait blocks until every callback in progress has finished.
+func (s *CallbackService) Wait() {
+	s.wg.Wait()
+}
+
+// deliver POSTs body to url until it succeeds, a 4xx response rules out
+// retrying, or the retries run out.
+func (s *CallbackService) deliver(tenantID, id, url string, body []byte) {
+	attrs := []any{"notification_id", id, "callback_url", url}
+	if tenantID != "" {
+		attrs = append(attrs, "tenant_id", tenantID)
+	}
+	signature := SignPayload(s.Secret, body)
+	delay := s.RetryDelay
+	for attempt := 1; attempt <= s.MaxRetries+1; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		record := &models.CallbackAttempt{NotificationID: id, TenantID: tenantID, URL: url, Attempt: attempt, AttemptedAt: time.Now()}
+		statusCode, err := s.post(url, signature, body)
+		record.StatusCode = statusCode
+		if err != nil {
+			record.Error = err.Error()
+		}
+		s.record(record)
+
+		if err == nil && statusCode >= 200 && statusCode <= 299 {
+			s.logger.Debug("Delivered status callback", append(attrs, "attempt", attempt)...)
+			return
+		}
+		// Only transport failures and server errors are retried
+		if err == nil && statusCode < 500 {
+			break
+		}
+	}
+	s.logger.Warn("Giving up on status callback", attrs...)
+}
+
+func (s *CallbackService) post(url, signature string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-Signature", signature)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (s *CallbackService) record(attempt *models.CallbackAttempt) {
+	if s.repository == nil {
+		return
+	}
+	if err := s.repository.RecordCallbackAttempt(context.Background(), attempt); err != nil {
+		s.logger.Error("Error recording callback attempt", "notification_id", attempt.NotificationID, "attempt", attempt.Attempt, "error", err)
+	}
+}
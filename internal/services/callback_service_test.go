@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallbackServiceNotify(t *testing.T) {
+	tests := []struct {
+		name             string
+		statuses         []int
+		expectedAttempts int
+	}{
+		{"Delivered", []int{http.StatusOK}, 1},
+		{"Retried after server errors", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusNoContent}, 3},
+		{"Gives up after retries", []int{500, 500, 500, 500, 500}, 4},
+		{"Client error not retried", []int{http.StatusNotFound, http.StatusOK}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests int
+				event    DeliveryEvent
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if !VerifySignature("callback-secret", body, r.Header.Get("X-Notification-Signature")) {
+					t.Errorf("Expected a valid X-Notification-Signature, got %q", r.Header.Get("X-Notification-Signature"))
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				json.Unmarshal(body, &event)
+				w.WriteHeader(tt.statuses[requests])
+				requests++
+			}))
+			defer server.Close()
+
+			repo := repository.NewMemoryRepository()
+			callbacks := NewCallbackService("callback-secret", repo, time.Second)
+			callbacks.Client = server.Client()
+			callbacks.RetryDelay = time.Millisecond
+
+			sentAt := time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC)
+			callbacks.Notify(&models.Notification{
+				ID:          "callback-1",
+				TenantID:    "acme",
+				Status:      models.StatusSent,
+				SentAt:      &sentAt,
+				CallbackURL: server.URL,
+			})
+			callbacks.Wait()
+
+			if requests != tt.expectedAttempts {
+				t.Errorf("Expected %d callback requests, got %d", tt.expectedAttempts, requests)
+			}
+			if event.NotificationID != "callback-1" || event.Status != models.StatusSent || event.SentAt == nil || !event.SentAt.Equal(sentAt) {
+				t.Errorf("Unexpected delivery event %+v", event)
+			}
+
+			attempts, err := repo.ListCallbackAttempts(t.Context(), "acme", "callback-1")
+			if err != nil {
+				t.Fatalf("Failed to list callback attempts: %v", err)
+			}
+			if len(attempts) != tt.expectedAttempts {
+				t.Fatalf("Expected %d recorded attempts, got %d", tt.expectedAttempts, len(attempts))
+			}
+			for i, attempt := range attempts {
+				if attempt.Attempt != i+1 || attempt.URL != server.URL || attempt.StatusCode != tt.statuses[i] {
+					t.Errorf("Unexpected attempt %d: %+v", i+1, attempt)
+				}
+			}
+		})
+	}
+}
+
+func TestCallbackServiceFailureEvent(t *testing.T) {
+	received := make(chan DeliveryEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event DeliveryEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer server.Close()
+
+	callbacks := NewCallbackService("callback-secret", nil, time.Second)
+	callbacks.Client = server.Client()
+	callbacks.Notify(&models.Notification{ID: "no-callback", Status: models.StatusSent})
+	callbacks.Notify(&models.Notification{ID: "callback-2", Status: models.StatusFailed, FailureReason: "smtp unavailable", CallbackURL: server.URL})
+	callbacks.Wait()
+
+	event := <-received
+	if event.NotificationID != "callback-2" || event.Status != models.StatusFailed || event.Error != "smtp unavailable" || event.SentAt != nil {
+		t.Errorf("Unexpected delivery event %+v", event)
+	}
+	if len(received) != 0 {
+		t.Error("Expected no callback for a notification without a callback URL")
+	}
+}
+
+func TestCallbackServiceBlocksInternalAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	repo := repository.NewMemoryRepository()
+	callbacks := NewCallbackService("callback-secret", repo, time.Second)
+	callbacks.MaxRetries = 0
+	callbacks.Notify(&models.Notification{ID: "callback-3", Status: models.StatusSent, CallbackURL: server.URL})
+	callbacks.Wait()
+
+	if requests != 0 {
+		t.Errorf("Expected no requests to reach the internal server, got %d", requests)
+	}
+	attempts, _ := repo.ListCallbackAttempts(t.Context(), "", "callback-3")
+	if len(attempts) != 1 || !strings.Contains(attempts[0].Error, ErrBlockedAddress.Error()) {
+		t.Errorf("Expected the attempt to be refused as blocked, got %+v", attempts)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the downstream service while a
+// channel's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerOptions configures a CircuitBreakerNotificationService.
+type CircuitBreakerOptions struct {
+	// FailureThreshold consecutive failures open the breaker.
+	FailureThreshold int
+	// Timeout is how long the breaker stays open before letting probes through.
+	Timeout time.Duration
+	// HalfOpenProbes successful probes close the breaker again.
+	HalfOpenProbes int
+}
+
+// CircuitBreakerNotificationService stops calling a failing channel. After
+// FailureThreshold consecutive failures it opens and fails fast with
+// ErrCircuitOpen; once Timeout has passed it lets HalfOpenProbes sends through
+// and closes if they all succeed, or opens again on the first failure.
+type CircuitBreakerNotificationService struct {
+	service NotificationService
+	channel models.NotificationChannel
+	options CircuitBreakerOptions
+
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// probes counts sends let through while half-open; successes counts
+	// those that succeeded.
+	probes    int
+	successes int
+	mu        sync.Mutex
+
+	Logger logging.Logger
+	// OnStateChange, when set, is called after every transition.
+	OnStateChange func(state CircuitState)
+}
+
+func NewCircuitBreakerNotificationService(service NotificationService, channel models.NotificationChannel, opts CircuitBreakerOptions) *CircuitBreakerNotificationService {
+	if opts.FailureThreshold < 1 {
+		opts.FailureThreshold = 1
+	}
+	if opts.HalfOpenProbes < 1 {
+		opts.HalfOpenProbes = 1
+	}
+	return &CircuitBreakerNotificationService{service: service, channel: channel, options: opts}
+}
+
+// State returns the breaker's current state.
+func (c *CircuitBreakerNotificationService) State() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Send counts truncated messages as successes, since they were delivered,
+// and suppressed ones, since the channel was not at fault.
+func (c *CircuitBreakerNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if !c.allow() {
+		return fmt.Errorf("%s notification %s: %w", c.channel, notification.ID, ErrCircuitOpen)
+	}
+	err := c.service.Send(ctx, notification)
+	c.record(!DeliveryFailed(err))
+	return err
+}
+
+// allow reports whether a send may go through, moving an open breaker to
+// half-open once its timeout has passed.
+func (c *CircuitBreakerNotificationService) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < c.options.Timeout {
+			return false
+		}
+		c.transition(CircuitHalfOpen)
+	case CircuitHalfOpen:
+		if c.probes >= c.options.HalfOpenProbes {
+			return false
+		}
+	default:
+		return true
+	}
+	c.probes++
+	return true
+}
+
+func (c *CircuitBreakerNotificationService) record(success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitClosed:
+		if success {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= c.options.FailureThreshold {
+			c.transition(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if !success {
+			c.transition(CircuitOpen)
+			return
+		}
+		c.successes++
+		if c.successes >= c.options.HalfOpenProbes {
+			c.transition(CircuitClosed)
+		}
+	}
+	// Sends that were let through before the breaker opened change nothing
+}
+
+// transition must be called with c.mu held.
+func (c *CircuitBreakerNotificationService) transition(state CircuitState) {
+	from := c.state
+	c.state = state
+	c.failures = 0
+	c.probes = 0
+	c.successes = 0
+	if state == CircuitOpen {
+		c.openedAt = time.Now()
+	}
+
+	logging.OrDefault(c.Logger).Warn("Circuit breaker state changed",
+		"channel", c.channel, "from", from.String(), "to", state.String())
+	if c.OnStateChange != nil {
+		c.OnStateChange(state)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/metrics"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerNotificationService(t *testing.T) {
+	downstream := errors.New("downstream unavailable")
+	inner := &mock.MockNotificationService{}
+	inner.FailNext(downstream, downstream, downstream)
+	breaker := NewCircuitBreakerNotificationService(inner, models.ChannelSlack, CircuitBreakerOptions{
+		FailureThreshold: 2,
+		Timeout:          20 * time.Millisecond,
+		HalfOpenProbes:   1,
+	})
+	var states []CircuitState
+	breaker.OnStateChange = func(state CircuitState) { states = append(states, state) }
+	notification := &models.Notification{ID: "cb", Channel: models.ChannelSlack}
+
+	for i := 0; i < 2; i++ {
+		if err := breaker.Send(context.Background(), notification); !errors.Is(err, downstream) {
+			t.Fatalf("Expected downstream error on send %d, got %v", i, err)
+		}
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("Expected breaker to open after 2 failures, got %s", breaker.State())
+	}
+
+	if err := breaker.Send(context.Background(), notification); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if len(inner.SentNotifications()) != 2 {
+		t.Errorf("Expected an open breaker not to call the wrapped service, got %d calls", len(inner.SentNotifications()))
+	}
+
+	// A failed probe opens the breaker again
+	time.Sleep(25 * time.Millisecond)
+	if err := breaker.Send(context.Background(), notification); !errors.Is(err, downstream) {
+		t.Fatalf("Expected the probe to reach the wrapped service, got %v", err)
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("Expected breaker to reopen after a failed probe, got %s", breaker.State())
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if err := breaker.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected the probe to succeed, got %v", err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("Expected breaker to close after a successful probe, got %s", breaker.State())
+	}
+
+	expected := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(states) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, states)
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Errorf("Expected transitions %v, got %v", expected, states)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	inner := &mock.MockNotificationService{}
+	inner.FailNext(errors.New("boom"))
+	breaker := NewCircuitBreakerNotificationService(inner, models.ChannelEmail, CircuitBreakerOptions{
+		FailureThreshold: 1,
+		Timeout:          10 * time.Millisecond,
+		HalfOpenProbes:   2,
+	})
+	notification := &models.Notification{ID: "cb-probes", Channel: models.ChannelEmail}
+
+	breaker.Send(context.Background(), notification)
+	time.Sleep(15 * time.Millisecond)
+
+	if err := breaker.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Unexpected error on first probe: %v", err)
+	}
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf("Expected breaker to stay half-open until every probe succeeds, got %s", breaker.State())
+	}
+	if err := breaker.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Unexpected error on second probe: %v", err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("Expected breaker to close, got %s", breaker.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresTruncation(t *testing.T) {
+	inner := &mock.MockNotificationService{}
+	inner.FailNext(ErrMessageTruncated, ErrMessageTruncated)
+	breaker := NewCircuitBreakerNotificationService(inner, models.ChannelMessage, CircuitBreakerOptions{FailureThreshold: 1, Timeout: time.Minute})
+	notification := &models.Notification{ID: "cb-truncated", Channel: models.ChannelMessage}
+
+	for i := 0; i < 2; i++ {
+		breaker.Send(context.Background(), notification)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("Expected truncated messages not to open the breaker, got %s", breaker.State())
+	}
+}
+
+func TestNotificationServiceFactoryAppliesCircuitBreakers(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{
+		RateLimits: map[string]config.RateLimitConfig{"slack": {RequestsPerSecond: 1, Burst: 1}},
+		CircuitBreakers: map[string]config.CircuitBreakerConfig{
+			"slack": {FailureThreshold: 1, Timeout: time.Minute, HalfOpenProbes: 1},
+		},
+	})
+	collector := metrics.NewMetricsCollector()
+	factory.WithMetrics(collector)
+
+	slack, _ := factory.GetService(models.ChannelSlack)
+	metricsService, ok := slack.(*MetricsNotificationService)
+	if !ok {
+		t.Fatalf("Expected metrics-wrapped Slack service, got %T", slack)
+	}
+	if _, ok := metricsService.service.(*CircuitBreakerNotificationService); !ok {
+		t.Errorf("Expected circuit breaker around the Slack service, got %T", metricsService.service)
+	}
+	email, _ := factory.GetService(models.ChannelEmail)
+	if _, ok := email.(*MetricsNotificationService).service.(*CircuitBreakerNotificationService); ok {
+		t.Error("Expected email service without a circuit breaker")
+	}
+
+	factory.breakers[models.ChannelSlack].record(false)
+	rr := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if expected := `circuit_breaker_state{channel="slack"} 1`; !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("Expected %q in metrics output", expected)
+	}
+}
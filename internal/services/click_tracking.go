@@ -0,0 +1,69 @@
+package services
+
+import (
+	"html"
+	"net/url"
+	"notification-service/internal/models"
+	"regexp"
+	"strings"
+)
+
+// hrefPattern matches quoted href attributes, capturing everything up to the
+// opening quote and the URL inside the quotes. The leading space keeps
+// attributes such as data-href from matching.
+var hrefPattern = regexp.MustCompile(`(?i)(\shref\s*=\s*)(?:"([^"]*)"|'([^']*)')`)
+
+// RewriteURLs rewrites the http and https links in the href attributes of
+// content to go through {baseURL}/track/click/{notifID}?url={original}, so
+// clicks can be recorded before redirecting. Other links, such as mailto:
+// and in-page anchors, are left alone.
+func RewriteURLs(content, baseURL, notifID string) string {
+	return rewriteURLs(content, baseURL, &models.Notification{ID: notifID}, nil)
+}
+
+// rewriteURLs is RewriteURLs for notification, limited to the links allowed
+// reports true for. Links of a tenant's notification also carry the tenant.
+// A nil allowed rewrites every http and https link.
+func rewriteURLs(content, baseURL string, notification *models.Notification, allowed func(*url.URL) bool) string {
+	return hrefPattern.ReplaceAllStringFunc(content, func(attr string) string {
+		match := hrefPattern.FindStringSubmatch(attr)
+		original := html.UnescapeString(match[2] + match[3])
+		target, err := url.Parse(original)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return attr
+		}
+		if allowed != nil && !allowed(target) {
+			return attr
+		}
+		link := trackingURL(baseURL, "click", notification, url.Values{"url": {original}})
+		return match[1] + `"` + html.EscapeString(link) + `"`
+	})
+}
+
+// AllowedRedirect reports whether target is an http or https URL whose host
+// is in hosts. An entry such as "*.example.com" allows every subdomain of
+// example.com, but not example.com itself.
+func AllowedRedirect(target string, hosts []string) bool {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	return allowedHost(u, hosts)
+}
+
+func allowedHost(u *url.URL, hosts []string) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package services
+
+import "testing"
+
+func TestRewriteURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Double quotes",
+			content:  `<a href="https://example.com/a?b=1&amp;c=2">Go</a>`,
+			expected: `<a href="https://notify.example.com/track/click/n-1?url=https%3A%2F%2Fexample.com%2Fa%3Fb%3D1%26c%3D2">Go</a>`,
+		},
+		{
+			name:     "Single quotes and spacing",
+			content:  `<a class="x" HREF = 'http://example.com/'>Go</a>`,
+			expected: `<a class="x" HREF = "https://notify.example.com/track/click/n-1?url=http%3A%2F%2Fexample.com%2F">Go</a>`,
+		},
+		{
+			name:     "Non-web links untouched",
+			content:  `<a href="mailto:a@example.com">Mail</a><a href="#top">Top</a><a href="/relative">Rel</a><a href="javascript:alert(1)">JS</a>`,
+			expected: `<a href="mailto:a@example.com">Mail</a><a href="#top">Top</a><a href="/relative">Rel</a><a href="javascript:alert(1)">JS</a>`,
+		},
+		{
+			name:     "Other attributes untouched",
+			content:  `<img src="https://example.com/logo.png" data-href="https://example.com/">`,
+			expected: `<img src="https://example.com/logo.png" data-href="https://example.com/">`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := RewriteURLs(tt.content, "https://notify.example.com/", "n-1"); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestAllowedRedirect(t *testing.T) {
+	hosts := []string{"example.com", "*.shop.example.com"}
+	tests := []struct {
+		target   string
+		expected bool
+	}{
+		{"https://example.com/page", true},
+		{"http://EXAMPLE.com:8080/", true},
+		{"https://sale.shop.example.com/", true},
+		{"https://shop.example.com/", false},
+		{"https://evil.com/?example.com", false},
+		{"https://example.com.evil.com/", false},
+		{"https://evilexample.com/", false},
+		{"//example.com/", false},
+		{"javascript://example.com/%0Aalert(1)", false},
+		{"/relative", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if result := AllowedRedirect(tt.target, hosts); result != tt.expected {
+			t.Errorf("Expected AllowedRedirect(%q) to be %v, got %v", tt.target, tt.expected, result)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ErrMessageTruncated is returned alongside a successful delivery when the
+// content had to be shortened to fit the channel's message limit.
+var ErrMessageTruncated = errors.New("message content was truncated")
+
+// channelLimits returns DefaultChannelLimits with cfg.ChannelLimits applied.
+func channelLimits(cfg *config.Config) map[models.NotificationChannel]config.ChannelLimitConfig {
+	limits := make(map[models.NotificationChannel]config.ChannelLimitConfig, len(DefaultChannelLimits))
+	for channel, limit := range DefaultChannelLimits {
+		limits[channel] = limit
+	}
+	if cfg != nil {
+		for channel, limit := range cfg.ChannelLimits {
+			limits[models.NotificationChannel(channel)] = limit
+		}
+	}
+	return limits
+}
+
+// ContentLimitNotificationService applies a channel's TruncatePolicy to
+// content longer than its MaxContentLength before passing the notification
+// on. Rejected content returns a *ValidationError without sending. Truncated
+// content is cut to the limit and ErrMessageTruncated returned once it is
+// delivered. Split content is sent as consecutive messages that each fit the
+// limit, except on the SMS channel where it is left to SplitSMS to send as a
+// concatenated message. The stored notification keeps its full content.
+type ContentLimitNotificationService struct {
+	service NotificationService
+	channel models.NotificationChannel
+	limit   config.ChannelLimitConfig
+	Logger  logging.Logger
+}
+
+func NewContentLimitNotificationService(service NotificationService, channel models.NotificationChannel, limit config.ChannelLimitConfig) *ContentLimitNotificationService {
+	return &ContentLimitNotificationService{service: service, channel: channel, limit: limit}
+}
+
+func (s *ContentLimitNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	limit := s.limit.MaxContentLength
+	n := utf8.RuneCountInString(notification.Content)
+	if limit <= 0 || n <= limit {
+		return s.service.Send(ctx, notification)
+	}
+
+	logger := logging.FromContext(ctx, s.Logger)
+	switch s.limit.TruncatePolicy {
+	case config.TruncationTruncate:
+		logger.Warn("Truncating notification content", logging.NotificationAttrs(notification, "length", n, "limit", limit)...)
+		content, _ := truncateContent(notification.Content, limit)
+		if err := s.sendContent(ctx, notification, content); err != nil {
+			return err
+		}
+		return ErrMessageTruncated
+	case config.TruncationSplit:
+		if s.channel == models.ChannelMessage {
+			return s.service.Send(ctx, notification)
+		}
+		parts := splitContent(notification.Content, limit)
+		logger.Warn("Splitting notification content", logging.NotificationAttrs(notification, "length", n, "limit", limit, "parts", len(parts))...)
+		for i, part := range parts {
+			if err := s.sendContent(ctx, notification, part); err != nil {
+				return fmt.Errorf("failed to send part %d of %d: %w", i+1, len(parts), err)
+			}
+		}
+		if notification.SentMetadata == nil {
+			notification.SentMetadata = make(map[string]string)
+		}
+		notification.SentMetadata["parts"] = strconv.Itoa(len(parts))
+		return nil
+	default:
+		return &ValidationError{Fields: []FieldError{{
+			Field:   "content",
+			Channel: s.channel,
+			Message: fmt.Sprintf("is %d characters, exceeding the limit of %d", n, limit),
+		}}}
+	}
+}
+
+// sendContent sends a copy of notification with content in place of its
+// own, keeping what the service records about the send.
+func (s *ContentLimitNotificationService) sendContent(ctx context.Context, notification *models.Notification, content string) error {
+	copied := *notification
+	copied.Content = content
+	err := s.service.Send(ctx, &copied)
+	notification.Metadata = copied.Metadata
+	notification.SentMetadata = copied.SentMetadata
+	notification.RecipientStatuses = copied.RecipientStatuses
+	return err
+}
+
+// truncateContent shortens content to at most limit characters, replacing
+// the tail with an ellipsis. It reports whether truncation happened.
+func truncateContent(content string, limit int) (string, bool) {
+	runes := []rune(content)
+	if len(runes) <= limit {
+		return content, false
+	}
+	return string(runes[:limit-1]) + "…", true
+}
+
+// splitContent splits content into parts of at most limit characters.
+func splitContent(content string, limit int) []string {
+	runes := []rune(content)
+	parts := make([]string, 0, (len(runes)+limit-1)/limit)
+	for len(runes) > limit {
+		parts = append(parts, string(runes[:limit]))
+		runes = runes[limit:]
+	}
+	return append(parts, string(runes))
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+)
+
+func TestContentLimitNotificationService(t *testing.T) {
+	tests := []struct {
+		name             string
+		channel          models.NotificationChannel
+		policy           config.TruncationPolicy
+		expectedErr      error
+		expectedContents []string
+	}{
+		{"Reject", models.ChannelSlack, "", &ValidationError{}, nil},
+		{"Explicit reject", models.ChannelSlack, config.TruncationReject, &ValidationError{}, nil},
+		{"Truncate", models.ChannelSlack, config.TruncationTruncate, ErrMessageTruncated, []string{"abcdefghi…"}},
+		{"Split", models.ChannelSlack, config.TruncationSplit, nil, []string{"abcdefghij", "klmnopqrst", "uvwxyz"}},
+		{"Split SMS", models.ChannelMessage, config.TruncationSplit, nil, []string{"abcdefghijklmnopqrstuvwxyz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &mock.MockNotificationService{}
+			service := NewContentLimitNotificationService(inner, tt.channel, config.ChannelLimitConfig{MaxContentLength: 10, TruncatePolicy: tt.policy})
+			notification := &models.Notification{ID: "limit-1", Content: "abcdefghijklmnopqrstuvwxyz", Recipients: []string{"ops"}}
+
+			err := service.Send(context.Background(), notification)
+			var validationErr *ValidationError
+			switch {
+			case errors.As(tt.expectedErr, &validationErr):
+				if !errors.As(err, &validationErr) || validationErr.Fields[0].Channel != tt.channel {
+					t.Errorf("Expected a content validation error, got %v", err)
+				}
+			case !errors.Is(err, tt.expectedErr):
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			sent := inner.SentNotifications()
+			contents := make([]string, len(sent))
+			for i, n := range sent {
+				contents[i] = n.Content
+			}
+			if strings.Join(contents, "|") != strings.Join(tt.expectedContents, "|") {
+				t.Errorf("Expected contents %q, got %q", tt.expectedContents, contents)
+			}
+			if notification.Content != "abcdefghijklmnopqrstuvwxyz" {
+				t.Errorf("Expected the notification to keep its content, got %q", notification.Content)
+			}
+		})
+	}
+
+	// Content within the limit is passed on unchanged
+	inner := &mock.MockNotificationService{}
+	service := NewContentLimitNotificationService(inner, models.ChannelSlack, config.ChannelLimitConfig{MaxContentLength: 10})
+	if err := service.Send(context.Background(), &models.Notification{Content: "short"}); err != nil || inner.SentNotifications()[0].Content != "short" {
+		t.Errorf("Expected short content to be sent as is, got %v", err)
+	}
+}
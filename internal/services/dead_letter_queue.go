@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeadLetter is a notification that failed its final delivery attempt.
+type DeadLetter struct {
+	Notification *models.Notification `json:"notification"`
+	LastError    string               `json:"last_error"`
+	FailedAt     time.Time            `json:"failed_at"`
+}
+
+// DeadLetterQueue stores notifications that could not be delivered. Entries
+// are keyed by notification ID, so adding the same notification again
+// replaces the previous entry.
+type DeadLetterQueue interface {
+	Add(notification *models.Notification, err error) error
+	List() ([]DeadLetter, error)
+	Remove(id string) error
+}
+
+type MemoryDeadLetterQueue struct {
+	entries map[string]DeadLetter
+	mu      sync.RWMutex
+}
+
+func NewMemoryDeadLetterQueue() *MemoryDeadLetterQueue {
+	return &MemoryDeadLetterQueue{entries: make(map[string]DeadLetter)}
+}
+
+func (q *MemoryDeadLetterQueue) Add(notification *models.Notification, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries[notification.ID] = DeadLetter{
+		Notification: notification,
+		LastError:    err.Error(),
+		FailedAt:     time.Now(),
+	}
+	return nil
+}
+
+func (q *MemoryDeadLetterQueue) List() ([]DeadLetter, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	entries := make([]DeadLetter, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FailedAt.Before(entries[j].FailedAt)
+	})
+	return entries, nil
+}
+
+func (q *MemoryDeadLetterQueue) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.entries, id)
+	return nil
+}
+
+// FileDeadLetterQueue keeps entries in memory and rewrites a JSON file on
+// every change so they survive restarts.
+type FileDeadLetterQueue struct {
+	*MemoryDeadLetterQueue
+	path string
+	// fileMu serialises writes to path.
+	fileMu sync.Mutex
+}
+
+func NewFileDeadLetterQueue(path string) (*FileDeadLetterQueue, error) {
+	q := &FileDeadLetterQueue{MemoryDeadLetterQueue: NewMemoryDeadLetterQueue(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter file: %w", err)
+	}
+
+	var entries []DeadLetter
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse dead-letter file: %w", err)
+		}
+	}
+	for _, entry := range entries {
+		q.entries[entry.Notification.ID] = entry
+	}
+	return q, nil
+}
+
+func (q *FileDeadLetterQueue) Add(notification *models.Notification, err error) error {
+	q.MemoryDeadLetterQueue.Add(notification, err)
+	return q.flush()
+}
+
+func (q *FileDeadLetterQueue) Remove(id string) error {
+	q.MemoryDeadLetterQueue.Remove(id)
+	return q.flush()
+}
+
+func (q *FileDeadLetterQueue) flush() error {
+	q.fileMu.Lock()
+	defer q.fileMu.Unlock()
+
+	entries, _ := q.List()
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letters: %w", err)
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// DeadLetterNotificationService records failed sends of the wrapped service
+// in a DeadLetterQueue. It should sit outside any retry wrapper so only the
+// final failure is recorded.
+type DeadLetterNotificationService struct {
+	service NotificationService
+	queue   DeadLetterQueue
+}
+
+func NewDeadLetterNotificationService(service NotificationService, queue DeadLetterQueue) *DeadLetterNotificationService {
+	return &DeadLetterNotificationService{service: service, queue: queue}
+}
+
+func (d *DeadLetterNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	err := d.service.Send(ctx, notification)
+	if DeliveryFailed(err) {
+		if dlqErr := d.queue.Add(notification, err); dlqErr != nil {
+			logging.FromContext(ctx, nil).Error("Error writing notification to dead-letter queue", logging.NotificationAttrs(notification, "error", dlqErr)...)
+		}
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeadLetterNotificationServiceRecordsFailures(t *testing.T) {
+	queue := NewMemoryDeadLetterQueue()
+	failing := &mock.MockNotificationService{}
+	failing.FailNext(errors.New("boom"))
+	service := NewDeadLetterNotificationService(failing, queue)
+
+	notification := &models.Notification{ID: "dlq-1", Channel: models.ChannelSlack}
+	if err := service.Send(context.Background(), notification); err == nil {
+		t.Fatal("Expected error from failing service")
+	}
+	// The second call succeeds and must not add another entry
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, _ := queue.List()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(entries))
+	}
+	if entries[0].Notification.ID != "dlq-1" || entries[0].LastError != "boom" {
+		t.Errorf("Unexpected dead letter: %+v", entries[0])
+	}
+}
+
+func TestFileDeadLetterQueuePersistsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.json")
+
+	queue, err := NewFileDeadLetterQueue(path)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	queue.Add(&models.Notification{ID: "a", Title: "First"}, errors.New("timeout"))
+	queue.Add(&models.Notification{ID: "b", Title: "Second"}, errors.New("refused"))
+	queue.Remove("a")
+
+	reloaded, err := NewFileDeadLetterQueue(path)
+	if err != nil {
+		t.Fatalf("Failed to reload queue: %v", err)
+	}
+	entries, _ := reloaded.List()
+	if len(entries) != 1 || entries[0].Notification.ID != "b" || entries[0].LastError != "refused" {
+		t.Errorf("Unexpected entries after reload: %+v", entries)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidDeliveryReceipt is returned for receipts that do not apply to the
+// notification they name.
+var ErrInvalidDeliveryReceipt = errors.New("invalid delivery receipt")
+
+// DeliveryReceipt reports what happened to a notification after it left the
+// service. Status is delivered, read or failed.
+type DeliveryReceipt struct {
+	TenantID       string
+	NotificationID string
+	Channel        models.NotificationChannel
+	Status         models.NotificationStatus
+	At             time.Time
+	Reason         string
+}
+
+// DeliveryReceiptService applies delivery receipts from channel providers to
+// stored notifications.
+type DeliveryReceiptService struct {
+	repository repository.NotificationRepository
+}
+
+func NewDeliveryReceiptService(repo repository.NotificationRepository) *DeliveryReceiptService {
+	return &DeliveryReceiptService{repository: repo}
+}
+
+// Record applies receipt and returns the updated notification. Receipts never
+// move a notification backwards, so a late delivered receipt does not undo a
+// read one. It returns repository.ErrNotFound for unknown notifications.
+func (s *DeliveryReceiptService) Record(ctx context.Context, receipt DeliveryReceipt) (*models.Notification, error) {
+	notification, err := s.repository.GetByID(ctx, receipt.TenantID, receipt.NotificationID)
+	if err != nil {
+		return nil, err
+	}
+	if notification.Channel != receipt.Channel && !hasChannel(notification.Channels, receipt.Channel) {
+		return nil, fmt.Errorf("%w: notification %s was not sent on %s", ErrInvalidDeliveryReceipt, notification.ID, receipt.Channel)
+	}
+	switch notification.Status {
+	case models.StatusPending, models.StatusCancelled, models.StatusSuppressed, models.StatusExpired, models.StatusMerged:
+		return nil, fmt.Errorf("%w: notification %s has not been sent", ErrInvalidDeliveryReceipt, notification.ID)
+	}
+
+	at := receipt.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	switch receipt.Status {
+	case models.StatusDelivered:
+		if notification.DeliveredAt == nil {
+			notification.DeliveredAt = &at
+		}
+		if notification.Status != models.StatusRead {
+			notification.Status = models.StatusDelivered
+		}
+	case models.StatusRead:
+		// Reading implies delivery even when the provider never reported it
+		if notification.DeliveredAt == nil {
+			notification.DeliveredAt = &at
+		}
+		if notification.ReadAt == nil {
+			notification.ReadAt = &at
+		}
+		notification.Status = models.StatusRead
+	case models.StatusFailed:
+		notification.Status = models.StatusFailed
+		notification.FailureReason = receipt.Reason
+		if notification.FailureReason == "" {
+			notification.FailureReason = "delivery failed on " + string(receipt.Channel)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidDeliveryReceipt, receipt.Status)
+	}
+
+	update := repository.StatusUpdate{
+		Status:        notification.Status,
+		FailureReason: notification.FailureReason,
+		DeliveredAt:   notification.DeliveredAt,
+		ReadAt:        notification.ReadAt,
+	}
+	if err := s.repository.UpdateStatus(ctx, notification.TenantID, notification.ID, update); err != nil {
+		return nil, fmt.Errorf("failed to record delivery receipt: %w", err)
+	}
+	return notification, nil
+}
+
+// RecordOpen records that the recipient of a notification sent on channel
+// opened it: the notification is marked read and its first open is kept as
+// OpenedAt.
+func (s *DeliveryReceiptService) RecordOpen(ctx context.Context, tenantID, id string, channel models.NotificationChannel) error {
+	at := time.Now().UTC()
+	receipt := DeliveryReceipt{TenantID: tenantID, NotificationID: id, Channel: channel, Status: models.StatusRead, At: at}
+	if _, err := s.Record(ctx, receipt); err != nil {
+		return err
+	}
+	return s.repository.RecordOpen(ctx, tenantID, id, at)
+}
+
+func hasChannel(channels []models.NotificationChannel, channel models.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// SignTwilioRequest returns the X-Twilio-Signature Twilio sends with a POST
+// of params to requestURL: the base64 HMAC-SHA1 of the URL followed by each
+// parameter's name and value, sorted by name.
+func SignTwilioRequest(authToken, requestURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var data strings.Builder
+	data.WriteString(requestURL)
+	for _, key := range keys {
+		values := append([]string(nil), params[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyTwilioSignature reports whether signature, the X-Twilio-Signature
+// header, matches a POST of params to requestURL for authToken.
+func VerifyTwilioSignature(authToken, requestURL string, params url.Values, signature string) bool {
+	if authToken == "" || signature == "" {
+		return false
+	}
+	expected := SignTwilioRequest(authToken, requestURL, params)
+	return hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature)))
+}
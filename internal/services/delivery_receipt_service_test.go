@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestDeliveryReceiptService(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	receipts := NewDeliveryReceiptService(repo)
+
+	repo.Save(ctx, &models.Notification{ID: "sent", Channel: models.ChannelEmail, Status: models.StatusSent})
+	repo.Save(ctx, &models.Notification{ID: "pending", Channel: models.ChannelEmail, Status: models.StatusPending})
+
+	deliveredAt := time.Now().Add(-time.Minute)
+	notification, err := receipts.Record(ctx, DeliveryReceipt{NotificationID: "sent", Channel: models.ChannelEmail, Status: models.StatusDelivered, At: deliveredAt})
+	if err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	if notification.Status != models.StatusDelivered || notification.DeliveredAt == nil || !notification.DeliveredAt.Equal(deliveredAt) {
+		t.Errorf("Expected delivered at %v, got %s at %v", deliveredAt, notification.Status, notification.DeliveredAt)
+	}
+
+	if _, err := receipts.Record(ctx, DeliveryReceipt{NotificationID: "sent", Channel: models.ChannelEmail, Status: models.StatusRead}); err != nil {
+		t.Fatalf("Failed to record read: %v", err)
+	}
+	// A late delivered receipt must not undo the read
+	if _, err := receipts.Record(ctx, DeliveryReceipt{NotificationID: "sent", Channel: models.ChannelEmail, Status: models.StatusDelivered}); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	stored, _ := repo.GetByID(ctx, "", "sent")
+	if stored.Status != models.StatusRead || stored.ReadAt == nil || !stored.DeliveredAt.Equal(deliveredAt) {
+		t.Errorf("Expected stored notification to stay read, got %s (read at %v, delivered at %v)", stored.Status, stored.ReadAt, stored.DeliveredAt)
+	}
+
+	tests := []struct {
+		name        string
+		receipt     DeliveryReceipt
+		expectedErr error
+	}{
+		{"Unknown notification", DeliveryReceipt{NotificationID: "missing", Channel: models.ChannelEmail, Status: models.StatusDelivered}, repository.ErrNotFound},
+		{"Wrong channel", DeliveryReceipt{NotificationID: "sent", Channel: models.ChannelSlack, Status: models.StatusDelivered}, ErrInvalidDeliveryReceipt},
+		{"Not sent yet", DeliveryReceipt{NotificationID: "pending", Channel: models.ChannelEmail, Status: models.StatusDelivered}, ErrInvalidDeliveryReceipt},
+		{"Unknown status", DeliveryReceipt{NotificationID: "sent", Channel: models.ChannelEmail, Status: models.StatusCancelled}, ErrInvalidDeliveryReceipt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := receipts.Record(ctx, tt.receipt); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultDigestWindow is how long notifications are collected before a
+	// digest is sent.
+	DefaultDigestWindow = time.Hour
+	// DefaultDigestMaxSize is how many notifications a digest holds before it
+	// is sent early.
+	DefaultDigestMaxSize = 50
+)
+
+// DigestService collects notifications for each recipient and sends them as
+// a single summary once the window has passed or the digest is full.
+// Notifications are grouped by tenant, digest key, channel and recipient.
+type DigestService struct {
+	sender     NotificationService
+	repository repository.NotificationRepository
+	window     time.Duration
+	maxSize    int
+	logger     logging.Logger
+	audit      AuditLogger
+
+	digests map[string]*digest
+	mu      sync.Mutex
+}
+
+// digest is a batch of notifications waiting to be summarised for one
+// recipient.
+type digest struct {
+	tenantID      string
+	key           string
+	channel       models.NotificationChannel
+	recipient     string
+	notifications []*models.Notification
+	timer         *time.Timer
+}
+
+// NewDigestService creates a digest service that sends summaries through
+// sender and records the outcome of each collected notification in repo. A
+// nil repo disables persistence; a non-positive window or maxSize uses the
+// defaults.
+func NewDigestService(sender NotificationService, repo repository.NotificationRepository, window time.Duration, maxSize int) *DigestService {
+	if window <= 0 {
+		window = DefaultDigestWindow
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultDigestMaxSize
+	}
+	return &DigestService{
+		sender:     sender,
+		repository: repo,
+		window:     window,
+		maxSize:    maxSize,
+		logger:     logging.Default(),
+		digests:    make(map[string]*digest),
+	}
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (d *DigestService) WithLogger(logger logging.Logger) {
+	d.logger = logger
+}
+
+// WithAuditLogger records whether each collected notification was sent or
+// failed when its digest goes out.
+func (d *DigestService) WithAuditLogger(audit AuditLogger) {
+	d.audit = audit
+}
+
+// Add queues notification for the digest named key of each of its
+// recipients. The notification must target a single channel.
+func (d *DigestService) Add(notification *models.Notification, key string) error {
+	if key == "" {
+		return fmt.Errorf("digest key is required")
+	}
+	if len(notification.Channels) > 0 {
+		return fmt.Errorf("digests support a single channel")
+	}
+
+	var full []*digest
+	d.mu.Lock()
+	for _, recipient := range notification.Recipients {
+		id := strings.Join([]string{notification.TenantID, key, string(notification.Channel), recipient}, "/")
+		batch, ok := d.digests[id]
+		if !ok {
+			batch = &digest{
+				tenantID:  notification.TenantID,
+				key:       key,
+				channel:   notification.Channel,
+				recipient: recipient,
+			}
+			batch.timer = time.AfterFunc(d.window, func() { d.flush(id) })
+			d.digests[id] = batch
+		}
+		batch.notifications = append(batch.notifications, notification)
+		if len(batch.notifications) >= d.maxSize {
+			batch.timer.Stop()
+			delete(d.digests, id)
+			full = append(full, batch)
+		}
+	}
+	d.mu.Unlock()
+
+	d.logger.Info("Added notification to digest", logging.NotificationAttrs(notification, "digest_key", key)...)
+	for _, batch := range full {
+		d.send(batch)
+	}
+	return nil
+}
+
+// Pending returns the number of digests waiting to be sent.
+func (d *DigestService) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.digests)
+}
+
+// Flush sends every pending digest immediately, e.g. before shutdown.
+func (d *DigestService) Flush() {
+	d.mu.Lock()
+	batches := make([]*digest, 0, len(d.digests))
+	for id, batch := range d.digests {
+		batch.timer.Stop()
+		delete(d.digests, id)
+		batches = append(batches, batch)
+	}
+	d.mu.Unlock()
+
+	for _, batch := range batches {
+		d.send(batch)
+	}
+}
+
+// flush sends the digest with the given id if its window has passed before
+// it filled up.
+func (d *DigestService) flush(id string) {
+	d.mu.Lock()
+	batch, ok := d.digests[id]
+	delete(d.digests, id)
+	d.mu.Unlock()
+
+	if ok {
+		d.send(batch)
+	}
+}
+
+// send delivers batch as one summary notification and records the outcome on
+// every notification it contains.
+func (d *DigestService) send(batch *digest) {
+	summary := &models.Notification{
+		ID:         uuid.New().String(),
+		TenantID:   batch.tenantID,
+		Title:      fmt.Sprintf("%s: %d notifications", batch.key, len(batch.notifications)),
+		Channel:    batch.channel,
+		Recipients: []string{batch.recipient},
+		CreatedAt:  time.Now(),
+		Status:     models.StatusPending,
+		Priority:   models.PriorityNormal,
+		Metadata:   map[string]string{"digest_key": batch.key},
+	}
+	for _, notification := range batch.notifications {
+		summary.Priority = max(summary.Priority, notification.Priority)
+	}
+	if batch.channel == models.ChannelEmail {
+		summary.Content = formatDigestTable(batch.notifications)
+		summary.Metadata[EmailContentTypeMetadataKey] = "text/html"
+	} else {
+		summary.Content = formatDigestList(batch.notifications)
+	}
+
+	update := repository.StatusUpdate{Status: models.StatusSent}
+	if err := d.sender.Send(context.Background(), summary); DeliverySuppressed(err) {
+		d.logger.Info("Suppressed digest to unsubscribed recipient", logging.NotificationAttrs(summary, "digest_key", batch.key)...)
+		update = repository.StatusUpdate{Status: models.StatusSuppressed}
+	} else if DeliveryFailed(err) {
+		d.logger.Error("Error sending digest", logging.NotificationAttrs(summary, "digest_key", batch.key, "error", err)...)
+		update = repository.StatusUpdate{Status: models.StatusFailed, FailureReason: err.Error()}
+	} else {
+		d.logger.Info("Sent digest", logging.NotificationAttrs(summary, "digest_key", batch.key, "count", len(batch.notifications))...)
+		sentAt := time.Now()
+		update.SentAt = &sentAt
+	}
+
+	for _, notification := range batch.notifications {
+		metadata := map[string]string{"digest_key": batch.key, "recipient": batch.recipient}
+		appendAudit(d.audit, d.logger, statusAuditEvent(notification, update, ActorDigest, metadata))
+	}
+
+	if d.repository == nil {
+		return
+	}
+	for _, notification := range batch.notifications {
+		if err := d.repository.UpdateStatus(context.Background(), notification.TenantID, notification.ID, update); err != nil {
+			d.logger.Error("Error updating notification status", logging.NotificationAttrs(notification, "status", update.Status, "error", err)...)
+		}
+	}
+}
+
+// formatDigestList renders the titles as a numbered list for chat and SMS
+// channels.
+func formatDigestList(notifications []*models.Notification) string {
+	var b strings.Builder
+	for i, notification := range notifications {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d. %s", i+1, notification.Title)
+	}
+	return b.String()
+}
+
+// formatDigestTable renders the notifications as an HTML table for email.
+func formatDigestTable(notifications []*models.Notification) string {
+	var b strings.Builder
+	b.WriteString("<table>\n<tr><th>#</th><th>Title</th><th>Content</th><th>Received</th></tr>\n")
+	for i, notification := range notifications {
+		fmt.Fprintf(&b, "<tr><td>%d</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			i+1, html.EscapeString(notification.Title), html.EscapeString(notification.Content),
+			notification.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
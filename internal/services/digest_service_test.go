@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func saveDigestNotification(t *testing.T, repo repository.NotificationRepository, id, title string, channel models.NotificationChannel, recipients ...string) *models.Notification {
+	t.Helper()
+	notification := &models.Notification{
+		ID:         id,
+		Title:      title,
+		Content:    title + " details",
+		Channel:    channel,
+		Recipients: recipients,
+		CreatedAt:  time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC),
+		Status:     models.StatusPending,
+	}
+	if err := repo.Save(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to save notification: %v", err)
+	}
+	return notification
+}
+
+func TestDigestServiceFlushesAfterWindow(t *testing.T) {
+	recorder := &mock.MockNotificationService{}
+	repo := repository.NewMemoryRepository()
+	digests := NewDigestService(recorder, repo, 50*time.Millisecond, 10)
+
+	for i, title := range []string{"Build passed", "Deploy started", "Deploy finished"} {
+		notification := saveDigestNotification(t, repo, "digest-"+string(rune('a'+i)), title, models.ChannelSlack, "U1", "U2")
+		if err := digests.Add(notification, "deploys"); err != nil {
+			t.Fatalf("Failed to add notification to digest: %v", err)
+		}
+	}
+	if digests.Pending() != 2 {
+		t.Fatalf("Expected a digest per recipient, got %d", digests.Pending())
+	}
+	if len(recorder.SentNotifications()) != 0 {
+		t.Fatal("Expected nothing to be sent before the window passes")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	summaries := recorder.SentNotifications()
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 digests, got %d", len(summaries))
+	}
+	for _, summary := range summaries {
+		if len(summary.Recipients) != 1 {
+			t.Errorf("Expected one recipient per digest, got %v", summary.Recipients)
+		}
+		if summary.Title != "deploys: 3 notifications" {
+			t.Errorf("Unexpected digest title %q", summary.Title)
+		}
+		expected := "1. Build passed\n2. Deploy started\n3. Deploy finished"
+		if summary.Content != expected {
+			t.Errorf("Expected content %q, got %q", expected, summary.Content)
+		}
+	}
+
+	stored, _ := repo.GetByID(context.Background(), "", "digest-a")
+	if stored.Status != models.StatusSent || stored.SentAt == nil {
+		t.Errorf("Expected collected notification to be marked sent, got %s", stored.Status)
+	}
+}
+
+func TestDigestServiceFlushesWhenFull(t *testing.T) {
+	recorder := &mock.MockNotificationService{}
+	repo := repository.NewMemoryRepository()
+	digests := NewDigestService(recorder, repo, time.Hour, 2)
+
+	digests.Add(saveDigestNotification(t, repo, "full-1", "First", models.ChannelMessage, "+15551234567"), "alerts")
+	if len(recorder.SentNotifications()) != 0 {
+		t.Fatal("Expected nothing to be sent before the digest is full")
+	}
+	digests.Add(saveDigestNotification(t, repo, "full-2", "Second", models.ChannelMessage, "+15551234567"), "alerts")
+	if summaries := recorder.SentNotifications(); len(summaries) != 1 || summaries[0].Content != "1. First\n2. Second" {
+		t.Fatalf("Expected a full digest to be sent early, got %+v", summaries)
+	}
+	if digests.Pending() != 0 {
+		t.Errorf("Expected no pending digests, got %d", digests.Pending())
+	}
+
+	// Different keys and channels are collected separately
+	digests.Add(saveDigestNotification(t, repo, "full-3", "Third", models.ChannelMessage, "+15551234567"), "billing")
+	digests.Add(saveDigestNotification(t, repo, "full-4", "Fourth", models.ChannelSlack, "+15551234567"), "alerts")
+	if digests.Pending() != 2 {
+		t.Errorf("Expected 2 pending digests, got %d", digests.Pending())
+	}
+	digests.Flush()
+	if len(recorder.SentNotifications()) != 3 || digests.Pending() != 0 {
+		t.Errorf("Expected flush to send every pending digest, got %d sent and %d pending", len(recorder.SentNotifications()), digests.Pending())
+	}
+}
+
+func TestDigestServiceEmailTable(t *testing.T) {
+	recorder := &mock.MockNotificationService{}
+	repo := repository.NewMemoryRepository()
+	digests := NewDigestService(recorder, repo, time.Hour, 10)
+
+	digests.Add(saveDigestNotification(t, repo, "email-1", "Invoice <paid>", models.ChannelEmail, "ana@example.com"), "billing")
+	digests.Flush()
+
+	summaries := recorder.SentNotifications()
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 digest, got %d", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.Metadata[EmailContentTypeMetadataKey] != "text/html" {
+		t.Errorf("Expected an HTML email digest, got metadata %v", summary.Metadata)
+	}
+	row := "<tr><td>1</td><td>Invoice &lt;paid&gt;</td><td>Invoice &lt;paid&gt; details</td><td>2025-03-31T09:00:00Z</td></tr>"
+	if !strings.HasPrefix(summary.Content, "<table>") || !strings.Contains(summary.Content, row) {
+		t.Errorf("Expected an HTML table with row %q, got %q", row, summary.Content)
+	}
+	if text := htmlToText(summary.Content); !strings.Contains(text, "1 Invoice <paid> Invoice <paid> details") {
+		t.Errorf("Expected a readable plain-text fallback, got %q", text)
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// discordMaxContentLength is the maximum message length Discord accepts.
+const discordMaxContentLength = 2000
+
+// DiscordNotificationService posts embeds to Discord channels using a bot
+// token. Each recipient is a Discord channel ID. When BotToken is empty the
+// notification is only printed to stdout. Content over Discord's limit is
+// truncated by the factory's channel limits rather than by the service.
+type DiscordNotificationService struct {
+	APIURL   string
+	BotToken string
+	Client   *http.Client
+	Logger   logging.Logger
+}
+
+func NewDiscordNotificationService(cfg *config.Config) *DiscordNotificationService {
+	return &DiscordNotificationService{
+		APIURL:   cfg.DiscordAPIURL,
+		BotToken: cfg.DiscordBotToken,
+		Client:   &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+func (d *DiscordNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if d.BotToken == "" {
+		logDryRun(ctx, d.Logger, notification)
+		return nil
+	}
+
+	message := discordMessage{Embeds: []discordEmbed{{Title: notification.Title, Description: notification.Content}}}
+	headers := map[string]string{"Authorization": "Bot " + d.BotToken}
+
+	for _, channelID := range notification.Recipients {
+		url := fmt.Sprintf("%s/channels/%s/messages", strings.TrimRight(d.APIURL, "/"), channelID)
+		err := postJSON(ctx, d.Client, "discord", url, headers, message, nil)
+		notification.RecordRecipient(channelID, err)
+		if err != nil {
+			return fmt.Errorf("failed to send discord message to channel %s: %w", channelID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestDiscordNotificationService(t *testing.T) {
+	var paths []string
+	var received discordMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if got := r.Header.Get("Authorization"); got != "Bot secret" {
+			t.Errorf("Expected bot authorization, got %q", got)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	service := &DiscordNotificationService{APIURL: server.URL, BotToken: "secret", Client: server.Client()}
+	notification := &models.Notification{
+		Title:      "Release",
+		Content:    "v1.2.0 is out",
+		Recipients: []string{"111", "222"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send Discord notification: %v", err)
+	}
+	if strings.Join(paths, ",") != "/channels/111/messages,/channels/222/messages" {
+		t.Errorf("Unexpected request paths: %v", paths)
+	}
+	if received.Embeds[0].Title != "Release" || received.Embeds[0].Description != "v1.2.0 is out" {
+		t.Errorf("Unexpected embed: %+v", received.Embeds[0])
+	}
+}
+
+func TestDiscordNotificationServiceTruncatesLongContent(t *testing.T) {
+	var received discordMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	// Discord's default channel limit truncates long content
+	factory := NewNotificationServiceFactory(&config.Config{DiscordAPIURL: server.URL, DiscordBotToken: "secret", HTTPTimeout: time.Second})
+	service, _ := factory.GetService(models.ChannelDiscord)
+	notification := &models.Notification{
+		Title:      "Long",
+		Content:    strings.Repeat("a", discordMaxContentLength+10),
+		Recipients: []string{"111"},
+	}
+
+	err := service.Send(context.Background(), notification)
+	if !errors.Is(err, ErrMessageTruncated) {
+		t.Fatalf("Expected ErrMessageTruncated, got %v", err)
+	}
+	description := received.Embeds[0].Description
+	if utf8.RuneCountInString(description) != discordMaxContentLength || !strings.HasSuffix(description, "…") {
+		t.Errorf("Expected content truncated to %d characters with ellipsis", discordMaxContentLength)
+	}
+}
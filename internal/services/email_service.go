@@ -0,0 +1,588 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SMTPTLSMode string
+
+const (
+	SMTPTLSNone     SMTPTLSMode = "none"
+	SMTPStartTLS    SMTPTLSMode = "starttls"
+	SMTPImplicitTLS SMTPTLSMode = "tls"
+)
+
+// EmailContentTypeMetadataKey names the notification metadata entry that,
+// set to "text/html", sends Content as HTML with a plain-text fallback.
+const EmailContentTypeMetadataKey = "content_type"
+
+// EmailCCMetadataKey and EmailBCCMetadataKey name the notification metadata
+// entries holding comma-separated addresses to copy and blind copy.
+const (
+	EmailCCMetadataKey  = "cc"
+	EmailBCCMetadataKey = "bcc"
+)
+
+// EmailReplyToMetadataKey names the notification metadata entry holding the
+// address replies should go to instead of the sender.
+const EmailReplyToMetadataKey = "reply_to"
+
+// EmailNotificationService delivers notifications over SMTP. When Host is
+// empty the notification is only printed to stdout. Setting
+// Metadata["html_template"] sends a multipart HTML email rendered by Templates.
+// Attachments are sent as parts of a multipart/mixed message. Recipients are
+// the To addresses; Metadata["cc"] and Metadata["bcc"] add copies and
+// Metadata["reply_to"] sets the Reply-To header. With Unsubscribe set, every
+// email ends with an unsubscribe link; a SuppressionNotificationService in
+// front drops the addresses that have used it.
+type EmailNotificationService struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	FromAddress string
+	TLSMode     SMTPTLSMode
+	Timeout     time.Duration
+	TLSConfig   *tls.Config
+	Templates   *TemplateService
+	Logger      logging.Logger
+	// TrackingBaseURL, when set, adds an open-tracking pixel loaded from
+	// {TrackingBaseURL}/track/open/{id} to HTML emails, and routes their
+	// links to ClickTrackingHosts through {TrackingBaseURL}/track/click/{id}.
+	// Notifications of a tenant add it in a tenant query parameter.
+	TrackingBaseURL    string
+	ClickTrackingHosts []string
+	Unsubscribe        *UnsubscribeService
+	// Outbox, when set, queues emails of stored notifications for an
+	// OutboxWorker to deliver instead of sending them straight away.
+	Outbox repository.EmailOutbox
+}
+
+func NewEmailNotificationService(cfg *config.Config) *EmailNotificationService {
+	return &EmailNotificationService{
+		Host:        cfg.SMTPHost,
+		Port:        cfg.SMTPPort,
+		Username:    cfg.SMTPUsername,
+		Password:    cfg.SMTPPassword,
+		FromAddress: cfg.SMTPFrom,
+		TLSMode:     SMTPTLSMode(cfg.SMTPTLSMode),
+		Timeout:     cfg.HTTPTimeout,
+
+		TrackingBaseURL:    cfg.TrackingBaseURL,
+		ClickTrackingHosts: cfg.ClickTrackingAllowedHosts,
+	}
+}
+
+// Send records the outcome for each envelope recipient in the
+// notification's RecipientStatuses, unless the email is queued in the outbox.
+func (e *EmailNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	cc, bcc, err := emailCopyRecipients(notification)
+	if err != nil {
+		return err
+	}
+	message, err := e.buildMessage(ctx, notification, cc)
+	if err != nil {
+		return err
+	}
+
+	if e.Host == "" {
+		logDryRun(ctx, e.Logger, notification)
+		return nil
+	}
+
+	// Blind copies are only named in the envelope, never in the headers
+	recipients := slices.Clone(notification.Recipients)
+	for _, address := range append(cc, bcc...) {
+		recipients = append(recipients, address.Address)
+	}
+	email := &models.OutboxEmail{
+		NotificationID: notification.ID,
+		TenantID:       notification.TenantID,
+		Recipients:     recipients,
+		Message:        message,
+		CreatedAt:      time.Now(),
+	}
+	if e.Outbox != nil {
+		sentAt := time.Now()
+		err := e.Outbox.EnqueueEmail(ctx, email, repository.StatusUpdate{Status: models.StatusSent, SentAt: &sentAt})
+		if !errors.Is(err, repository.ErrNotFound) {
+			if err != nil {
+				return fmt.Errorf("failed to queue email: %w", err)
+			}
+			return nil
+		}
+		// Notifications that are not stored, such as digest summaries,
+		// are sent straight away
+	}
+	return e.deliver(ctx, email, notification.RecordRecipient)
+}
+
+// deliver sends email over SMTP, passing the outcome for each of its
+// recipients to record once the server has been reached. Recipients the
+// server rejects are skipped; the email fails only if all of them are.
+func (e *EmailNotificationService) deliver(ctx context.Context, email *models.OutboxEmail, record func(recipient string, err error)) error {
+	client, err := e.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Abort the SMTP conversation if ctx is cancelled mid-send
+	stop := context.AfterFunc(ctx, func() { client.Close() })
+	defer stop()
+
+	if e.TLSMode == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", e.Host)
+		}
+		if err := client.StartTLS(e.tlsConfig()); err != nil {
+			return fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(e.FromAddress); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	var accepted []string
+	var rcptErr error
+	for _, recipient := range email.Recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			rcptErr = fmt.Errorf("smtp RCPT TO %s failed: %w", recipient, err)
+			record(recipient, rcptErr)
+			continue
+		}
+		accepted = append(accepted, recipient)
+	}
+	if len(accepted) == 0 && rcptErr != nil {
+		return rcptErr
+	}
+
+	err = writeData(client, email.Message)
+	for _, recipient := range accepted {
+		record(recipient, err)
+	}
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// writeData sends message as the body of the email.
+func writeData(client *smtp.Client, message []byte) error {
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to write email body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func (e *EmailNotificationService) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	dialer := &net.Dialer{Timeout: e.Timeout}
+
+	var conn net.Conn
+	var err error
+	if e.TLSMode == SMTPImplicitTLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: e.tlsConfig()}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server %s: %w", addr, err)
+	}
+
+	var deadline time.Time
+	if e.Timeout > 0 {
+		deadline = time.Now().Add(e.Timeout)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+	if !deadline.IsZero() {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	return client, nil
+}
+
+func (e *EmailNotificationService) tlsConfig() *tls.Config {
+	if e.TLSConfig != nil {
+		return e.TLSConfig
+	}
+	return &tls.Config{ServerName: e.Host}
+}
+
+// buildMessage renders the HTML template named in metadata when there is one
+// and falls back to a plain-text message otherwise. Attachments wrap the body
+// in a multipart/mixed message.
+func (e *EmailNotificationService) buildMessage(ctx context.Context, notification *models.Notification, cc []*mail.Address) ([]byte, error) {
+	contentType, body, err := e.buildBody(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+	if len(notification.Attachments) > 0 {
+		contentType, body, err = buildMixedBody(contentType, body, notification.Attachments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var replyTo *mail.Address
+	if value := notification.Metadata[EmailReplyToMetadataKey]; value != "" {
+		replyTo, err = mail.ParseAddress(value)
+		if err != nil {
+			logging.FromContext(ctx, e.Logger).Warn("Ignoring malformed reply_to address",
+				logging.NotificationAttrs(notification, "error", err)...)
+		}
+	}
+
+	var b bytes.Buffer
+	writeEmailHeaders(&b, e.from(notification), notification, cc, replyTo)
+	// Clients offer List-Unsubscribe as a button, which can only act for
+	// one address
+	if links := e.unsubscribeLinks(notification); len(links) == 1 {
+		fmt.Fprintf(&b, "List-Unsubscribe: <%s>\r\n", links[0].url)
+	}
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+	b.WriteString("\r\n")
+	b.Write(body)
+	return b.Bytes(), nil
+}
+
+// buildBody returns the content type and body of the message text.
+func (e *EmailNotificationService) buildBody(ctx context.Context, notification *models.Notification) (string, []byte, error) {
+	textBody, htmlBody, err := e.renderBody(ctx, notification)
+	if err != nil {
+		return "", nil, err
+	}
+	if htmlBody == "" {
+		return "text/plain; charset=UTF-8", []byte(textBody + "\r\n"), nil
+	}
+	return buildAlternativeBody(htmlBody, textBody)
+}
+
+// renderBody returns the plain-text message text and, for HTML emails, its
+// HTML version, with tracking and unsubscribe links added. htmlBody is empty
+// for plain-text emails.
+func (e *EmailNotificationService) renderBody(ctx context.Context, notification *models.Notification) (textBody, htmlBody string, err error) {
+	links := e.unsubscribeLinks(notification)
+	name := notification.Metadata["html_template"]
+	if name == "" {
+		if notification.Metadata[EmailContentTypeMetadataKey] == "text/html" {
+			htmlBody := addUnsubscribeHTML(e.addTracking(notification.Content, notification), links)
+			return addUnsubscribeText(htmlToText(notification.Content), links), htmlBody, nil
+		}
+		return addUnsubscribeText(notification.Content, links), "", nil
+	}
+	if e.Templates == nil {
+		return "", "", fmt.Errorf("html_template %s requested but no template service is configured", name)
+	}
+
+	htmlBody, textBody, err = e.Templates.RenderEmail(ctx, name, notification)
+	if err != nil {
+		return "", "", err
+	}
+	return addUnsubscribeText(textBody, links), addUnsubscribeHTML(e.addTracking(htmlBody, notification), links), nil
+}
+
+// trackingURL returns {baseURL}/track/{kind}/{id} for notification with
+// query, adding the notification's tenant, if any, for the tenant-scoped
+// tracking routes.
+func trackingURL(baseURL, kind string, notification *models.Notification, query url.Values) string {
+	link := strings.TrimRight(baseURL, "/") + "/track/" + kind + "/" + url.PathEscape(notification.ID)
+	if notification.TenantID != "" {
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("tenant", notification.TenantID)
+	}
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+// addTracking rewrites the links of htmlBody to ClickTrackingHosts to record
+// clicks, and appends a 1x1 image reporting the notification's opens, inside
+// its body element if it has one. Links to other hosts are left alone, since
+// the click endpoint would refuse to redirect to them. Without
+// TrackingBaseURL or a notification ID htmlBody is returned unchanged.
+func (e *EmailNotificationService) addTracking(htmlBody string, notification *models.Notification) string {
+	if e.TrackingBaseURL == "" || notification.ID == "" {
+		return htmlBody
+	}
+	if len(e.ClickTrackingHosts) > 0 {
+		htmlBody = rewriteURLs(htmlBody, e.TrackingBaseURL, notification, func(u *url.URL) bool {
+			return allowedHost(u, e.ClickTrackingHosts)
+		})
+	}
+	src := trackingURL(e.TrackingBaseURL, "open", notification, nil)
+	pixel := `<img src="` + html.EscapeString(src) + `" width="1" height="1" alt="" style="display:none">`
+	if i := strings.LastIndex(strings.ToLower(htmlBody), "</body>"); i >= 0 {
+		return htmlBody[:i] + pixel + htmlBody[i:]
+	}
+	return htmlBody + pixel
+}
+
+// unsubscribeLink is the unsubscribe URL of one recipient.
+type unsubscribeLink struct {
+	address string
+	url     string
+}
+
+// unsubscribeLinks returns the unsubscribe link of each To recipient, or
+// nil without Unsubscribe.
+func (e *EmailNotificationService) unsubscribeLinks(notification *models.Notification) []unsubscribeLink {
+	if e.Unsubscribe == nil {
+		return nil
+	}
+	links := make([]unsubscribeLink, len(notification.Recipients))
+	for i, recipient := range notification.Recipients {
+		links[i] = unsubscribeLink{address: recipient, url: e.Unsubscribe.URL(recipient, notification.Category)}
+	}
+	return links
+}
+
+// addUnsubscribeText appends a footer with links to textBody. A message to
+// several recipients names the address each link unsubscribes.
+func addUnsubscribeText(textBody string, links []unsubscribeLink) string {
+	if len(links) == 0 {
+		return textBody
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(textBody, "\r\n"))
+	b.WriteString("\r\n\r\n--\r\n")
+	if len(links) == 1 {
+		fmt.Fprintf(&b, "Unsubscribe: %s", links[0].url)
+		return b.String()
+	}
+	for i, link := range links {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		fmt.Fprintf(&b, "Unsubscribe %s: %s", link.address, link.url)
+	}
+	return b.String()
+}
+
+// addUnsubscribeHTML adds a footer with links to htmlBody, inside its body
+// element if it has one.
+func addUnsubscribeHTML(htmlBody string, links []unsubscribeLink) string {
+	if len(links) == 0 {
+		return htmlBody
+	}
+	anchors := make([]string, len(links))
+	for i, link := range links {
+		label := "Unsubscribe"
+		if len(links) > 1 {
+			label += " " + link.address
+		}
+		anchors[i] = `<a href="` + html.EscapeString(link.url) + `">` + html.EscapeString(label) + `</a>`
+	}
+	footer := `<p style="font-size:12px;color:#888888">` + strings.Join(anchors, " | ") + `</p>`
+	if i := strings.LastIndex(strings.ToLower(htmlBody), "</body>"); i >= 0 {
+		return htmlBody[:i] + footer + htmlBody[i:]
+	}
+	return htmlBody + footer
+}
+
+// from is the From header of notification's email: FromAddress, named with
+// the sender alias when there is one.
+func (e *EmailNotificationService) from(notification *models.Notification) string {
+	alias := notification.Metadata[SenderAliasMetadataKey]
+	if alias == "" {
+		return e.FromAddress
+	}
+	return (&mail.Address{Name: alias, Address: e.FromAddress}).String()
+}
+
+// writeEmailHeaders writes the message headers. There is deliberately no
+// Bcc header, so To and Cc recipients cannot see the blind copies.
+func writeEmailHeaders(b *bytes.Buffer, from string, notification *models.Notification, cc []*mail.Address, replyTo *mail.Address) {
+	fmt.Fprintf(b, "From: %s\r\n", from)
+	fmt.Fprintf(b, "To: %s\r\n", strings.Join(notification.Recipients, ", "))
+	if len(cc) > 0 {
+		addresses := make([]string, len(cc))
+		for i, address := range cc {
+			addresses[i] = address.String()
+		}
+		fmt.Fprintf(b, "Cc: %s\r\n", strings.Join(addresses, ", "))
+	}
+	if replyTo != nil {
+		fmt.Fprintf(b, "Reply-To: %s\r\n", replyTo.String())
+	}
+	fmt.Fprintf(b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Title))
+	fmt.Fprintf(b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+}
+
+// emailCopyRecipients parses the cc and bcc address lists in notification's
+// metadata.
+func emailCopyRecipients(notification *models.Notification) (cc, bcc []*mail.Address, err error) {
+	if cc, err = parseEmailAddressList(notification.Metadata[EmailCCMetadataKey]); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s addresses: %w", EmailCCMetadataKey, err)
+	}
+	if bcc, err = parseEmailAddressList(notification.Metadata[EmailBCCMetadataKey]); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s addresses: %w", EmailBCCMetadataKey, err)
+	}
+	return cc, bcc, nil
+}
+
+// parseEmailAddressList parses a comma-separated address list such as
+// "Ana <ana@example.com>, bob@example.com". An empty list is valid.
+func parseEmailAddressList(list string) ([]*mail.Address, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	return mail.ParseAddressList(list)
+}
+
+// buildAlternativeBody builds a multipart/alternative body. RFC 2046 orders
+// parts from plainest to richest, so the plain-text fallback comes first and
+// clients that render HTML pick the last part.
+func buildAlternativeBody(htmlBody, textBody string) (string, []byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", textBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to build email part: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return "", nil, fmt.Errorf("failed to encode email part: %w", err)
+		}
+		qp.Close()
+	}
+	if err := writer.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	return "multipart/alternative; boundary=" + writer.Boundary(), body.Bytes(), nil
+}
+
+// buildMixedBody builds a multipart/mixed body holding the message text
+// followed by one base64-encoded part per attachment.
+func buildMixedBody(contentType string, content []byte, attachments []models.NotificationAttachment) (string, []byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	w, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build email part: %w", err)
+	}
+	w.Write(content)
+
+	for _, attachment := range attachments {
+		attachmentType := attachment.ContentType
+		if attachmentType == "" {
+			attachmentType = "application/octet-stream"
+		}
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(attachmentType, map[string]string{"name": attachment.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to build attachment %s: %w", attachment.Filename, err)
+		}
+		if err := writeBase64Lines(w, attachment.Data); err != nil {
+			return "", nil, fmt.Errorf("failed to encode attachment %s: %w", attachment.Filename, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	return "multipart/mixed; boundary=" + writer.Boundary(), body.Bytes(), nil
+}
+
+// writeBase64Lines writes data base64-encoded in lines of 76 characters, the
+// most RFC 2045 allows.
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+var (
+	htmlBlockPattern   = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlBreakPattern   = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr)>`)
+	htmlCellPattern    = regexp.MustCompile(`(?i)</t[dh]>`)
+	htmlTagPattern     = regexp.MustCompile(`<[^>]*>`)
+	blankLinesPattern  = regexp.MustCompile(`\n{3,}`)
+	lineSpacingPattern = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToText strips markup from an HTML body for the plain-text fallback,
+// keeping line breaks for block elements and spaces between table cells.
+func htmlToText(body string) string {
+	text := htmlBlockPattern.ReplaceAllString(body, "")
+	text = htmlBreakPattern.ReplaceAllString(text, "\n")
+	text = htmlCellPattern.ReplaceAllString(text, " ")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(lineSpacingPattern.ReplaceAllString(line, " "))
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(text, "\n\n"))
+}
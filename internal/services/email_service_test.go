@@ -0,0 +1,685 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type smtpEnvelope struct {
+	From       string
+	Recipients []string
+	Data       string
+}
+
+// startFakeSMTPServer accepts a single plain-text SMTP session and reports
+// the received envelope on the returned channel.
+func startFakeSMTPServer(t *testing.T) (string, int, <-chan smtpEnvelope) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start fake SMTP server: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	envelopes := make(chan smtpEnvelope, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var env smtpEnvelope
+		reply("220 localhost fake smtp")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(line)
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				env.From = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:<REJECTED"):
+				reply("550 No such user")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				env.Recipients = append(env.Recipients, strings.Trim(line[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				env.Data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				envelopes <- env
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return host, port, envelopes
+}
+
+func TestEmailNotificationServiceSMTP(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+
+	service := &EmailNotificationService{
+		Host:        host,
+		Port:        port,
+		FromAddress: "noreply@company.com",
+		TLSMode:     SMTPTLSNone,
+		Timeout:     time.Second,
+	}
+	notification := &models.Notification{
+		ID:         "email-1",
+		Title:      "Weekly Report",
+		Content:    "Your report is ready.",
+		Channel:    models.ChannelEmail,
+		Recipients: []string{"a@example.com", "b@example.com"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	select {
+	case env := <-envelopes:
+		if env.From != "noreply@company.com" {
+			t.Errorf("Expected MAIL FROM noreply@company.com, got %q", env.From)
+		}
+		if strings.Join(env.Recipients, ",") != "a@example.com,b@example.com" {
+			t.Errorf("Unexpected recipients: %v", env.Recipients)
+		}
+		if !strings.Contains(env.Data, "Subject: Weekly Report\r\n") {
+			t.Errorf("Expected subject header in message, got %q", env.Data)
+		}
+		if !strings.Contains(env.Data, "To: a@example.com, b@example.com\r\n") {
+			t.Errorf("Expected To header in message, got %q", env.Data)
+		}
+		if !strings.Contains(env.Data, "Your report is ready.") {
+			t.Errorf("Expected content in message, got %q", env.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SMTP envelope")
+	}
+}
+
+func TestEmailNotificationServiceCopies(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+
+	service := &EmailNotificationService{
+		Host:        host,
+		Port:        port,
+		FromAddress: "noreply@company.com",
+		TLSMode:     SMTPTLSNone,
+		Timeout:     time.Second,
+	}
+	notification := &models.Notification{
+		Title:      "Weekly Report",
+		Content:    "Your report is ready.",
+		Channel:    models.ChannelEmail,
+		Recipients: []string{"a@example.com"},
+		Metadata:   map[string]string{"cc": "Ana <ana@example.com>, b@example.com", "bcc": "audit@example.com"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	select {
+	case env := <-envelopes:
+		if strings.Join(env.Recipients, ",") != "a@example.com,ana@example.com,b@example.com,audit@example.com" {
+			t.Errorf("Expected To, Cc and Bcc recipients in the envelope, got %v", env.Recipients)
+		}
+		message, err := mail.ReadMessage(strings.NewReader(env.Data))
+		if err != nil {
+			t.Fatalf("Failed to parse message: %v", err)
+		}
+		if to := message.Header.Get("To"); to != "a@example.com" {
+			t.Errorf("Expected To header a@example.com, got %q", to)
+		}
+		if cc := message.Header.Get("Cc"); cc != `"Ana" <ana@example.com>, <b@example.com>` {
+			t.Errorf("Unexpected Cc header %q", cc)
+		}
+		if strings.Contains(env.Data, "audit@example.com") {
+			t.Errorf("Expected blind copy to be left out of the message, got %q", env.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SMTP envelope")
+	}
+
+	notification.Metadata["bcc"] = "not an address"
+	if err := service.Send(context.Background(), notification); err == nil || !strings.Contains(err.Error(), "invalid bcc addresses") {
+		t.Errorf("Expected invalid bcc error, got %v", err)
+	}
+}
+
+func TestEmailNotificationServiceRecipientStatuses(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+
+	service := &EmailNotificationService{
+		Host:        host,
+		Port:        port,
+		FromAddress: "noreply@company.com",
+		TLSMode:     SMTPTLSNone,
+		Timeout:     time.Second,
+	}
+	notification := &models.Notification{
+		Title:      "Weekly Report",
+		Content:    "Your report is ready.",
+		Channel:    models.ChannelEmail,
+		Recipients: []string{"a@example.com", "rejected@example.com"},
+		Metadata:   map[string]string{"bcc": "audit@example.com"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected the email to be sent to the accepted recipients, got %v", err)
+	}
+	select {
+	case env := <-envelopes:
+		if strings.Join(env.Recipients, ",") != "a@example.com,audit@example.com" {
+			t.Errorf("Expected only the accepted recipients in the envelope, got %v", env.Recipients)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SMTP envelope")
+	}
+
+	statuses := make(map[string]models.RecipientStatus)
+	for _, status := range notification.RecipientStatuses {
+		statuses[status.Recipient] = status
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("Expected a status for each envelope recipient, got %+v", notification.RecipientStatuses)
+	}
+	for _, recipient := range []string{"a@example.com", "audit@example.com"} {
+		if status := statuses[recipient]; status.Status != models.StatusSent || status.SentAt == nil {
+			t.Errorf("Expected %s to be sent, got %+v", recipient, status)
+		}
+	}
+	if status := statuses["rejected@example.com"]; status.Status != models.StatusFailed || !strings.Contains(status.Error, "550") {
+		t.Errorf("Expected rejected@example.com to fail, got %+v", status)
+	}
+}
+
+func TestEmailNotificationServiceAllRecipientsRejected(t *testing.T) {
+	host, port, _ := startFakeSMTPServer(t)
+
+	service := &EmailNotificationService{Host: host, Port: port, FromAddress: "noreply@company.com", TLSMode: SMTPTLSNone, Timeout: time.Second}
+	notification := &models.Notification{Title: "Report", Content: "Ready", Recipients: []string{"rejected@example.com"}}
+
+	if err := service.Send(context.Background(), notification); err == nil || !strings.Contains(err.Error(), "RCPT TO rejected@example.com") {
+		t.Errorf("Expected the email to fail, got %v", err)
+	}
+	if len(notification.RecipientStatuses) != 1 || notification.RecipientStatuses[0].Status != models.StatusFailed {
+		t.Errorf("Expected the rejected recipient recorded as failed, got %+v", notification.RecipientStatuses)
+	}
+}
+
+func TestEmailNotificationServiceUnsubscribe(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+	ctx := context.Background()
+	unsubscribe := NewUnsubscribeService("s3cret", "https://notify.example.com", repository.NewMemoryRepository())
+	unsubscribe.Unsubscribe(ctx, unsubscribe.Token("b@example.com", "billing"))
+	unsubscribe.Unsubscribe(ctx, unsubscribe.Token("audit@example.com", "billing"))
+
+	service := NewSuppressionNotificationService(&EmailNotificationService{
+		Host:        host,
+		Port:        port,
+		FromAddress: "noreply@company.com",
+		TLSMode:     SMTPTLSNone,
+		Timeout:     time.Second,
+		Unsubscribe: unsubscribe,
+	})
+	service.Unsubscribe = unsubscribe
+	notification := &models.Notification{
+		ID:         "email-1",
+		Title:      "Invoice",
+		Content:    "Your invoice is ready.",
+		Channel:    models.ChannelEmail,
+		Category:   "billing",
+		Recipients: []string{"a@example.com", "B@example.com"},
+		Metadata:   map[string]string{"bcc": "audit@example.com"},
+	}
+	if err := service.Send(ctx, notification); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	select {
+	case env := <-envelopes:
+		if strings.Join(env.Recipients, ",") != "a@example.com" {
+			t.Errorf("Expected unsubscribed addresses to be skipped, got %v", env.Recipients)
+		}
+		message, err := mail.ReadMessage(strings.NewReader(env.Data))
+		if err != nil {
+			t.Fatalf("Failed to parse message: %v", err)
+		}
+		link := unsubscribe.URL("a@example.com", "billing")
+		if header := message.Header.Get("List-Unsubscribe"); header != "<"+link+">" {
+			t.Errorf("Expected List-Unsubscribe <%s>, got %q", link, header)
+		}
+		body, _ := io.ReadAll(message.Body)
+		if !strings.HasSuffix(strings.TrimSpace(string(body)), "Your invoice is ready.\r\n\r\n--\r\nUnsubscribe: "+link) {
+			t.Errorf("Expected unsubscribe footer, got %q", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SMTP envelope")
+	}
+	if len(notification.RecipientStatuses) != 1 || notification.RecipientStatuses[0].Recipient != "a@example.com" {
+		t.Errorf("Expected the outcome recorded on the notification, got %+v", notification.RecipientStatuses)
+	}
+
+	unsubscribe.Unsubscribe(ctx, unsubscribe.Token("a@example.com", "billing"))
+	err := service.Send(ctx, notification)
+	if !errors.Is(err, ErrRecipientsSuppressed) || !DeliverySuppressed(err) || DeliveryFailed(err) {
+		t.Errorf("Expected ErrRecipientsSuppressed, got %v", err)
+	}
+	if len(notification.Recipients) != 2 {
+		t.Errorf("Expected the notification's recipients to be left alone, got %v", notification.Recipients)
+	}
+}
+
+func TestUnsubscribeFooter(t *testing.T) {
+	links := []unsubscribeLink{{"a@example.com", "https://x/unsubscribe/a"}, {"b@example.com", "https://x/unsubscribe/b?c&d"}}
+	if text := addUnsubscribeText("Hi\r\n", links); text != "Hi\r\n\r\n--\r\nUnsubscribe a@example.com: https://x/unsubscribe/a\r\nUnsubscribe b@example.com: https://x/unsubscribe/b?c&d" {
+		t.Errorf("Unexpected text footer %q", text)
+	}
+	expected := `<html><body>Hi<p style="font-size:12px;color:#888888"><a href="https://x/unsubscribe/a">Unsubscribe a@example.com</a> | ` +
+		`<a href="https://x/unsubscribe/b?c&amp;d">Unsubscribe b@example.com</a></p></body></html>`
+	if body := addUnsubscribeHTML("<html><body>Hi</body></html>", links); body != expected {
+		t.Errorf("Expected HTML footer %q, got %q", expected, body)
+	}
+	if body := addUnsubscribeHTML("<p>Hi</p>", nil); body != "<p>Hi</p>" {
+		t.Errorf("Expected no footer without links, got %q", body)
+	}
+}
+
+func TestEmailNotificationServiceReplyTo(t *testing.T) {
+	service := &EmailNotificationService{FromAddress: "noreply@company.com"}
+
+	tests := []struct {
+		name     string
+		replyTo  string
+		expected string
+	}{
+		{"Address", "support@example.com", "<support@example.com>"},
+		{"Named address", "Support Team <support@example.com>", `"Support Team" <support@example.com>`},
+		{"Malformed address ignored", "support at example.com", ""},
+		{"Unset", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := &models.Notification{Title: "T", Content: "C", Recipients: []string{"a@example.com"}}
+			if tt.replyTo != "" {
+				notification.Metadata = map[string]string{"reply_to": tt.replyTo}
+			}
+			data, err := service.buildMessage(context.Background(), notification, nil)
+			if err != nil {
+				t.Fatalf("Failed to build message: %v", err)
+			}
+			message, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Failed to parse message: %v", err)
+			}
+			if replyTo := message.Header.Get("Reply-To"); replyTo != tt.expected {
+				t.Errorf("Expected Reply-To %q, got %q", tt.expected, replyTo)
+			}
+		})
+	}
+}
+
+func TestEmailNotificationServiceSenderAlias(t *testing.T) {
+	service := &EmailNotificationService{FromAddress: "noreply@company.com"}
+
+	tests := []struct {
+		name     string
+		alias    string
+		expected string
+	}{
+		{"Unset", "", "noreply@company.com"},
+		{"Alias", "Reports Bot", `"Reports Bot" <noreply@company.com>`},
+		{"Non-ASCII alias", "Relatórios", "=?utf-8?q?Relat=C3=B3rios?= <noreply@company.com>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := &models.Notification{Title: "T", Content: "C", Recipients: []string{"a@example.com"}}
+			if tt.alias != "" {
+				notification.Metadata = map[string]string{SenderAliasMetadataKey: tt.alias}
+			}
+			data, err := service.buildMessage(context.Background(), notification, nil)
+			if err != nil {
+				t.Fatalf("Failed to build message: %v", err)
+			}
+			message, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Failed to parse message: %v", err)
+			}
+			if from := message.Header.Get("From"); from != tt.expected {
+				t.Errorf("Expected From %q, got %q", tt.expected, from)
+			}
+		})
+	}
+}
+
+func TestEmailNotificationServiceTrackingPixel(t *testing.T) {
+	pixel := `<img src="https://notify.example.com/track/open/email-1" width="1" height="1" alt="" style="display:none">`
+	tests := []struct {
+		name            string
+		trackingBaseURL string
+		clickHosts      []string
+		content         string
+		expectedHTML    string
+	}{
+		{"Appended to fragment", "https://notify.example.com/", nil, "<p>Hi</p>", "<p>Hi</p>" + pixel},
+		{"Inside body", "https://notify.example.com", nil, "<html><body><p>Hi</p></BODY></html>", "<html><body><p>Hi</p>" + pixel + "</BODY></html>"},
+		{"Tracking disabled", "", []string{"shop.example.com"}, `<a href="https://shop.example.com/">Shop</a>`, `<a href="https://shop.example.com/">Shop</a>`},
+		{
+			"Links to allowed hosts rewritten", "https://notify.example.com", []string{"shop.example.com"},
+			`<a href="https://shop.example.com/sale">Sale</a> <a href="https://other.example.com/">Other</a>`,
+			`<a href="https://notify.example.com/track/click/email-1?url=https%3A%2F%2Fshop.example.com%2Fsale">Sale</a> <a href="https://other.example.com/">Other</a>` + pixel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &EmailNotificationService{FromAddress: "noreply@company.com", TrackingBaseURL: tt.trackingBaseURL, ClickTrackingHosts: tt.clickHosts}
+			notification := &models.Notification{
+				ID:         "email-1",
+				Title:      "T",
+				Content:    tt.content,
+				Recipients: []string{"a@example.com"},
+				Metadata:   map[string]string{EmailContentTypeMetadataKey: "text/html"},
+			}
+			contentType, body, err := service.buildBody(context.Background(), notification)
+			if err != nil {
+				t.Fatalf("Failed to build body: %v", err)
+			}
+			_, params, _ := mime.ParseMediaType(contentType)
+			reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+			var parts []string
+			for {
+				part, err := reader.NextPart()
+				if err != nil {
+					break
+				}
+				data, _ := io.ReadAll(part)
+				parts = append(parts, string(data))
+			}
+			if len(parts) != 2 {
+				t.Fatalf("Expected text and HTML parts, got %d", len(parts))
+			}
+			if parts[1] != tt.expectedHTML {
+				t.Errorf("Expected HTML %q, got %q", tt.expectedHTML, parts[1])
+			}
+			if strings.Contains(parts[0], "track/open") {
+				t.Errorf("Expected no pixel in the plain-text part, got %q", parts[0])
+			}
+		})
+	}
+}
+
+func TestTrackingURL(t *testing.T) {
+	notification := &models.Notification{ID: "email 1"}
+	if link := trackingURL("https://notify.example.com/", "open", notification, nil); link != "https://notify.example.com/track/open/email%201" {
+		t.Errorf("Unexpected link %q", link)
+	}
+	notification.TenantID = "acme"
+	if link := trackingURL("https://notify.example.com", "open", notification, nil); link != "https://notify.example.com/track/open/email%201?tenant=acme" {
+		t.Errorf("Expected the tenant in the link, got %q", link)
+	}
+	content := rewriteURLs(`<a href="https://shop.example.com/">Shop</a>`, "https://notify.example.com", notification, nil)
+	if expected := `<a href="https://notify.example.com/track/click/email%201?tenant=acme&amp;url=https%3A%2F%2Fshop.example.com%2F">Shop</a>`; content != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+}
+
+func TestEmailNotificationServiceRequiresStartTLS(t *testing.T) {
+	host, port, _ := startFakeSMTPServer(t)
+
+	service := &EmailNotificationService{
+		Host:        host,
+		Port:        port,
+		FromAddress: "noreply@company.com",
+		TLSMode:     SMTPStartTLS,
+		Timeout:     time.Second,
+	}
+
+	err := service.Send(context.Background(), &models.Notification{Title: "T", Content: "C", Recipients: []string{"a@example.com"}})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Expected STARTTLS error, got %v", err)
+	}
+}
+
+func TestEmailNotificationServiceHTMLTemplate(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+
+	templates := NewTemplateService(repository.NewMemoryTemplateRepository())
+	err := templates.Register(context.Background(), &models.NotificationTemplate{
+		Name:        "report",
+		HTMLContent: `<h1>{{.Title}}</h1><p>{{.Content}}</p><p>Team: {{index .Metadata "team"}}</p>`,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register template: %v", err)
+	}
+
+	service := &EmailNotificationService{
+		Host:        host,
+		Port:        port,
+		FromAddress: "noreply@company.com",
+		TLSMode:     SMTPTLSNone,
+		Timeout:     time.Second,
+		Templates:   templates,
+	}
+	notification := &models.Notification{
+		ID:         "email-2",
+		Title:      "Weekly Report",
+		Content:    "Revenue is up & costs <down>.",
+		Channel:    models.ChannelEmail,
+		Recipients: []string{"a@example.com"},
+		Metadata:   map[string]string{"html_template": "report", "team": "ops"},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	var env smtpEnvelope
+	select {
+	case env = <-envelopes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SMTP envelope")
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(env.Data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q", mediaType)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	expected := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", "Weekly Report\r\nRevenue is up & costs <down>.\r\nTeam: ops"},
+		{"text/html; charset=UTF-8", "<h1>Weekly Report</h1><p>Revenue is up &amp; costs &lt;down&gt;.</p><p>Team: ops</p>"},
+	}
+	for _, want := range expected {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Expected %s part: %v", want.contentType, err)
+		}
+		if part.Header.Get("Content-Type") != want.contentType {
+			t.Errorf("Expected content type %q, got %q", want.contentType, part.Header.Get("Content-Type"))
+		}
+		// multipart.Reader decodes quoted-printable parts transparently
+		body, _ := io.ReadAll(part)
+		if string(body) != want.body {
+			t.Errorf("Expected %s body %q, got %q", want.contentType, want.body, body)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("Expected exactly two parts, got %v", err)
+	}
+}
+
+func TestEmailNotificationServiceAttachments(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+
+	service := &EmailNotificationService{
+		Host:        host,
+		Port:        port,
+		FromAddress: "noreply@company.com",
+		TLSMode:     SMTPTLSNone,
+		Timeout:     time.Second,
+	}
+	report := bytes.Repeat([]byte{0x00, 0xff, 'P', 'D', 'F'}, 100)
+	notification := &models.Notification{
+		ID:         "email-3",
+		Title:      "Invoice",
+		Content:    "Your invoice is attached.",
+		Channel:    models.ChannelEmail,
+		Recipients: []string{"a@example.com"},
+		Attachments: []models.NotificationAttachment{
+			{Filename: "invoice.pdf", ContentType: "application/pdf", Data: report},
+			{Filename: "notes.txt", Data: []byte("Paid in full")},
+		},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	var env smtpEnvelope
+	select {
+	case env = <-envelopes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SMTP envelope")
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(env.Data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		t.Fatalf("Expected multipart/mixed, got %q", mediaType)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("Expected body part: %v", err)
+	}
+	if body, _ := io.ReadAll(part); part.Header.Get("Content-Type") != "text/plain; charset=UTF-8" || string(body) != "Your invoice is attached.\r\n" {
+		t.Errorf("Unexpected body part %q: %q", part.Header.Get("Content-Type"), body)
+	}
+
+	expected := []struct {
+		filename    string
+		contentType string
+		data        []byte
+	}{
+		{"invoice.pdf", "application/pdf", report},
+		{"notes.txt", "application/octet-stream", []byte("Paid in full")},
+	}
+	for _, want := range expected {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Expected attachment %s: %v", want.filename, err)
+		}
+		if part.FileName() != want.filename {
+			t.Errorf("Expected filename %q, got %q", want.filename, part.FileName())
+		}
+		if contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type")); contentType != want.contentType {
+			t.Errorf("Expected content type %q, got %q", want.contentType, contentType)
+		}
+		data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		if err != nil {
+			t.Fatalf("Failed to decode attachment %s: %v", want.filename, err)
+		}
+		if !bytes.Equal(data, want.data) {
+			t.Errorf("Expected attachment %s to round-trip, got %q", want.filename, data)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("Expected exactly three parts, got %v", err)
+	}
+}
+
+func TestEmailNotificationServiceHTMLTemplateErrors(t *testing.T) {
+	notification := &models.Notification{
+		Title:      "Weekly Report",
+		Recipients: []string{"a@example.com"},
+		Metadata:   map[string]string{"html_template": "missing"},
+	}
+
+	service := &EmailNotificationService{}
+	if err := service.Send(context.Background(), notification); err == nil {
+		t.Error("Expected error without a template service, got nil")
+	}
+
+	service.Templates = NewTemplateService(repository.NewMemoryTemplateRepository())
+	if err := service.Send(context.Background(), notification); !errors.Is(err, repository.ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound, got %v", err)
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{"Paragraphs", "<p>Hello</p><p>World</p>", "Hello\nWorld"},
+		{"Line breaks", "One<br>Two<br/>Three", "One\nTwo\nThree"},
+		{"Entities", "Fish &amp; Chips &lt;3", "Fish & Chips <3"},
+		{"Styles and scripts", "<style>p{color:red}</style><p>Visible</p><script>alert(1)</script>", "Visible"},
+		{"Whitespace", "<div>\n   lots    of\tspace  </div>\n\n\n\n<div>end</div>", "lots of space\n\nend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToText(tt.html); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
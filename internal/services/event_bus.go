@@ -0,0 +1,117 @@
+package services
+
+import (
+	"notification-service/internal/models"
+	"sync"
+)
+
+const (
+	// eventBufferSize is how many events a subscriber may fall behind by
+	// before further events are dropped.
+	eventBufferSize = 16
+	// allEventsBufferSize is eventBufferSize for subscribers to every
+	// notification.
+	allEventsBufferSize = 256
+)
+
+// NotificationEventBus fans the events of each notification out to its
+// subscribers. It implements repository.EventPublisher.
+type NotificationEventBus interface {
+	// Publish sends event to the subscribers of its notification without
+	// blocking.
+	Publish(event *models.NotificationEvent)
+	// Subscribe returns a channel receiving the events of notification id
+	// in tenantID, and a function that unsubscribes and closes it.
+	Subscribe(tenantID, id string) (<-chan *models.NotificationEvent, func())
+	// SubscribeAll is Subscribe for the events of every notification in
+	// every tenant.
+	SubscribeAll() (<-chan *models.NotificationEvent, func())
+}
+
+// EventBus is an in-memory NotificationEventBus. Events published on one
+// instance only reach subscribers of that instance, and a subscriber that
+// falls a full buffer behind misses the rest until it catches up.
+type EventBus struct {
+	// topics maps an eventTopicKey to its *eventTopic
+	topics sync.Map
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+type eventTopicKey struct {
+	tenantID string
+	id       string
+	// all marks the topic of SubscribeAll
+	all bool
+}
+
+var allEventsKey = eventTopicKey{all: true}
+
+// eventTopic holds the subscribers of one notification, or of all of them.
+// A topic is closed once its last subscriber leaves and it is removed from
+// the bus.
+type eventTopic struct {
+	subscribers map[chan *models.NotificationEvent]struct{}
+	closed      bool
+	mu          sync.Mutex
+}
+
+func (b *EventBus) Publish(event *models.NotificationEvent) {
+	for _, key := range []eventTopicKey{{tenantID: event.TenantID, id: event.NotificationID}, allEventsKey} {
+		if value, ok := b.topics.Load(key); ok {
+			value.(*eventTopic).publish(event)
+		}
+	}
+}
+
+func (t *eventTopic) publish(event *models.NotificationEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for subscriber := range t.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+func (b *EventBus) Subscribe(tenantID, id string) (<-chan *models.NotificationEvent, func()) {
+	return b.subscribe(eventTopicKey{tenantID: tenantID, id: id}, eventBufferSize)
+}
+
+func (b *EventBus) SubscribeAll() (<-chan *models.NotificationEvent, func()) {
+	return b.subscribe(allEventsKey, allEventsBufferSize)
+}
+
+func (b *EventBus) subscribe(key eventTopicKey, size int) (<-chan *models.NotificationEvent, func()) {
+	events := make(chan *models.NotificationEvent, size)
+	for {
+		value, _ := b.topics.LoadOrStore(key, &eventTopic{subscribers: make(map[chan *models.NotificationEvent]struct{})})
+		topic := value.(*eventTopic)
+		topic.mu.Lock()
+		if topic.closed {
+			// The last subscriber left while this one joined; the topic
+			// is gone from the bus, so join a new one
+			topic.mu.Unlock()
+			continue
+		}
+		topic.subscribers[events] = struct{}{}
+		topic.mu.Unlock()
+
+		var once sync.Once
+		return events, func() {
+			once.Do(func() {
+				topic.mu.Lock()
+				defer topic.mu.Unlock()
+				delete(topic.subscribers, events)
+				close(events)
+				if len(topic.subscribers) == 0 {
+					topic.closed = true
+					b.topics.CompareAndDelete(key, topic)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"notification-service/internal/models"
+	"sync"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+
+	first, unsubscribeFirst := bus.Subscribe("tenant", "n1")
+	second, unsubscribeSecond := bus.Subscribe("tenant", "n1")
+	other, unsubscribeOther := bus.Subscribe("other", "n1")
+	defer unsubscribeOther()
+	all, unsubscribeAll := bus.SubscribeAll()
+	defer unsubscribeAll()
+
+	bus.Publish(&models.NotificationEvent{TenantID: "tenant", NotificationID: "n1", Type: models.EventStatusChanged})
+	bus.Publish(&models.NotificationEvent{TenantID: "tenant", NotificationID: "n2", Type: models.EventStatusChanged})
+	for _, events := range []<-chan *models.NotificationEvent{first, second} {
+		if event := <-events; event.NotificationID != "n1" {
+			t.Errorf("Expected the event of n1, got %+v", event)
+		}
+	}
+	select {
+	case event := <-other:
+		t.Errorf("Expected no event for another tenant, got %+v", event)
+	default:
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected both events for the subscriber to every notification, got %d", len(all))
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	if _, open := <-first; open {
+		t.Error("Expected unsubscribing to close the channel")
+	}
+	bus.Publish(&models.NotificationEvent{TenantID: "tenant", NotificationID: "n1", Type: models.EventDeleted})
+	if event := <-second; event.Type != models.EventDeleted {
+		t.Errorf("Expected the remaining subscriber to receive the event, got %+v", event)
+	}
+
+	unsubscribeSecond()
+	if _, ok := bus.topics.Load(eventTopicKey{tenantID: "tenant", id: "n1"}); ok {
+		t.Error("Expected the topic to be removed after its last subscriber left")
+	}
+}
+
+func TestEventBusSlowSubscriber(t *testing.T) {
+	bus := NewEventBus()
+	events, unsubscribe := bus.Subscribe("", "n1")
+	defer unsubscribe()
+
+	// Publishing never blocks; events beyond the buffer are dropped
+	for range eventBufferSize + 5 {
+		bus.Publish(&models.NotificationEvent{NotificationID: "n1"})
+	}
+	if len(events) != eventBufferSize {
+		t.Errorf("Expected %d buffered events, got %d", eventBufferSize, len(events))
+	}
+}
+
+func TestEventBusConcurrentSubscribers(t *testing.T) {
+	bus := NewEventBus()
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, unsubscribe := bus.Subscribe("", "n1")
+			unsubscribe()
+		}()
+		go func() {
+			defer wg.Done()
+			bus.Publish(&models.NotificationEvent{NotificationID: "n1"})
+		}()
+	}
+	wg.Wait()
+	if _, ok := bus.topics.Load(eventTopicKey{id: "n1"}); ok {
+		t.Error("Expected no topic once every subscriber left")
+	}
+}
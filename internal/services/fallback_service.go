@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// FallbackChannelsMetadataKey names the notification metadata entry holding
+// the comma-separated channels to try, in order, when the notification's
+// channel fails.
+const FallbackChannelsMetadataKey = "fallback_channels"
+
+// DeliveredViaMetadataKey names the notification metadata entry recording
+// the channel of a fallback chain that delivered the notification.
+const DeliveredViaMetadataKey = "delivered_via"
+
+// ParseFallbackChannels returns the channels in a FallbackChannelsMetadataKey
+// entry, or nil if it is empty.
+func ParseFallbackChannels(value string) []models.NotificationChannel {
+	var channels []models.NotificationChannel
+	for _, channel := range strings.Split(value, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			channels = append(channels, models.NotificationChannel(channel))
+		}
+	}
+	return channels
+}
+
+// FormatFallbackChannels joins channels into a FallbackChannelsMetadataKey
+// entry.
+func FormatFallbackChannels(channels []models.NotificationChannel) string {
+	parts := make([]string, len(channels))
+	for i, channel := range channels {
+		parts[i] = string(channel)
+	}
+	return strings.Join(parts, ",")
+}
+
+// FallbackChannel is a channel of a fallback chain and the service that
+// delivers to it.
+type FallbackChannel struct {
+	Channel models.NotificationChannel
+	Service NotificationService
+}
+
+// FallbackNotificationService sends a notification to the first of its
+// channels and, each time delivery fails, to the next one, stopping at the
+// first that delivers it. A channel's retries are exhausted before its error
+// reaches the chain, so any error that still means the notification was not
+// delivered moves on to the next channel. The channel that delivered it is
+// recorded in Metadata under DeliveredViaMetadataKey; suppressed recipients
+// are not delivered to again on another channel.
+type FallbackNotificationService struct {
+	channels []FallbackChannel
+	Logger   logging.Logger
+}
+
+func NewFallbackNotificationService(channels []FallbackChannel) *FallbackNotificationService {
+	return &FallbackNotificationService{channels: channels}
+}
+
+// Send tries each channel in turn, using Notification.ChannelRecipients
+// where a channel has its own recipients. If every channel fails, the
+// returned error wraps each of their errors. A cancelled context stops the
+// chain at the channel that was being tried.
+func (s *FallbackNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	var errs []error
+	for i, fallback := range s.channels {
+		copied := *notification
+		copied.Channel = fallback.Channel
+		copied.SentMetadata = nil
+		copied.RecipientStatuses = nil
+		copied.Metadata = maps.Clone(notification.Metadata)
+		if recipients, ok := notification.ChannelRecipients[fallback.Channel]; ok {
+			copied.Recipients = recipients
+		}
+
+		err := fallback.Service.Send(ctx, &copied)
+		notification.Metadata = copied.Metadata
+		notification.SentMetadata = copied.SentMetadata
+		notification.RecipientStatuses = append(notification.RecipientStatuses, copied.RecipientStatuses...)
+		if !DeliveryFailed(err) {
+			if !DeliverySuppressed(err) {
+				if notification.Metadata == nil {
+					notification.Metadata = make(map[string]string)
+				}
+				notification.Metadata[DeliveredViaMetadataKey] = string(fallback.Channel)
+			}
+			return err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", fallback.Channel, err))
+		if ctx.Err() != nil || i == len(s.channels)-1 {
+			break
+		}
+		logging.FromContext(ctx, s.Logger).Warn("Falling back to next channel",
+			logging.NotificationAttrs(&copied, "fallback_channel", s.channels[i+1].Channel, "error", err)...)
+	}
+	return fmt.Errorf("delivery failed on %d fallback channel(s): %w", len(errs), errors.Join(errs...))
+}
+
+// Fallback returns a FallbackNotificationService that tries channels in
+// order, each with the service GetService returns for it.
+func (f *NotificationServiceFactory) Fallback(channels []models.NotificationChannel) (*FallbackNotificationService, error) {
+	fallbacks := make([]FallbackChannel, len(channels))
+	for i, channel := range channels {
+		service, err := f.GetService(channel)
+		if err != nil {
+			return nil, err
+		}
+		fallbacks[i] = FallbackChannel{Channel: channel, Service: service}
+	}
+	return NewFallbackNotificationService(fallbacks), nil
+}
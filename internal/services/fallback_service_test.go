@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+)
+
+func TestFallbackNotificationService(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+	slack := &mock.MockNotificationService{}
+	email := &mock.MockNotificationService{}
+	sms := &mock.MockNotificationService{}
+	factory.services[models.ChannelSlack] = slack
+	factory.services[models.ChannelEmail] = email
+	factory.services[models.ChannelMessage] = sms
+
+	slack.SetError(errors.New("channel_not_found"))
+	chain, err := factory.Fallback([]models.NotificationChannel{models.ChannelSlack, models.ChannelEmail, models.ChannelMessage})
+	if err != nil {
+		t.Fatalf("Failed to build fallback chain: %v", err)
+	}
+
+	notification := &models.Notification{
+		ID:                "fallback-1",
+		Title:             "Outage",
+		Content:           "API is down",
+		Channel:           models.ChannelSlack,
+		Recipients:        []string{"U123"},
+		ChannelRecipients: map[models.NotificationChannel][]string{models.ChannelEmail: {"ops@example.com"}},
+	}
+	if err := chain.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected the email fallback to deliver, got %v", err)
+	}
+	if len(slack.SentNotifications()) != 1 || len(sms.SentNotifications()) != 0 {
+		t.Errorf("Expected slack tried and SMS skipped, got slack=%d sms=%d", len(slack.SentNotifications()), len(sms.SentNotifications()))
+	}
+	sent := email.SentNotifications()
+	if len(sent) != 1 || sent[0].Channel != models.ChannelEmail || sent[0].Recipients[0] != "ops@example.com" {
+		t.Fatalf("Expected one email to the email recipients, got %+v", sent)
+	}
+	if via := notification.Metadata[DeliveredViaMetadataKey]; via != string(models.ChannelEmail) {
+		t.Errorf("Expected delivered_via email, got %q", via)
+	}
+	if notification.Channel != models.ChannelSlack {
+		t.Errorf("Expected the notification to keep its channel, got %s", notification.Channel)
+	}
+
+	// Truncated content still counts as delivered
+	slack.SetError(nil)
+	slack.FailNext(ErrMessageTruncated)
+	truncated := &models.Notification{ID: "fallback-2", Channel: models.ChannelSlack, Recipients: []string{"U123"}}
+	if err := chain.Send(context.Background(), truncated); !errors.Is(err, ErrMessageTruncated) {
+		t.Errorf("Expected the truncation to be returned, got %v", err)
+	}
+	if via := truncated.Metadata[DeliveredViaMetadataKey]; via != string(models.ChannelSlack) {
+		t.Errorf("Expected delivered_via slack, got %q", via)
+	}
+}
+
+func TestFallbackNotificationServiceAllFail(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+	slackErr, emailErr := errors.New("channel_not_found"), errors.New("connection refused")
+	slack := &mock.MockNotificationService{}
+	slack.SetError(slackErr)
+	email := &mock.MockNotificationService{}
+	email.SetError(emailErr)
+	factory.services[models.ChannelSlack] = slack
+	factory.services[models.ChannelEmail] = email
+
+	chain, _ := factory.Fallback([]models.NotificationChannel{models.ChannelSlack, models.ChannelEmail})
+	notification := &models.Notification{ID: "fallback-3", Channel: models.ChannelSlack, Recipients: []string{"ops"}}
+	err := chain.Send(context.Background(), notification)
+	if !errors.Is(err, slackErr) || !errors.Is(err, emailErr) || !strings.Contains(err.Error(), "2 fallback channel(s)") {
+		t.Errorf("Expected both channel errors, got %v", err)
+	}
+	if _, ok := notification.Metadata[DeliveredViaMetadataKey]; ok {
+		t.Error("Expected no delivered_via when every channel fails")
+	}
+
+	if _, err := factory.Fallback([]models.NotificationChannel{models.ChannelSlack, "carrier-pigeon"}); err == nil {
+		t.Error("Expected an unknown channel to be rejected")
+	}
+}
+
+func TestFanOutNotificationServiceFallback(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+	slack := &mock.MockNotificationService{}
+	slack.SetError(errors.New("channel_not_found"))
+	email := &mock.MockNotificationService{}
+	factory.services[models.ChannelSlack] = slack
+	factory.services[models.ChannelEmail] = email
+	service := NewFanOutNotificationService(factory)
+
+	notification := &models.Notification{
+		ID:         "fallback-4",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"ops"},
+		Metadata:   map[string]string{FallbackChannelsMetadataKey: FormatFallbackChannels([]models.NotificationChannel{models.ChannelEmail})},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected the fallback to deliver, got %v", err)
+	}
+	if len(email.SentNotifications()) != 1 || notification.Metadata[DeliveredViaMetadataKey] != string(models.ChannelEmail) {
+		t.Errorf("Expected delivery via email, got %d emails and %v", len(email.SentNotifications()), notification.Metadata)
+	}
+}
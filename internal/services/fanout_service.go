@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"notification-service/internal/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FanOutError reports the channels that failed during a fan-out send.
+type FanOutError struct {
+	Errors map[models.NotificationChannel]error
+}
+
+func (e *FanOutError) Error() string {
+	channels := make([]string, 0, len(e.Errors))
+	for channel := range e.Errors {
+		channels = append(channels, string(channel))
+	}
+	sort.Strings(channels)
+
+	parts := make([]string, len(channels))
+	for i, channel := range channels {
+		parts[i] = fmt.Sprintf("%s: %v", channel, e.Errors[models.NotificationChannel(channel)])
+	}
+	return fmt.Sprintf("delivery failed on %d channel(s): %s", len(parts), strings.Join(parts, "; "))
+}
+
+func (e *FanOutError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// DeliveryFailed reports whether err means at least one channel did not
+// receive the notification. Truncated content and attachments that failed
+// to upload still count as delivered, and suppressed recipients were
+// skipped on purpose.
+func DeliveryFailed(err error) bool {
+	var fanOutErr *FanOutError
+	if errors.As(err, &fanOutErr) {
+		for _, channelErr := range fanOutErr.Errors {
+			if DeliveryFailed(channelErr) {
+				return true
+			}
+		}
+		return false
+	}
+	return err != nil && !errors.Is(err, ErrMessageTruncated) && !errors.Is(err, ErrAttachmentsNotUploaded) &&
+		!errors.Is(err, ErrRecipientsSuppressed)
+}
+
+// DeliverySuppressed reports whether err means nothing was sent because
+// every recipient has unsubscribed. A notification fanned out to several
+// channels is never entirely suppressed, since only email keeps a
+// suppression list.
+func DeliverySuppressed(err error) bool {
+	var fanOutErr *FanOutError
+	return errors.Is(err, ErrRecipientsSuppressed) && !errors.As(err, &fanOutErr)
+}
+
+// FanOutNotificationService sends a notification to every channel in
+// Notification.Channels concurrently, using Notification.ChannelRecipients
+// where a channel has its own recipients. Notifications without Channels go
+// to Notification.Channel and their error is returned unwrapped, falling back
+// to the channels in their FallbackChannelsMetadataKey entry if it fails.
+// Each notification is sent with its tenant's services.
+type FanOutNotificationService struct {
+	factory *NotificationServiceFactory
+}
+
+func NewFanOutNotificationService(factory *NotificationServiceFactory) *FanOutNotificationService {
+	return &FanOutNotificationService{factory: factory}
+}
+
+func (f *FanOutNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	factory := f.factory.ForTenant(notification.TenantID)
+	if len(notification.Channels) == 0 {
+		if fallbacks := ParseFallbackChannels(notification.Metadata[FallbackChannelsMetadataKey]); len(fallbacks) > 0 {
+			chain, err := factory.Fallback(append([]models.NotificationChannel{notification.Channel}, fallbacks...))
+			if err != nil {
+				return err
+			}
+			return chain.Send(ctx, notification)
+		}
+		service, err := factory.GetService(notification.Channel)
+		if err != nil {
+			return err
+		}
+		return service.Send(ctx, notification)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[models.NotificationChannel]error)
+	)
+	for _, channel := range notification.Channels {
+		service, err := factory.GetService(channel)
+		if err != nil {
+			mu.Lock()
+			errs[channel] = err
+			mu.Unlock()
+			continue
+		}
+
+		// Each channel gets its own copy so services can record provider
+		// metadata without racing each other.
+		copied := *notification
+		copied.Channel = channel
+		copied.Channels = nil
+		copied.ChannelRecipients = nil
+		copied.SentMetadata = nil
+		copied.RecipientStatuses = nil
+		mu.Lock()
+		copied.Metadata = maps.Clone(notification.Metadata)
+		mu.Unlock()
+		if recipients, ok := notification.ChannelRecipients[channel]; ok {
+			copied.Recipients = recipients
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := service.Send(ctx, &copied)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[channel] = err
+			}
+			for key, value := range copied.SentMetadata {
+				if notification.SentMetadata == nil {
+					notification.SentMetadata = make(map[string]string)
+				}
+				notification.SentMetadata[key] = value
+			}
+			notification.RecipientStatuses = append(notification.RecipientStatuses, copied.RecipientStatuses...)
+			// Services may add metadata too, such as Slack file IDs
+			for key, value := range copied.Metadata {
+				if notification.Metadata == nil {
+					notification.Metadata = make(map[string]string)
+				}
+				notification.Metadata[key] = value
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &FanOutError{Errors: errs}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFanOutNotificationService(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+	slack := &mock.MockNotificationService{}
+	email := &mock.MockNotificationService{}
+	factory.services[models.ChannelSlack] = slack
+	factory.services[models.ChannelEmail] = email
+	service := NewFanOutNotificationService(factory)
+
+	notification := &models.Notification{
+		ID:         "fanout-1",
+		Title:      "Outage",
+		Content:    "API is down",
+		Channels:   []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail},
+		Recipients: []string{"ops"},
+		CreatedAt:  time.Now(),
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(slack.SentNotifications()) != 1 || len(email.SentNotifications()) != 1 {
+		t.Errorf("Expected one send per channel, got slack=%d email=%d", len(slack.SentNotifications()), len(email.SentNotifications()))
+	}
+
+	// Without Channels the notification goes to Channel only
+	single := &models.Notification{ID: "fanout-2", Channel: models.ChannelEmail, Recipients: []string{"ops"}}
+	if err := service.Send(context.Background(), single); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(slack.SentNotifications()) != 1 || len(email.SentNotifications()) != 2 {
+		t.Errorf("Expected single-channel send to email, got slack=%d email=%d", len(slack.SentNotifications()), len(email.SentNotifications()))
+	}
+}
+
+func TestFanOutNotificationServiceErrors(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+	sendErr := errors.New("connection refused")
+	factory.services[models.ChannelSlack] = &mock.MockNotificationService{}
+	email := &mock.MockNotificationService{}
+	email.SetError(sendErr)
+	factory.services[models.ChannelEmail] = email
+	service := NewFanOutNotificationService(factory)
+
+	notification := &models.Notification{
+		ID:         "fanout-3",
+		Title:      "Outage",
+		Content:    "API is down",
+		Channels:   []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail, "carrier-pigeon"},
+		Recipients: []string{"ops"},
+	}
+	err := service.Send(context.Background(), notification)
+
+	var fanOutErr *FanOutError
+	if !errors.As(err, &fanOutErr) {
+		t.Fatalf("Expected FanOutError, got %v", err)
+	}
+	if len(fanOutErr.Errors) != 2 {
+		t.Errorf("Expected 2 failed channels, got %v", fanOutErr.Errors)
+	}
+	if _, failed := fanOutErr.Errors[models.ChannelSlack]; failed {
+		t.Error("Expected slack to succeed")
+	}
+	if !errors.Is(err, sendErr) {
+		t.Errorf("Expected error to wrap the email failure, got %v", err)
+	}
+	if _, failed := fanOutErr.Errors["carrier-pigeon"]; !failed {
+		t.Error("Expected unknown channel to be reported")
+	}
+}
+
+func TestFanOutNotificationServiceChannelRecipients(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+	slack := &mock.MockNotificationService{}
+	email := &mock.MockNotificationService{}
+	factory.services[models.ChannelSlack] = slack
+	factory.services[models.ChannelEmail] = email
+
+	notification := &models.Notification{
+		ID:         "fanout-4",
+		Channels:   []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail},
+		Recipients: []string{"U123", "ana@example.com"},
+		ChannelRecipients: map[models.NotificationChannel][]string{
+			models.ChannelSlack: {"U123"},
+			models.ChannelEmail: {"ana@example.com"},
+		},
+	}
+	if err := NewFanOutNotificationService(factory).Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	recipients := func(service *mock.MockNotificationService) string {
+		var all []string
+		for _, sent := range service.SentNotifications() {
+			all = append(all, sent.Recipients...)
+		}
+		return strings.Join(all, ",")
+	}
+	if recipients(slack) != "U123" || recipients(email) != "ana@example.com" {
+		t.Errorf("Expected per-channel recipients, got slack=%s email=%s", recipients(slack), recipients(email))
+	}
+}
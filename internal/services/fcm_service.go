@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// InvalidTokenError lists device tokens the push provider reported as
+// unregistered or malformed so callers can remove them from storage.
+type InvalidTokenError struct {
+	Tokens []string
+}
+
+func (e *InvalidTokenError) Error() string {
+	return fmt.Sprintf("%d invalid device token(s): %s", len(e.Tokens), strings.Join(e.Tokens, ", "))
+}
+
+// FCMNotificationService sends push notifications through the Firebase Cloud
+// Messaging HTTP v1 API. Each recipient is a device registration token. When
+// ProjectID is empty the notification is only printed to stdout.
+type FCMNotificationService struct {
+	APIURL      string
+	ProjectID   string
+	TokenSource TokenSource
+	Client      *http.Client
+	Logger      logging.Logger
+}
+
+func NewFCMNotificationService(cfg *config.Config) *FCMNotificationService {
+	client := &http.Client{Timeout: cfg.HTTPTimeout}
+	return &FCMNotificationService{
+		APIURL:    cfg.FCMAPIURL,
+		ProjectID: cfg.FCMProjectID,
+		TokenSource: &ServiceAccountTokenSource{
+			CredentialsFile: cfg.FCMCredentialsFile,
+			Scope:           "https://www.googleapis.com/auth/firebase.messaging",
+			Client:          client,
+		},
+		Client: client,
+	}
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmErrorResponse struct {
+	Error struct {
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (f *FCMNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if f.ProjectID == "" {
+		logDryRun(ctx, f.Logger, notification)
+		return nil
+	}
+
+	accessToken, err := f.TokenSource.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain fcm access token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/projects/%s/messages:send", strings.TrimRight(f.APIURL, "/"), f.ProjectID)
+	headers := map[string]string{"Authorization": "Bearer " + accessToken}
+
+	var invalid []string
+	for _, token := range notification.Recipients {
+		request := fcmRequest{Message: fcmMessage{
+			Token:        token,
+			Notification: fcmNotification{Title: notification.Title, Body: notification.Content},
+			Data:         notification.Metadata,
+		}}
+
+		err := postJSON(ctx, f.Client, "fcm", url, headers, request, nil)
+		notification.RecordRecipient(token, err)
+		if isFCMInvalidTokenError(err) {
+			invalid = append(invalid, token)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to send fcm message: %w", err)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &InvalidTokenError{Tokens: invalid}
+	}
+	return nil
+}
+
+func isFCMInvalidTokenError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	var resp fcmErrorResponse
+	if json.Unmarshal([]byte(apiErr.Body), &resp) != nil {
+		return false
+	}
+	for _, detail := range resp.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" || detail.ErrorCode == "INVALID_ARGUMENT" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"testing"
+)
+
+func TestFCMNotificationServiceReportsInvalidTokens(t *testing.T) {
+	var sent []fcmRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/demo/messages:send" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer access" {
+			t.Errorf("Expected bearer token, got %q", got)
+		}
+
+		var req fcmRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+
+		if req.Message.Token == "stale" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	service := &FCMNotificationService{
+		APIURL:      server.URL,
+		ProjectID:   "demo",
+		TokenSource: StaticTokenSource("access"),
+		Client:      server.Client(),
+	}
+	notification := &models.Notification{
+		Title:      "Hello",
+		Content:    "World",
+		Recipients: []string{"good", "stale"},
+		Metadata:   map[string]string{"deep_link": "/inbox"},
+	}
+
+	err := service.Send(context.Background(), notification)
+
+	var tokenErr *InvalidTokenError
+	if !errors.As(err, &tokenErr) {
+		t.Fatalf("Expected InvalidTokenError, got %v", err)
+	}
+	if len(tokenErr.Tokens) != 1 || tokenErr.Tokens[0] != "stale" {
+		t.Errorf("Expected stale token to be reported, got %v", tokenErr.Tokens)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(sent))
+	}
+	if sent[0].Message.Notification.Title != "Hello" || sent[0].Message.Data["deep_link"] != "/inbox" {
+		t.Errorf("Unexpected message: %+v", sent[0].Message)
+	}
+}
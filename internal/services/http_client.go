@@ -0,0 +1,189 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// APIError is returned by HTTP-backed channel services when the provider
+// responds with a non-2xx status.
+type APIError struct {
+	Channel    string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s api returned status %d: %s", e.Channel, e.StatusCode, e.Body)
+}
+
+// RateLimitError is returned by HTTP-backed channel services when the
+// provider responds 429 Too Many Requests. RetryAfter is the wait its
+// Retry-After header asked for, or zero when it sent none.
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitError) Error() string { return e.Err.Error() }
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// rateLimited wraps err in a RateLimitError when resp is a 429 response.
+func rateLimited(resp *http.Response, err error) error {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return err
+	}
+	return &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Err: err}
+}
+
+// parseRetryAfter reads a Retry-After header, either a number of seconds or
+// an HTTP date, as the wait from now. It returns zero for a missing or
+// malformed header and for dates that have passed. Waits too long for a
+// time.Duration are capped at its maximum.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(header, 10, 64); err == nil || errors.Is(err, strconv.ErrRange) {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(min(seconds, int64(math.MaxInt64/time.Second))) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// postJSON marshals payload, POSTs it to url with the given headers and
+// decodes a successful JSON response into out when out is non-nil.
+func postJSON(ctx context.Context, client *http.Client, channel, url string, headers map[string]string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", channel, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", channel, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", channel, transportError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return rateLimited(resp, &APIError{Channel: channel, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))})
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", channel, err)
+		}
+	}
+	return nil
+}
+
+// transportError drops the request URL from a transport failure. Providers
+// such as Telegram and Slack put credentials in the URL, and send errors are
+// returned to API clients and kept in the dead-letter queue and audit log.
+func transportError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// isTransientHTTPError reports whether err is worth retrying: transport
+// failures, 429 and 5xx responses.
+func isTransientHTTPError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// sleepContext waits for d or until ctx is done, returning ctx's error in
+// the latter case.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ErrBlockedAddress is returned when a URL supplied with a notification, such
+// as a webhook recipient or callback URL, resolves to an address inside the
+// service's own network.
+var ErrBlockedAddress = errors.New("destination address is not allowed")
+
+// sharedAddressSpace is carrier-grade NAT space, private like RFC 1918.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// blockedAddress reports whether addr is loopback, link-local (including
+// cloud metadata endpoints such as 169.254.169.254), private, unspecified or
+// multicast.
+func blockedAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() || sharedAddressSpace.Contains(addr)
+}
+
+// guardDial is a net.Dialer Control hook refusing connections to blocked
+// addresses. It runs on the resolved address of every connection, so
+// redirects and DNS rebinding cannot reach them either.
+func guardDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if blockedAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewGuardedClient returns a client for URLs supplied with notifications. It
+// refuses to connect to blocked addresses and ignores proxy settings, since
+// the proxy would make the connection instead.
+func NewGuardedClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: guardDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
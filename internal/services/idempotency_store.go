@@ -0,0 +1,102 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long idempotency records are kept when no TTL
+// is configured.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyRecord is the response originally returned for an idempotency
+// key.
+type IdempotencyRecord struct {
+	StatusCode int
+	Body       []byte
+	CreatedAt  time.Time
+}
+
+// IdempotencyStore remembers responses by idempotency key so retried
+// requests are answered without repeating their side effects.
+type IdempotencyStore interface {
+	// Begin returns the record stored for key, or reserves key for a new
+	// request and returns nil. ok is false while another request holds the
+	// reservation.
+	Begin(key string) (record *IdempotencyRecord, ok bool)
+	// Complete stores the response for a reserved key.
+	Complete(key string, record IdempotencyRecord)
+	// Release drops a reservation without storing a response, so the
+	// request can be retried.
+	Release(key string)
+}
+
+// MemoryIdempotencyStore keeps idempotency records in memory. Records are
+// lost on restart.
+type MemoryIdempotencyStore struct {
+	ttl        time.Duration
+	records    map[string]IdempotencyRecord
+	inFlight   map[string]bool
+	lastPurged time.Time
+	mu         sync.Mutex
+}
+
+// NewMemoryIdempotencyStore keeps records for ttl, or DefaultIdempotencyTTL
+// when ttl is not positive.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &MemoryIdempotencyStore{
+		ttl:        ttl,
+		records:    make(map[string]IdempotencyRecord),
+		inFlight:   make(map[string]bool),
+		lastPurged: time.Now(),
+	}
+}
+
+func (s *MemoryIdempotencyStore) Begin(key string) (*IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, exists := s.records[key]; exists {
+		if time.Since(record.CreatedAt) < s.ttl {
+			return &record, true
+		}
+		delete(s.records, key)
+	}
+	if s.inFlight[key] {
+		return nil, false
+	}
+	s.inFlight[key] = true
+	return nil, true
+}
+
+func (s *MemoryIdempotencyStore) Complete(key string, record IdempotencyRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.inFlight, key)
+	s.records[key] = record
+	s.purgeExpired()
+}
+
+func (s *MemoryIdempotencyStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, key)
+}
+
+// purgeExpired drops expired records at most once a minute so the map does
+// not grow without bound. Callers must hold mu.
+func (s *MemoryIdempotencyStore) purgeExpired() {
+	if time.Since(s.lastPurged) < time.Minute {
+		return
+	}
+	for key, record := range s.records {
+		if time.Since(record.CreatedAt) >= s.ttl {
+			delete(s.records, key)
+		}
+	}
+	s.lastPurged = time.Now()
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Hour)
+
+	record, ok := store.Begin("key")
+	if !ok || record != nil {
+		t.Fatalf("Expected a new reservation, got %+v (ok=%v)", record, ok)
+	}
+	if _, ok := store.Begin("key"); ok {
+		t.Error("Expected a second Begin to fail while the key is in flight")
+	}
+
+	store.Complete("key", IdempotencyRecord{StatusCode: http.StatusAccepted, Body: []byte("{}"), CreatedAt: time.Now()})
+	record, ok = store.Begin("key")
+	if !ok || record == nil || record.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected stored record, got %+v (ok=%v)", record, ok)
+	}
+
+	store.Begin("released")
+	store.Release("released")
+	if record, ok := store.Begin("released"); !ok || record != nil {
+		t.Errorf("Expected released key to be reservable, got %+v (ok=%v)", record, ok)
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Minute)
+
+	store.Begin("key")
+	store.Complete("key", IdempotencyRecord{StatusCode: http.StatusOK, CreatedAt: time.Now().Add(-2 * time.Minute)})
+	if record, ok := store.Begin("key"); !ok || record != nil {
+		t.Errorf("Expected expired record to be dropped, got %+v (ok=%v)", record, ok)
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// MailgunTagsMetadataKey names the notification metadata entry holding the
+// comma-separated tags Mailgun files the email under, to filter it by in the
+// Mailgun dashboard.
+const MailgunTagsMetadataKey = "tags"
+
+// MailgunMessageIDSentMetadataKey names the SentMetadata entry holding the
+// ID Mailgun assigned to the email, which its events and logs refer to.
+const MailgunMessageIDSentMetadataKey = "mailgun_message_id"
+
+// mailgunMaxTags is the most tags Mailgun accepts on a message.
+const mailgunMaxTags = 3
+
+// The Mailgun API base URL of each region.
+const (
+	mailgunUSAPIURL = "https://api.mailgun.net"
+	mailgunEUAPIURL = "https://api.eu.mailgun.net"
+)
+
+// MailgunEmailService delivers email notifications through the Mailgun v3
+// messages API of Domain. The email carries the notification's content,
+// rendered as Email renders it for SMTP, and copy recipients, the reply-to
+// address, attachments and the suppression list are handled as Email handles
+// them; emails are never queued in its outbox. When APIKey is empty the
+// notification is only printed to stdout.
+type MailgunEmailService struct {
+	APIURL string
+	APIKey string
+	Domain string
+	Email  *EmailNotificationService
+	Client *http.Client
+	Logger logging.Logger
+}
+
+// NewMailgunEmailService returns a service sending from email's FromAddress
+// with its templates, tracking and suppression list, through the API of
+// cfg.MailgunRegion.
+func NewMailgunEmailService(cfg *config.Config, email *EmailNotificationService) *MailgunEmailService {
+	apiURL := mailgunUSAPIURL
+	if cfg.MailgunRegion == "eu" {
+		apiURL = mailgunEUAPIURL
+	}
+	return &MailgunEmailService{
+		APIURL: apiURL,
+		APIKey: cfg.MailgunAPIKey,
+		Domain: cfg.MailgunDomain,
+		Email:  email,
+		Client: &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type mailgunResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// Send records the outcome for each To, Cc and Bcc recipient in the
+// notification's RecipientStatuses, and the message ID in its SentMetadata.
+// Mailgun accepts or rejects a message as a whole, so the recipients all
+// share the outcome.
+func (m *MailgunEmailService) Send(ctx context.Context, notification *models.Notification) error {
+	cc, bcc, err := emailCopyRecipients(notification)
+	if err != nil {
+		return err
+	}
+	tags, err := parseMailgunTags(notification.Metadata[MailgunTagsMetadataKey])
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	contentType, body, err := m.buildForm(ctx, notification, cc, bcc, tags)
+	if err != nil {
+		return err
+	}
+
+	if m.APIKey == "" {
+		logDryRun(ctx, m.Logger, notification)
+		return nil
+	}
+
+	response, err := m.post(ctx, contentType, body)
+	for _, recipient := range notification.Recipients {
+		notification.RecordRecipient(recipient, err)
+	}
+	for _, address := range append(cc, bcc...) {
+		notification.RecordRecipient(address.Address, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send mailgun email: %w", err)
+	}
+
+	if notification.SentMetadata == nil {
+		notification.SentMetadata = make(map[string]string)
+	}
+	notification.SentMetadata[MailgunMessageIDSentMetadataKey] = response.ID
+	return nil
+}
+
+// buildForm returns the content type and body of the multipart form the
+// messages API takes.
+func (m *MailgunEmailService) buildForm(ctx context.Context, notification *models.Notification, cc, bcc []*mail.Address, tags []string) (string, []byte, error) {
+	textBody, htmlBody, err := m.Email.renderBody(ctx, notification)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("from", m.Email.from(notification))
+	for _, recipient := range notification.Recipients {
+		writer.WriteField("to", recipient)
+	}
+	for _, address := range cc {
+		writer.WriteField("cc", address.String())
+	}
+	for _, address := range bcc {
+		writer.WriteField("bcc", address.String())
+	}
+	writer.WriteField("subject", notification.Title)
+	writer.WriteField("text", textBody)
+	if htmlBody != "" {
+		writer.WriteField("html", htmlBody)
+	}
+	for _, tag := range tags {
+		writer.WriteField("o:tag", tag)
+	}
+
+	if value := notification.Metadata[EmailReplyToMetadataKey]; value != "" {
+		if replyTo, err := mail.ParseAddress(value); err != nil {
+			logging.FromContext(ctx, m.Logger).Warn("Ignoring malformed reply_to address",
+				logging.NotificationAttrs(notification, "error", err)...)
+		} else {
+			writer.WriteField("h:Reply-To", replyTo.String())
+		}
+	}
+	if links := m.Email.unsubscribeLinks(notification); len(links) == 1 {
+		writer.WriteField("h:List-Unsubscribe", "<"+links[0].url+">")
+	}
+
+	for _, attachment := range notification.Attachments {
+		w, err := writer.CreateFormFile("attachment", attachment.Filename)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to build attachment %s: %w", attachment.Filename, err)
+		}
+		w.Write(attachment.Data)
+	}
+	if err := writer.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to build mailgun form: %w", err)
+	}
+	return writer.FormDataContentType(), body.Bytes(), nil
+}
+
+// post sends the form to the messages API of Domain.
+func (m *MailgunEmailService) post(ctx context.Context, contentType string, body []byte) (*mailgunResponse, error) {
+	endpoint := fmt.Sprintf("%s/v3/%s/messages", strings.TrimRight(m.APIURL, "/"), url.PathEscape(m.Domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build mailgun request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.SetBasicAuth("api", m.APIKey)
+
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mailgun request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, rateLimited(resp, &APIError{Channel: "mailgun", StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))})
+	}
+	var response mailgunResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode mailgun response: %w", err)
+	}
+	return &response, nil
+}
+
+// parseMailgunTags returns the tags in a MailgunTagsMetadataKey entry, of
+// which Mailgun accepts at most mailgunMaxTags.
+func parseMailgunTags(value string) ([]string, error) {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > mailgunMaxTags {
+		return nil, fmt.Errorf("invalid mailgun %s: at most %d tags are allowed, got %d", MailgunTagsMetadataKey, mailgunMaxTags, len(tags))
+	}
+	return tags, nil
+}
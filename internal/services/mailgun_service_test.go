@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestMailgunEmailService(t *testing.T) {
+	var form map[string][]string
+	var attachment string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mg.example.com/messages" {
+			t.Errorf("Expected the messages API of the domain, got %s", r.URL.Path)
+		}
+		if user, key, ok := r.BasicAuth(); !ok || user != "api" || key != "key" {
+			t.Errorf("Expected basic auth with the API key, got %q %q", user, key)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("Failed to parse form: %v", err)
+		}
+		form = r.MultipartForm.Value
+		if files := r.MultipartForm.File["attachment"]; len(files) == 1 {
+			f, _ := files[0].Open()
+			data, _ := io.ReadAll(f)
+			attachment = files[0].Filename + ":" + string(data)
+		}
+		w.Write([]byte(`{"id":"<20261016.1@mg.example.com>","message":"Queued. Thank you."}`))
+	}))
+	defer server.Close()
+
+	service := &MailgunEmailService{APIURL: server.URL, APIKey: "key", Domain: "mg.example.com", Email: &EmailNotificationService{FromAddress: "noreply@example.com"}, Client: server.Client()}
+	notification := &models.Notification{
+		Title:       "Welcome",
+		Content:     "<p>Hello Ana</p>",
+		Recipients:  []string{"ana@example.com"},
+		Metadata:    map[string]string{"content_type": "text/html", "bcc": "audit@example.com", "tags": "welcome, onboarding", "sender_alias": "Acme"},
+		Attachments: []models.NotificationAttachment{{Filename: "terms.txt", ContentType: "text/plain", Data: []byte("Be nice")}},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send Mailgun email: %v", err)
+	}
+
+	expected := map[string][]string{
+		"from":    {`"Acme" <noreply@example.com>`},
+		"to":      {"ana@example.com"},
+		"bcc":     {"<audit@example.com>"},
+		"subject": {"Welcome"},
+		"text":    {"Hello Ana"},
+		"html":    {"<p>Hello Ana</p>"},
+		"o:tag":   {"welcome", "onboarding"},
+	}
+	if !reflect.DeepEqual(form, expected) {
+		t.Errorf("Expected form %v, got %v", expected, form)
+	}
+	if attachment != "terms.txt:Be nice" {
+		t.Errorf("Expected the attachment to be uploaded, got %q", attachment)
+	}
+	if id := notification.SentMetadata["mailgun_message_id"]; id != "<20261016.1@mg.example.com>" {
+		t.Errorf("Expected the Mailgun message ID in SentMetadata, got %q", id)
+	}
+	if len(notification.RecipientStatuses) != 2 {
+		t.Errorf("Expected the To and Bcc recipients recorded, got %+v", notification.RecipientStatuses)
+	}
+
+	// Too many tags are not sent or retried
+	form = nil
+	notification = &models.Notification{Title: "Welcome", Content: "Hi", Recipients: []string{"ana@example.com"}, Metadata: map[string]string{"tags": "a,b,c,d"}}
+	var permanent *PermanentError
+	if err := service.Send(context.Background(), notification); !errors.As(err, &permanent) {
+		t.Errorf("Expected a permanent error, got %v", err)
+	}
+	if form != nil {
+		t.Errorf("Expected no email to be sent, got %v", form)
+	}
+}
+
+func TestNewMailgunEmailServiceRegion(t *testing.T) {
+	for region, expected := range map[string]string{"us": "https://api.mailgun.net", "eu": "https://api.eu.mailgun.net"} {
+		service := NewMailgunEmailService(&config.Config{MailgunRegion: region}, &EmailNotificationService{})
+		if service.APIURL != expected {
+			t.Errorf("Expected %s region to use %s, got %s", region, expected, service.APIURL)
+		}
+	}
+}
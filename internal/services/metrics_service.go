@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/metrics"
+	"notification-service/internal/models"
+	"time"
+)
+
+// MetricsNotificationService records the outcome and latency of every send
+// made through the wrapped service.
+type MetricsNotificationService struct {
+	service NotificationService
+	channel models.NotificationChannel
+	metrics *metrics.MetricsCollector
+}
+
+func NewMetricsNotificationService(service NotificationService, channel models.NotificationChannel, collector *metrics.MetricsCollector) *MetricsNotificationService {
+	return &MetricsNotificationService{service: service, channel: channel, metrics: collector}
+}
+
+// Send counts truncated messages and messages whose attachments failed to
+// upload as sent, since they were delivered, and notifications to
+// unsubscribed recipients as suppressed.
+func (m *MetricsNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	start := time.Now()
+	err := m.service.Send(ctx, notification)
+
+	status := models.StatusSent
+	switch {
+	case errors.Is(err, ErrRecipientsSuppressed):
+		status = models.StatusSuppressed
+	case err != nil && !errors.Is(err, ErrMessageTruncated) && !errors.Is(err, ErrAttachmentsNotUploaded):
+		status = models.StatusFailed
+	}
+	m.metrics.ObserveSend(string(m.channel), string(status), time.Since(start))
+	return err
+}
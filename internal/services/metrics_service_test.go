@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/metrics"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsNotificationService(t *testing.T) {
+	collector := metrics.NewMetricsCollector()
+	flaky := &mock.MockNotificationService{}
+	flaky.FailNext(&RetryableError{Err: errors.New("timeout")}, errors.New("bad request"))
+	retry := NewRetryNotificationService(flaky, 3, 0, 0)
+	retry.OnRetry = func() { collector.IncRetryAttempts(string(models.ChannelSlack)) }
+	service := NewMetricsNotificationService(retry, models.ChannelSlack, collector)
+
+	notification := &models.Notification{Title: "Deploy", Content: "Done", Recipients: []string{"U1"}}
+	if err := service.Send(context.Background(), notification); err == nil {
+		t.Fatal("Expected the permanent error to be returned")
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// Truncated messages were delivered, so they count as sent
+	truncating := &mock.MockNotificationService{}
+	truncating.FailNext(ErrMessageTruncated)
+	NewMetricsNotificationService(truncating, models.ChannelSlack, collector).Send(context.Background(), notification)
+
+	rr := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, expected := range []string{
+		`notifications_sent_total{channel="slack",status="failed"} 1`,
+		`notifications_sent_total{channel="slack",status="sent"} 2`,
+		`retry_attempts_total{channel="slack"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in metrics output", expected)
+		}
+	}
+}
+
+func TestSchedulerScheduleDrift(t *testing.T) {
+	collector := metrics.NewMetricsCollector()
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repository.NewMemoryRepository())
+	scheduler.WithMetrics(collector)
+
+	scheduledAt := time.Now().Add(20 * time.Millisecond)
+	notification := &models.Notification{ID: "late", Title: "Deploy", Content: "Done", Channel: models.ChannelSlack, Recipients: []string{"U1"}, ScheduledAt: &scheduledAt}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+	// The scheduler was not started, so the notification fires late
+	time.Sleep(600 * time.Millisecond)
+	scheduler.dispatchDue()
+
+	rr := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, expected := range []string{
+		`schedule_drift_seconds_bucket{le="0.5"} 0`,
+		`schedule_drift_seconds_bucket{le="60"} 1`,
+		`schedule_drift_seconds_count 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in metrics output", expected)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Package mock provides test doubles for the notification services.
+package mock
+
+import (
+	"context"
+	"notification-service/internal/models"
+	"sync"
+)
+
+// MockNotificationService is a NotificationService that records every
+// notification passed to Send instead of delivering it. It is safe for
+// concurrent use.
+type MockNotificationService struct {
+	sent   []*models.Notification
+	queued []error
+	err    error
+	mu     sync.Mutex
+}
+
+// Send records notification and returns the next error queued with FailNext,
+// or the error set with SetError once the queue is empty. Failed sends are
+// recorded too.
+func (m *MockNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, notification)
+	if len(m.queued) > 0 {
+		err := m.queued[0]
+		m.queued = m.queued[1:]
+		return err
+	}
+	return m.err
+}
+
+// FailNext makes the next len(errs) sends return errs in order. A nil entry
+// lets that send succeed.
+func (m *MockNotificationService) FailNext(errs ...error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queued = append(m.queued, errs...)
+}
+
+// SetError makes every send after the queued errors return err. A nil err
+// makes them succeed again.
+func (m *MockNotificationService) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// SentNotifications returns the notifications passed to Send, oldest first.
+func (m *MockNotificationService) SentNotifications() []*models.Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Notification(nil), m.sent...)
+}
+
+// Reset forgets recorded sends and configured errors.
+func (m *MockNotificationService) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+	m.queued = nil
+	m.err = nil
+}
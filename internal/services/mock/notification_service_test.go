@@ -0,0 +1,55 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/services"
+	"sync"
+	"testing"
+)
+
+var _ services.NotificationService = (*MockNotificationService)(nil)
+
+func TestMockNotificationService(t *testing.T) {
+	service := &MockNotificationService{}
+	queued := errors.New("queued")
+	always := errors.New("always")
+	service.FailNext(queued, nil)
+	service.SetError(always)
+
+	expected := []error{queued, nil, always}
+	for i, want := range expected {
+		if err := service.Send(context.Background(), &models.Notification{ID: "mock"}); err != want {
+			t.Errorf("Expected send %d to return %v, got %v", i, want, err)
+		}
+	}
+	if len(service.SentNotifications()) != len(expected) {
+		t.Errorf("Expected failed sends to be recorded, got %d", len(service.SentNotifications()))
+	}
+
+	service.Reset()
+	if err := service.Send(context.Background(), &models.Notification{ID: "after-reset"}); err != nil {
+		t.Errorf("Expected reset to clear errors, got %v", err)
+	}
+	if sent := service.SentNotifications(); len(sent) != 1 || sent[0].ID != "after-reset" {
+		t.Errorf("Expected only the send after reset, got %+v", sent)
+	}
+}
+
+func TestMockNotificationServiceConcurrentSends(t *testing.T) {
+	service := &MockNotificationService{}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			service.Send(context.Background(), &models.Notification{ID: "concurrent"})
+		}()
+	}
+	wg.Wait()
+
+	if len(service.SentNotifications()) != 50 {
+		t.Errorf("Expected 50 sends, got %d", len(service.SentNotifications()))
+	}
+}
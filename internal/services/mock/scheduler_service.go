@@ -0,0 +1,121 @@
+package mock
+
+import (
+	"context"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"sync"
+	"time"
+)
+
+// RecurringNotification is a call to MockSchedulerService.ScheduleRecurring.
+type RecurringNotification struct {
+	Notification   *models.Notification
+	CronExpression string
+}
+
+// MockSchedulerService is a Scheduler that captures scheduled notifications
+// instead of sending them. It is safe for concurrent use.
+type MockSchedulerService struct {
+	scheduled []*models.Notification
+	recurring []RecurringNotification
+	err       error
+	mu        sync.Mutex
+}
+
+// ScheduleNotification captures notification, or returns the error set with
+// SetError.
+func (m *MockSchedulerService) ScheduleNotification(ctx context.Context, notification *models.Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.scheduled = append(m.scheduled, notification)
+	return nil
+}
+
+// ScheduleRecurring captures notification and expr, or returns the error set
+// with SetError. The expression is not validated.
+func (m *MockSchedulerService) ScheduleRecurring(notification *models.Notification, expr string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.recurring = append(m.recurring, RecurringNotification{Notification: notification, CronExpression: expr})
+	return nil
+}
+
+// CancelNotification forgets a captured notification of the tenant. It
+// returns repository.ErrNotFound if none matches.
+func (m *MockSchedulerService) CancelNotification(tenantID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	for i, n := range m.scheduled {
+		if n.TenantID == tenantID && n.ID == id {
+			m.scheduled = append(m.scheduled[:i:i], m.scheduled[i+1:]...)
+			return nil
+		}
+	}
+	for i, r := range m.recurring {
+		if r.Notification.TenantID == tenantID && r.Notification.ID == id {
+			m.recurring = append(m.recurring[:i:i], m.recurring[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// RescheduleNotification moves a captured one-off notification of the tenant
+// to scheduledAt. It returns repository.ErrNotFound if none matches.
+func (m *MockSchedulerService) RescheduleNotification(tenantID, id string, scheduledAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	for _, n := range m.scheduled {
+		if n.TenantID == tenantID && n.ID == id {
+			n.ScheduledAt = &scheduledAt
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// SetError makes every call fail with err. A nil err makes them succeed
+// again.
+func (m *MockSchedulerService) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// ScheduledNotifications returns the captured one-off notifications that
+// have not been cancelled, oldest first.
+func (m *MockSchedulerService) ScheduledNotifications() []*models.Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Notification(nil), m.scheduled...)
+}
+
+// RecurringNotifications returns the captured recurring notifications that
+// have not been cancelled, oldest first.
+func (m *MockSchedulerService) RecurringNotifications() []RecurringNotification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]RecurringNotification(nil), m.recurring...)
+}
+
+// Reset forgets captured notifications and the configured error.
+func (m *MockSchedulerService) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scheduled = nil
+	m.recurring = nil
+	m.err = nil
+}
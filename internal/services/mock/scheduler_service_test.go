@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services"
+	"testing"
+)
+
+var _ services.Scheduler = (*MockSchedulerService)(nil)
+
+func TestMockSchedulerService(t *testing.T) {
+	scheduler := &MockSchedulerService{}
+	scheduler.ScheduleNotification(context.Background(), &models.Notification{ID: "once", TenantID: "acme"})
+	scheduler.ScheduleRecurring(&models.Notification{ID: "daily"}, "@daily")
+
+	if scheduled := scheduler.ScheduledNotifications(); len(scheduled) != 1 || scheduled[0].ID != "once" {
+		t.Errorf("Expected the one-off notification to be captured, got %+v", scheduled)
+	}
+	if recurring := scheduler.RecurringNotifications(); len(recurring) != 1 || recurring[0].CronExpression != "@daily" {
+		t.Errorf("Expected the recurring notification to be captured, got %+v", recurring)
+	}
+
+	tests := []struct {
+		name        string
+		tenantID    string
+		id          string
+		expectedErr error
+	}{
+		{"Other tenant", "globex", "once", repository.ErrNotFound},
+		{"Scheduled notification", "acme", "once", nil},
+		{"Recurring notification", "", "daily", nil},
+		{"Cancelled twice", "acme", "once", repository.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := scheduler.CancelNotification(tt.tenantID, tt.id); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	failure := errors.New("scheduler down")
+	scheduler.SetError(failure)
+	if err := scheduler.ScheduleNotification(context.Background(), &models.Notification{ID: "failed"}); err != failure {
+		t.Errorf("Expected configured error, got %v", err)
+	}
+	scheduler.Reset()
+	if err := scheduler.ScheduleNotification(context.Background(), &models.Notification{ID: "after-reset"}); err != nil || len(scheduler.ScheduledNotifications()) != 1 {
+		t.Errorf("Expected reset to clear captures and errors, got %v", err)
+	}
+}
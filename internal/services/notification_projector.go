@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+)
+
+// NotificationProjector derives the state of notifications from the event
+// history the repository appends to on every change. Replaying the history
+// gives the same state as the notifications table, so it can be used to
+// audit a notification or to rebuild a read model from scratch.
+type NotificationProjector struct {
+	repository repository.NotificationRepository
+}
+
+func NewNotificationProjector(repo repository.NotificationRepository) *NotificationProjector {
+	return &NotificationProjector{repository: repo}
+}
+
+// Rebuild replays the stored history of notification id. It returns
+// repository.ErrNotFound when the notification has no history in tenantID.
+func (p *NotificationProjector) Rebuild(ctx context.Context, tenantID, id string) (*models.NotificationReadModel, error) {
+	events, err := p.repository.GetEventHistory(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return p.Project(events)
+}
+
+// Project replays events, oldest first, into a read model.
+func (p *NotificationProjector) Project(events []*models.NotificationEvent) (*models.NotificationReadModel, error) {
+	state := &models.NotificationReadModel{}
+	for _, event := range events {
+		if err := apply(state, event); err != nil {
+			return nil, fmt.Errorf("failed to apply event %d of notification %s: %w", event.Sequence, event.NotificationID, err)
+		}
+		state.Version = event.Sequence
+		state.UpdatedAt = event.Timestamp
+	}
+	return state, nil
+}
+
+func apply(state *models.NotificationReadModel, event *models.NotificationEvent) error {
+	switch event.Type {
+	case models.EventCreated, models.EventUpdated:
+		// Saves carry the whole notification
+		state.Notification = models.Notification{}
+		state.Deleted = false
+		return json.Unmarshal(event.Payload, &state.Notification)
+	case models.EventStatusChanged, models.EventRescheduled:
+		return json.Unmarshal(event.Payload, &state.Notification)
+	case models.EventOpened:
+		if state.OpenedAt != nil {
+			return nil
+		}
+		return json.Unmarshal(event.Payload, &state.Notification)
+	case models.EventClicked:
+		state.Clicks++
+	case models.EventDeleted:
+		state.Deleted = true
+	default:
+		return fmt.Errorf("unknown event type %q", event.Type)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestNotificationProjector(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	projector := NewNotificationProjector(repo)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	scheduledAt := now.Add(time.Hour)
+	notification := &models.Notification{
+		ID:          "projected",
+		TenantID:    "acme",
+		Title:       "Invoice",
+		Content:     "Your invoice is ready",
+		Channel:     models.ChannelEmail,
+		Recipients:  []string{"ana@example.com"},
+		ScheduledAt: &now,
+		CreatedAt:   now,
+	}
+	repo.Save(ctx, notification)
+	repo.UpdateScheduledAt(ctx, "acme", "projected", scheduledAt)
+	repo.UpdateStatus(ctx, "acme", "projected", repository.StatusUpdate{Status: models.StatusFailed, FailureReason: "smtp unavailable"})
+	repo.UpdateStatus(ctx, "acme", "projected", repository.StatusUpdate{Status: models.StatusSent, SentAt: &scheduledAt})
+	repo.RecordOpen(ctx, "acme", "projected", scheduledAt.Add(time.Minute))
+	repo.RecordOpen(ctx, "acme", "projected", scheduledAt.Add(time.Hour))
+	repo.RecordClick(ctx, "acme", "projected", "https://example.com/invoice", scheduledAt.Add(time.Hour))
+
+	state, err := projector.Rebuild(ctx, "acme", "projected")
+	if err != nil {
+		t.Fatalf("Failed to rebuild notification: %v", err)
+	}
+	stored, _ := repo.GetByID(ctx, "acme", "projected")
+	if state.Status != stored.Status || state.FailureReason != stored.FailureReason || state.Title != stored.Title {
+		t.Errorf("Expected the projection to match the stored notification, got %+v", state.Notification)
+	}
+	if !state.ScheduledAt.Equal(*stored.ScheduledAt) || !state.SentAt.Equal(*stored.SentAt) || !state.OpenedAt.Equal(*stored.OpenedAt) {
+		t.Errorf("Expected projected times to match the stored notification, got %+v", state.Notification)
+	}
+	if state.Clicks != 1 || state.Deleted || state.Version != 7 {
+		t.Errorf("Expected 1 click at version 7, got %d clicks at version %d (deleted %v)", state.Clicks, state.Version, state.Deleted)
+	}
+
+	repo.Delete(ctx, "acme", "projected")
+	if state, err = projector.Rebuild(ctx, "acme", "projected"); err != nil || !state.Deleted {
+		t.Errorf("Expected the deleted notification to be rebuilt as deleted, got %+v, %v", state, err)
+	}
+	if _, err := projector.Rebuild(ctx, "other", "projected"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected %v, got %v", repository.ErrNotFound, err)
+	}
+	if _, err := projector.Project([]*models.NotificationEvent{{Type: "archived", Payload: []byte(`{}`)}}); err == nil {
+		t.Error("Expected an error for an unknown event type")
+	}
+}
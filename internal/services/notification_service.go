@@ -1,56 +1,193 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/metrics"
 	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type NotificationService interface {
-	Send(notification *models.Notification) error
+	Send(ctx context.Context, notification *models.Notification) error
 }
 
-type SlackNotificationService struct{}
-
-func (s *SlackNotificationService) Send(notification *models.Notification) error {
-	fmt.Printf("[SLACK] Sending notification to %v: %s - %s\n",
-		notification.Recipients,
-		notification.Title,
-		notification.Content)
-	return nil
+// MessageNotificationService sends SMS notifications, which are only
+// printed to stdout. Content too long for a single SMS is split with SplitSMS
+// and each part sent on its own, sharing a session ID in
+// Metadata["session_id"]; SentMetadata["parts"] counts the parts.
+type MessageNotificationService struct {
+	Logger logging.Logger
+	// AlphanumericSenderID prefixes the content with the notification's
+	// sender alias, as "Alias: content", when the alias is a valid
+	// alphanumeric sender ID.
+	AlphanumericSenderID bool
 }
 
-type EmailNotificationService struct{}
+func (m *MessageNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	content := notification.Content
+	if alias := notification.Metadata[SenderAliasMetadataKey]; m.AlphanumericSenderID && isAlphanumericSenderID(alias) {
+		content = alias + ": " + content
+	}
+	parts := SplitSMS(content)
+	if len(parts) == 1 {
+		logging.FromContext(ctx, m.Logger).Info("Sending notification",
+			logging.NotificationAttrs(notification, "dry_run", true, "title", notification.Title, "content", content)...)
+		return nil
+	}
 
-func (e *EmailNotificationService) Send(notification *models.Notification) error {
-	fmt.Printf("[EMAIL] Sending notification to %v: %s - %s\n",
-		notification.Recipients,
-		notification.Title,
-		notification.Content)
+	sessionID := uuid.New().String()
+	if notification.Metadata == nil {
+		notification.Metadata = make(map[string]string)
+	}
+	notification.Metadata[SMSSessionIDMetadataKey] = sessionID
+	for i, part := range parts {
+		logging.FromContext(ctx, m.Logger).Info("Sending notification",
+			logging.NotificationAttrs(notification, "dry_run", true, "session_id", sessionID,
+				"part", i+1, "parts", len(parts), "title", notification.Title, "content", part)...)
+	}
+
+	if notification.SentMetadata == nil {
+		notification.SentMetadata = make(map[string]string)
+	}
+	notification.SentMetadata["parts"] = strconv.Itoa(len(parts))
 	return nil
 }
 
-type MessageNotificationService struct{}
+// newSMSService returns the service of cfg.SMSProvider, which logs messages
+// unless a provider is configured.
+func newSMSService(cfg *config.Config) NotificationService {
+	switch cfg.SMSProvider {
+	case "vonage":
+		return NewVonageNotificationService(cfg)
+	case "sns":
+		return NewSNSNotificationService(cfg)
+	}
+	return &MessageNotificationService{AlphanumericSenderID: cfg.SMSAlphanumericSenderID}
+}
 
-func (m *MessageNotificationService) Send(notification *models.Notification) error {
-	fmt.Printf("[MESSAGE] Sending notification to %v: %s - %s\n",
-		notification.Recipients,
-		notification.Title,
-		notification.Content)
-	return nil
+// newEmailService returns the service of cfg.EmailProvider, which sends
+// over SMTP with email unless SendGrid or Mailgun is configured.
+func newEmailService(cfg *config.Config, email *EmailNotificationService) NotificationService {
+	switch cfg.EmailProvider {
+	case "sendgrid":
+		return NewSendGridEmailService(cfg, email)
+	case "mailgun":
+		return NewMailgunEmailService(cfg, email)
+	}
+	return email
+}
+
+// logDryRun records a notification that a channel without credentials would
+// have sent.
+func logDryRun(ctx context.Context, logger logging.Logger, notification *models.Notification) {
+	logging.FromContext(ctx, logger).Info("Sending notification",
+		logging.NotificationAttrs(notification, "dry_run", true, "title", notification.Title, "content", notification.Content)...)
 }
 
 type NotificationServiceFactory struct {
-	services map[models.NotificationChannel]NotificationService
+	config      *config.Config
+	services    map[models.NotificationChannel]NotificationService
+	email       *EmailNotificationService
+	suppression *SuppressionNotificationService
+	deadLetters DeadLetterQueue
+	metrics     *metrics.MetricsCollector
+	tracer      trace.Tracer
+	breakers    map[models.NotificationChannel]*CircuitBreakerNotificationService
+	limiters    map[models.NotificationChannel]*RateLimitedNotificationService
+	retries     map[models.NotificationChannel]RetryOptions
+	plugins     []ChannelPlugin
+	// tenants holds the factories of tenants with their own channel
+	// configuration.
+	tenants map[string]*NotificationServiceFactory
+	mu      sync.RWMutex
 }
 
-func NewNotificationServiceFactory() *NotificationServiceFactory {
-	return &NotificationServiceFactory{
+// NewNotificationServiceFactory builds a service for every channel. Channels
+// with an entry in cfg.RateLimits are wrapped in a RateLimitedNotificationService
+// and those with an entry in cfg.CircuitBreakers in a
+// CircuitBreakerNotificationService outside it.
+func NewNotificationServiceFactory(cfg *config.Config) *NotificationServiceFactory {
+	email := NewEmailNotificationService(cfg)
+	slack := NewSlackNotificationService(cfg.SlackWebhookURL, cfg.HTTPTimeout)
+	if cfg.SlackBotToken != "" {
+		slack.APIURL, slack.BotToken = cfg.SlackAPIURL, cfg.SlackBotToken
+		slack.UserLookup = NewSlackAPIUserLookup(cfg)
+	}
+	suppression := NewSuppressionNotificationService(newEmailService(cfg, email))
+	factory := &NotificationServiceFactory{
+		config:      cfg,
+		email:       email,
+		suppression: suppression,
+		breakers:    make(map[models.NotificationChannel]*CircuitBreakerNotificationService),
+		limiters:    make(map[models.NotificationChannel]*RateLimitedNotificationService),
 		services: map[models.NotificationChannel]NotificationService{
-			models.ChannelSlack:   &SlackNotificationService{},
-			models.ChannelEmail:   &EmailNotificationService{},
-			models.ChannelMessage: &MessageNotificationService{},
+			models.ChannelSlack:     slack,
+			models.ChannelEmail:     suppression,
+			models.ChannelMessage:   newSMSService(cfg),
+			models.ChannelWhatsApp:  NewWhatsAppNotificationService(cfg),
+			models.ChannelTeams:     NewTeamsNotificationService(cfg),
+			models.ChannelDiscord:   NewDiscordNotificationService(cfg),
+			models.ChannelPagerDuty: NewPagerDutyNotificationService(cfg),
+			models.ChannelFCM:       NewFCMNotificationService(cfg),
+			models.ChannelAPNs:      NewAPNsNotificationService(cfg),
+			models.ChannelSNS:       NewSNSNotificationService(cfg),
+			models.ChannelTelegram:  NewTelegramNotificationService(cfg),
+			models.ChannelWebhook:   NewWebhookNotificationService(cfg),
 		},
 	}
+
+	for channel, service := range factory.services {
+		factory.services[channel] = factory.wrap(channel, service)
+	}
+
+	return factory
+}
+
+// wrap puts service in a ContentLimitNotificationService if channel has a
+// content length limit, in a RateLimitedNotificationService outside it if
+// channel has an entry in the factory's RateLimits and in a
+// CircuitBreakerNotificationService outside that if it has one in
+// CircuitBreakers.
+func (f *NotificationServiceFactory) wrap(channel models.NotificationChannel, service NotificationService) NotificationService {
+	f.mu.RLock()
+	contentLimit := channelLimits(f.config)[channel]
+	limit, limited := f.config.RateLimits[string(channel)]
+	breaker, broken := f.config.CircuitBreakers[string(channel)]
+	f.mu.RUnlock()
+
+	if contentLimit.MaxContentLength > 0 {
+		service = NewContentLimitNotificationService(service, channel, contentLimit)
+	}
+	if limited {
+		limiter := NewRateLimitedNotificationService(service, limit.RequestsPerSecond, limit.Burst)
+		f.limiters[channel] = limiter
+		service = limiter
+	}
+	if broken {
+		wrapped := NewCircuitBreakerNotificationService(service, channel, CircuitBreakerOptions{
+			FailureThreshold: breaker.FailureThreshold,
+			Timeout:          breaker.Timeout,
+			HalfOpenProbes:   breaker.HalfOpenProbes,
+		})
+		// Metrics may be enabled later, so the collector is looked up on
+		// every transition
+		wrapped.OnStateChange = func(state CircuitState) {
+			if f.metrics != nil {
+				f.metrics.SetCircuitBreakerState(string(channel), int(state))
+			}
+		}
+		f.breakers[channel] = wrapped
+		service = wrapped
+	}
+	return service
 }
 
 func (f *NotificationServiceFactory) GetService(channel models.NotificationChannel) (NotificationService, error) {
@@ -58,5 +195,213 @@ func (f *NotificationServiceFactory) GetService(channel models.NotificationChann
 	if !exists {
 		return nil, fmt.Errorf("unsupported notification channel: %s", channel)
 	}
+	if f.metrics != nil {
+		service = NewMetricsNotificationService(service, channel, f.metrics)
+	}
+	if f.tracer != nil {
+		service = NewTracingNotificationService(service, channel, f.tracer)
+	}
+	if f.deadLetters != nil {
+		return NewDeadLetterNotificationService(service, f.deadLetters), nil
+	}
 	return service, nil
 }
+
+// WithRetry wraps the registered service for channel in a
+// RetryNotificationService so later GetService calls return the wrapper.
+func (f *NotificationServiceFactory) WithRetry(channel models.NotificationChannel, opts RetryOptions) error {
+	service, exists := f.services[channel]
+	if !exists {
+		return fmt.Errorf("unsupported notification channel: %s", channel)
+	}
+	retry := NewRetryNotificationService(service, opts.MaxAttempts, opts.InitialDelay, opts.Jitter)
+	retry.options.MaxRetryAfter = opts.MaxRetryAfter
+	// Metrics may be enabled after retries, so the collector is looked up
+	// on every retry
+	retry.OnRetry = func() {
+		if f.metrics != nil {
+			f.metrics.IncRetryAttempts(string(channel))
+		}
+	}
+	f.services[channel] = retry
+	// Remembered for tenants registered later
+	if f.retries == nil {
+		f.retries = make(map[models.NotificationChannel]RetryOptions)
+	}
+	f.retries[channel] = opts
+	for _, tenant := range f.tenantFactories() {
+		tenant.WithRetry(channel, opts)
+	}
+	return nil
+}
+
+// WithDeadLetterQueue makes every service returned by GetService record its
+// final failures in queue.
+func (f *NotificationServiceFactory) WithDeadLetterQueue(queue DeadLetterQueue) {
+	f.deadLetters = queue
+	for _, tenant := range f.tenantFactories() {
+		tenant.deadLetters = queue
+	}
+}
+
+// WithTemplateService lets the email service render HTML templates named in
+// Metadata["html_template"].
+func (f *NotificationServiceFactory) WithTemplateService(templates *TemplateService) {
+	f.email.Templates = templates
+	for _, tenant := range f.tenantFactories() {
+		tenant.email.Templates = templates
+	}
+}
+
+// WithUnsubscribeService makes the email channel skip addresses on the
+// suppression list, whichever provider sends it, and add unsubscribe links
+// to every email.
+func (f *NotificationServiceFactory) WithUnsubscribeService(unsubscribe *UnsubscribeService) {
+	f.email.Unsubscribe = unsubscribe
+	f.suppression.Unsubscribe = unsubscribe
+	for _, tenant := range f.tenantFactories() {
+		tenant.email.Unsubscribe = unsubscribe
+		tenant.suppression.Unsubscribe = unsubscribe
+	}
+}
+
+// WithEmailOutbox makes the email service queue the emails of stored
+// notifications in outbox for an OutboxWorker to deliver.
+func (f *NotificationServiceFactory) WithEmailOutbox(outbox repository.EmailOutbox) {
+	f.email.Outbox = outbox
+	for _, tenant := range f.tenantFactories() {
+		tenant.email.Outbox = outbox
+	}
+}
+
+// WithMetrics makes every service returned by GetService record its sends in
+// collector.
+func (f *NotificationServiceFactory) WithMetrics(collector *metrics.MetricsCollector) {
+	f.metrics = collector
+	for channel, breaker := range f.breakers {
+		collector.SetCircuitBreakerState(string(channel), int(breaker.State()))
+	}
+	for _, tenant := range f.tenantFactories() {
+		tenant.metrics = collector
+	}
+}
+
+// WithTracer makes every service returned by GetService record its sends as
+// spans of tracer.
+func (f *NotificationServiceFactory) WithTracer(tracer trace.Tracer) {
+	f.tracer = tracer
+	for _, tenant := range f.tenantFactories() {
+		tenant.tracer = tracer
+	}
+}
+
+// WithTenant gives tenant its own services, built from the factory's
+// configuration with the tenant's overrides applied. Rate limits and circuit
+// breakers are tracked separately per tenant; dead letters, metrics,
+// templates, the suppression list, the email outbox, retries and plugins are
+// shared. Calling it again replaces the tenant's services.
+func (f *NotificationServiceFactory) WithTenant(tenant *models.Tenant) {
+	f.mu.RLock()
+	cfg := tenantConfig(f.config, tenant.Config)
+	f.mu.RUnlock()
+	factory := NewNotificationServiceFactory(cfg)
+	factory.deadLetters = f.deadLetters
+	factory.metrics = f.metrics
+	factory.tracer = f.tracer
+	factory.email.Templates = f.email.Templates
+	factory.email.Unsubscribe = f.email.Unsubscribe
+	factory.suppression.Unsubscribe = f.email.Unsubscribe
+	factory.email.Outbox = f.email.Outbox
+	for _, p := range f.plugins {
+		factory.RegisterPlugin(p)
+	}
+	for channel, opts := range f.retries {
+		factory.WithRetry(channel, opts)
+	}
+	// The circuit breaker gauge is labelled by channel alone, so it only
+	// tracks the default tenant's breakers
+	for _, breaker := range factory.breakers {
+		breaker.OnStateChange = nil
+	}
+
+	f.mu.Lock()
+	if f.tenants == nil {
+		f.tenants = make(map[string]*NotificationServiceFactory)
+	}
+	f.tenants[tenant.ID] = factory
+	f.mu.Unlock()
+}
+
+// SetRateLimits applies limits, keyed by channel name, to the channels that
+// were rate limited when the factory was built and lifts the limit of those
+// missing from limits. It returns the channels that have a limit in limits
+// but were built without one; limiting them takes a restart.
+func (f *NotificationServiceFactory) SetRateLimits(limits map[string]config.RateLimitConfig) []models.NotificationChannel {
+	var unapplied []models.NotificationChannel
+	for name := range limits {
+		channel := models.NotificationChannel(name)
+		_, exists := f.services[channel]
+		if _, limited := f.limiters[channel]; exists && !limited {
+			unapplied = append(unapplied, channel)
+		}
+	}
+	for channel, limiter := range f.limiters {
+		limit := limits[string(channel)]
+		limiter.SetLimit(limit.RequestsPerSecond, limit.Burst)
+	}
+
+	// Tenants registered later start from the new limits
+	f.mu.Lock()
+	cfg := *f.config
+	cfg.RateLimits = limits
+	f.config = &cfg
+	f.mu.Unlock()
+
+	for _, tenant := range f.tenantFactories() {
+		tenant.SetRateLimits(limits)
+	}
+	return unapplied
+}
+
+// ForTenant returns the factory serving tenantID: the tenant's own when it
+// was registered with WithTenant, and f otherwise.
+func (f *NotificationServiceFactory) ForTenant(tenantID string) *NotificationServiceFactory {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if factory, exists := f.tenants[tenantID]; exists {
+		return factory
+	}
+	return f
+}
+
+func (f *NotificationServiceFactory) tenantFactories() []*NotificationServiceFactory {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	factories := make([]*NotificationServiceFactory, 0, len(f.tenants))
+	for _, factory := range f.tenants {
+		factories = append(factories, factory)
+	}
+	return factories
+}
+
+// tenantConfig returns a copy of base with the non-empty overrides applied.
+func tenantConfig(base *config.Config, overrides models.TenantConfig) *config.Config {
+	cfg := *base
+	override := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	override(&cfg.SlackWebhookURL, overrides.SlackWebhookURL)
+	override(&cfg.TeamsWebhookURL, overrides.TeamsWebhookURL)
+	override(&cfg.DiscordBotToken, overrides.DiscordBotToken)
+	override(&cfg.PagerDutyRoutingKey, overrides.PagerDutyRoutingKey)
+	override(&cfg.TelegramBotToken, overrides.TelegramBotToken)
+	override(&cfg.SMTPFrom, overrides.SMTPFrom)
+	override(&cfg.WebhookSecret, overrides.WebhookSecret)
+	return &cfg
+}
+
+func (f *NotificationServiceFactory) DeadLetterQueue() DeadLetterQueue {
+	return f.deadLetters
+}
@@ -1,7 +1,12 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"notification-service/internal/config"
 	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
 	"testing"
 	"time"
 )
@@ -17,7 +22,7 @@ func TestSlackNotificationService(t *testing.T) {
 		CreatedAt:  time.Now(),
 	}
 
-	err := service.Send(notification)
+	err := service.Send(context.Background(), notification)
 	if err != nil {
 		t.Errorf("Failed to send Slack notification: %v", err)
 	}
@@ -34,7 +39,7 @@ func TestEmailNotificationService(t *testing.T) {
 		CreatedAt:  time.Now(),
 	}
 
-	err := service.Send(notification)
+	err := service.Send(context.Background(), notification)
 	if err != nil {
 		t.Errorf("Failed to send Email notification: %v", err)
 	}
@@ -51,14 +56,14 @@ func TestMessageNotificationService(t *testing.T) {
 		CreatedAt:  time.Now(),
 	}
 
-	err := service.Send(notification)
+	err := service.Send(context.Background(), notification)
 	if err != nil {
 		t.Errorf("Failed to send SMS notification: %v", err)
 	}
 }
 
 func TestNotificationServiceFactory(t *testing.T) {
-	factory := NewNotificationServiceFactory()
+	factory := NewNotificationServiceFactory(&config.Config{})
 
 	// Test getting Slack service
 	slackService, err := factory.GetService(models.ChannelSlack)
@@ -100,7 +105,8 @@ func TestNotificationServiceFactory(t *testing.T) {
 func TestSchedulerService(t *testing.T) {
 	// Create a test notification service
 	testService := &SlackNotificationService{}
-	scheduler := NewSchedulerService(testService)
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(testService, repo)
 
 	// Test scheduling a notification
 	scheduledTime := time.Now().Add(2 * time.Second)
@@ -114,7 +120,7 @@ func TestSchedulerService(t *testing.T) {
 		CreatedAt:   time.Now(),
 	}
 
-	err := scheduler.ScheduleNotification(notification)
+	err := scheduler.ScheduleNotification(context.Background(), notification)
 	if err != nil {
 		t.Errorf("Failed to schedule notification: %v", err)
 	}
@@ -125,11 +131,46 @@ func TestSchedulerService(t *testing.T) {
 
 	// Wait for the notification to be sent
 	time.Sleep(3 * time.Second)
+
+	stored, err := repo.GetByID(context.Background(), "", "test-4")
+	if err != nil {
+		t.Fatalf("Failed to load scheduled notification: %v", err)
+	}
+	if stored.Status != models.StatusSent || stored.SentAt == nil {
+		t.Errorf("Expected status %s with SentAt, got %s", models.StatusSent, stored.Status)
+	}
+}
+
+func TestSchedulerServiceJobs(t *testing.T) {
+	scheduler := NewSchedulerService(&SlackNotificationService{}, nil)
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	scheduledAt := time.Now().Add(time.Hour)
+	scheduler.ScheduleNotification(context.Background(), &models.Notification{ID: "one-off", TenantID: "acme", Channel: models.ChannelEmail, Recipients: []string{"a@example.com"}, ScheduledAt: &scheduledAt})
+	scheduler.ScheduleRecurring(&models.Notification{ID: "recurring", Channel: models.ChannelSlack, Recipients: []string{"U123"}}, "@every 1m")
+
+	jobs, err := scheduler.Jobs(context.Background())
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %d (%v)", len(jobs), err)
+	}
+	recurring, oneOff := jobs[0], jobs[1]
+	if recurring.NotificationID != "recurring" || recurring.JobID == 0 || recurring.CronExpr != "@every 1m" || recurring.FiresAt.IsZero() {
+		t.Errorf("Expected the recurring job first with its cron entry, got %+v", recurring)
+	}
+	if oneOff.NotificationID != "one-off" || oneOff.JobID != 0 || oneOff.TenantID != "acme" || !oneOff.FiresAt.Equal(scheduledAt) || oneOff.Recipients[0] != "a@example.com" {
+		t.Errorf("Expected the one-off job at its scheduled time, got %+v", oneOff)
+	}
+
+	scheduler.CancelNotification("", "recurring")
+	if jobs, _ := scheduler.Jobs(context.Background()); len(jobs) != 1 {
+		t.Errorf("Expected the cancelled job to be dropped, got %+v", jobs)
+	}
 }
 
 func TestMultipleScheduledNotifications(t *testing.T) {
 	testService := &SlackNotificationService{}
-	scheduler := NewSchedulerService(testService)
+	scheduler := NewSchedulerService(testService, nil)
 	scheduler.Start()
 	defer scheduler.Stop()
 
@@ -161,7 +202,7 @@ func TestMultipleScheduledNotifications(t *testing.T) {
 
 	// Schedule all notifications
 	for _, notification := range notifications {
-		err := scheduler.ScheduleNotification(notification)
+		err := scheduler.ScheduleNotification(context.Background(), notification)
 		if err != nil {
 			t.Errorf("Failed to schedule notification %s: %v", notification.ID, err)
 		}
@@ -171,9 +212,44 @@ func TestMultipleScheduledNotifications(t *testing.T) {
 	time.Sleep(5 * time.Second)
 }
 
+func TestScheduleNotificationTwice(t *testing.T) {
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repository.NewMemoryRepository())
+
+	scheduledAt := time.Now().Add(time.Hour)
+	notification := &models.Notification{
+		ID:          "test-dup",
+		Title:       "Duplicate Notification",
+		Content:     "Scheduled twice",
+		Channel:     models.ChannelSlack,
+		Recipients:  []string{"test-user"},
+		ScheduledAt: &scheduledAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); !errors.Is(err, ErrAlreadyScheduled) {
+		t.Errorf("Expected %v scheduling twice, got %v", ErrAlreadyScheduled, err)
+	}
+	if err := scheduler.ScheduleRecurring(notification, "@hourly"); !errors.Is(err, ErrAlreadyScheduled) {
+		t.Errorf("Expected %v scheduling a recurring copy, got %v", ErrAlreadyScheduled, err)
+	}
+	if scheduler.QueueDepth() != 1 {
+		t.Errorf("Expected 1 scheduled entry, got %d", scheduler.QueueDepth())
+	}
+
+	// Once cancelled the ID can be scheduled again
+	if err := scheduler.CancelNotification("", "test-dup"); err != nil {
+		t.Fatalf("Failed to cancel notification: %v", err)
+	}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Errorf("Expected a cancelled notification to be schedulable again, got %v", err)
+	}
+}
+
 func TestInvalidScheduledTime(t *testing.T) {
 	testService := &SlackNotificationService{}
-	scheduler := NewSchedulerService(testService)
+	scheduler := NewSchedulerService(testService, nil)
 
 	// Test with past scheduled time
 	pastTime := time.Now().Add(-1 * time.Hour)
@@ -187,7 +263,7 @@ func TestInvalidScheduledTime(t *testing.T) {
 		CreatedAt:   time.Now(),
 	}
 
-	err := scheduler.ScheduleNotification(notification)
+	err := scheduler.ScheduleNotification(context.Background(), notification)
 	if err == nil {
 		t.Error("Expected error for past scheduled time, got nil")
 	}
@@ -195,7 +271,7 @@ func TestInvalidScheduledTime(t *testing.T) {
 
 func TestNilScheduledTime(t *testing.T) {
 	testService := &SlackNotificationService{}
-	scheduler := NewSchedulerService(testService)
+	scheduler := NewSchedulerService(testService, nil)
 
 	notification := &models.Notification{
 		ID:         "test-8",
@@ -206,8 +282,393 @@ func TestNilScheduledTime(t *testing.T) {
 		CreatedAt:  time.Now(),
 	}
 
-	err := scheduler.ScheduleNotification(notification)
+	err := scheduler.ScheduleNotification(context.Background(), notification)
 	if err == nil {
 		t.Error("Expected error for nil scheduled time, got nil")
 	}
 }
+
+func TestCancelScheduledNotification(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(&SlackNotificationService{}, repo)
+
+	scheduledTime := time.Now().Add(time.Hour)
+	notification := &models.Notification{
+		ID:          "test-9",
+		Title:       "Cancelled Notification",
+		Content:     "This should never be sent",
+		Channel:     models.ChannelSlack,
+		Recipients:  []string{"test-user"},
+		ScheduledAt: &scheduledTime,
+		CreatedAt:   time.Now(),
+	}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+
+	if err := scheduler.CancelNotification("", "test-9"); err != nil {
+		t.Fatalf("Failed to cancel notification: %v", err)
+	}
+	stored, _ := repo.GetByID(context.Background(), "", "test-9")
+	if stored.Status != models.StatusCancelled {
+		t.Errorf("Expected status %s, got %s", models.StatusCancelled, stored.Status)
+	}
+
+	if err := scheduler.CancelNotification("", "test-9"); !errors.Is(err, ErrNotificationNotPending) {
+		t.Errorf("Expected ErrNotificationNotPending for second cancel, got %v", err)
+	}
+	if err := scheduler.CancelNotification("", "unknown"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown ID, got %v", err)
+	}
+}
+
+func TestCancelScheduledNotificationOfOtherTenant(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(&SlackNotificationService{}, repo)
+
+	scheduledTime := time.Now().Add(time.Hour)
+	notification := &models.Notification{
+		ID:          "test-11",
+		TenantID:    "acme",
+		Title:       "Tenant Notification",
+		Content:     "Only acme can cancel this",
+		Channel:     models.ChannelSlack,
+		Recipients:  []string{"test-user"},
+		ScheduledAt: &scheduledTime,
+		CreatedAt:   time.Now(),
+	}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+
+	if err := scheduler.CancelNotification("globex", "test-11"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from another tenant, got %v", err)
+	}
+	if scheduler.QueueDepth() != 1 {
+		t.Errorf("Expected the notification to stay scheduled, got queue depth %d", scheduler.QueueDepth())
+	}
+	if err := scheduler.CancelNotification("acme", "test-11"); err != nil {
+		t.Errorf("Failed to cancel notification: %v", err)
+	}
+}
+
+func TestScheduleRecurring(t *testing.T) {
+	counter := &mock.MockNotificationService{}
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(counter, repo)
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	notification := &models.Notification{
+		ID:         "test-10",
+		Title:      "Recurring Notification",
+		Content:    "Every second",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"test-user"},
+		CreatedAt:  time.Now(),
+	}
+	if err := scheduler.ScheduleRecurring(notification, "@every 1s"); err != nil {
+		t.Fatalf("Failed to schedule recurring notification: %v", err)
+	}
+
+	time.Sleep(2500 * time.Millisecond)
+	if err := scheduler.CancelNotification("", "test-10"); err != nil {
+		t.Fatalf("Failed to cancel recurring notification: %v", err)
+	}
+	fired := len(counter.SentNotifications())
+	if fired < 2 {
+		t.Errorf("Expected at least 2 sends, got %d", fired)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if len(counter.SentNotifications()) != fired {
+		t.Errorf("Expected no sends after cancel, got %d more", len(counter.SentNotifications())-fired)
+	}
+
+	stored, _ := repo.GetByID(context.Background(), "", "test-10")
+	if stored.CronExpr != "@every 1s" || stored.Status != models.StatusCancelled {
+		t.Errorf("Expected cancelled recurring notification, got %q (%s)", stored.CronExpr, stored.Status)
+	}
+}
+
+func TestExpiredNotificationIsNotSent(t *testing.T) {
+	counter := &mock.MockNotificationService{}
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(counter, repo)
+
+	scheduledTime := time.Now().Add(20 * time.Millisecond)
+	expiresAt := scheduledTime.Add(20 * time.Millisecond)
+	notification := &models.Notification{
+		ID:          "test-11",
+		Title:       "Expiring Notification",
+		Content:     "Only useful for a moment",
+		Channel:     models.ChannelSlack,
+		Recipients:  []string{"test-user"},
+		ScheduledAt: &scheduledTime,
+		ExpiresAt:   &expiresAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+
+	// The scheduler was not started, so the notification is dispatched late
+	time.Sleep(50 * time.Millisecond)
+	scheduler.dispatchDue()
+
+	if len(counter.SentNotifications()) != 0 {
+		t.Errorf("Expected expired notification not to be sent, got %d sends", len(counter.SentNotifications()))
+	}
+	stored, _ := repo.GetByID(context.Background(), "", "test-11")
+	if stored.Status != models.StatusExpired {
+		t.Errorf("Expected status %s, got %s", models.StatusExpired, stored.Status)
+	}
+
+	expiresAt = scheduledTime
+	notification.ID = "test-12"
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err == nil {
+		t.Error("Expected error for expiry before the scheduled time")
+	}
+}
+
+func TestSweepExpired(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repo)
+
+	// The scheduler is not started, so only the sweeper can discard them
+	scheduledTime := time.Now().Add(10 * time.Millisecond)
+	expiresAt := time.Now().Add(20 * time.Millisecond)
+	oneOff := &models.Notification{
+		ID: "test-13", Title: "One-off", Content: "Expires before it is dispatched", Channel: models.ChannelSlack,
+		Recipients: []string{"test-user"}, ScheduledAt: &scheduledTime, ExpiresAt: &expiresAt, CreatedAt: time.Now(),
+	}
+	recurring := &models.Notification{
+		ID: "test-14", Title: "Recurring", Content: "Expires between runs", Channel: models.ChannelSlack,
+		Recipients: []string{"test-user"}, ExpiresAt: &expiresAt, CreatedAt: time.Now(),
+	}
+	if err := scheduler.ScheduleNotification(context.Background(), oneOff); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+	if err := scheduler.ScheduleRecurring(recurring, "@hourly"); err != nil {
+		t.Fatalf("Failed to schedule recurring notification: %v", err)
+	}
+
+	scheduler.sweepExpired()
+	if scheduler.QueueDepth() != 1 {
+		t.Fatalf("Expected unexpired notification to stay queued, got depth %d", scheduler.QueueDepth())
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	scheduler.sweepExpired()
+	if scheduler.QueueDepth() != 0 {
+		t.Errorf("Expected expired notification to leave the queue, got depth %d", scheduler.QueueDepth())
+	}
+	if len(scheduler.cron.Entries()) != 2 {
+		t.Errorf("Expected only the dispatch and sweep cron entries, got %d", len(scheduler.cron.Entries()))
+	}
+	for _, id := range []string{"test-13", "test-14"} {
+		stored, _ := repo.GetByID(context.Background(), "", id)
+		if stored.Status != models.StatusExpired {
+			t.Errorf("Expected %s to be %s, got %s", id, models.StatusExpired, stored.Status)
+		}
+		if err := scheduler.CancelNotification("", id); !errors.Is(err, ErrNotificationNotPending) {
+			t.Errorf("Expected ErrNotificationNotPending cancelling %s, got %v", id, err)
+		}
+	}
+}
+
+func TestValidateCronExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"0 9 * * MON", false},
+		{"*/30 * * * * *", false},
+		{"@hourly", false},
+		{"@every 5m", false},
+		{"not a cron", true},
+		{"61 * * * *", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			err := ValidateCronExpression(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// blockingService holds each send until release is closed.
+type blockingService struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingService) Send(ctx context.Context, notification *models.Notification) error {
+	s.started <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func TestSchedulerServiceStopDrainsInFlightSends(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		releaseAfter time.Duration
+		minStop      time.Duration
+		maxStop      time.Duration
+	}{
+		{"Waits for send to finish", time.Second, 100 * time.Millisecond, 100 * time.Millisecond, 900 * time.Millisecond},
+		{"Abandons send after timeout", 100 * time.Millisecond, time.Second, 100 * time.Millisecond, 900 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &blockingService{started: make(chan struct{}, 1), release: make(chan struct{})}
+			defer close(sender.release)
+			scheduler := NewSchedulerService(sender, repository.NewMemoryRepository())
+			scheduler.WithDrainTimeout(tt.drainTimeout)
+			scheduler.Start()
+
+			scheduledAt := time.Now().Add(10 * time.Millisecond)
+			notification := &models.Notification{ID: "drain", Channel: models.ChannelSlack, ScheduledAt: &scheduledAt}
+			if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+				t.Fatalf("Failed to schedule notification: %v", err)
+			}
+			select {
+			case <-sender.started:
+			case <-time.After(3 * time.Second):
+				t.Fatal("Expected the scheduled send to start")
+			}
+			if inFlight := scheduler.inFlightNotifications(); len(inFlight) != 1 || inFlight[0].ID != "drain" {
+				t.Fatalf("Expected the send to be in flight, got %v", inFlight)
+			}
+
+			release := time.AfterFunc(tt.releaseAfter, func() { sender.release <- struct{}{} })
+			defer release.Stop()
+			start := time.Now()
+			scheduler.Stop()
+			elapsed := time.Since(start)
+			if elapsed < tt.minStop || elapsed > tt.maxStop {
+				t.Errorf("Expected Stop to take between %v and %v, got %v", tt.minStop, tt.maxStop, elapsed)
+			}
+		})
+	}
+}
+
+func TestRescheduleNotification(t *testing.T) {
+	counter := &mock.MockNotificationService{}
+	repo := repository.NewMemoryRepository()
+	scheduler := NewSchedulerService(counter, repo)
+
+	scheduledTime := time.Now().Add(20 * time.Millisecond)
+	expiresAt := time.Now().Add(time.Hour)
+	notification := &models.Notification{ID: "test-15", TenantID: "acme", Channel: models.ChannelSlack, ScheduledAt: &scheduledTime, ExpiresAt: &expiresAt}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+	if err := scheduler.ScheduleRecurring(&models.Notification{ID: "test-16", TenantID: "acme"}, "@hourly"); err != nil {
+		t.Fatalf("Failed to schedule recurring notification: %v", err)
+	}
+
+	later := time.Now().Add(30 * time.Minute)
+	tests := []struct {
+		name        string
+		tenantID    string
+		id          string
+		scheduledAt time.Time
+		expectedErr error
+	}{
+		{"Other tenant", "globex", "test-15", later, repository.ErrNotFound},
+		{"Past time", "acme", "test-15", time.Now().Add(-time.Minute), ErrScheduledTimeNotInFuture},
+		{"After expiry", "acme", "test-15", expiresAt.Add(time.Minute), ErrExpiryNotAfterSchedule},
+		{"Recurring notification", "acme", "test-16", later, ErrRecurringNotification},
+		{"Pending notification", "acme", "test-15", later, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := scheduler.RescheduleNotification(tt.tenantID, tt.id, tt.scheduledAt); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	// The old time passes without the notification being sent
+	time.Sleep(50 * time.Millisecond)
+	scheduler.dispatchDue()
+	if len(counter.SentNotifications()) != 0 {
+		t.Fatalf("Expected no send at the old time, got %d", len(counter.SentNotifications()))
+	}
+	stored, _ := repo.GetByID(context.Background(), "acme", "test-15")
+	if !stored.ScheduledAt.Equal(later) {
+		t.Errorf("Expected stored scheduled time %v, got %v", later, stored.ScheduledAt)
+	}
+
+	if err := scheduler.RescheduleNotification("acme", "test-15", time.Now().Add(10*time.Millisecond)); err != nil {
+		t.Fatalf("Failed to reschedule notification: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	scheduler.dispatchDue()
+	if len(counter.SentNotifications()) != 1 {
+		t.Errorf("Expected a send at the new time, got %d", len(counter.SentNotifications()))
+	}
+	if err := scheduler.RescheduleNotification("acme", "test-15", later); !errors.Is(err, ErrNotificationNotPending) {
+		t.Errorf("Expected ErrNotificationNotPending after sending, got %v", err)
+	}
+}
+
+func TestSchedulerServiceRestoresAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	if err := repo.SaveTenant(ctx, &models.Tenant{ID: "acme"}); err != nil {
+		t.Fatalf("Failed to save tenant: %v", err)
+	}
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repo)
+
+	due := time.Now().Add(20 * time.Millisecond)
+	later := time.Now().Add(time.Hour)
+	expiresAt := due.Add(10 * time.Millisecond)
+	for _, notification := range []*models.Notification{
+		{ID: "due", Channel: models.ChannelSlack, Recipients: []string{"U1"}, ScheduledAt: &due},
+		{ID: "later", TenantID: "acme", Channel: models.ChannelSlack, Recipients: []string{"U1"}, ScheduledAt: &later},
+		{ID: "expiring", Channel: models.ChannelSlack, Recipients: []string{"U1"}, ScheduledAt: &due, ExpiresAt: &expiresAt},
+	} {
+		if err := scheduler.ScheduleNotification(ctx, notification); err != nil {
+			t.Fatalf("Failed to schedule notification: %v", err)
+		}
+	}
+	if err := scheduler.ScheduleRecurring(&models.Notification{ID: "recurring", TenantID: "acme", Channel: models.ChannelSlack, Recipients: []string{"U1"}}, "@hourly"); err != nil {
+		t.Fatalf("Failed to schedule recurring notification: %v", err)
+	}
+
+	// The service stops before anything is sent and starts again after the
+	// first notifications came due
+	time.Sleep(50 * time.Millisecond)
+	counter := &mock.MockNotificationService{}
+	restarted := NewSchedulerService(counter, repo)
+	restarted.Start()
+	defer restarted.Stop()
+
+	jobs, err := restarted.Jobs(ctx)
+	if err != nil || len(jobs) != 3 {
+		t.Fatalf("Expected the due, later and recurring jobs, got %+v (%v)", jobs, err)
+	}
+	stored, _ := repo.GetByID(ctx, "", "expiring")
+	if stored.Status != models.StatusExpired {
+		t.Errorf("Expected the expired notification to be marked %s, got %s", models.StatusExpired, stored.Status)
+	}
+
+	restarted.dispatchDue()
+	if sent := counter.SentNotifications(); len(sent) != 1 || sent[0].ID != "due" {
+		t.Errorf("Expected the due notification to be sent, got %+v", sent)
+	}
+	if err := restarted.CancelNotification("acme", "recurring"); err != nil {
+		t.Errorf("Failed to cancel restored recurring notification: %v", err)
+	}
+	if err := restarted.CancelNotification("acme", "later"); err != nil {
+		t.Errorf("Failed to cancel restored notification: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"time"
+)
+
+// outboxBatchSize is how many outbox emails are delivered per poll.
+const outboxBatchSize = 100
+
+// OutboxWorker delivers the emails queued in an EmailOutbox, each with the
+// SMTP server of its notification's tenant. Every poll it sends the oldest
+// emails, deleting those that are sent and counting a retry for those that
+// fail. The outcome for each recipient is recorded on the notification once
+// its email is sent or given up on. An email that has failed maxRetries
+// times stays in the outbox and its notification is marked failed.
+//
+// Delivery is at least once: an email sent just before the process stops
+// is sent again if its row was not yet deleted.
+type OutboxWorker struct {
+	outbox       repository.EmailOutbox
+	repository   repository.NotificationRepository
+	factory      *NotificationServiceFactory
+	pollInterval time.Duration
+	maxRetries   int
+	logger       logging.Logger
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+func NewOutboxWorker(outbox repository.EmailOutbox, repo repository.NotificationRepository, factory *NotificationServiceFactory, pollInterval time.Duration, maxRetries int) *OutboxWorker {
+	return &OutboxWorker{
+		outbox:       outbox,
+		repository:   repo,
+		factory:      factory,
+		pollInterval: pollInterval,
+		maxRetries:   maxRetries,
+		logger:       logging.Default(),
+	}
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (w *OutboxWorker) WithLogger(logger logging.Logger) {
+	w.logger = logger
+}
+
+// Start delivers queued emails every poll interval until Stop is called.
+func (w *OutboxWorker) Start() {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				w.deliverQueued(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop stops polling and waits for the emails being delivered.
+func (w *OutboxWorker) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop = nil
+}
+
+// deliverQueued delivers one batch of queued emails.
+func (w *OutboxWorker) deliverQueued(ctx context.Context) {
+	emails, err := w.outbox.ListOutboxEmails(ctx, w.maxRetries, outboxBatchSize)
+	if err != nil {
+		w.logger.Error("Error listing outbox emails", "error", err)
+		return
+	}
+	for _, email := range emails {
+		w.deliver(ctx, email)
+	}
+}
+
+func (w *OutboxWorker) deliver(ctx context.Context, email *models.OutboxEmail) {
+	attrs := []any{"notification_id", email.NotificationID, "tenant_id", email.TenantID, "outbox_id", email.ID}
+	var recorded models.Notification
+	err := w.factory.ForTenant(email.TenantID).email.deliver(ctx, email, recorded.RecordRecipient)
+	if err == nil {
+		if err := w.outbox.DeleteOutboxEmail(ctx, email.ID); err != nil {
+			w.logger.Error("Error deleting delivered outbox email", append(attrs, "error", err)...)
+		}
+		// The notification was marked sent when queued
+		update := repository.StatusUpdate{Status: models.StatusSent, RecipientStatuses: recorded.RecipientStatuses}
+		if err := w.repository.UpdateStatus(ctx, email.TenantID, email.NotificationID, update); err != nil {
+			w.logger.Error("Error recording recipient statuses", append(attrs, "error", err)...)
+		}
+		return
+	}
+
+	retries := email.RetryCount + 1
+	w.logger.Warn("Error delivering outbox email", append(attrs, "retry_count", retries, "error", err)...)
+	if err := w.outbox.RecordOutboxEmailFailure(ctx, email.ID, err.Error()); err != nil {
+		w.logger.Error("Error recording outbox email failure", append(attrs, "error", err)...)
+		return
+	}
+	if retries < w.maxRetries {
+		return
+	}
+
+	w.logger.Error("Giving up on outbox email", append(attrs, "retry_count", retries)...)
+	update := repository.StatusUpdate{Status: models.StatusFailed, FailureReason: err.Error(), RecipientStatuses: recorded.RecipientStatuses}
+	if err := w.repository.UpdateStatus(ctx, email.TenantID, email.NotificationID, update); err != nil {
+		w.logger.Error("Error marking notification failed", append(attrs, "error", err)...)
+	}
+}
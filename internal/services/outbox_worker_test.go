@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"net"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutboxWorker(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+	repo := repository.NewMemoryRepository()
+	factory := NewNotificationServiceFactory(&config.Config{SMTPHost: host, SMTPPort: port, SMTPFrom: "noreply@company.com", SMTPTLSMode: "none", HTTPTimeout: time.Second})
+	factory.WithEmailOutbox(repo)
+
+	ctx := context.Background()
+	notification := &models.Notification{
+		ID:         "email-1",
+		Title:      "Weekly Report",
+		Content:    "Your report is ready.",
+		Channel:    models.ChannelEmail,
+		Recipients: []string{"a@example.com"},
+		Metadata:   map[string]string{EmailBCCMetadataKey: "audit@example.com"},
+	}
+	repo.Save(ctx, notification)
+
+	service, _ := factory.GetService(models.ChannelEmail)
+	if err := service.Send(ctx, notification); err != nil {
+		t.Fatalf("Failed to queue email: %v", err)
+	}
+	select {
+	case <-envelopes:
+		t.Fatal("Expected the email to wait in the outbox")
+	default:
+	}
+	stored, _ := repo.GetByID(ctx, "", "email-1")
+	if stored.Status != models.StatusSent || stored.SentAt == nil {
+		t.Errorf("Expected the notification marked sent once queued, got %s", stored.Status)
+	}
+
+	worker := NewOutboxWorker(repo, repo, factory, time.Minute, 3)
+	worker.deliverQueued(ctx)
+	select {
+	case env := <-envelopes:
+		if strings.Join(env.Recipients, ",") != "a@example.com,audit@example.com" {
+			t.Errorf("Expected the envelope to keep the blind copy, got %v", env.Recipients)
+		}
+		if !strings.Contains(env.Data, "Subject: Weekly Report\r\n") || strings.Contains(env.Data, "audit@example.com") {
+			t.Errorf("Expected the queued message without the blind copy, got %q", env.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SMTP envelope")
+	}
+	if emails, _ := repo.ListOutboxEmails(ctx, 3, 10); len(emails) != 0 {
+		t.Errorf("Expected the delivered email to be deleted, got %d", len(emails))
+	}
+	if stored, _ := repo.GetByID(ctx, "", "email-1"); len(stored.RecipientStatuses) != 2 || stored.Status != models.StatusSent {
+		t.Errorf("Expected a sent status for both envelope recipients, got %s %+v", stored.Status, stored.RecipientStatuses)
+	}
+}
+
+func TestOutboxWorkerRetries(t *testing.T) {
+	// A port nothing listens on
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	host, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+	port, _ := strconv.Atoi(portStr)
+
+	repo := repository.NewMemoryRepository()
+	factory := NewNotificationServiceFactory(&config.Config{SMTPHost: host, SMTPPort: port, SMTPFrom: "noreply@company.com", SMTPTLSMode: "none", HTTPTimeout: time.Second})
+	factory.WithEmailOutbox(repo)
+
+	ctx := context.Background()
+	notification := &models.Notification{ID: "email-2", Title: "Report", Content: "Ready", Channel: models.ChannelEmail, Recipients: []string{"a@example.com"}}
+	repo.Save(ctx, notification)
+	service, _ := factory.GetService(models.ChannelEmail)
+	if err := service.Send(ctx, notification); err != nil {
+		t.Fatalf("Failed to queue email: %v", err)
+	}
+
+	worker := NewOutboxWorker(repo, repo, factory, time.Minute, 2)
+	worker.deliverQueued(ctx)
+	emails, _ := repo.ListOutboxEmails(ctx, 2, 10)
+	if len(emails) != 1 || emails[0].RetryCount != 1 || !strings.Contains(emails[0].LastError, "failed to connect") {
+		t.Fatalf("Expected one failed delivery to be counted, got %+v", emails)
+	}
+	if stored, _ := repo.GetByID(ctx, "", "email-2"); stored.Status != models.StatusSent {
+		t.Errorf("Expected the notification to stay sent while retries remain, got %s", stored.Status)
+	}
+
+	worker.deliverQueued(ctx)
+	if emails, _ := repo.ListOutboxEmails(ctx, 2, 10); len(emails) != 0 {
+		t.Errorf("Expected the email to be given up on, got %d", len(emails))
+	}
+	if stored, _ := repo.GetByID(ctx, "", "email-2"); stored.Status != models.StatusFailed || stored.FailureReason == "" {
+		t.Errorf("Expected the notification marked failed, got %s %q", stored.Status, stored.FailureReason)
+	}
+}
+
+func TestOutboxSkipsUnstoredNotifications(t *testing.T) {
+	host, port, envelopes := startFakeSMTPServer(t)
+	repo := repository.NewMemoryRepository()
+	service := &EmailNotificationService{Host: host, Port: port, FromAddress: "noreply@company.com", TLSMode: SMTPTLSNone, Timeout: time.Second, Outbox: repo}
+
+	if err := service.Send(context.Background(), &models.Notification{ID: "digest", Title: "Digest", Content: "3 notifications", Recipients: []string{"a@example.com"}}); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	select {
+	case <-envelopes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a notification that is not stored to be sent straight away")
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+)
+
+var pagerDutySeverities = map[string]bool{
+	"critical": true,
+	"error":    true,
+	"warning":  true,
+	"info":     true,
+}
+
+// PagerDutyNotificationService triggers incidents through the PagerDuty
+// Events API v2. When RoutingKey is empty the notification is only printed
+// to stdout.
+type PagerDutyNotificationService struct {
+	EventsURL  string
+	RoutingKey string
+	Client     *http.Client
+	Logger     logging.Logger
+}
+
+func NewPagerDutyNotificationService(cfg *config.Config) *PagerDutyNotificationService {
+	return &PagerDutyNotificationService{
+		EventsURL:  cfg.PagerDutyEventsURL,
+		RoutingKey: cfg.PagerDutyRoutingKey,
+		Client:     &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key,omitempty"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	DedupKey string `json:"dedup_key"`
+}
+
+func (p *PagerDutyNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	severity := notification.Metadata["severity"]
+	if severity == "" {
+		severity = "error"
+	}
+	if !pagerDutySeverities[severity] {
+		return fmt.Errorf("invalid pagerduty severity %q: must be one of critical, error, warning, info", severity)
+	}
+
+	if p.RoutingKey == "" {
+		logDryRun(ctx, p.Logger, notification)
+		return nil
+	}
+
+	source := notification.Metadata["source"]
+	if source == "" {
+		source = "notification-service"
+	}
+
+	event := pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    notification.ID,
+		Payload: pagerDutyPayload{
+			Summary:  notification.Title,
+			Source:   source,
+			Severity: severity,
+			CustomDetails: map[string]interface{}{
+				"details":    notification.Content,
+				"recipients": notification.Recipients,
+			},
+		},
+	}
+
+	var resp pagerDutyResponse
+	if err := postJSON(ctx, p.Client, "pagerduty", p.EventsURL, nil, event, &resp); err != nil {
+		return fmt.Errorf("failed to trigger pagerduty event: %w", err)
+	}
+
+	if notification.SentMetadata == nil {
+		notification.SentMetadata = make(map[string]string)
+	}
+	notification.SentMetadata["dedup_key"] = resp.DedupKey
+	return nil
+}
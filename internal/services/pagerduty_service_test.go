@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"testing"
+)
+
+func TestPagerDutyNotificationService(t *testing.T) {
+	var received pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(pagerDutyResponse{Status: "success", DedupKey: "dedup-123"})
+	}))
+	defer server.Close()
+
+	service := &PagerDutyNotificationService{EventsURL: server.URL, RoutingKey: "key", Client: server.Client()}
+	notification := &models.Notification{
+		ID:       "pd-1",
+		Title:    "Database down",
+		Content:  "Primary is unreachable",
+		Metadata: map[string]string{"severity": "critical"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send PagerDuty event: %v", err)
+	}
+	if received.Payload.Summary != "Database down" || received.Payload.Severity != "critical" {
+		t.Errorf("Unexpected payload: %+v", received.Payload)
+	}
+	if received.Payload.CustomDetails["details"] != "Primary is unreachable" {
+		t.Errorf("Expected content in custom details, got %v", received.Payload.CustomDetails)
+	}
+	if notification.SentMetadata["dedup_key"] != "dedup-123" {
+		t.Errorf("Expected dedup key to be recorded, got %v", notification.SentMetadata)
+	}
+}
+
+func TestPagerDutyNotificationServiceInvalidSeverity(t *testing.T) {
+	service := &PagerDutyNotificationService{}
+	notification := &models.Notification{Metadata: map[string]string{"severity": "urgent"}}
+
+	if err := service.Send(context.Background(), notification); err == nil {
+		t.Error("Expected error for invalid severity, got nil")
+	}
+}
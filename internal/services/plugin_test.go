@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testPlugin struct {
+	name string
+	mock.MockNotificationService
+}
+
+func (p *testPlugin) ChannelName() models.NotificationChannel {
+	return models.NotificationChannel(p.name)
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{
+		CircuitBreakers: map[string]config.CircuitBreakerConfig{"console": {FailureThreshold: 3, Timeout: time.Minute}},
+	})
+
+	tests := []struct {
+		name        string
+		plugin      string
+		expectError bool
+	}{
+		{"New channel", "console", false},
+		{"Duplicate plugin", "console", true},
+		{"Built-in channel", "slack", true},
+		{"Empty name", "", true},
+		{"Invalid name", "My Channel", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := factory.RegisterPlugin(&testPlugin{name: tt.plugin})
+			if tt.expectError && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+
+	service, err := factory.GetService("console")
+	if err != nil {
+		t.Fatalf("Expected plugin channel to be served, got %v", err)
+	}
+	if _, ok := service.(*CircuitBreakerNotificationService); !ok {
+		t.Errorf("Expected plugin to get the channel's circuit breaker, got %T", service)
+	}
+
+	factory.WithTenant(&models.Tenant{ID: "acme"})
+	tenantService, err := factory.ForTenant("acme").GetService("console")
+	if err != nil {
+		t.Fatalf("Expected plugin channel for tenant, got %v", err)
+	}
+	notification := &models.Notification{ID: "plugin-1", Channel: "console"}
+	if err := tenantService.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send through plugin: %v", err)
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+
+	if _, err := factory.LoadPlugins(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for a missing plugin directory, got nil")
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a plugin"), 0o644)
+	channels, err := factory.LoadPlugins(dir)
+	if err != nil || len(channels) != 0 {
+		t.Errorf("Expected no plugins from a directory without .so files, got %v, %v", channels, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "broken.so"), []byte("not a shared library"), 0o644)
+	if _, err := factory.LoadPlugins(dir); err == nil {
+		t.Error("Expected error for an invalid shared library, got nil")
+	}
+}
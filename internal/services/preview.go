@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"slices"
+	"strings"
+)
+
+// Preview formats reported by ChannelPreview.Format.
+const (
+	PreviewFormatPlain        = "plain"
+	PreviewFormatSlackMrkdwn  = "slack_mrkdwn"
+	PreviewFormatMarkdown     = "markdown"
+	PreviewFormatWhatsApp     = "whatsapp"
+	PreviewFormatHTML         = "html"
+	PreviewFormatAdaptiveCard = "adaptive_card"
+	PreviewFormatJSON         = "json"
+)
+
+// ChannelPreview is a notification formatted the way a channel's service
+// would send it, for showing to users before anything is sent.
+type ChannelPreview struct {
+	Channel models.NotificationChannel `json:"channel"`
+	// Format names the markup Text is written in, one of the
+	// PreviewFormat constants or a Telegram parse mode.
+	Format string `json:"format"`
+	// Title is set for channels that show the title apart from the text,
+	// such as the subject of an email.
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+	// HTML is the HTML body of emails that have one.
+	HTML string `json:"html,omitempty"`
+	// Parts are the messages an SMS too long for one is split into.
+	Parts []string `json:"parts,omitempty"`
+	// Truncated is set when the channel shortens the content to fit.
+	Truncated bool `json:"truncated,omitempty"`
+	// Hints explain how clients render Text and what changes on sending.
+	Hints []string `json:"hints,omitempty"`
+}
+
+// Preview formats notification for channel without sending it. Channels
+// added by plugins are previewed as plain text. It fails where sending
+// would fail before reaching the provider, such as on an unsupported
+// Telegram parse mode or an unknown email template.
+func (f *NotificationServiceFactory) Preview(ctx context.Context, notification *models.Notification, channel models.NotificationChannel) (*ChannelPreview, error) {
+	preview := &ChannelPreview{Channel: channel, Format: PreviewFormatPlain, Text: notification.Content}
+	switch channel {
+	case models.ChannelSlack:
+		mentions := make([]string, len(notification.Recipients))
+		for i, recipient := range notification.Recipients {
+			mentions[i] = formatSlackMention(recipient)
+		}
+		preview.Format = PreviewFormatSlackMrkdwn
+		preview.Text = formatSlackText(notification, mentions)
+		preview.Hints = []string{"Slack mrkdwn: *bold*, _italic_, ~strike~, `code` and <https://example.com|links>"}
+		if slices.ContainsFunc(notification.Recipients, func(recipient string) bool { return strings.HasPrefix(recipient, "@") }) {
+			preview.Hints = append(preview.Hints, "@display-name recipients are looked up and mentioned by user ID when sent")
+		}
+		if data, ok := notification.Metadata[SlackBlocksMetadataKey]; ok {
+			if _, err := parseSlackBlocks(data); err != nil {
+				preview.Hints = append(preview.Hints, "The Block Kit blocks in metadata are malformed and will be ignored: "+err.Error())
+			} else {
+				preview.Hints = append(preview.Hints, "Clients show the Block Kit blocks in metadata in place of the text")
+			}
+		}
+	case models.ChannelEmail:
+		preview.Title = notification.Title
+		if err := f.previewEmail(ctx, notification, preview); err != nil {
+			return nil, err
+		}
+	case models.ChannelMessage:
+		preview.Parts = SplitSMS(notification.Content)
+		if len(preview.Parts) > 1 {
+			encoding := "UCS-2"
+			if isGSM7(notification.Content) {
+				encoding = "GSM-7"
+			}
+			preview.Hints = []string{fmt.Sprintf("Sent as %d %s messages", len(preview.Parts), encoding)}
+		}
+	case models.ChannelWhatsApp:
+		if name := notification.Metadata["template"]; name != "" {
+			preview.Hints = []string{"The approved WhatsApp template " + name + " is sent in place of the text"}
+		}
+		preview.Format = PreviewFormatWhatsApp
+		preview.Text = buildWhatsAppMessage("", notification).Text.Body
+		preview.Hints = append(preview.Hints, "WhatsApp formatting: *bold*, _italic_, ~strike~ and ```monospace```")
+	case models.ChannelTelegram:
+		parseMode := notification.Metadata["parse_mode"]
+		if parseMode == "" {
+			parseMode = TelegramParseModeMarkdownV2
+		}
+		if parseMode != TelegramParseModeMarkdownV2 && parseMode != TelegramParseModeHTML {
+			return nil, fmt.Errorf("unsupported telegram parse_mode %q: must be %s or %s",
+				parseMode, TelegramParseModeMarkdownV2, TelegramParseModeHTML)
+		}
+		preview.Format = parseMode
+		preview.Text = buildTelegramText(notification.Title, notification.Content, parseMode)
+		preview.Truncated = preview.Text != formatTelegramText(notification.Title, notification.Content, parseMode)
+		preview.Hints = []string{"Title and content are escaped, so their own markup is shown as typed"}
+	case models.ChannelDiscord:
+		preview.Format = PreviewFormatMarkdown
+		preview.Title = notification.Title
+		f.mu.RLock()
+		limit := channelLimits(f.config)[channel]
+		f.mu.RUnlock()
+		if limit.TruncatePolicy == config.TruncationTruncate && limit.MaxContentLength > 0 {
+			preview.Text, preview.Truncated = truncateContent(notification.Content, limit.MaxContentLength)
+		}
+		preview.Hints = []string{"Shown as an embed with the title above the description"}
+	case models.ChannelTeams:
+		preview.Format = PreviewFormatAdaptiveCard
+		preview.Title = notification.Title
+		preview.Hints = []string{"Shown as an Adaptive Card; TextBlocks support a subset of Markdown"}
+		if color := notification.Metadata["color"]; color != "" && !teamsCardColors[color] {
+			preview.Hints = append(preview.Hints, "The color "+color+" is not an Adaptive Card color and will be ignored")
+		}
+	case models.ChannelPagerDuty, models.ChannelFCM, models.ChannelAPNs:
+		preview.Title = notification.Title
+	case models.ChannelWebhook:
+		preview.Format = PreviewFormatJSON
+		preview.Title = notification.Title
+		preview.Hints = []string{"The whole notification is POSTed as JSON and signed"}
+	default:
+		preview.Title = notification.Title
+	}
+	return preview, nil
+}
+
+// previewEmail fills in the body of an email preview. Tracking and
+// unsubscribe links are left out, as they are only added when sent.
+func (f *NotificationServiceFactory) previewEmail(ctx context.Context, notification *models.Notification, preview *ChannelPreview) error {
+	preview.Hints = []string{"Open tracking, click tracking and unsubscribe links are added when sent"}
+	if name := notification.Metadata["html_template"]; name != "" {
+		templates := f.email.Templates
+		if templates == nil {
+			return fmt.Errorf("html_template %s requested but no template service is configured", name)
+		}
+		htmlBody, textBody, err := templates.RenderEmail(ctx, name, notification)
+		if err != nil {
+			return err
+		}
+		preview.Format, preview.HTML, preview.Text = PreviewFormatHTML, htmlBody, textBody
+		return nil
+	}
+	if notification.Metadata[EmailContentTypeMetadataKey] == "text/html" {
+		preview.Format, preview.HTML, preview.Text = PreviewFormatHTML, notification.Content, htmlToText(notification.Content)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{})
+	long := strings.Repeat("a", 200)
+
+	tests := []struct {
+		name            string
+		channel         models.NotificationChannel
+		notification    models.Notification
+		expectedFormat  string
+		expectedText    string
+		expectedParts   int
+		expectTruncated bool
+	}{
+		{
+			name:           "Slack mentions recipients",
+			channel:        models.ChannelSlack,
+			notification:   models.Notification{Title: "Deploy", Content: "Done", Recipients: []string{"U123"}},
+			expectedFormat: PreviewFormatSlackMrkdwn,
+			expectedText:   "*Deploy*\nDone\n<@U123>",
+		},
+		{
+			name:           "HTML email",
+			channel:        models.ChannelEmail,
+			notification:   models.Notification{Title: "Hi", Content: "<p>Hello <b>Ana</b></p>", Metadata: map[string]string{EmailContentTypeMetadataKey: "text/html"}},
+			expectedFormat: PreviewFormatHTML,
+			expectedText:   "Hello Ana",
+		},
+		{
+			name:           "Long SMS",
+			channel:        models.ChannelMessage,
+			notification:   models.Notification{Content: long},
+			expectedFormat: PreviewFormatPlain,
+			expectedText:   long,
+			expectedParts:  2,
+		},
+		{
+			name:           "Telegram escapes markup",
+			channel:        models.ChannelTelegram,
+			notification:   models.Notification{Title: "v1.2", Content: "ok"},
+			expectedFormat: TelegramParseModeMarkdownV2,
+			expectedText:   "*v1\\.2*\nok",
+		},
+		{
+			name:            "Discord truncates",
+			channel:         models.ChannelDiscord,
+			notification:    models.Notification{Title: "Long", Content: strings.Repeat("a", discordMaxContentLength+1)},
+			expectedFormat:  PreviewFormatMarkdown,
+			expectedText:    strings.Repeat("a", discordMaxContentLength-1) + "…",
+			expectTruncated: true,
+		},
+		{
+			name:           "Webhook",
+			channel:        models.ChannelWebhook,
+			notification:   models.Notification{Title: "Hook", Content: "Body"},
+			expectedFormat: PreviewFormatJSON,
+			expectedText:   "Body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview, err := factory.Preview(context.Background(), &tt.notification, tt.channel)
+			if err != nil {
+				t.Fatalf("Failed to preview: %v", err)
+			}
+			if preview.Format != tt.expectedFormat {
+				t.Errorf("Expected format %s, got %s", tt.expectedFormat, preview.Format)
+			}
+			if strings.TrimSpace(preview.Text) != tt.expectedText {
+				t.Errorf("Expected text %q, got %q", tt.expectedText, preview.Text)
+			}
+			if len(preview.Parts) != tt.expectedParts {
+				t.Errorf("Expected %d parts, got %d", tt.expectedParts, len(preview.Parts))
+			}
+			if preview.Truncated != tt.expectTruncated {
+				t.Errorf("Expected truncated %v, got %v", tt.expectTruncated, preview.Truncated)
+			}
+		})
+	}
+
+	if _, err := factory.Preview(context.Background(), &models.Notification{Metadata: map[string]string{"html_template": "welcome"}}, models.ChannelEmail); err == nil {
+		t.Error("Expected an html_template without a template service to fail")
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"container/heap"
+	"notification-service/internal/models"
+)
+
+// PriorityQueue orders notifications by descending priority, then by
+// scheduled time, then by creation time.
+type PriorityQueue struct {
+	items notificationHeap
+}
+
+func NewPriorityQueue() *PriorityQueue {
+	return &PriorityQueue{}
+}
+
+func (q *PriorityQueue) Len() int {
+	return q.items.Len()
+}
+
+func (q *PriorityQueue) Push(notification *models.Notification) {
+	heap.Push(&q.items, notification)
+}
+
+// Pop removes and returns the most urgent notification, or nil if the queue
+// is empty.
+func (q *PriorityQueue) Pop() *models.Notification {
+	if q.items.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(&q.items).(*models.Notification)
+}
+
+type notificationHeap []*models.Notification
+
+func (h notificationHeap) Len() int { return len(h) }
+
+func (h notificationHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if a.ScheduledAt != nil && b.ScheduledAt != nil && !a.ScheduledAt.Equal(*b.ScheduledAt) {
+		return a.ScheduledAt.Before(*b.ScheduledAt)
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+func (h notificationHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *notificationHeap) Push(x interface{}) {
+	*h = append(*h, x.(*models.Notification))
+}
+
+func (h *notificationHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
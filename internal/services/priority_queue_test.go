@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Minute)
+	queue := NewPriorityQueue()
+
+	queue.Push(&models.Notification{ID: "normal-late", Priority: models.PriorityNormal, ScheduledAt: &now})
+	queue.Push(&models.Notification{ID: "low", Priority: models.PriorityLow, ScheduledAt: &earlier})
+	queue.Push(&models.Notification{ID: "critical", Priority: models.PriorityCritical, ScheduledAt: &now})
+	queue.Push(&models.Notification{ID: "normal-early", Priority: models.PriorityNormal, ScheduledAt: &earlier})
+	queue.Push(&models.Notification{ID: "high", Priority: models.PriorityHigh, ScheduledAt: &now})
+
+	expected := []string{"critical", "high", "normal-early", "normal-late", "low"}
+	for _, id := range expected {
+		notification := queue.Pop()
+		if notification == nil || notification.ID != id {
+			t.Fatalf("Expected %s, got %+v", id, notification)
+		}
+	}
+	if queue.Pop() != nil || queue.Len() != 0 {
+		t.Error("Expected queue to be empty")
+	}
+}
+
+func TestSchedulerDispatchesByPriority(t *testing.T) {
+	recorder := &mock.MockNotificationService{}
+	scheduler := NewSchedulerService(recorder, nil)
+
+	scheduledAt := time.Now().Add(50 * time.Millisecond)
+	priorities := map[string]models.NotificationPriority{
+		"low":      models.PriorityLow,
+		"critical": models.PriorityCritical,
+		"normal":   models.PriorityNormal,
+		"high":     models.PriorityHigh,
+	}
+	for id, priority := range priorities {
+		err := scheduler.ScheduleNotification(context.Background(), &models.Notification{
+			ID:          id,
+			Recipients:  []string{id},
+			Priority:    priority,
+			ScheduledAt: &scheduledAt,
+		})
+		if err != nil {
+			t.Fatalf("Failed to schedule %s: %v", id, err)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	scheduler.dispatchDue()
+
+	expected := []string{"critical", "high", "normal", "low"}
+	sent := recorder.SentNotifications()
+	if len(sent) != len(expected) {
+		t.Fatalf("Expected %d sends, got %d", len(expected), len(sent))
+	}
+	for i, id := range expected {
+		if sent[i].ID != id {
+			t.Errorf("Expected send %d to be %s, got %s", i, id, sent[i].ID)
+		}
+	}
+}
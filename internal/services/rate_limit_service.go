@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"sync"
+	"time"
+)
+
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+// TokenBucket is a thread-safe token-bucket limiter refilled at rate tokens
+// per second up to burst tokens.
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token, blocking until one is available. It returns
+// ErrRateLimitExceeded if ctx is done, or its deadline would pass, first.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		wait, ok := b.take()
+		if ok {
+			return nil
+		}
+
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) < wait {
+			return ErrRateLimitExceeded
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %v", ErrRateLimitExceeded, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// take consumes a token if one is available, otherwise it reports how long
+// until the next token is due.
+func (b *TokenBucket) take() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	if b.rate <= 0 {
+		return time.Hour, false
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second)), false
+}
+
+// RateLimitedNotificationService throttles a wrapped service with a token
+// bucket. The limit can be changed or lifted while sends are in flight.
+type RateLimitedNotificationService struct {
+	service NotificationService
+	bucket  *TokenBucket
+	mu      sync.RWMutex
+}
+
+func NewRateLimitedNotificationService(service NotificationService, requestsPerSecond float64, burst int) *RateLimitedNotificationService {
+	return &RateLimitedNotificationService{
+		service: service,
+		bucket:  NewTokenBucket(requestsPerSecond, burst),
+	}
+}
+
+// SetLimit replaces the token bucket with a full one of the given rate and
+// burst. A non-positive rate lifts the limit.
+func (r *RateLimitedNotificationService) SetLimit(requestsPerSecond float64, burst int) {
+	var bucket *TokenBucket
+	if requestsPerSecond > 0 {
+		bucket = NewTokenBucket(requestsPerSecond, burst)
+	}
+	r.mu.Lock()
+	r.bucket = bucket
+	r.mu.Unlock()
+}
+
+// Send waits for a token until ctx's deadline before sending. Critical
+// notifications bypass the limit.
+func (r *RateLimitedNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	r.mu.RLock()
+	bucket := r.bucket
+	r.mu.RUnlock()
+	if bucket == nil || notification.Priority == models.PriorityCritical {
+		return r.service.Send(ctx, notification)
+	}
+	if err := bucket.Wait(ctx); err != nil {
+		return fmt.Errorf("%s notification %s: %w", notification.Channel, notification.ID, err)
+	}
+	return r.service.Send(ctx, notification)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedNotificationService(t *testing.T) {
+	inner := &mock.MockNotificationService{}
+	service := NewRateLimitedNotificationService(inner, 1, 2)
+	notification := &models.Notification{ID: "rl", Channel: models.ChannelSlack}
+
+	// The burst allows two immediate sends
+	for i := 0; i < 2; i++ {
+		if err := service.Send(context.Background(), notification); err != nil {
+			t.Fatalf("Unexpected error on send %d: %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := service.Send(ctx, notification)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Fatalf("Expected ErrRateLimitExceeded, got %v", err)
+	}
+	if len(inner.SentNotifications()) != 2 {
+		t.Errorf("Expected 2 calls to wrapped service, got %d", len(inner.SentNotifications()))
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	bucket := NewTokenBucket(50, 1)
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected to wait for a refill, waited %s", elapsed)
+	}
+}
+
+func TestNotificationServiceFactoryAppliesRateLimits(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{
+		RateLimits: map[string]config.RateLimitConfig{"slack": {RequestsPerSecond: 1, Burst: 1}},
+	})
+
+	slack, _ := factory.GetService(models.ChannelSlack)
+	if _, ok := slack.(*RateLimitedNotificationService); !ok {
+		t.Errorf("Expected rate-limited Slack service, got %T", slack)
+	}
+	email, _ := factory.GetService(models.ChannelEmail)
+	if _, ok := email.(*RateLimitedNotificationService); ok {
+		t.Error("Expected email service without rate limit")
+	}
+}
+
+func TestRateLimitedNotificationServiceCriticalBypass(t *testing.T) {
+	inner := &mock.MockNotificationService{}
+	service := NewRateLimitedNotificationService(inner, 0.001, 1)
+
+	normal := &models.Notification{ID: "rl-normal", Channel: models.ChannelSlack, Priority: models.PriorityNormal}
+	if err := service.Send(context.Background(), normal); err != nil {
+		t.Fatalf("Unexpected error on first send: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := service.Send(ctx, normal); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded for normal priority, got %v", err)
+	}
+
+	critical := &models.Notification{ID: "rl-critical", Channel: models.ChannelSlack, Priority: models.PriorityCritical}
+	for i := 0; i < 3; i++ {
+		if err := service.Send(ctx, critical); err != nil {
+			t.Errorf("Expected critical send %d to bypass the limit, got %v", i, err)
+		}
+	}
+	if len(inner.SentNotifications()) != 4 {
+		t.Errorf("Expected 4 sends, got %d", len(inner.SentNotifications()))
+	}
+}
+
+func TestRateLimitedNotificationServiceSetLimit(t *testing.T) {
+	inner := &mock.MockNotificationService{}
+	service := NewRateLimitedNotificationService(inner, 0.001, 1)
+	notification := &models.Notification{ID: "rl-set", Channel: models.ChannelSlack}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	service.Send(ctx, notification)
+	if err := service.Send(ctx, notification); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Fatalf("Expected ErrRateLimitExceeded before the limit changes, got %v", err)
+	}
+
+	service.SetLimit(0, 0)
+	for i := 0; i < 3; i++ {
+		if err := service.Send(ctx, notification); err != nil {
+			t.Errorf("Expected send %d to be unlimited, got %v", i, err)
+		}
+	}
+
+	service.SetLimit(0.001, 2)
+	for i := 0; i < 2; i++ {
+		if err := service.Send(ctx, notification); err != nil {
+			t.Errorf("Expected send %d within the new burst, got %v", i, err)
+		}
+	}
+	if err := service.Send(ctx, notification); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded once the new burst is used, got %v", err)
+	}
+}
+
+func TestNotificationServiceFactorySetRateLimits(t *testing.T) {
+	factory := NewNotificationServiceFactory(&config.Config{
+		RateLimits: map[string]config.RateLimitConfig{"slack": {RequestsPerSecond: 1, Burst: 1}},
+	})
+
+	unapplied := factory.SetRateLimits(map[string]config.RateLimitConfig{
+		"slack": {RequestsPerSecond: 5, Burst: 10},
+		"email": {RequestsPerSecond: 1, Burst: 1},
+	})
+	if len(unapplied) != 1 || unapplied[0] != models.ChannelEmail {
+		t.Errorf("Expected email limit to need a restart, got %v", unapplied)
+	}
+
+	factory.WithTenant(&models.Tenant{ID: "acme"})
+	if burst := factory.ForTenant("acme").config.RateLimits["slack"].Burst; burst != 10 {
+		t.Errorf("Expected new tenants to use the reloaded burst of 10, got %d", burst)
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/logging"
+	"notification-service/internal/metrics"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/tracing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redis keys shared by every instance using the Redis scheduler. The sorted
+// set scores each scheduled notification by its Unix time; the hash holds
+// the notifications as JSON. Both are keyed by scheduleKey.
+const (
+	redisScheduleKey = "notifications:scheduled"
+	redisPayloadKey  = "notifications:scheduled:payloads"
+)
+
+// redisClaimBatch is the most notifications claimed in one round trip.
+const redisClaimBatch = 100
+
+// claimDueScript atomically removes up to ARGV[2] notifications scored at or
+// before ARGV[1] and returns their payloads, so each due notification is
+// claimed by exactly one instance.
+var claimDueScript = redis.NewScript(`
+local keys = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local payloads = {}
+for _, key in ipairs(keys) do
+	redis.call('ZREM', KEYS[1], key)
+	local payload = redis.call('HGET', KEYS[2], key)
+	redis.call('HDEL', KEYS[2], key)
+	if payload then
+		table.insert(payloads, payload)
+	end
+end
+return payloads
+`)
+
+// cancelScript removes notification ARGV[1] from the schedule and returns 1,
+// or returns 0 if it was not scheduled.
+var cancelScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1
+`)
+
+// rescheduleScript moves notification ARGV[1] to score ARGV[2] with payload
+// ARGV[3] and returns 1, or returns 0 if it is no longer scheduled.
+var rescheduleScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[2], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+return 1
+`)
+
+// RedisSchedulerService keeps one-off scheduled notifications in Redis so
+// several instances can share them: whichever instance polls first after a
+// notification is due sends it, and no other does. Recurring notifications
+// run on the instance that scheduled them, as with SchedulerService.
+type RedisSchedulerService struct {
+	client       *redis.Client
+	local        *SchedulerService
+	logger       logging.Logger
+	pollInterval time.Duration
+	now          func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRedisSchedulerService creates a scheduler that queues notifications in
+// client's database and records status transitions in repo. A nil repo
+// disables persistence.
+func NewRedisSchedulerService(client *redis.Client, notificationService NotificationService, repo repository.NotificationRepository) *RedisSchedulerService {
+	return &RedisSchedulerService{
+		client:       client,
+		local:        NewSchedulerService(notificationService, repo),
+		logger:       logging.Default(),
+		pollInterval: time.Second,
+		now:          time.Now,
+	}
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (s *RedisSchedulerService) WithLogger(logger logging.Logger) {
+	s.logger = logger
+	s.local.WithLogger(logger)
+}
+
+// WithDrainTimeout sets how long Stop waits for sends in flight, as
+// SchedulerService.WithDrainTimeout does.
+func (s *RedisSchedulerService) WithDrainTimeout(timeout time.Duration) {
+	s.local.WithDrainTimeout(timeout)
+}
+
+// WithAuditLogger records the transitions of notifications this instance
+// sends, as SchedulerService.WithAuditLogger does.
+func (s *RedisSchedulerService) WithAuditLogger(audit AuditLogger) {
+	s.local.WithAuditLogger(audit)
+}
+
+// WithCallbackService reports the outcome of notifications this instance
+// sends to their callback URLs.
+func (s *RedisSchedulerService) WithCallbackService(callbacks *CallbackService) {
+	s.local.WithCallbackService(callbacks)
+}
+
+// WithMetrics records the schedule drift of notifications this instance
+// sends, as SchedulerService.WithMetrics does.
+func (s *RedisSchedulerService) WithMetrics(collector *metrics.MetricsCollector) {
+	s.local.WithMetrics(collector)
+}
+
+// WithTracer traces each call to ScheduleNotification with tracer, as
+// SchedulerService.WithTracer does.
+func (s *RedisSchedulerService) WithTracer(tracer trace.Tracer) {
+	s.local.WithTracer(tracer)
+}
+
+// QueueDepth returns the number of one-off notifications waiting in Redis
+// across every instance, or 0 if Redis cannot be reached.
+func (s *RedisSchedulerService) QueueDepth() int {
+	depth, err := s.client.ZCard(context.Background(), redisScheduleKey).Result()
+	if err != nil {
+		return 0
+	}
+	return int(depth)
+}
+
+// Jobs returns the one-off notifications waiting in Redis across every
+// instance and the recurring notifications of this instance, soonest first.
+func (s *RedisSchedulerService) Jobs(ctx context.Context) ([]ScheduledJob, error) {
+	payloads, err := s.client.HGetAll(ctx, redisPayloadKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled notifications: %w", err)
+	}
+	jobs, _ := s.local.Jobs(ctx)
+	for _, payload := range payloads {
+		var notification models.Notification
+		if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+			s.logger.Error("Error decoding scheduled notification", "error", err)
+			continue
+		}
+		jobs = append(jobs, newScheduledJob(&notification, 0, *notification.ScheduledAt))
+	}
+	sortScheduledJobs(jobs)
+	return jobs, nil
+}
+
+// Start polls Redis for due notifications in the background until Stop is
+// called.
+func (s *RedisSchedulerService) Start() {
+	s.local.Start()
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.dispatchDue(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop stops polling and waits for notifications being sent to finish, for
+// up to the drain timeout. Notifications still queued stay in Redis for
+// other instances.
+func (s *RedisSchedulerService) Stop() {
+	cronDone := s.local.stopCron()
+	pollDone := s.done
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-cronDone
+		if pollDone != nil {
+			<-pollDone
+		}
+	}()
+	s.local.drain(done)
+}
+
+// Running reports whether the scheduler has been started and not stopped.
+func (s *RedisSchedulerService) Running() bool {
+	return s.local.Running()
+}
+
+// Ping checks that Redis can be reached.
+func (s *RedisSchedulerService) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Close closes the Redis client.
+func (s *RedisSchedulerService) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisSchedulerService) ScheduleNotification(ctx context.Context, notification *models.Notification) (err error) {
+	ctx, span := tracing.Start(ctx, s.local.tracer, "SchedulerService.ScheduleNotification", tracing.NotificationAttributes(notification)...)
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.local.storeScheduled(notification); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode scheduled notification: %v", err)
+	}
+	key := scheduleKey(notification.TenantID, notification.ID)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisPayloadKey, key, payload)
+		pipe.ZAdd(ctx, redisScheduleKey, redis.Z{Score: redisScore(*notification.ScheduledAt), Member: key})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue scheduled notification: %v", err)
+	}
+
+	s.logger.Info("Scheduled notification", logging.NotificationAttrs(notification, "scheduled_at", notification.ScheduledAt)...)
+	return nil
+}
+
+// ScheduleRecurring sends notification every time expr matches until the
+// notification is cancelled. The schedule is kept by this instance only.
+func (s *RedisSchedulerService) ScheduleRecurring(notification *models.Notification, expr string) error {
+	return s.local.ScheduleRecurring(notification, expr)
+}
+
+// CancelNotification removes a tenant's pending scheduled or recurring
+// notification and marks it cancelled, with the same errors as
+// SchedulerService.CancelNotification.
+func (s *RedisSchedulerService) CancelNotification(tenantID, id string) error {
+	removed, err := cancelScript.Run(context.Background(), s.client, []string{redisScheduleKey, redisPayloadKey}, scheduleKey(tenantID, id)).Int()
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled notification: %v", err)
+	}
+	if removed == 0 {
+		return s.local.CancelNotification(tenantID, id)
+	}
+	return s.local.markCancelled(tenantID, id)
+}
+
+// RescheduleNotification moves a tenant's pending one-off notification to
+// scheduledAt, with the same errors as
+// SchedulerService.RescheduleNotification.
+func (s *RedisSchedulerService) RescheduleNotification(tenantID, id string, scheduledAt time.Time) error {
+	if !scheduledAt.After(s.now()) {
+		return ErrScheduledTimeNotInFuture
+	}
+	ctx := context.Background()
+	key := scheduleKey(tenantID, id)
+
+	payload, err := s.client.HGet(ctx, redisPayloadKey, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return s.local.RescheduleNotification(tenantID, id, scheduledAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled notification: %v", err)
+	}
+	var notification models.Notification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return fmt.Errorf("failed to decode scheduled notification: %v", err)
+	}
+	if notification.ExpiresAt != nil && !notification.ExpiresAt.After(scheduledAt) {
+		return ErrExpiryNotAfterSchedule
+	}
+
+	notification.ScheduledAt = &scheduledAt
+	updated, err := json.Marshal(&notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode scheduled notification: %v", err)
+	}
+	// Another instance may have claimed the notification since it was read
+	moved, err := rescheduleScript.Run(ctx, s.client, []string{redisScheduleKey, redisPayloadKey}, key, redisScore(scheduledAt), updated).Int()
+	if err != nil {
+		return fmt.Errorf("failed to reschedule notification: %v", err)
+	}
+	if moved == 0 {
+		return ErrNotificationNotPending
+	}
+	if s.local.repository != nil {
+		if err := s.local.repository.UpdateScheduledAt(ctx, tenantID, id, scheduledAt); err != nil {
+			return fmt.Errorf("failed to store rescheduled notification: %w", err)
+		}
+	}
+
+	s.logger.Info("Rescheduled notification", logging.NotificationAttrs(&notification, "scheduled_at", scheduledAt)...)
+	return nil
+}
+
+// dispatchDue claims every notification due by now and sends each batch
+// highest priority first.
+func (s *RedisSchedulerService) dispatchDue(ctx context.Context) {
+	now := redisScore(s.now())
+	for {
+		payloads, err := claimDueScript.Run(ctx, s.client, []string{redisScheduleKey, redisPayloadKey}, now, redisClaimBatch).StringSlice()
+		if err != nil {
+			s.logger.Error("Error claiming scheduled notifications", "error", err)
+			return
+		}
+
+		queue := NewPriorityQueue()
+		for _, payload := range payloads {
+			var notification models.Notification
+			if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+				s.logger.Error("Error decoding scheduled notification", "error", err)
+				continue
+			}
+			queue.Push(&notification)
+		}
+		for queue.Len() > 0 {
+			s.local.send(queue.Pop())
+		}
+
+		if len(payloads) < redisClaimBatch {
+			return
+		}
+	}
+}
+
+// redisScore is t as fractional Unix seconds.
+func redisScore(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
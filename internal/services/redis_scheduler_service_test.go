@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisScheduler(t *testing.T, server *miniredis.Miniredis, service NotificationService, repo repository.NotificationRepository) *RedisSchedulerService {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisSchedulerService(client, service, repo)
+}
+
+func TestRedisSchedulerServiceSharedQueue(t *testing.T) {
+	server := miniredis.RunT(t)
+	repo := repository.NewMemoryRepository()
+	sender := &mock.MockNotificationService{}
+	first := newTestRedisScheduler(t, server, sender, repo)
+	second := newTestRedisScheduler(t, server, sender, repo)
+
+	scheduledAt := time.Now().Add(time.Hour)
+	for i := 0; i < 150; i++ {
+		notification := &models.Notification{ID: fmt.Sprintf("redis-%d", i), Channel: models.ChannelSlack, ScheduledAt: &scheduledAt}
+		if err := first.ScheduleNotification(context.Background(), notification); err != nil {
+			t.Fatalf("Failed to schedule notification: %v", err)
+		}
+	}
+	if depth := second.QueueDepth(); depth != 150 {
+		t.Fatalf("Expected the queue to be shared, got depth %d", depth)
+	}
+
+	// Both instances poll once the notifications are due
+	var wg sync.WaitGroup
+	for _, scheduler := range []*RedisSchedulerService{first, second} {
+		scheduler.now = func() time.Time { return scheduledAt }
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.dispatchDue(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	sent := make(map[string]int)
+	for _, notification := range sender.SentNotifications() {
+		sent[notification.ID]++
+	}
+	if len(sent) != 150 {
+		t.Errorf("Expected every notification to be sent, got %d", len(sent))
+	}
+	for id, count := range sent {
+		if count != 1 {
+			t.Errorf("Expected %s to be sent once, got %d", id, count)
+		}
+	}
+	if depth := first.QueueDepth(); depth != 0 {
+		t.Errorf("Expected an empty queue, got depth %d", depth)
+	}
+	if stored, _ := repo.GetByID(context.Background(), "", "redis-0"); stored.Status != models.StatusSent {
+		t.Errorf("Expected status %s, got %s", models.StatusSent, stored.Status)
+	}
+}
+
+func TestRedisSchedulerServiceCancel(t *testing.T) {
+	server := miniredis.RunT(t)
+	repo := repository.NewMemoryRepository()
+	sender := &mock.MockNotificationService{}
+	scheduler := newTestRedisScheduler(t, server, sender, repo)
+
+	scheduledAt := time.Now().Add(time.Hour)
+	if err := scheduler.ScheduleNotification(context.Background(), &models.Notification{ID: "redis-cancel", TenantID: "acme", ScheduledAt: &scheduledAt}); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+	if err := scheduler.ScheduleRecurring(&models.Notification{ID: "redis-recurring", TenantID: "acme"}, "@daily"); err != nil {
+		t.Fatalf("Failed to schedule recurring notification: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		tenantID    string
+		id          string
+		expectedErr error
+	}{
+		{"Other tenant", "globex", "redis-cancel", repository.ErrNotFound},
+		{"Scheduled notification", "acme", "redis-cancel", nil},
+		{"Cancelled twice", "acme", "redis-cancel", ErrNotificationNotPending},
+		{"Recurring notification", "acme", "redis-recurring", nil},
+		{"Unknown notification", "acme", "missing", repository.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := scheduler.CancelNotification(tt.tenantID, tt.id); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	stored, _ := repo.GetByID(context.Background(), "acme", "redis-cancel")
+	if stored.Status != models.StatusCancelled {
+		t.Errorf("Expected status %s, got %s", models.StatusCancelled, stored.Status)
+	}
+	if depth := scheduler.QueueDepth(); depth != 0 {
+		t.Errorf("Expected an empty queue, got depth %d", depth)
+	}
+}
+
+func TestRedisSchedulerServiceJobs(t *testing.T) {
+	server := miniredis.RunT(t)
+	sender := &mock.MockNotificationService{}
+	first := newTestRedisScheduler(t, server, sender, nil)
+	second := newTestRedisScheduler(t, server, sender, nil)
+
+	scheduledAt := time.Now().Add(time.Hour)
+	first.ScheduleNotification(context.Background(), &models.Notification{ID: "redis-later", Channel: models.ChannelSlack, ScheduledAt: &scheduledAt})
+	sooner := scheduledAt.Add(-time.Minute)
+	first.ScheduleNotification(context.Background(), &models.Notification{ID: "redis-sooner", Channel: models.ChannelSlack, ScheduledAt: &sooner})
+	second.ScheduleRecurring(&models.Notification{ID: "redis-recurring"}, "@daily")
+
+	jobs, err := first.Jobs(context.Background())
+	if err != nil || len(jobs) != 2 || jobs[0].NotificationID != "redis-sooner" || jobs[1].NotificationID != "redis-later" {
+		t.Fatalf("Expected the shared one-off jobs soonest first, got %+v (%v)", jobs, err)
+	}
+	if jobs, _ := second.Jobs(context.Background()); len(jobs) != 3 {
+		t.Errorf("Expected the shared jobs and the local recurring job, got %+v", jobs)
+	}
+}
+
+func TestRedisSchedulerServiceExpiry(t *testing.T) {
+	server := miniredis.RunT(t)
+	repo := repository.NewMemoryRepository()
+	sender := &mock.MockNotificationService{}
+	scheduler := newTestRedisScheduler(t, server, sender, repo)
+
+	scheduledAt := time.Now().Add(20 * time.Millisecond)
+	expiresAt := scheduledAt.Add(10 * time.Millisecond)
+	notification := &models.Notification{ID: "redis-expired", ScheduledAt: &scheduledAt, ExpiresAt: &expiresAt}
+	if err := scheduler.ScheduleNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	scheduler.dispatchDue(context.Background())
+	if len(sender.SentNotifications()) != 0 {
+		t.Error("Expected expired notification not to be sent")
+	}
+	if stored, _ := repo.GetByID(context.Background(), "", "redis-expired"); stored.Status != models.StatusExpired {
+		t.Errorf("Expected status %s, got %s", models.StatusExpired, stored.Status)
+	}
+}
+
+func TestRedisSchedulerServiceReschedule(t *testing.T) {
+	server := miniredis.RunT(t)
+	repo := repository.NewMemoryRepository()
+	sender := &mock.MockNotificationService{}
+	scheduler := newTestRedisScheduler(t, server, sender, repo)
+
+	scheduledAt := time.Now().Add(time.Hour)
+	if err := scheduler.ScheduleNotification(context.Background(), &models.Notification{ID: "redis-reschedule", TenantID: "acme", ScheduledAt: &scheduledAt}); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+	later := scheduledAt.Add(time.Hour)
+	if err := scheduler.RescheduleNotification("globex", "redis-reschedule", later); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected repository.ErrNotFound for another tenant, got %v", err)
+	}
+	if err := scheduler.RescheduleNotification("acme", "redis-reschedule", later); err != nil {
+		t.Fatalf("Failed to reschedule notification: %v", err)
+	}
+
+	scheduler.now = func() time.Time { return scheduledAt }
+	scheduler.dispatchDue(context.Background())
+	if len(sender.SentNotifications()) != 0 {
+		t.Error("Expected no send at the old time")
+	}
+	if depth := scheduler.QueueDepth(); depth != 1 {
+		t.Errorf("Expected the notification to stay queued, got depth %d", depth)
+	}
+	stored, _ := repo.GetByID(context.Background(), "acme", "redis-reschedule")
+	if !stored.ScheduledAt.Equal(later) {
+		t.Errorf("Expected stored scheduled time %v, got %v", later, stored.ScheduledAt)
+	}
+
+	soon := scheduledAt.Add(time.Minute)
+	if err := scheduler.RescheduleNotification("acme", "redis-reschedule", soon); err != nil {
+		t.Fatalf("Failed to reschedule notification: %v", err)
+	}
+	scheduler.now = func() time.Time { return soon }
+	scheduler.dispatchDue(context.Background())
+	sent := sender.SentNotifications()
+	if len(sent) != 1 || !sent[0].ScheduledAt.Equal(soon) {
+		t.Errorf("Expected one send carrying the new time, got %v", sent)
+	}
+	if err := scheduler.RescheduleNotification("acme", "redis-reschedule", later); !errors.Is(err, ErrNotificationNotPending) {
+		t.Errorf("Expected ErrNotificationNotPending after sending, got %v", err)
+	}
+}
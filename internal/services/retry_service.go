@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"notification-service/internal/models"
+	"time"
+)
+
+// RetryableError marks a failure as transient so RetryNotificationService
+// will try again.
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// PermanentError marks a failure that must not be retried.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// IsRetryable classifies err. Explicit RetryableError/PermanentError wrappers
+// win; otherwise rate limits, network failures and 429/503 responses are
+// retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var permanent *PermanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var retryable *RetryableError
+	if errors.As(err, &retryable) {
+		return true
+	}
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.StatusCode)
+	}
+	var slackErr *SlackError
+	if errors.As(err, &slackErr) {
+		return isRetryableStatus(slackErr.StatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// DefaultMaxRetryAfter is the longest Retry-After waited for when
+// RetryOptions.MaxRetryAfter is zero.
+const DefaultMaxRetryAfter = 30 * time.Second
+
+type RetryOptions struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	// Jitter randomises each delay by up to this fraction, e.g. 0.2 = ±20%.
+	Jitter float64
+	// MaxRetryAfter is the longest Retry-After a provider may ask for before
+	// the send gives up; zero uses DefaultMaxRetryAfter.
+	MaxRetryAfter time.Duration
+}
+
+// RetryNotificationService retries a wrapped service with exponential
+// backoff and jitter on retryable errors. A RateLimitError with a RetryAfter
+// waits that long instead, unless the wait is longer than MaxRetryAfter or
+// would outlast the context's deadline.
+type RetryNotificationService struct {
+	service NotificationService
+	options RetryOptions
+	// OnRetry, when set, is called before every attempt after the first.
+	OnRetry func()
+}
+
+func NewRetryNotificationService(service NotificationService, maxAttempts int, initialDelay time.Duration, jitter float64) *RetryNotificationService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &RetryNotificationService{
+		service: service,
+		options: RetryOptions{MaxAttempts: maxAttempts, InitialDelay: initialDelay, Jitter: jitter},
+	}
+}
+
+// Send stops waiting between attempts once ctx is done.
+func (r *RetryNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	var err error
+	for attempt := 1; attempt <= r.options.MaxAttempts; attempt++ {
+		err = r.service.Send(ctx, notification)
+		if err == nil || !IsRetryable(err) || attempt == r.options.MaxAttempts {
+			break
+		}
+
+		wait, ok := r.delay(ctx, attempt, err)
+		if !ok {
+			break
+		}
+		if ctxErr := sleepContext(ctx, wait); ctxErr != nil {
+			return fmt.Errorf("retry aborted after %d attempt(s): %w", attempt, ctxErr)
+		}
+		if r.OnRetry != nil {
+			r.OnRetry()
+		}
+	}
+	return err
+}
+
+// delay is how long to wait after attempt failed with err: the RetryAfter
+// the provider asked for, if any, or the backoff. It reports false for a
+// RetryAfter longer than MaxRetryAfter or ctx's remaining time, so callers
+// such as the scheduler get the rate limit error back instead of blocking.
+func (r *RetryNotificationService) delay(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter <= 0 {
+		return r.backoff(attempt), true
+	}
+	limit := r.options.MaxRetryAfter
+	if limit <= 0 {
+		limit = DefaultMaxRetryAfter
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		limit = min(limit, time.Until(deadline))
+	}
+	return rateLimitErr.RetryAfter, rateLimitErr.RetryAfter <= limit
+}
+
+func (r *RetryNotificationService) backoff(attempt int) time.Duration {
+	delay := r.options.InitialDelay << (attempt - 1)
+	if r.options.Jitter > 0 {
+		delta := float64(delay) * r.options.Jitter
+		delay += time.Duration(delta * (2*rand.Float64() - 1))
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"notification-service/internal/services/mock"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryNotificationService(t *testing.T) {
+	tests := []struct {
+		name          string
+		errs          []error
+		expectedCalls int
+		expectError   bool
+	}{
+		{
+			name:          "Succeeds after retryable errors",
+			errs:          []error{&APIError{StatusCode: http.StatusServiceUnavailable}, &RetryableError{Err: errors.New("io")}},
+			expectedCalls: 3,
+		},
+		{
+			name:          "Stops on permanent error",
+			errs:          []error{&PermanentError{Err: errors.New("bad request")}},
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name:          "Stops on unclassified error",
+			errs:          []error{&APIError{StatusCode: http.StatusBadRequest}},
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name: "Gives up after max attempts",
+			errs: []error{
+				&APIError{StatusCode: http.StatusTooManyRequests},
+				&APIError{StatusCode: http.StatusTooManyRequests},
+				&APIError{StatusCode: http.StatusTooManyRequests},
+				&APIError{StatusCode: http.StatusTooManyRequests},
+			},
+			expectedCalls: 3,
+			expectError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flaky := &mock.MockNotificationService{}
+			flaky.FailNext(tt.errs...)
+			service := NewRetryNotificationService(flaky, 3, time.Millisecond, 0.5)
+
+			err := service.Send(context.Background(), &models.Notification{ID: "retry"})
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got %v", tt.expectError, err)
+			}
+			if len(flaky.SentNotifications()) != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, len(flaky.SentNotifications()))
+			}
+		})
+	}
+}
+
+func TestRetryNotificationServiceContextCancellation(t *testing.T) {
+	flaky := &mock.MockNotificationService{}
+	flaky.FailNext(&RetryableError{Err: errors.New("io")})
+	service := NewRetryNotificationService(flaky, 3, time.Hour, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := service.Send(ctx, &models.Notification{ID: "retry"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(flaky.SentNotifications()) != 1 {
+		t.Errorf("Expected 1 call, got %d", len(flaky.SentNotifications()))
+	}
+}
+
+func TestRetryNotificationServiceRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		service func(url string) NotificationService
+	}{
+		{"Slack", func(url string) NotificationService { return NewSlackNotificationService(url, time.Second) }},
+		{"Discord", func(url string) NotificationService {
+			return &DiscordNotificationService{APIURL: url, BotToken: "secret"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Retry-After", "5")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			err := tt.service(server.URL).Send(context.Background(), &models.Notification{Title: "T", Content: "C", Recipients: []string{"111"}})
+			var rateLimitErr *RateLimitError
+			if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != 5*time.Second {
+				t.Fatalf("Expected a RateLimitError to retry after 5s, got %v", err)
+			}
+
+			// The backoff alone would retry within the deadline, but
+			// Retry-After outlasts it, so the send gives up at once
+			requests.Store(0)
+			service := NewRetryNotificationService(tt.service(server.URL), 3, time.Millisecond, 0)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			started := time.Now()
+			err = service.Send(ctx, &models.Notification{Title: "T", Content: "C", Recipients: []string{"111"}})
+			if !errors.As(err, &rateLimitErr) || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the RateLimitError without waiting for the deadline, got %v", err)
+			}
+			if elapsed := time.Since(started); elapsed > time.Second {
+				t.Errorf("Expected the send to give up without waiting, took %s", elapsed)
+			}
+			if requests.Load() != 1 {
+				t.Errorf("Expected 1 request, got %d", requests.Load())
+			}
+		})
+	}
+}
+
+func TestRetryNotificationServiceMaxRetryAfter(t *testing.T) {
+	tests := []struct {
+		name             string
+		retryAfter       time.Duration
+		maxRetryAfter    time.Duration
+		expectedAttempts int
+	}{
+		{"Within the maximum", 10 * time.Millisecond, time.Second, 2},
+		{"Over the maximum", time.Hour, time.Second, 1},
+		{"Over the default maximum", time.Hour, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited := &mock.MockNotificationService{}
+			limited.SetError(&RateLimitError{RetryAfter: tt.retryAfter, Err: errors.New("rate limited")})
+			service := NewRetryNotificationService(limited, 2, 0, 0)
+			service.options.MaxRetryAfter = tt.maxRetryAfter
+
+			// Scheduled sends have no deadline
+			err := service.Send(context.Background(), &models.Notification{ID: "retry"})
+			var rateLimitErr *RateLimitError
+			if !errors.As(err, &rateLimitErr) {
+				t.Errorf("Expected the RateLimitError, got %v", err)
+			}
+			if len(limited.SentNotifications()) != tt.expectedAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.expectedAttempts, len(limited.SentNotifications()))
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"5", 5 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"-3", 0},
+		{"99999999999", time.Duration(math.MaxInt64/time.Second) * time.Second},
+		{"99999999999999999999999", time.Duration(math.MaxInt64/time.Second) * time.Second},
+		{"soon", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.expected {
+			t.Errorf("Expected Retry-After %q to wait %s, got %s", tt.header, tt.expected, got)
+		}
+	}
+}
+
+func TestNotificationServiceFactoryWithRetry(t *testing.T) {
+	factory := &NotificationServiceFactory{services: map[models.NotificationChannel]NotificationService{
+		models.ChannelSlack: &mock.MockNotificationService{},
+	}}
+
+	if err := factory.WithRetry(models.ChannelSlack, RetryOptions{MaxAttempts: 2}); err != nil {
+		t.Fatalf("Failed to wrap service: %v", err)
+	}
+	service, _ := factory.GetService(models.ChannelSlack)
+	if _, ok := service.(*RetryNotificationService); !ok {
+		t.Errorf("Expected RetryNotificationService, got %T", service)
+	}
+
+	if err := factory.WithRetry("invalid-channel", RetryOptions{}); err == nil {
+		t.Error("Expected error for unsupported channel")
+	}
+}
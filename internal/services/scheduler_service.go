@@ -1,89 +1,763 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"notification-service/internal/logging"
+	"notification-service/internal/metrics"
 	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/tracing"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrNotificationNotPending is returned when cancelling a notification that
+// has already fired.
+var ErrNotificationNotPending = errors.New("notification is no longer pending")
+
+// ErrRecurringNotification is returned when rescheduling a recurring
+// notification, whose runs follow its cron expression.
+var ErrRecurringNotification = errors.New("recurring notifications cannot be rescheduled")
+
+// ErrAlreadyScheduled is returned when scheduling a notification whose ID is
+// already waiting in the scheduler.
+var ErrAlreadyScheduled = errors.New("notification is already scheduled")
+
+// Errors for scheduled times a notification cannot be scheduled at.
+var (
+	ErrScheduledTimeNotInFuture = errors.New("scheduled time must be in the future")
+	ErrExpiryNotAfterSchedule   = errors.New("expiry must be after the scheduled time")
+)
+
+// expirySweepInterval is how often scheduled notifications are checked for
+// expiry.
+const expirySweepInterval = time.Minute
+
+// DefaultDrainTimeout is how long Stop waits for sends in flight unless
+// WithDrainTimeout sets otherwise.
+const DefaultDrainTimeout = 30 * time.Second
+
+// cronParser accepts standard five-field expressions, an optional leading
+// seconds field and descriptors such as @hourly or @every 5m.
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ValidateCronExpression reports whether expr can be used with ScheduleRecurring.
+func ValidateCronExpression(expr string) error {
+	if _, err := cronParser.Parse(expr); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return nil
+}
+
+// Scheduler defers notifications to a later time or a recurring schedule.
+// SchedulerService implements it.
+type Scheduler interface {
+	ScheduleNotification(ctx context.Context, notification *models.Notification) error
+	ScheduleRecurring(notification *models.Notification, expr string) error
+	CancelNotification(tenantID, id string) error
+	RescheduleNotification(tenantID, id string, scheduledAt time.Time) error
+}
+
 type SchedulerService struct {
 	cron                *cron.Cron
 	notificationService NotificationService
-	jobs                map[string]cron.EntryID
-	mu                  sync.RWMutex
+	repository          repository.NotificationRepository
+	logger              logging.Logger
+	audit               AuditLogger
+	callbacks           *CallbackService
+	metrics             *metrics.MetricsCollector
+	tracer              trace.Tracer
+	drainTimeout        time.Duration
+	// pending holds one-off notifications until they are due; jobs holds
+	// recurring ones. Both are keyed by scheduleKey.
+	pending map[string]*models.Notification
+	jobs    map[string]recurringJob
+	running atomic.Bool
+	mu      sync.RWMutex
+
+	// inFlight holds the notifications being sent, keyed by a sequence
+	// number since runs of a recurring notification can overlap.
+	inFlight   map[uint64]*models.Notification
+	inFlightID uint64
+	inFlightMu sync.Mutex
+}
+
+// recurringJob is a recurring notification and its cron entry.
+type recurringJob struct {
+	entryID      cron.EntryID
+	notification *models.Notification
+}
+
+// ScheduledJob is a notification waiting in the scheduler, for inspecting
+// its state.
+type ScheduledJob struct {
+	// JobID is the cron entry of a recurring notification, and zero for a
+	// one-off one.
+	JobID          int                        `json:"job_id,omitempty"`
+	NotificationID string                     `json:"notification_id"`
+	TenantID       string                     `json:"tenant_id,omitempty"`
+	Channel        models.NotificationChannel `json:"channel"`
+	Recipients     []string                   `json:"recipients"`
+	CronExpr       string                     `json:"cron_expression,omitempty"`
+	// FiresAt is when the notification is next sent. It is zero for a
+	// recurring notification until the scheduler starts.
+	FiresAt time.Time `json:"fires_at"`
+}
+
+func newScheduledJob(notification *models.Notification, jobID int, firesAt time.Time) ScheduledJob {
+	return ScheduledJob{
+		JobID:          jobID,
+		NotificationID: notification.ID,
+		TenantID:       notification.TenantID,
+		Channel:        notification.Channel,
+		Recipients:     slices.Clone(notification.Recipients),
+		CronExpr:       notification.CronExpr,
+		FiresAt:        firesAt,
+	}
+}
+
+// sortScheduledJobs orders jobs by when they fire, soonest first.
+func sortScheduledJobs(jobs []ScheduledJob) {
+	slices.SortFunc(jobs, func(a, b ScheduledJob) int {
+		return a.FiresAt.Compare(b.FiresAt)
+	})
 }
 
-func NewSchedulerService(notificationService NotificationService) *SchedulerService {
-	return &SchedulerService{
-		cron:                cron.New(cron.WithSeconds()),
+// NewSchedulerService creates a scheduler that records status transitions in
+// repo. A nil repo disables persistence.
+func NewSchedulerService(notificationService NotificationService, repo repository.NotificationRepository) *SchedulerService {
+	s := &SchedulerService{
+		cron:                cron.New(cron.WithParser(cronParser)),
 		notificationService: notificationService,
-		jobs:                make(map[string]cron.EntryID),
+		repository:          repo,
+		logger:              logging.Default(),
+		drainTimeout:        DefaultDrainTimeout,
+		pending:             make(map[string]*models.Notification),
+		jobs:                make(map[string]recurringJob),
+		inFlight:            make(map[uint64]*models.Notification),
+	}
+	s.cron.AddFunc("@every 1s", s.dispatchDue)
+	s.cron.Schedule(cron.Every(expirySweepInterval), cron.FuncJob(s.sweepExpired))
+	return s
+}
+
+// WithLogger replaces the logger, which defaults to slog.Default().
+func (s *SchedulerService) WithLogger(logger logging.Logger) {
+	s.logger = logger
+}
+
+// WithDrainTimeout sets how long Stop waits for sends in flight. A
+// non-positive timeout waits for as long as they take.
+func (s *SchedulerService) WithDrainTimeout(timeout time.Duration) {
+	s.drainTimeout = timeout
+}
+
+// WithAuditLogger records the transitions the scheduler makes: sends,
+// failures, expiries and recurring notifications rescheduled after a run.
+func (s *SchedulerService) WithAuditLogger(audit AuditLogger) {
+	s.audit = audit
+}
+
+// WithCallbackService reports the outcome of every send to the
+// notification's callback URL.
+func (s *SchedulerService) WithCallbackService(callbacks *CallbackService) {
+	s.callbacks = callbacks
+}
+
+// WithMetrics records in collector how long after its scheduled time each
+// one-off notification fires.
+func (s *SchedulerService) WithMetrics(collector *metrics.MetricsCollector) {
+	s.metrics = collector
+}
+
+// WithTracer traces each call to ScheduleNotification with tracer.
+func (s *SchedulerService) WithTracer(tracer trace.Tracer) {
+	s.tracer = tracer
+}
+
+// QueueDepth returns the number of one-off notifications waiting to be sent.
+func (s *SchedulerService) QueueDepth() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.pending)
+}
+
+// Jobs returns the recurring notifications, from their cron entries, and the
+// one-off notifications waiting to be sent, soonest first.
+func (s *SchedulerService) Jobs(ctx context.Context) ([]ScheduledJob, error) {
+	entries := s.cron.Entries()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	recurring := make(map[cron.EntryID]*models.Notification, len(s.jobs))
+	for _, job := range s.jobs {
+		recurring[job.entryID] = job.notification
 	}
+	jobs := make([]ScheduledJob, 0, len(s.jobs)+len(s.pending))
+	for _, entry := range entries {
+		// The dispatch and expiry sweep entries have no notification
+		if notification, ok := recurring[entry.ID]; ok {
+			jobs = append(jobs, newScheduledJob(notification, int(entry.ID), entry.Next))
+		}
+	}
+	for _, notification := range s.pending {
+		jobs = append(jobs, newScheduledJob(notification, 0, *notification.ScheduledAt))
+	}
+	sortScheduledJobs(jobs)
+	return jobs, nil
 }
 
+// Start starts sending scheduled notifications, after restoring those stored
+// as pending before a restart.
 func (s *SchedulerService) Start() {
+	s.restore()
 	s.cron.Start()
+	s.running.Store(true)
 }
 
+// Stop stops scheduling new sends and waits for those in flight to finish,
+// for up to the drain timeout.
 func (s *SchedulerService) Stop() {
-	s.cron.Stop()
+	s.drain(s.stopCron())
+}
+
+// stopCron stops the cron scheduler and returns a channel closed once its
+// running jobs have finished.
+func (s *SchedulerService) stopCron() <-chan struct{} {
+	s.running.Store(false)
+	return s.cron.Stop().Done()
+}
+
+// drain waits until done is closed or the drain timeout passes. It logs the
+// sends still in flight when it starts and those abandoned on timeout.
+func (s *SchedulerService) drain(done <-chan struct{}) {
+	for _, notification := range s.inFlightNotifications() {
+		s.logger.Info("Waiting for scheduled send to finish", logging.NotificationAttrs(notification)...)
+	}
+
+	var timeout <-chan time.Time
+	if s.drainTimeout > 0 {
+		timer := time.NewTimer(s.drainTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-done:
+	case <-timeout:
+		for _, notification := range s.inFlightNotifications() {
+			s.logger.Warn("Abandoned scheduled send after drain timeout", logging.NotificationAttrs(notification, "drain_timeout", s.drainTimeout)...)
+		}
+	}
+}
+
+// trackInFlight records notification as being sent until the returned
+// function is called.
+func (s *SchedulerService) trackInFlight(notification *models.Notification) func() {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	s.inFlightID++
+	id := s.inFlightID
+	s.inFlight[id] = notification
+	return func() {
+		s.inFlightMu.Lock()
+		defer s.inFlightMu.Unlock()
+		delete(s.inFlight, id)
+	}
+}
+
+// inFlightNotifications returns the notifications being sent, in the order
+// their sends started.
+func (s *SchedulerService) inFlightNotifications() []*models.Notification {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	ids := make([]uint64, 0, len(s.inFlight))
+	for id := range s.inFlight {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	notifications := make([]*models.Notification, len(ids))
+	for i, id := range ids {
+		notifications[i] = s.inFlight[id]
+	}
+	return notifications
+}
+
+// Running reports whether the scheduler has been started and not stopped.
+func (s *SchedulerService) Running() bool {
+	return s.running.Load()
+}
+
+// ScheduleNotification sends notification at its ScheduledAt. It returns
+// ErrAlreadyScheduled if a notification with the same ID is still waiting.
+func (s *SchedulerService) ScheduleNotification(ctx context.Context, notification *models.Notification) (err error) {
+	_, span := tracing.Start(ctx, s.tracer, "SchedulerService.ScheduleNotification", tracing.NotificationAttributes(notification)...)
+	defer func() { tracing.End(span, err) }()
+
+	key := scheduleKey(notification.TenantID, notification.ID)
+	if s.isScheduled(key) {
+		return ErrAlreadyScheduled
+	}
+	if err := s.storeScheduled(notification); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another call may have scheduled the same ID while this one was
+	// storing it
+	if s.scheduled(key) {
+		return ErrAlreadyScheduled
+	}
+	s.pending[key] = notification
+
+	s.logger.Info("Scheduled notification", logging.NotificationAttrs(notification, "scheduled_at", notification.ScheduledAt)...)
+	return nil
+}
+
+// isScheduled reports whether the notification with key is pending or
+// recurring.
+func (s *SchedulerService) isScheduled(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.scheduled(key)
 }
 
-func (s *SchedulerService) ScheduleNotification(notification *models.Notification) error {
+// scheduled is isScheduled for callers holding s.mu.
+func (s *SchedulerService) scheduled(key string) bool {
+	_, pending := s.pending[key]
+	_, recurring := s.jobs[key]
+	return pending || recurring
+}
+
+// storeScheduled checks notification's scheduled time and expiry and stores
+// it as pending.
+func (s *SchedulerService) storeScheduled(notification *models.Notification) error {
+	if err := checkSchedule(notification); err != nil {
+		return err
+	}
+
+	notification.Status = models.StatusPending
+	if s.repository != nil {
+		if err := s.repository.Save(context.Background(), notification); err != nil {
+			return fmt.Errorf("failed to store scheduled notification: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkSchedule reports whether notification can be scheduled: it needs a
+// scheduled time in the future and any expiry after it.
+func checkSchedule(notification *models.Notification) error {
 	if notification.ScheduledAt == nil {
 		return fmt.Errorf("scheduled time is required")
 	}
+	if !notification.ScheduledAt.After(time.Now()) {
+		return ErrScheduledTimeNotInFuture
+	}
+	if notification.ExpiresAt != nil && !notification.ExpiresAt.After(*notification.ScheduledAt) {
+		return ErrExpiryNotAfterSchedule
+	}
+	return nil
+}
+
+// dispatchDue sends every one-off notification whose scheduled time has
+// passed, highest priority first. Due notifications leave pending before
+// sending so a concurrent cancel cannot race them.
+func (s *SchedulerService) dispatchDue() {
+	now := time.Now()
+	queue := NewPriorityQueue()
+
+	s.mu.Lock()
+	for key, notification := range s.pending {
+		if !notification.ScheduledAt.After(now) {
+			queue.Push(notification)
+			delete(s.pending, key)
+		}
+	}
+	s.mu.Unlock()
 
-	delay := notification.ScheduledAt.Sub(time.Now())
-	if delay <= 0 {
-		return fmt.Errorf("scheduled time must be in the future")
+	for queue.Len() > 0 {
+		s.send(queue.Pop())
 	}
+}
 
-	// Create a one-time job that will run at the scheduled time
-	job := func() {
-		if err := s.notificationService.Send(notification); err != nil {
-			fmt.Printf("Error sending notification: %v\n", err)
+// ScheduleRecurring sends notification every time expr matches until the
+// notification is cancelled. Like ScheduleNotification, it returns
+// ErrAlreadyScheduled rather than adding a second cron entry for an ID.
+func (s *SchedulerService) ScheduleRecurring(notification *models.Notification, expr string) error {
+	if err := ValidateCronExpression(expr); err != nil {
+		return err
+	}
+	key := scheduleKey(notification.TenantID, notification.ID)
+	if s.isScheduled(key) {
+		return ErrAlreadyScheduled
+	}
+
+	notification.CronExpr = expr
+	notification.Status = models.StatusPending
+	if s.repository != nil {
+		if err := s.repository.Save(context.Background(), notification); err != nil {
+			return fmt.Errorf("failed to store recurring notification: %v", err)
 		}
-		// Remove the job after execution
-		s.mu.Lock()
-		if entryID, exists := s.jobs[notification.ID]; exists {
-			s.cron.Remove(entryID)
-			delete(s.jobs, notification.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scheduled(key) {
+		return ErrAlreadyScheduled
+	}
+	if err := s.addRecurring(key, notification); err != nil {
+		return err
+	}
+
+	s.logger.Info("Scheduled recurring notification", logging.NotificationAttrs(notification, "cron_expr", expr)...)
+	return nil
+}
+
+// addRecurring adds a cron entry sending notification on its CronExpr. The
+// caller must hold s.mu.
+func (s *SchedulerService) addRecurring(key string, notification *models.Notification) error {
+	entryID, err := s.cron.AddFunc(notification.CronExpr, func() {
+		// Runs can overlap, so each one sends its own copy
+		run := *notification
+		run.SentMetadata = nil
+		run.RecipientStatuses = nil
+		s.send(&run)
+		s.recordRescheduled(notification)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule recurring notification: %v", err)
+	}
+	s.jobs[key] = recurringJob{entryID: entryID, notification: notification}
+	return nil
+}
+
+// restore loads the pending one-off and recurring notifications of every
+// tenant from the repository, so they survive a restart. One-off
+// notifications that came due while the service was down are sent on the
+// next dispatch, and those that expired meanwhile are marked expired.
+func (s *SchedulerService) restore() {
+	if s.repository == nil {
+		return
+	}
+	ctx := context.Background()
+	tenantIDs := []string{""}
+	if tenants, ok := s.repository.(repository.TenantRepository); ok {
+		stored, err := tenants.ListTenants(ctx)
+		if err != nil {
+			s.logger.Error("Error listing tenants to restore scheduled notifications", "error", err)
+		}
+		for _, tenant := range stored {
+			tenantIDs = append(tenantIDs, tenant.ID)
 		}
-		s.mu.Unlock()
 	}
 
-	// Schedule the job
-	entryID, err := s.cron.AddFunc("@every 1s", func() {
-		now := time.Now()
-		if now.After(*notification.ScheduledAt) || now.Equal(*notification.ScheduledAt) {
-			job()
+	restored := 0
+	for _, tenantID := range tenantIDs {
+		notifications, err := s.repository.ListByStatus(ctx, tenantID, models.StatusPending)
+		if err != nil {
+			s.logger.Error("Error restoring scheduled notifications", "tenant_id", tenantID, "error", err)
+			continue
 		}
-	})
+		for _, notification := range notifications {
+			if s.restoreNotification(notification) {
+				restored++
+			}
+		}
+	}
+	if restored > 0 {
+		s.logger.Info("Restored scheduled notifications", "count", restored)
+	}
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to schedule notification: %v", err)
+// restoreNotification schedules a stored pending notification again and
+// reports whether it did. Notifications that were being sent immediately
+// rather than scheduled are left alone.
+func (s *SchedulerService) restoreNotification(notification *models.Notification) bool {
+	if notification.ScheduledAt == nil && notification.CronExpr == "" {
+		return false
+	}
+	if isExpired(notification, time.Now()) {
+		s.markExpired(notification)
+		return false
+	}
+
+	key := scheduleKey(notification.TenantID, notification.ID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scheduled(key) {
+		return false
+	}
+	if notification.CronExpr == "" {
+		s.pending[key] = notification
+		return true
 	}
+	if err := s.addRecurring(key, notification); err != nil {
+		s.logger.Error("Error restoring recurring notification", logging.NotificationAttrs(notification, "error", err)...)
+		return false
+	}
+	return true
+}
+
+// recordRescheduled records that a recurring notification is waiting for
+// its next run, unless it was cancelled or expired meanwhile.
+func (s *SchedulerService) recordRescheduled(notification *models.Notification) {
+	if s.audit == nil {
+		return
+	}
+	s.mu.RLock()
+	job, recurring := s.jobs[scheduleKey(notification.TenantID, notification.ID)]
+	s.mu.RUnlock()
+	if !recurring {
+		return
+	}
+	metadata := map[string]string{"cron_expr": notification.CronExpr}
+	if next := s.cron.Entry(job.entryID).Next; !next.IsZero() {
+		metadata["next_run"] = next.UTC().Format(time.RFC3339)
+	}
+	appendAudit(s.audit, s.logger, NewAuditEvent(models.AuditRescheduled, notification, ActorScheduler, metadata))
+}
 
-	// Store the job ID
+// CancelNotification removes a tenant's pending scheduled or recurring
+// notification and marks it cancelled. It returns repository.ErrNotFound for
+// unknown IDs and ErrNotificationNotPending if the notification has already
+// fired.
+func (s *SchedulerService) CancelNotification(tenantID, id string) error {
+	key := scheduleKey(tenantID, id)
 	s.mu.Lock()
-	s.jobs[notification.ID] = entryID
+	_, exists := s.pending[key]
+	delete(s.pending, key)
+	if job, recurring := s.jobs[key]; recurring {
+		s.cron.Remove(job.entryID)
+		delete(s.jobs, key)
+		exists = true
+	}
 	s.mu.Unlock()
 
-	fmt.Printf("Scheduled notification for %s\n", notification.ScheduledAt)
+	if !exists {
+		return s.notPending(tenantID, id)
+	}
+	return s.markCancelled(tenantID, id)
+}
+
+// notPending explains why a tenant's notification is not scheduled: it
+// returns repository.ErrNotFound for unknown IDs and otherwise
+// ErrNotificationNotPending.
+func (s *SchedulerService) notPending(tenantID, id string) error {
+	if s.repository == nil {
+		return repository.ErrNotFound
+	}
+	if _, err := s.repository.GetByID(context.Background(), tenantID, id); err != nil {
+		return err
+	}
+	return ErrNotificationNotPending
+}
+
+// RescheduleNotification moves a tenant's pending one-off notification to
+// scheduledAt. It returns repository.ErrNotFound for unknown IDs,
+// ErrNotificationNotPending if the notification has already fired,
+// ErrRecurringNotification for recurring notifications, and
+// ErrScheduledTimeNotInFuture or ErrExpiryNotAfterSchedule for times it
+// cannot be sent at.
+func (s *SchedulerService) RescheduleNotification(tenantID, id string, scheduledAt time.Time) error {
+	if !scheduledAt.After(time.Now()) {
+		return ErrScheduledTimeNotInFuture
+	}
+	key := scheduleKey(tenantID, id)
+
+	// The lock is held while storing the new time so the notification
+	// cannot be dispatched at the old one meanwhile
+	s.mu.Lock()
+	if _, recurring := s.jobs[key]; recurring {
+		s.mu.Unlock()
+		return ErrRecurringNotification
+	}
+	notification, exists := s.pending[key]
+	if !exists {
+		s.mu.Unlock()
+		return s.notPending(tenantID, id)
+	}
+	rescheduled, err := s.storeRescheduled(notification, scheduledAt)
+	if err == nil {
+		s.pending[key] = rescheduled
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Rescheduled notification", logging.NotificationAttrs(rescheduled, "scheduled_at", scheduledAt)...)
+	return nil
+}
+
+// storeRescheduled returns a copy of notification scheduled at scheduledAt,
+// after checking it has not expired by then and storing the new time.
+func (s *SchedulerService) storeRescheduled(notification *models.Notification, scheduledAt time.Time) (*models.Notification, error) {
+	if notification.ExpiresAt != nil && !notification.ExpiresAt.After(scheduledAt) {
+		return nil, ErrExpiryNotAfterSchedule
+	}
+	rescheduled := *notification
+	rescheduled.ScheduledAt = &scheduledAt
+	if s.repository != nil {
+		if err := s.repository.UpdateScheduledAt(context.Background(), notification.TenantID, notification.ID, scheduledAt); err != nil {
+			return nil, fmt.Errorf("failed to store rescheduled notification: %w", err)
+		}
+	}
+	return &rescheduled, nil
+}
+
+// markCancelled records that a tenant's notification was removed from the
+// schedule.
+func (s *SchedulerService) markCancelled(tenantID, id string) error {
+	if s.repository != nil {
+		update := repository.StatusUpdate{Status: models.StatusCancelled}
+		if err := s.repository.UpdateStatus(context.Background(), tenantID, id, update); err != nil {
+			return fmt.Errorf("failed to mark notification cancelled: %w", err)
+		}
+	}
+
+	attrs := []any{"notification_id", id}
+	if tenantID != "" {
+		attrs = append(attrs, "tenant_id", tenantID)
+	}
+	s.logger.Info("Cancelled scheduled notification", attrs...)
 	return nil
 }
 
+// scheduleKey identifies a scheduled notification within its tenant, so one
+// tenant cannot cancel another's.
+func scheduleKey(tenantID, id string) string {
+	return tenantID + "/" + id
+}
+
+// sweepExpired discards every scheduled or recurring notification whose
+// expiry has passed, so they do not wait for their next run to be dropped.
+func (s *SchedulerService) sweepExpired() {
+	now := time.Now()
+	var expired []*models.Notification
+
+	s.mu.Lock()
+	for key, notification := range s.pending {
+		if isExpired(notification, now) {
+			expired = append(expired, notification)
+			delete(s.pending, key)
+		}
+	}
+	for key, job := range s.jobs {
+		if isExpired(job.notification, now) {
+			expired = append(expired, job.notification)
+			s.cron.Remove(job.entryID)
+			delete(s.jobs, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, notification := range expired {
+		s.markExpired(notification)
+	}
+}
+
+// expire drops notification from the schedule and marks it expired.
+func (s *SchedulerService) expire(notification *models.Notification) {
+	key := scheduleKey(notification.TenantID, notification.ID)
+	s.mu.Lock()
+	delete(s.pending, key)
+	if job, recurring := s.jobs[key]; recurring {
+		s.cron.Remove(job.entryID)
+		delete(s.jobs, key)
+	}
+	s.mu.Unlock()
+
+	s.markExpired(notification)
+}
+
+func (s *SchedulerService) markExpired(notification *models.Notification) {
+	notification.Status = models.StatusExpired
+	s.logger.Info("Discarded expired notification", logging.NotificationAttrs(notification, "expires_at", notification.ExpiresAt)...)
+	appendAudit(s.audit, s.logger, NewAuditEvent(models.AuditExpired, notification, ActorScheduler, nil))
+	if s.repository == nil {
+		return
+	}
+	update := repository.StatusUpdate{Status: models.StatusExpired}
+	if err := s.repository.UpdateStatus(context.Background(), notification.TenantID, notification.ID, update); err != nil {
+		s.logger.Error("Error updating notification status", logging.NotificationAttrs(notification, "status", update.Status, "error", err)...)
+	}
+}
+
+// FiresIn returns how long after now the scheduler sends a pending one-off
+// notification, or zero once it is due. It reports false for notifications
+// that are not waiting for a scheduled time.
+func FiresIn(notification *models.Notification, now time.Time) (time.Duration, bool) {
+	if notification.Status != models.StatusPending || notification.ScheduledAt == nil || notification.CronExpr != "" {
+		return 0, false
+	}
+	return max(notification.ScheduledAt.Sub(now), 0), true
+}
+
+// isExpired reports whether notification has an expiry that is not after now.
+func isExpired(notification *models.Notification, now time.Time) bool {
+	return notification.ExpiresAt != nil && !now.Before(*notification.ExpiresAt)
+}
+
+// send delivers notification and records the resulting status, or discards
+// it if it has expired.
+func (s *SchedulerService) send(notification *models.Notification) {
+	if isExpired(notification, time.Now()) {
+		s.expire(notification)
+		return
+	}
+	defer s.trackInFlight(notification)()
+
+	firedAt := time.Now()
+	update := repository.StatusUpdate{Status: models.StatusSent}
+	err := s.notificationService.Send(context.Background(), notification)
+	if s.metrics != nil && notification.ScheduledAt != nil && notification.CronExpr == "" {
+		s.metrics.ObserveScheduleDrift(firedAt.Sub(*notification.ScheduledAt))
+	}
+	if DeliverySuppressed(err) {
+		s.logger.Info("Suppressed notification to unsubscribed recipients", logging.NotificationAttrs(notification)...)
+		update = repository.StatusUpdate{Status: models.StatusSuppressed}
+	} else if err != nil {
+		s.logger.Error("Error sending notification", logging.NotificationAttrs(notification, "error", err)...)
+		update = repository.StatusUpdate{Status: models.StatusFailed, FailureReason: err.Error()}
+	} else {
+		s.logger.Debug("Sent notification", logging.NotificationAttrs(notification)...)
+		sentAt := time.Now()
+		update.SentAt = &sentAt
+		update.Metadata = notification.Metadata
+		notification.SentAt = &sentAt
+	}
+	notification.Status = update.Status
+	notification.FailureReason = update.FailureReason
+	update.RecipientStatuses = notification.RecipientStatuses
+	appendAudit(s.audit, s.logger, statusAuditEvent(notification, update, ActorScheduler, nil))
+
+	if s.repository != nil {
+		if err := s.repository.UpdateStatus(context.Background(), notification.TenantID, notification.ID, update); err != nil {
+			s.logger.Error("Error updating notification status", logging.NotificationAttrs(notification, "status", update.Status, "error", err)...)
+		}
+	}
+	if s.callbacks != nil {
+		s.callbacks.Notify(notification)
+	}
+}
+
 type notificationJob struct {
 	notification *models.Notification
 	service      NotificationService
 }
 
 func (j *notificationJob) Run() {
-	if err := j.service.Send(j.notification); err != nil {
-		fmt.Printf("Error sending notification: %v\n", err)
+	if err := j.service.Send(context.Background(), j.notification); err != nil {
+		logging.Default().Error("Error sending notification", logging.NotificationAttrs(j.notification, "error", err)...)
 	}
 }
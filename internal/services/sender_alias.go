@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SenderAliasMetadataKey names the notification metadata entry holding the
+// display name the notification is sent under: the name in an email's From
+// header, the username of a Slack webhook message and, where alphanumeric
+// sender IDs are supported, a prefix of SMS content.
+const SenderAliasMetadataKey = "sender_alias"
+
+// SenderAliasMaxLength is the most characters a sender alias may have.
+const SenderAliasMaxLength = 64
+
+// alphanumericSenderID matches what carriers accept as an alphanumeric SMS
+// sender ID: up to 11 letters, digits and spaces, at least one a letter.
+var alphanumericSenderID = regexp.MustCompile(`^[A-Za-z0-9 ]{1,11}$`)
+
+// ValidateSenderAlias reports why alias cannot be used as a sender alias.
+func ValidateSenderAlias(alias string) error {
+	switch {
+	case strings.TrimSpace(alias) == "":
+		return fmt.Errorf("must not be blank")
+	case utf8.RuneCountInString(alias) > SenderAliasMaxLength:
+		return fmt.Errorf("must be at most %d characters", SenderAliasMaxLength)
+	case strings.IndexFunc(alias, unicode.IsControl) >= 0:
+		return fmt.Errorf("must not contain control characters")
+	}
+	return nil
+}
+
+// isAlphanumericSenderID reports whether alias can be sent as an
+// alphanumeric SMS sender ID.
+func isAlphanumericSenderID(alias string) bool {
+	return alphanumericSenderID.MatchString(alias) && strings.IndexFunc(alias, unicode.IsLetter) >= 0
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+)
+
+// SendGridTemplateIDMetadataKey names the notification metadata entry holding
+// the ID of a SendGrid dynamic template to send instead of the notification's
+// content, and SendGridTemplateDataMetadataKey the JSON object the template
+// is rendered with.
+const (
+	SendGridTemplateIDMetadataKey   = "sendgrid_template_id"
+	SendGridTemplateDataMetadataKey = "template_data"
+)
+
+// SendGridEmailService delivers email notifications through the SendGrid v3
+// Mail Send API. With Metadata["sendgrid_template_id"] SendGrid renders the
+// email from that dynamic template; otherwise the email carries the
+// notification's content, rendered as Email renders it for SMTP. Copy
+// recipients, the reply-to address, attachments and the suppression list
+// are handled as Email handles them; emails are never queued in its outbox.
+// When APIKey is empty the notification is only printed to stdout.
+type SendGridEmailService struct {
+	APIURL string
+	APIKey string
+	Email  *EmailNotificationService
+	Client *http.Client
+	Logger logging.Logger
+}
+
+// NewSendGridEmailService returns a service sending from email's FromAddress
+// with its templates, tracking and suppression list.
+func NewSendGridEmailService(cfg *config.Config, email *EmailNotificationService) *SendGridEmailService {
+	return &SendGridEmailService{
+		APIURL: cfg.SendGridAPIURL,
+		APIKey: cfg.SendGridAPIKey,
+		Email:  email,
+		Client: &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To                  []sendGridAddress `json:"to"`
+	CC                  []sendGridAddress `json:"cc,omitempty"`
+	BCC                 []sendGridAddress `json:"bcc,omitempty"`
+	DynamicTemplateData map[string]any    `json:"dynamic_template_data,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	ReplyTo          *sendGridAddress          `json:"reply_to,omitempty"`
+	Subject          string                    `json:"subject,omitempty"`
+	Content          []sendGridContent         `json:"content,omitempty"`
+	TemplateID       string                    `json:"template_id,omitempty"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+}
+
+// Send records the outcome for each To, Cc and Bcc recipient in the
+// notification's RecipientStatuses. SendGrid accepts or rejects a message as
+// a whole, so they all share it.
+func (s *SendGridEmailService) Send(ctx context.Context, notification *models.Notification) error {
+	cc, bcc, err := emailCopyRecipients(notification)
+	if err != nil {
+		return err
+	}
+	message, err := s.buildMail(ctx, notification, cc, bcc)
+	if err != nil {
+		return err
+	}
+
+	if s.APIKey == "" {
+		logDryRun(ctx, s.Logger, notification)
+		return nil
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + s.APIKey}
+	err = postJSON(ctx, s.Client, "sendgrid", s.APIURL, headers, message, nil)
+	for _, recipient := range notification.Recipients {
+		notification.RecordRecipient(recipient, err)
+	}
+	for _, address := range append(cc, bcc...) {
+		notification.RecordRecipient(address.Address, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send sendgrid email: %w", err)
+	}
+	return nil
+}
+
+// buildMail returns the Mail Send request for notification, using its
+// dynamic template when it names one.
+func (s *SendGridEmailService) buildMail(ctx context.Context, notification *models.Notification, cc, bcc []*mail.Address) (*sendGridMail, error) {
+	personalization := sendGridPersonalization{
+		To:  make([]sendGridAddress, len(notification.Recipients)),
+		CC:  sendGridAddresses(cc),
+		BCC: sendGridAddresses(bcc),
+	}
+	for i, recipient := range notification.Recipients {
+		personalization.To[i] = sendGridAddress{Email: recipient}
+	}
+	message := &sendGridMail{
+		Personalizations: []sendGridPersonalization{personalization},
+		From:             sendGridAddress{Email: s.Email.FromAddress, Name: notification.Metadata[SenderAliasMetadataKey]},
+		Subject:          notification.Title,
+	}
+
+	if value := notification.Metadata[EmailReplyToMetadataKey]; value != "" {
+		replyTo, err := mail.ParseAddress(value)
+		if err != nil {
+			logging.FromContext(ctx, s.Logger).Warn("Ignoring malformed reply_to address",
+				logging.NotificationAttrs(notification, "error", err)...)
+		} else {
+			message.ReplyTo = &sendGridAddress{Email: replyTo.Address, Name: replyTo.Name}
+		}
+	}
+	if links := s.Email.unsubscribeLinks(notification); len(links) == 1 {
+		message.Headers = map[string]string{"List-Unsubscribe": "<" + links[0].url + ">"}
+	}
+	for _, attachment := range notification.Attachments {
+		message.Attachments = append(message.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(attachment.Data),
+			Type:        attachment.ContentType,
+			Filename:    attachment.Filename,
+			Disposition: "attachment",
+		})
+	}
+
+	if templateID := notification.Metadata[SendGridTemplateIDMetadataKey]; templateID != "" {
+		data, err := parseSendGridTemplateData(notification.Metadata[SendGridTemplateDataMetadataKey])
+		if err != nil {
+			return nil, &PermanentError{Err: err}
+		}
+		message.TemplateID = templateID
+		message.Personalizations[0].DynamicTemplateData = data
+		return message, nil
+	}
+
+	textBody, htmlBody, err := s.Email.renderBody(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+	// SendGrid requires text/plain to come before text/html
+	message.Content = []sendGridContent{{Type: "text/plain", Value: textBody}}
+	if htmlBody != "" {
+		message.Content = append(message.Content, sendGridContent{Type: "text/html", Value: htmlBody})
+	}
+	return message, nil
+}
+
+func sendGridAddresses(addresses []*mail.Address) []sendGridAddress {
+	if len(addresses) == 0 {
+		return nil
+	}
+	converted := make([]sendGridAddress, len(addresses))
+	for i, address := range addresses {
+		converted[i] = sendGridAddress{Email: address.Address, Name: address.Name}
+	}
+	return converted
+}
+
+// parseSendGridTemplateData reads a SendGridTemplateDataMetadataKey entry,
+// which must be a JSON object. An empty entry renders the template without
+// data.
+func parseSendGridTemplateData(value string) (map[string]any, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return nil, fmt.Errorf("invalid %s: must be a JSON object: %w", SendGridTemplateDataMetadataKey, err)
+	}
+	return data, nil
+}
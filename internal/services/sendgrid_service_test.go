@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestSendGridEmailService(t *testing.T) {
+	var sent []sendGridMail
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer SG.key" {
+			t.Errorf("Expected bearer auth with the API key, got %q", auth)
+		}
+		var message sendGridMail
+		json.NewDecoder(r.Body).Decode(&message)
+		sent = append(sent, message)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	email := &EmailNotificationService{FromAddress: "noreply@example.com"}
+	service := &SendGridEmailService{APIURL: server.URL, APIKey: "SG.key", Email: email, Client: server.Client()}
+	to := []sendGridAddress{{Email: "ana@example.com"}}
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		expected sendGridMail
+	}{
+		{
+			name: "Plain text",
+			expected: sendGridMail{
+				Personalizations: []sendGridPersonalization{{To: to}},
+				From:             sendGridAddress{Email: "noreply@example.com"},
+				Subject:          "Welcome",
+				Content:          []sendGridContent{{Type: "text/plain", Value: "<p>Hello Ana</p>"}},
+			},
+		},
+		{
+			name:     "HTML with copies and sender alias",
+			metadata: map[string]string{"content_type": "text/html", "cc": "Bob <bob@example.com>", "reply_to": "support@example.com", "sender_alias": "Acme"},
+			expected: sendGridMail{
+				Personalizations: []sendGridPersonalization{{To: to, CC: []sendGridAddress{{Email: "bob@example.com", Name: "Bob"}}}},
+				From:             sendGridAddress{Email: "noreply@example.com", Name: "Acme"},
+				ReplyTo:          &sendGridAddress{Email: "support@example.com"},
+				Subject:          "Welcome",
+				Content:          []sendGridContent{{Type: "text/plain", Value: "Hello Ana"}, {Type: "text/html", Value: "<p>Hello Ana</p>"}},
+			},
+		},
+		{
+			name:     "Dynamic template",
+			metadata: map[string]string{"sendgrid_template_id": "d-123", "template_data": `{"name":"Ana","items":2}`},
+			expected: sendGridMail{
+				Personalizations: []sendGridPersonalization{{To: to, DynamicTemplateData: map[string]any{"name": "Ana", "items": float64(2)}}},
+				From:             sendGridAddress{Email: "noreply@example.com"},
+				Subject:          "Welcome",
+				TemplateID:       "d-123",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = nil
+			notification := &models.Notification{Title: "Welcome", Content: "<p>Hello Ana</p>", Recipients: []string{"ana@example.com"}, Metadata: tt.metadata}
+			if err := service.Send(context.Background(), notification); err != nil {
+				t.Fatalf("Failed to send SendGrid email: %v", err)
+			}
+			if len(sent) != 1 || !reflect.DeepEqual(sent[0], tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, sent)
+			}
+			for _, status := range notification.RecipientStatuses {
+				if status.Status != models.StatusSent {
+					t.Errorf("Expected %s recorded as sent, got %s", status.Recipient, status.Status)
+				}
+			}
+		})
+	}
+
+	// Malformed template data is not sent or retried
+	sent = nil
+	notification := &models.Notification{Title: "Welcome", Recipients: []string{"ana@example.com"}, Metadata: map[string]string{"sendgrid_template_id": "d-123", "template_data": "not json"}}
+	var permanent *PermanentError
+	if err := service.Send(context.Background(), notification); !errors.As(err, &permanent) {
+		t.Errorf("Expected a permanent error, got %v", err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("Expected no email to be sent, got %d", len(sent))
+	}
+}
+
+func TestSendGridEmailServiceFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":[{"message":"The from address does not match a verified Sender Identity."}]}`))
+	}))
+	defer server.Close()
+
+	service := &SendGridEmailService{APIURL: server.URL, APIKey: "SG.key", Email: &EmailNotificationService{FromAddress: "noreply@example.com"}, Client: server.Client()}
+	notification := &models.Notification{Title: "Welcome", Content: "Hi", Recipients: []string{"ana@example.com"}, Metadata: map[string]string{"bcc": "audit@example.com"}}
+	var apiErr *APIError
+	if err := service.Send(context.Background(), notification); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected a 400 API error, got %v", err)
+	}
+	if len(notification.RecipientStatuses) != 2 {
+		t.Fatalf("Expected both recipients recorded, got %+v", notification.RecipientStatuses)
+	}
+	for _, status := range notification.RecipientStatuses {
+		if status.Status != models.StatusFailed {
+			t.Errorf("Expected %s recorded as failed, got %s", status.Recipient, status.Status)
+		}
+	}
+}
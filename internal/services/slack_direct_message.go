@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlackSendMode selects how a Slack notification is delivered.
+type SlackSendMode string
+
+const (
+	// SlackChannelPost posts to the incoming webhook's channel, mentioning
+	// the recipients.
+	SlackChannelPost SlackSendMode = "channel"
+	// SlackDirectMessage sends each user ID recipient a direct message and
+	// posts to each "#channel" or channel ID recipient with the Web API.
+	SlackDirectMessage SlackSendMode = "direct"
+	// SlackEphemeralMessage shows each user ID recipient a message only they
+	// can see in the channel named by the channel_id metadata.
+	SlackEphemeralMessage SlackSendMode = "ephemeral"
+)
+
+// SlackSendModeMetadataKey names the notification metadata entry holding
+// the SlackSendMode; without it notifications are posted to the webhook.
+const SlackSendModeMetadataKey = "send_mode"
+
+// SlackChannelIDMetadataKey names the notification metadata entry holding
+// the channel ephemeral messages are shown in.
+const SlackChannelIDMetadataKey = "channel_id"
+
+// SlackDMChannelTTL is how long the DM channel opened for a user is reused
+// before it is looked up again.
+const SlackDMChannelTTL = 30 * time.Minute
+
+// ParseSlackSendMode returns the send mode named by value, which defaults to
+// SlackChannelPost when empty.
+func ParseSlackSendMode(value string) (SlackSendMode, error) {
+	switch mode := SlackSendMode(value); mode {
+	case "":
+		return SlackChannelPost, nil
+	case SlackChannelPost, SlackDirectMessage, SlackEphemeralMessage:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unsupported slack send_mode %q: must be %s, %s or %s",
+		value, SlackChannelPost, SlackDirectMessage, SlackEphemeralMessage)
+}
+
+// slackAPIMessage is a chat.postMessage or chat.postEphemeral request.
+type slackAPIMessage struct {
+	Channel  string                `json:"channel"`
+	User     string                `json:"user,omitempty"`
+	Text     string                `json:"text"`
+	Blocks   []SlackBlock          `json:"blocks,omitempty"`
+	Metadata *SlackMessageMetadata `json:"metadata,omitempty"`
+}
+
+// slackAPIResponse is the part of a Web API response every method shares.
+// Most errors are reported with a 200 status and "ok": false.
+type slackAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type slackConversationResponse struct {
+	slackAPIResponse
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+}
+
+// slackDMCache maps user IDs to the DM channels opened for them.
+type slackDMCache struct {
+	channels map[string]slackDMChannel
+	mu       sync.Mutex
+}
+
+type slackDMChannel struct {
+	id      string
+	expires time.Time
+}
+
+func (c *slackDMCache) get(userID string, now time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	channel, ok := c.channels[userID]
+	if !ok || !now.Before(channel.expires) {
+		return "", false
+	}
+	return channel.id, true
+}
+
+func (c *slackDMCache) put(userID, channelID string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.channels == nil {
+		c.channels = make(map[string]slackDMChannel)
+	}
+	c.channels[userID] = slackDMChannel{id: channelID, expires: expires}
+}
+
+// sendWithAPI delivers notification as direct or ephemeral messages with the
+// Web API, stopping at the first recipient that fails.
+func (s *SlackNotificationService) sendWithAPI(ctx context.Context, notification *models.Notification, mode SlackSendMode) error {
+	channelID := notification.Metadata[SlackChannelIDMetadataKey]
+	if mode == SlackEphemeralMessage && channelID == "" {
+		return fmt.Errorf("slack ephemeral messages require %s metadata", SlackChannelIDMetadataKey)
+	}
+
+	message := slackAPIMessage{
+		Text:     formatSlackText(notification, nil),
+		Blocks:   s.blocks(ctx, notification),
+		Metadata: slackMetadata(notification),
+	}
+	for _, recipient := range notification.Recipients {
+		err := s.sendToRecipient(ctx, message, mode, channelID, recipient)
+		notification.RecordRecipient(recipient, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendToRecipient delivers message to one recipient of sendWithAPI.
+func (s *SlackNotificationService) sendToRecipient(ctx context.Context, message slackAPIMessage, mode SlackSendMode, channelID, recipient string) error {
+	target, err := s.resolveRecipient(recipient)
+	if err != nil {
+		return err
+	}
+
+	method := "chat.postMessage"
+	switch {
+	case mode == SlackEphemeralMessage:
+		if !isSlackUserID(target) {
+			return fmt.Errorf("slack ephemeral message recipient %s is not a user ID", recipient)
+		}
+		// Ephemeral messages cannot carry metadata
+		method, message.Channel, message.User, message.Metadata = "chat.postEphemeral", channelID, target, nil
+	case isSlackUserID(target):
+		if message.Channel, err = s.dmChannel(ctx, target); err != nil {
+			return err
+		}
+	default:
+		message.Channel = target
+	}
+
+	if err := s.callAPI(ctx, method, message, &slackAPIResponse{}); err != nil {
+		return fmt.Errorf("failed to send slack message to %s: %w", recipient, err)
+	}
+	return nil
+}
+
+// resolveRecipient returns the user ID of an "@display-name" recipient when
+// there is a UserLookup, and other recipients as they are.
+func (s *SlackNotificationService) resolveRecipient(recipient string) (string, error) {
+	name, ok := strings.CutPrefix(recipient, "@")
+	if !ok {
+		return recipient, nil
+	}
+	if s.UserLookup == nil {
+		return name, nil
+	}
+	id, err := s.UserLookup.LookupByDisplayName(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up slack user %s: %w", recipient, err)
+	}
+	return id, nil
+}
+
+// dmChannel returns the ID of the DM channel with userID, opening it with
+// conversations.open unless it was opened within SlackDMChannelTTL.
+func (s *SlackNotificationService) dmChannel(ctx context.Context, userID string) (string, error) {
+	now := time.Now()
+	if id, ok := s.dmChannels.get(userID, now); ok {
+		return id, nil
+	}
+
+	var resp slackConversationResponse
+	if err := s.callAPI(ctx, "conversations.open", map[string]string{"users": userID}, &resp); err != nil {
+		return "", fmt.Errorf("failed to open slack DM with %s: %w", userID, err)
+	}
+	s.dmChannels.put(userID, resp.Channel.ID, now.Add(SlackDMChannelTTL))
+	logging.FromContext(ctx, s.Logger).Debug("Opened Slack DM channel", "user_id", userID, "channel_id", resp.Channel.ID)
+	return resp.Channel.ID, nil
+}
+
+// callAPI POSTs payload to the Web API method and decodes the response into
+// out, returning an error if it is not ok.
+func (s *SlackNotificationService) callAPI(ctx context.Context, method string, payload any, out interface{ ok() (bool, string) }) error {
+	url := strings.TrimRight(s.APIURL, "/") + "/" + method
+	headers := map[string]string{"Authorization": "Bearer " + s.BotToken}
+	if err := postJSON(ctx, s.Client, "slack", url, headers, payload, out); err != nil {
+		return err
+	}
+	if ok, reason := out.ok(); !ok {
+		return fmt.Errorf("slack %s failed: %s", method, reason)
+	}
+	return nil
+}
+
+func (r *slackAPIResponse) ok() (bool, string) {
+	return r.OK, r.Error
+}
+
+// isSlackUserID reports whether recipient looks like a Slack user ID, such
+// as U024BE7LH.
+func isSlackUserID(recipient string) bool {
+	return len(recipient) > 1 && recipient[0] == 'U' && strings.ToUpper(recipient) == recipient
+}
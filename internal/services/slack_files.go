@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strconv"
+	"strings"
+)
+
+// SlackFileIDMetadataKeyPrefix prefixes the notification metadata entries
+// holding the file ID of each uploaded attachment, keyed by the
+// attachment's index, as in "slack_file_id.0".
+const SlackFileIDMetadataKeyPrefix = "slack_file_id."
+
+// ErrAttachmentsNotUploaded is returned alongside a successful delivery when
+// some of the notification's attachments could not be uploaded.
+var ErrAttachmentsNotUploaded = errors.New("attachments were not uploaded")
+
+// SlackAttachmentError lists the attachments of a delivered Slack
+// notification that failed to upload. It wraps ErrAttachmentsNotUploaded.
+type SlackAttachmentError struct {
+	Failures []SlackAttachmentFailure
+}
+
+// SlackAttachmentFailure is the error uploading or sharing one attachment.
+type SlackAttachmentFailure struct {
+	Filename string
+	Err      error
+}
+
+func (e *SlackAttachmentError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		parts[i] = fmt.Sprintf("%s: %v", failure.Filename, failure.Err)
+	}
+	return fmt.Sprintf("failed to upload %d slack attachment(s): %s", len(parts), strings.Join(parts, "; "))
+}
+
+func (e *SlackAttachmentError) Unwrap() error {
+	return ErrAttachmentsNotUploaded
+}
+
+type slackUploadURLResponse struct {
+	slackAPIResponse
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+// slackCompleteUpload is a files.completeUploadExternal request.
+type slackCompleteUpload struct {
+	Files []slackUploadedFile `json:"files"`
+	// Channels is a comma-separated list of the channel IDs to share the
+	// files to.
+	Channels string `json:"channels"`
+}
+
+type slackUploadedFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// uploadAttachments uploads each of the notification's attachments with
+// files.getUploadURLExternal and files.completeUploadExternal, sharing them
+// to the channels the message went to, and records their file IDs under
+// SlackFileIDMetadataKeyPrefix. Attachments are uploaded one by one, so a
+// failure only loses that attachment; failures are returned together as a
+// *SlackAttachmentError.
+func (s *SlackNotificationService) uploadAttachments(ctx context.Context, notification *models.Notification, mode SlackSendMode) error {
+	// An error finding the channels fails every attachment
+	channels, channelsErr := s.attachmentChannels(ctx, notification, mode)
+
+	var failures []SlackAttachmentFailure
+	for i, attachment := range notification.Attachments {
+		fileID, err := "", channelsErr
+		if err == nil {
+			fileID, err = s.uploadFile(ctx, attachment, channels)
+		}
+		if err != nil {
+			failures = append(failures, SlackAttachmentFailure{Filename: attachment.Filename, Err: err})
+			logging.FromContext(ctx, s.Logger).Warn("Error uploading Slack attachment",
+				logging.NotificationAttrs(notification, "filename", attachment.Filename, "error", err)...)
+			continue
+		}
+
+		if notification.Metadata == nil {
+			notification.Metadata = make(map[string]string)
+		}
+		notification.Metadata[SlackFileIDMetadataKeyPrefix+strconv.Itoa(i)] = fileID
+	}
+
+	if len(failures) > 0 {
+		return &SlackAttachmentError{Failures: failures}
+	}
+	return nil
+}
+
+// attachmentChannels returns the IDs of the channels the notification's
+// message was sent to in mode, which its attachments are shared to.
+func (s *SlackNotificationService) attachmentChannels(ctx context.Context, notification *models.Notification, mode SlackSendMode) ([]string, error) {
+	if s.BotToken == "" {
+		return nil, errors.New("uploading slack attachments requires a bot token")
+	}
+
+	switch mode {
+	case SlackEphemeralMessage:
+		return nil, errors.New("slack attachments cannot be shared in ephemeral messages")
+	case SlackDirectMessage:
+		channels := make([]string, 0, len(notification.Recipients))
+		for _, recipient := range notification.Recipients {
+			target, err := s.resolveRecipient(recipient)
+			if err != nil {
+				return nil, err
+			}
+			if isSlackUserID(target) {
+				if target, err = s.dmChannel(ctx, target); err != nil {
+					return nil, err
+				}
+			}
+			channels = append(channels, target)
+		}
+		return channels, nil
+	}
+
+	// The webhook does not say which channel it posts to
+	channelID := notification.Metadata[SlackChannelIDMetadataKey]
+	if channelID == "" {
+		return nil, fmt.Errorf("slack attachments posted with the webhook require %s metadata", SlackChannelIDMetadataKey)
+	}
+	return []string{channelID}, nil
+}
+
+// uploadFile uploads attachment and shares it to channels, returning its
+// file ID.
+func (s *SlackNotificationService) uploadFile(ctx context.Context, attachment models.NotificationAttachment, channels []string) (string, error) {
+	var upload slackUploadURLResponse
+	form := url.Values{
+		"filename": {attachment.Filename},
+		"length":   {strconv.Itoa(len(attachment.Data))},
+	}
+	if err := s.callAPIForm(ctx, "files.getUploadURLExternal", form, &upload); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, upload.UploadURL, bytes.NewReader(attachment.Data))
+	if err != nil {
+		return "", fmt.Errorf("failed to build slack upload request: %w", err)
+	}
+	if attachment.ContentType != "" {
+		req.Header.Set("Content-Type", attachment.ContentType)
+	}
+	if err := s.do(req, nil); err != nil {
+		return "", fmt.Errorf("slack file upload failed: %w", err)
+	}
+
+	complete := slackCompleteUpload{
+		Files:    []slackUploadedFile{{ID: upload.FileID, Title: attachment.Filename}},
+		Channels: strings.Join(channels, ","),
+	}
+	if err := s.callAPI(ctx, "files.completeUploadExternal", complete, &slackAPIResponse{}); err != nil {
+		return "", err
+	}
+	return upload.FileID, nil
+}
+
+// callAPIForm is callAPI for Web API methods that take form-encoded
+// arguments rather than JSON.
+func (s *SlackNotificationService) callAPIForm(ctx context.Context, method string, form url.Values, out interface{ ok() (bool, string) }) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(s.APIURL, "/")+"/"+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.BotToken)
+	if err := s.do(req, out); err != nil {
+		return fmt.Errorf("slack request failed: %w", err)
+	}
+	if ok, reason := out.ok(); !ok {
+		return fmt.Errorf("slack %s failed: %s", method, reason)
+	}
+	return nil
+}
+
+// do sends req and decodes a successful JSON response into out when out is
+// non-nil.
+func (s *SlackNotificationService) do(req *http.Request, out any) error {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return rateLimited(resp, &APIError{Channel: "slack", StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))})
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackNotificationServiceAttachments(t *testing.T) {
+	var (
+		uploads   = make(map[string]string)
+		completed []slackCompleteUpload
+		posted    []slackAPIMessage
+	)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files.getUploadURLExternal":
+			r.ParseForm()
+			if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+				t.Errorf("Expected bot token authorization, got %q", r.Header.Get("Authorization"))
+			}
+			filename := r.PostForm.Get("filename")
+			if filename == "missing.txt" {
+				json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "invalid_arguments"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"ok": true, "upload_url": server.URL + "/upload/" + filename, "file_id": "F-" + filename + "-" + r.PostForm.Get("length")})
+		case "/files.completeUploadExternal":
+			var complete slackCompleteUpload
+			json.NewDecoder(r.Body).Decode(&complete)
+			completed = append(completed, complete)
+			json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		case "/conversations.open":
+			json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": map[string]string{"id": "D-U123"}})
+		case "/chat.postMessage":
+			var message slackAPIMessage
+			json.NewDecoder(r.Body).Decode(&message)
+			posted = append(posted, message)
+			json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		default:
+			filename, ok := strings.CutPrefix(r.URL.Path, "/upload/")
+			if !ok {
+				t.Errorf("Unexpected request to %s", r.URL.Path)
+				return
+			}
+			if r.Header.Get("Authorization") != "" {
+				t.Error("Expected the upload URL to be called without the bot token")
+			}
+			body, _ := io.ReadAll(r.Body)
+			uploads[filename] = string(body)
+		}
+	}))
+	defer server.Close()
+
+	service := NewSlackNotificationService("", time.Second)
+	service.APIURL, service.BotToken = server.URL, "xoxb-test"
+	notification := &models.Notification{
+		ID:         "slack-files",
+		Title:      "Report",
+		Content:    "Attached",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"U123", "C999"},
+		Metadata:   map[string]string{SlackSendModeMetadataKey: string(SlackDirectMessage)},
+		Attachments: []models.NotificationAttachment{
+			{Filename: "report.csv", ContentType: "text/csv", Data: []byte("a,b\n1,2\n")},
+			{Filename: "missing.txt", Data: []byte("gone")},
+		},
+	}
+
+	err := service.Send(context.Background(), notification)
+	var attachmentErr *SlackAttachmentError
+	if !errors.As(err, &attachmentErr) || !errors.Is(err, ErrAttachmentsNotUploaded) {
+		t.Fatalf("Expected a SlackAttachmentError, got %v", err)
+	}
+	if len(attachmentErr.Failures) != 1 || attachmentErr.Failures[0].Filename != "missing.txt" {
+		t.Errorf("Expected only missing.txt to fail, got %+v", attachmentErr.Failures)
+	}
+	if DeliveryFailed(err) {
+		t.Error("Expected attachments failing to upload to count as delivered")
+	}
+
+	if len(posted) != 2 {
+		t.Errorf("Expected the message sent to both recipients, got %d messages", len(posted))
+	}
+	if uploads["report.csv"] != "a,b\n1,2\n" {
+		t.Errorf("Expected report.csv to be uploaded, got %q", uploads["report.csv"])
+	}
+	if len(completed) != 1 || completed[0].Channels != "D-U123,C999" || completed[0].Files[0].ID != "F-report.csv-8" {
+		t.Errorf("Expected report.csv shared to the DM and C999, got %+v", completed)
+	}
+	if fileID := notification.Metadata[SlackFileIDMetadataKeyPrefix+"0"]; fileID != "F-report.csv-8" {
+		t.Errorf("Expected the file ID of report.csv in metadata, got %q", fileID)
+	}
+	if _, ok := notification.Metadata[SlackFileIDMetadataKeyPrefix+"1"]; ok {
+		t.Error("Expected no file ID for the failed attachment")
+	}
+}
+
+func TestSlackNotificationServiceAttachmentsUnshareable(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer webhook.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		name     string
+		botToken string
+		metadata map[string]string
+	}{
+		{"Webhook without bot token", "", nil},
+		{"Webhook without channel_id", "xoxb-test", nil},
+		{"Ephemeral", "xoxb-test", map[string]string{SlackSendModeMetadataKey: string(SlackEphemeralMessage), SlackChannelIDMetadataKey: "C789"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewSlackNotificationService(webhook.URL, time.Second)
+			service.APIURL, service.BotToken = api.URL, tt.botToken
+			err := service.Send(context.Background(), &models.Notification{
+				Title: "Report", Content: "Attached", Recipients: []string{"U123"}, Metadata: tt.metadata,
+				Attachments: []models.NotificationAttachment{{Filename: "a.txt", Data: []byte("a")}, {Filename: "b.txt", Data: []byte("b")}},
+			})
+
+			var attachmentErr *SlackAttachmentError
+			if !errors.As(err, &attachmentErr) || len(attachmentErr.Failures) != 2 {
+				t.Errorf("Expected both attachments to fail, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlackNotificationService posts notifications to a Slack incoming webhook,
+// or sends them as direct or ephemeral messages with the Web API when their
+// send_mode metadata asks for it. When WebhookURL, or BotToken for the Web
+// API, is empty the notification is only printed to stdout.
+type SlackNotificationService struct {
+	WebhookURL string
+	// APIURL and BotToken reach the Web API; the token needs the chat:write
+	// and im:write scopes to send direct and ephemeral messages, and
+	// files:write to upload attachments.
+	APIURL   string
+	BotToken string
+	Client   *http.Client
+	Logger   logging.Logger
+	// UserLookup, when set, resolves "@display-name" recipients to user IDs.
+	// Without it such recipients are taken to be user IDs.
+	UserLookup SlackUserLookup
+
+	dmChannels slackDMCache
+}
+
+func NewSlackNotificationService(webhookURL string, timeout time.Duration) *SlackNotificationService {
+	return &SlackNotificationService{
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: timeout},
+	}
+}
+
+// SlackError is returned when the webhook responds with a non-2xx status,
+// wrapped in a RateLimitError for 429 responses.
+type SlackError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *SlackError) Error() string {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return fmt.Sprintf("slack webhook rate limited (status %d), retry after %s", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("slack webhook returned status %d: %s", e.StatusCode, e.Body)
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+	// Username overrides the webhook's name with the sender alias.
+	Username string                `json:"username,omitempty"`
+	Metadata *SlackMessageMetadata `json:"metadata,omitempty"`
+}
+
+// SlackSentEventType is the event type of the metadata attached to every
+// message, which lets message events be matched back to their notification.
+const SlackSentEventType = "notification_sent"
+
+// SlackMessageMetadata is Slack message metadata. The payload of a
+// SlackSentEventType event holds notification_id and, for a tenant's
+// notifications, tenant_id.
+type SlackMessageMetadata struct {
+	EventType    string            `json:"event_type"`
+	EventPayload map[string]string `json:"event_payload"`
+}
+
+// SlackBlocksMetadataKey names the notification metadata entry holding a
+// JSON array of Block Kit blocks to send instead of the plain-text message.
+const SlackBlocksMetadataKey = "blocks"
+
+// BlockKitMessage is a Slack message laid out with Block Kit. Text is only
+// used for notifications and by clients that cannot render the blocks.
+type BlockKitMessage struct {
+	Text     string                `json:"text,omitempty"`
+	Blocks   []SlackBlock          `json:"blocks"`
+	Username string                `json:"username,omitempty"`
+	Metadata *SlackMessageMetadata `json:"metadata,omitempty"`
+}
+
+// SlackBlock is a section, divider, header or image block. Only the fields
+// used by its type are set.
+type SlackBlock struct {
+	Type    string `json:"type"`
+	BlockID string `json:"block_id,omitempty"`
+	// Text is the markdown or plain text of a section, or the plain text of
+	// a header.
+	Text *SlackTextObject `json:"text,omitempty"`
+	// Fields are shown in two columns below a section's text.
+	Fields []SlackTextObject `json:"fields,omitempty"`
+	// Accessory is an element shown beside a section's text, passed through
+	// unchanged.
+	Accessory json.RawMessage `json:"accessory,omitempty"`
+	// ImageURL, AltText and Title describe an image block.
+	ImageURL string           `json:"image_url,omitempty"`
+	AltText  string           `json:"alt_text,omitempty"`
+	Title    *SlackTextObject `json:"title,omitempty"`
+}
+
+// SlackTextObject is a "mrkdwn" or "plain_text" text object.
+type SlackTextObject struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji *bool  `json:"emoji,omitempty"`
+}
+
+// validate reports whether b has the fields its type requires.
+func (b SlackBlock) validate() error {
+	switch b.Type {
+	case "section":
+		if b.Text == nil && len(b.Fields) == 0 {
+			return fmt.Errorf("section block requires text or fields")
+		}
+	case "divider":
+	case "header":
+		if b.Text == nil || b.Text.Type != "plain_text" {
+			return fmt.Errorf("header block requires plain_text text")
+		}
+	case "image":
+		if b.ImageURL == "" || b.AltText == "" {
+			return fmt.Errorf("image block requires image_url and alt_text")
+		}
+	default:
+		return fmt.Errorf("unsupported block type %q", b.Type)
+	}
+	return nil
+}
+
+// parseSlackBlocks decodes and checks a JSON array of blocks.
+func parseSlackBlocks(data string) ([]SlackBlock, error) {
+	var blocks []SlackBlock
+	if err := json.Unmarshal([]byte(data), &blocks); err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no blocks")
+	}
+	for i, block := range blocks {
+		if err := block.validate(); err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+	return blocks, nil
+}
+
+// Send delivers the notification and then uploads its attachments.
+// Attachments that fail to upload are returned in a *SlackAttachmentError
+// once the message is delivered.
+func (s *SlackNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	mode, err := ParseSlackSendMode(notification.Metadata[SlackSendModeMetadataKey])
+	if err != nil {
+		return err
+	}
+	dryRun := s.BotToken == "" && (mode != SlackChannelPost || s.WebhookURL == "")
+	if err := s.sendMessage(ctx, notification, mode); err != nil || dryRun || len(notification.Attachments) == 0 {
+		return err
+	}
+	return s.uploadAttachments(ctx, notification, mode)
+}
+
+// sendMessage delivers the notification's message in mode.
+func (s *SlackNotificationService) sendMessage(ctx context.Context, notification *models.Notification, mode SlackSendMode) error {
+	if mode != SlackChannelPost {
+		if s.BotToken == "" {
+			logDryRun(ctx, s.Logger, notification)
+			return nil
+		}
+		return s.sendWithAPI(ctx, notification, mode)
+	}
+
+	if s.WebhookURL == "" {
+		logDryRun(ctx, s.Logger, notification)
+		return nil
+	}
+
+	mentions, err := s.mentions(notification.Recipients)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(s.message(ctx, notification, mentions))
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack payload: %w", err)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack webhook request failed: %w", transportError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		slackErr := &SlackError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+		err := rateLimited(resp, slackErr)
+		if rateLimitErr, ok := err.(*RateLimitError); ok {
+			slackErr.RetryAfter = rateLimitErr.RetryAfter
+		}
+		return err
+	}
+
+	return nil
+}
+
+// mentions formats every recipient as a mention. With a UserLookup,
+// "@display-name" recipients are mentioned by the ID of the user with that
+// display name or, if there is none, linked as the channel "#display-name".
+func (s *SlackNotificationService) mentions(recipients []string) ([]string, error) {
+	mentions := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		if s.UserLookup == nil || !strings.HasPrefix(recipient, "@") {
+			mentions = append(mentions, formatSlackMention(recipient))
+			continue
+		}
+		id, err := s.UserLookup.LookupByDisplayName(strings.TrimPrefix(recipient, "@"))
+		switch {
+		case err == nil:
+			mentions = append(mentions, formatSlackMention(id))
+		case errors.Is(err, ErrSlackUserNotFound):
+			mentions = append(mentions, "#"+strings.TrimPrefix(recipient, "@"))
+		default:
+			return nil, fmt.Errorf("failed to look up slack user %s: %w", recipient, err)
+		}
+	}
+	return mentions, nil
+}
+
+// message builds the webhook payload, using the Block Kit blocks in the
+// notification's metadata when they are present and valid and posting as the
+// sender alias when there is one.
+func (s *SlackNotificationService) message(ctx context.Context, notification *models.Notification, mentions []string) any {
+	text := formatSlackText(notification, mentions)
+	username := notification.Metadata[SenderAliasMetadataKey]
+	metadata := slackMetadata(notification)
+	if blocks := s.blocks(ctx, notification); blocks != nil {
+		return BlockKitMessage{Text: text, Blocks: blocks, Username: username, Metadata: metadata}
+	}
+	return slackMessage{Text: text, Username: username, Metadata: metadata}
+}
+
+// blocks returns the Block Kit blocks in the notification's metadata, or nil
+// if there are none or they are malformed.
+func (s *SlackNotificationService) blocks(ctx context.Context, notification *models.Notification) []SlackBlock {
+	data, ok := notification.Metadata[SlackBlocksMetadataKey]
+	if !ok {
+		return nil
+	}
+	blocks, err := parseSlackBlocks(data)
+	if err != nil {
+		logging.FromContext(ctx, s.Logger).Warn("Ignoring malformed Slack blocks",
+			logging.NotificationAttrs(notification, "error", err)...)
+		return nil
+	}
+	return blocks
+}
+
+// slackMetadata identifies notification in the message it is sent as, or
+// returns nil if it has no ID.
+func slackMetadata(notification *models.Notification) *SlackMessageMetadata {
+	if notification.ID == "" {
+		return nil
+	}
+	payload := map[string]string{"notification_id": notification.ID}
+	if notification.TenantID != "" {
+		payload["tenant_id"] = notification.TenantID
+	}
+	return &SlackMessageMetadata{EventType: SlackSentEventType, EventPayload: payload}
+}
+
+// formatSlackText renders the title in bold followed by the content and a
+// line of the recipients' mentions.
+func formatSlackText(notification *models.Notification, mentions []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n%s", notification.Title, notification.Content)
+
+	if len(mentions) > 0 {
+		b.WriteString("\n")
+		b.WriteString(strings.Join(mentions, " "))
+	}
+
+	return b.String()
+}
+
+func formatSlackMention(recipient string) string {
+	if strings.HasPrefix(recipient, "<@") && strings.HasSuffix(recipient, ">") {
+		return recipient
+	}
+	return "<@" + strings.TrimPrefix(recipient, "@") + ">"
+}
+
+// SlackSignatureMaxAge is how old a signed request from Slack may be before
+// it is rejected as a possible replay.
+const SlackSignatureMaxAge = 5 * time.Minute
+
+// SignSlackRequest returns the X-Slack-Signature for body sent at timestamp,
+// the hex-encoded HMAC-SHA256 of "v0:<timestamp>:<body>" prefixed with "v0=".
+func SignSlackRequest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySlackSignature reports whether signature matches body and timestamp,
+// the X-Slack-Request-Timestamp header, for secret, and whether timestamp is
+// within SlackSignatureMaxAge of now.
+func VerifySlackSignature(secret, timestamp string, body []byte, signature string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > SlackSignatureMaxAge || age < -SlackSignatureMaxAge {
+		return false
+	}
+	expected := SignSlackRequest(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature)))
+}
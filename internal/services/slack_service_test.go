@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlackNotificationServiceWebhook(t *testing.T) {
+	var received slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	service := NewSlackNotificationService(server.URL, time.Second)
+	notification := &models.Notification{
+		ID:         "slack-1",
+		Title:      "Deploy",
+		Content:    "Deploy finished",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"U123", "@U456"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send Slack notification: %v", err)
+	}
+
+	expected := "*Deploy*\nDeploy finished\n<@U123> <@U456>"
+	if received.Text != expected {
+		t.Errorf("Expected text %q, got %q", expected, received.Text)
+	}
+	if received.Metadata == nil || received.Metadata.EventType != SlackSentEventType || received.Metadata.EventPayload["notification_id"] != "slack-1" {
+		t.Errorf("Expected metadata identifying notification slack-1, got %+v", received.Metadata)
+	}
+	if received.Username != "" {
+		t.Errorf("Expected the webhook's own name without a sender alias, got %q", received.Username)
+	}
+
+	notification.Metadata = map[string]string{SenderAliasMetadataKey: "Deploy Bot"}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send Slack notification: %v", err)
+	}
+	if received.Username != "Deploy Bot" {
+		t.Errorf("Expected username Deploy Bot, got %q", received.Username)
+	}
+}
+
+func TestSlackNotificationServiceBlockKit(t *testing.T) {
+	// The recorded webhook request uses every supported block type
+	data, err := os.ReadFile("testdata/slack_block_kit.json")
+	if err != nil {
+		t.Fatalf("Failed to read recorded interaction: %v", err)
+	}
+	var recorded struct {
+		Request  json.RawMessage `json:"request"`
+		Response struct {
+			Status int    `json:"status"`
+			Body   string `json:"body"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		t.Fatalf("Failed to decode recorded interaction: %v", err)
+	}
+	var message BlockKitMessage
+	if err := json.Unmarshal(recorded.Request, &message); err != nil {
+		t.Fatalf("Failed to decode recorded request: %v", err)
+	}
+	blocks, err := json.Marshal(message.Blocks)
+	if err != nil {
+		t.Fatalf("Failed to encode blocks: %v", err)
+	}
+
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(recorded.Response.Status)
+		w.Write([]byte(recorded.Response.Body))
+	}))
+	defer server.Close()
+
+	service := NewSlackNotificationService(server.URL, time.Second)
+	notification := &models.Notification{
+		ID:         "slack-blocks",
+		Title:      "Deploy",
+		Content:    "Deploy finished",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"U123"},
+		Metadata:   map[string]string{SlackBlocksMetadataKey: string(blocks)},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send Slack notification: %v", err)
+	}
+
+	var expected, actual interface{}
+	json.Unmarshal(recorded.Request, &expected)
+	if err := json.Unmarshal(received, &actual); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected payload %s, got %s", recorded.Request, received)
+	}
+}
+
+func TestSlackNotificationServiceMalformedBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks string
+	}{
+		{"Invalid JSON", `[{"type": "section"`},
+		{"Empty", `[]`},
+		{"Unsupported type", `[{"type": "video", "title": {"type": "plain_text", "text": "Demo"}}]`},
+		{"Section without text", `[{"type": "section"}]`},
+		{"Header with mrkdwn", `[{"type": "header", "text": {"type": "mrkdwn", "text": "*Deploy*"}}]`},
+		{"Image without alt text", `[{"type": "image", "image_url": "https://example.com/graph.png"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received map[string]json.RawMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			service := NewSlackNotificationService(server.URL, time.Second)
+			notification := &models.Notification{
+				ID:       "slack-malformed",
+				Title:    "Deploy",
+				Content:  "Deploy finished",
+				Channel:  models.ChannelSlack,
+				Metadata: map[string]string{SlackBlocksMetadataKey: tt.blocks},
+			}
+			if err := service.Send(context.Background(), notification); err != nil {
+				t.Fatalf("Failed to send Slack notification: %v", err)
+			}
+
+			if _, ok := received["blocks"]; ok {
+				t.Errorf("Expected malformed blocks to be dropped, got %s", received["blocks"])
+			}
+			if string(received["text"]) != `"*Deploy*\nDeploy finished"` {
+				t.Errorf("Expected the plain-text message, got %s", received["text"])
+			}
+		})
+	}
+}
+
+func TestSlackNotificationServiceErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		expected   time.Duration
+	}{
+		{name: "Server error", status: http.StatusInternalServerError},
+		{name: "Rate limited", status: http.StatusTooManyRequests, retryAfter: "30", expected: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			service := NewSlackNotificationService(server.URL, time.Second)
+			err := service.Send(context.Background(), &models.Notification{Title: "T", Content: "C"})
+
+			var slackErr *SlackError
+			if !errors.As(err, &slackErr) {
+				t.Fatalf("Expected SlackError, got %v", err)
+			}
+			if slackErr.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, slackErr.StatusCode)
+			}
+			if slackErr.RetryAfter != tt.expected {
+				t.Errorf("Expected retry after %s, got %s", tt.expected, slackErr.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestSlackNotificationServiceTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	service := NewSlackNotificationService(server.URL, 50*time.Millisecond)
+	if err := service.Send(context.Background(), &models.Notification{Title: "T", Content: "C"}); err == nil {
+		t.Error("Expected timeout error, got nil")
+	}
+}
+
+// staticSlackUserLookup resolves the display names in users, and fails every
+// lookup with err if it is set.
+type staticSlackUserLookup struct {
+	users map[string]string
+	err   error
+}
+
+func (l staticSlackUserLookup) LookupByDisplayName(name string) (string, error) {
+	if l.err != nil {
+		return "", l.err
+	}
+	if id, ok := l.users[name]; ok {
+		return id, nil
+	}
+	return "", ErrSlackUserNotFound
+}
+
+func TestSlackNotificationServiceUserLookup(t *testing.T) {
+	var received slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		lookup       SlackUserLookup
+		recipients   []string
+		expectedText string
+		expectError  bool
+	}{
+		{
+			name:         "Display name resolved",
+			lookup:       staticSlackUserLookup{users: map[string]string{"ana.lopez": "U123"}},
+			recipients:   []string{"@ana.lopez", "U456"},
+			expectedText: "*Deploy*\nDone\n<@U123> <@U456>",
+		},
+		{
+			name:         "Unknown name treated as channel",
+			lookup:       staticSlackUserLookup{},
+			recipients:   []string{"@deploys"},
+			expectedText: "*Deploy*\nDone\n#deploys",
+		},
+		{
+			name:         "Without lookup",
+			recipients:   []string{"@U123"},
+			expectedText: "*Deploy*\nDone\n<@U123>",
+		},
+		{
+			name:        "Lookup failure",
+			lookup:      staticSlackUserLookup{err: errors.New("users.list unavailable")},
+			recipients:  []string{"@ana.lopez"},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = slackMessage{}
+			service := NewSlackNotificationService(server.URL, time.Second)
+			service.UserLookup = tt.lookup
+			err := service.Send(context.Background(), &models.Notification{Title: "Deploy", Content: "Done", Recipients: tt.recipients})
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to send Slack notification: %v", err)
+			}
+			if received.Text != tt.expectedText {
+				t.Errorf("Expected text %q, got %q", tt.expectedText, received.Text)
+			}
+		})
+	}
+}
+
+// slackAPIServer answers conversations.open with a DM channel per user and
+// records the messages posted to the other Web API methods.
+func slackAPIServer(t *testing.T, opened *int, posted map[string][]slackAPIMessage) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("Expected bot token authorization, got %q", r.Header.Get("Authorization"))
+		}
+		method := r.URL.Path[1:]
+		if method == "conversations.open" {
+			var request map[string]string
+			json.NewDecoder(r.Body).Decode(&request)
+			*opened++
+			json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": map[string]string{"id": "D-" + request["users"]}})
+			return
+		}
+		var message slackAPIMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		posted[method] = append(posted[method], message)
+		if message.Channel == "#archived" {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "is_archived"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSlackNotificationServiceDirectMessage(t *testing.T) {
+	opened := 0
+	posted := make(map[string][]slackAPIMessage)
+	server := slackAPIServer(t, &opened, posted)
+
+	service := NewSlackNotificationService("", time.Second)
+	service.APIURL, service.BotToken = server.URL, "xoxb-test"
+	service.UserLookup = staticSlackUserLookup{users: map[string]string{"ana.lopez": "U456"}}
+	notification := &models.Notification{
+		ID:         "slack-dm",
+		Title:      "Deploy",
+		Content:    "Deploy finished",
+		Channel:    models.ChannelSlack,
+		Recipients: []string{"U123", "@ana.lopez", "#deploys"},
+		Metadata:   map[string]string{SlackSendModeMetadataKey: string(SlackDirectMessage)},
+	}
+
+	for range 2 {
+		if err := service.Send(context.Background(), notification); err != nil {
+			t.Fatalf("Failed to send Slack direct messages: %v", err)
+		}
+	}
+
+	if opened != 2 {
+		t.Errorf("Expected a DM channel opened once per user, got %d conversations.open calls", opened)
+	}
+	messages := posted["chat.postMessage"]
+	if len(messages) != 6 {
+		t.Fatalf("Expected 6 messages, got %d", len(messages))
+	}
+	var channels []string
+	for _, message := range messages[:3] {
+		channels = append(channels, message.Channel)
+	}
+	if !reflect.DeepEqual(channels, []string{"D-U123", "D-U456", "#deploys"}) {
+		t.Errorf("Expected DMs to both users and a post to #deploys, got %v", channels)
+	}
+	if messages[0].Text != "*Deploy*\nDeploy finished" {
+		t.Errorf("Expected text without mentions, got %q", messages[0].Text)
+	}
+	if messages[0].Metadata == nil || messages[0].Metadata.EventPayload["notification_id"] != "slack-dm" {
+		t.Errorf("Expected metadata identifying notification slack-dm, got %+v", messages[0].Metadata)
+	}
+
+	notification.Recipients = []string{"#archived"}
+	if err := service.Send(context.Background(), notification); err == nil {
+		t.Error("Expected error for a response that is not ok, got nil")
+	}
+}
+
+func TestSlackNotificationServiceEphemeralMessage(t *testing.T) {
+	opened := 0
+	posted := make(map[string][]slackAPIMessage)
+	server := slackAPIServer(t, &opened, posted)
+
+	service := NewSlackNotificationService("", time.Second)
+	service.APIURL, service.BotToken = server.URL, "xoxb-test"
+
+	tests := []struct {
+		name        string
+		recipients  []string
+		metadata    map[string]string
+		expectError bool
+	}{
+		{
+			name:       "Shown to each user",
+			recipients: []string{"U123", "U456"},
+			metadata:   map[string]string{SlackSendModeMetadataKey: string(SlackEphemeralMessage), SlackChannelIDMetadataKey: "C789"},
+		},
+		{
+			name:        "Without channel_id",
+			recipients:  []string{"U123"},
+			metadata:    map[string]string{SlackSendModeMetadataKey: string(SlackEphemeralMessage)},
+			expectError: true,
+		},
+		{
+			name:        "Channel recipient",
+			recipients:  []string{"#deploys"},
+			metadata:    map[string]string{SlackSendModeMetadataKey: string(SlackEphemeralMessage), SlackChannelIDMetadataKey: "C789"},
+			expectError: true,
+		},
+		{
+			name:        "Unknown send mode",
+			recipients:  []string{"U123"},
+			metadata:    map[string]string{SlackSendModeMetadataKey: "broadcast"},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(posted)
+			err := service.Send(context.Background(), &models.Notification{ID: "slack-eph", Title: "Deploy", Content: "Done", Recipients: tt.recipients, Metadata: tt.metadata})
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to send Slack ephemeral messages: %v", err)
+			}
+			messages := posted["chat.postEphemeral"]
+			if len(messages) != 2 {
+				t.Fatalf("Expected 2 ephemeral messages, got %d", len(messages))
+			}
+			for i, user := range tt.recipients {
+				if messages[i].Channel != "C789" || messages[i].User != user || messages[i].Metadata != nil {
+					t.Errorf("Expected an ephemeral message to %s in C789 without metadata, got %+v", user, messages[i])
+				}
+			}
+		})
+	}
+	if opened != 0 {
+		t.Errorf("Expected no DM channels opened for ephemeral messages, got %d", opened)
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"notification-service/internal/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSlackUserNotFound is returned by LookupByDisplayName when no Slack user
+// has the display name.
+var ErrSlackUserNotFound = errors.New("slack user not found")
+
+// SlackUserLookup resolves Slack display names to user IDs.
+type SlackUserLookup interface {
+	LookupByDisplayName(name string) (string, error)
+}
+
+// slackUsersPageSize is how many users each users.list call asks for.
+const slackUsersPageSize = 200
+
+// SlackAPIUserLookup is a SlackUserLookup that reads the workspace's users
+// with the Slack users.list API, using a bot token with the users:read
+// scope. The users are cached for TTL, so a name that is not found is only
+// looked for again once the cache expires.
+type SlackAPIUserLookup struct {
+	APIURL   string
+	BotToken string
+	Client   *http.Client
+	TTL      time.Duration
+
+	// users maps lower-cased display names to user IDs.
+	users   map[string]string
+	expires time.Time
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+func NewSlackAPIUserLookup(cfg *config.Config) *SlackAPIUserLookup {
+	return &SlackAPIUserLookup{
+		APIURL:   cfg.SlackAPIURL,
+		BotToken: cfg.SlackBotToken,
+		Client:   &http.Client{Timeout: cfg.HTTPTimeout},
+		TTL:      cfg.SlackUserCacheTTL,
+		now:      time.Now,
+	}
+}
+
+type slackUsersResponse struct {
+	OK               bool        `json:"ok"`
+	Error            string      `json:"error"`
+	Members          []slackUser `json:"members"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+type slackUser struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Deleted bool   `json:"deleted"`
+	Profile struct {
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+// LookupByDisplayName returns the ID of the user whose display name is name,
+// ignoring case and a leading "@". Users without a display name are matched
+// by their username.
+func (l *SlackAPIUserLookup) LookupByDisplayName(name string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.users == nil || !now.Before(l.expires) {
+		users, err := l.listUsers()
+		if err != nil {
+			return "", err
+		}
+		l.users, l.expires = users, now.Add(l.TTL)
+	}
+
+	id, ok := l.users[strings.ToLower(strings.TrimPrefix(name, "@"))]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSlackUserNotFound, name)
+	}
+	return id, nil
+}
+
+// listUsers pages through users.list and indexes the active users by
+// display name.
+func (l *SlackAPIUserLookup) listUsers() (map[string]string, error) {
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	users := make(map[string]string)
+	cursor := ""
+	for {
+		query := url.Values{"limit": {fmt.Sprint(slackUsersPageSize)}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		endpoint := strings.TrimRight(l.APIURL, "/") + "/users.list?" + query.Encode()
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build slack users.list request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+l.BotToken)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("slack users.list request failed: %w", err)
+		}
+		page, err := decodeSlackUsers(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range page.Members {
+			if user.Deleted {
+				continue
+			}
+			name := user.Profile.DisplayName
+			if name == "" {
+				name = user.Name
+			}
+			users[strings.ToLower(name)] = user.ID
+		}
+		cursor = page.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return users, nil
+		}
+	}
+}
+
+// decodeSlackUsers reads a users.list response, which reports most errors
+// with a 200 status and "ok": false.
+func decodeSlackUsers(resp *http.Response) (*slackUsersResponse, error) {
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &APIError{Channel: "slack", StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	var page slackUsersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode slack users.list response: %w", err)
+	}
+	if !page.OK {
+		return nil, fmt.Errorf("slack users.list failed: %s", page.Error)
+	}
+	return &page, nil
+}
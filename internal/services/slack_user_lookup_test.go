@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlackAPIUserLookup(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/users.list" {
+			t.Errorf("Expected /users.list, got %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer xoxb-token" {
+			t.Errorf("Expected bot token, got %q", auth)
+		}
+		// The directory spans two pages
+		if r.URL.Query().Get("cursor") == "" {
+			fmt.Fprint(w, `{"ok": true, "members": [
+				{"id": "U1", "name": "ana", "profile": {"display_name": "Ana.Lopez"}},
+				{"id": "U2", "name": "bob", "deleted": true, "profile": {"display_name": "bob"}}
+			], "response_metadata": {"next_cursor": "page-2"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true, "members": [
+			{"id": "U3", "name": "carla", "profile": {"display_name": ""}}
+		], "response_metadata": {"next_cursor": ""}}`)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	lookup := &SlackAPIUserLookup{APIURL: server.URL, BotToken: "xoxb-token", TTL: time.Minute, now: func() time.Time { return now }}
+
+	tests := []struct {
+		name       string
+		lookup     string
+		expectedID string
+		expectErr  error
+	}{
+		{"Display name", "ana.lopez", "U1", nil},
+		{"Leading @", "@Ana.Lopez", "U1", nil},
+		{"Username without display name", "carla", "U3", nil},
+		{"Deleted user", "bob", "", ErrSlackUserNotFound},
+		{"Unknown user", "dave", "", ErrSlackUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := lookup.LookupByDisplayName(tt.lookup)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if id != tt.expectedID {
+				t.Errorf("Expected ID %q, got %q", tt.expectedID, id)
+			}
+		})
+	}
+	if requests != 2 {
+		t.Errorf("Expected the directory to be listed once in 2 requests, got %d", requests)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := lookup.LookupByDisplayName("ana.lopez"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests != 4 {
+		t.Errorf("Expected the expired directory to be listed again, got %d requests", requests)
+	}
+}
+
+func TestSlackAPIUserLookupError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok": false, "error": "missing_scope"}`)
+	}))
+	defer server.Close()
+
+	lookup := &SlackAPIUserLookup{APIURL: server.URL, BotToken: "xoxb-token", TTL: time.Minute, now: time.Now}
+	_, err := lookup.LookupByDisplayName("ana")
+	if err == nil || errors.Is(err, ErrSlackUserNotFound) {
+		t.Errorf("Expected a users.list error, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RequestLimiter counts requests per key over a sliding window.
+type RequestLimiter interface {
+	// Allow records a request for key if fewer than limit requests fall in
+	// the window ending now. Otherwise it reports how long until one would
+	// be allowed, without recording the request.
+	Allow(ctx context.Context, key string, limit int) (bool, time.Duration, error)
+}
+
+// slidingWindow decides whether a request is allowed given the requests
+// counted in the previous and current fixed windows, elapsed into the
+// current one. The previous window's count is weighted by how much of it
+// the sliding window still covers.
+func slidingWindow(previous, current, limit int, elapsed, window time.Duration) (bool, time.Duration) {
+	remaining := 1 - float64(elapsed)/float64(window)
+	if float64(previous)*remaining+float64(current+1) <= float64(limit) {
+		return true, 0
+	}
+	// Wait for enough of the previous window to slide out, or, if the
+	// current window alone is over the limit, for it to become the previous
+	// one and slide out in turn
+	if current+1 <= limit {
+		covered := float64(limit-current-1) / float64(previous)
+		return false, time.Duration((1-covered)*float64(window)) - elapsed
+	}
+	wait := window - elapsed
+	if limit > 0 {
+		covered := float64(limit-1) / float64(current)
+		wait += time.Duration(math.Max(0, 1-covered) * float64(window))
+	}
+	return false, wait
+}
+
+// windowCounts holds the requests of a key in the current fixed window,
+// which started at start, and the one before it.
+type windowCounts struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+// SlidingWindowLimiter is a RequestLimiter that keeps its counts in memory,
+// so each instance limits on its own.
+type SlidingWindowLimiter struct {
+	window    time.Duration
+	counts    map[string]*windowCounts
+	lastSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+func NewSlidingWindowLimiter(window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		window: window,
+		counts: make(map[string]*windowCounts),
+		now:    time.Now,
+	}
+}
+
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	start := now.Truncate(l.window)
+	l.sweep(start)
+
+	counts, ok := l.counts[key]
+	if !ok {
+		counts = &windowCounts{start: start}
+		l.counts[key] = counts
+	}
+	switch {
+	case counts.start.Equal(start):
+	case counts.start.Add(l.window).Equal(start):
+		counts.start, counts.previous, counts.current = start, counts.current, 0
+	default:
+		counts.start, counts.previous, counts.current = start, 0, 0
+	}
+
+	allowed, retryAfter := slidingWindow(counts.previous, counts.current, limit, now.Sub(start), l.window)
+	if allowed {
+		counts.current++
+	}
+	return allowed, retryAfter, nil
+}
+
+// sweep drops keys with no requests in the window before start, once per
+// window, so clients that went away do not accumulate.
+func (l *SlidingWindowLimiter) sweep(start time.Time) {
+	if !start.After(l.lastSweep) {
+		return
+	}
+	l.lastSweep = start
+	for key, counts := range l.counts {
+		if counts.start.Add(l.window).Before(start) {
+			delete(l.counts, key)
+		}
+	}
+}
+
+// redisRateLimitKeyPrefix namespaces the limiter's counters in Redis.
+const redisRateLimitKeyPrefix = "notifications:ratelimit:"
+
+// slidingWindowScript counts a request in KEYS[1], the current window, if
+// the previous window KEYS[2] weighted by ARGV[2] plus the current window
+// stay within the limit ARGV[1]. It returns whether the request was
+// counted, and the counts it was decided on.
+var slidingWindowScript = redis.NewScript(`
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if previous * tonumber(ARGV[2]) + current + 1 > tonumber(ARGV[1]) then
+	return {0, previous, current}
+end
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1, previous, current}
+`)
+
+// RedisSlidingWindowLimiter is a RequestLimiter that keeps its counts in
+// Redis, so every instance sharing the server shares the limits.
+type RedisSlidingWindowLimiter struct {
+	client *redis.Client
+	window time.Duration
+	now    func() time.Time
+}
+
+func NewRedisSlidingWindowLimiter(client *redis.Client, window time.Duration) *RedisSlidingWindowLimiter {
+	return &RedisSlidingWindowLimiter{client: client, window: window, now: time.Now}
+}
+
+// Close closes the Redis client.
+func (l *RedisSlidingWindowLimiter) Close() error {
+	return l.client.Close()
+}
+
+func (l *RedisSlidingWindowLimiter) Allow(ctx context.Context, key string, limit int) (bool, time.Duration, error) {
+	now := l.now()
+	start := now.Truncate(l.window)
+	elapsed := now.Sub(start)
+	index := start.UnixNano() / int64(l.window)
+	keys := []string{
+		redisRateLimitKeyPrefix + key + ":" + strconv.FormatInt(index, 10),
+		redisRateLimitKeyPrefix + key + ":" + strconv.FormatInt(index-1, 10),
+	}
+	remaining := 1 - float64(elapsed)/float64(l.window)
+
+	result, err := slidingWindowScript.Run(ctx, l.client, keys, limit, remaining, (2 * l.window).Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to count request in Redis: %w", err)
+	}
+	if result[0] == 1 {
+		return true, 0, nil
+	}
+	_, retryAfter := slidingWindow(int(result[1]), int(result[2]), limit, elapsed, l.window)
+	return false, retryAfter, nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	memory := NewSlidingWindowLimiter(time.Minute)
+	shared := NewRedisSlidingWindowLimiter(client, time.Minute)
+
+	tests := []struct {
+		name    string
+		limiter RequestLimiter
+		setNow  func(now func() time.Time)
+	}{
+		{"Memory", memory, func(now func() time.Time) { memory.now = now }},
+		{"Redis", shared, func(now func() time.Time) { shared.now = now }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, 3, 31, 15, 0, 0, 0, time.UTC)
+			tt.setNow(func() time.Time { return now })
+			ctx := context.Background()
+
+			for i := 0; i < 4; i++ {
+				if allowed, _, err := tt.limiter.Allow(ctx, "client", 4); err != nil || !allowed {
+					t.Fatalf("Expected request %d to be allowed, got %v (%v)", i+1, allowed, err)
+				}
+			}
+			allowed, retryAfter, err := tt.limiter.Allow(ctx, "client", 4)
+			if err != nil || allowed {
+				t.Fatalf("Expected the fifth request to be limited, got %v (%v)", allowed, err)
+			}
+			// The window's 4 requests must slide a quarter out: 15s into the next window
+			if retryAfter != 75*time.Second {
+				t.Errorf("Expected retry after 75s, got %v", retryAfter)
+			}
+			if allowed, _, _ := tt.limiter.Allow(ctx, "other", 4); !allowed {
+				t.Error("Expected another key to have its own limit")
+			}
+
+			// Halfway into the next window half of the previous one still counts
+			now = now.Add(90 * time.Second)
+			for i := 0; i < 2; i++ {
+				if allowed, _, _ := tt.limiter.Allow(ctx, "client", 4); !allowed {
+					t.Fatalf("Expected request %d of the next window to be allowed", i+1)
+				}
+			}
+			if allowed, retryAfter, _ := tt.limiter.Allow(ctx, "client", 4); allowed || retryAfter != 15*time.Second {
+				t.Errorf("Expected to be limited for 15s, got %v (%v)", allowed, retryAfter)
+			}
+
+			// Once a window has passed without requests the limit resets
+			now = now.Add(2 * time.Minute)
+			if allowed, _, _ := tt.limiter.Allow(ctx, "client", 4); !allowed {
+				t.Error("Expected the limit to reset")
+			}
+		})
+	}
+}
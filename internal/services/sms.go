@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// SMS payload sizes. A single message holds 160 GSM-7 characters or 70 UCS-2
+// ones; the parts of a concatenated message hold 7 bytes less to make room
+// for the user data header that reassembles them.
+const (
+	smsGSM7Length     = 160
+	smsGSM7PartLength = 153
+	smsUCS2Length     = 70
+	smsUCS2PartLength = 67
+)
+
+// smsMaxParts is how many parts the default content limit allows for.
+const smsMaxParts = 10
+
+// SMSSessionIDMetadataKey names the notification metadata entry holding the
+// ID shared by the parts of a concatenated SMS.
+const SMSSessionIDMetadataKey = "session_id"
+
+// gsm7Basic and gsm7Extended are the characters of the GSM 03.38 default
+// alphabet. Extended characters are sent as an escape plus the character, so
+// they count twice.
+const (
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extended = "\f^{}\\[~]|€"
+)
+
+// SplitSMS splits content into the parts of a concatenated SMS. Content that
+// fits a single message is returned as its only part. Content using only the
+// GSM-7 alphabet is split into parts of at most 153 characters, anything else
+// is sent as UCS-2 in parts of at most 67 UTF-16 code units. Characters that
+// take two units are never split across parts.
+func SplitSMS(content string) []string {
+	single, part, width := smsUCS2Length, smsUCS2PartLength, ucs2Width
+	if isGSM7(content) {
+		single, part, width = smsGSM7Length, smsGSM7PartLength, gsm7Width
+	}
+
+	total := 0
+	for _, r := range content {
+		total += width(r)
+	}
+	if total <= single {
+		return []string{content}
+	}
+
+	var parts []string
+	var current strings.Builder
+	length := 0
+	for _, r := range content {
+		w := width(r)
+		if length+w > part {
+			parts = append(parts, current.String())
+			current.Reset()
+			length = 0
+		}
+		current.WriteRune(r)
+		length += w
+	}
+	return append(parts, current.String())
+}
+
+// isGSM7 reports whether every character of content is in the GSM-7
+// alphabet.
+func isGSM7(content string) bool {
+	for _, r := range content {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extended, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// gsm7Width is how many septets r takes in GSM-7.
+func gsm7Width(r rune) int {
+	if strings.ContainsRune(gsm7Extended, r) {
+		return 2
+	}
+	return 1
+}
+
+// ucs2Width is how many UTF-16 code units r takes.
+func ucs2Width(r rune) int {
+	if utf16.RuneLen(r) == 2 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestSplitSMS(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedParts []int
+	}{
+		{"Single GSM-7 message", strings.Repeat("a", 160), []int{160}},
+		{"Concatenated GSM-7 message", strings.Repeat("a", 161), []int{153, 8}},
+		{"Extended characters count twice", strings.Repeat("€", 80), []int{80}},
+		{"Extended character not split", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), []int{152, 11}},
+		{"Single UCS-2 message", strings.Repeat("ж", 70), []int{70}},
+		{"Concatenated UCS-2 message", strings.Repeat("ж", 71), []int{67, 4}},
+		{"Surrogate pair not split", strings.Repeat("ж", 66) + "😀" + strings.Repeat("ж", 5), []int{66, 6}},
+		{"Empty content", "", []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := SplitSMS(tt.content)
+			if len(parts) != len(tt.expectedParts) {
+				t.Fatalf("Expected %d parts, got %d", len(tt.expectedParts), len(parts))
+			}
+			for i, part := range parts {
+				if n := len([]rune(part)); n != tt.expectedParts[i] {
+					t.Errorf("Expected part %d to have %d characters, got %d", i, tt.expectedParts[i], n)
+				}
+			}
+			if strings.Join(parts, "") != tt.content {
+				t.Error("Expected parts to join back into the content")
+			}
+		})
+	}
+
+	for _, part := range SplitSMS(strings.Repeat("😀", 100)) {
+		if n := len(utf16.Encode([]rune(part))); n > smsUCS2PartLength {
+			t.Errorf("Expected UCS-2 parts of at most %d code units, got %d", smsUCS2PartLength, n)
+		}
+	}
+}
+
+func TestMessageNotificationServiceSplitsLongContent(t *testing.T) {
+	service := &MessageNotificationService{}
+
+	short := &models.Notification{Content: "Your code is 1234", Channel: models.ChannelMessage}
+	if err := service.Send(context.Background(), short); err != nil {
+		t.Fatalf("Failed to send SMS: %v", err)
+	}
+	if short.Metadata[SMSSessionIDMetadataKey] != "" || short.SentMetadata["parts"] != "" {
+		t.Errorf("Expected a single SMS without a session, got %v / %v", short.Metadata, short.SentMetadata)
+	}
+
+	long := &models.Notification{Content: strings.Repeat("a", 400), Channel: models.ChannelMessage}
+	if err := service.Send(context.Background(), long); err != nil {
+		t.Fatalf("Failed to send SMS: %v", err)
+	}
+	if long.Metadata[SMSSessionIDMetadataKey] == "" {
+		t.Error("Expected a session ID in metadata")
+	}
+	if long.SentMetadata["parts"] != "3" {
+		t.Errorf("Expected 3 parts, got %q", long.SentMetadata["parts"])
+	}
+}
+
+func TestMessageNotificationServiceSenderAlias(t *testing.T) {
+	// 155 characters fit one SMS, but not with "Reports: " in front
+	content := strings.Repeat("a", 155)
+	tests := []struct {
+		name          string
+		alphanumeric  bool
+		alias         string
+		expectedParts string
+	}{
+		{"Alphanumeric sender ID", true, "Reports", "2"},
+		{"Not supported by the number", false, "Reports", ""},
+		{"Alias too long", true, "Monthly Reports", ""},
+		{"Alias with punctuation", true, "Reports!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &MessageNotificationService{AlphanumericSenderID: tt.alphanumeric}
+			notification := &models.Notification{Content: content, Channel: models.ChannelMessage, Metadata: map[string]string{SenderAliasMetadataKey: tt.alias}}
+			if err := service.Send(context.Background(), notification); err != nil {
+				t.Fatalf("Failed to send SMS: %v", err)
+			}
+			if parts := notification.SentMetadata["parts"]; parts != tt.expectedParts {
+				t.Errorf("Expected parts %q, got %q", tt.expectedParts, parts)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"fmt"
+	"notification-service/internal/models"
+	"regexp"
+	"unicode/utf8"
+)
+
+// e164Pattern matches phone numbers in E.164 format, e.g. +14155552671.
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// SMSValidator checks the recipients and content of text messages sent to
+// phone numbers, by SMS or WhatsApp.
+type SMSValidator struct {
+	// MaxContentLength caps the content in characters; zero leaves it
+	// unrestricted.
+	MaxContentLength int
+}
+
+// ValidateRecipient reports whether recipient is an E.164 phone number.
+func (v SMSValidator) ValidateRecipient(recipient string) error {
+	if !e164Pattern.MatchString(recipient) {
+		return fmt.Errorf("invalid recipient %s: must be an E.164 phone number, e.g. +14155552671", recipient)
+	}
+	return nil
+}
+
+// ValidateContent reports whether content fits MaxContentLength.
+func (v SMSValidator) ValidateContent(content string) error {
+	if n := utf8.RuneCountInString(content); v.MaxContentLength > 0 && n > v.MaxContentLength {
+		return fmt.Errorf("content is %d characters, exceeding the limit of %d", n, v.MaxContentLength)
+	}
+	return nil
+}
+
+// Validate returns the first problem with the notification's content or
+// recipients.
+func (v SMSValidator) Validate(notification *models.Notification) error {
+	if err := v.ValidateContent(notification.Content); err != nil {
+		return err
+	}
+	for _, recipient := range notification.Recipients {
+		if err := v.ValidateRecipient(recipient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"notification-service/internal/models"
+	"testing"
+)
+
+func TestSMSValidator(t *testing.T) {
+	validator := SMSValidator{MaxContentLength: 5}
+	tests := []struct {
+		name         string
+		notification *models.Notification
+		valid        bool
+	}{
+		{"Valid", &models.Notification{Content: "Hello", Recipients: []string{"+14155552671", "+447700900000"}}, true},
+		{"Counts characters", &models.Notification{Content: "héllo", Recipients: []string{"+14155552671"}}, true},
+		{"Too long", &models.Notification{Content: "Hello!", Recipients: []string{"+14155552671"}}, false},
+		{"Missing plus", &models.Notification{Content: "Hi", Recipients: []string{"14155552671"}}, false},
+		{"Leading zero", &models.Notification{Content: "Hi", Recipients: []string{"+04155552671"}}, false},
+		{"Too many digits", &models.Notification{Content: "Hi", Recipients: []string{"+1234567890123456"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validator.Validate(tt.notification); (err == nil) != tt.valid {
+				t.Errorf("Expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// SNSTopicARNMetadataKey names the notification metadata entry holding the
+// ARN of an SNS topic to publish the notification to, fanning it out to the
+// topic's subscribers, instead of publishing it to each recipient.
+const SNSTopicARNMetadataKey = "sns_topic_arn"
+
+// SNSMessageIDSentMetadataKey names the SentMetadata entry holding the ID SNS
+// assigned to the last message it published.
+const SNSMessageIDSentMetadataKey = "sns_message_id"
+
+// snsMaxSubjectLength is the longest subject SNS accepts for topic email
+// subscribers.
+const snsMaxSubjectLength = 100
+
+// SNSPublisher publishes messages to Amazon SNS; *sns.Client implements it.
+type SNSPublisher interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSNotificationService publishes notifications through Amazon SNS. Each
+// recipient is an E.164 phone number, sent the content as an SMS, or the ARN
+// of a platform application endpoint, sent the title and content as a push
+// notification. With Metadata["sns_topic_arn"] the notification is instead
+// published once to that topic. When Publisher is nil the notification is
+// only printed to stdout.
+type SNSNotificationService struct {
+	Publisher SNSPublisher
+	// AlphanumericSenderID sends SMS from the sender alias when it is a
+	// valid sender ID.
+	AlphanumericSenderID bool
+	Logger               logging.Logger
+}
+
+// NewSNSNotificationService returns a service publishing in cfg.AWSRegion with
+// cfg's access key, or only logging notifications when there is none.
+func NewSNSNotificationService(cfg *config.Config) *SNSNotificationService {
+	service := &SNSNotificationService{AlphanumericSenderID: cfg.SMSAlphanumericSenderID}
+	if cfg.AWSAccessKeyID == "" {
+		return service
+	}
+	credentials := aws.Credentials{
+		AccessKeyID:     cfg.AWSAccessKeyID,
+		SecretAccessKey: cfg.AWSSecretAccessKey,
+		Source:          "notification-service config",
+	}
+	service.Publisher = sns.New(sns.Options{
+		Region: cfg.AWSRegion,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return credentials, nil
+		}),
+		HTTPClient: &http.Client{Timeout: cfg.HTTPTimeout},
+	})
+	return service
+}
+
+func (s *SNSNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if s.Publisher == nil {
+		logDryRun(ctx, s.Logger, notification)
+		return nil
+	}
+
+	if topicARN := notification.Metadata[SNSTopicARNMetadataKey]; topicARN != "" {
+		input := &sns.PublishInput{TopicArn: aws.String(topicARN), Message: aws.String(notification.Content)}
+		if isSNSSubject(notification.Title) {
+			input.Subject = aws.String(notification.Title)
+		}
+		if err := s.publish(ctx, notification, input); err != nil {
+			return fmt.Errorf("failed to publish to sns topic %s: %w", topicARN, err)
+		}
+		return nil
+	}
+
+	for _, recipient := range notification.Recipients {
+		input, err := s.recipientInput(notification, recipient)
+		if err == nil {
+			err = s.publish(ctx, notification, input)
+		}
+		notification.RecordRecipient(recipient, err)
+		if err != nil {
+			return fmt.Errorf("failed to publish sns message to %s: %w", recipient, err)
+		}
+	}
+	return nil
+}
+
+// recipientInput returns the request publishing notification to recipient, a
+// platform endpoint ARN or a phone number.
+func (s *SNSNotificationService) recipientInput(notification *models.Notification, recipient string) (*sns.PublishInput, error) {
+	if strings.HasPrefix(recipient, "arn:") {
+		return &sns.PublishInput{
+			TargetArn:        aws.String(recipient),
+			Message:          aws.String(snsPushMessage(notification)),
+			MessageStructure: aws.String("json"),
+		}, nil
+	}
+
+	if err := (SMSValidator{}).ValidateRecipient(recipient); err != nil {
+		return nil, &PermanentError{Err: err}
+	}
+	input := &sns.PublishInput{PhoneNumber: aws.String(recipient), Message: aws.String(notification.Content)}
+	if alias := notification.Metadata[SenderAliasMetadataKey]; s.AlphanumericSenderID && isAlphanumericSenderID(alias) {
+		input.MessageAttributes = map[string]types.MessageAttributeValue{
+			"AWS.SNS.SMS.SenderID": {DataType: aws.String("String"), StringValue: aws.String(alias)},
+		}
+	}
+	return input, nil
+}
+
+// publish sends input, recording the message ID on notification. SNS
+// rejecting a parameter is permanent: the same request would fail again.
+func (s *SNSNotificationService) publish(ctx context.Context, notification *models.Notification, input *sns.PublishInput) error {
+	output, err := s.Publisher.Publish(ctx, input)
+	if err != nil {
+		var invalidParameter *types.InvalidParameterException
+		var invalidValue *types.InvalidParameterValueException
+		if errors.As(err, &invalidParameter) || errors.As(err, &invalidValue) {
+			return &PermanentError{Err: err}
+		}
+		return err
+	}
+
+	if notification.SentMetadata == nil {
+		notification.SentMetadata = make(map[string]string)
+	}
+	notification.SentMetadata[SNSMessageIDSentMetadataKey] = aws.ToString(output.MessageId)
+	return nil
+}
+
+// snsPushMessage builds the per-platform JSON message SNS delivers to
+// platform endpoints, showing the title and content as an alert on APNs and
+// as a notification on FCM.
+func snsPushMessage(notification *models.Notification) string {
+	apns, _ := json.Marshal(apnsPayload{APS: apnsAPS{Alert: apnsAlert{Title: notification.Title, Body: notification.Content}}})
+	gcm, _ := json.Marshal(map[string]interface{}{
+		"notification": map[string]string{"title": notification.Title, "body": notification.Content},
+	})
+	message, _ := json.Marshal(map[string]string{
+		"default":      notification.Content,
+		"APNS":         string(apns),
+		"APNS_SANDBOX": string(apns),
+		"GCM":          string(gcm),
+	})
+	return string(message)
+}
+
+// isSNSSubject reports whether title can be a topic message's subject, which
+// must be printable ASCII of at most snsMaxSubjectLength characters.
+func isSNSSubject(title string) bool {
+	if title == "" || len(title) > snsMaxSubjectLength {
+		return false
+	}
+	for _, r := range title {
+		if r < ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
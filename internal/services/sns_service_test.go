@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"notification-service/internal/models"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+type fakeSNSPublisher struct {
+	published []*sns.PublishInput
+	err       error
+}
+
+func (p *fakeSNSPublisher) Publish(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.published = append(p.published, input)
+	return &sns.PublishOutput{MessageId: aws.String("msg-1")}, nil
+}
+
+func TestSNSNotificationService(t *testing.T) {
+	publisher := &fakeSNSPublisher{}
+	service := &SNSNotificationService{Publisher: publisher, AlphanumericSenderID: true}
+	endpoint := "arn:aws:sns:eu-west-1:123456789012:endpoint/APNS/app/0f1e2d3c"
+	notification := &models.Notification{
+		Title:      "Deploy finished",
+		Content:    "v1.4 is live",
+		Recipients: []string{"+447700900000", endpoint},
+		Metadata:   map[string]string{"sender_alias": "Acme"},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to publish SNS messages: %v", err)
+	}
+	if len(publisher.published) != 2 {
+		t.Fatalf("Expected a message per recipient, got %d", len(publisher.published))
+	}
+
+	sms := publisher.published[0]
+	if aws.ToString(sms.PhoneNumber) != "+447700900000" || aws.ToString(sms.Message) != "v1.4 is live" {
+		t.Errorf("Expected the content sent as SMS, got %q to %q", aws.ToString(sms.Message), aws.ToString(sms.PhoneNumber))
+	}
+	if senderID := sms.MessageAttributes["AWS.SNS.SMS.SenderID"]; aws.ToString(senderID.StringValue) != "Acme" {
+		t.Errorf("Expected the SMS sent from the sender alias, got %q", aws.ToString(senderID.StringValue))
+	}
+
+	push := publisher.published[1]
+	if aws.ToString(push.TargetArn) != endpoint || aws.ToString(push.MessageStructure) != "json" {
+		t.Errorf("Expected a JSON message to the endpoint, got %q to %q", aws.ToString(push.MessageStructure), aws.ToString(push.TargetArn))
+	}
+	var message map[string]string
+	json.Unmarshal([]byte(aws.ToString(push.Message)), &message)
+	var apns apnsPayload
+	json.Unmarshal([]byte(message["APNS"]), &apns)
+	if message["default"] != "v1.4 is live" || apns.APS.Alert.Title != "Deploy finished" {
+		t.Errorf("Expected per-platform messages with the title and content, got %v", message)
+	}
+
+	if len(notification.RecipientStatuses) != 2 || notification.SentMetadata["sns_message_id"] != "msg-1" {
+		t.Errorf("Expected both recipients recorded and the message ID, got %+v %v", notification.RecipientStatuses, notification.SentMetadata)
+	}
+}
+
+func TestSNSNotificationServiceTopic(t *testing.T) {
+	publisher := &fakeSNSPublisher{}
+	service := &SNSNotificationService{Publisher: publisher}
+	topic := "arn:aws:sns:eu-west-1:123456789012:deploys"
+	notification := &models.Notification{
+		Title:      "Deploy finished",
+		Content:    "v1.4 is live",
+		Recipients: []string{"+447700900000"},
+		Metadata:   map[string]string{"sns_topic_arn": topic},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to publish to SNS topic: %v", err)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("Expected a single message to the topic, got %d", len(publisher.published))
+	}
+	input := publisher.published[0]
+	if aws.ToString(input.TopicArn) != topic || aws.ToString(input.Subject) != "Deploy finished" || input.PhoneNumber != nil {
+		t.Errorf("Expected the notification published to the topic only, got %+v", input)
+	}
+}
+
+func TestSNSNotificationServiceInvalidParameter(t *testing.T) {
+	publisher := &fakeSNSPublisher{err: &types.InvalidParameterException{Message: aws.String("Invalid parameter: PhoneNumber")}}
+	service := &SNSNotificationService{Publisher: publisher}
+	notification := &models.Notification{Content: "Hi", Recipients: []string{"+447700900000"}}
+
+	var permanent *PermanentError
+	if err := service.Send(context.Background(), notification); !errors.As(err, &permanent) {
+		t.Errorf("Expected a permanent error, got %v", err)
+	}
+	if len(notification.RecipientStatuses) != 1 || notification.RecipientStatuses[0].Status != models.StatusFailed {
+		t.Errorf("Expected the recipient recorded as failed, got %+v", notification.RecipientStatuses)
+	}
+
+	// Other failures are left to the retry classification
+	publisher.err = errors.New("connection reset")
+	if err := service.Send(context.Background(), notification); errors.As(err, &permanent) {
+		t.Errorf("Expected a transport failure not to be permanent, got %v", err)
+	}
+}
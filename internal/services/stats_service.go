@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/repository"
+	"sync"
+	"time"
+)
+
+// StatsCacheTTL is how long aggregated notification stats are reused before
+// the repository is asked again.
+const StatsCacheTTL = time.Minute
+
+// StatsService serves notification stats, aggregating each window at most
+// once per StatsCacheTTL.
+type StatsService struct {
+	repository repository.NotificationRepository
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[repository.StatsOptions]cachedStats
+}
+
+type cachedStats struct {
+	stats   *repository.NotificationStats
+	expires time.Time
+}
+
+func NewStatsService(repo repository.NotificationRepository) *StatsService {
+	return &StatsService{
+		repository: repo,
+		ttl:        StatsCacheTTL,
+		now:        time.Now,
+		cache:      make(map[repository.StatsOptions]cachedStats),
+	}
+}
+
+// Stats returns the stats of the notifications matching opts, from the
+// cache when they were aggregated within the TTL.
+func (s *StatsService) Stats(ctx context.Context, opts repository.StatsOptions) (*repository.NotificationStats, error) {
+	// Equal times in different locations must share an entry
+	opts.From, opts.To = opts.From.UTC(), opts.To.UTC()
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[opts]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.stats, nil
+	}
+
+	stats, err := s.repository.Aggregate(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Windows are chosen by callers, so drop expired ones as we go
+	for key, entry := range s.cache {
+		if !now.Before(entry.expires) {
+			delete(s.cache, key)
+		}
+	}
+	s.cache[opts] = cachedStats{stats: stats, expires: now.Add(s.ttl)}
+	return stats, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestStatsServiceCachesAggregate(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	stats := NewStatsService(repo)
+	now := time.Now()
+	stats.now = func() time.Time { return now }
+
+	save := func(id string, status models.NotificationStatus) {
+		repo.Save(ctx, &models.Notification{ID: id, Channel: models.ChannelEmail, Status: status, CreatedAt: time.Now()})
+	}
+	save("stats-1", models.StatusSent)
+
+	first, err := stats.Stats(ctx, repository.StatsOptions{})
+	if err != nil {
+		t.Fatalf("Failed to aggregate stats: %v", err)
+	}
+	if first.ByStatus[models.StatusSent] != 1 || first.ByChannel[models.ChannelEmail] != 1 || first.Last24h != 1 {
+		t.Fatalf("Unexpected stats %+v", first)
+	}
+
+	save("stats-2", models.StatusFailed)
+	if cached, _ := stats.Stats(ctx, repository.StatsOptions{}); cached.ByStatus[models.StatusFailed] != 0 {
+		t.Errorf("Expected cached stats within the TTL, got %+v", cached)
+	}
+	if windowed, _ := stats.Stats(ctx, repository.StatsOptions{From: now.Add(-time.Hour)}); windowed.ByStatus[models.StatusFailed] != 1 {
+		t.Errorf("Expected a different window to be aggregated separately, got %+v", windowed)
+	}
+
+	now = now.Add(StatsCacheTTL)
+	if refreshed, _ := stats.Stats(ctx, repository.StatsOptions{}); refreshed.ByStatus[models.StatusFailed] != 1 || refreshed.Last24h != 2 {
+		t.Errorf("Expected stats refreshed after the TTL, got %+v", refreshed)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/mail"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// SuppressionNotificationService drops the To, Cc and Bcc addresses that
+// have unsubscribed from a notification's category before passing it on to
+// an email provider. It returns ErrRecipientsSuppressed without sending when
+// none of the To recipients are left. Without Unsubscribe it sends every
+// notification as it is.
+type SuppressionNotificationService struct {
+	service     NotificationService
+	Unsubscribe *UnsubscribeService
+	Logger      logging.Logger
+}
+
+func NewSuppressionNotificationService(service NotificationService) *SuppressionNotificationService {
+	return &SuppressionNotificationService{service: service}
+}
+
+// Send passes on a copy of notification without the suppressed addresses,
+// and copies back what the provider recorded on it, such as
+// RecipientStatuses, leaving the notification's recipients and metadata
+// alone.
+func (s *SuppressionNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if s.Unsubscribe == nil {
+		return s.service.Send(ctx, notification)
+	}
+	cc, bcc, err := emailCopyRecipients(notification)
+	if err != nil {
+		return err
+	}
+
+	var dropped []string
+	keep := func(address string) (bool, error) {
+		suppressed, err := s.Unsubscribe.Suppressed(ctx, address, notification.Category)
+		if suppressed {
+			dropped = append(dropped, address)
+		}
+		return !suppressed, err
+	}
+	var recipients []string
+	for _, recipient := range notification.Recipients {
+		ok, err := keep(recipient)
+		if err != nil {
+			return err
+		}
+		if ok {
+			recipients = append(recipients, recipient)
+		}
+	}
+	if cc, err = keepAddresses(cc, keep); err != nil {
+		return err
+	}
+	if bcc, err = keepAddresses(bcc, keep); err != nil {
+		return err
+	}
+	if len(dropped) == 0 {
+		return s.service.Send(ctx, notification)
+	}
+
+	logging.FromContext(ctx, s.Logger).Info("Skipped unsubscribed email addresses",
+		logging.NotificationAttrs(notification, "addresses", dropped)...)
+	if len(recipients) == 0 {
+		return fmt.Errorf("email notification %s: %w", notification.ID, ErrRecipientsSuppressed)
+	}
+	sent := *notification
+	sent.Recipients = recipients
+	sent.Metadata = maps.Clone(notification.Metadata)
+	setAddressList(sent.Metadata, EmailCCMetadataKey, cc)
+	setAddressList(sent.Metadata, EmailBCCMetadataKey, bcc)
+	err = s.service.Send(ctx, &sent)
+	recorded := sent
+	recorded.Recipients, recorded.Metadata = notification.Recipients, notification.Metadata
+	*notification = recorded
+	return err
+}
+
+// setAddressList sets metadata[key] to addresses as a comma-separated list,
+// or deletes it when there are none.
+func setAddressList(metadata map[string]string, key string, addresses []*mail.Address) {
+	if len(addresses) == 0 {
+		delete(metadata, key)
+		return
+	}
+	list := make([]string, len(addresses))
+	for i, address := range addresses {
+		list[i] = address.String()
+	}
+	metadata[key] = strings.Join(list, ", ")
+}
+
+// keepAddresses returns the addresses keep reports true for.
+func keepAddresses(addresses []*mail.Address, keep func(string) (bool, error)) ([]*mail.Address, error) {
+	var kept []*mail.Address
+	for _, address := range addresses {
+		ok, err := keep(address.Address)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, address)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
+	"testing"
+)
+
+func TestSuppressionNotificationService(t *testing.T) {
+	ctx := context.Background()
+	unsubscribe := NewUnsubscribeService("s3cret", "https://notify.example.com", repository.NewMemoryRepository())
+	unsubscribe.Unsubscribe(ctx, unsubscribe.Token("b@example.com", "billing"))
+	unsubscribe.Unsubscribe(ctx, unsubscribe.Token("audit@example.com", "billing"))
+
+	provider := &mock.MockNotificationService{}
+	service := NewSuppressionNotificationService(provider)
+	notification := &models.Notification{
+		ID:         "email-1",
+		Category:   "billing",
+		Recipients: []string{"a@example.com", "b@example.com"},
+		Metadata:   map[string]string{"cc": "Ana <ana@example.com>, b@example.com", "bcc": "audit@example.com"},
+	}
+
+	// Without an unsubscribe service every address is sent to
+	if err := service.Send(ctx, notification); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if sent := provider.SentNotifications(); sent[0] != notification {
+		t.Errorf("Expected the notification to be passed on as it is, got %+v", sent[0])
+	}
+
+	service.Unsubscribe = unsubscribe
+	if err := service.Send(ctx, notification); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	sent := provider.SentNotifications()[1]
+	if len(sent.Recipients) != 1 || sent.Recipients[0] != "a@example.com" ||
+		sent.Metadata["cc"] != `"Ana" <ana@example.com>` || sent.Metadata["bcc"] != "" {
+		t.Errorf("Expected unsubscribed addresses to be dropped, got %v %v", sent.Recipients, sent.Metadata)
+	}
+	if len(notification.Recipients) != 2 || notification.Metadata["bcc"] != "audit@example.com" {
+		t.Errorf("Expected the notification to be left alone, got %v %v", notification.Recipients, notification.Metadata)
+	}
+}
+
+func TestSuppressionAppliesToEveryEmailProvider(t *testing.T) {
+	ctx := context.Background()
+	unsubscribe := NewUnsubscribeService("s3cret", "https://notify.example.com", repository.NewMemoryRepository())
+	unsubscribe.Unsubscribe(ctx, unsubscribe.Token("a@example.com", "billing"))
+
+	for _, provider := range []string{"smtp", "sendgrid", "mailgun"} {
+		t.Run(provider, func(t *testing.T) {
+			factory := NewNotificationServiceFactory(&config.Config{EmailProvider: provider})
+			factory.WithUnsubscribeService(unsubscribe)
+			service, _ := factory.GetService(models.ChannelEmail)
+			notification := &models.Notification{ID: "email-1", Title: "Invoice", Content: "Ready", Category: "billing", Recipients: []string{"a@example.com"}}
+			if err := service.Send(ctx, notification); !errors.Is(err, ErrRecipientsSuppressed) {
+				t.Errorf("Expected ErrRecipientsSuppressed, got %v", err)
+			}
+		})
+	}
+}
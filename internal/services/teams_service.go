@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"time"
+)
+
+// TeamsNotificationService posts Adaptive Cards to a Microsoft Teams incoming
+// webhook. When WebhookURL is empty the notification is only printed to stdout.
+type TeamsNotificationService struct {
+	WebhookURL string
+	Client     *http.Client
+	MaxRetries int
+	RetryDelay time.Duration
+	Logger     logging.Logger
+}
+
+func NewTeamsNotificationService(cfg *config.Config) *TeamsNotificationService {
+	return &TeamsNotificationService{
+		WebhookURL: cfg.TeamsWebhookURL,
+		Client:     &http.Client{Timeout: cfg.HTTPTimeout},
+		MaxRetries: 2,
+		RetryDelay: 500 * time.Millisecond,
+	}
+}
+
+// teamsCardColors are the text colors supported by Adaptive Cards.
+var teamsCardColors = map[string]bool{
+	"default":   true,
+	"dark":      true,
+	"light":     true,
+	"accent":    true,
+	"good":      true,
+	"warning":   true,
+	"attention": true,
+}
+
+type teamsTextBlock struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Wrap   bool   `json:"wrap"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+}
+
+type teamsAdaptiveCard struct {
+	Schema  string           `json:"$schema"`
+	Type    string           `json:"type"`
+	Version string           `json:"version"`
+	Body    []teamsTextBlock `json:"body"`
+}
+
+type teamsAttachment struct {
+	ContentType string            `json:"contentType"`
+	Content     teamsAdaptiveCard `json:"content"`
+}
+
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+func (t *TeamsNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if t.WebhookURL == "" {
+		logDryRun(ctx, t.Logger, notification)
+		return nil
+	}
+
+	message := buildTeamsMessage(notification)
+
+	var err error
+	for attempt := 0; attempt <= t.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if ctxErr := sleepContext(ctx, t.RetryDelay*time.Duration(attempt)); ctxErr != nil {
+				break
+			}
+		}
+		err = postJSON(ctx, t.Client, "teams", t.WebhookURL, nil, message, nil)
+		if !isTransientHTTPError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send teams notification: %w", err)
+	}
+	return nil
+}
+
+func buildTeamsMessage(notification *models.Notification) teamsMessage {
+	title := teamsTextBlock{
+		Type:   "TextBlock",
+		Text:   notification.Title,
+		Wrap:   true,
+		Weight: "Bolder",
+		Size:   "Medium",
+	}
+	if color := notification.Metadata["color"]; teamsCardColors[color] {
+		title.Color = color
+	}
+
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsAdaptiveCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body: []teamsTextBlock{
+					title,
+					{Type: "TextBlock", Text: notification.Content, Wrap: true},
+				},
+			},
+		}},
+	}
+}
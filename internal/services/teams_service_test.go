@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"testing"
+)
+
+func TestTeamsNotificationServiceRetriesTransientFailures(t *testing.T) {
+	attempts := 0
+	var received teamsMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	service := &TeamsNotificationService{WebhookURL: server.URL, Client: server.Client(), MaxRetries: 2}
+	notification := &models.Notification{
+		Title:    "Build failed",
+		Content:  "main is red",
+		Metadata: map[string]string{"color": "attention"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send Teams notification: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+
+	body := received.Attachments[0].Content.Body
+	if body[0].Text != "Build failed" || body[0].Color != "attention" {
+		t.Errorf("Unexpected title block: %+v", body[0])
+	}
+	if body[1].Text != "main is red" {
+		t.Errorf("Unexpected content block: %+v", body[1])
+	}
+}
+
+func TestTeamsNotificationServiceDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	service := &TeamsNotificationService{WebhookURL: server.URL, Client: server.Client(), MaxRetries: 2}
+	if err := service.Send(context.Background(), &models.Notification{Title: "T", Content: "C"}); err == nil {
+		t.Fatal("Expected error for 400 response")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
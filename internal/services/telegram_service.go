@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+	"unicode/utf8"
+)
+
+// telegramMaxMessageLength is the maximum text length accepted by sendMessage.
+const telegramMaxMessageLength = 4096
+
+// telegramMaxTitleLength leaves room for content under
+// telegramMaxMessageLength even once the title is escaped.
+const telegramMaxTitleLength = 1024
+
+const (
+	TelegramParseModeMarkdownV2 = "MarkdownV2"
+	TelegramParseModeHTML       = "HTML"
+)
+
+// telegramMarkdownEscaper escapes the characters MarkdownV2 reserves.
+var telegramMarkdownEscaper = strings.NewReplacer(
+	"_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+	"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-",
+	"=", "\\=", "|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+	"\\", "\\\\",
+)
+
+// TelegramNotificationService sends messages through a Telegram bot. Each
+// recipient is a chat ID. When BotToken is empty the notification is only
+// printed to stdout.
+type TelegramNotificationService struct {
+	APIURL   string
+	BotToken string
+	Client   *http.Client
+	Logger   logging.Logger
+}
+
+func NewTelegramNotificationService(cfg *config.Config) *TelegramNotificationService {
+	return &TelegramNotificationService{
+		APIURL:   cfg.TelegramAPIURL,
+		BotToken: cfg.TelegramBotToken,
+		Client:   &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type telegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+func (t *TelegramNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	parseMode := notification.Metadata["parse_mode"]
+	if parseMode == "" {
+		parseMode = TelegramParseModeMarkdownV2
+	}
+	if parseMode != TelegramParseModeMarkdownV2 && parseMode != TelegramParseModeHTML {
+		return fmt.Errorf("unsupported telegram parse_mode %q: must be %s or %s",
+			parseMode, TelegramParseModeMarkdownV2, TelegramParseModeHTML)
+	}
+
+	if t.BotToken == "" {
+		logDryRun(ctx, t.Logger, notification)
+		return nil
+	}
+
+	text := buildTelegramText(notification.Title, notification.Content, parseMode)
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(t.APIURL, "/"), t.BotToken)
+
+	for _, chatID := range notification.Recipients {
+		message := telegramMessage{ChatID: chatID, Text: text, ParseMode: parseMode}
+		err := postJSON(ctx, t.Client, "telegram", url, nil, message, nil)
+		notification.RecordRecipient(chatID, err)
+		if err != nil {
+			return fmt.Errorf("failed to send telegram message to chat %s: %w", chatID, err)
+		}
+	}
+	return nil
+}
+
+// buildTelegramText formats the title in bold above the content and shortens
+// the content until the escaped message fits Telegram's length limit. The
+// title is shortened too once the content is down to its ellipsis.
+func buildTelegramText(title, content, parseMode string) string {
+	text := formatTelegramText(title, content, parseMode)
+	for {
+		overflow := utf8.RuneCountInString(text) - telegramMaxMessageLength
+		if overflow <= 0 {
+			return text
+		}
+		field := &content
+		if utf8.RuneCountInString(content) <= 1 {
+			field = &title
+		}
+		limit := utf8.RuneCountInString(*field) - overflow
+		if limit < 1 {
+			limit = 1
+		}
+		*field, _ = truncateContent(*field, limit)
+		text = formatTelegramText(title, content, parseMode)
+	}
+}
+
+func formatTelegramText(title, content, parseMode string) string {
+	if parseMode == TelegramParseModeHTML {
+		return fmt.Sprintf("<b>%s</b>\n%s", html.EscapeString(title), html.EscapeString(content))
+	}
+	return fmt.Sprintf("*%s*\n%s", telegramMarkdownEscaper.Replace(title), telegramMarkdownEscaper.Replace(content))
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTelegramNotificationService(t *testing.T) {
+	var received telegramMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret/sendMessage" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	service := &TelegramNotificationService{APIURL: server.URL, BotToken: "secret", Client: server.Client()}
+	notification := &models.Notification{
+		Title:      "Build #42",
+		Content:    "All tests passed.",
+		Recipients: []string{"-1001"},
+	}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send Telegram message: %v", err)
+	}
+	if received.ChatID != "-1001" || received.ParseMode != TelegramParseModeMarkdownV2 {
+		t.Errorf("Unexpected message: %+v", received)
+	}
+	if expected := "*Build \\#42*\nAll tests passed\\."; received.Text != expected {
+		t.Errorf("Expected text %q, got %q", expected, received.Text)
+	}
+}
+
+func TestTelegramNotificationServiceHidesToken(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	service := &TelegramNotificationService{APIURL: server.URL, BotToken: "123456:secret-token", Client: &http.Client{}}
+	err := service.Send(context.Background(), &models.Notification{Title: "T", Content: "C", Recipients: []string{"-1001"}})
+	if err == nil {
+		t.Fatal("Expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("Expected the bot token left out of the error, got %q", err)
+	}
+	if !isTransientHTTPError(err) {
+		t.Errorf("Expected a transport failure to stay retryable, got %v", err)
+	}
+}
+
+func TestBuildTelegramText(t *testing.T) {
+	html := buildTelegramText("A & B", "<ok>", TelegramParseModeHTML)
+	if html != "<b>A &amp; B</b>\n&lt;ok&gt;" {
+		t.Errorf("Unexpected HTML text %q", html)
+	}
+
+	long := buildTelegramText("Title", strings.Repeat(".", 5000), TelegramParseModeMarkdownV2)
+	if utf8.RuneCountInString(long) > telegramMaxMessageLength {
+		t.Errorf("Expected text capped at %d characters, got %d", telegramMaxMessageLength, utf8.RuneCountInString(long))
+	}
+	if !strings.HasSuffix(long, "…") {
+		t.Error("Expected truncated text to end with an ellipsis")
+	}
+
+	// A title too long on its own is shortened once the content is used up
+	longTitle := buildTelegramText(strings.Repeat("#", 5000), "Content", TelegramParseModeMarkdownV2)
+	if utf8.RuneCountInString(longTitle) > telegramMaxMessageLength {
+		t.Errorf("Expected text capped at %d characters, got %d", telegramMaxMessageLength, utf8.RuneCountInString(longTitle))
+	}
+	if !strings.HasSuffix(longTitle, "…*\n…") {
+		t.Errorf("Expected the title and content to end with ellipses, got %q", longTitle[len(longTitle)-10:])
+	}
+}
+
+func TestTelegramNotificationServiceInvalidParseMode(t *testing.T) {
+	service := &TelegramNotificationService{}
+	notification := &models.Notification{Metadata: map[string]string{"parse_mode": "Markdown"}}
+
+	if err := service.Send(context.Background(), notification); err == nil {
+		t.Error("Expected error for unsupported parse mode, got nil")
+	}
+}
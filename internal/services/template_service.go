@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"notification-service/internal/i18n"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ErrInvalidTemplate is returned for templates that are missing a name or do
+// not parse.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// ErrTemplateExists is returned when creating a template whose name is taken.
+var ErrTemplateExists = errors.New("template already exists")
+
+// DefaultTemplateCacheTTL is how long templates are cached unless
+// WithCacheTTL sets otherwise.
+const DefaultTemplateCacheTTL = time.Minute
+
+// TemplateService validates, stores and renders notification templates.
+// Templates can translate messages from the catalog with {{t "key" args...}},
+// and a template named e.g. "welcome.es" is used instead of "welcome" for
+// Spanish notifications.
+type TemplateService struct {
+	repository repository.TemplateRepository
+	catalog    *i18n.Catalog
+
+	// cache holds templates looked up by name, and names that were not
+	// found, until they expire or the template is written.
+	cache    map[string]cachedTemplate
+	cacheTTL time.Duration
+	cacheMu  sync.RWMutex
+}
+
+// cachedTemplate is a repository lookup: a template, or the error it failed
+// with.
+type cachedTemplate struct {
+	template *models.NotificationTemplate
+	err      error
+	expires  time.Time
+}
+
+func NewTemplateService(repo repository.TemplateRepository) *TemplateService {
+	return &TemplateService{
+		repository: repo,
+		catalog:    i18n.NewCatalog(),
+		cache:      make(map[string]cachedTemplate),
+		cacheTTL:   DefaultTemplateCacheTTL,
+	}
+}
+
+// WithCacheTTL sets how long looked up templates are cached. Writes through
+// this service take effect at once; writes by other instances sharing the
+// repository take up to ttl. A non-positive ttl disables the cache.
+func (s *TemplateService) WithCacheTTL(ttl time.Duration) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.cacheTTL = ttl
+	s.cache = make(map[string]cachedTemplate)
+}
+
+// WithCatalog replaces the message catalog, which defaults to the embedded
+// English and Spanish messages.
+func (s *TemplateService) WithCatalog(catalog *i18n.Catalog) {
+	s.catalog = catalog
+}
+
+// Register parses tmpl before storing it so syntax errors are reported
+// immediately rather than at send time.
+func (s *TemplateService) Register(ctx context.Context, tmpl *models.NotificationTemplate) error {
+	if err := s.validate(tmpl); err != nil {
+		return err
+	}
+	defer s.invalidate(tmpl.Name)
+	return s.repository.SaveTemplate(ctx, tmpl)
+}
+
+// Create registers tmpl, or returns ErrTemplateExists if its name is taken.
+func (s *TemplateService) Create(ctx context.Context, tmpl *models.NotificationTemplate) error {
+	if err := s.validate(tmpl); err != nil {
+		return err
+	}
+	_, err := s.repository.GetTemplate(ctx, tmpl.Name)
+	if err == nil {
+		return ErrTemplateExists
+	}
+	if !errors.Is(err, repository.ErrTemplateNotFound) {
+		return err
+	}
+	return s.Register(ctx, tmpl)
+}
+
+// Update stores tmpl as the latest version of the template named tmpl.Name,
+// or returns repository.ErrTemplateNotFound if there is none. Earlier
+// versions are kept and can still be rendered with RenderVersion.
+func (s *TemplateService) Update(ctx context.Context, tmpl *models.NotificationTemplate) error {
+	if err := s.validate(tmpl); err != nil {
+		return err
+	}
+	if _, err := s.repository.GetTemplate(ctx, tmpl.Name); err != nil {
+		return err
+	}
+	return s.Register(ctx, tmpl)
+}
+
+// Delete removes the named template, or returns
+// repository.ErrTemplateNotFound if there is none.
+func (s *TemplateService) Delete(ctx context.Context, name string) error {
+	defer s.invalidate(name)
+	return s.repository.DeleteTemplate(ctx, name)
+}
+
+// validate parses tmpl so syntax errors are reported when it is stored
+// rather than at send time. Every error wraps ErrInvalidTemplate.
+func (s *TemplateService) validate(tmpl *models.NotificationTemplate) error {
+	if tmpl.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	}
+	funcs := s.funcs("")
+	if _, err := parseTemplate(tmpl.Name+".title", tmpl.TitleTemplate, funcs); err != nil {
+		return err
+	}
+	if _, err := parseTemplate(tmpl.Name+".content", tmpl.ContentTemplate, funcs); err != nil {
+		return err
+	}
+
+	// Email bodies are executed against an empty notification so references
+	// to unknown fields fail here rather than at send time.
+	htmlBody, textBody, err := parseEmailTemplates(tmpl, funcs)
+	if err != nil {
+		return err
+	}
+	sample := &models.Notification{Metadata: map[string]string{}}
+	if htmlBody != nil {
+		if err := htmlBody.Execute(io.Discard, sample); err != nil {
+			return fmt.Errorf("%w %s.html: %w", ErrInvalidTemplate, tmpl.Name, err)
+		}
+	}
+	if textBody != nil {
+		if err := textBody.Execute(io.Discard, sample); err != nil {
+			return fmt.Errorf("%w %s.text: %w", ErrInvalidTemplate, tmpl.Name, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the named template, from the cache if it was looked up within
+// the cache TTL.
+func (s *TemplateService) Get(ctx context.Context, name string) (*models.NotificationTemplate, error) {
+	now := time.Now()
+	s.cacheMu.RLock()
+	cached, ok := s.cache[name]
+	s.cacheMu.RUnlock()
+	if ok && now.Before(cached.expires) {
+		return copyTemplate(cached.template), cached.err
+	}
+
+	tmpl, err := s.repository.GetTemplate(ctx, name)
+	// Only lookups with a definite answer are cached
+	if err == nil || errors.Is(err, repository.ErrTemplateNotFound) {
+		s.cacheMu.Lock()
+		if s.cacheTTL > 0 {
+			s.cache[name] = cachedTemplate{template: tmpl, err: err, expires: now.Add(s.cacheTTL)}
+		}
+		s.cacheMu.Unlock()
+	}
+	return copyTemplate(tmpl), err
+}
+
+func (s *TemplateService) List(ctx context.Context) ([]*models.NotificationTemplate, error) {
+	return s.repository.ListTemplates(ctx)
+}
+
+// Versions returns every version of the named template, oldest first.
+func (s *TemplateService) Versions(ctx context.Context, name string) ([]*models.NotificationTemplate, error) {
+	return s.repository.ListTemplateVersions(ctx, name)
+}
+
+// GetVersion returns the given version of the named template. Versions never
+// change once stored, so they are read straight from the repository.
+func (s *TemplateService) GetVersion(ctx context.Context, name string, version int) (*models.NotificationTemplate, error) {
+	return s.repository.GetTemplateVersion(ctx, name, version)
+}
+
+// invalidate drops the named template from the cache.
+func (s *TemplateService) invalidate(name string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	delete(s.cache, name)
+}
+
+// copyTemplate copies tmpl so callers cannot change the cached template.
+func copyTemplate(tmpl *models.NotificationTemplate) *models.NotificationTemplate {
+	if tmpl == nil {
+		return nil
+	}
+	copied := *tmpl
+	return &copied
+}
+
+// Render executes the named template's title and content with data. Missing
+// keys are an error so half-filled notifications are never sent.
+func (s *TemplateService) Render(ctx context.Context, name string, data map[string]interface{}) (title, content string, err error) {
+	return s.RenderLocalized(ctx, name, "", data)
+}
+
+// RenderLocalized renders the variant of the named template for locale,
+// falling back as described by i18n.Fallbacks and then to the template
+// itself. Catalog messages are translated into locale.
+func (s *TemplateService) RenderLocalized(ctx context.Context, name, locale string, data map[string]interface{}) (title, content string, err error) {
+	tmpl, err := s.localized(ctx, name, locale)
+	if err != nil {
+		return "", "", err
+	}
+	return s.render(tmpl, locale, data)
+}
+
+// RenderVersion renders the given version of the named template, translating
+// catalog messages into locale. A pinned version is of the named template
+// itself, so its locale variants are not used.
+func (s *TemplateService) RenderVersion(ctx context.Context, name string, version int, locale string, data map[string]interface{}) (title, content string, err error) {
+	tmpl, err := s.GetVersion(ctx, name, version)
+	if err != nil {
+		return "", "", err
+	}
+	return s.render(tmpl, locale, data)
+}
+
+// render executes the title and content of tmpl with data.
+func (s *TemplateService) render(tmpl *models.NotificationTemplate, locale string, data map[string]interface{}) (title, content string, err error) {
+	funcs := s.funcs(locale)
+	title, err = executeTemplate(tmpl.Name+".title", tmpl.TitleTemplate, data, funcs)
+	if err != nil {
+		return "", "", err
+	}
+	content, err = executeTemplate(tmpl.Name+".content", tmpl.ContentTemplate, data, funcs)
+	if err != nil {
+		return "", "", err
+	}
+	return title, content, nil
+}
+
+// RenderEmail renders the named template's HTML body for notification in its
+// locale. The plain-text body comes from PlainTextContent, or from the HTML
+// with its tags stripped when no plain-text template is set.
+func (s *TemplateService) RenderEmail(ctx context.Context, name string, notification *models.Notification) (htmlBody, textBody string, err error) {
+	tmpl, err := s.localized(ctx, name, notification.Locale)
+	if err != nil {
+		return "", "", err
+	}
+	htmlTemplate, textTemplate, err := parseEmailTemplates(tmpl, s.funcs(notification.Locale))
+	if err != nil {
+		return "", "", err
+	}
+	if htmlTemplate == nil {
+		return "", "", fmt.Errorf("template %s has no HTML content", name)
+	}
+
+	var out strings.Builder
+	if err := htmlTemplate.Execute(&out, notification); err != nil {
+		return "", "", fmt.Errorf("failed to render template %s.html: %w", name, err)
+	}
+	htmlBody = out.String()
+	if textTemplate == nil {
+		return htmlBody, htmlToText(htmlBody), nil
+	}
+
+	out.Reset()
+	if err := textTemplate.Execute(&out, notification); err != nil {
+		return "", "", fmt.Errorf("failed to render template %s.text: %w", name, err)
+	}
+	return htmlBody, out.String(), nil
+}
+
+// localized returns the most specific variant of the named template for
+// locale, e.g. "welcome.es-mx", then "welcome.es", then "welcome".
+func (s *TemplateService) localized(ctx context.Context, name, locale string) (*models.NotificationTemplate, error) {
+	if locale != "" {
+		for _, candidate := range i18n.Fallbacks(locale) {
+			tmpl, err := s.Get(ctx, name+"."+candidate)
+			if err == nil || !errors.Is(err, repository.ErrTemplateNotFound) {
+				return tmpl, err
+			}
+		}
+	}
+	return s.Get(ctx, name)
+}
+
+// funcs returns the template functions for rendering in locale.
+func (s *TemplateService) funcs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...interface{}) (string, error) {
+			if s.catalog == nil {
+				return "", fmt.Errorf("no message catalog is configured")
+			}
+			return s.catalog.Translate(locale, key, args...)
+		},
+	}
+}
+
+// parseEmailTemplates parses the HTML and plain-text bodies of tmpl. Either
+// result is nil when the template does not define that body.
+func parseEmailTemplates(tmpl *models.NotificationTemplate, funcs template.FuncMap) (*htmltemplate.Template, *template.Template, error) {
+	var htmlBody *htmltemplate.Template
+	var textBody *template.Template
+	var err error
+
+	if tmpl.HTMLContent != "" {
+		htmlBody, err = htmltemplate.New(tmpl.Name + ".html").Funcs(htmltemplate.FuncMap(funcs)).Parse(tmpl.HTMLContent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w %s.html: %w", ErrInvalidTemplate, tmpl.Name, err)
+		}
+	}
+	if tmpl.PlainTextContent != "" {
+		textBody, err = template.New(tmpl.Name + ".text").Funcs(funcs).Parse(tmpl.PlainTextContent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w %s.text: %w", ErrInvalidTemplate, tmpl.Name, err)
+		}
+	}
+	return htmlBody, textBody, nil
+}
+
+func parseTemplate(name, text string, funcs template.FuncMap) (*template.Template, error) {
+	parsed, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrInvalidTemplate, name, err)
+	}
+	return parsed, nil
+}
+
+func executeTemplate(name, text string, data map[string]interface{}, funcs template.FuncMap) (string, error) {
+	parsed, err := parseTemplate(name, text, funcs)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := parsed.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"testing"
+)
+
+func TestTemplateService(t *testing.T) {
+	ctx := context.Background()
+	service := NewTemplateService(repository.NewMemoryTemplateRepository())
+
+	err := service.Register(ctx, &models.NotificationTemplate{
+		Name:            "order_shipped",
+		TitleTemplate:   "Order {{.OrderID}} shipped",
+		ContentTemplate: "Hi {{.Name}}, your order ships via {{.Carrier}}.",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register template: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		template        string
+		data            map[string]interface{}
+		expectedTitle   string
+		expectedContent string
+		expectError     bool
+	}{
+		{
+			name:            "All variables provided",
+			template:        "order_shipped",
+			data:            map[string]interface{}{"OrderID": 42, "Name": "Ana", "Carrier": "DHL"},
+			expectedTitle:   "Order 42 shipped",
+			expectedContent: "Hi Ana, your order ships via DHL.",
+		},
+		{
+			name:        "Missing variable",
+			template:    "order_shipped",
+			data:        map[string]interface{}{"OrderID": 42},
+			expectError: true,
+		},
+		{
+			name:        "Unknown template",
+			template:    "missing",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, content, err := service.Render(ctx, tt.template, tt.data)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if title != tt.expectedTitle || content != tt.expectedContent {
+				t.Errorf("Expected %q / %q, got %q / %q", tt.expectedTitle, tt.expectedContent, title, content)
+			}
+		})
+	}
+
+	if _, _, err := service.Render(ctx, "missing", nil); !errors.Is(err, repository.ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound, got %v", err)
+	}
+}
+
+func TestTemplateServiceCache(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTemplateRepository()
+	service := NewTemplateService(repo)
+
+	if _, err := service.Get(ctx, "welcome"); !errors.Is(err, repository.ErrTemplateNotFound) {
+		t.Fatalf("Expected ErrTemplateNotFound, got %v", err)
+	}
+	if err := service.Create(ctx, &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Welcome", ContentTemplate: "Hi"}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	if tmpl, err := service.Get(ctx, "welcome"); err != nil || tmpl.TitleTemplate != "Welcome" {
+		t.Fatalf("Expected the created template, got %+v (%v)", tmpl, err)
+	}
+	if err := service.Create(ctx, &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Hello", ContentTemplate: "Hi"}); !errors.Is(err, ErrTemplateExists) {
+		t.Errorf("Expected ErrTemplateExists, got %v", err)
+	}
+
+	// Writes behind the service's back are not seen until the entry expires
+	repo.SaveTemplate(ctx, &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Hello", ContentTemplate: "Hi"})
+	if tmpl, _ := service.Get(ctx, "welcome"); tmpl.TitleTemplate != "Welcome" {
+		t.Errorf("Expected the cached template, got %+v", tmpl)
+	}
+
+	if err := service.Update(ctx, &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Hey", ContentTemplate: "Hi"}); err != nil {
+		t.Fatalf("Failed to update template: %v", err)
+	}
+	if tmpl, _ := service.Get(ctx, "welcome"); tmpl.TitleTemplate != "Hey" {
+		t.Errorf("Expected the updated template, got %+v", tmpl)
+	}
+	if err := service.Delete(ctx, "welcome"); err != nil {
+		t.Fatalf("Failed to delete template: %v", err)
+	}
+	if _, err := service.Get(ctx, "welcome"); !errors.Is(err, repository.ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound after delete, got %v", err)
+	}
+	if err := service.Update(ctx, &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Hey", ContentTemplate: "Hi"}); !errors.Is(err, repository.ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound updating a deleted template, got %v", err)
+	}
+
+	service.WithCacheTTL(0)
+	repo.SaveTemplate(ctx, &models.NotificationTemplate{Name: "welcome", TitleTemplate: "Hello", ContentTemplate: "Hi"})
+	if tmpl, err := service.Get(ctx, "welcome"); err != nil || tmpl.TitleTemplate != "Hello" {
+		t.Errorf("Expected the uncached template, got %+v (%v)", tmpl, err)
+	}
+}
+
+func TestTemplateServiceRejectsInvalidTemplates(t *testing.T) {
+	service := NewTemplateService(repository.NewMemoryTemplateRepository())
+
+	invalid := []*models.NotificationTemplate{
+		{TitleTemplate: "No name", ContentTemplate: "body"},
+		{Name: "bad_title", TitleTemplate: "{{.Name", ContentTemplate: "body"},
+		{Name: "bad_content", TitleTemplate: "title", ContentTemplate: "{{if .X}}unterminated"},
+		{Name: "bad_html", HTMLContent: "<p>{{.Title</p>"},
+		{Name: "unknown_field", HTMLContent: "<p>{{.Subject}}</p>"},
+		{Name: "bad_plain_text", HTMLContent: "<p>ok</p>", PlainTextContent: "{{.Body}}"},
+	}
+	for _, tmpl := range invalid {
+		if err := service.Register(context.Background(), tmpl); err == nil {
+			t.Errorf("Expected error registering %+v", tmpl)
+		}
+	}
+
+	templates, _ := service.List(context.Background())
+	if len(templates) != 0 {
+		t.Errorf("Expected no templates to be stored, got %d", len(templates))
+	}
+}
+
+func TestTemplateServiceRenderEmail(t *testing.T) {
+	ctx := context.Background()
+	service := NewTemplateService(repository.NewMemoryTemplateRepository())
+	service.Register(ctx, &models.NotificationTemplate{
+		Name:             "digest",
+		HTMLContent:      "<h1>{{.Title}}</h1>",
+		PlainTextContent: "== {{.Title}} ==",
+	})
+	service.Register(ctx, &models.NotificationTemplate{Name: "text_only", TitleTemplate: "Hi"})
+
+	notification := &models.Notification{Title: "Daily digest"}
+	htmlBody, textBody, err := service.RenderEmail(ctx, "digest", notification)
+	if err != nil {
+		t.Fatalf("Failed to render email: %v", err)
+	}
+	if htmlBody != "<h1>Daily digest</h1>" || textBody != "== Daily digest ==" {
+		t.Errorf("Unexpected bodies %q / %q", htmlBody, textBody)
+	}
+
+	if _, _, err := service.RenderEmail(ctx, "text_only", notification); err == nil {
+		t.Error("Expected error for template without HTML content, got nil")
+	}
+}
+
+func TestTemplateServiceRenderLocalized(t *testing.T) {
+	ctx := context.Background()
+	service := NewTemplateService(repository.NewMemoryTemplateRepository())
+	for _, tmpl := range []*models.NotificationTemplate{
+		{Name: "shipped", TitleTemplate: `{{t "order_shipped" .OrderID}}`, ContentTemplate: "Tracking: {{.Tracking}}"},
+		{Name: "shipped.pt-br", TitleTemplate: "Pedido {{.OrderID}} enviado", ContentTemplate: "Rastreamento: {{.Tracking}}"},
+	} {
+		if err := service.Register(ctx, tmpl); err != nil {
+			t.Fatalf("Failed to register template %s: %v", tmpl.Name, err)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		locale          string
+		expectedTitle   string
+		expectedContent string
+	}{
+		{"Default locale", "", "Your order 42 has shipped", "Tracking: 1Z999"},
+		{"Catalog translation", "es", "Tu pedido 42 ha sido enviado", "Tracking: 1Z999"},
+		{"Regional catalog fallback", "es-MX", "Tu pedido 42 ha sido enviado", "Tracking: 1Z999"},
+		{"Template variant", "pt_BR", "Pedido 42 enviado", "Rastreamento: 1Z999"},
+		{"Unknown locale", "de", "Your order 42 has shipped", "Tracking: 1Z999"},
+	}
+
+	data := map[string]interface{}{"OrderID": "42", "Tracking": "1Z999"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, content, err := service.RenderLocalized(ctx, "shipped", tt.locale, data)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if title != tt.expectedTitle || content != tt.expectedContent {
+				t.Errorf("Expected %q / %q, got %q / %q", tt.expectedTitle, tt.expectedContent, title, content)
+			}
+		})
+	}
+
+	err := service.Register(ctx, &models.NotificationTemplate{Name: "missing_key", TitleTemplate: `{{t "no_such_key"}}`, ContentTemplate: "body"})
+	if err != nil {
+		t.Fatalf("Failed to register template: %v", err)
+	}
+	if _, _, err := service.RenderLocalized(ctx, "missing_key", "en", nil); err == nil {
+		t.Error("Expected error for unknown message key, got nil")
+	}
+}
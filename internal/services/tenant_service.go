@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"regexp"
+	"time"
+)
+
+// ErrInvalidTenantID is returned for tenant IDs that cannot be used as a
+// subdomain.
+var ErrInvalidTenantID = errors.New("invalid tenant ID")
+
+// tenantIDPattern matches a DNS label, so every tenant can be addressed by
+// subdomain.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// TenantService validates the tenant of incoming requests and keeps each
+// tenant's channel services in the factory up to date.
+type TenantService struct {
+	repository repository.TenantRepository
+	factory    *NotificationServiceFactory
+}
+
+func NewTenantService(repo repository.TenantRepository, factory *NotificationServiceFactory) *TenantService {
+	return &TenantService{repository: repo, factory: factory}
+}
+
+// Load registers the services of every stored tenant with the factory.
+func (s *TenantService) Load(ctx context.Context) error {
+	tenants, err := s.repository.ListTenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		s.factory.WithTenant(tenant)
+	}
+	return nil
+}
+
+// Get returns repository.ErrTenantNotFound for unknown tenants.
+func (s *TenantService) Get(ctx context.Context, id string) (*models.Tenant, error) {
+	if id == "" {
+		return nil, repository.ErrTenantNotFound
+	}
+	return s.repository.GetTenant(ctx, id)
+}
+
+// Save creates or updates tenant and rebuilds its services.
+func (s *TenantService) Save(ctx context.Context, tenant *models.Tenant) error {
+	if !tenantIDPattern.MatchString(tenant.ID) {
+		return fmt.Errorf("%w: %q must be a lowercase DNS label", ErrInvalidTenantID, tenant.ID)
+	}
+	if tenant.CreatedAt.IsZero() {
+		tenant.CreatedAt = time.Now().UTC()
+	}
+	if err := s.repository.SaveTenant(ctx, tenant); err != nil {
+		return err
+	}
+	s.factory.WithTenant(tenant)
+	return nil
+}
+
+// List returns every tenant sorted by ID.
+func (s *TenantService) List(ctx context.Context) ([]*models.Tenant, error) {
+	return s.repository.ListTenants(ctx)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTenantServiceRoutesToTenantWebhook(t *testing.T) {
+	var defaultCalls, acmeCalls atomic.Int32
+	defaultServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defaultCalls.Add(1)
+	}))
+	defer defaultServer.Close()
+	acmeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acmeCalls.Add(1)
+	}))
+	defer acmeServer.Close()
+
+	factory := NewNotificationServiceFactory(&config.Config{SlackWebhookURL: defaultServer.URL, HTTPTimeout: time.Second})
+	tenants := NewTenantService(repository.NewMemoryRepository(), factory)
+	ctx := context.Background()
+	if err := tenants.Save(ctx, &models.Tenant{ID: "acme", Config: models.TenantConfig{SlackWebhookURL: acmeServer.URL}}); err != nil {
+		t.Fatalf("Failed to save tenant: %v", err)
+	}
+	if err := tenants.Save(ctx, &models.Tenant{ID: "globex"}); err != nil {
+		t.Fatalf("Failed to save tenant: %v", err)
+	}
+
+	fanOut := NewFanOutNotificationService(factory)
+	for _, tenantID := range []string{"acme", "globex", ""} {
+		notification := &models.Notification{ID: "n-" + tenantID, TenantID: tenantID, Title: "Hello", Content: "World", Channel: models.ChannelSlack, Recipients: []string{"U1"}}
+		if err := fanOut.Send(ctx, notification); err != nil {
+			t.Fatalf("Failed to send for tenant %q: %v", tenantID, err)
+		}
+	}
+	// Tenants without an override share the default webhook
+	if acmeCalls.Load() != 1 || defaultCalls.Load() != 2 {
+		t.Errorf("Expected 1 call to the tenant webhook and 2 to the default, got %d and %d", acmeCalls.Load(), defaultCalls.Load())
+	}
+}
+
+func TestTenantService(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	factory := NewNotificationServiceFactory(&config.Config{})
+	tenants := NewTenantService(repo, factory)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		id       string
+		expected error
+	}{
+		{"Valid ID", "acme-eu", nil},
+		{"Empty ID", "", ErrInvalidTenantID},
+		{"Uppercase ID", "Acme", ErrInvalidTenantID},
+		{"Dotted ID", "acme.eu", ErrInvalidTenantID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tenants.Save(ctx, &models.Tenant{ID: tt.id}); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	if _, err := tenants.Get(ctx, "unknown"); !errors.Is(err, repository.ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound, got %v", err)
+	}
+	if factory.ForTenant("acme-eu") == factory || factory.ForTenant("unknown") != factory {
+		t.Error("Expected only saved tenants to get their own services")
+	}
+
+	// A restarted service picks up stored tenants
+	restarted := NewNotificationServiceFactory(&config.Config{})
+	if err := NewTenantService(repo, restarted).Load(ctx); err != nil {
+		t.Fatalf("Failed to load tenants: %v", err)
+	}
+	if restarted.ForTenant("acme-eu") == restarted {
+		t.Error("Expected loaded tenants to get their own services")
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials is returned for unknown users and wrong passwords
+	// alike, so callers cannot probe for user IDs.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidToken is returned for tokens that are malformed, expired or
+	// not signed by this service.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// DefaultTokenTTL is used when no token lifetime is configured.
+const DefaultTokenTTL = 15 * time.Minute
+
+// Claims are the JWT claims issued by TokenService. Roles holds
+// models.RoleSender and models.RoleAdmin, and TenantID the tenant the user is
+// bound to, if any.
+type Claims struct {
+	Roles    []string `json:"roles"`
+	TenantID string   `json:"tenant_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasRole reports whether the claims grant role. Admins hold every role.
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, models.RoleAdmin) || slices.Contains(c.Roles, role)
+}
+
+// TokenService issues short-lived JWTs to users with credentials and
+// verifies the tokens it issued.
+type TokenService struct {
+	users      repository.UserRepository
+	method     jwt.SigningMethod
+	signingKey interface{}
+	verifyKey  interface{}
+	ttl        time.Duration
+}
+
+// NewTokenService reads the signing key described by cfg.
+func NewTokenService(users repository.UserRepository, cfg *config.Config) (*TokenService, error) {
+	s := &TokenService{users: users, ttl: cfg.JWTTTL}
+	if s.ttl <= 0 {
+		s.ttl = DefaultTokenTTL
+	}
+
+	switch cfg.JWTAlgorithm {
+	case "", "HS256":
+		if cfg.JWTSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is required for HS256")
+		}
+		s.method = jwt.SigningMethodHS256
+		s.signingKey = []byte(cfg.JWTSecret)
+		s.verifyKey = s.signingKey
+	case "RS256":
+		data, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT private key: %w", err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT private key: %w", err)
+		}
+		s.method = jwt.SigningMethodRS256
+		s.signingKey = key
+		s.verifyKey = &key.PublicKey
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.JWTAlgorithm)
+	}
+	return s, nil
+}
+
+// SetCredentials stores a bcrypt hash of password and roles for userID, whose
+// tokens are bound to tenantID unless it is empty.
+func (s *TokenService) SetCredentials(ctx context.Context, userID, password string, roles []string, tenantID string) error {
+	if password == "" {
+		return fmt.Errorf("password is required")
+	}
+	for _, role := range roles {
+		if role != models.RoleSender && role != models.RoleAdmin {
+			return fmt.Errorf("unknown role %q", role)
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	return s.users.SaveCredentials(ctx, userID, models.Credentials{PasswordHash: string(hash), Roles: roles, TenantID: tenantID})
+}
+
+// Issue returns a signed token for userID carrying their roles, and when it
+// expires.
+func (s *TokenService) Issue(ctx context.Context, userID, password string) (string, time.Time, error) {
+	credentials, err := s.users.GetCredentials(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(credentials.PasswordHash), []byte(password)) != nil {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	claims := Claims{
+		Roles:    credentials.Roles,
+		TenantID: credentials.TenantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(s.method, claims).SignedString(s.signingKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// Verify returns the claims of token, or ErrInvalidToken.
+func (s *TokenService) Verify(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.verifyKey, nil
+	}, jwt.WithValidMethods([]string{s.method.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
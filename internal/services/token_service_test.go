@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokenService(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "jwt.pem")
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("Failed to write RSA key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  *config.Config
+	}{
+		{"HS256", &config.Config{JWTAlgorithm: "HS256", JWTSecret: "secret"}},
+		{"RS256", &config.Config{JWTAlgorithm: "RS256", JWTPrivateKeyFile: keyFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, err := NewTokenService(repository.NewMemoryRepository(), tt.cfg)
+			if err != nil {
+				t.Fatalf("Failed to create token service: %v", err)
+			}
+			ctx := context.Background()
+			if err := service.SetCredentials(ctx, "user-1", "hunter2", []string{models.RoleSender}, "acme"); err != nil {
+				t.Fatalf("Failed to set credentials: %v", err)
+			}
+
+			if _, _, err := service.Issue(ctx, "user-1", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Expected ErrInvalidCredentials for a wrong password, got %v", err)
+			}
+			if _, _, err := service.Issue(ctx, "user-2", "hunter2"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Expected ErrInvalidCredentials for an unknown user, got %v", err)
+			}
+
+			token, expiresAt, err := service.Issue(ctx, "user-1", "hunter2")
+			if err != nil {
+				t.Fatalf("Failed to issue token: %v", err)
+			}
+			if time.Until(expiresAt) > DefaultTokenTTL || time.Until(expiresAt) <= 0 {
+				t.Errorf("Expected the token to expire within %v, got %v", DefaultTokenTTL, expiresAt)
+			}
+			claims, err := service.Verify(token)
+			if err != nil {
+				t.Fatalf("Failed to verify token: %v", err)
+			}
+			if claims.Subject != "user-1" || claims.TenantID != "acme" || !claims.HasRole(models.RoleSender) || claims.HasRole(models.RoleAdmin) {
+				t.Errorf("Unexpected claims: %+v", claims)
+			}
+			if _, err := service.Verify(token + "x"); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Expected ErrInvalidToken for a tampered token, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTokenServiceRejectsForeignTokens(t *testing.T) {
+	service, err := NewTokenService(repository.NewMemoryRepository(), &config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, key interface{}, expiresAt time.Time) string {
+		claims := Claims{
+			Roles:            []string{models.RoleAdmin},
+			RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(expiresAt)},
+		}
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"Expired", sign(jwt.SigningMethodHS256, []byte("secret"), time.Now().Add(-time.Minute))},
+		{"Wrong secret", sign(jwt.SigningMethodHS256, []byte("guess"), time.Now().Add(time.Minute))},
+		{"Wrong algorithm", sign(jwt.SigningMethodHS512, []byte("secret"), time.Now().Add(time.Minute))},
+		{"Unsigned", sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, time.Now().Add(time.Minute))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewTokenServiceConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Config
+	}{
+		{"Missing secret", &config.Config{JWTAlgorithm: "HS256"}},
+		{"Missing key file", &config.Config{JWTAlgorithm: "RS256", JWTPrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"Unsupported algorithm", &config.Config{JWTAlgorithm: "ES256", JWTSecret: "secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTokenService(repository.NewMemoryRepository(), tt.cfg); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
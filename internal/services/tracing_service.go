@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"notification-service/internal/models"
+	"notification-service/internal/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingNotificationService records every send made through the wrapped
+// service as a span.
+type TracingNotificationService struct {
+	service NotificationService
+	channel models.NotificationChannel
+	tracer  trace.Tracer
+}
+
+func NewTracingNotificationService(service NotificationService, channel models.NotificationChannel, tracer trace.Tracer) *TracingNotificationService {
+	return &TracingNotificationService{service: service, channel: channel, tracer: tracer}
+}
+
+// Send records the channel the span covers rather than the notification's,
+// since a fan-out sends on each of its channels in a span of its own.
+func (t *TracingNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	ctx, span := tracing.Start(ctx, t.tracer, "NotificationService.Send", tracing.NotificationAttributes(notification)...)
+	span.SetAttributes(tracing.NotificationChannelKey.String(string(t.channel)))
+	err := t.service.Send(ctx, notification)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"notification-service/internal/services/mock"
+	"notification-service/internal/tracing"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecordingTracer() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	return recorder, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestTracingNotificationService(t *testing.T) {
+	recorder, provider := newRecordingTracer()
+	flaky := &mock.MockNotificationService{}
+	flaky.FailNext(errors.New("bad request"))
+	service := NewTracingNotificationService(flaky, models.ChannelSlack, provider.Tracer("test"))
+
+	// A fan-out's span names the channel being sent on
+	notification := &models.Notification{
+		ID:         "n1",
+		Title:      "Deploy",
+		Content:    "Done",
+		Channels:   []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail},
+		Recipients: []string{"U1", "U2"},
+	}
+	if err := service.Send(context.Background(), notification); err == nil {
+		t.Fatal("Expected the error to be returned")
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected a span per send, got %d", len(spans))
+	}
+	attributes := spanAttributes(spans[0])
+	if spans[0].Name() != "NotificationService.Send" || attributes[tracing.NotificationIDKey].AsString() != "n1" ||
+		attributes[tracing.NotificationChannelKey].AsString() != "slack" || attributes[tracing.RecipientCountKey].AsInt64() != 2 ||
+		attributes[tracing.ScheduledKey].AsBool() {
+		t.Errorf("Unexpected span %s %v", spans[0].Name(), attributes)
+	}
+	if spans[0].Status().Code != codes.Error || spans[1].Status().Code == codes.Error {
+		t.Errorf("Expected only the failed send's span to be an error, got %v and %v", spans[0].Status(), spans[1].Status())
+	}
+}
+
+func TestSchedulerTracing(t *testing.T) {
+	recorder, provider := newRecordingTracer()
+	scheduler := NewSchedulerService(&mock.MockNotificationService{}, repository.NewMemoryRepository())
+	scheduler.WithTracer(provider.Tracer("test"))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	scheduledAt := time.Now().Add(time.Hour)
+	notification := &models.Notification{ID: "n1", Title: "Deploy", Content: "Done", Channel: models.ChannelSlack, Recipients: []string{"U1"}, ScheduledAt: &scheduledAt}
+	if err := scheduler.ScheduleNotification(ctx, notification); err != nil {
+		t.Fatalf("Failed to schedule notification: %v", err)
+	}
+	if err := scheduler.ScheduleNotification(ctx, notification); !errors.Is(err, ErrAlreadyScheduled) {
+		t.Fatalf("Expected ErrAlreadyScheduled, got %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected a span per call and the parent, got %d", len(spans))
+	}
+	span := spans[0]
+	attributes := spanAttributes(span)
+	if span.Name() != "SchedulerService.ScheduleNotification" || span.Parent().SpanID() != parent.SpanContext().SpanID() ||
+		attributes[tracing.NotificationChannelKey].AsString() != "slack" || !attributes[tracing.ScheduledKey].AsBool() {
+		t.Errorf("Unexpected span %s %v", span.Name(), attributes)
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("Expected the rejected call's span to be an error, got %v", spans[1].Status())
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"notification-service/internal/repository"
+	"strings"
+)
+
+// ErrInvalidUnsubscribeToken is returned for unsubscribe tokens that are
+// malformed or were not signed with the service's secret.
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// ErrRecipientsSuppressed is returned by the email service when every
+// recipient has unsubscribed from the notification's category, so nothing
+// was sent. It does not count as a delivery failure.
+var ErrRecipientsSuppressed = errors.New("every recipient has unsubscribed")
+
+// UnsubscribeService signs the unsubscribe links added to emails and keeps
+// the suppression list they add addresses to. A token is the address and
+// category followed by their HMAC-SHA256, so it needs no storage until it
+// is used. Addresses are compared ignoring case.
+type UnsubscribeService struct {
+	secret     []byte
+	baseURL    string
+	repository repository.SuppressionRepository
+}
+
+func NewUnsubscribeService(secret, baseURL string, repo repository.SuppressionRepository) *UnsubscribeService {
+	return &UnsubscribeService{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/"), repository: repo}
+}
+
+// Token returns the token unsubscribing email from category.
+func (s *UnsubscribeService) Token(email, category string) string {
+	payload := strings.ToLower(email) + "\n" + category
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// URL returns the link unsubscribing email from category,
+// {baseURL}/unsubscribe/{token}.
+func (s *UnsubscribeService) URL(email, category string) string {
+	return s.baseURL + "/unsubscribe/" + url.PathEscape(s.Token(email, category))
+}
+
+// Verify returns the address and category token unsubscribes.
+func (s *UnsubscribeService) Verify(token string) (string, string, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.sign(string(payload))) {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	email, category, ok := strings.Cut(string(payload), "\n")
+	if !ok || email == "" {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	return email, category, nil
+}
+
+// Unsubscribe adds the address token was issued for to the suppression list
+// of its category and returns them.
+func (s *UnsubscribeService) Unsubscribe(ctx context.Context, token string) (string, string, error) {
+	email, category, err := s.Verify(token)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.repository.AddSuppression(ctx, email, category); err != nil {
+		return "", "", err
+	}
+	return email, category, nil
+}
+
+// Suppressed reports whether email has unsubscribed from category.
+func (s *UnsubscribeService) Suppressed(ctx context.Context, email, category string) (bool, error) {
+	suppressed, err := s.repository.IsSuppressed(ctx, strings.ToLower(email), category)
+	if err != nil {
+		return false, fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	return suppressed, nil
+}
+
+func (s *UnsubscribeService) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
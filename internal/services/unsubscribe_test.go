@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribeToken(t *testing.T) {
+	service := NewUnsubscribeService("s3cret", "https://notify.example.com/", repository.NewMemoryRepository())
+
+	token := service.Token("Ana@Example.com", "billing")
+	email, category, err := service.Verify(token)
+	if err != nil || email != "ana@example.com" || category != "billing" {
+		t.Errorf("Expected ana@example.com and billing, got %q, %q (%v)", email, category, err)
+	}
+	if url := service.URL("ana@example.com", "billing"); url != "https://notify.example.com/unsubscribe/"+token {
+		t.Errorf("Unexpected unsubscribe URL %s", url)
+	}
+
+	payload, mac, _ := strings.Cut(token, ".")
+	other := NewUnsubscribeService("other", "https://notify.example.com", repository.NewMemoryRepository())
+	forged, _, _ := strings.Cut(service.Token("bob@example.com", "billing"), ".")
+	for name, invalid := range map[string]string{
+		"Empty":            "",
+		"No signature":     payload,
+		"Not base64":       "!!!." + mac,
+		"Other secret":     other.Token("ana@example.com", "billing"),
+		"Swapped payload":  forged + "." + mac,
+		"Truncated MAC":    payload + "." + mac[:10],
+		"Signature only":   "." + mac,
+		"Extra separators": token + ".x",
+	} {
+		if _, _, err := service.Verify(invalid); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+			t.Errorf("%s: expected ErrInvalidUnsubscribeToken, got %v", name, err)
+		}
+	}
+}
+
+func TestUnsubscribeServiceSuppression(t *testing.T) {
+	ctx := context.Background()
+	service := NewUnsubscribeService("s3cret", "https://notify.example.com", repository.NewMemoryRepository())
+
+	if _, _, err := service.Unsubscribe(ctx, service.Token("ana@example.com", "billing")); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	tests := []struct {
+		email    string
+		category string
+		expected bool
+	}{
+		{"ana@example.com", "billing", true},
+		{"ANA@example.com", "billing", true},
+		{"ana@example.com", "marketing", false},
+		{"bob@example.com", "billing", false},
+	}
+	for _, tt := range tests {
+		if suppressed, err := service.Suppressed(ctx, tt.email, tt.category); err != nil || suppressed != tt.expected {
+			t.Errorf("Expected %s suppressed from %s to be %v, got %v (%v)", tt.email, tt.category, tt.expected, suppressed, err)
+		}
+	}
+}
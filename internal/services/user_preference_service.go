@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+)
+
+// UserPreferenceService records which channel each user wants to be notified
+// on and resolves user IDs to channel addresses.
+type UserPreferenceService struct {
+	repository repository.UserRepository
+}
+
+func NewUserPreferenceService(repo repository.UserRepository) *UserPreferenceService {
+	return &UserPreferenceService{repository: repo}
+}
+
+// SetPreference stores user after checking it has an address on its
+// preferred channel.
+func (s *UserPreferenceService) SetPreference(ctx context.Context, user *models.User) error {
+	if user.ID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+	if user.PreferredChannel == "" {
+		return fmt.Errorf("preferred channel is required for user %s", user.ID)
+	}
+	if _, ok := user.Address(user.PreferredChannel); !ok {
+		return fmt.Errorf("user %s has no address for preferred channel %s", user.ID, user.PreferredChannel)
+	}
+	return s.repository.SaveUser(ctx, user)
+}
+
+func (s *UserPreferenceService) GetPreference(ctx context.Context, id string) (*models.User, error) {
+	return s.repository.GetUser(ctx, id)
+}
+
+// SetSubscriptions records user id's opt-ins and opt-outs. Categories not
+// listed keep their current setting.
+func (s *UserPreferenceService) SetSubscriptions(ctx context.Context, id string, subscriptions []models.CategorySubscription) error {
+	if _, err := s.repository.GetUser(ctx, id); err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if subscription.Category == "" {
+			return fmt.Errorf("category is required")
+		}
+	}
+	for _, subscription := range subscriptions {
+		if err := s.repository.SaveSubscription(ctx, id, subscription); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscriptions returns the categories user id has opted in to or out of.
+func (s *UserPreferenceService) Subscriptions(ctx context.Context, id string) ([]models.CategorySubscription, error) {
+	if _, err := s.repository.GetUser(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repository.ListSubscriptions(ctx, id)
+}
+
+// IsSubscribed reports whether user id receives notifications in category.
+// Every user receives uncategorised notifications.
+func (s *UserPreferenceService) IsSubscribed(ctx context.Context, id, category string) (bool, error) {
+	if category == "" {
+		return true, nil
+	}
+	subscriptions, err := s.repository.ListSubscriptions(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	for _, subscription := range subscriptions {
+		if subscription.Category == category {
+			return subscription.Subscribed, nil
+		}
+	}
+	return true, nil
+}
+
+// Resolve groups the addresses of userIDs by each user's preferred channel.
+// Users who have opted out of category are left out and returned as
+// suppressed. It fails if any user is unknown.
+func (s *UserPreferenceService) Resolve(ctx context.Context, userIDs []string, category string) (routes map[models.NotificationChannel][]string, suppressed []string, err error) {
+	routes = make(map[models.NotificationChannel][]string)
+	for _, id := range userIDs {
+		user, err := s.repository.GetUser(ctx, id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve user %s: %w", id, err)
+		}
+		subscribed, err := s.IsSubscribed(ctx, id, category)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve user %s: %w", id, err)
+		}
+		if !subscribed {
+			suppressed = append(suppressed, id)
+			continue
+		}
+		address, ok := user.Address(user.PreferredChannel)
+		if !ok {
+			return nil, nil, fmt.Errorf("user %s has no address for preferred channel %s", id, user.PreferredChannel)
+		}
+		routes[user.PreferredChannel] = append(routes[user.PreferredChannel], address)
+	}
+	return routes, suppressed, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notification-service/internal/models"
+	"notification-service/internal/repository"
+	"sort"
+	"testing"
+)
+
+func TestUserPreferenceService(t *testing.T) {
+	ctx := context.Background()
+	service := NewUserPreferenceService(repository.NewMemoryRepository())
+
+	users := []*models.User{
+		{ID: "ana", Email: "ana@example.com", PreferredChannel: models.ChannelEmail},
+		{ID: "ben", Email: "ben@example.com", PreferredChannel: models.ChannelEmail},
+		{ID: "cho", SlackID: "U123", PreferredChannel: models.ChannelSlack},
+		{ID: "dev", Metadata: map[string]string{"telegram": "42"}, PreferredChannel: models.ChannelTelegram},
+	}
+	for _, user := range users {
+		if err := service.SetPreference(ctx, user); err != nil {
+			t.Fatalf("Failed to set preference for %s: %v", user.ID, err)
+		}
+	}
+
+	routes, _, err := service.Resolve(ctx, []string{"ana", "cho", "ben", "dev"}, "")
+	if err != nil {
+		t.Fatalf("Failed to resolve users: %v", err)
+	}
+	emails := routes[models.ChannelEmail]
+	sort.Strings(emails)
+	if len(routes) != 3 || len(emails) != 2 || emails[0] != "ana@example.com" || emails[1] != "ben@example.com" {
+		t.Errorf("Unexpected email routes: %v", routes)
+	}
+	if got := routes[models.ChannelSlack]; len(got) != 1 || got[0] != "U123" {
+		t.Errorf("Expected slack route to U123, got %v", got)
+	}
+	if got := routes[models.ChannelTelegram]; len(got) != 1 || got[0] != "42" {
+		t.Errorf("Expected telegram route to 42, got %v", got)
+	}
+
+	if _, _, err := service.Resolve(ctx, []string{"ana", "zed"}, ""); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for unknown user, got %v", err)
+	}
+}
+
+func TestUserPreferenceServiceSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	service := NewUserPreferenceService(repository.NewMemoryRepository())
+	service.SetPreference(ctx, &models.User{ID: "ana", Email: "ana@example.com", PreferredChannel: models.ChannelEmail})
+	service.SetPreference(ctx, &models.User{ID: "ben", Email: "ben@example.com", PreferredChannel: models.ChannelEmail})
+
+	optOut := []models.CategorySubscription{{Category: "marketing", Subscribed: false}}
+	if err := service.SetSubscriptions(ctx, "ana", optOut); err != nil {
+		t.Fatalf("Failed to set subscriptions: %v", err)
+	}
+	if err := service.SetSubscriptions(ctx, "zed", optOut); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for unknown user, got %v", err)
+	}
+	if err := service.SetSubscriptions(ctx, "ana", []models.CategorySubscription{{Subscribed: true}}); err == nil {
+		t.Error("Expected error for subscription without a category")
+	}
+
+	routes, suppressed, err := service.Resolve(ctx, []string{"ana", "ben"}, "marketing")
+	if err != nil {
+		t.Fatalf("Failed to resolve users: %v", err)
+	}
+	if got := routes[models.ChannelEmail]; len(got) != 1 || got[0] != "ben@example.com" {
+		t.Errorf("Expected only ben to be routed, got %v", routes)
+	}
+	if len(suppressed) != 1 || suppressed[0] != "ana" {
+		t.Errorf("Expected ana to be suppressed, got %v", suppressed)
+	}
+
+	// Opting back in and other categories are unaffected by the opt-out
+	if _, suppressed, _ := service.Resolve(ctx, []string{"ana"}, "billing"); len(suppressed) != 0 {
+		t.Errorf("Expected no suppression for billing, got %v", suppressed)
+	}
+	service.SetSubscriptions(ctx, "ana", []models.CategorySubscription{{Category: "marketing", Subscribed: true}})
+	if _, suppressed, _ := service.Resolve(ctx, []string{"ana"}, "marketing"); len(suppressed) != 0 {
+		t.Errorf("Expected no suppression after opting back in, got %v", suppressed)
+	}
+}
+
+func TestUserPreferenceServiceValidation(t *testing.T) {
+	service := NewUserPreferenceService(repository.NewMemoryRepository())
+
+	invalid := []*models.User{
+		{Email: "no-id@example.com", PreferredChannel: models.ChannelEmail},
+		{ID: "no-channel", Email: "a@example.com"},
+		{ID: "no-address", Email: "a@example.com", PreferredChannel: models.ChannelSlack},
+	}
+	for _, user := range invalid {
+		if err := service.SetPreference(context.Background(), user); err == nil {
+			t.Errorf("Expected error for %+v", user)
+		}
+	}
+}
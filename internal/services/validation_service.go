@@ -0,0 +1,246 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultChannelLimits are the length limits applied unless
+// config.Config.ChannelLimits overrides them. Discord truncates long content
+// and Telegram limits only titles, since its service shortens content to fit
+// the escaped message itself. SMS content is limited to what ten concatenated
+// GSM-7 parts hold.
+var DefaultChannelLimits = map[models.NotificationChannel]config.ChannelLimitConfig{
+	models.ChannelMessage:   {MaxContentLength: smsMaxParts * smsGSM7PartLength},
+	models.ChannelDiscord:   {MaxContentLength: discordMaxContentLength, TruncatePolicy: config.TruncationTruncate},
+	models.ChannelSlack:     {MaxContentLength: 4000},
+	models.ChannelWhatsApp:  {MaxContentLength: 4096},
+	models.ChannelPagerDuty: {MaxTitleLength: 1024},
+	models.ChannelTelegram:  {MaxTitleLength: telegramMaxTitleLength},
+}
+
+// FieldError describes why a single field of a notification is invalid.
+type FieldError struct {
+	Field string `json:"field"`
+	// Channel is set when the field breaks that channel's limits.
+	Channel models.NotificationChannel `json:"channel,omitempty"`
+	Message string                     `json:"message"`
+}
+
+// ValidationError lists every invalid field of a notification.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+		if field.Channel != "" {
+			messages[i] = fmt.Sprintf("%s (%s): %s", field.Field, field.Channel, field.Message)
+		}
+	}
+	return "invalid notification: " + strings.Join(messages, "; ")
+}
+
+// ValidationService checks notification titles and content before they are
+// stored or sent.
+type ValidationService struct {
+	limits        map[models.NotificationChannel]config.ChannelLimitConfig
+	smsProvider   string
+	emailProvider string
+}
+
+func NewValidationService(cfg *config.Config) *ValidationService {
+	service := &ValidationService{limits: channelLimits(cfg)}
+	if cfg != nil {
+		service.smsProvider = cfg.SMSProvider
+		service.emailProvider = cfg.EmailProvider
+	}
+	return service
+}
+
+// ValidateNotification returns a *ValidationError listing every invalid field.
+func (v *ValidationService) ValidateNotification(notification *models.Notification) error {
+	var fields []FieldError
+	for _, field := range []struct{ name, value string }{
+		{"title", notification.Title},
+		{"content", notification.Content},
+	} {
+		if message := checkCharacters(field.value); message != "" {
+			fields = append(fields, FieldError{Field: field.name, Message: message})
+		}
+	}
+
+	for _, channel := range notificationChannels(notification) {
+		switch channel {
+		case models.ChannelEmail:
+			fields = append(fields, v.checkEmailMetadata(notification)...)
+		case models.ChannelSlack:
+			fields = append(fields, checkSlackMetadata(notification)...)
+		case models.ChannelMessage:
+			fields = append(fields, v.checkSMSMetadata(notification)...)
+		case models.ChannelAPNs:
+			fields = append(fields, checkAPNsMetadata(notification)...)
+		}
+		fields = append(fields, v.checkLimits(notification, channel)...)
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// checkEmailMetadata requires an email's cc and bcc metadata to be address
+// lists and its reply_to an address. With SendGrid, a dynamic template's
+// template_data must be a JSON object; with Mailgun, tags are limited.
+func (v *ValidationService) checkEmailMetadata(notification *models.Notification) []FieldError {
+	var fields []FieldError
+	for _, key := range []string{EmailCCMetadataKey, EmailBCCMetadataKey} {
+		if _, err := parseEmailAddressList(notification.Metadata[key]); err != nil {
+			fields = append(fields, FieldError{
+				Field:   "metadata." + key,
+				Channel: models.ChannelEmail,
+				Message: "is not a valid address list: " + err.Error(),
+			})
+		}
+	}
+	if replyTo, ok := notification.Metadata[EmailReplyToMetadataKey]; ok {
+		if _, err := mail.ParseAddress(replyTo); err != nil {
+			fields = append(fields, FieldError{
+				Field:   "metadata." + EmailReplyToMetadataKey,
+				Channel: models.ChannelEmail,
+				Message: "is not a valid address: " + err.Error(),
+			})
+		}
+	}
+	if v.emailProvider == "sendgrid" && notification.Metadata[SendGridTemplateIDMetadataKey] != "" {
+		if _, err := parseSendGridTemplateData(notification.Metadata[SendGridTemplateDataMetadataKey]); err != nil {
+			fields = append(fields, FieldError{
+				Field:   "metadata." + SendGridTemplateDataMetadataKey,
+				Channel: models.ChannelEmail,
+				Message: "must be a JSON object",
+			})
+		}
+	}
+	if v.emailProvider == "mailgun" {
+		if _, err := parseMailgunTags(notification.Metadata[MailgunTagsMetadataKey]); err != nil {
+			fields = append(fields, FieldError{
+				Field:   "metadata." + MailgunTagsMetadataKey,
+				Channel: models.ChannelEmail,
+				Message: fmt.Sprintf("must list at most %d tags", mailgunMaxTags),
+			})
+		}
+	}
+	return fields
+}
+
+// checkSlackMetadata requires a known send_mode, and a channel_id for
+// ephemeral messages.
+func checkSlackMetadata(notification *models.Notification) []FieldError {
+	var fields []FieldError
+	mode, err := ParseSlackSendMode(notification.Metadata[SlackSendModeMetadataKey])
+	if err != nil {
+		fields = append(fields, FieldError{
+			Field:   "metadata." + SlackSendModeMetadataKey,
+			Channel: models.ChannelSlack,
+			Message: fmt.Sprintf("must be %s, %s or %s", SlackChannelPost, SlackDirectMessage, SlackEphemeralMessage),
+		})
+	}
+	if mode == SlackEphemeralMessage && notification.Metadata[SlackChannelIDMetadataKey] == "" {
+		fields = append(fields, FieldError{
+			Field:   "metadata." + SlackChannelIDMetadataKey,
+			Channel: models.ChannelSlack,
+			Message: "is required for ephemeral messages",
+		})
+	}
+	return fields
+}
+
+// checkSMSMetadata requires SMS sent through Vonage to name a known provider,
+// if any.
+func (v *ValidationService) checkSMSMetadata(notification *models.Notification) []FieldError {
+	provider, ok := notification.Metadata[VonageProviderMetadataKey]
+	if !ok || v.smsProvider != "vonage" {
+		return nil
+	}
+	if _, _, err := vonageProvider(provider); err != nil {
+		return []FieldError{{
+			Field:   "metadata." + VonageProviderMetadataKey,
+			Channel: models.ChannelMessage,
+			Message: fmt.Sprintf("must be %s or %s", VonageProviderSMS, VonageProviderWhatsApp),
+		}}
+	}
+	return nil
+}
+
+// checkAPNsMetadata requires an APNs badge, if any, to be a count.
+func checkAPNsMetadata(notification *models.Notification) []FieldError {
+	badge, ok := notification.Metadata[APNsBadgeMetadataKey]
+	if !ok {
+		return nil
+	}
+	if _, err := parseAPNsBadge(badge); err != nil {
+		return []FieldError{{
+			Field:   "metadata." + APNsBadgeMetadataKey,
+			Channel: models.ChannelAPNs,
+			Message: "must be a non-negative integer",
+		}}
+	}
+	return nil
+}
+
+// checkLimits holds the title and content to channel's length limits. Long
+// content is allowed on channels that truncate or split it.
+func (v *ValidationService) checkLimits(notification *models.Notification, channel models.NotificationChannel) []FieldError {
+	var fields []FieldError
+	limit := v.limits[channel]
+	if n := utf8.RuneCountInString(notification.Title); limit.MaxTitleLength > 0 && n > limit.MaxTitleLength {
+		fields = append(fields, FieldError{
+			Field:   "title",
+			Channel: channel,
+			Message: fmt.Sprintf("is %d characters, exceeding the limit of %d", n, limit.MaxTitleLength),
+		})
+	}
+	rejects := limit.TruncatePolicy == "" || limit.TruncatePolicy == config.TruncationReject
+	if n := utf8.RuneCountInString(notification.Content); rejects && limit.MaxContentLength > 0 && n > limit.MaxContentLength {
+		fields = append(fields, FieldError{
+			Field:   "content",
+			Channel: channel,
+			Message: fmt.Sprintf("is %d characters, exceeding the limit of %d", n, limit.MaxContentLength),
+		})
+	}
+	return fields
+}
+
+// checkCharacters describes the first problem with value's characters, or
+// returns "" if there is none: it must be valid UTF-8 without control
+// characters other than line breaks and tabs.
+func checkCharacters(value string) string {
+	if !utf8.ValidString(value) {
+		return "is not valid UTF-8"
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return fmt.Sprintf("contains control character %U", r)
+		}
+	}
+	return ""
+}
+
+// notificationChannels returns the channels notification will be sent on.
+func notificationChannels(notification *models.Notification) []models.NotificationChannel {
+	if len(notification.Channels) > 0 {
+		return notification.Channels
+	}
+	if notification.Channel == "" {
+		return nil
+	}
+	return []models.NotificationChannel{notification.Channel}
+}
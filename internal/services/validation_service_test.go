@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestValidateNotification(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         *config.Config
+		notification   *models.Notification
+		expectedFields []FieldError
+	}{
+		{
+			name:         "SMS within limit",
+			notification: &models.Notification{Title: "Hi", Content: strings.Repeat("é", 1530), Channel: models.ChannelMessage},
+		},
+		{
+			name:         "SMS over limit",
+			notification: &models.Notification{Title: "Hi", Content: strings.Repeat("a", 1531), Channel: models.ChannelMessage},
+			expectedFields: []FieldError{
+				{Field: "content", Channel: models.ChannelMessage, Message: "is 1531 characters, exceeding the limit of 1530"},
+			},
+		},
+		{
+			name:         "Email is unrestricted",
+			notification: &models.Notification{Title: "Hi", Content: strings.Repeat("a", 100000), Channel: models.ChannelEmail},
+		},
+		{
+			name: "Fan-out checks every channel",
+			notification: &models.Notification{
+				Title: "Hi", Content: strings.Repeat("a", 4001),
+				Channels: []models.NotificationChannel{models.ChannelEmail, models.ChannelSlack, models.ChannelMessage},
+			},
+			expectedFields: []FieldError{
+				{Field: "content", Channel: models.ChannelSlack, Message: "is 4001 characters, exceeding the limit of 4000"},
+				{Field: "content", Channel: models.ChannelMessage, Message: "is 4001 characters, exceeding the limit of 1530"},
+			},
+		},
+		{
+			name:         "Telegram title over limit",
+			notification: &models.Notification{Title: strings.Repeat("a", 5000), Content: strings.Repeat("a", 5000), Channel: models.ChannelTelegram},
+			expectedFields: []FieldError{
+				{Field: "title", Channel: models.ChannelTelegram, Message: "is 5000 characters, exceeding the limit of 1024"},
+			},
+		},
+		{
+			name:         "Configured override",
+			config:       &config.Config{ChannelLimits: map[string]config.ChannelLimitConfig{"email": {MaxTitleLength: 5}, "message": {}}},
+			notification: &models.Notification{Title: "Too long", Content: strings.Repeat("a", 200), Channels: []models.NotificationChannel{models.ChannelEmail, models.ChannelMessage}},
+			expectedFields: []FieldError{
+				{Field: "title", Channel: models.ChannelEmail, Message: "is 8 characters, exceeding the limit of 5"},
+			},
+		},
+		{
+			name:         "Truncated or split content",
+			config:       &config.Config{ChannelLimits: map[string]config.ChannelLimitConfig{"slack": {MaxContentLength: 100, TruncatePolicy: config.TruncationTruncate}, "message": {MaxContentLength: 160, TruncatePolicy: config.TruncationSplit}}},
+			notification: &models.Notification{Title: "Hi", Content: strings.Repeat("a", 5000), Channels: []models.NotificationChannel{models.ChannelSlack, models.ChannelMessage, models.ChannelDiscord}},
+		},
+		{
+			name:         "Invalid APNs badge",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelAPNs, Metadata: map[string]string{"badge": "-1"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.badge", Channel: models.ChannelAPNs, Message: "must be a non-negative integer"},
+			},
+		},
+		{
+			name:         "Unknown Vonage provider",
+			config:       &config.Config{SMSProvider: "vonage"},
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelMessage, Metadata: map[string]string{"provider": "rcs"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.provider", Channel: models.ChannelMessage, Message: "must be sms or whatsapp"},
+			},
+		},
+		{
+			name:         "Malformed SendGrid template data",
+			config:       &config.Config{EmailProvider: "sendgrid"},
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelEmail, Metadata: map[string]string{"sendgrid_template_id": "d-123", "template_data": "[1, 2]"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.template_data", Channel: models.ChannelEmail, Message: "must be a JSON object"},
+			},
+		},
+		{
+			name:         "Too many Mailgun tags",
+			config:       &config.Config{EmailProvider: "mailgun"},
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelEmail, Metadata: map[string]string{"tags": "welcome, onboarding, trial, beta"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.tags", Channel: models.ChannelEmail, Message: "must list at most 3 tags"},
+			},
+		},
+		{
+			name:         "Email copies",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelEmail, Metadata: map[string]string{"cc": "Ana <ana@example.com>, bob@example.com", "bcc": "carla@example.com"}},
+		},
+		{
+			name:         "Invalid email copies",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelEmail, Metadata: map[string]string{"cc": "ana@example.com", "bcc": "not an address"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.bcc", Channel: models.ChannelEmail, Message: "is not a valid address list: mail: no angle-addr"},
+			},
+		},
+		{
+			name:         "Email reply_to",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelEmail, Metadata: map[string]string{"reply_to": "Support <support@example.com>"}},
+		},
+		{
+			name:         "Invalid email reply_to",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelEmail, Metadata: map[string]string{"reply_to": "a@example.com, b@example.com"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.reply_to", Channel: models.ChannelEmail, Message: "is not a valid address: mail: expected single address, got \", b@example.com\""},
+			},
+		},
+		{
+			name:         "Slack ephemeral message",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelSlack, Metadata: map[string]string{"send_mode": "ephemeral", "channel_id": "C789"}},
+		},
+		{
+			name:         "Slack ephemeral message without channel",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelSlack, Metadata: map[string]string{"send_mode": "ephemeral"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.channel_id", Channel: models.ChannelSlack, Message: "is required for ephemeral messages"},
+			},
+		},
+		{
+			name:         "Unknown Slack send mode",
+			notification: &models.Notification{Title: "Hi", Content: "Hi", Channel: models.ChannelSlack, Metadata: map[string]string{"send_mode": "broadcast"}},
+			expectedFields: []FieldError{
+				{Field: "metadata.send_mode", Channel: models.ChannelSlack, Message: "must be channel, direct or ephemeral"},
+			},
+		},
+		{
+			name:         "Control characters",
+			notification: &models.Notification{Title: "Hi\x00", Content: "line one\nline two\ttabbed", Channel: models.ChannelSlack},
+			expectedFields: []FieldError{
+				{Field: "title", Message: "contains control character U+0000"},
+			},
+		},
+		{
+			name:         "Invalid UTF-8",
+			notification: &models.Notification{Title: "Hi", Content: "\xff", Channel: models.ChannelSlack},
+			expectedFields: []FieldError{
+				{Field: "content", Message: "is not valid UTF-8"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewValidationService(tt.config).ValidateNotification(tt.notification)
+			if tt.expectedFields == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a ValidationError, got %v", err)
+			}
+			if len(validationErr.Fields) != len(tt.expectedFields) {
+				t.Fatalf("Expected %d field errors, got %+v", len(tt.expectedFields), validationErr.Fields)
+			}
+			for i, expected := range tt.expectedFields {
+				if validationErr.Fields[i] != expected {
+					t.Errorf("Expected field error %+v, got %+v", expected, validationErr.Fields[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// VonageProviderMetadataKey names the notification metadata entry choosing
+// whether Vonage sends the message by SMS, the default, or WhatsApp.
+const VonageProviderMetadataKey = "provider"
+
+const (
+	VonageProviderSMS      = "sms"
+	VonageProviderWhatsApp = "whatsapp"
+)
+
+// The longest text the Messages API accepts for each provider.
+const (
+	vonageSMSMaxLength      = 1000
+	vonageWhatsAppMaxLength = 4096
+)
+
+// VonageNotificationService sends SMS and WhatsApp Business messages through
+// the Vonage Messages API. Each recipient is an E.164 phone number. Vonage
+// splits long SMS into concatenated parts itself. When APIKey is empty the
+// notification is only printed to stdout.
+type VonageNotificationService struct {
+	APIURL    string
+	APIKey    string
+	APISecret string
+	// From is the number messages are sent from, or for SMS the sender alias
+	// when AlphanumericSenderID is set and the alias is a valid sender ID.
+	From                 string
+	AlphanumericSenderID bool
+	Client               *http.Client
+	Logger               logging.Logger
+}
+
+func NewVonageNotificationService(cfg *config.Config) *VonageNotificationService {
+	return &VonageNotificationService{
+		APIURL:               cfg.VonageAPIURL,
+		APIKey:               cfg.VonageAPIKey,
+		APISecret:            cfg.VonageAPISecret,
+		From:                 cfg.VonageFrom,
+		AlphanumericSenderID: cfg.SMSAlphanumericSenderID,
+		Client:               &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type vonageMessage struct {
+	MessageType string `json:"message_type"`
+	Text        string `json:"text"`
+	To          string `json:"to"`
+	From        string `json:"from"`
+	Channel     string `json:"channel"`
+}
+
+type vonageResponse struct {
+	MessageUUID string `json:"message_uuid"`
+}
+
+func (v *VonageNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	provider, validator, err := vonageProvider(notification.Metadata[VonageProviderMetadataKey])
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	if err := validator.Validate(notification); err != nil {
+		return &PermanentError{Err: err}
+	}
+	if v.APIKey == "" {
+		logDryRun(ctx, v.Logger, notification)
+		return nil
+	}
+
+	from := strings.TrimPrefix(v.From, "+")
+	if alias := notification.Metadata[SenderAliasMetadataKey]; provider == VonageProviderSMS && v.AlphanumericSenderID && isAlphanumericSenderID(alias) {
+		from = alias
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte(v.APIKey + ":" + v.APISecret))
+	headers := map[string]string{"Authorization": "Basic " + credentials}
+
+	for _, recipient := range notification.Recipients {
+		message := vonageMessage{
+			MessageType: "text",
+			Text:        notification.Content,
+			To:          strings.TrimPrefix(recipient, "+"),
+			From:        from,
+			Channel:     provider,
+		}
+		var response vonageResponse
+		err := postJSON(ctx, v.Client, "vonage", v.APIURL, headers, message, &response)
+		notification.RecordRecipient(recipient, err)
+		if err != nil {
+			return fmt.Errorf("failed to send vonage %s message to %s: %w", provider, recipient, err)
+		}
+	}
+	return nil
+}
+
+// vonageProvider returns the provider named by a VonageProviderMetadataKey
+// entry and the validator for its messages.
+func vonageProvider(value string) (string, SMSValidator, error) {
+	switch value {
+	case "", VonageProviderSMS:
+		return VonageProviderSMS, SMSValidator{MaxContentLength: vonageSMSMaxLength}, nil
+	case VonageProviderWhatsApp:
+		return VonageProviderWhatsApp, SMSValidator{MaxContentLength: vonageWhatsAppMaxLength}, nil
+	}
+	return "", SMSValidator{}, fmt.Errorf("unsupported vonage provider %q: must be %s or %s", value, VonageProviderSMS, VonageProviderWhatsApp)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestVonageNotificationService(t *testing.T) {
+	var sent []vonageMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, secret, ok := r.BasicAuth(); !ok || key != "key" || secret != "secret" {
+			t.Errorf("Expected basic auth with the API key and secret, got %q %q", key, secret)
+		}
+		var message vonageMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		sent = append(sent, message)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"message_uuid":"aaaaaaaa-bbbb-cccc-dddd-0123456789ab"}`))
+	}))
+	defer server.Close()
+
+	service := &VonageNotificationService{APIURL: server.URL, APIKey: "key", APISecret: "secret", From: "+14155550100", AlphanumericSenderID: true, Client: server.Client()}
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		expected vonageMessage
+	}{
+		{"SMS", nil, vonageMessage{MessageType: "text", Text: "Your code is 1234", To: "447700900000", From: "14155550100", Channel: "sms"}},
+		{"SMS with sender alias", map[string]string{"sender_alias": "Acme"}, vonageMessage{MessageType: "text", Text: "Your code is 1234", To: "447700900000", From: "Acme", Channel: "sms"}},
+		{"WhatsApp", map[string]string{"provider": "whatsapp", "sender_alias": "Acme"}, vonageMessage{MessageType: "text", Text: "Your code is 1234", To: "447700900000", From: "14155550100", Channel: "whatsapp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = nil
+			notification := &models.Notification{Title: "Login", Content: "Your code is 1234", Recipients: []string{"+447700900000"}, Metadata: tt.metadata}
+			if err := service.Send(context.Background(), notification); err != nil {
+				t.Fatalf("Failed to send Vonage message: %v", err)
+			}
+			if len(sent) != 1 || sent[0] != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, sent)
+			}
+			if len(notification.RecipientStatuses) != 1 || notification.RecipientStatuses[0].Status != models.StatusSent {
+				t.Errorf("Expected the recipient recorded as sent, got %+v", notification.RecipientStatuses)
+			}
+		})
+	}
+
+	// Invalid messages are not sent or retried
+	sent = nil
+	for _, notification := range []*models.Notification{
+		{Content: "Hi", Recipients: []string{"07700900000"}},
+		{Content: strings.Repeat("a", vonageSMSMaxLength+1), Recipients: []string{"+447700900000"}},
+		{Content: "Hi", Recipients: []string{"+447700900000"}, Metadata: map[string]string{"provider": "rcs"}},
+	} {
+		var permanent *PermanentError
+		if err := service.Send(context.Background(), notification); !errors.As(err, &permanent) {
+			t.Errorf("Expected a permanent error, got %v", err)
+		}
+	}
+	if len(sent) != 0 {
+		t.Errorf("Expected no invalid message to be sent, got %d", len(sent))
+	}
+}
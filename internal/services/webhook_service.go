@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"strings"
+	"time"
+)
+
+// DeliveryAttempt records the outcome of a single webhook POST.
+type DeliveryAttempt struct {
+	URL        string
+	Attempt    int
+	StatusCode int
+	Error      string
+	Timestamp  time.Time
+}
+
+// WebhookDeliveryError is returned when a webhook could not be delivered and
+// carries every attempt made against the failing URL.
+type WebhookDeliveryError struct {
+	URL      string
+	Attempts []DeliveryAttempt
+}
+
+func (e *WebhookDeliveryError) Error() string {
+	last := e.Attempts[len(e.Attempts)-1]
+	if last.Error != "" {
+		return fmt.Sprintf("webhook delivery to %s failed after %d attempt(s): %s", e.URL, len(e.Attempts), last.Error)
+	}
+	return fmt.Sprintf("webhook delivery to %s failed after %d attempt(s): status %d", e.URL, len(e.Attempts), last.StatusCode)
+}
+
+// WebhookNotificationService POSTs the notification as JSON to every
+// recipient URL, signing the body with HMAC-SHA256. The default client
+// refuses recipients inside the service's own network.
+type WebhookNotificationService struct {
+	Secret     string
+	Client     *http.Client
+	MaxRetries int
+	RetryDelay time.Duration
+}
+
+func NewWebhookNotificationService(cfg *config.Config) *WebhookNotificationService {
+	return &WebhookNotificationService{
+		Secret:     cfg.WebhookSecret,
+		Client:     NewGuardedClient(10 * time.Second),
+		MaxRetries: 3,
+		RetryDelay: time.Second,
+	}
+}
+
+func (s *WebhookNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	signature := SignPayload(s.Secret, body)
+
+	for _, url := range notification.Recipients {
+		err := s.deliver(ctx, url, notification.ID, signature, body)
+		notification.RecordRecipient(url, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WebhookNotificationService) deliver(ctx context.Context, url, notificationID, signature string, body []byte) error {
+	var attempts []DeliveryAttempt
+	for attempt := 1; attempt <= s.MaxRetries+1; attempt++ {
+		if attempt > 1 {
+			if ctxErr := sleepContext(ctx, s.RetryDelay*time.Duration(attempt-1)); ctxErr != nil {
+				break
+			}
+		}
+
+		record := DeliveryAttempt{URL: url, Attempt: attempt, Timestamp: time.Now()}
+		statusCode, err := s.post(ctx, url, notificationID, signature, body)
+		record.StatusCode = statusCode
+		if err != nil {
+			record.Error = err.Error()
+		}
+		attempts = append(attempts, record)
+
+		if err == nil && statusCode >= 200 && statusCode <= 299 {
+			return nil
+		}
+		// Only transport failures and server errors are retried
+		if err == nil && statusCode < 500 {
+			break
+		}
+	}
+	return &WebhookDeliveryError{URL: url, Attempts: attempts}
+}
+
+func (s *WebhookNotificationService) post(ctx context.Context, url, notificationID, signature string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-ID", notificationID)
+	req.Header.Set("X-Signature", signature)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// SignPayload returns the hex-encoded HMAC-SHA256 of body prefixed with
+// "sha256=", the format receivers verify against X-Signature.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature matches body for secret.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	expected := SignPayload(secret, body)
+	return hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature)))
+}
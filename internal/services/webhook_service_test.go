@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestWebhookNotificationServiceSignsPayload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Notification-ID") != "hook-1" {
+			t.Errorf("Expected X-Notification-ID header, got %q", r.Header.Get("X-Notification-ID"))
+		}
+		if !VerifySignature("secret", body, r.Header.Get("X-Signature")) {
+			t.Error("Signature did not verify")
+		}
+	}))
+	defer server.Close()
+
+	service := &WebhookNotificationService{Secret: "secret", Client: server.Client(), MaxRetries: 3}
+	notification := &models.Notification{ID: "hook-1", Title: "T", Content: "C", Recipients: []string{server.URL}}
+
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to deliver webhook: %v", err)
+	}
+}
+
+func TestWebhookNotificationServiceRetriesServerErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	service := &WebhookNotificationService{Secret: "secret", Client: server.Client(), MaxRetries: 3}
+	notification := &models.Notification{ID: "hook-2", Recipients: []string{server.URL}}
+
+	err := service.Send(context.Background(), notification)
+
+	var deliveryErr *WebhookDeliveryError
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("Expected WebhookDeliveryError, got %v", err)
+	}
+	if attempts != 4 || len(deliveryErr.Attempts) != 4 {
+		t.Errorf("Expected 4 attempts, got %d requests and %d records", attempts, len(deliveryErr.Attempts))
+	}
+	if deliveryErr.Attempts[0].StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected recorded status 502, got %d", deliveryErr.Attempts[0].StatusCode)
+	}
+}
+
+func TestWebhookNotificationServiceBlocksInternalAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	// Redirects to an internal address are refused as well
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+	defer redirect.Close()
+
+	service := NewWebhookNotificationService(&config.Config{WebhookSecret: "secret"})
+	service.MaxRetries = 0
+	for _, url := range []string{server.URL, redirect.URL, "http://169.254.169.254/latest/meta-data/", "http://[::1]:80/", "http://10.0.0.1/"} {
+		err := service.Send(context.Background(), &models.Notification{ID: "hook-3", Recipients: []string{url}})
+		if err == nil || !strings.Contains(err.Error(), ErrBlockedAddress.Error()) {
+			t.Errorf("Expected %s to be blocked, got %v", url, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("Expected no requests to reach the internal server, got %d", requests)
+	}
+}
+
+func TestBlockedAddress(t *testing.T) {
+	for address, blocked := range map[string]bool{
+		"127.0.0.1":        true,
+		"::1":              true,
+		"169.254.169.254":  true,
+		"fe80::1":          true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"100.64.0.1":       true,
+		"fd00::1":          true,
+		"0.0.0.0":          true,
+		"::ffff:127.0.0.1": true,
+		"93.184.216.34":    false,
+		"2606:4700::1111":  false,
+	} {
+		if got := blockedAddress(netip.MustParseAddr(address)); got != blocked {
+			t.Errorf("blockedAddress(%s) = %v, want %v", address, got, blocked)
+		}
+	}
+}
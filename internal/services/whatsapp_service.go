@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/logging"
+	"notification-service/internal/models"
+	"strings"
+)
+
+// WhatsAppNotificationService sends messages through the Meta WhatsApp Cloud
+// API. When AccessToken is empty the notification is only printed to stdout.
+type WhatsAppNotificationService struct {
+	APIURL        string
+	PhoneNumberID string
+	AccessToken   string
+	Client        *http.Client
+	Logger        logging.Logger
+}
+
+func NewWhatsAppNotificationService(cfg *config.Config) *WhatsAppNotificationService {
+	return &WhatsAppNotificationService{
+		APIURL:        cfg.WhatsAppAPIURL,
+		PhoneNumberID: cfg.WhatsAppPhoneNumberID,
+		AccessToken:   cfg.WhatsAppAccessToken,
+		Client:        &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type whatsAppText struct {
+	Body string `json:"body"`
+}
+
+type whatsAppLanguage struct {
+	Code string `json:"code"`
+}
+
+type whatsAppTemplate struct {
+	Name     string           `json:"name"`
+	Language whatsAppLanguage `json:"language"`
+}
+
+type whatsAppMessage struct {
+	MessagingProduct string            `json:"messaging_product"`
+	To               string            `json:"to"`
+	Type             string            `json:"type"`
+	Text             *whatsAppText     `json:"text,omitempty"`
+	Template         *whatsAppTemplate `json:"template,omitempty"`
+}
+
+func (w *WhatsAppNotificationService) Send(ctx context.Context, notification *models.Notification) error {
+	if w.AccessToken == "" {
+		logDryRun(ctx, w.Logger, notification)
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/%s/messages", strings.TrimRight(w.APIURL, "/"), w.PhoneNumberID)
+	headers := map[string]string{"Authorization": "Bearer " + w.AccessToken}
+
+	for _, recipient := range notification.Recipients {
+		message := buildWhatsAppMessage(recipient, notification)
+		err := postJSON(ctx, w.Client, "whatsapp", url, headers, message, nil)
+		notification.RecordRecipient(recipient, err)
+		if err != nil {
+			return fmt.Errorf("failed to send whatsapp message to %s: %w", recipient, err)
+		}
+	}
+	return nil
+}
+
+// buildWhatsAppMessage uses the approved template named in
+// Metadata["template"] when present, otherwise a plain text message.
+func buildWhatsAppMessage(recipient string, notification *models.Notification) whatsAppMessage {
+	message := whatsAppMessage{
+		MessagingProduct: "whatsapp",
+		To:               strings.TrimPrefix(recipient, "+"),
+	}
+
+	if name := notification.Metadata["template"]; name != "" {
+		language := notification.Metadata["template_language"]
+		if language == "" {
+			language = "en_US"
+		}
+		message.Type = "template"
+		message.Template = &whatsAppTemplate{Name: name, Language: whatsAppLanguage{Code: language}}
+		return message
+	}
+
+	message.Type = "text"
+	message.Text = &whatsAppText{Body: fmt.Sprintf("*%s*\n%s", notification.Title, notification.Content)}
+	return message
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"testing"
+)
+
+func TestWhatsAppNotificationService(t *testing.T) {
+	var messages []whatsAppMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/12345/messages" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Expected bearer token, got %q", got)
+		}
+		var message whatsAppMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		messages = append(messages, message)
+	}))
+	defer server.Close()
+
+	service := &WhatsAppNotificationService{
+		APIURL:        server.URL,
+		PhoneNumberID: "12345",
+		AccessToken:   "token",
+		Client:        server.Client(),
+	}
+
+	notification := &models.Notification{
+		Title:      "Order shipped",
+		Content:    "Your order is on its way",
+		Channel:    models.ChannelWhatsApp,
+		Recipients: []string{"+14155552671"},
+	}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send WhatsApp message: %v", err)
+	}
+
+	notification.Metadata = map[string]string{"template": "order_update"}
+	if err := service.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Failed to send WhatsApp template: %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Type != "text" || messages[0].Text == nil || messages[0].To != "14155552671" {
+		t.Errorf("Unexpected text message: %+v", messages[0])
+	}
+	if messages[1].Type != "template" || messages[1].Template == nil || messages[1].Template.Name != "order_update" {
+		t.Errorf("Unexpected template message: %+v", messages[1])
+	}
+}
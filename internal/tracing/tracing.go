@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"notification-service/internal/config"
+	"notification-service/internal/models"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName names the tracer the service's spans are created with.
+const instrumentationName = "notification-service"
+
+// Span attributes describing a notification.
+const (
+	NotificationIDKey      = attribute.Key("notification.id")
+	NotificationChannelKey = attribute.Key("notification.channel")
+	RecipientCountKey      = attribute.Key("recipient.count")
+	ScheduledKey           = attribute.Key("scheduled")
+)
+
+// TracerProvider wraps the OpenTelemetry SDK's provider, batching spans to
+// an OTLP/HTTP collector.
+type TracerProvider struct {
+	provider *sdktrace.TracerProvider
+}
+
+// NewTracerProvider returns a provider exporting to cfg.OTLPEndpoint as
+// cfg.TracingServiceName. The exporter connects lazily, so an unreachable
+// collector only drops spans.
+func NewTracerProvider(ctx context.Context, cfg *config.Config) (*TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	if err != nil {
+		return nil, err
+	}
+	return &TracerProvider{provider: sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.TracingServiceName))),
+	)}, nil
+}
+
+// Tracer returns the tracer the service's spans are created with.
+func (p *TracerProvider) Tracer() trace.Tracer {
+	return p.provider.Tracer(instrumentationName)
+}
+
+// Shutdown exports the spans still buffered and stops the provider.
+func (p *TracerProvider) Shutdown(ctx context.Context) error {
+	return p.provider.Shutdown(ctx)
+}
+
+// Middleware continues the trace named by a request's traceparent header, so
+// spans started from the request context join the caller's trace.
+func (p *TracerProvider) Middleware(next http.Handler) http.Handler {
+	propagator := propagation.TraceContext{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Start starts a span named name as a child of any span in ctx. A nil tracer
+// starts a span that records nothing, so callers need not check whether
+// tracing is enabled.
+func Start(ctx context.Context, tracer trace.Tracer, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer(instrumentationName)
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// NotificationAttributes describes notification for a span. The channel of
+// a fan-out lists each of its channels, separated by commas.
+func NotificationAttributes(notification *models.Notification) []attribute.KeyValue {
+	channel := string(notification.Channel)
+	if len(notification.Channels) > 0 {
+		channels := make([]string, len(notification.Channels))
+		for i, c := range notification.Channels {
+			channels[i] = string(c)
+		}
+		channel = strings.Join(channels, ",")
+	}
+	return []attribute.KeyValue{
+		NotificationIDKey.String(notification.ID),
+		NotificationChannelKey.String(channel),
+		RecipientCountKey.Int(len(notification.Recipients)),
+		ScheduledKey.Bool(notification.ScheduledAt != nil || notification.CronExpr != ""),
+	}
+}
+
+// End records err, if any, as the span's error status and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"notification-service/internal/models"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := &TracerProvider{provider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))}
+
+	handler := provider.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), provider.Tracer(), "handler")
+		span.End()
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/notifications", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		spans[0].Parent().SpanID().String() != "00f067aa0ba902b7" || !spans[0].Parent().IsRemote() {
+		t.Errorf("Expected the span to continue the caller's trace, got %v with parent %v", spans[0].SpanContext(), spans[0].Parent())
+	}
+}
+
+func TestStartWithoutTracer(t *testing.T) {
+	_, span := Start(context.Background(), nil, "untraced")
+	if span.IsRecording() || span.SpanContext().IsValid() {
+		t.Error("Expected a nil tracer to record nothing")
+	}
+	End(span, nil)
+}
+
+func TestNotificationAttributes(t *testing.T) {
+	scheduledAt := time.Now()
+	tests := []struct {
+		name         string
+		notification *models.Notification
+		channel      string
+		recipients   int64
+		scheduled    bool
+	}{
+		{"immediate", &models.Notification{ID: "n1", Channel: models.ChannelSlack, Recipients: []string{"U1", "U2"}}, "slack", 2, false},
+		{"scheduled", &models.Notification{ID: "n1", Channel: models.ChannelEmail, ScheduledAt: &scheduledAt}, "email", 0, true},
+		{"recurring", &models.Notification{ID: "n1", Channel: models.ChannelMessage, CronExpr: "0 9 * * *"}, "message", 0, true},
+		{"fan-out", &models.Notification{ID: "n1", Channels: []models.NotificationChannel{models.ChannelSlack, models.ChannelEmail}}, "slack,email", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributes := map[string]any{}
+			for _, kv := range NotificationAttributes(tt.notification) {
+				attributes[string(kv.Key)] = kv.Value.AsInterface()
+			}
+			if attributes["notification.id"] != "n1" || attributes["notification.channel"] != tt.channel ||
+				attributes["recipient.count"] != tt.recipients || attributes["scheduled"] != tt.scheduled {
+				t.Errorf("Unexpected attributes %v", attributes)
+			}
+		})
+	}
+}
@@ -4,11 +4,24 @@ import (
 	"log"
 	"notification-service/internal/app"
 	"notification-service/internal/config"
+	// Embed the time zone database for scheduling in hosts without one
+	_ "time/tzdata"
 )
 
 func main() {
-	cfg := config.NewConfig()
-	application := app.NewApp(cfg)
+	cfg, err := config.NewConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	application, err := app.NewApp(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize application: %v", err)
+	}
 
 	if err := application.Run(); err != nil {
 		log.Fatalf("Failed to run application: %v", err)